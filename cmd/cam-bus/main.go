@@ -7,13 +7,13 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"github.com/joho/godotenv"
 
 	"github.com/sua-org/cam-bus/internal/mqttclient"
 	"github.com/sua-org/cam-bus/internal/storage"
 	"github.com/sua-org/cam-bus/internal/supervisor"
+	"github.com/sua-org/cam-bus/internal/tracing"
 )
 
 func main() {
@@ -33,29 +33,49 @@ func main() {
 	} else {
 		storage.DefaultStore = store
 	}
+	// Perfis extras (storage_profile por câmera no /info)
+	storage.LoadProfilesFromEnv()
 
-	mqttCli, err := mqttclient.NewClientFromEnv("cam-bus")
+	// Last Will: o broker marca o collector offline se o processo cair
+	mqttCfg := mqttclient.ConfigFromEnv("cam-bus")
+	mqttCfg.WillTopic = supervisor.CollectorAvailabilityTopic(baseTopic)
+	mqttCfg.WillPayload = []byte(supervisor.AvailabilityOffline)
+	mqttCfg.WillQoS = 1
+	mqttCfg.WillRetained = true
+	mqttCli, err := mqttclient.NewClient(mqttCfg)
 	if err != nil {
 		log.Fatalf("erro ao conectar no MQTT: %v", err)
 	}
 	defer mqttCli.Close()
 
-	sup := supervisor.New(mqttCli, baseTopic)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Tracing OTLP opcional (OTEL_TRACES_ENABLED)
+	waitTracing := tracing.InitFromEnv(ctx)
+
+	sup := supervisor.New(mqttCli, baseTopic)
+
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
 
+	// done fecha quando o Run termina o shutdown (estado, workers, fila de
+	// publicação, offline no availability, métricas); só então o MQTT fecha.
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
 		if err := sup.Run(ctx); err != nil {
 			log.Printf("[main] supervisor terminou com erro: %v", err)
 		}
 	}()
-	<-sig
-	log.Println("[main] sinal recebido, encerrando...")
+	select {
+	case <-sig:
+		log.Println("[main] sinal recebido, encerrando...")
+	case <-done:
+	}
 	cancel()
-	time.Sleep(1 * time.Second)
+	<-done
+	waitTracing()
 }
 
 func getenv(key, def string) string {
@@ -0,0 +1,206 @@
+// cmd/cam-selftest/main.go
+//
+// Verifica as dependências do cam-bus antes de declarar o deploy saudável:
+// MQTT (publish/subscribe de ida e volta), storage (upload + leitura de um
+// objeto pequeno) e FindFace (alcançabilidade da API). Sai com código 1 se
+// alguma checagem falhar; dependência não configurada aparece como SKIP.
+//
+// uso: go run ./cmd/cam-selftest [-timeout 15s] [-profile nome] [-skip mqtt,storage,findface] [-strict]
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"github.com/sua-org/cam-bus/internal/findface"
+	"github.com/sua-org/cam-bus/internal/mqttclient"
+	"github.com/sua-org/cam-bus/internal/storage"
+)
+
+// errNotConfigured marca dependência sem configuração no ambiente (SKIP).
+var errNotConfigured = errors.New("não configurado")
+
+type check struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// pubSub é o pedaço do mqttclient.Client usado no round-trip.
+type pubSub interface {
+	Publish(topic string, qos byte, retained bool, payload []byte) error
+	Subscribe(topic string, qos byte, handler func(topic string, payload []byte)) error
+}
+
+// pinger é o pedaço do findface.Client usado na checagem.
+type pinger interface {
+	Ping(ctx context.Context, path string) error
+}
+
+func main() {
+	timeout := flag.Duration("timeout", 15*time.Second, "timeout de cada checagem")
+	profile := flag.String("profile", "", "storage_profile a testar (vazio = store default)")
+	skip := flag.String("skip", "", "checagens a pular, separadas por vírgula (mqtt,storage,findface)")
+	strict := flag.Bool("strict", false, "dependência não configurada conta como falha")
+	flag.Parse()
+
+	log.SetFlags(0)
+	if err := godotenv.Load(); err == nil {
+		log.Printf("[selftest] .env carregado")
+	}
+
+	nonce := randomNonce()
+	checks := []check{
+		{name: "mqtt", run: func(ctx context.Context) error { return runMQTTCheck(ctx, nonce) }},
+		{name: "storage", run: func(ctx context.Context) error { return runStorageCheck(ctx, *profile, nonce) }},
+		{name: "findface", run: runFindFaceCheck},
+	}
+
+	skipped := map[string]bool{}
+	for _, name := range strings.Split(*skip, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			skipped[name] = true
+		}
+	}
+
+	failed := false
+	for _, c := range checks {
+		if skipped[c.name] {
+			fmt.Printf("%-9s SKIP (-skip)\n", c.name)
+			continue
+		}
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		err := c.run(ctx)
+		cancel()
+		elapsed := time.Since(start).Round(time.Millisecond)
+
+		switch {
+		case err == nil:
+			fmt.Printf("%-9s OK   (%s)\n", c.name, elapsed)
+		case errors.Is(err, errNotConfigured) && !*strict:
+			fmt.Printf("%-9s SKIP (%v)\n", c.name, err)
+		default:
+			fmt.Printf("%-9s FAIL (%s): %v\n", c.name, elapsed, err)
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func runMQTTCheck(ctx context.Context, nonce string) error {
+	cli, err := mqttclient.NewClientFromEnv("cam-selftest-" + nonce)
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	baseTopic := strings.TrimRight(getenv("MQTT_BASE_TOPIC", "security-vision/cameras"), "/")
+	return checkMQTT(ctx, cli, baseTopic+"/selftest/"+nonce, []byte(nonce))
+}
+
+// checkMQTT assina topic, publica payload e espera a própria mensagem voltar.
+func checkMQTT(ctx context.Context, ps pubSub, topic string, payload []byte) error {
+	got := make(chan []byte, 1)
+	if err := ps.Subscribe(topic, 1, func(_ string, p []byte) {
+		select {
+		case got <- append([]byte(nil), p...):
+		default:
+		}
+	}); err != nil {
+		return fmt.Errorf("subscribe %s: %w", topic, err)
+	}
+	if err := ps.Publish(topic, 1, false, payload); err != nil {
+		return fmt.Errorf("publish %s: %w", topic, err)
+	}
+
+	for {
+		select {
+		case p := <-got:
+			if bytes.Equal(p, payload) {
+				return nil
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("mensagem de teste não voltou em %s: %w", topic, ctx.Err())
+		}
+	}
+}
+
+func runStorageCheck(ctx context.Context, profile, nonce string) error {
+	// mesma inicialização do cam-bus: MINIO_* vira o DefaultStore e
+	// STORAGE_PROFILES os perfis; perfil desconhecido cai no default
+	if os.Getenv("MINIO_ACCESS_KEY") != "" {
+		store, err := storage.NewMinioStoreFromEnv()
+		if err != nil {
+			return err
+		}
+		storage.DefaultStore = store
+	}
+	storage.LoadProfilesFromEnv()
+
+	store := storage.StoreFor(profile)
+	if store == nil {
+		return fmt.Errorf("%w: MINIO_ACCESS_KEY vazio e sem storage_profile %q", errNotConfigured, profile)
+	}
+	return checkStorage(ctx, store, "selftest/"+nonce+".txt", []byte("cam-selftest "+nonce))
+}
+
+// checkStorage grava data em key e confere a leitura de volta.
+func checkStorage(ctx context.Context, store storage.ImageStore, key string, data []byte) error {
+	url, err := store.SaveSnapshot(ctx, key, data, "text/plain")
+	if err != nil {
+		return fmt.Errorf("upload: %w", err)
+	}
+	back, _, err := storage.Load(ctx, store, key)
+	if err != nil {
+		return fmt.Errorf("leitura de %s: %w", url, err)
+	}
+	if !bytes.Equal(back, data) {
+		return fmt.Errorf("conteúdo lido de %s difere do enviado (%d != %d bytes)", url, len(back), len(data))
+	}
+	return nil
+}
+
+func runFindFaceCheck(ctx context.Context) error {
+	if strings.TrimSpace(os.Getenv("FINDFACE_BASE_URL")) == "" {
+		return fmt.Errorf("%w: FINDFACE_BASE_URL vazio", errNotConfigured)
+	}
+	client, err := findface.NewFromEnv()
+	if err != nil {
+		return err
+	}
+	return checkFindFace(ctx, client, strings.TrimSpace(os.Getenv("FINDFACE_KEEPALIVE_PATH")))
+}
+
+// checkFindFace chama o endpoint barato do FindFace (o mesmo do keepalive).
+func checkFindFace(ctx context.Context, p pinger, path string) error {
+	return p.Ping(ctx, path)
+}
+
+func randomNonce() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+func getenv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -49,7 +49,7 @@ func main() {
     sig := make(chan os.Signal, 1)
     signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
 
-    if err := mqttCli.Subscribe(subTopic, 1, func(topic string, payload []byte) {
+    if err := mqttCli.Subscribe(subTopic, mqttclient.DefaultQoS, func(topic string, payload []byte) {
         handleMessage(ctx, mqttCli, baseTopic, mgr, topic, payload)
     }); err != nil {
         log.Fatalf("erro ao assinar tópico %s: %v", subTopic, err)
@@ -109,7 +109,7 @@ func handleMessage(
             safe(out.AnalyticType, "unknown"),
         )
 
-        if err := mqttCli.Publish(topicOut, 1, false, b); err != nil {
+        if err := mqttCli.Publish(topicOut, mqttclient.DefaultQoS, false, b); err != nil {
             log.Printf("[face-router] erro ao publicar em %s: %v", topicOut, err)
         } else {
             log.Printf("[face-router] published %s -> %s (source_event=%s)", out.AnalyticType, topicOut, evt.EventID)
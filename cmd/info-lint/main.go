@@ -0,0 +1,290 @@
+// cmd/info-lint/main.go
+//
+// Valida payloads de /info (JSON ou YAML) antes de publicar uma câmera.
+//
+// uso: go run ./cmd/info-lint [-topic base/tenant/building/floor/type/id/info] camera.json [...]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/drivers"
+	"github.com/sua-org/cam-bus/internal/mediamtx"
+	"github.com/sua-org/cam-bus/internal/uplink"
+	"gopkg.in/yaml.v3"
+)
+
+type severity string
+
+const (
+	sevError severity = "ERRO"
+	sevWarn  severity = "AVISO"
+)
+
+type issue struct {
+	sev   severity
+	field string
+	msg   string
+}
+
+func main() {
+	topic := flag.String("topic", "", "tópico /info (preenche tenant/building/floor/device_type/device_id)")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "uso: info-lint [-topic <tópico /info>] <arquivo.json|yaml> [...]")
+		os.Exit(2)
+	}
+
+	failed := false
+	for _, path := range flag.Args() {
+		info, unknown, err := loadInfo(path)
+		if err != nil {
+			fmt.Printf("%s: %s: %v\n", path, sevError, err)
+			failed = true
+			continue
+		}
+		if *topic != "" {
+			applyTopic(&info, *topic)
+		}
+
+		issues := unknownFieldIssues(unknown)
+		issues = append(issues, lint(info)...)
+		if len(issues) == 0 {
+			fmt.Printf("%s: OK\n", path)
+			continue
+		}
+		for _, is := range issues {
+			fmt.Printf("%s: %s: %s: %s\n", path, is.sev, is.field, is.msg)
+			if is.sev == sevError {
+				failed = true
+			}
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// loadInfo lê JSON ou YAML. YAML é convertido para JSON para respeitar as tags
+// json de core.CameraInfo. unknown lista os campos que o supervisor ignora
+// (aviso, não erro: o /info pode trazer campos de outras ferramentas).
+func loadInfo(path string) (info core.CameraInfo, unknown []string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return info, nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var raw map[string]interface{}
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return info, nil, fmt.Errorf("yaml inválido: %w", err)
+		}
+		if data, err = json.Marshal(raw); err != nil {
+			return info, nil, fmt.Errorf("convertendo yaml: %w", err)
+		}
+	}
+
+	if err := json.Unmarshal(data, &info); err != nil {
+		return info, nil, fmt.Errorf("json inválido: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return info, nil, fmt.Errorf("json inválido: %w", err)
+	}
+	return info, unknownFields(fields), nil
+}
+
+// unknownFields devolve, em ordem, as chaves sem campo em core.CameraInfo
+// (mesma regra do encoding/json: tag json, sem diferenciar maiúsculas).
+func unknownFields(fields map[string]json.RawMessage) []string {
+	known := map[string]bool{}
+	t := reflect.TypeOf(core.CameraInfo{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		known[strings.ToLower(name)] = true
+	}
+
+	var out []string
+	for key := range fields {
+		if !known[strings.ToLower(key)] {
+			out = append(out, key)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func unknownFieldIssues(unknown []string) []issue {
+	out := make([]issue, 0, len(unknown))
+	for _, field := range unknown {
+		out = append(out, issue{sev: sevWarn, field: field, msg: "campo desconhecido, ignorado pelo supervisor (erro de digitação?)"})
+	}
+	return out
+}
+
+// applyTopic imita o supervisor: identidade da câmera vem do tópico.
+func applyTopic(info *core.CameraInfo, topic string) {
+	parts := strings.Split(strings.Trim(topic, "/"), "/")
+	if len(parts) < 6 || parts[len(parts)-1] != "info" {
+		return
+	}
+	ids := parts[len(parts)-6 : len(parts)-1]
+	info.Tenant, info.Building, info.Floor, info.DeviceType, info.DeviceID = ids[0], ids[1], ids[2], ids[3], ids[4]
+}
+
+func lint(info core.CameraInfo) []issue {
+	var out []issue
+	add := func(sev severity, field, format string, args ...interface{}) {
+		out = append(out, issue{sev: sev, field: field, msg: fmt.Sprintf(format, args...)})
+	}
+
+	// identidade
+	for field, value := range map[string]string{
+		"tenant":      info.Tenant,
+		"building":    info.Building,
+		"floor":       info.Floor,
+		"device_type": info.DeviceType,
+		"device_id":   info.DeviceID,
+	} {
+		if strings.TrimSpace(value) == "" {
+			add(sevWarn, field, "vazio (no supervisor vem do tópico; use -topic)")
+		}
+	}
+
+	// conexão
+	if strings.TrimSpace(info.IP) == "" {
+		add(sevError, "ip", "obrigatório")
+	} else if net.ParseIP(info.IP) == nil {
+		add(sevWarn, "ip", "%q não é um IP literal (hostname?)", info.IP)
+	}
+	if info.Port < 0 || info.Port > 65535 {
+		add(sevError, "port", "%d fora do intervalo 0-65535", info.Port)
+	}
+	if info.Username == "" || info.Password == "" {
+		add(sevWarn, "username/password", "credenciais vazias, o digest auth vai falhar")
+	}
+	if info.CertFingerprint != "" && !info.UseTLS {
+		add(sevWarn, "cert_fingerprint", "definido mas use_tls=false (ignorado)")
+	}
+	if !info.Enabled {
+		add(sevWarn, "enabled", "false: o supervisor vai tratar como remoção")
+	}
+
+	// driver + analytics
+	if _, err := drivers.GetDriver(info); err != nil {
+		add(sevError, "manufacturer", "%v", err)
+	} else if sel, known := drivers.SelectAnalytics(info); known {
+		lintAnalytics(info, sel, add)
+	}
+
+	if info.SubscribeHeartbeatSeconds < 0 {
+		add(sevError, "subscribe_heartbeat_seconds", "negativo (supervisor usa o padrão)")
+	}
+	if !drivers.ValidEventTransport(strings.ToLower(strings.TrimSpace(info.EventTransport))) {
+		add(sevError, "event_transport", "%q inválido (http, rtsp ou poll)", info.EventTransport)
+	} else if strings.EqualFold(strings.TrimSpace(info.EventTransport), drivers.EventTransportRTSP) && info.RTSPURL == "" {
+		add(sevError, "event_transport", "rtsp exige rtsp_url")
+	} else if strings.EqualFold(strings.TrimSpace(info.EventTransport), drivers.EventTransportPoll) {
+		if _, err := drivers.NewPollDriver(info); err != nil {
+			add(sevError, "event_transport", "%v", err)
+		}
+	}
+	if info.PollIntervalSeconds < 0 {
+		add(sevError, "poll_interval_seconds", "negativo (supervisor usa o padrão)")
+	}
+	for _, ch := range info.Channels {
+		if ch <= 0 {
+			add(sevError, "channels", "canal %d inválido (ignorado pelo driver)", ch)
+		}
+	}
+	if info.SnapshotChannel < 0 {
+		add(sevError, "snapshot_channel", "negativo (supervisor usa o canal do evento)")
+	}
+	if info.SnapshotType < 0 {
+		add(sevError, "snapshot_type", "negativo (supervisor omite o type)")
+	}
+	if !drivers.ValidAuthMode(strings.ToLower(strings.TrimSpace(info.AuthMode))) {
+		add(sevError, "auth_mode", "%q inválido (digest ou session)", info.AuthMode)
+	} else if strings.EqualFold(strings.TrimSpace(info.AuthMode), drivers.AuthModeSession) {
+		if info.SessionLogin != nil && !drivers.ValidSessionFormat(strings.ToLower(strings.TrimSpace(info.SessionLogin.Format))) {
+			add(sevError, "session_login.format", "%q inválido (form ou json)", info.SessionLogin.Format)
+		}
+		if info.Username == "" || info.Password == "" {
+			add(sevWarn, "auth_mode", "session sem username/password")
+		}
+	} else if info.SessionLogin != nil {
+		add(sevWarn, "session_login", "ignorado sem auth_mode=session")
+	}
+
+	// streaming / uplink
+	if info.RTSPURL == "" {
+		add(sevWarn, "rtsp_url", "vazio, a câmera não entra no MediaMTX proxy")
+	} else if !strings.HasPrefix(strings.ToLower(info.RTSPURL), "rtsp://") && !strings.HasPrefix(strings.ToLower(info.RTSPURL), "rtsps://") {
+		add(sevError, "rtsp_url", "%q não é rtsp://", info.RTSPURL)
+	}
+	if info.CentralHost != "" || info.CentralSRTPort != 0 {
+		centralPath := info.CentralPath
+		if centralPath == "" {
+			centralPath = uplink.CentralPathFor(info)
+		}
+		if info.CentralHost == "" {
+			add(sevError, "central_host", "obrigatório quando central_srt_port é definido")
+		} else if _, err := uplink.BuildSRTURLCandidates(info.CentralHost, info.CentralSRTPort, centralPath); err != nil {
+			add(sevError, "central_host", "uplink SRT inválido: %v", err)
+		}
+	}
+
+	// gravação
+	if info.RecordRetentionMinutes < 0 {
+		add(sevError, "record_retention_minutes", "negativo (supervisor usa 0)")
+	} else if retention, clamped := mediamtx.RetentionFor(info); clamped {
+		add(sevWarn, "record_retention_minutes", "%d min será limitado a %s pelo MediaMTX", info.RecordRetentionMinutes, retention)
+	}
+	if info.PreRollSeconds < 0 {
+		add(sevError, "pre_roll_seconds", "negativo (supervisor usa 0)")
+	}
+
+	return out
+}
+
+// lintAnalytics reporta o que o driver vai assinar: itens ignorados e, sem
+// nada válido no /info, o fallback efetivo (lido do ambiente do info-lint).
+func lintAnalytics(info core.CameraInfo, sel drivers.AnalyticsSelection, add func(sev severity, field, format string, args ...interface{})) {
+	if len(sel.Unsupported) > 0 {
+		if sel.Fallback && len(info.Analytics) > 0 {
+			add(sevError, "analytics", "nenhum analytic suportado por %s: %v", info.Manufacturer, sel.Unsupported)
+		} else {
+			add(sevWarn, "analytics", "serão ignorados por %s: %v", info.Manufacturer, sel.Unsupported)
+		}
+	}
+	if !sel.Fallback {
+		return
+	}
+	switch {
+	case len(sel.Selected) == 0:
+		add(sevError, "analytics", "nenhum analytic válido e fallback %s vazio, o driver não assina nada", sel.FallbackEnv)
+	case sel.FallbackEnv != "":
+		add(sevWarn, "analytics", "nenhum analytic válido, o driver usa o fallback %s=%s", sel.FallbackEnv, strings.Join(sel.Selected, ","))
+	default:
+		add(sevWarn, "analytics", "nenhum analytic válido, o driver usa %s", strings.Join(sel.Selected, ","))
+	}
+}
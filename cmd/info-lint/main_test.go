@@ -0,0 +1,145 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeInfo(t *testing.T, name, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func lintFile(t *testing.T, name, body string) []issue {
+	t.Helper()
+	info, unknown, err := loadInfo(writeInfo(t, name, body))
+	if err != nil {
+		t.Fatalf("loadInfo: %v", err)
+	}
+	applyTopic(&info, "cams/acme/hq/1/camera/cam01/info")
+	return append(unknownFieldIssues(unknown), lint(info)...)
+}
+
+func findIssue(issues []issue, sev severity, field, substr string) bool {
+	for _, is := range issues {
+		if is.sev == sev && is.field == field && strings.Contains(is.msg, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasErrors(issues []issue) bool {
+	for _, is := range issues {
+		if is.sev == sevError {
+			return true
+		}
+	}
+	return false
+}
+
+const goodInfo = `{
+  "ip": "10.0.0.10",
+  "port": 80,
+  "username": "admin",
+  "password": "secret",
+  "manufacturer": "hikvision",
+  "model": "any",
+  "enabled": true,
+  "analytics": ["faceCapture"],
+  "rtsp_url": "rtsp://10.0.0.10/Streaming/Channels/101"
+}`
+
+func TestLintGoodInfo(t *testing.T) {
+	if issues := lintFile(t, "cam.json", goodInfo); len(issues) != 0 {
+		t.Fatalf("esperava nenhum problema, veio %+v", issues)
+	}
+}
+
+func TestLintGoodYAML(t *testing.T) {
+	body := `ip: 10.0.0.10
+username: admin
+password: secret
+manufacturer: dahua
+enabled: true
+analytics: [ALL]
+rtsp_url: rtsp://10.0.0.10/cam/realmonitor
+`
+	if issues := lintFile(t, "cam.yaml", body); len(issues) != 0 {
+		t.Fatalf("esperava nenhum problema, veio %+v", issues)
+	}
+}
+
+func TestLintBadInputs(t *testing.T) {
+	cases := []struct {
+		name, from, to string
+		field          string
+	}{
+		{"porta", `"port": 80`, `"port": 70000`, "port"},
+		{"ip vazio", `"ip": "10.0.0.10"`, `"ip": ""`, "ip"},
+		{"driver", `"manufacturer": "hikvision"`, `"manufacturer": "acme"`, "manufacturer"},
+		{"rtsp", `"rtsp_url": "rtsp://10.0.0.10/Streaming/Channels/101"`, `"rtsp_url": "http://x"`, "rtsp_url"},
+		{"uplink sem host", `"enabled": true`, `"enabled": true, "central_srt_port": 8890`, "central_host"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			issues := lintFile(t, "cam.json", strings.Replace(goodInfo, tc.from, tc.to, 1))
+			if !findIssue(issues, sevError, tc.field, "") {
+				t.Fatalf("esperava erro em %s, veio %+v", tc.field, issues)
+			}
+		})
+	}
+}
+
+func TestLintUnknownFieldIsWarning(t *testing.T) {
+	issues := lintFile(t, "cam.json", strings.Replace(goodInfo, `"enabled": true`, `"enabled": true, "colour": "red"`, 1))
+	if !findIssue(issues, sevWarn, "colour", "desconhecido") {
+		t.Fatalf("esperava aviso de campo desconhecido, veio %+v", issues)
+	}
+	if hasErrors(issues) {
+		t.Fatalf("campo desconhecido não deve ser erro: %+v", issues)
+	}
+}
+
+func TestLintAnalyticsFallback(t *testing.T) {
+	noAnalytics := strings.Replace(goodInfo, `"analytics": ["faceCapture"],`, "", 1)
+
+	t.Run("default", func(t *testing.T) {
+		issues := lintFile(t, "cam.json", noAnalytics)
+		if !findIssue(issues, sevWarn, "analytics", "HIK_FALLBACK_ANALYTICS=faceCapture") {
+			t.Fatalf("esperava aviso do fallback padrão, veio %+v", issues)
+		}
+	})
+	t.Run("configurado", func(t *testing.T) {
+		t.Setenv("HIK_FALLBACK_ANALYTICS", "fielddetection")
+		issues := lintFile(t, "cam.json", noAnalytics)
+		if !findIssue(issues, sevWarn, "analytics", "HIK_FALLBACK_ANALYTICS=fielddetection") {
+			t.Fatalf("esperava aviso do fallback configurado, veio %+v", issues)
+		}
+	})
+	t.Run("vazio", func(t *testing.T) {
+		t.Setenv("HIK_FALLBACK_ANALYTICS", "")
+		issues := lintFile(t, "cam.json", noAnalytics)
+		if !findIssue(issues, sevError, "analytics", "não assina nada") {
+			t.Fatalf("esperava erro com fallback vazio, veio %+v", issues)
+		}
+	})
+}
+
+func TestLintUnsupportedAnalytics(t *testing.T) {
+	issues := lintFile(t, "cam.json", strings.Replace(goodInfo, `["faceCapture"]`, `["faceCapture", "bogus"]`, 1))
+	if !findIssue(issues, sevWarn, "analytics", "bogus") || hasErrors(issues) {
+		t.Fatalf("esperava só aviso para bogus, veio %+v", issues)
+	}
+
+	issues = lintFile(t, "cam.json", strings.Replace(goodInfo, `["faceCapture"]`, `["bogus"]`, 1))
+	if !findIssue(issues, sevError, "analytics", "nenhum analytic suportado") {
+		t.Fatalf("esperava erro sem analytic suportado, veio %+v", issues)
+	}
+}
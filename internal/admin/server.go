@@ -0,0 +1,101 @@
+// internal/admin/server.go
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Server é o endpoint HTTP de administração/diagnóstico (ADMIN_ADDR).
+// Com ADMIN_TOKEN definido, toda rota exige "Authorization: Bearer <token>".
+type Server struct {
+	addr  string
+	token string
+	mux   *http.ServeMux
+}
+
+// NewFromEnv cria o servidor a partir de ADMIN_ADDR (ex.: ":8081").
+// Vazio = admin desligado (nil; os métodos aceitam receptor nil).
+func NewFromEnv() *Server {
+	addr := strings.TrimSpace(os.Getenv("ADMIN_ADDR"))
+	if addr == "" {
+		return nil
+	}
+	token := strings.TrimSpace(os.Getenv("ADMIN_TOKEN"))
+	if token == "" {
+		log.Printf("[admin] ADMIN_TOKEN vazio: API de admin sem autenticação em %s", addr)
+	}
+	return &Server{addr: addr, token: token, mux: http.NewServeMux()}
+}
+
+// Handle registra uma rota (padrões do net/http, ex.: "POST /recognize").
+func (s *Server) Handle(pattern string, h http.Handler) {
+	if s == nil {
+		return
+	}
+	s.mux.Handle(pattern, h)
+}
+
+// HandleFunc registra uma função como rota.
+func (s *Server) HandleFunc(pattern string, fn func(http.ResponseWriter, *http.Request)) {
+	s.Handle(pattern, http.HandlerFunc(fn))
+}
+
+// Run serve até ctx ser cancelado.
+func (s *Server) Run(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	srv := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.authorize(s.mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("[admin] escutando em %s", s.addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("[admin] servidor encerrado com erro: %v", err)
+	}
+}
+
+func (s *Server) authorize(next http.Handler) http.Handler {
+	if s.token == "" {
+		return next
+	}
+	want := []byte("Bearer " + s.token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			WriteError(w, http.StatusUnauthorized, errors.New("token inválido"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WriteJSON responde v como JSON com o status dado.
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("[admin] erro ao escrever resposta: %v", err)
+	}
+}
+
+// WriteError responde {"error": "..."}.
+func WriteError(w http.ResponseWriter, status int, err error) {
+	WriteJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,52 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAuthorizeRequiresToken(t *testing.T) {
+	t.Setenv("ADMIN_ADDR", ":0")
+	t.Setenv("ADMIN_TOKEN", "s3nha")
+	s := NewFromEnv()
+	s.HandleFunc("GET /cameras", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, []string{})
+	})
+	h := s.authorize(s.mux)
+
+	for _, auth := range []string{"", "Bearer errado", "s3nha", "Basic s3nha"} {
+		req := httptest.NewRequest(http.MethodGet, "/cameras", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), `"error"`) {
+			t.Fatalf("Authorization=%q: status %d corpo %q, esperava 401", auth, rec.Code, rec.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/cameras", nil)
+	req.Header.Set("Authorization", "Bearer s3nha")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Fatalf("token certo: status %d corpo %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAuthorizeWithoutToken(t *testing.T) {
+	t.Setenv("ADMIN_ADDR", ":0")
+	t.Setenv("ADMIN_TOKEN", "")
+	s := NewFromEnv()
+	s.HandleFunc("GET /cameras", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, []string{})
+	})
+	rec := httptest.NewRecorder()
+	s.authorize(s.mux).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cameras", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("sem ADMIN_TOKEN a rota deveria ficar aberta, status %d", rec.Code)
+	}
+}
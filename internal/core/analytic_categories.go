@@ -0,0 +1,246 @@
+// internal/core/analytic_categories.go
+package core
+
+import (
+    "sort"
+    "strings"
+)
+
+// Categorias canônicas publicadas em Meta["analytic_categories"] para roteamento.
+const (
+    CategoryFace      = "face"
+    CategoryMotion    = "motion"
+    CategoryPerson    = "person"
+    CategoryVehicle   = "vehicle"
+    CategoryIntrusion = "intrusion"
+    CategoryObject    = "object"
+    CategoryCounting  = "counting"
+    CategoryTamper    = "tamper"
+    CategoryAudio     = "audio"
+    CategoryFire      = "fire"
+    CategoryThermal   = "thermal"
+    CategoryAlarm     = "alarm"
+    CategoryAccess    = "access"
+    CategoryStorage   = "storage"
+    CategorySystem    = "system"
+    CategoryOther     = "other"
+)
+
+// analyticCategories mapeia AnalyticType (lowercase) -> categorias.
+// Tipos de Hikvision, Dahua e das engines (faceRecognized) compartilham a tabela.
+var analyticCategories = map[string][]string{
+    // face
+    "facecapture":                     {CategoryFace, CategoryPerson},
+    "facedetection":                   {CategoryFace, CategoryPerson},
+    "facerecognized":                  {CategoryFace, CategoryPerson},
+    "facelikelyrecognized":            {CategoryFace, CategoryPerson},
+    "facesnapmodeling":                {CategoryFace, CategoryPerson},
+    "facetemperaturemeasurementevent": {CategoryFace, CategoryThermal},
+
+    // movimento
+    "vmd":                 {CategoryMotion},
+    "videomotion":         {CategoryMotion},
+    "mdresult":            {CategoryMotion},
+    "movedetection":       {CategoryMotion},
+    "rapidmove":           {CategoryMotion, CategoryPerson},
+    "violentmotion":       {CategoryMotion, CategoryPerson},
+    "running":             {CategoryMotion, CategoryPerson},
+    "smartmotionhuman":    {CategoryMotion, CategoryPerson},
+    "smartmotionvehicle":  {CategoryMotion, CategoryVehicle},
+    "pir":                 {CategoryMotion, CategoryAlarm},
+    "motiondetection":     {CategoryMotion},
+    "cellmotiondetection": {CategoryMotion},
+    "motionalarm":         {CategoryMotion},
+
+    // perímetro
+    "linedetection":        {CategoryIntrusion},
+    "fielddetection":       {CategoryIntrusion},
+    "regionentrance":       {CategoryIntrusion},
+    "regionexiting":        {CategoryIntrusion},
+    "crosslinedetection":   {CategoryIntrusion},
+    "crossregiondetection": {CategoryIntrusion},
+    "nonpoliceintrusion":   {CategoryIntrusion, CategoryPerson},
+    "radarfielddetection":  {CategoryIntrusion},
+    "radarlinedetection":   {CategoryIntrusion},
+    "radarperimeterrule":   {CategoryIntrusion},
+    "loitering":            {CategoryIntrusion, CategoryPerson},
+    "loiteringguard":       {CategoryIntrusion, CategoryPerson},
+    "fenceguard":           {CategoryIntrusion},
+    "objectanalytics":      {CategoryIntrusion},
+    "wanderdetection":      {CategoryIntrusion, CategoryPerson},
+    "reachheight":          {CategoryIntrusion, CategoryPerson},
+    "advreachheight":       {CategoryIntrusion, CategoryPerson},
+    "fireescapedetection":  {CategoryIntrusion},
+    "mixedtargetdetection": {CategoryIntrusion, CategoryPerson, CategoryVehicle},
+    "radartargetdetection": {CategoryIntrusion},
+    "radarvideodetection":  {CategoryIntrusion},
+
+    // objetos
+    "leftdetection":      {CategoryObject},
+    "takenawaydetection": {CategoryObject},
+    "attendedbaggage":    {CategoryObject},
+    "unattendedbaggage":  {CategoryObject},
+    "tossing":            {CategoryObject},
+
+    // pessoas / contagem
+    "peoplecounting":             {CategoryCounting, CategoryPerson},
+    "peoplenumchange":            {CategoryCounting, CategoryPerson},
+    "peoplenumcounting":          {CategoryCounting, CategoryPerson},
+    "framespeoplecounting":       {CategoryCounting, CategoryPerson},
+    "regiontargetnumbercounting": {CategoryCounting},
+    "personqueuecounting":        {CategoryCounting, CategoryPerson},
+    "personqueuedetection":       {CategoryCounting, CategoryPerson},
+    "personqueuerealtime":        {CategoryCounting, CategoryPerson},
+    "personqueuetime":            {CategoryCounting, CategoryPerson},
+    "persondensitydetection":     {CategoryCounting, CategoryPerson},
+    "crowddetection":             {CategoryCounting, CategoryPerson},
+    "crowdsituationanalysis":     {CategoryCounting, CategoryPerson},
+    "rioterdetection":            {CategoryCounting, CategoryPerson},
+    "group":                      {CategoryCounting, CategoryPerson},
+    "heatmap":                    {CategoryCounting},
+    "humanbodycomparison":        {CategoryPerson},
+    "targetcapture":              {CategoryPerson},
+    "faildown":                   {CategoryPerson},
+    "getup":                      {CategoryPerson},
+    "keypersongetup":             {CategoryPerson},
+    "leaveposition":              {CategoryPerson},
+    "playcellphone":              {CategoryPerson},
+    "safetyhelmetdetection":      {CategoryPerson},
+    "sitquietly":                 {CategoryPerson},
+    "standup":                    {CategoryPerson},
+    "personabnormalalarm":        {CategoryPerson, CategoryAlarm},
+
+    // veículos
+    "anpr":                 {CategoryVehicle},
+    "parking":              {CategoryVehicle},
+    "parkingdetection":     {CategoryVehicle},
+    "overspeed":            {CategoryVehicle},
+    "vehiclematchresult":   {CategoryVehicle},
+    "vehiclercogresult":    {CategoryVehicle},
+    "adas":                 {CategoryVehicle},
+    "adasalarm":            {CategoryVehicle, CategoryAlarm},
+    "aid":                  {CategoryVehicle},
+    "tfs":                  {CategoryVehicle},
+    "tma":                  {CategoryVehicle},
+    "tmpa":                 {CategoryVehicle},
+    "dbd":                  {CategoryVehicle},
+    "abnormaldriving":      {CategoryVehicle},
+    "abnormalacceleration": {CategoryVehicle},
+    "collision":            {CategoryVehicle},
+    "rollover":             {CategoryVehicle},
+    "shipsdetection":       {CategoryVehicle},
+
+    // sabotagem / vídeo
+    "videoloss":              {CategoryTamper},
+    "tamperdetection":        {CategoryTamper},
+    "tampering":              {CategoryTamper},
+    "videoblind":             {CategoryTamper},
+    "videounfocus":           {CategoryTamper},
+    "videoabnormaldetection": {CategoryTamper},
+    "shelteralarm":           {CategoryTamper},
+    "defocus":                {CategoryTamper},
+    "scenechangedetection":   {CategoryTamper},
+    "videoexception":         {CategoryTamper},
+
+    // áudio
+    "audiomutation":  {CategoryAudio},
+    "audioanomaly":   {CategoryAudio},
+    "audioabnormal":  {CategoryAudio},
+    "audioexception": {CategoryAudio},
+
+    // fogo / térmico
+    "firewarning":           {CategoryFire},
+    "firewarninginfo":       {CategoryFire},
+    "firedetection":         {CategoryFire},
+    "smokedetection":        {CategoryFire},
+    "smokeandfiredetection": {CategoryFire},
+    "heatimagingtemper":     {CategoryThermal},
+    "temperature":           {CategoryThermal},
+    "thermometry":           {CategoryThermal},
+    "hightempalarm":         {CategoryThermal, CategoryAlarm},
+
+    // alarmes / IO
+    "io":             {CategoryAlarm},
+    "softio":         {CategoryAlarm},
+    "alarmlocal":     {CategoryAlarm},
+    "alarmoutput":    {CategoryAlarm},
+    "sensoralarm":    {CategoryAlarm},
+    "emergencyalarm": {CategoryAlarm},
+    "radaralarm":     {CategoryAlarm},
+    "faultalarm":     {CategoryAlarm},
+
+    // controle de acesso
+    "accesscontrollerevent":     {CategoryAccess},
+    "idcardinfoevent":           {CategoryAccess},
+    "qrcodeevent":               {CategoryAccess},
+    "certificatecaptureevent":   {CategoryAccess},
+    "uncertificatecompareevent": {CategoryAccess},
+    "cardmatch":                 {CategoryAccess},
+    "attendance":                {CategoryAccess},
+
+    // armazenamento / sistema
+    "storagenotexist":     {CategoryStorage},
+    "storagefailure":      {CategoryStorage},
+    "storagelowspace":     {CategoryStorage},
+    "diskfull":            {CategoryStorage},
+    "diskerror":           {CategoryStorage},
+    "diskunformat":        {CategoryStorage},
+    "sysstorfull":         {CategoryStorage},
+    "hdbadblock":          {CategoryStorage},
+    "hdimpact":            {CategoryStorage},
+    "severehdfailure":     {CategoryStorage},
+    "recordexception":     {CategoryStorage},
+    "recordcycleabnormal": {CategoryStorage},
+    "ipconflict":          {CategorySystem},
+    "nicbroken":           {CategorySystem},
+    "illaccess":           {CategorySystem},
+    "nodeoffline":         {CategorySystem},
+    "versionabnormal":     {CategorySystem},
+    "ipctransferabnormal": {CategorySystem},
+}
+
+// AnalyticCategories devolve as categorias canônicas de um AnalyticType.
+// Tipos desconhecidos caem em "other" para que o campo nunca venha vazio.
+func AnalyticCategories(analyticType string) []string {
+    cats, ok := analyticCategories[strings.ToLower(strings.TrimSpace(analyticType))]
+    if !ok {
+        return []string{CategoryOther}
+    }
+    out := make([]string, len(cats))
+    copy(out, cats)
+    return out
+}
+
+// EventCategories combina as categorias do AnalyticType com as de
+// Meta["analytic_types"] (quando o driver sinaliza tipos adicionais no mesmo
+// evento, ex.: faceCapture disparado junto com VMD). Resultado ordenado.
+func EventCategories(evt AnalyticEvent) []string {
+    types := []string{evt.AnalyticType}
+    if extra, ok := evt.Meta["analytic_types"].([]string); ok {
+        types = append(types, extra...)
+    }
+
+    seen := map[string]struct{}{}
+    var out []string
+    for _, t := range types {
+        for _, c := range AnalyticCategories(t) {
+            if _, dup := seen[c]; dup {
+                continue
+            }
+            seen[c] = struct{}{}
+            out = append(out, c)
+        }
+    }
+    if len(out) > 1 {
+        // "other" só faz sentido sozinho
+        filtered := out[:0]
+        for _, c := range out {
+            if c != CategoryOther {
+                filtered = append(filtered, c)
+            }
+        }
+        out = filtered
+    }
+    sort.Strings(out)
+    return out
+}
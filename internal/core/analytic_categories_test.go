@@ -0,0 +1,43 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyticCategories(t *testing.T) {
+	cases := map[string][]string{
+		"faceCapture":      {CategoryFace, CategoryPerson},
+		" FACERECOGNIZED ": {CategoryFace, CategoryPerson},
+		"tipoQualquer":     {CategoryOther},
+		"":                 {CategoryOther},
+	}
+	for typ, want := range cases {
+		if got := AnalyticCategories(typ); !reflect.DeepEqual(got, want) {
+			t.Errorf("AnalyticCategories(%q) = %v, esperava %v", typ, got, want)
+		}
+	}
+
+	// o retorno é cópia: alterar não pode vazar para a tabela
+	got := AnalyticCategories("faceCapture")
+	got[0] = "x"
+	if AnalyticCategories("faceCapture")[0] != CategoryFace {
+		t.Fatal("AnalyticCategories devolveu a slice da tabela")
+	}
+}
+
+func TestEventCategoriesMergesExtraTypes(t *testing.T) {
+	evt := AnalyticEvent{
+		AnalyticType: "faceCapture",
+		Meta:         map[string]interface{}{"analytic_types": []string{"VMD", "desconhecido"}},
+	}
+	got := EventCategories(evt)
+	want := []string{CategoryFace, CategoryMotion, CategoryPerson}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("EventCategories = %v, esperava %v (ordenado, sem other)", got, want)
+	}
+
+	if got := EventCategories(AnalyticEvent{AnalyticType: "desconhecido"}); !reflect.DeepEqual(got, []string{CategoryOther}) {
+		t.Fatalf("tipo desconhecido sozinho = %v, esperava [other]", got)
+	}
+}
@@ -0,0 +1,38 @@
+// internal/core/axis_analytics.go
+package core
+
+import (
+    "sort"
+    "strings"
+)
+
+// AxisEventTopics mapeia o analytic publicado -> tópico VAPIX/ONVIF assinado
+// no event stream da Axis. O driver pede o tópico e os subtópicos ("//.") e
+// casa as notificações pelo prefixo sem namespaces.
+var AxisEventTopics = map[string]string{
+    "MotionDetection": "tns1:VideoAnalytics/tnsaxis:MotionDetection",
+    "VMD":             "tnsaxis:CameraApplicationPlatform/VMD",
+    "ObjectAnalytics": "tnsaxis:CameraApplicationPlatform/ObjectAnalytics",
+    "FenceGuard":      "tnsaxis:CameraApplicationPlatform/FenceGuard",
+    "LoiteringGuard":  "tnsaxis:CameraApplicationPlatform/LoiteringGuard",
+    "FaceDetection":   "tns1:RuleEngine/FaceDetector",
+    "Tampering":       "tns1:VideoSource/tnsaxis:Tampering",
+}
+
+// AxisEventTypes são os analytics aceitos no /info de câmeras Axis.
+var AxisEventTypes = func() []string {
+    out := make([]string, 0, len(AxisEventTopics))
+    for name := range AxisEventTopics {
+        out = append(out, name)
+    }
+    sort.Strings(out)
+    return out
+}()
+
+var AxisEventTypeSet = func() map[string]struct{} {
+    m := make(map[string]struct{}, len(AxisEventTopics))
+    for t := range AxisEventTopics {
+        m[strings.ToLower(t)] = struct{}{}
+    }
+    return m
+}()
@@ -0,0 +1,23 @@
+// internal/core/event_state.go
+package core
+
+import "strings"
+
+// Estados normalizados de AnalyticEvent.EventState.
+const (
+	EventStateActive   = "active"
+	EventStateInactive = "inactive"
+)
+
+// NormalizeEventState converte o estado da câmera (Hikvision eventState
+// active/inactive, Dahua action Start/Stop/Pulse) para active/inactive.
+// Valores desconhecidos viram "".
+func NormalizeEventState(raw string) string {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "active", "start", "pulse", "true", "1":
+		return EventStateActive
+	case "inactive", "stop", "end", "false", "0":
+		return EventStateInactive
+	}
+	return ""
+}
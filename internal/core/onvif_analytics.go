@@ -0,0 +1,38 @@
+// internal/core/onvif_analytics.go
+package core
+
+import (
+    "sort"
+    "strings"
+)
+
+// OnvifEventTopics mapeia o analytic publicado -> prefixo do tópico ONVIF
+// (sem o namespace "tns1:"). O driver casa pelo prefixo, então subtópicos
+// como RuleEngine/FaceDetector/Face caem no mesmo analytic.
+var OnvifEventTopics = map[string]string{
+    "FaceDetection":       "RuleEngine/FaceDetector",
+    "MotionDetection":     "VideoAnalytics/MotionDetection",
+    "CellMotionDetection": "RuleEngine/CellMotionDetector",
+    "MotionAlarm":         "VideoSource/MotionAlarm",
+    "LineDetection":       "RuleEngine/LineDetector",
+    "FieldDetection":      "RuleEngine/FieldDetector",
+    "TamperDetection":     "RuleEngine/TamperDetector",
+}
+
+// OnvifEventTypes são os analytics aceitos no /info de câmeras ONVIF.
+var OnvifEventTypes = func() []string {
+    out := make([]string, 0, len(OnvifEventTopics))
+    for name := range OnvifEventTopics {
+        out = append(out, name)
+    }
+    sort.Strings(out)
+    return out
+}()
+
+var OnvifEventTypeSet = func() map[string]struct{} {
+    m := make(map[string]struct{}, len(OnvifEventTopics))
+    for t := range OnvifEventTopics {
+        m[strings.ToLower(t)] = struct{}{}
+    }
+    return m
+}()
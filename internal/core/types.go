@@ -15,6 +15,47 @@ type CameraInfo struct {
 	Analytics    []string `json:"analytics,omitempty"`
 	Enabled      bool     `json:"enabled"`
 
+	// CertFingerprint fixa o certificado TLS da câmera (SHA-256 do cert em hex,
+	// com ou sem ':'). Vazio = TLS sem verificação (rede interna).
+	CertFingerprint string `json:"cert_fingerprint,omitempty"`
+
+	// SubscribeHeartbeatSeconds sobrescreve DRIVER_SUBSCRIBE_HEARTBEAT para esta
+	// câmera; o watchdog do stream de eventos deriva dele. 0 = padrão.
+	SubscribeHeartbeatSeconds int `json:"subscribe_heartbeat_seconds,omitempty"`
+
+	// EventTransport escolhe de onde vêm os eventos: vazio/"http" = canal HTTP
+	// do driver do fabricante; "rtsp" = trilha de metadata ONVIF do RTSPURL;
+	// "poll" = consulta periódica do snapshot e, opcionalmente, de um CGI de
+	// movimento (câmeras sem push de eventos).
+	EventTransport string `json:"event_transport,omitempty"`
+
+	// Configuração do event_transport=poll. SnapshotPath e MotionPath são
+	// caminhos na câmera (ou URLs absolutas); SnapshotPath vazio = snapshot
+	// padrão do fabricante. PollIntervalSeconds 0 = POLL_INTERVAL_SECONDS.
+	// MotionKey é a chave lida na resposta do MotionPath (vazio = "motion").
+	PollIntervalSeconds int    `json:"poll_interval_seconds,omitempty"`
+	SnapshotPath        string `json:"snapshot_path,omitempty"`
+	MotionPath          string `json:"motion_path,omitempty"`
+	MotionKey           string `json:"motion_key,omitempty"`
+
+	// AuthMode escolhe a autenticação HTTP com a câmera: vazio/"digest" =
+	// Digest (padrão); "session" = login por POST (SessionLogin) e cookie de sessão.
+	AuthMode     string        `json:"auth_mode,omitempty"`
+	SessionLogin *SessionLogin `json:"session_login,omitempty"`
+
+	// Channels lista os canais assinados num NVR Hikvision (um <Event> por canal
+	// e analytic no subscribeEvent); vazio = [1].
+	Channels []int `json:"channels,omitempty"`
+
+	// SnapshotChannel fixa o canal do snapshot (Dahua snapshot.cgi?channel=N),
+	// no lugar do canal do evento; 0 = canal do evento (default 1).
+	// SnapshotType é o type=M do snapshot.cgi (ex.: 1 = sub stream, menor); 0 = omitido.
+	SnapshotChannel int `json:"snapshot_channel,omitempty"`
+	SnapshotType    int `json:"snapshot_type,omitempty"`
+
+	// StorageProfile escolhe o backend de snapshots (STORAGE_PROFILES); vazio = padrão.
+	StorageProfile string `json:"storage_profile,omitempty"`
+
 	RTSPURL                string `json:"rtsp_url,omitempty"`
 	ProxyPath              string `json:"proxy_path,omitempty"`
 	CentralHost            string `json:"central_host,omitempty"`
@@ -33,6 +74,20 @@ type CameraInfo struct {
 
 	// Shard responsável por essa câmera (ex.: "shard-1", "shard-2", "ceara-sede", etc.)
 	Shard string `json:"shard,omitempty"`
+
+	// Weight é a carga relativa da câmera na divisão entre shards (CAMBUS_SHARDS);
+	// 0 = 1. Só vale quando Shard não é fixado no /info.
+	Weight float64 `json:"weight,omitempty"`
+}
+
+// SessionLogin descreve o endpoint de login de auth_mode=session. Campos
+// vazios usam os padrões (POST /login, form, username/password).
+type SessionLogin struct {
+	Path      string            `json:"path,omitempty"`
+	Format    string            `json:"format,omitempty"` // "form" ou "json"
+	UserField string            `json:"user_field,omitempty"`
+	PassField string            `json:"pass_field,omitempty"`
+	Extra     map[string]string `json:"extra,omitempty"` // campos fixos adicionais do login
 }
 
 type AnalyticEvent struct {
@@ -42,6 +97,10 @@ type AnalyticEvent struct {
 	CameraName   string    `json:"CameraName"`
 	AnalyticType string    `json:"AnalyticType"`
 
+	// EventState normalizado: "active" (início/pulso) ou "inactive" (fim de um
+	// evento sustentado). Vazio quando a câmera não informa.
+	EventState string `json:"EventState,omitempty"`
+
 	// Contexto da câmera (copiado do CameraInfo)
 	Tenant     string `json:"Tenant,omitempty"`
 	Building   string `json:"Building,omitempty"`
@@ -55,9 +114,18 @@ type AnalyticEvent struct {
 	// URL pública do snapshot no MinIO
 	SnapshotURL string `json:"SnapshotURL,omitempty"`
 
+	// URL da miniatura (<key>.thumb.jpg) ao lado do snapshot, com
+	// MINIO_GENERATE_THUMBNAILS
+	ThumbnailURL string `json:"ThumbnailURL,omitempty"`
+
 	// Legacy / debug only – base64 do snapshot, se quiser manter
 	SnapshotB64 string `json:"SnapshotB64,omitempty"`
 
 	// ⚠️ Novo: bytes crus do snapshot em memória (NÃO vai pro JSON / MQTT)
 	RawSnapshot []byte `json:"-"` // usado internamente pelo face engine (FindFace)
+
+	// Snapshot ainda não salvo (SNAPSHOT_STORE_POLICY matched/unmatched):
+	// chave e content-type que o driver usaria, para o supervisor salvar depois.
+	SnapshotKey         string `json:"-"`
+	SnapshotContentType string `json:"-"`
 }
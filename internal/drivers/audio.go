@@ -0,0 +1,128 @@
+// internal/drivers/audio.go
+package drivers
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+// AnalyticAudioAnomaly é o analytic canônico dos eventos de áudio: Dahua
+// AudioAnomaly/AudioMutation e Hikvision audioexception/audioAbnormal saem
+// todos com esse AnalyticType (o código original fica em Meta["sourceAnalytic"]).
+const AnalyticAudioAnomaly = "audioAnomaly"
+
+var audioAnalyticCodes = map[string]bool{
+	"audioanomaly":   true,
+	"audiomutation":  true,
+	"audioabnormal":  true,
+	"audioexception": true,
+}
+
+// Nomes dos parâmetros de áudio nos payloads conhecidos (Dahua "Data",
+// Hikvision JSON); a busca desce um nível em objetos aninhados.
+var (
+	audioLevelKeys     = []string{"Decibel", "decibel", "Level", "level", "SoundLevel", "soundLevel", "Intensity", "intensity", "Volume", "volume"}
+	audioThresholdKeys = []string{"Threshold", "threshold", "DecibelThreshold", "decibelThreshold", "Limit", "limit"}
+	audioTypeKeys      = []string{"AudioType", "audioType", "AudioMode", "audioMode", "Type", "type"}
+)
+
+func isAudioAnalytic(code string) bool {
+	return audioAnalyticCodes[strings.ToLower(strings.TrimSpace(code))]
+}
+
+// applyAudioAnalytic troca o AnalyticType de eventos de áudio pelo canônico e
+// preenche Meta com audioLevel, audioThreshold e audioType (quando vierem em
+// data). Eventos de outros tipos passam sem mudança.
+func applyAudioAnalytic(evt *core.AnalyticEvent, data map[string]interface{}) {
+	if evt == nil || !isAudioAnalytic(evt.AnalyticType) {
+		return
+	}
+	if evt.Meta == nil {
+		evt.Meta = map[string]interface{}{}
+	}
+	source := evt.AnalyticType
+	evt.Meta["sourceAnalytic"] = source
+	evt.AnalyticType = AnalyticAudioAnomaly
+
+	if v, ok := findAudioValue(data, audioLevelKeys, 1); ok {
+		if f, ok := audioNumber(v); ok {
+			evt.Meta["audioLevel"] = f
+		}
+	}
+	if v, ok := findAudioValue(data, audioThresholdKeys, 1); ok {
+		if f, ok := audioNumber(v); ok {
+			evt.Meta["audioThreshold"] = f
+		}
+	}
+	audioType := source // sem tipo no payload, o próprio código diz (ex.: AudioMutation)
+	if v, ok := findAudioValue(data, audioTypeKeys, 1); ok {
+		if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+			audioType = strings.TrimSpace(s)
+		}
+	}
+	evt.Meta["audioType"] = audioType
+}
+
+func findAudioValue(m map[string]interface{}, keys []string, depth int) (interface{}, bool) {
+	if m == nil {
+		return nil, false
+	}
+	if v, ok := firstValue(m, keys); ok {
+		return v, true
+	}
+	if depth <= 0 {
+		return nil, false
+	}
+	names := make([]string, 0, len(m))
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Strings(names) // determinístico quando mais de um objeto traz o campo
+	for _, k := range names {
+		if nested, ok := m[k].(map[string]interface{}); ok {
+			if found, ok := findAudioValue(nested, keys, depth-1); ok {
+				return found, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// audioNumber aceita número JSON ou string numérica (formato KV do Dahua).
+func audioNumber(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case int:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case json.Number:
+		f, err := x.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// dahuaKVData extrai o JSON de "data={...}" do formato texto do Dahua
+// (Code=AudioMutation;action=Start;index=0;data={...}).
+func dahuaKVData(body string) map[string]interface{} {
+	idx := strings.Index(body, "data=")
+	if idx < 0 {
+		return nil
+	}
+	dec := json.NewDecoder(strings.NewReader(body[idx+len("data="):]))
+	dec.UseNumber()
+	var data map[string]interface{}
+	if err := dec.Decode(&data); err != nil {
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,98 @@
+package drivers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func TestDahuaAudioEvents(t *testing.T) {
+	allowed := map[string]struct{}{"audiomutation": {}, "audioanomaly": {}}
+	cases := []struct {
+		name      string
+		body      string
+		source    string
+		level     interface{}
+		threshold interface{}
+		audioType string
+	}{
+		{
+			name:   "texto com data=",
+			body:   `Code=AudioMutation;action=Start;index=0;data={"Decibel": 82.5, "Threshold": "70"}`,
+			source: "AudioMutation", level: 82.5, threshold: 70.0, audioType: "AudioMutation",
+		},
+		{
+			name:   "json com Data",
+			body:   `{"Code":"AudioAnomaly","Action":"Start","Index":0,"Data":{"AudioType":"Scream","Intensity":91,"Limit":60}}`,
+			source: "AudioAnomaly", level: 91.0, threshold: 60.0, audioType: "Scream",
+		},
+		{
+			name:   "sem parâmetros",
+			body:   "Code=AudioAnomaly;action=Start;index=0",
+			source: "AudioAnomaly", audioType: "AudioAnomaly",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var queries []string
+			d := newTestDahua(t, cameraServer(t, snapshotHandler(&queries)))
+			evt, _, _, err := d.parseEventAndSnapshot(context.Background(), []byte(tc.body), allowed)
+			if err != nil || evt == nil {
+				t.Fatalf("evt=%v err=%v", evt, err)
+			}
+			if evt.AnalyticType != AnalyticAudioAnomaly || evt.Meta["sourceAnalytic"] != tc.source {
+				t.Fatalf("AnalyticType=%q sourceAnalytic=%v", evt.AnalyticType, evt.Meta["sourceAnalytic"])
+			}
+			if evt.Meta["audioLevel"] != tc.level || evt.Meta["audioThreshold"] != tc.threshold || evt.Meta["audioType"] != tc.audioType {
+				t.Fatalf("Meta = %v", evt.Meta)
+			}
+		})
+	}
+}
+
+func TestHikvisionAudioEvent(t *testing.T) {
+	d := newTestHikvision(t, core.CameraInfo{IP: "10.0.0.1", DeviceID: "c1"})
+	evt, err := d.parseJSONEvent([]byte(`{
+		"eventType": "audioexception",
+		"eventState": "active",
+		"channelID": 1,
+		"dateTime": "2024-03-01T12:00:00Z",
+		"audioException": {"audioMode": "soundIntensityMutation", "soundLevel": 77, "decibelThreshold": 65}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evt.AnalyticType != AnalyticAudioAnomaly || evt.Meta["sourceAnalytic"] != "audioexception" {
+		t.Fatalf("AnalyticType=%q Meta=%v", evt.AnalyticType, evt.Meta)
+	}
+	if evt.Meta["audioLevel"] != 77.0 || evt.Meta["audioThreshold"] != 65.0 || evt.Meta["audioType"] != "soundIntensityMutation" {
+		t.Fatalf("Meta = %v", evt.Meta)
+	}
+}
+
+func TestApplyAudioAnalyticIgnoresOtherTypes(t *testing.T) {
+	evt := &core.AnalyticEvent{AnalyticType: "faceCapture"}
+	applyAudioAnalytic(evt, map[string]interface{}{"Level": 90})
+	if evt.AnalyticType != "faceCapture" || evt.Meta != nil {
+		t.Fatalf("evento não-áudio alterado: %+v", evt)
+	}
+}
+
+func TestAudioNumber(t *testing.T) {
+	for _, tc := range []struct {
+		in   interface{}
+		want float64
+		ok   bool
+	}{
+		{42.5, 42.5, true},
+		{int64(7), 7, true},
+		{" 63.2 ", 63.2, true},
+		{"alto", 0, false},
+		{true, 0, false},
+	} {
+		if got, ok := audioNumber(tc.in); got != tc.want || ok != tc.ok {
+			t.Errorf("audioNumber(%#v) = %v, %t", tc.in, got, ok)
+		}
+	}
+}
@@ -0,0 +1,412 @@
+// internal/drivers/axis.go
+package drivers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/eventid"
+	"github.com/sua-org/cam-bus/internal/logthrottle"
+	"github.com/sua-org/cam-bus/internal/netproxy"
+	"github.com/sua-org/cam-bus/internal/tracing"
+)
+
+const axisEventStreamPath = "/vapix/ws-data-stream?sources=events"
+
+// AxisDriver assina o event stream VAPIX via WebSocket (ws-data-stream,
+// events:configure) e mapeia os tópicos de core.AxisEventTopics para
+// AnalyticEvent. Snapshots vêm de /axis-cgi/jpg/image.cgi.
+type AxisDriver struct {
+	httpCamera
+	dialer    *websocket.Dialer
+	heartbeat time.Duration     // intervalo dos pings do WebSocket (DRIVER_SUBSCRIBE_HEARTBEAT)
+	backoff   *reconnectBackoff // espera entre reconexões (CAMERA_RECONNECT_*)
+}
+
+func NewAxisDriver(info core.CameraInfo) (CameraDriver, error) {
+	var httpClient *http.Client
+	dialer := &websocket.Dialer{
+		Proxy:            netproxy.Func,
+		HandshakeTimeout: 10 * time.Second,
+	}
+
+	if info.UseTLS {
+		tlsCfg, err := cameraTLSConfig(info)
+		if err != nil {
+			return nil, err
+		}
+		httpClient = &http.Client{Transport: netproxy.Transport(tlsCfg)}
+		dialer.TLSClientConfig = tlsCfg
+		if info.CertFingerprint != "" {
+			log.Printf("[axis] TLS com certificado fixado habilitado para %s (%s)", info.Name, info.IP)
+		} else {
+			log.Printf("[axis] TLS inseguro habilitado para %s (%s)", info.Name, info.IP)
+		}
+	} else {
+		httpClient = netproxy.Client(0)
+	}
+
+	session, err := newSessionAuth(info)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AxisDriver{
+		httpCamera: httpCamera{info: info, client: httpClient, session: session},
+		dialer:     dialer,
+		heartbeat:  subscribeHeartbeat(info, defaultAxisHeartbeat),
+		backoff:    newReconnectBackoff(),
+	}, nil
+}
+
+func init() {
+	// fabricante "Axis", modelo "any"
+	RegisterDriver("axis", "any", func(info core.CameraInfo) (CameraDriver, error) {
+		return NewAxisDriver(info)
+	})
+}
+
+// ActiveAnalytics retorna a lista efetiva de analytics assinados para a câmera.
+func (d *AxisDriver) ActiveAnalytics() []string {
+	return d.selectedAnalytics()
+}
+
+// selectedAnalytics aplica info.Analytics como filtro de tópicos: "ALL"/"*" =
+// todos; nada válido = AXIS_FALLBACK_ANALYTICS (default MotionDetection).
+func (d *AxisDriver) selectedAnalytics() []string {
+	sel := axisAnalytics(d.info.Analytics)
+	for _, name := range sel.Unsupported {
+		log.Printf("[axis] camera %s: analytics '%s' não é suportado, ignorando", d.info.DeviceID, name)
+	}
+	if sel.Fallback && len(sel.Selected) > 0 {
+		log.Printf("[axis] camera %s: nenhum analytics válido no /info, usando fallback %s",
+			d.info.DeviceID, strings.Join(sel.Selected, ","))
+	}
+	return sel.Selected
+}
+
+// axisAnalytics são as regras de selectedAnalytics.
+func axisAnalytics(analytics []string) AnalyticsSelection {
+	sel := filterAnalytics(analytics, axisAnalyticName, core.AxisEventTypes)
+	if len(sel.Selected) == 0 {
+		sel.Fallback, sel.FallbackEnv = true, "AXIS_FALLBACK_ANALYTICS"
+		sel.Selected = fallbackAnalytics(sel.FallbackEnv, []string{"MotionDetection"}, core.AxisEventTypeSet)
+		for i, name := range sel.Selected {
+			sel.Selected[i], _ = axisAnalyticName(name)
+		}
+	}
+	return sel
+}
+
+// axisAnalyticName devolve o nome canônico (chave de AxisEventTopics).
+func axisAnalyticName(name string) (string, bool) {
+	for canonical := range core.AxisEventTopics {
+		if strings.EqualFold(canonical, name) {
+			return canonical, true
+		}
+	}
+	return "", false
+}
+
+func (d *AxisDriver) Run(ctx context.Context, events chan<- core.AnalyticEvent) error {
+	log.Printf("[axis] starting driver for %s (%s)", d.info.Name, d.info.IP)
+
+	if len(d.selectedAnalytics()) == 0 {
+		return waitMisconfigured(ctx, d.notifyStatus, "nenhum analytics válido no /info (fallback AXIS_FALLBACK_ANALYTICS vazio)")
+	}
+
+	return runReconnecting(ctx, d.backoff, "axis", d.info, d.notifyStatus, func(ctx context.Context) error {
+		return d.runOnce(ctx, events)
+	})
+}
+
+// axisMessage cobre as mensagens JSON do ws-data-stream: resposta do
+// events:configure (result/error) e notificações (events:notify).
+type axisMessage struct {
+	Method string `json:"method"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+	Params struct {
+		Notification struct {
+			Topic     string `json:"topic"`
+			Timestamp int64  `json:"timestamp"`
+			Message   struct {
+				Source map[string]interface{} `json:"source"`
+				Key    map[string]interface{} `json:"key"`
+				Data   map[string]interface{} `json:"data"`
+			} `json:"message"`
+		} `json:"notification"`
+	} `json:"params"`
+}
+
+func (d *AxisDriver) runOnce(ctx context.Context, events chan<- core.AnalyticEvent) error {
+	analytics := d.selectedAnalytics()
+	d.notifyStatus(StatusUpdate{State: ConnectionStateConnecting, Reason: "abrindo event stream"})
+
+	conn, err := d.dial(ctx)
+	if err != nil {
+		d.notifyStatus(StatusUpdate{State: ConnectionStateNotEstablished, Reason: err.Error()})
+		return fmt.Errorf("ws-data-stream: %w", err)
+	}
+	defer conn.Close()
+
+	// fecha a conexão no cancelamento para destravar o ReadMessage
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	filters := make([]map[string]string, 0, len(analytics))
+	for _, name := range analytics {
+		filters = append(filters, map[string]string{"topicFilter": core.AxisEventTopics[name] + "//."})
+	}
+	configure := map[string]interface{}{
+		"apiVersion": "1.0",
+		"method":     "events:configure",
+		"params":     map[string]interface{}{"eventFilterList": filters},
+	}
+	if err := conn.WriteJSON(configure); err != nil {
+		d.notifyStatus(StatusUpdate{State: ConnectionStateNotEstablished, Reason: err.Error()})
+		return fmt.Errorf("events:configure: %w", err)
+	}
+
+	// sem nada (nem pong) por heartbeat×DRIVER_WATCHDOG_FACTOR, reconecta
+	timeout := watchdogTimeout(d.heartbeat)
+	extend := func() {
+		if timeout > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(timeout))
+		}
+	}
+	extend()
+	conn.SetPongHandler(func(string) error { extend(); return nil })
+	go d.pingLoop(ctx, conn)
+
+	configured := false
+	for {
+		var msg axisMessage
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			d.notifyStatus(StatusUpdate{State: ConnectionStateOffline, Reason: err.Error()})
+			return fmt.Errorf("error reading event stream: %w", err)
+		}
+		extend()
+		if err := json.Unmarshal(data, &msg); err != nil {
+			cameraLog(d.info).Warnf("[axis] mensagem inválida do event stream: %v; raw=%s", err, string(data))
+			continue
+		}
+		if msg.Error != nil {
+			d.notifyStatus(StatusUpdate{State: ConnectionStateNotEstablished, Reason: msg.Error.Message})
+			return fmt.Errorf("events:configure erro %d: %s", msg.Error.Code, msg.Error.Message)
+		}
+		if !configured && msg.Method == "events:configure" {
+			configured = true
+			d.notifyStatus(StatusUpdate{
+				State:  ConnectionStateOnline,
+				Reason: fmt.Sprintf("subscribed to [%s]", strings.Join(analytics, ",")),
+			})
+			continue
+		}
+		if msg.Method != "events:notify" {
+			continue
+		}
+
+		evt := d.parseNotification(msg, analytics)
+		if evt == nil {
+			continue
+		}
+		d.emit(ctx, events, evt)
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// dial abre o WebSocket; no 401 refaz o handshake com Digest (mesma conta
+// do digestRequest).
+func (d *AxisDriver) dial(ctx context.Context) (*websocket.Conn, error) {
+	scheme := "ws"
+	if d.info.UseTLS {
+		scheme = "wss"
+	}
+	wsURL := scheme + "://" + d.hostPort() + axisEventStreamPath
+
+	if d.session != nil {
+		return d.dialSession(ctx, wsURL)
+	}
+
+	conn, resp, err := d.dialer.DialContext(ctx, wsURL, nil)
+	if err == nil {
+		return conn, nil
+	}
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		return nil, dialError(resp, err)
+	}
+
+	u, _ := url.Parse(wsURL)
+	auth, err := digestAuthorization(&d.nonces, resp.Header.Get("WWW-Authenticate"), d.info.Username, d.info.Password, http.MethodGet, u.RequestURI())
+	if err != nil {
+		return nil, err
+	}
+	conn, resp, err = d.dialer.DialContext(ctx, wsURL, http.Header{"Authorization": {auth}})
+	if err != nil {
+		return nil, dialError(resp, err)
+	}
+	return conn, nil
+}
+
+// dialSession abre o WebSocket com o cookie de auth_mode=session; no 401
+// refaz o login uma vez.
+func (d *AxisDriver) dialSession(ctx context.Context, wsURL string) (*websocket.Conn, error) {
+	for attempt := 0; ; attempt++ {
+		cookie, err := d.session.cookieHeader(ctx, d.do, attempt > 0)
+		if err != nil {
+			return nil, err
+		}
+		conn, resp, err := d.dialer.DialContext(ctx, wsURL, http.Header{"Cookie": {cookie}})
+		if err == nil {
+			return conn, nil
+		}
+		if attempt > 0 || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+			return nil, dialError(resp, err)
+		}
+	}
+}
+
+func dialError(resp *http.Response, err error) error {
+	if resp == nil || !errors.Is(err, websocket.ErrBadHandshake) {
+		return err
+	}
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("handshake status %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+}
+
+// pingLoop manda ping a cada heartbeat; o pong estende o read deadline.
+func (d *AxisDriver) pingLoop(ctx context.Context, conn *websocket.Conn) {
+	ticker := time.NewTicker(d.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// parseNotification converte um events:notify em AnalyticEvent; nil quando o
+// tópico não está no filtro ou a notificação é de fim (active=0), como o
+// Dahua com action!=Start.
+func (d *AxisDriver) parseNotification(msg axisMessage, analytics []string) *core.AnalyticEvent {
+	n := msg.Params.Notification
+	topic := onvifTopicPath(n.Topic)
+	analytic := ""
+	for _, name := range analytics {
+		prefix := onvifTopicPath(core.AxisEventTopics[name])
+		if prefix != "" && strings.HasPrefix(strings.ToLower(topic), strings.ToLower(prefix)) {
+			analytic = name
+			break
+		}
+	}
+	if analytic == "" {
+		return nil
+	}
+
+	for _, key := range []string{"active", "State", "state", "IsMotion", "IsTampered"} {
+		if v, ok := n.Message.Data[key]; ok {
+			if s := strings.ToLower(strings.TrimSpace(fmt.Sprint(v))); s == "0" || s == "false" {
+				return nil
+			}
+			break
+		}
+	}
+
+	ts := time.Now().UTC()
+	meta := map[string]interface{}{
+		"topic":  topic,
+		"source": n.Message.Source,
+		"key":    n.Message.Key,
+		"data":   n.Message.Data,
+	}
+	if n.Timestamp > 0 {
+		meta["camera_timestamp"] = n.Timestamp
+	}
+	channel := defaultSnapshotChannel
+	if ch, ok := toChannel(n.Message.Source["channel"]); ok && ch >= 0 {
+		// channel da Axis é 0-based; image.cgi usa camera=1..N
+		channel = ch + 1
+	}
+	meta["channelID"] = channel
+
+	return &core.AnalyticEvent{
+		Timestamp:    ts,
+		EventID:      eventid.Resolve("", "axis", ts),
+		CameraIP:     d.info.IP,
+		CameraName:   d.info.Name,
+		AnalyticType: analytic,
+		EventState:   core.EventStateActive,
+		Meta:         meta,
+
+		Tenant:     d.info.Tenant,
+		Building:   d.info.Building,
+		Floor:      d.info.Floor,
+		DeviceType: d.info.DeviceType,
+		DeviceID:   d.info.DeviceID,
+	}
+}
+
+// emit baixa o snapshot do canal, salva como o Dahua e envia o evento.
+func (d *AxisDriver) emit(ctx context.Context, events chan<- core.AnalyticEvent, evt *core.AnalyticEvent) {
+	evtCtx, span := tracing.Start(ctx, "driver.receive")
+	span.SetAttr("camera.id", d.info.DeviceID)
+	span.SetAttr("analytic.type", evt.AnalyticType)
+	span.SetAttr("event.id", evt.EventID)
+
+	channel, _ := evt.Meta["channelID"].(int)
+	img, ct, err := fetchImage(evtCtx, d.doDigest, d.snapshotURL(channel))
+	if err != nil {
+		logthrottle.Printf("axis:snapshot:"+d.info.IP, "[axis] erro ao buscar snapshot: %v", err)
+	}
+	d.attachSnapshot(evtCtx, "axis", evt, img, ct)
+	evt.Meta = tracing.Inject(evtCtx, evt.Meta)
+	span.End()
+
+	select {
+	case events <- *evt:
+	case <-ctx.Done():
+	}
+}
+
+func (d *AxisDriver) snapshotURL(channel int) string {
+	if channel <= 0 {
+		channel = defaultSnapshotChannel
+	}
+	scheme := "http"
+	if d.info.UseTLS {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/axis-cgi/jpg/image.cgi?camera=%d", scheme, d.hostPort(), channel)
+}
+
+func (d *AxisDriver) hostPort() string {
+	if d.info.Port != 0 {
+		return fmt.Sprintf("%s:%d", d.info.IP, d.info.Port)
+	}
+	return d.info.IP
+}
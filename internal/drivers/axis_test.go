@@ -0,0 +1,204 @@
+package drivers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func newTestAxis(t *testing.T, info core.CameraInfo) *AxisDriver {
+	t.Helper()
+	info.Manufacturer = "axis"
+	drv, err := NewAxisDriver(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return drv.(*AxisDriver)
+}
+
+func axisNotify(topic string, source, data map[string]interface{}) string {
+	msg := map[string]interface{}{
+		"apiVersion": "1.0",
+		"method":     "events:notify",
+		"params": map[string]interface{}{
+			"notification": map[string]interface{}{
+				"topic":     topic,
+				"timestamp": 1709294400000,
+				"message":   map[string]interface{}{"source": source, "key": map[string]interface{}{}, "data": data},
+			},
+		},
+	}
+	b, _ := json.Marshal(msg)
+	return string(b)
+}
+
+func TestAxisParseNotificationTopics(t *testing.T) {
+	d := newTestAxis(t, core.CameraInfo{DeviceID: "cam1", IP: "10.0.0.7"})
+	analytics := []string{"MotionDetection", "VMD", "FaceDetection"}
+
+	cases := []struct {
+		name    string
+		raw     string
+		want    string // "" = sem evento
+		channel int
+	}{
+		{"motion", axisNotify("tns1:VideoAnalytics/tnsaxis:MotionDetection", map[string]interface{}{"window": "1"}, map[string]interface{}{"motion": "1"}), "MotionDetection", 1},
+		{"VMD com perfil", axisNotify("tnsaxis:CameraApplicationPlatform/VMD/Camera1Profile1", map[string]interface{}{"channel": "1"}, map[string]interface{}{"active": "1"}), "VMD", 2},
+		{"face", axisNotify("tns1:RuleEngine/FaceDetector", map[string]interface{}{"channel": 0}, map[string]interface{}{"State": true}), "FaceDetection", 1},
+		{"fim do VMD", axisNotify("tnsaxis:CameraApplicationPlatform/VMD/Camera1Profile1", nil, map[string]interface{}{"active": "0"}), "", 0},
+		{"face encerrada", axisNotify("tns1:RuleEngine/FaceDetector", nil, map[string]interface{}{"State": false}), "", 0},
+		{"fora do filtro", axisNotify("tnsaxis:CameraApplicationPlatform/FenceGuard/Camera1Profile1", nil, map[string]interface{}{"active": "1"}), "", 0},
+	}
+	for _, tc := range cases {
+		var msg axisMessage
+		if err := json.Unmarshal([]byte(tc.raw), &msg); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		evt := d.parseNotification(msg, analytics)
+		if tc.want == "" {
+			if evt != nil {
+				t.Errorf("%s: evento %+v, esperava nil", tc.name, evt)
+			}
+			continue
+		}
+		if evt == nil {
+			t.Errorf("%s: sem evento, esperava %s", tc.name, tc.want)
+			continue
+		}
+		if evt.AnalyticType != tc.want || evt.EventState != core.EventStateActive || evt.DeviceID != "cam1" {
+			t.Errorf("%s: evento %+v", tc.name, evt)
+		}
+		if evt.Meta["channelID"] != tc.channel || evt.Meta["camera_timestamp"] != int64(1709294400000) {
+			t.Errorf("%s: Meta = %v, esperava channelID %d", tc.name, evt.Meta, tc.channel)
+		}
+	}
+}
+
+func TestAxisAnalyticsAndSnapshotURL(t *testing.T) {
+	sel := axisAnalytics([]string{"vmd", "AXIS/Face", "faceDetection"})
+	if strings.Join(sel.Selected, ",") != "VMD,FaceDetection" || len(sel.Unsupported) != 1 {
+		t.Fatalf("seleção = %+v", sel)
+	}
+
+	d := newTestAxis(t, core.CameraInfo{IP: "10.0.0.7", Port: 8443, UseTLS: true})
+	if got := d.snapshotURL(3); got != "https://10.0.0.7:8443/axis-cgi/jpg/image.cgi?camera=3" {
+		t.Fatalf("snapshotURL(3) = %s", got)
+	}
+	if got := d.snapshotURL(0); !strings.HasSuffix(got, "camera=1") {
+		t.Fatalf("snapshotURL(0) = %s, esperava o canal padrão", got)
+	}
+}
+
+func TestAxisEventStreamAgainstServer(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		configure map[string]interface{}
+		snapshots []string
+		wsAuth    []string
+	)
+	upgrader := websocket.Upgrader{}
+	info := cameraServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/axis-cgi/jpg/image.cgi":
+			mu.Lock()
+			snapshots = append(snapshots, r.URL.RawQuery)
+			mu.Unlock()
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write([]byte("jpeg"))
+		case "/vapix/ws-data-stream":
+			auth := r.Header.Get("Authorization")
+			mu.Lock()
+			wsAuth = append(wsAuth, auth)
+			mu.Unlock()
+			if !strings.HasPrefix(auth, "Digest ") || !strings.Contains(auth, `username="root"`) {
+				w.Header().Set("WWW-Authenticate", `Digest realm="AXIS", qop="auth", nonce="n1"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer conn.Close()
+			var req map[string]interface{}
+			if err := conn.ReadJSON(&req); err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			configure = req
+			mu.Unlock()
+			for _, msg := range []string{
+				`{"apiVersion":"1.0","method":"events:configure"}`,
+				`isto não é json`,
+				axisNotify("tnsaxis:CameraApplicationPlatform/VMD/Camera1Profile1", nil, map[string]interface{}{"active": "0"}),
+				axisNotify("tnsaxis:CameraApplicationPlatform/VMD/Camera1Profile1", map[string]interface{}{"channel": "1"}, map[string]interface{}{"active": "1"}),
+			} {
+				conn.WriteMessage(websocket.TextMessage, []byte(msg))
+			}
+			conn.ReadMessage() // segura até o driver fechar
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	info.Username, info.Password = "root", "pass"
+	info.Analytics = []string{"VMD"}
+	d := newTestAxis(t, info)
+
+	var statuses []ConnectionState
+	d.SetStatusHandler(func(u StatusUpdate) {
+		mu.Lock()
+		statuses = append(statuses, u.State)
+		mu.Unlock()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan core.AnalyticEvent, 2)
+	done := make(chan error, 1)
+	go func() { done <- d.runOnce(ctx, events) }()
+
+	var evt core.AnalyticEvent
+	select {
+	case evt = <-events:
+	case <-time.After(3 * time.Second):
+		t.Fatal("nenhum evento do ws-data-stream")
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("runOnce = %v", err)
+	}
+
+	if evt.AnalyticType != "VMD" || evt.Meta["channelID"] != 2 {
+		t.Fatalf("evento = %+v", evt)
+	}
+	if img, _ := base64.StdEncoding.DecodeString(evt.SnapshotB64); string(img) != "jpeg" {
+		t.Fatalf("snapshot não anexado: %q", evt.SnapshotB64)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(wsAuth) != 2 || wsAuth[0] != "" {
+		t.Fatalf("handshake = %v, esperava 401 e depois Digest", wsAuth)
+	}
+	if len(snapshots) != 1 || snapshots[0] != "camera=2" {
+		t.Fatalf("snapshots = %v, esperava camera=2 (channel 1, 0-based)", snapshots)
+	}
+	raw, _ := json.Marshal(configure)
+	if !strings.Contains(string(raw), `"method":"events:configure"`) ||
+		!strings.Contains(string(raw), `"topicFilter":"tnsaxis:CameraApplicationPlatform/VMD//."`) {
+		t.Fatalf("events:configure = %s", raw)
+	}
+	if len(statuses) < 2 || statuses[0] != ConnectionStateConnecting || statuses[1] != ConnectionStateOnline {
+		t.Fatalf("status = %v", statuses)
+	}
+}
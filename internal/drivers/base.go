@@ -3,6 +3,8 @@ package drivers
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/sua-org/cam-bus/internal/core"
 )
@@ -27,6 +29,8 @@ const (
 type StatusUpdate struct {
 	State  ConnectionState
 	Reason string
+	// Reconnect indica que o driver está iniciando uma nova tentativa após falha.
+	Reconnect bool
 }
 
 // StatusAwareDriver permite que o supervisor receba atualizações do driver em tempo real.
@@ -39,6 +43,13 @@ type AnalyticsReporter interface {
 	ActiveAnalytics() []string
 }
 
+// LatencyReporter expõe o RTT HTTP observado nas requisições à câmera.
+// LastRTT é a última amostra; AverageRTT é a média móvel.
+type LatencyReporter interface {
+	LastRTT() time.Duration
+	AverageRTT() time.Duration
+}
+
 type DriverFactory func(info core.CameraInfo) (CameraDriver, error)
 
 // registry: fabricante:model -> factory
@@ -50,6 +61,15 @@ func RegisterDriver(manufacturer, model string, f DriverFactory) {
 }
 
 func GetDriver(info core.CameraInfo) (CameraDriver, error) {
+	// event_transport=rtsp troca o canal HTTP do fabricante pela trilha de
+	// metadata ONVIF do RTSP, qualquer que seja o fabricante
+	if strings.EqualFold(strings.TrimSpace(info.EventTransport), EventTransportRTSP) {
+		return NewRTSPMetadataDriver(info)
+	}
+	// event_transport=poll consulta snapshot/motion periodicamente (câmeras sem push)
+	if strings.EqualFold(strings.TrimSpace(info.EventTransport), EventTransportPoll) {
+		return NewPollDriver(info)
+	}
 	if f, ok := registry[keyFor(info)]; ok {
 		return f(info)
 	}
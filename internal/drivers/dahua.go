@@ -2,72 +2,77 @@
 package drivers
 
 import (
-	"bytes"
 	"context"
-	"crypto/tls"
-	"encoding/base64"
 	"fmt"
 	"io"
 	"log"
 	"mime"
 	"mime/multipart"
 	"net/http"
-	"net/url"
 	"strings"
 	"time"
 
 	"github.com/sua-org/cam-bus/internal/core"
-	"github.com/sua-org/cam-bus/internal/storage"
+	"github.com/sua-org/cam-bus/internal/envconf"
+	"github.com/sua-org/cam-bus/internal/eventid"
+	"github.com/sua-org/cam-bus/internal/logthrottle"
+	"github.com/sua-org/cam-bus/internal/netproxy"
+	"github.com/sua-org/cam-bus/internal/tracing"
 )
 
 type DahuaDriver struct {
-	info          core.CameraInfo
-	client        *http.Client
-	statusHandler func(StatusUpdate)
+	httpCamera
+	rawMeta      rawMetaPolicy
+	emitStop     bool              // DAHUA_EMIT_STOP_EVENTS: action=Stop vira evento inactive
+	heartbeat    time.Duration     // heartbeat pedido no attach (DRIVER_SUBSCRIBE_HEARTBEAT)
+	backoff      *reconnectBackoff // espera entre reconexões (CAMERA_RECONNECT_*)
+	rtspFallback bool              // SNAPSHOT_RTSP_FALLBACK: frame do RTSPURL se o snapshot.cgi falhar
+	fields       FieldMap          // chaves de code/action/index (DRIVER_FIELD_MAP)
 }
 
 func NewDahuaDriver(info core.CameraInfo) (CameraDriver, error) {
 	var httpClient *http.Client
 
 	if info.UseTLS {
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true, // rede interna, cert inválido
-			},
+		tlsCfg, err := cameraTLSConfig(info)
+		if err != nil {
+			return nil, err
 		}
+		tr := netproxy.Transport(tlsCfg)
 		httpClient = &http.Client{
 			Timeout:   0,
 			Transport: tr,
 		}
-		log.Printf("[dahua] TLS inseguro habilitado para %s (%s)", info.Name, info.IP)
-	} else {
-		httpClient = &http.Client{
-			Timeout: 0,
+		if info.CertFingerprint != "" {
+			log.Printf("[dahua] TLS com certificado fixado habilitado para %s (%s)", info.Name, info.IP)
+		} else {
+			log.Printf("[dahua] TLS inseguro habilitado para %s (%s)", info.Name, info.IP)
 		}
+	} else {
+		httpClient = netproxy.Client(0)
+	}
+
+	session, err := newSessionAuth(info)
+	if err != nil {
+		return nil, err
 	}
 
 	return &DahuaDriver{
-		info:   info,
-		client: httpClient,
+		httpCamera:   httpCamera{info: info, client: httpClient, session: session},
+		rawMeta:      rawMetaPolicyFromEnv(),
+		emitStop:     dahuaEmitStopEvents(),
+		heartbeat:    subscribeHeartbeat(info, defaultDahuaHeartbeat),
+		backoff:      newReconnectBackoff(),
+		rtspFallback: rtspSnapshotFallback(),
+		fields:       fieldMapFor(info),
 	}, nil
 }
 
-// SetStatusHandler registra callback para mudanças de status de conexão.
-func (d *DahuaDriver) SetStatusHandler(fn func(StatusUpdate)) {
-	d.statusHandler = fn
-}
-
 // ActiveAnalytics retorna a lista efetiva de analytics assinados para a câmera.
 func (d *DahuaDriver) ActiveAnalytics() []string {
 	return d.selectedEventCodes()
 }
 
-func (d *DahuaDriver) notifyStatus(update StatusUpdate) {
-	if d.statusHandler != nil {
-		d.statusHandler(update)
-	}
-}
-
 func init() {
 	// fabricante "Dahua", modelo "any"
 	RegisterDriver("dahua", "any", func(info core.CameraInfo) (CameraDriver, error) {
@@ -81,68 +86,61 @@ func init() {
 // Regras:
 // - Se info.Analytics tiver "ALL" ou "*" => usa todos core.DahuaEventTypes.
 // - Senão, pega só os que existem em core.DahuaEventTypeSet.
-// - Se nada válido => fallback em ["FaceDetection"] (comportamento atual).
+// - Se nada válido => fallback DAHUA_FALLBACK_ANALYTICS (default ["FaceDetection"]).
+// - Fallback vazio => nenhum código, o driver reporta a configuração inválida.
 func (d *DahuaDriver) selectedEventCodes() []string {
-	var selected []string
-	allRequested := false
-
-	for _, a := range d.info.Analytics {
-		name := strings.TrimSpace(a)
-		if name == "" {
-			continue
-		}
+	sel := dahuaAnalytics(d.info.Analytics)
+	for _, name := range sel.Unsupported {
+		log.Printf(
+			"[dahua] camera %s: analytics '%s' não é suportado, ignorando",
+			d.info.DeviceID, name,
+		)
+	}
 
-		if strings.EqualFold(name, "all") || name == "*" {
-			allRequested = true
-			break
-		}
+	if sel.All {
+		log.Printf("[dahua] camera %s: usando TODOS os DahuaEventTypes (ALL/* no /info)",
+			d.info.DeviceID)
+		return sel.Selected
+	}
 
-		key := strings.ToLower(name)
-		if _, ok := core.DahuaEventTypeSet[key]; ok {
-			selected = append(selected, name)
+	selected := sel.Selected
+	if sel.Fallback {
+		if len(selected) == 0 {
+			log.Printf(
+				"[dahua] camera %s: nenhum analytics válido no /info e fallback vazio, nada será assinado",
+				d.info.DeviceID,
+			)
 		} else {
 			log.Printf(
-				"[dahua] camera %s: analytics '%s' não é suportado, ignorando",
-				d.info.DeviceID, name,
+				"[dahua] camera %s: nenhum analytics válido no /info, usando fallback %s",
+				d.info.DeviceID, strings.Join(selected, ","),
 			)
 		}
 	}
 
-	if allRequested {
-		log.Printf("[dahua] camera %s: usando TODOS os DahuaEventTypes (ALL/* no /info)",
-			d.info.DeviceID)
-		return core.DahuaEventTypes
-	}
+	return selected
+}
 
-	if len(selected) == 0 {
-		log.Printf(
-			"[dahua] camera %s: nenhum analytics válido no /info, usando fallback FaceDetection",
-			d.info.DeviceID,
-		)
-		return []string{"FaceDetection"}
+// dahuaAnalytics são as regras de selectedEventCodes.
+func dahuaAnalytics(analytics []string) AnalyticsSelection {
+	sel := filterAnalytics(analytics, inSet(core.DahuaEventTypeSet), core.DahuaEventTypes)
+	if len(sel.Selected) == 0 {
+		sel.Fallback, sel.FallbackEnv = true, "DAHUA_FALLBACK_ANALYTICS"
+		sel.Selected = fallbackAnalytics(sel.FallbackEnv, []string{"FaceDetection"}, core.DahuaEventTypeSet)
 	}
-
-	return selected
+	return sel
 }
 
 func (d *DahuaDriver) Run(ctx context.Context, events chan<- core.AnalyticEvent) error {
 	log.Printf("[dahua] starting driver for %s (%s)", d.info.Name, d.info.IP)
 
-	for {
-		if err := d.runOnce(ctx, events); err != nil {
-			if ctx.Err() != nil {
-				return nil
-			}
-			log.Printf("[dahua] error for %s: %v, retrying in 5s", d.info.Name, err)
-			select {
-			case <-time.After(5 * time.Second):
-			case <-ctx.Done():
-				return nil
-			}
-		} else {
-			return nil
-		}
+	if len(d.selectedEventCodes()) == 0 {
+		return waitMisconfigured(ctx, d.notifyStatus, "nenhum analytics válido no /info (fallback DAHUA_FALLBACK_ANALYTICS vazio)")
 	}
+
+	return runReconnecting(ctx, d.backoff, "dahua", d.info, d.notifyStatus, func(ctx context.Context) error {
+		return d.runOnce(ctx, events)
+	})
 }
 
 func (d *DahuaDriver) runOnce(ctx context.Context, events chan<- core.AnalyticEvent) error {
@@ -172,10 +170,11 @@ func (d *DahuaDriver) runOnce(ctx context.Context, events chan<- core.AnalyticEv
 
 	// Stream de eventos Dahua: agora com múltiplos códigos.
 	evtURL := fmt.Sprintf(
-		"%s://%s/cgi-bin/eventManager.cgi?action=attach&codes=[%s]&heartbeat=5",
+		"%s://%s/cgi-bin/eventManager.cgi?action=attach&codes=[%s]&heartbeat=%d",
 		scheme,
 		host,
 		codesStr,
+		heartbeatSeconds(d.heartbeat),
 	)
 
 	resp, err := d.doDigest(ctx, http.MethodGet, evtURL, nil, "")
@@ -214,6 +213,8 @@ func (d *DahuaDriver) runOnce(ctx context.Context, events chan<- core.AnalyticEv
 		Reason: fmt.Sprintf("subscribed to [%s]", codesStr),
 	})
 
+	// sem nenhum byte (nem heartbeat) por heartbeat×DRIVER_WATCHDOG_FACTOR, reconecta
+	resp.Body = withWatchdog(resp.Body, watchdogTimeout(d.heartbeat))
 	mr := multipart.NewReader(resp.Body, boundary)
 
 	for {
@@ -230,38 +231,33 @@ func (d *DahuaDriver) runOnce(ctx context.Context, events chan<- core.AnalyticEv
 		}
 
 		pCT := part.Header.Get("Content-Type")
-		if pCT == "" || strings.HasPrefix(pCT, "text/plain") {
+		if pCT == "" || strings.HasPrefix(pCT, "text/plain") || strings.HasPrefix(pCT, "application/json") {
 			data, err := io.ReadAll(part)
 			_ = part.Close()
 			if err != nil {
-				log.Printf("[dahua] error reading text part: %v", err)
+				cameraLog(d.info).Warnf("[dahua] error reading text part: %v", err)
 				continue
 			}
 
 			evt, snapshotBytes, snapshotCT, err := d.parseEventAndSnapshot(ctx, data, allowedCodes)
 			if err != nil {
-				log.Printf("[dahua] parseEvent error: %v; raw=%s", err, string(data))
+				cameraLog(d.info).Warnf("[dahua] parseEvent error: %v; raw=%s", err, string(data))
 				continue
 			}
 			if evt == nil {
-				// evento ignorado (código não permitido, action != Start, etc.)
+				// evento ignorado (código não permitido, action != Start/Stop, etc.)
 				continue
 			}
 
-			// Se conseguimos snapshot, salva no MinIO + base64
-			if len(snapshotBytes) > 0 {
-				if storage.DefaultStore != nil {
-					ctxUp, cancelUp := context.WithTimeout(ctx, 5*time.Second)
-					url, err := storage.DefaultStore.SaveSnapshot(ctxUp, d.buildSnapshotKey(evt), snapshotBytes, snapshotCT)
-					cancelUp()
-					if err != nil {
-						log.Printf("[dahua] erro ao salvar snapshot no MinIO: %v", err)
-					} else {
-						evt.SnapshotURL = url
-					}
-				}
-				evt.SnapshotB64 = base64.StdEncoding.EncodeToString(snapshotBytes)
-			}
+			evtCtx, span := tracing.Start(ctx, "driver.receive")
+			span.SetAttr("camera.id", d.info.DeviceID)
+			span.SetAttr("analytic.type", evt.AnalyticType)
+			span.SetAttr("event.id", evt.EventID)
+
+			// Se conseguimos snapshot (válido), salva no MinIO + base64
+			d.attachSnapshot(evtCtx, "dahua", evt, snapshotBytes, snapshotCT)
+			evt.Meta = tracing.Inject(evtCtx, evt.Meta)
+			span.End()
 
 			select {
 			case events <- *evt:
@@ -292,10 +288,24 @@ func (d *DahuaDriver) parseEventAndSnapshot(
 ) (*core.AnalyticEvent, []byte, string, error) {
 	body := string(data)
 
-	// Formato típico: "Code=FaceDetection;action=Start;index=0;..."
-	code := extractKV(body, "Code")
-	if code == "" {
-		code = extractKV(body, "code")
+	var (
+		code, action string
+		extra        map[string]interface{}
+	)
+	if trimmed := strings.TrimSpace(body); strings.HasPrefix(trimmed, "{") {
+		// Firmwares mais novos: {"Code":"FaceDetection","Action":"Start","Index":0,"Data":{...}}
+		var err error
+		code, action, extra, err = parseDahuaJSONEvent([]byte(trimmed), d.fields)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("json event: %w", err)
+		}
+	} else {
+		// Formato típico: "Code=FaceDetection;action=Start;index=0;..."
+		code = firstKV(body, d.fields.Code)
+		action = firstKV(body, d.fields.Action)
+		if idx := firstKV(body, d.fields.Index); idx != "" {
+			extra = map[string]interface{}{"index": idx}
+		}
 	}
 	if code == "" {
 		// não conseguimos identificar código -> ignora
@@ -308,26 +318,37 @@ func (d *DahuaDriver) parseEventAndSnapshot(
 		return nil, nil, "", nil
 	}
 
-	// Só processamos action=Start (mantém comportamento original e evita flood)
-	action := extractKV(body, "action")
-	if action != "" && !strings.EqualFold(action, "Start") {
+	// Só processamos action=Start (mantém comportamento original e evita flood);
+	// com DAHUA_EMIT_STOP_EVENTS, action=Stop vira o fim do evento.
+	stop := strings.EqualFold(action, "Stop")
+	if action != "" && !strings.EqualFold(action, "Start") && !(stop && d.emitStop) {
 		return nil, nil, "", nil
 	}
 
 	ts := time.Now().UTC()
 
 	meta := map[string]interface{}{
-		"raw":    body,
 		"code":   code,
 		"action": action,
 	}
+	d.rawMeta.apply(meta, body)
+	for k, v := range extra {
+		meta[k] = v
+	}
+	// index do Dahua é 0-based; snapshot.cgi usa channel 1-based
+	channel := defaultSnapshotChannel
+	if idx, ok := toChannel(meta["index"]); ok && idx >= 0 {
+		channel = idx + 1
+	}
+	meta["channelID"] = channel
 
 	evt := &core.AnalyticEvent{
 		Timestamp:    ts,
-		EventID:      fmt.Sprintf("dahua-%d", ts.UnixNano()),
+		EventID:      eventid.Resolve(getString(meta, "EventID", "UID"), "dahua", ts),
 		CameraIP:     d.info.IP,
 		CameraName:   d.info.Name,
 		AnalyticType: code, // ex: "FaceDetection", "CrossLineDetection", etc.
+		EventState:   core.NormalizeEventState(action),
 		Meta:         meta,
 
 		Tenant:     d.info.Tenant,
@@ -337,170 +358,102 @@ func (d *DahuaDriver) parseEventAndSnapshot(
 		DeviceID:   d.info.DeviceID,
 	}
 
-	// tenta pegar snapshot imediato (mesma rota já usada e validada)
-	img, ctype, err := d.fetchSnapshot(ctx)
-	if err != nil {
-		log.Printf("[dahua] erro ao buscar snapshot: %v", err)
-		// evento ainda é válido, só que sem imagem
-		return evt, nil, "", nil
+	if isAudioAnalytic(code) {
+		if _, ok := meta["data"]; !ok {
+			if data := dahuaKVData(body); data != nil {
+				meta["data"] = data
+			}
+		}
+		applyAudioAnalytic(evt, meta)
 	}
 
-	return evt, img, ctype, nil
-}
-
-// fetchSnapshot baixa um snapshot único da câmera Dahua.
-// Em muitos modelos a rota é /cgi-bin/snapshot.cgi?channel=1.
-// Se o teu for diferente, só ajusta essa URL.
-func (d *DahuaDriver) fetchSnapshot(ctx context.Context) ([]byte, string, error) {
-	scheme := "http"
-	if d.info.UseTLS {
-		scheme = "https"
+	if d.emitStop {
+		// mesmo contrato do Meta.eventState da Hikvision (active/inactive)
+		meta["eventState"] = evt.EventState
 	}
-	host := d.info.IP
-	if d.info.Port != 0 {
-		host = fmt.Sprintf("%s:%d", host, d.info.Port)
+	if stop {
+		return evt, nil, "", nil // fim do evento: sem snapshot
 	}
 
-	snapURL := fmt.Sprintf("%s://%s/cgi-bin/snapshot.cgi?channel=1", scheme, host)
-
-	resp, err := d.doDigest(ctx, http.MethodGet, snapURL, nil, "")
+	// a câmera pode mandar a URL da imagem no próprio evento; sem ela (ou se
+	// falhar) usa o snapshot imediato do canal (mesma rota já usada e validada)
+	img, ctype, err := d.fetchEventImage(ctx, meta)
 	if err != nil {
-		return nil, "", err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		b, _ := io.ReadAll(resp.Body)
-		return nil, "", fmt.Errorf("snapshot status %d: %s", resp.StatusCode, string(b))
+		logthrottle.Printf("dahua:image_url:"+d.info.IP, "[dahua] erro ao buscar imagem do evento: %v", err)
 	}
-
-	ctype := resp.Header.Get("Content-Type")
-	if ctype == "" {
-		ctype = "image/jpeg"
+	if len(img) > 0 {
+		return evt, img, ctype, nil
 	}
-
-	img, err := io.ReadAll(resp.Body)
+	img, ctype, err = d.fetchSnapshot(ctx, channel)
 	if err != nil {
-		return nil, "", err
-	}
-	if len(img) == 0 {
-		return nil, "", fmt.Errorf("snapshot vazio")
+		eventLog(d.info, evt).Warnf("[dahua] erro ao buscar snapshot: %v", err)
+		if d.rtspFallback {
+			// último recurso: um frame do RTSPURL (SNAPSHOT_RTSP_FALLBACK)
+			if img, ctype, err = fetchRTSPSnapshot(ctx, d.info); err == nil {
+				return evt, img, ctype, nil
+			}
+			logthrottle.Printf("dahua:rtsp-snapshot:"+d.info.IP, "[dahua] erro ao capturar snapshot pelo RTSP: %v", err)
+		}
+		// evento ainda é válido, só que sem imagem
+		return evt, nil, "", nil
 	}
 
-	return img, ctype, nil
+	return evt, img, ctype, nil
 }
 
-// buildSnapshotKey gera a chave para salvar snapshots Dahua no MinIO.
-func (d *DahuaDriver) buildSnapshotKey(evt *core.AnalyticEvent) string {
-	ts := evt.Timestamp
-	if ts.IsZero() {
-		ts = time.Now().UTC()
-	}
-
-	tenant := safePath(d.info.Tenant, "default")
-	building := safePath(d.info.Building, "building")
-	floor := safePath(d.info.Floor, "floor")
-	dtype := safePath(d.info.DeviceType, "device")
-	did := safePath(d.info.DeviceID, "id")
-	analytic := safePath(evt.AnalyticType, "analytic")
-
-	return fmt.Sprintf(
-		"%s/%s/%s/%s/%s/%s/%04d/%02d/%02d/%s_%d.jpg",
-		tenant, building, floor, dtype, did, analytic,
-		ts.Year(), ts.Month(), ts.Day(),
-		evt.EventID, ts.UnixNano(),
-	)
+// dahuaEmitStopEvents lê DAHUA_EMIT_STOP_EVENTS (default: false, só Start,
+// para não dobrar o volume de eventos de quem já usa).
+func dahuaEmitStopEvents() bool {
+	return envconf.Bool("DAHUA_EMIT_STOP_EVENTS", false)
 }
 
-// doDigest é igual ao da Hikvision, reaproveitando parseDigestAuthHeader/md5Hex/randomHex
-// já definidos no pacote drivers (em hikvision.go).
-func (d *DahuaDriver) doDigest(
-	ctx context.Context,
-	method, rawURL string,
-	body io.Reader,
-	contentType string,
-) (*http.Response, error) {
-	// 1ª tentativa sem Authorization
-	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
-	if err != nil {
-		return nil, err
+// fetchSnapshot baixa um snapshot único da câmera Dahua, do canal/lente do
+// evento (ou snapshot_channel do /info). Em muitos modelos a rota é
+// /cgi-bin/snapshot.cgi?channel=N[&type=M]. Se o teu for diferente, só ajusta essa URL.
+func (d *DahuaDriver) fetchSnapshot(ctx context.Context, channel int) ([]byte, string, error) {
+	if d.info.SnapshotChannel > 0 {
+		channel = d.info.SnapshotChannel
 	}
-	if contentType != "" {
-		req.Header.Set("Content-Type", contentType)
-	}
-	req.Header.Set("Connection", "keep-alive")
+	return fetchImage(ctx, d.doDigest, dahuaSnapshotURL(d.scheme(), d.hostPort(), channel, d.info.SnapshotType))
+}
 
-	resp, err := d.client.Do(req)
-	if err != nil {
-		return nil, err
+// fetchEventImage baixa a imagem cuja URL veio no próprio evento (Data.pictureURL etc.).
+func (d *DahuaDriver) fetchEventImage(ctx context.Context, meta map[string]interface{}) ([]byte, string, error) {
+	raw := eventImageURL(meta)
+	if raw == "" {
+		return nil, "", nil
 	}
-	if resp.StatusCode != http.StatusUnauthorized {
-		return resp, nil
-	}
-
-	// 401 -> Digest
-	authHeader := resp.Header.Get("WWW-Authenticate")
-	_ = resp.Body.Close()
-	digest, err := parseDigestAuthHeader(authHeader)
+	abs, err := resolveEventImageURL(d.scheme()+"://"+d.hostPort(), raw)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
+	meta[metaEventImageURL] = abs
+	return fetchImage(ctx, d.doDigest, abs)
+}
 
-	username := d.info.Username
-	password := d.info.Password
-
-	u, err := url.Parse(rawURL)
-	if err != nil {
-		return nil, err
+func (d *DahuaDriver) scheme() string {
+	if d.info.UseTLS {
+		return "https"
 	}
+	return "http"
+}
 
-	// Recria body se necessário
-	var bodyBytes []byte
-	if body != nil {
-		if rb, ok := body.(*bytes.Reader); ok {
-			rb.Seek(0, io.SeekStart)
-			bodyBytes, _ = io.ReadAll(rb)
-		} else if b, ok := body.(*bytes.Buffer); ok {
-			bodyBytes = b.Bytes()
-		}
+func (d *DahuaDriver) hostPort() string {
+	if d.info.Port != 0 {
+		return fmt.Sprintf("%s:%d", d.info.IP, d.info.Port)
 	}
+	return d.info.IP
+}
 
-	var body2 io.Reader
-	if bodyBytes != nil {
-		body2 = bytes.NewReader(bodyBytes)
-	}
-
-	nc := "00000001"
-	cnonce := randomHex(16)
-	ha1 := md5Hex(fmt.Sprintf("%s:%s:%s", username, digest.Realm, password))
-	ha2 := md5Hex(fmt.Sprintf("%s:%s", method, u.RequestURI()))
-	response := md5Hex(fmt.Sprintf("%s:%s:%s:%s:%s:%s",
-		ha1, digest.Nonce, nc, cnonce, digest.Qop, ha2,
-	))
-
-	authValue := fmt.Sprintf(
-		`Digest username="%s", realm="%s", nonce="%s", uri="%s", algorithm=MD5, response="%s", qop=%s, nc=%s, cnonce="%s"`,
-		username,
-		digest.Realm,
-		digest.Nonce,
-		u.RequestURI(),
-		response,
-		digest.Qop,
-		nc,
-		cnonce,
-	)
-
-	req2, err := http.NewRequestWithContext(ctx, method, rawURL, body2)
-	if err != nil {
-		return nil, err
+func dahuaSnapshotURL(scheme, host string, channel, snapType int) string {
+	if channel <= 0 {
+		channel = defaultSnapshotChannel
 	}
-	if contentType != "" {
-		req2.Header.Set("Content-Type", contentType)
+	u := fmt.Sprintf("%s://%s/cgi-bin/snapshot.cgi?channel=%d", scheme, host, channel)
+	if snapType > 0 {
+		u += fmt.Sprintf("&type=%d", snapType)
 	}
-	req2.Header.Set("Connection", "keep-alive")
-	req2.Header.Set("Authorization", authValue)
-
-	return d.client.Do(req2)
+	return u
 }
 
 // extractKV pega "Key=Value" de um texto tosco do Dahua.
@@ -518,3 +471,44 @@ func extractKV(body, key string) string {
 	}
 	return strings.TrimSpace(rest)
 }
+
+// firstKV devolve o primeiro extractKV não vazio entre as chaves.
+func firstKV(body string, keys []string) string {
+	for _, k := range keys {
+		if v := extractKV(body, k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// parseDahuaJSONEvent extrai code/action de um evento JSON do eventManager,
+// nas chaves do FieldMap da câmera. Index e Data (quando presentes) vão para o
+// Meta, como no formato texto.
+func parseDahuaJSONEvent(data []byte, fields FieldMap) (string, string, map[string]interface{}, error) {
+	var raw map[string]interface{}
+	if err := decodeJSON(data, &raw); err != nil {
+		return "", "", nil, err
+	}
+
+	field := func(keys ...string) interface{} {
+		for _, k := range keys {
+			if v, ok := raw[k]; ok {
+				return v
+			}
+		}
+		return nil
+	}
+
+	code, _ := field(fields.Code...).(string)
+	action, _ := field(fields.Action...).(string)
+
+	extra := make(map[string]interface{})
+	if v := field(fields.Index...); v != nil {
+		extra["index"] = v
+	}
+	if v := field("Data", "data"); v != nil {
+		extra["data"] = v
+	}
+	return strings.TrimSpace(code), strings.TrimSpace(action), extra, nil
+}
@@ -0,0 +1,62 @@
+// internal/drivers/dahua_raw.go
+package drivers
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const defaultDahuaRawMetaMax = 1024
+
+// rawMetaPolicy controla Meta["raw"] nos eventos Dahua
+// (DAHUA_RAW_META=full|truncated|off, default truncated).
+type rawMetaPolicy struct {
+	mode string
+	max  int // DAHUA_RAW_META_MAX, em bytes, para o modo truncated
+}
+
+func rawMetaPolicyFromEnv() rawMetaPolicy {
+	p := rawMetaPolicy{mode: "truncated", max: defaultDahuaRawMetaMax}
+	switch mode := strings.ToLower(strings.TrimSpace(os.Getenv("DAHUA_RAW_META"))); mode {
+	case "":
+	case "full", "truncated", "off":
+		p.mode = mode
+	default:
+		log.Printf("[dahua] DAHUA_RAW_META inválido (%q), usando truncated", mode)
+	}
+	if raw := strings.TrimSpace(os.Getenv("DAHUA_RAW_META_MAX")); raw != "" {
+		if v, err := strconv.Atoi(raw); err == nil && v > 0 {
+			p.max = v
+		} else {
+			log.Printf("[dahua] DAHUA_RAW_META_MAX inválido (%q), usando %d", raw, p.max)
+		}
+	}
+	return p
+}
+
+// apply grava o corpo do evento em meta conforme a política. No modo truncated
+// corta em p.max bytes (sem quebrar UTF-8) e marca raw_truncated/raw_size.
+func (p rawMetaPolicy) apply(meta map[string]interface{}, body string) {
+	switch p.mode {
+	case "off":
+		return
+	case "full":
+		meta["raw"] = body
+		return
+	}
+
+	if len(body) <= p.max {
+		meta["raw"] = body
+		return
+	}
+	cut := p.max
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	meta["raw"] = body[:cut]
+	meta["raw_truncated"] = true
+	meta["raw_size"] = len(body)
+}
@@ -0,0 +1,56 @@
+package drivers
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRawMetaPolicyFromEnv(t *testing.T) {
+	t.Setenv("DAHUA_RAW_META", "")
+	t.Setenv("DAHUA_RAW_META_MAX", "")
+	if p := rawMetaPolicyFromEnv(); p.mode != "truncated" || p.max != defaultDahuaRawMetaMax {
+		t.Fatalf("default = %+v", p)
+	}
+	t.Setenv("DAHUA_RAW_META", "OFF")
+	t.Setenv("DAHUA_RAW_META_MAX", "0")
+	if p := rawMetaPolicyFromEnv(); p.mode != "off" || p.max != defaultDahuaRawMetaMax {
+		t.Fatalf("off com max inválido = %+v", p)
+	}
+	t.Setenv("DAHUA_RAW_META", "tudo")
+	if p := rawMetaPolicyFromEnv(); p.mode != "truncated" {
+		t.Fatalf("modo inválido = %+v", p)
+	}
+}
+
+func TestRawMetaPolicyApply(t *testing.T) {
+	long := strings.Repeat("x", 9) + "ção" // corte no meio de um rune
+
+	meta := map[string]interface{}{}
+	rawMetaPolicy{mode: "off"}.apply(meta, long)
+	if _, ok := meta["raw"]; ok {
+		t.Fatal("modo off não deveria gravar raw")
+	}
+
+	meta = map[string]interface{}{}
+	rawMetaPolicy{mode: "full", max: 4}.apply(meta, long)
+	if meta["raw"] != long || meta["raw_truncated"] != nil {
+		t.Fatalf("modo full = %v", meta)
+	}
+
+	meta = map[string]interface{}{}
+	rawMetaPolicy{mode: "truncated", max: 64}.apply(meta, "curto")
+	if meta["raw"] != "curto" || meta["raw_truncated"] != nil {
+		t.Fatalf("corpo dentro do limite = %v", meta)
+	}
+
+	meta = map[string]interface{}{}
+	rawMetaPolicy{mode: "truncated", max: 10}.apply(meta, long)
+	raw, _ := meta["raw"].(string)
+	if len(raw) > 10 || !utf8.ValidString(raw) || !strings.HasPrefix(long, raw) {
+		t.Fatalf("truncado = %q", raw)
+	}
+	if meta["raw_truncated"] != true || meta["raw_size"] != len(long) {
+		t.Fatalf("marcas de truncamento = %v", meta)
+	}
+}
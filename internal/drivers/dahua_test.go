@@ -0,0 +1,202 @@
+package drivers
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+// cameraServer sobe um httptest.Server e devolve o CameraInfo apontando para ele.
+func cameraServer(t *testing.T, handler http.HandlerFunc) core.CameraInfo {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	host, portStr, err := net.SplitHostPort(strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, _ := strconv.Atoi(portStr)
+	return core.CameraInfo{IP: host, Port: port, DeviceID: "cam1", Manufacturer: "dahua"}
+}
+
+// snapshotHandler responde snapshot.cgi com bytes fixos e anota a query.
+func snapshotHandler(queries *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*queries = append(*queries, r.URL.Path+"?"+r.URL.RawQuery)
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("jpeg"))
+	}
+}
+
+func newTestDahua(t *testing.T, info core.CameraInfo) *DahuaDriver {
+	t.Helper()
+	drv, err := NewDahuaDriver(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return drv.(*DahuaDriver)
+}
+
+func TestDahuaParsesTextAndJSONEvents(t *testing.T) {
+	cases := []struct {
+		name    string
+		body    string
+		channel int
+	}{
+		{"texto", "Code=FaceDetection;action=Start;index=1", 2},
+		{"json", `{"Code":"FaceDetection","Action":"Start","Index":1,"Data":{"Name":"entrada"}}`, 2},
+		{"json sem index", `{"Code":"FaceDetection","Action":"Start"}`, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var queries []string
+			d := newTestDahua(t, cameraServer(t, snapshotHandler(&queries)))
+			allowed := map[string]struct{}{"facedetection": {}}
+
+			evt, img, ct, err := d.parseEventAndSnapshot(context.Background(), []byte(tc.body), allowed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if evt == nil {
+				t.Fatal("evento não gerado")
+			}
+			if evt.AnalyticType != "FaceDetection" || evt.EventState != core.EventStateActive {
+				t.Fatalf("evento = %s/%s", evt.AnalyticType, evt.EventState)
+			}
+			if evt.Meta["channelID"] != tc.channel {
+				t.Fatalf("channelID = %v, esperava %d", evt.Meta["channelID"], tc.channel)
+			}
+			if string(img) != "jpeg" || ct != "image/jpeg" {
+				t.Fatalf("snapshot = %q (%s)", img, ct)
+			}
+			want := "/cgi-bin/snapshot.cgi?channel=" + strconv.Itoa(tc.channel)
+			if len(queries) != 1 || queries[0] != want {
+				t.Fatalf("snapshot pedido em %v, esperava %s", queries, want)
+			}
+		})
+	}
+}
+
+func TestDahuaJSONEventKeepsData(t *testing.T) {
+	var queries []string
+	d := newTestDahua(t, cameraServer(t, snapshotHandler(&queries)))
+	body := `{"Code":"CrossLineDetection","Action":"Start","Index":0,"Data":{"Direction":"LeftToRight"}}`
+
+	evt, _, _, err := d.parseEventAndSnapshot(context.Background(), []byte(body), map[string]struct{}{"crosslinedetection": {}})
+	if err != nil || evt == nil {
+		t.Fatalf("evt=%v err=%v", evt, err)
+	}
+	data, ok := evt.Meta["data"].(map[string]interface{})
+	if !ok || data["Direction"] != "LeftToRight" {
+		t.Fatalf("Meta.data = %#v", evt.Meta["data"])
+	}
+}
+
+func TestDahuaIgnoresUnwantedEvents(t *testing.T) {
+	var queries []string
+	d := newTestDahua(t, cameraServer(t, snapshotHandler(&queries)))
+	allowed := map[string]struct{}{"facedetection": {}}
+
+	for _, body := range []string{
+		"Code=VideoMotion;action=Start;index=0",              // código fora do /info
+		`{"Code":"FaceDetection","Action":"Stop","Index":0}`, // Stop sem DAHUA_EMIT_STOP_EVENTS
+		"Heartbeat", // sem Code
+	} {
+		evt, _, _, err := d.parseEventAndSnapshot(context.Background(), []byte(body), allowed)
+		if err != nil || evt != nil {
+			t.Fatalf("%q: evt=%v err=%v, esperava ignorado", body, evt, err)
+		}
+	}
+	if len(queries) != 0 {
+		t.Fatalf("eventos ignorados não devem buscar snapshot: %v", queries)
+	}
+}
+
+func TestDahuaInvalidJSONEvent(t *testing.T) {
+	var queries []string
+	d := newTestDahua(t, cameraServer(t, snapshotHandler(&queries)))
+	if _, _, _, err := d.parseEventAndSnapshot(context.Background(), []byte(`{"Code":`), nil); err == nil {
+		t.Fatal("JSON truncado deveria dar erro")
+	}
+}
+
+func TestDahuaEmitStopEvents(t *testing.T) {
+	t.Setenv("DAHUA_EMIT_STOP_EVENTS", "true")
+	var queries []string
+	d := newTestDahua(t, cameraServer(t, snapshotHandler(&queries)))
+	allowed := map[string]struct{}{"facedetection": {}, "crosslinedetection": {}}
+
+	for _, body := range []string{
+		"Code=FaceDetection;action=Stop;index=1",
+		`{"Code":"CrossLineDetection","Action":"Stop","Index":0}`,
+	} {
+		evt, img, _, err := d.parseEventAndSnapshot(context.Background(), []byte(body), allowed)
+		if err != nil || evt == nil {
+			t.Fatalf("%q: evt=%v err=%v", body, evt, err)
+		}
+		if evt.EventState != core.EventStateInactive || evt.Meta["eventState"] != core.EventStateInactive || img != nil {
+			t.Fatalf("%q: estado %q, Meta %v, %d bytes de imagem", body, evt.EventState, evt.Meta, len(img))
+		}
+	}
+	if len(queries) != 0 {
+		t.Fatalf("fim do evento não deveria buscar snapshot: %v", queries)
+	}
+
+	// Start segue ativo e com snapshot; outras actions continuam ignoradas
+	evt, img, _, err := d.parseEventAndSnapshot(context.Background(), []byte("Code=FaceDetection;action=Start;index=1"), allowed)
+	if err != nil || evt == nil || evt.EventState != core.EventStateActive || evt.Meta["eventState"] != core.EventStateActive || string(img) != "jpeg" {
+		t.Fatalf("Start: evt=%+v img=%q err=%v", evt, img, err)
+	}
+	if evt, _, _, _ := d.parseEventAndSnapshot(context.Background(), []byte("Code=FaceDetection;action=Pulse;index=1"), allowed); evt != nil {
+		t.Fatalf("action=Pulse deveria ser ignorada: %+v", evt)
+	}
+}
+
+func TestDahuaEmitStopEventsFromEnv(t *testing.T) {
+	for raw, want := range map[string]bool{"": false, "true": true, "on": true, "1": true, "false": false, "talvez": false} {
+		t.Setenv("DAHUA_EMIT_STOP_EVENTS", raw)
+		if got := dahuaEmitStopEvents(); got != want {
+			t.Errorf("DAHUA_EMIT_STOP_EVENTS=%q: %t, esperava %t", raw, got, want)
+		}
+	}
+}
+
+func TestDahuaSnapshotFromCameraInfo(t *testing.T) {
+	cases := []struct {
+		name string
+		info string // /info parcial
+		body string
+		want string
+	}{
+		{"sem config, sem index", `{}`, "Code=FaceDetection;action=Start", "/cgi-bin/snapshot.cgi?channel=1"},
+		{"canal do evento", `{}`, "Code=FaceDetection;action=Start;index=2", "/cgi-bin/snapshot.cgi?channel=3"},
+		{"snapshot_type", `{"snapshot_type": 1}`, "Code=FaceDetection;action=Start;index=0", "/cgi-bin/snapshot.cgi?channel=1&type=1"},
+		{"snapshot_channel vence o index", `{"snapshot_channel": 4}`, "Code=FaceDetection;action=Start;index=2", "/cgi-bin/snapshot.cgi?channel=4"},
+		{"canal e type fixos", `{"snapshot_channel": 2, "snapshot_type": 1}`, `{"Code":"FaceDetection","Action":"Start","Index":5}`, "/cgi-bin/snapshot.cgi?channel=2&type=1"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var queries []string
+			info := cameraServer(t, snapshotHandler(&queries))
+			if err := json.Unmarshal([]byte(tc.info), &info); err != nil {
+				t.Fatal(err)
+			}
+			d := newTestDahua(t, info)
+
+			_, img, _, err := d.parseEventAndSnapshot(context.Background(), []byte(tc.body), map[string]struct{}{"facedetection": {}})
+			if err != nil || string(img) != "jpeg" {
+				t.Fatalf("img=%q err=%v", img, err)
+			}
+			if len(queries) != 1 || queries[0] != tc.want {
+				t.Fatalf("snapshot pedido = %v, esperava %s", queries, tc.want)
+			}
+		})
+	}
+}
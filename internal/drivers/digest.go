@@ -0,0 +1,193 @@
+// internal/drivers/digest.go
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// digestRequest faz a requisição com HTTP Digest (MD5/SHA-256, qop=auth). Com
+// um desafio em cache no driver (nonces), o Authorization já vai na 1ª
+// tentativa e o nc incrementa no mesmo nonce; sem cache, a 1ª tentativa vai
+// sem Authorization. No 401 (sem cache ou nonce expirado) guarda o novo
+// WWW-Authenticate e repete. do é o executor do driver (mede RTT). Bodies
+// precisam ser *bytes.Reader ou *bytes.Buffer para poderem ser reenviados.
+func digestRequest(
+	ctx context.Context,
+	do func(*http.Request) (*http.Response, error),
+	nonces *digestNonces,
+	username, password string,
+	method, rawURL string,
+	body io.Reader,
+	contentType string,
+) (*http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	send := func(body io.Reader, authValue string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+		if err != nil {
+			return nil, err
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		req.Header.Set("Connection", "keep-alive")
+		if authValue != "" {
+			req.Header.Set("Authorization", authValue)
+		}
+		return do(req)
+	}
+
+	var authValue string
+	if challenge := nonces.challenge(); challenge != "" {
+		authValue, _ = digestAuthorization(nonces, challenge, username, password, method, u.RequestURI())
+	}
+	resp, err := send(body, authValue)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// 401: Authorization a partir do WWW-Authenticate
+	challenge := resp.Header.Get("WWW-Authenticate")
+	_ = resp.Body.Close()
+	authValue, err = digestAuthorization(nonces, challenge, username, password, method, u.RequestURI())
+	if err != nil {
+		return nil, err
+	}
+	nonces.remember(challenge)
+
+	// Precisamos recriar o body, pois já foi consumido na 1ª tentativa.
+	return send(replayBody(body), authValue)
+}
+
+// replayBody recria um body já consumido para reenviar a requisição; só
+// *bytes.Reader e *bytes.Buffer podem ser reenviados (outros viram nil).
+func replayBody(body io.Reader) io.Reader {
+	var bodyBytes []byte
+	if body != nil {
+		if rb, ok := body.(*bytes.Reader); ok {
+			rb.Seek(0, io.SeekStart)
+			bodyBytes, _ = io.ReadAll(rb)
+		} else if b, ok := body.(*bytes.Buffer); ok {
+			bodyBytes = b.Bytes()
+		}
+	}
+	if bodyBytes == nil {
+		return nil
+	}
+	return bytes.NewReader(bodyBytes)
+}
+
+// digestNonces guarda o último desafio Digest do driver e o nc/cnonce do
+// nonce do servidor: enquanto a câmera aceita o mesmo realm+nonce o nc
+// incrementa (00000001, 00000002, ...); nonce novo zera o contador e sorteia
+// outro cnonce.
+type digestNonces struct {
+	mu     sync.Mutex
+	last   string // WWW-Authenticate do último 401, para o Authorization preemptivo
+	key    string // realm + nonce
+	nc     uint32
+	cnonce string
+}
+
+// challenge devolve o último WWW-Authenticate guardado ("" = nenhum).
+func (n *digestNonces) challenge() string {
+	if n == nil {
+		return ""
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.last
+}
+
+// remember guarda o WWW-Authenticate para as próximas requisições.
+func (n *digestNonces) remember(challenge string) {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	n.last = challenge
+	n.mu.Unlock()
+}
+
+// next devolve nc e cnonce para o desafio; nil = sempre nc=00000001.
+func (n *digestNonces) next(realm, nonce string) (string, string) {
+	if n == nil {
+		return "00000001", randomHex(16)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if key := realm + "\x00" + nonce; key != n.key {
+		n.key, n.nc, n.cnonce = key, 0, randomHex(16)
+	}
+	n.nc++
+	return fmt.Sprintf("%08x", n.nc), n.cnonce
+}
+
+// digestAuthorization monta o header Authorization (Digest qop=auth) para o
+// desafio WWW-Authenticate recebido. uri é o RequestURI do pedido.
+func digestAuthorization(nonces *digestNonces, challenge, username, password, method, uri string) (string, error) {
+	digest, err := parseDigestAuthHeader(challenge)
+	if err != nil {
+		return "", err
+	}
+
+	nc, cnonce := nonces.next(digest.Realm, digest.Nonce)
+	response, err := digestResponse(digest, username, password, method, uri, nc, cnonce)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		`Digest username="%s", realm="%s", nonce="%s", uri="%s", algorithm=%s, response="%s", qop=%s, nc=%s, cnonce="%s"`,
+		username,
+		digest.Realm,
+		digest.Nonce,
+		uri,
+		digest.Algorithm,
+		response,
+		digest.Qop,
+		nc,
+		cnonce,
+	), nil
+}
+
+// digestResponse calcula o response (RFC 7616) com o hash pedido no desafio;
+// nas variantes -sess o HA1 é H(H(user:realm:pass):nonce:cnonce).
+func digestResponse(digest *digestChallenge, username, password, method, uri, nc, cnonce string) (string, error) {
+	var hash func(string) string
+	algorithm := strings.ToUpper(digest.Algorithm)
+	switch strings.TrimSuffix(algorithm, "-SESS") {
+	case "", "MD5":
+		hash = md5Hex
+	case "SHA-256":
+		hash = sha256Hex
+	default:
+		return "", fmt.Errorf("algoritmo Digest não suportado: %s", digest.Algorithm)
+	}
+
+	ha1 := hash(fmt.Sprintf("%s:%s:%s", username, digest.Realm, password))
+	if strings.HasSuffix(algorithm, "-SESS") {
+		ha1 = hash(fmt.Sprintf("%s:%s:%s", ha1, digest.Nonce, cnonce))
+	}
+	ha2 := hash(fmt.Sprintf("%s:%s", method, uri))
+	return hash(fmt.Sprintf("%s:%s:%s:%s:%s:%s",
+		ha1, digest.Nonce, nc, cnonce, digest.Qop, ha2,
+	)), nil
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
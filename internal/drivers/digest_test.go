@@ -0,0 +1,161 @@
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Vetores do RFC 7616, seção 3.9.1.
+const (
+	rfcChallengeMD5    = `Digest realm="http-auth@example.org", qop="auth, auth-int", algorithm=MD5, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`
+	rfcChallengeSHA256 = `Digest realm="http-auth@example.org", qop="auth, auth-int", algorithm=SHA-256, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`
+	rfcCnonce          = "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ"
+)
+
+func TestDigestResponseRFC7616Vectors(t *testing.T) {
+	cases := []struct {
+		challenge, want string
+	}{
+		{rfcChallengeMD5, "8ca523f5e9506fed4657c9700eebdbec"},
+		{rfcChallengeSHA256, "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1"},
+	}
+	for _, tc := range cases {
+		digest, err := parseDigestAuthHeader(tc.challenge)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := digestResponse(digest, "Mufasa", "Circle of Life", "GET", "/dir/index.html", "00000001", rfcCnonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("%s: response = %s, esperava %s", digest.Algorithm, got, tc.want)
+		}
+	}
+}
+
+func TestDigestResponseSessAndUnsupported(t *testing.T) {
+	digest := &digestChallenge{Realm: "r", Nonce: "n", Qop: "auth", Algorithm: "SHA-256-sess"}
+	sess, err := digestResponse(digest, "u", "p", "GET", "/", "00000001", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest.Algorithm = "SHA-256"
+	plain, _ := digestResponse(digest, "u", "p", "GET", "/", "00000001", "c")
+	if sess == plain || len(sess) != 64 {
+		t.Fatalf("-sess deveria mudar o HA1 (sess=%s plain=%s)", sess, plain)
+	}
+
+	digest.Algorithm = "SHA-512-256"
+	if _, err := digestResponse(digest, "u", "p", "GET", "/", "00000001", "c"); err == nil {
+		t.Fatal("algoritmo não suportado deveria falhar")
+	}
+}
+
+// digestServer exige Digest com o nonce atual e registra o nc de cada
+// requisição ("-" = sem Authorization).
+type digestServer struct {
+	mu    sync.Mutex
+	nonce string
+	seen  []string
+}
+
+func (s *digestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	auth := r.Header.Get("Authorization")
+	fields := map[string]string{}
+	for _, kv := range digestRx.FindAllStringSubmatch(auth, -1) {
+		fields[strings.ToLower(kv[1])] = kv[2] + kv[3]
+	}
+	if auth == "" {
+		s.seen = append(s.seen, "-")
+	} else {
+		s.seen = append(s.seen, fields["nc"])
+	}
+
+	challenge := &digestChallenge{Realm: "cam", Nonce: s.nonce, Qop: "auth", Algorithm: "MD5"}
+	want, _ := digestResponse(challenge, "admin", "secret", r.Method, r.URL.RequestURI(), fields["nc"], fields["cnonce"])
+	if auth == "" || fields["nonce"] != s.nonce || fields["response"] != want {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Digest realm="cam", qop="auth", nonce="%s"`, s.nonce))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *digestServer) take() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := s.seen
+	s.seen = nil
+	return out
+}
+
+func TestDigestRequestPreemptiveNonceCount(t *testing.T) {
+	ds := &digestServer{nonce: "n1"}
+	srv := httptest.NewServer(ds)
+	defer srv.Close()
+
+	var nonces digestNonces
+	get := func() int {
+		t.Helper()
+		resp, err := digestRequest(context.Background(), srv.Client().Do, &nonces, "admin", "secret", http.MethodPost, srv.URL+"/ISAPI/x?y=1", bytes.NewReader([]byte("<xml/>")), "application/xml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := get(); code != http.StatusOK {
+		t.Fatalf("1ª requisição: status %d", code)
+	}
+	if got := ds.take(); !reflect.DeepEqual(got, []string{"-", "00000001"}) {
+		t.Fatalf("1ª requisição: nc = %v, esperava sem auth e depois 00000001", got)
+	}
+
+	// desafio em cache: Authorization já na 1ª tentativa, nc incrementa
+	for _, want := range []string{"00000002", "00000003"} {
+		if code := get(); code != http.StatusOK {
+			t.Fatalf("status %d", code)
+		}
+		if got := ds.take(); !reflect.DeepEqual(got, []string{want}) {
+			t.Fatalf("nc = %v, esperava só %s (sem 401)", got, want)
+		}
+	}
+
+	// nonce expirou: 401, novo desafio e nc volta a 00000001
+	ds.mu.Lock()
+	ds.nonce = "n2"
+	ds.mu.Unlock()
+	if code := get(); code != http.StatusOK {
+		t.Fatalf("após troca de nonce: status %d", code)
+	}
+	if got := ds.take(); !reflect.DeepEqual(got, []string{"00000004", "00000001"}) {
+		t.Fatalf("após troca de nonce: nc = %v", got)
+	}
+}
+
+func TestDigestRequestWrongPassword(t *testing.T) {
+	ds := &digestServer{nonce: "n1"}
+	srv := httptest.NewServer(ds)
+	defer srv.Close()
+
+	resp, err := digestRequest(context.Background(), srv.Client().Do, &digestNonces{}, "admin", "errada", http.MethodGet, srv.URL+"/", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized || len(ds.take()) != 2 {
+		t.Fatalf("senha errada: esperava 401 após uma única repetição, veio %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,47 @@
+// internal/drivers/fallback.go
+package drivers
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+)
+
+// fallbackAnalytics devolve os analytics usados quando o /info não traz nenhum
+// válido. envKey (HIK_FALLBACK_ANALYTICS / DAHUA_FALLBACK_ANALYTICS) aceita uma
+// lista separada por vírgula; definido vazio ou "none" = não assinar nada.
+// Sem a variável, vale def (comportamento histórico). Itens fora de allowed
+// são ignorados.
+func fallbackAnalytics(envKey string, def []string, allowed map[string]struct{}) []string {
+	raw, ok := os.LookupEnv(envKey)
+	if !ok {
+		return def
+	}
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.EqualFold(raw, "none") {
+		return nil
+	}
+
+	var out []string
+	for _, item := range strings.Split(raw, ",") {
+		name := strings.TrimSpace(item)
+		if name == "" {
+			continue
+		}
+		if _, ok := allowed[strings.ToLower(name)]; !ok {
+			log.Printf("[drivers] %s: analytics '%s' não é suportado, ignorando", envKey, name)
+			continue
+		}
+		out = append(out, name)
+	}
+	return out
+}
+
+// waitMisconfigured reporta que a câmera não tem analytics para assinar e
+// segura o driver até o ctx ser cancelado (um novo /info reinicia o worker).
+func waitMisconfigured(ctx context.Context, notify func(StatusUpdate), reason string) error {
+	notify(StatusUpdate{State: ConnectionStateNotEstablished, Reason: reason})
+	<-ctx.Done()
+	return nil
+}
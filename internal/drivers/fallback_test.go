@@ -0,0 +1,51 @@
+package drivers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFallbackAnalytics(t *testing.T) {
+	def := []string{"faceCapture"}
+	allowed := map[string]struct{}{"facecapture": {}, "vmd": {}}
+
+	if got := fallbackAnalytics("TEST_FALLBACK_ANALYTICS", def, allowed); !reflect.DeepEqual(got, def) {
+		t.Fatalf("sem a variável: %v, esperava o default %v", got, def)
+	}
+
+	cases := map[string][]string{
+		"":                          nil,
+		"NONE":                      nil,
+		" VMD , bogus,,faceCapture": {"VMD", "faceCapture"},
+	}
+	for raw, want := range cases {
+		t.Setenv("TEST_FALLBACK_ANALYTICS", raw)
+		if got := fallbackAnalytics("TEST_FALLBACK_ANALYTICS", def, allowed); !reflect.DeepEqual(got, want) {
+			t.Errorf("TEST_FALLBACK_ANALYTICS=%q: %v, esperava %v", raw, got, want)
+		}
+	}
+}
+
+func TestWaitMisconfiguredHoldsUntilCancel(t *testing.T) {
+	var updates []StatusUpdate
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- waitMisconfigured(ctx, func(u StatusUpdate) { updates = append(updates, u) }, "sem analytics")
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("driver sem analytics não deveria sair antes do cancel")
+	case <-time.After(20 * time.Millisecond):
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 1 || updates[0].State != ConnectionStateNotEstablished || updates[0].Reason != "sem analytics" {
+		t.Fatalf("status reportado = %+v", updates)
+	}
+}
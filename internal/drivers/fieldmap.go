@@ -0,0 +1,145 @@
+// internal/drivers/fieldmap.go
+package drivers
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+// FieldMap lista, em ordem de preferência, as chaves onde cada dado do
+// evento é procurado (JSON da Hikvision; JSON e texto Key=Value da Dahua).
+// Firmwares diferentes usam nomes diferentes (ex.: dateTime x time,
+// faceScore x score, Code x code).
+type FieldMap struct {
+	Timestamp []string `json:"timestamp,omitempty"`
+	Score     []string `json:"score,omitempty"`
+	Geometry  []string `json:"geometry,omitempty"`
+	Code      []string `json:"code,omitempty"`
+	Action    []string `json:"action,omitempty"`
+	Index     []string `json:"index,omitempty"`
+}
+
+// defaultFieldMaps mantém as chaves que os parsers sempre usaram.
+var defaultFieldMaps = map[string]FieldMap{
+	"hikvision": {
+		Timestamp: []string{"dateTime"},
+		Score:     []string{"faceScore"},
+		Geometry:  []string{"faceRect"},
+	},
+	"dahua": {
+		Code:   []string{"Code", "code"},
+		Action: []string{"Action", "action"},
+		Index:  []string{"Index", "index"},
+	},
+}
+
+var (
+	fieldMapsOnce sync.Once
+	fieldMaps     map[string]map[string]FieldMap // fabricante -> modelo -> mapa
+)
+
+// loadFieldMaps lê DRIVER_FIELD_MAP (arquivo JSON) no formato:
+//
+//	{"hikvision": {"*": {"score": ["score"]}, "DS-2CD7A26G0": {"timestamp": ["time"]}}}
+//
+// Chave "*" (ou "any") vale para todos os modelos do fabricante. Na Dahua,
+// code/action/index valem para o JSON e para o texto "Code=...;action=...".
+func loadFieldMaps() {
+	fieldMaps = make(map[string]map[string]FieldMap)
+
+	path := strings.TrimSpace(os.Getenv("DRIVER_FIELD_MAP"))
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("[drivers] erro ao ler DRIVER_FIELD_MAP=%s: %v", path, err)
+		return
+	}
+
+	var raw map[string]map[string]FieldMap
+	if err := json.Unmarshal(data, &raw); err != nil {
+		log.Printf("[drivers] DRIVER_FIELD_MAP=%s inválido: %v", path, err)
+		return
+	}
+	for manufacturer, models := range raw {
+		byModel := make(map[string]FieldMap, len(models))
+		for model, fm := range models {
+			model = normalize(model)
+			if model == "any" {
+				model = "*"
+			}
+			byModel[model] = fm
+		}
+		fieldMaps[normalize(manufacturer)] = byModel
+	}
+	log.Printf("[drivers] DRIVER_FIELD_MAP carregado de %s (%d fabricantes)", path, len(fieldMaps))
+}
+
+// fieldMapFor resolve o mapa da câmera: modelo exato > "*" > default do fabricante.
+// Campos não definidos no arquivo herdam do nível anterior.
+func fieldMapFor(info core.CameraInfo) FieldMap {
+	fieldMapsOnce.Do(loadFieldMaps)
+
+	manufacturer := normalize(info.Manufacturer)
+	fm := defaultFieldMaps[manufacturer]
+	if byModel, ok := fieldMaps[manufacturer]; ok {
+		if wildcard, ok := byModel["*"]; ok {
+			fm = fm.merge(wildcard)
+		}
+		if exact, ok := byModel[normalize(info.Model)]; ok {
+			fm = fm.merge(exact)
+		}
+	}
+	return fm
+}
+
+func (fm FieldMap) merge(override FieldMap) FieldMap {
+	if len(override.Timestamp) > 0 {
+		fm.Timestamp = override.Timestamp
+	}
+	if len(override.Score) > 0 {
+		fm.Score = override.Score
+	}
+	if len(override.Geometry) > 0 {
+		fm.Geometry = override.Geometry
+	}
+	if len(override.Code) > 0 {
+		fm.Code = override.Code
+	}
+	if len(override.Action) > 0 {
+		fm.Action = override.Action
+	}
+	if len(override.Index) > 0 {
+		fm.Index = override.Index
+	}
+	return fm
+}
+
+func firstFloat(m map[string]interface{}, keys []string) (float64, bool) {
+	for _, k := range keys {
+		switch v := m[k].(type) {
+		case float64:
+			return v, true
+		case json.Number:
+			if f, err := v.Float64(); err == nil {
+				return f, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func firstValue(m map[string]interface{}, keys []string) (interface{}, bool) {
+	for _, k := range keys {
+		if v, ok := m[k]; ok && v != nil {
+			return v, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,85 @@
+package drivers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+// withFieldMap grava um DRIVER_FIELD_MAP temporário e força a releitura.
+func withFieldMap(t *testing.T, content string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fieldmap.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DRIVER_FIELD_MAP", path)
+	fieldMapsOnce = sync.Once{}
+	t.Cleanup(func() { fieldMapsOnce = sync.Once{} })
+}
+
+func TestFieldMapPrecedence(t *testing.T) {
+	withFieldMap(t, `{
+		"Hikvision": {
+			"*": {"score": ["score"]},
+			"DS-X": {"timestamp": ["time"]}
+		}
+	}`)
+
+	fm := fieldMapFor(core.CameraInfo{Manufacturer: "hikvision", Model: "ds-x"})
+	if len(fm.Timestamp) != 1 || fm.Timestamp[0] != "time" {
+		t.Fatalf("timestamp do modelo = %v", fm.Timestamp)
+	}
+	if len(fm.Score) != 1 || fm.Score[0] != "score" {
+		t.Fatalf("score do wildcard = %v", fm.Score)
+	}
+	if len(fm.Geometry) != 1 || fm.Geometry[0] != "faceRect" {
+		t.Fatalf("geometry deveria herdar o default: %v", fm.Geometry)
+	}
+
+	other := fieldMapFor(core.CameraInfo{Manufacturer: "hikvision", Model: "DS-Y"})
+	if other.Timestamp[0] != "dateTime" {
+		t.Fatalf("outro modelo deveria usar o timestamp padrão: %v", other.Timestamp)
+	}
+}
+
+func TestHikvisionJSONWithCustomFieldMap(t *testing.T) {
+	withFieldMap(t, `{"hikvision": {"*": {"timestamp": ["time"], "score": ["score"], "geometry": ["rect"]}}}`)
+	drv, err := NewHikvisionDriver(core.CameraInfo{Manufacturer: "hikvision", IP: "10.0.0.1", DeviceID: "c1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := drv.(*HikvisionDriver)
+
+	body := `{"eventType":"faceCapture","eventState":"active","channelID":1,"time":"2024-05-01T10:00:00Z",
+		"faceCapture":[{"faces":[{"faceId":1,"score":0.9,"rect":{"x":0.1,"y":0.2,"width":0.3,"height":0.4}}]}]}`
+	evt, err := d.parseJSONEvent([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evt.Meta["camera_ts"] != "2024-05-01T10:00:00Z" {
+		t.Fatalf("timestamp do campo mapeado não usado: %v", evt.Meta["camera_ts"])
+	}
+	if evt.Meta["bestScore"] != 0.9 || evt.Meta["bestFaceRect"] == nil {
+		t.Fatalf("score/geometry do campo mapeado não usados: %v", evt.Meta)
+	}
+}
+
+func TestDahuaTextWithCustomFieldMap(t *testing.T) {
+	withFieldMap(t, `{"dahua": {"*": {"code": ["EventCode"], "action": ["Act"], "index": ["Chan"]}}}`)
+	var queries []string
+	d := newTestDahua(t, cameraServer(t, snapshotHandler(&queries)))
+
+	evt, _, _, err := d.parseEventAndSnapshot(context.Background(),
+		[]byte("EventCode=FaceDetection;Act=Start;Chan=2"), map[string]struct{}{"facedetection": {}})
+	if err != nil || evt == nil {
+		t.Fatalf("evt=%v err=%v", evt, err)
+	}
+	if evt.AnalyticType != "FaceDetection" || evt.Meta["channelID"] != 3 {
+		t.Fatalf("evento = %s canal %v", evt.AnalyticType, evt.Meta["channelID"])
+	}
+}
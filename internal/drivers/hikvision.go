@@ -7,7 +7,6 @@ import (
 	"context"
 	"crypto/md5"
 	crand "crypto/rand"
-	"crypto/tls"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
@@ -19,66 +18,68 @@ import (
 	"mime"
 	"mime/multipart"
 	"net/http"
-	"net/url"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/eventid"
+	"github.com/sua-org/cam-bus/internal/logthrottle"
+	"github.com/sua-org/cam-bus/internal/netproxy"
 	"github.com/sua-org/cam-bus/internal/storage"
+	"github.com/sua-org/cam-bus/internal/tracing"
 )
 
 type HikvisionDriver struct {
-	info          core.CameraInfo
-	client        *http.Client
-	statusHandler func(StatusUpdate)
+	httpCamera
+	fields       FieldMap
+	heartbeat    time.Duration     // <heartbeat> do subscribeEvent (DRIVER_SUBSCRIBE_HEARTBEAT)
+	backoff      *reconnectBackoff // espera entre reconexões (CAMERA_RECONNECT_*)
+	rtspFallback bool              // SNAPSHOT_RTSP_FALLBACK: frame do RTSPURL sem outra imagem
 }
 
 func NewHikvisionDriver(info core.CameraInfo) (CameraDriver, error) {
 	var httpClient *http.Client
 
 	if info.UseTLS {
-		// SEM controle por env: sempre ignora cert quando UseTLS=true
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true, //nolint:gosec - uso consciente em rede interna
-			},
+		tlsCfg, err := cameraTLSConfig(info)
+		if err != nil {
+			return nil, err
 		}
+		tr := netproxy.Transport(tlsCfg)
 		httpClient = &http.Client{
 			Timeout:   0,
 			Transport: tr,
 		}
-		log.Printf("[hikvision] TLS inseguro (sempre) habilitado para %s (%s)",
-			info.Name, info.IP)
-	} else {
-		httpClient = &http.Client{
-			Timeout: 0,
+		if info.CertFingerprint != "" {
+			log.Printf("[hikvision] TLS com certificado fixado habilitado para %s (%s)", info.Name, info.IP)
+		} else {
+			log.Printf("[hikvision] TLS inseguro habilitado para %s (%s)", info.Name, info.IP)
 		}
+	} else {
+		httpClient = netproxy.Client(0)
+	}
+
+	session, err := newSessionAuth(info)
+	if err != nil {
+		return nil, err
 	}
 
 	d := &HikvisionDriver{
-		info:   info,
-		client: httpClient,
+		httpCamera:   httpCamera{info: info, client: httpClient, session: session},
+		fields:       fieldMapFor(info),
+		heartbeat:    subscribeHeartbeat(info, defaultHikvisionHeartbeat),
+		backoff:      newReconnectBackoff(),
+		rtspFallback: rtspSnapshotFallback(),
 	}
 	return d, nil
 }
 
-// SetStatusHandler registra callback para mudanças de estado da câmera.
-func (d *HikvisionDriver) SetStatusHandler(fn func(StatusUpdate)) {
-	d.statusHandler = fn
-}
-
 // ActiveAnalytics retorna a lista efetiva de analytics assinados para a câmera.
 func (d *HikvisionDriver) ActiveAnalytics() []string {
 	return d.selectedEventTypes()
 }
 
-func (d *HikvisionDriver) notifyStatus(update StatusUpdate) {
-	if d.statusHandler != nil {
-		d.statusHandler(update)
-	}
-}
-
 func init() {
 	// registra Hikvision para qualquer modelo: "hikvision:any"
 	RegisterDriver("hikvision", "any", func(info core.CameraInfo) (CameraDriver, error) {
@@ -90,22 +91,13 @@ func init() {
 func (d *HikvisionDriver) Run(ctx context.Context, events chan<- core.AnalyticEvent) error {
 	log.Printf("[hikvision] starting driver for %s (%s)", d.info.Name, d.info.IP)
 
-	// Laço de reconexão em caso de erro
-	for {
-		if err := d.runOnce(ctx, events); err != nil {
-			if ctx.Err() != nil {
-				return nil
-			}
-			log.Printf("[hikvision] error for %s: %v, retrying in 5s", d.info.Name, err)
-			select {
-			case <-time.After(5 * time.Second):
-			case <-ctx.Done():
-				return nil
-			}
-		} else {
-			return nil
-		}
+	if len(d.selectedEventTypes()) == 0 {
+		return waitMisconfigured(ctx, d.notifyStatus, "nenhum analytics válido no /info (fallback HIK_FALLBACK_ANALYTICS vazio)")
 	}
+
+	return runReconnecting(ctx, d.backoff, "hikvision", d.info, d.notifyStatus, func(ctx context.Context) error {
+		return d.runOnce(ctx, events)
+	})
 }
 
 func (d *HikvisionDriver) runOnce(ctx context.Context, events chan<- core.AnalyticEvent) error {
@@ -153,123 +145,261 @@ func (d *HikvisionDriver) runOnce(ctx context.Context, events chan<- core.Analyt
 		d.notifyStatus(StatusUpdate{State: ConnectionStateNotEstablished, Reason: err.Error()})
 		return fmt.Errorf("invalid Content-Type %q: %w", ct, err)
 	}
-	if !strings.HasPrefix(mediatype, "multipart/") {
-		// Em algumas versões, pode vir "application/xml" contínuo; aqui assumimos multipart.
+	// Em geral vem multipart/mixed; alguns firmwares mandam application/xml
+	// contínuo (um EventNotificationAlert atrás do outro, sem boundary).
+	multipartStream := strings.HasPrefix(mediatype, "multipart/")
+	xmlStream := mediatype == "application/xml" || mediatype == "text/xml"
+	if !multipartStream && !xmlStream {
 		resp.Body.Close()
 		d.notifyStatus(StatusUpdate{State: ConnectionStateNotEstablished, Reason: "unexpected media type"})
 		return fmt.Errorf("unexpected media type: %s", mediatype)
 	}
 
 	boundary := params["boundary"]
-	if boundary == "" {
+	if multipartStream && boundary == "" {
 		resp.Body.Close()
 		d.notifyStatus(StatusUpdate{State: ConnectionStateNotEstablished, Reason: "missing boundary"})
 		return fmt.Errorf("no boundary in Content-Type: %s", ct)
 	}
 
-	mr := multipart.NewReader(resp.Body, boundary)
+	// sem nenhum byte (nem heartbeat) por heartbeat×DRIVER_WATCHDOG_FACTOR, reconecta
+	resp.Body = withWatchdog(resp.Body, watchdogTimeout(d.heartbeat))
+	defer resp.Body.Close()
 	d.notifyStatus(StatusUpdate{State: ConnectionStateOnline, Reason: "stream ativo"})
 
-	// pendingEvent: guardamos o evento textual até chegar a imagem.
+	partsCtx, stopParts := context.WithCancel(ctx)
+	defer stopParts()
+	var parts <-chan multipartPart
+	if multipartStream {
+		parts = readMultipartParts(partsCtx, multipart.NewReader(resp.Body, boundary))
+	} else {
+		log.Printf("[hikvision] stream %s sem multipart para %s; lendo documentos XML em sequência", mediatype, d.info.IP)
+		parts = readXMLDocuments(partsCtx, resp.Body, mediatype)
+	}
+
+	// pendingEvent: guardamos o evento textual até chegarem as imagens.
+	// Várias partes image/* seguidas (cena + recortes de face) são juntadas
+	// até HIK_SNAPSHOT_COLLECT_WINDOW sem novas imagens.
 	var pendingEvent *core.AnalyticEvent
+	var images []snapshotImage
+	var collect <-chan time.Time
+	window := snapshotCollectWindow()
+
+	flush := func() bool {
+		collect = nil
+		if pendingEvent == nil {
+			return true
+		}
+		if len(images) == 0 {
+			if imgURL, _ := pendingEvent.Meta[metaEventImageURL].(string); imgURL != "" {
+				// a câmera mandou a URL da imagem no próprio evento
+				img, ctype, err := fetchImage(ctx, d.doDigest, imgURL)
+				if err == nil {
+					images = []snapshotImage{{data: img, contentType: ctype}}
+				} else {
+					logthrottle.Printf("hikvision:image_url:"+d.info.IP, "[hikvision] erro ao buscar imagem do evento (%s): %v", imgURL, err)
+				}
+			}
+		}
+		if len(images) == 0 {
+			// evento sem parte image/*: busca o snapshot do canal/lente do evento
+			// e, por último, um frame do RTSP (SNAPSHOT_RTSP_FALLBACK)
+			channel := eventChannel(pendingEvent.Meta, "channelID", "dynChannelID")
+			img, ctype, err := d.fetchSnapshot(ctx, baseURL, channel)
+			if err != nil {
+				logthrottle.Printf("hikvision:snapshot:"+d.info.IP, "[hikvision] erro ao buscar snapshot do canal %d: %v", channel, err)
+			}
+			if err != nil && d.rtspFallback {
+				if img, ctype, err = fetchRTSPSnapshot(ctx, d.info); err != nil {
+					logthrottle.Printf("hikvision:rtsp-snapshot:"+d.info.IP, "[hikvision] erro ao capturar snapshot pelo RTSP: %v", err)
+				}
+			}
+			if err != nil {
+				pendingEvent = nil
+				return true
+			}
+			images = []snapshotImage{{data: img, contentType: ctype}}
+		}
+		if images = validSnapshotImages("hikvision", d.info.IP, images); len(images) == 0 {
+			pendingEvent = nil // só imagens corrompidas (SNAPSHOT_VALIDATE)
+			return true
+		}
+		evt := d.finishEvent(ctx, pendingEvent, images)
+		pendingEvent, images = nil, nil
+		select {
+		case events <- evt:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
 
 	for {
-		part, err := mr.NextPart()
-		if err != nil {
-			if err == io.EOF {
-				resp.Body.Close()
+		var part multipartPart
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-collect:
+			if !flush() {
+				return nil
+			}
+			continue
+		case part, ok = <-parts:
+		}
+		if !ok {
+			return nil
+		}
+
+		if part.err != nil {
+			if !flush() {
+				return nil
+			}
+			if part.err == io.EOF {
 				d.notifyStatus(StatusUpdate{State: ConnectionStateOffline, Reason: "stream ended"})
 				return fmt.Errorf("stream ended")
 			}
-			resp.Body.Close()
-			d.notifyStatus(StatusUpdate{State: ConnectionStateOffline, Reason: err.Error()})
-			return fmt.Errorf("error reading part: %w", err)
+			d.notifyStatus(StatusUpdate{State: ConnectionStateOffline, Reason: part.err.Error()})
+			return fmt.Errorf("error reading part: %w", part.err)
 		}
 
-		pCT := part.Header.Get("Content-Type")
+		pCT := part.contentType
 
 		if strings.HasPrefix(pCT, "application/json") {
 			// Evento em JSON
-			data, err := io.ReadAll(part)
+			evt, err := d.parseJSONEvent(part.data)
 			if err != nil {
-				log.Printf("[hikvision] error reading json part: %v", err)
+				cameraLog(d.info).Warnf("[hikvision] json parse error: %v; raw=%s", err, string(part.data))
 				continue
 			}
-
-			evt, err := d.parseJSONEvent(data)
-			if err != nil {
-				log.Printf("[hikvision] json parse error: %v; raw=%s", err, string(data))
-				continue
+			if !flush() {
+				return nil
 			}
 			pendingEvent = evt
+			d.resolveImageURL(baseURL, evt)
+			// sem imagem até o fim da janela, flush busca o snapshot do canal
+			collect = time.After(max(window, defaultSnapshotCollectWindow))
 			continue
 		}
 
 		if strings.HasPrefix(pCT, "application/xml") || strings.HasPrefix(pCT, "text/xml") {
 			// Evento em XML (não é o foco, mas podemos tentar extrair infos básicas)
-			data, err := io.ReadAll(part)
+			evt, err := d.parseXMLEvent(part.data)
 			if err != nil {
-				log.Printf("[hikvision] error reading xml part: %v", err)
+				cameraLog(d.info).Warnf("[hikvision] xml parse error: %v", err)
 				continue
 			}
-			evt, err := d.parseXMLEvent(data)
-			if err != nil {
-				log.Printf("[hikvision] xml parse error: %v", err)
-				continue
+			if !flush() {
+				return nil
 			}
 			pendingEvent = evt
+			collect = time.After(max(window, defaultSnapshotCollectWindow))
 			continue
 		}
 
 		if strings.HasPrefix(pCT, "image/") {
-			imgBytes, err := io.ReadAll(part)
-			if err != nil {
-				log.Printf("[hikvision] error reading image part: %v", err)
+			if pendingEvent == nil {
+				cameraLog(d.info).Debugf("[hikvision] image part sem evento pendente, descartando")
 				continue
 			}
-
-			if pendingEvent != nil {
-				// Salva em MinIO, se disponível
-				if storage.DefaultStore != nil {
-					ctxUp, cancelUp := context.WithTimeout(ctx, 5*time.Second)
-					url, err := storage.DefaultStore.SaveSnapshot(ctxUp, d.buildSnapshotKey(pendingEvent), imgBytes, pCT)
-					cancelUp()
-					if err != nil {
-						log.Printf("[hikvision] erro ao salvar snapshot no MinIO: %v", err)
-					} else {
-						pendingEvent.SnapshotURL = url
-					}
-				}
-
-				// Sempre guarda base64 para o faceengine poder usar,
-				// mesmo que o MinIO esteja privado.
-				pendingEvent.SnapshotB64 = base64.StdEncoding.EncodeToString(imgBytes)
-
-				// Envia evento
-				select {
-				case events <- *pendingEvent:
-				case <-ctx.Done():
-					part.Close()
-					resp.Body.Close()
+			images = append(images, snapshotImage{data: part.data, contentType: pCT})
+			if window <= 0 {
+				if !flush() {
 					return nil
 				}
-
-				pendingEvent = nil
-			} else {
-				log.Printf("[hikvision] image part sem evento pendente, descartando")
+				continue
 			}
-
+			collect = time.After(window)
 			continue
 		}
 
 		// Outros tipos: apenas descarta
-		_ = part.Close()
 	}
 }
 
+// fetchSnapshot baixa a imagem atual do canal/lente indicado pelo evento
+// (/ISAPI/Streaming/channels/<canal>01/picture, stream principal).
+func (d *HikvisionDriver) fetchSnapshot(ctx context.Context, baseURL string, channel int) ([]byte, string, error) {
+	return fetchImage(ctx, d.doDigest, hikvisionPictureURL(baseURL, channel))
+}
+
+// resolveImageURL valida a URL de imagem do evento (Meta["event_image_url"]),
+// deixando-a absoluta; URLs inválidas ou de outro host são descartadas.
+func (d *HikvisionDriver) resolveImageURL(baseURL string, evt *core.AnalyticEvent) bool {
+	raw, _ := evt.Meta[metaEventImageURL].(string)
+	if raw == "" {
+		return false
+	}
+	abs, err := resolveEventImageURL(baseURL, raw)
+	if err != nil {
+		eventLog(d.info, evt).Warnf("[hikvision] ignorando URL de imagem do evento: %v", err)
+		delete(evt.Meta, metaEventImageURL)
+		return false
+	}
+	evt.Meta[metaEventImageURL] = abs
+	return true
+}
+
+func hikvisionPictureURL(baseURL string, channel int) string {
+	if channel <= 0 {
+		channel = defaultSnapshotChannel
+	}
+	return fmt.Sprintf("%s/ISAPI/Streaming/channels/%d01/picture", baseURL, channel)
+}
+
+// finishEvent completa o evento pendente com as imagens recebidas: a maior
+// vira o snapshot principal (SnapshotURL/SnapshotB64, como antes) e as demais
+// são salvas como recortes em Meta["face_thumb_urls"]; Meta["snapshot_urls"]
+// lista todas, principal primeiro.
+func (d *HikvisionDriver) finishEvent(ctx context.Context, pendingEvent *core.AnalyticEvent, images []snapshotImage) core.AnalyticEvent {
+	evtCtx, span := tracing.Start(ctx, "driver.receive")
+	defer span.End()
+	span.SetAttr("camera.id", d.info.DeviceID)
+	span.SetAttr("analytic.type", pendingEvent.AnalyticType)
+	span.SetAttr("event.id", pendingEvent.EventID)
+
+	primary, extras := splitPrimaryImage(images)
+	key := d.buildSnapshotKey(pendingEvent)
+	primary.data, primary.contentType = storage.ProcessSnapshot(primary.data, primary.contentType)
+	for i := range extras {
+		extras[i].data, extras[i].contentType = storage.ProcessSnapshot(extras[i].data, extras[i].contentType)
+	}
+
+	// Salva em MinIO, se disponível (ou adia, conforme SNAPSHOT_STORE_POLICY)
+	if storage.DefaultPolicy().Deferred() {
+		pendingEvent.RawSnapshot = primary.data
+		pendingEvent.SnapshotKey = key
+		pendingEvent.SnapshotContentType = primary.contentType
+	} else if store := storage.StoreFor(d.info.StorageProfile); store != nil && storage.DefaultPolicy().StoreInDriver() {
+		ctxUp, cancelUp := context.WithTimeout(evtCtx, 5*time.Second)
+		url, thumbURL, err := storage.SaveWithThumbnail(ctxUp, store, key, primary.data, primary.contentType)
+		cancelUp()
+		if err != nil {
+			eventLog(d.info, pendingEvent).Errorf("[hikvision] erro ao salvar snapshot no MinIO: %v", err)
+		} else {
+			pendingEvent.SnapshotURL = url
+			pendingEvent.ThumbnailURL = thumbURL
+		}
+
+		if len(extras) > 0 {
+			pendingEvent.Meta = storeExtraSnapshots(evtCtx, store, key, pendingEvent.SnapshotURL, extras, pendingEvent.Meta)
+		}
+	}
+
+	// Sempre guarda base64 para o faceengine poder usar,
+	// mesmo que o MinIO esteja privado.
+	pendingEvent.SnapshotB64 = base64.StdEncoding.EncodeToString(primary.data)
+	if len(extras) > 0 {
+		span.SetAttr("snapshot.images", fmt.Sprintf("%d", len(images)))
+	}
+	pendingEvent.Meta = tracing.Inject(evtCtx, pendingEvent.Meta)
+	return *pendingEvent
+}
+
 // buildSubscribeEventXML monta o XML de subscribeEvent
-// baseado na lista de analytics vinda do /info (CameraInfo.Analytics).
-// Se não vier nada válido, cai no fallback: faceCapture.
+// baseado na lista de analytics vinda do /info (CameraInfo.Analytics),
+// com um <Event> por canal (CameraInfo.Channels) para NVRs.
+// Se não vier nada válido, cai no fallback (HIK_FALLBACK_ANALYTICS, default faceCapture).
 func (d *HikvisionDriver) buildSubscribeEventXML() []byte {
 	selected := d.selectedEventTypes()
 
@@ -277,14 +407,15 @@ func (d *HikvisionDriver) buildSubscribeEventXML() []byte {
 	var b strings.Builder
 	b.WriteString(`<SubscribeEvent xmlns="http://www.isapi.org/ver20/XMLSchema">`)
 	b.WriteString(`<format>json</format>`)
-	b.WriteString(`<heartbeat>30</heartbeat>`)
+	fmt.Fprintf(&b, `<heartbeat>%d</heartbeat>`, heartbeatSeconds(d.heartbeat))
 	b.WriteString(`<eventMode>list</eventMode>`)
 	b.WriteString(`<EventList>`)
 
+	channels := d.subscribeChannels()
 	for _, t := range selected {
-		b.WriteString(`<Event><type>`)
-		b.WriteString(t)
-		b.WriteString(`</type><channels>1</channels></Event>`)
+		for _, ch := range channels {
+			fmt.Fprintf(&b, `<Event><type>%s</type><channels>%d</channels></Event>`, t, ch)
+		}
 	}
 
 	b.WriteString(`</EventList>`)
@@ -293,143 +424,75 @@ func (d *HikvisionDriver) buildSubscribeEventXML() []byte {
 	return []byte(b.String())
 }
 
-func (d *HikvisionDriver) selectedEventTypes() []string {
-	var selected []string
-
-	if len(d.info.Analytics) > 0 {
-		for _, a := range d.info.Analytics {
-			name := strings.TrimSpace(a)
-			if name == "" {
-				continue
-			}
-			key := strings.ToLower(name)
-			if _, ok := core.HikvisionEventTypeSet[key]; ok {
-				selected = append(selected, name)
-			} else {
-				log.Printf(
-					"[hikvision] camera %s: analytics '%s' não é suportado, ignorando",
-					d.info.DeviceID, name,
-				)
-			}
+// subscribeChannels devolve os canais do /info (CameraInfo.Channels), sem
+// repetidos nem inválidos; vazio = só o canal 1 (câmera avulsa).
+func (d *HikvisionDriver) subscribeChannels() []int {
+	var out []int
+	seen := map[int]bool{}
+	for _, ch := range d.info.Channels {
+		if ch <= 0 || seen[ch] {
+			continue
 		}
+		seen[ch] = true
+		out = append(out, ch)
 	}
-
-	if len(selected) == 0 {
-		selected = []string{"faceCapture"}
-		log.Printf(
-			"[hikvision] camera %s: nenhum analytics válido no /info, usando fallback faceCapture",
-			d.info.DeviceID,
-		)
+	if len(out) == 0 {
+		return []int{1}
 	}
-
-	return selected
+	return out
 }
 
-// ----------------------------------
-// Digest Auth helper
-// ----------------------------------
-
-func (d *HikvisionDriver) doDigest(
-	ctx context.Context,
-	method, rawURL string,
-	body io.Reader,
-	contentType string,
-) (*http.Response, error) {
-	// 1ª tentativa sem Authorization, só pra pegar WWW-Authenticate
-	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
-	if err != nil {
-		return nil, err
-	}
-	if contentType != "" {
-		req.Header.Set("Content-Type", contentType)
-	}
-	req.Header.Set("Connection", "keep-alive")
-
-	resp, err := d.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusUnauthorized {
-		return resp, nil
-	}
-
-	// 401: parse WWW-Authenticate
-	authHeader := resp.Header.Get("WWW-Authenticate")
-	_ = resp.Body.Close()
-	digest, err := parseDigestAuthHeader(authHeader)
-	if err != nil {
-		return nil, err
-	}
-
-	username := d.info.Username
-	password := d.info.Password
-
-	u, err := url.Parse(rawURL)
-	if err != nil {
-		return nil, err
+func (d *HikvisionDriver) selectedEventTypes() []string {
+	sel := hikvisionAnalytics(d.info.Analytics)
+	for _, name := range sel.Unsupported {
+		log.Printf(
+			"[hikvision] camera %s: analytics '%s' não é suportado, ignorando",
+			d.info.DeviceID, name,
+		)
 	}
 
-	// Monta segunda requisição com Authorization Digest
-	// Precisamos recriar o body, pois já foi consumido na 1ª tentativa.
-	var bodyBytes []byte
-	if body != nil {
-		if rb, ok := body.(*bytes.Reader); ok {
-			// reader original era bytes.Reader
-			rb.Seek(0, io.SeekStart)
-			bodyBytes, _ = io.ReadAll(rb)
-		} else if b, ok := body.(*bytes.Buffer); ok {
-			bodyBytes = b.Bytes()
+	selected := sel.Selected
+	if sel.Fallback {
+		if len(selected) == 0 {
+			log.Printf(
+				"[hikvision] camera %s: nenhum analytics válido no /info e fallback vazio, nada será assinado",
+				d.info.DeviceID,
+			)
 		} else {
-			// sem como reaproveitar: consideramos que as chamadas que usam body
-			// já estão passando bytes.Reader/buffer (SubscribeEvent, etc.)
+			log.Printf(
+				"[hikvision] camera %s: nenhum analytics válido no /info, usando fallback %s",
+				d.info.DeviceID, strings.Join(selected, ","),
+			)
 		}
 	}
 
-	var body2 io.Reader
-	if bodyBytes != nil {
-		body2 = bytes.NewReader(bodyBytes)
-	}
-
-	nc := "00000001"
-	cnonce := randomHex(16)
-	ha1 := md5Hex(fmt.Sprintf("%s:%s:%s", username, digest.Realm, password))
-	ha2 := md5Hex(fmt.Sprintf("%s:%s", method, u.RequestURI()))
-	response := md5Hex(fmt.Sprintf("%s:%s:%s:%s:%s:%s",
-		ha1, digest.Nonce, nc, cnonce, digest.Qop, ha2,
-	))
-
-	authValue := fmt.Sprintf(
-		`Digest username="%s", realm="%s", nonce="%s", uri="%s", algorithm=MD5, response="%s", qop=%s, nc=%s, cnonce="%s"`,
-		username,
-		digest.Realm,
-		digest.Nonce,
-		u.RequestURI(),
-		response,
-		digest.Qop,
-		nc,
-		cnonce,
-	)
+	return selected
+}
 
-	req2, err := http.NewRequestWithContext(ctx, method, rawURL, body2)
-	if err != nil {
-		return nil, err
-	}
-	if contentType != "" {
-		req2.Header.Set("Content-Type", contentType)
+// hikvisionAnalytics são as regras de selectedEventTypes (Hikvision não
+// aceita "ALL"/"*").
+func hikvisionAnalytics(analytics []string) AnalyticsSelection {
+	sel := filterAnalytics(analytics, inSet(core.HikvisionEventTypeSet), nil)
+	if len(sel.Selected) == 0 {
+		sel.Fallback, sel.FallbackEnv = true, "HIK_FALLBACK_ANALYTICS"
+		sel.Selected = fallbackAnalytics(sel.FallbackEnv, []string{"faceCapture"}, core.HikvisionEventTypeSet)
 	}
-	req2.Header.Set("Connection", "keep-alive")
-	req2.Header.Set("Authorization", authValue)
-
-	return d.client.Do(req2)
+	return sel
 }
 
+// ----------------------------------
+// Digest Auth helper
+// ----------------------------------
+
 type digestChallenge struct {
-	Realm string
-	Nonce string
-	Qop   string
+	Realm     string
+	Nonce     string
+	Qop       string
+	Algorithm string // MD5 (default), MD5-sess, SHA-256 ou SHA-256-sess
 }
 
-var digestRx = regexp.MustCompile(`(\w+)="([^"]+)"`)
+// algorithm costuma vir sem aspas (algorithm=SHA-256), os demais entre aspas
+var digestRx = regexp.MustCompile(`(\w+)=(?:"([^"]*)"|([^\s,]+))`)
 
 func parseDigestAuthHeader(h string) (*digestChallenge, error) {
 	if !strings.HasPrefix(strings.ToLower(h), "digest ") {
@@ -439,11 +502,11 @@ func parseDigestAuthHeader(h string) (*digestChallenge, error) {
 	m := digestRx.FindAllStringSubmatch(h, -1)
 	res := &digestChallenge{}
 	for _, kv := range m {
-		if len(kv) != 3 {
+		if len(kv) != 4 {
 			continue
 		}
 		k := strings.ToLower(kv[1])
-		v := kv[2]
+		v := kv[2] + kv[3]
 		switch k {
 		case "realm":
 			res.Realm = v
@@ -451,13 +514,18 @@ func parseDigestAuthHeader(h string) (*digestChallenge, error) {
 			res.Nonce = v
 		case "qop":
 			res.Qop = v
+		case "algorithm":
+			res.Algorithm = v
 		}
 	}
 	if res.Realm == "" || res.Nonce == "" {
 		return nil, fmt.Errorf("realm/nonce ausentes em WWW-Authenticate: %s", h)
 	}
-	if res.Qop == "" {
-		res.Qop = "auth"
+	if res.Qop == "" || strings.Contains(res.Qop, ",") {
+		res.Qop = "auth" // qop="auth,auth-int": só auth é suportado
+	}
+	if res.Algorithm == "" {
+		res.Algorithm = "MD5"
 	}
 	return res, nil
 }
@@ -484,7 +552,7 @@ func randomHex(n int) string {
 
 func (d *HikvisionDriver) parseJSONEvent(data []byte) (*core.AnalyticEvent, error) {
 	var raw map[string]interface{}
-	if err := json.Unmarshal(data, &raw); err != nil {
+	if err := decodeJSON(data, &raw); err != nil {
 		return nil, err
 	}
 
@@ -501,11 +569,16 @@ func (d *HikvisionDriver) parseJSONEvent(data []byte) (*core.AnalyticEvent, erro
 		"channelID":        getNumber(raw, "channelID"),
 		"channelName":      getString(raw, "channelName"),
 	}
+	if imgURL := eventImageURL(raw); imgURL != "" {
+		meta[metaEventImageURL] = imgURL
+	}
 
 	// faceCapture: extrai informações da lista faceCapture[]
 	if eventType == "faceCapture" {
 		bestScore := 0.0
 		facesCount := 0
+		var bestRect interface{}
+		var faces []map[string]interface{}
 
 		if fcRaw, ok := raw["faceCapture"]; ok {
 			if arr, ok2 := fcRaw.([]interface{}); ok2 {
@@ -522,9 +595,11 @@ func (d *HikvisionDriver) parseJSONEvent(data []byte) (*core.AnalyticEvent, erro
 								if !ok6 {
 									continue
 								}
-								if sc, ok7 := fObj["faceScore"].(float64); ok7 {
+								faces = append(faces, d.parseFace(fObj))
+								if sc, ok7 := firstFloat(fObj, d.fields.Score); ok7 {
 									if sc > bestScore {
 										bestScore = sc
+										bestRect, _ = firstValue(fObj, d.fields.Geometry)
 									}
 								}
 							}
@@ -536,14 +611,21 @@ func (d *HikvisionDriver) parseJSONEvent(data []byte) (*core.AnalyticEvent, erro
 
 		meta["facesCount"] = facesCount
 		meta["bestScore"] = bestScore
+		if bestRect != nil {
+			meta["bestFaceRect"] = bestRect
+		}
+		if len(faces) > 0 {
+			meta["faces"] = faces
+		}
 	}
 
-	tsStr := getString(raw, "dateTime")
+	tsStr := getString(raw, d.fields.Timestamp...)
 	var ts time.Time
 	if tsStr != "" {
 		t, err := time.Parse(time.RFC3339, tsStr)
 		if err == nil {
 			ts = t.UTC()
+			meta["camera_ts"] = ts.Format(time.RFC3339Nano)
 		}
 	}
 	if ts.IsZero() {
@@ -556,6 +638,7 @@ func (d *HikvisionDriver) parseJSONEvent(data []byte) (*core.AnalyticEvent, erro
 		CameraIP:     d.info.IP,
 		CameraName:   d.info.Name,
 		AnalyticType: analytic,
+		EventState:   core.NormalizeEventState(getString(meta, "eventState")),
 		Meta:         meta,
 
 		Tenant:     d.info.Tenant,
@@ -564,10 +647,68 @@ func (d *HikvisionDriver) parseJSONEvent(data []byte) (*core.AnalyticEvent, erro
 		DeviceType: d.info.DeviceType,
 		DeviceID:   d.info.DeviceID,
 	}
+	applyAudioAnalytic(evt, raw)
 
 	return evt, nil
 }
 
+// hikvisionFaceAttrs são os atributos demográficos do faceCapture, cada um
+// como {"value": ...} (age também traz ageGroup/ageDeviation).
+var hikvisionFaceAttrs = []string{"age", "gender", "glass", "mask", "smile", "hat", "beard", "faceExpression"}
+
+// parseFace monta a entrada de Meta["faces"]: rect normalizado (x/y/w/h em
+// 0..1, como a câmera manda), score e atributos presentes.
+func (d *HikvisionDriver) parseFace(f map[string]interface{}) map[string]interface{} {
+	face := map[string]interface{}{}
+	if id := getNumber(f, "faceId"); id != nil {
+		face["id"] = id
+	}
+	if sc, ok := firstFloat(f, d.fields.Score); ok {
+		face["score"] = sc
+	}
+	if raw, ok := firstValue(f, d.fields.Geometry); ok {
+		if r, ok := raw.(map[string]interface{}); ok {
+			rect := map[string]interface{}{}
+			for key, names := range map[string][]string{
+				"x": {"x"},
+				"y": {"y"},
+				"w": {"width", "w"},
+				"h": {"height", "h"},
+			} {
+				if v, ok := firstFloat(r, names); ok {
+					rect[key] = v
+				}
+			}
+			if len(rect) == 4 {
+				face["rect"] = rect
+			}
+		}
+	}
+	for _, attr := range hikvisionFaceAttrs {
+		raw, ok := f[attr]
+		if !ok || raw == nil {
+			continue
+		}
+		obj, isObj := raw.(map[string]interface{})
+		if !isObj {
+			face[attr] = raw
+			continue
+		}
+		if v, ok := obj["value"]; ok {
+			face[attr] = v
+		}
+		if attr == "age" {
+			if g := getString(obj, "ageGroup"); g != "" {
+				face["ageGroup"] = g
+			}
+			if dev := getNumber(obj, "ageDeviation"); dev != nil {
+				face["ageDeviation"] = dev
+			}
+		}
+	}
+	return face
+}
+
 func (d *HikvisionDriver) parseXMLEvent(data []byte) (*core.AnalyticEvent, error) {
 	type EventNotificationAlert struct {
 		XMLName          xml.Name `xml:"EventNotificationAlert"`
@@ -609,6 +750,7 @@ func (d *HikvisionDriver) parseXMLEvent(data []byte) (*core.AnalyticEvent, error
 		t, err := time.Parse(time.RFC3339, alert.DateTime)
 		if err == nil {
 			ts = t.UTC()
+			meta["camera_ts"] = ts.Format(time.RFC3339Nano)
 		}
 	}
 	if ts.IsZero() {
@@ -617,10 +759,11 @@ func (d *HikvisionDriver) parseXMLEvent(data []byte) (*core.AnalyticEvent, error
 
 	evt := &core.AnalyticEvent{
 		Timestamp:    ts,
-		EventID:      fmt.Sprintf("xml-%d", ts.UnixNano()),
+		EventID:      eventid.New("xml", ts),
 		CameraIP:     d.info.IP,
 		CameraName:   d.info.Name,
 		AnalyticType: analytic,
+		EventState:   core.NormalizeEventState(getString(meta, "eventState")),
 		Meta:         meta,
 
 		Tenant:     d.info.Tenant,
@@ -629,6 +772,7 @@ func (d *HikvisionDriver) parseXMLEvent(data []byte) (*core.AnalyticEvent, error
 		DeviceType: d.info.DeviceType,
 		DeviceID:   d.info.DeviceID,
 	}
+	applyAudioAnalytic(evt, nil)
 
 	return evt, nil
 }
@@ -637,36 +781,9 @@ func (d *HikvisionDriver) parseXMLEvent(data []byte) (*core.AnalyticEvent, error
 // Helpers diversos
 // ----------------------------------
 
-func (d *HikvisionDriver) buildSnapshotKey(evt *core.AnalyticEvent) string {
-	ts := evt.Timestamp
-	if ts.IsZero() {
-		ts = time.Now().UTC()
-	}
-
-	tenant := safePath(d.info.Tenant, "default")
-	building := safePath(d.info.Building, "building")
-	floor := safePath(d.info.Floor, "floor")
-	dtype := safePath(d.info.DeviceType, "device")
-	did := safePath(d.info.DeviceID, "id")
-	analytic := safePath(evt.AnalyticType, "analytic")
-
-	return fmt.Sprintf(
-		"%s/%s/%s/%s/%s/%s/%04d/%02d/%02d/%s_%d.jpg",
-		tenant, building, floor, dtype, did, analytic,
-		ts.Year(), ts.Month(), ts.Day(),
-		evt.EventID, ts.UnixNano(),
-	)
-}
-
 func (d *HikvisionDriver) buildJSONEventID(raw map[string]interface{}) string {
-	// tenta alguns campos; se não tiver, gera um ID pseudo-único
-	if v, ok := raw["uid"].(string); ok && v != "" {
-		return v
-	}
-	if v, ok := raw["eventID"].(string); ok && v != "" {
-		return v
-	}
-	return fmt.Sprintf("json-%d", time.Now().UnixNano())
+	// tenta o id da câmera; sem ele, gera conforme EVENT_ID_STRATEGY
+	return eventid.Resolve(getString(raw, "uid", "eventID"), "json", time.Now())
 }
 
 func getString(m map[string]interface{}, keys ...string) string {
@@ -680,9 +797,24 @@ func getString(m map[string]interface{}, keys ...string) string {
 	return ""
 }
 
+// decodeJSON decodifica com UseNumber: ids grandes e scores mantêm precisão
+// (e não viram notação científica ao republicar).
+func decodeJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
 func getNumber(m map[string]interface{}, key string) interface{} {
 	if v, ok := m[key]; ok {
 		switch x := v.(type) {
+		case json.Number:
+			if i, err := x.Int64(); err == nil {
+				return i
+			}
+			if f, err := x.Float64(); err == nil {
+				return f
+			}
 		case float64:
 			return x
 		case int:
@@ -0,0 +1,109 @@
+package drivers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func newTestHikvision(t *testing.T, info core.CameraInfo) *HikvisionDriver {
+	t.Helper()
+	info.Manufacturer = "hikvision"
+	drv, err := NewHikvisionDriver(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return drv.(*HikvisionDriver)
+}
+
+func TestHikvisionFaceCaptureFixture(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "hikvision", "face_capture.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := newTestHikvision(t, core.CameraInfo{IP: "192.168.1.64", DeviceID: "c1"})
+
+	evt, err := d.parseJSONEvent(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evt.AnalyticType != "faceCapture" || evt.Meta["camera_ts"] != "2024-03-01T15:00:00Z" {
+		t.Fatalf("evento = %+v", evt)
+	}
+
+	// compatibilidade: facesCount/bestScore/bestFaceRect continuam lá
+	if evt.Meta["facesCount"] != 3 || evt.Meta["bestScore"] != 91.0 {
+		t.Fatalf("facesCount/bestScore = %v/%v", evt.Meta["facesCount"], evt.Meta["bestScore"])
+	}
+	// bestFaceRect é o faceRect cru (json.Number), como sempre foi
+	if best, _ := evt.Meta["bestFaceRect"].(map[string]interface{}); best["x"] != json.Number("0.712") {
+		t.Fatalf("bestFaceRect = %v", evt.Meta["bestFaceRect"])
+	}
+
+	faces, ok := evt.Meta["faces"].([]map[string]interface{})
+	if !ok || len(faces) != 3 {
+		t.Fatalf("Meta[faces] = %#v", evt.Meta["faces"])
+	}
+	want := []map[string]interface{}{
+		{
+			"id":    int64(101),
+			"score": 87.0,
+			"rect":  map[string]interface{}{"x": 0.405, "y": 0.281, "w": 0.074, "h": 0.132},
+			"age":   json.Number("33"), "ageGroup": "prime", "ageDeviation": int64(5),
+			"gender": "male", "glass": "no", "mask": "yes", "smile": "no",
+		},
+		{
+			"id":     int64(102),
+			"score":  91.0,
+			"rect":   map[string]interface{}{"x": 0.712, "y": 0.402, "w": 0.055, "h": 0.098},
+			"gender": "female",
+		},
+		// rect incompleto (sem width/height) fica de fora
+		{"id": int64(103), "score": 40.0},
+	}
+	for i := range want {
+		if !reflect.DeepEqual(faces[i], want[i]) {
+			t.Errorf("face %d = %v\nesperava %v", i, faces[i], want[i])
+		}
+	}
+}
+
+func TestHikvisionSubscribePerChannel(t *testing.T) {
+	type subscribe struct {
+		Events []struct {
+			Type     string `xml:"type"`
+			Channels int    `xml:"channels"`
+		} `xml:"EventList>Event"`
+	}
+	events := func(info core.CameraInfo) []string {
+		t.Helper()
+		var sub subscribe
+		raw := newTestHikvision(t, info).buildSubscribeEventXML()
+		if err := xml.Unmarshal(raw, &sub); err != nil {
+			t.Fatalf("XML inválido: %v\n%s", err, raw)
+		}
+		out := make([]string, len(sub.Events))
+		for i, e := range sub.Events {
+			out[i] = fmt.Sprintf("%s@%d", e.Type, e.Channels)
+		}
+		return out
+	}
+
+	nvr := core.CameraInfo{IP: "10.0.0.1", DeviceID: "nvr1", Analytics: []string{"faceCapture", "linedetection"}, Channels: []int{3, 1, 3, 0, -2, 5}}
+	want := []string{"faceCapture@3", "faceCapture@1", "faceCapture@5", "linedetection@3", "linedetection@1", "linedetection@5"}
+	if got := events(nvr); !reflect.DeepEqual(got, want) {
+		t.Fatalf("eventos do NVR = %v, esperava %v", got, want)
+	}
+
+	// câmera avulsa (sem channels): só o canal 1
+	cam := core.CameraInfo{IP: "10.0.0.2", DeviceID: "c1", Analytics: []string{"faceCapture"}}
+	if got := events(cam); !reflect.DeepEqual(got, []string{"faceCapture@1"}) {
+		t.Fatalf("eventos da câmera = %v", got)
+	}
+}
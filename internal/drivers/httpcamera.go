@@ -0,0 +1,121 @@
+// internal/drivers/httpcamera.go
+package drivers
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/storage"
+)
+
+// httpCamera é a parte comum dos drivers que falam HTTP com a câmera
+// (Hikvision, Dahua, Axis, ONVIF, poll): cliente com RTT, Digest/sessão,
+// status e chave/gravação do snapshot.
+type httpCamera struct {
+	info          core.CameraInfo
+	client        *http.Client
+	statusHandler func(StatusUpdate)
+	rtt           rttTracker
+	session       *sessionAuth // auth_mode=session; nil = Digest
+	nonces        digestNonces // nc do Digest por nonce do servidor
+}
+
+// SetStatusHandler registra callback para mudanças de estado da câmera.
+func (c *httpCamera) SetStatusHandler(fn func(StatusUpdate)) {
+	c.statusHandler = fn
+}
+
+func (c *httpCamera) notifyStatus(update StatusUpdate) {
+	if c.statusHandler != nil {
+		c.statusHandler(update)
+	}
+}
+
+// LastRTT retorna o RTT da última requisição HTTP bem-sucedida à câmera.
+func (c *httpCamera) LastRTT() time.Duration {
+	last, _ := c.rtt.values()
+	return last
+}
+
+// AverageRTT retorna a média móvel do RTT das requisições à câmera.
+func (c *httpCamera) AverageRTT() time.Duration {
+	_, avg := c.rtt.values()
+	return avg
+}
+
+// do executa a requisição medindo o RTT até a chegada dos headers.
+func (c *httpCamera) do(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	if err == nil {
+		c.rtt.observe(time.Since(start))
+	}
+	return resp, err
+}
+
+// doDigest faz a requisição com a autenticação da câmera: sessão
+// (auth_mode=session) ou Digest.
+func (c *httpCamera) doDigest(
+	ctx context.Context,
+	method, rawURL string,
+	body io.Reader,
+	contentType string,
+) (*http.Response, error) {
+	if c.session != nil {
+		return c.session.request(ctx, c.do, method, rawURL, body, contentType)
+	}
+	return digestRequest(ctx, c.do, &c.nonces, c.info.Username, c.info.Password, method, rawURL, body, contentType)
+}
+
+// buildSnapshotKey gera a chave do snapshot no MinIO:
+// tenant/building/floor/device_type/device_id/analytic/AAAA/MM/DD/<event>_<ns>.jpg
+func (c *httpCamera) buildSnapshotKey(evt *core.AnalyticEvent) string {
+	ts := evt.Timestamp
+	if ts.IsZero() {
+		ts = time.Now().UTC()
+	}
+
+	return fmt.Sprintf(
+		"%s/%s/%s/%s/%s/%s/%04d/%02d/%02d/%s_%d.jpg",
+		safePath(c.info.Tenant, "default"),
+		safePath(c.info.Building, "building"),
+		safePath(c.info.Floor, "floor"),
+		safePath(c.info.DeviceType, "device"),
+		safePath(c.info.DeviceID, "id"),
+		safePath(evt.AnalyticType, "analytic"),
+		ts.Year(), ts.Month(), ts.Day(),
+		evt.EventID, ts.UnixNano(),
+	)
+}
+
+// attachSnapshot valida e processa img, salva conforme SNAPSHOT_STORE_POLICY
+// (adiado para o publisher ou no driver, com miniatura) e preenche
+// SnapshotB64. tag é o prefixo dos logs ("dahua", "poll", ...).
+func (c *httpCamera) attachSnapshot(ctx context.Context, tag string, evt *core.AnalyticEvent, img []byte, ct string) {
+	img = validSnapshot(tag, c.info.IP, img)
+	if len(img) == 0 {
+		return
+	}
+	img, ct = storage.ProcessSnapshot(img, ct)
+	if storage.DefaultPolicy().Deferred() {
+		evt.RawSnapshot = img
+		evt.SnapshotKey = c.buildSnapshotKey(evt)
+		evt.SnapshotContentType = ct
+	} else if store := storage.StoreFor(c.info.StorageProfile); store != nil && storage.DefaultPolicy().StoreInDriver() {
+		ctxUp, cancelUp := context.WithTimeout(ctx, 5*time.Second)
+		url, thumbURL, err := storage.SaveWithThumbnail(ctxUp, store, c.buildSnapshotKey(evt), img, ct)
+		cancelUp()
+		if err != nil {
+			eventLog(c.info, evt).Errorf("[%s] erro ao salvar snapshot no MinIO: %v", tag, err)
+		} else {
+			evt.SnapshotURL = url
+			evt.ThumbnailURL = thumbURL
+		}
+	}
+	evt.SnapshotB64 = base64.StdEncoding.EncodeToString(img)
+}
@@ -0,0 +1,31 @@
+package drivers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func TestBuildSnapshotKey(t *testing.T) {
+	c := httpCamera{info: core.CameraInfo{Tenant: "acme", Floor: "3", DeviceID: "cam-1"}}
+	ts := time.Date(2024, 5, 7, 10, 0, 0, 42, time.UTC)
+	evt := &core.AnalyticEvent{Timestamp: ts, EventID: "evt1", AnalyticType: "faceCapture"}
+
+	want := "acme/building/3/device/cam-1/facecapture/2024/05/07/evt1_1715076000000000042.jpg"
+	if got := c.buildSnapshotKey(evt); got != want {
+		t.Fatalf("buildSnapshotKey = %q, esperava %q", got, want)
+	}
+}
+
+func TestHTTPCameraNotifyStatus(t *testing.T) {
+	var c httpCamera
+	c.notifyStatus(StatusUpdate{State: ConnectionStateOnline}) // sem handler: no-op
+
+	var got []ConnectionState
+	c.SetStatusHandler(func(u StatusUpdate) { got = append(got, u.State) })
+	c.notifyStatus(StatusUpdate{State: ConnectionStateOffline})
+	if len(got) != 1 || got[0] != ConnectionStateOffline {
+		t.Fatalf("handler recebeu %v", got)
+	}
+}
@@ -0,0 +1,24 @@
+// internal/drivers/logfields.go
+package drivers
+
+import (
+	"fmt"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/logging"
+)
+
+// cameraLog devolve o logger com os campos da câmera; a chave é a mesma do
+// supervisor (tenant|building|floor|type|id), para cruzar as linhas no JSON.
+func cameraLog(info core.CameraInfo) logging.Entry {
+	return logging.With(logging.Camera(logKey(info), info.Tenant))
+}
+
+// eventLog é o cameraLog com analytic e event_id do evento.
+func eventLog(info core.CameraInfo, evt *core.AnalyticEvent) logging.Entry {
+	return logging.With(logging.Event(logKey(info), info.Tenant, evt.AnalyticType, evt.EventID))
+}
+
+func logKey(info core.CameraInfo) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%s", info.Tenant, info.Building, info.Floor, info.DeviceType, info.DeviceID)
+}
@@ -0,0 +1,147 @@
+// internal/drivers/mock.go
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"image/jpeg"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/envconf"
+	"github.com/sua-org/cam-bus/internal/eventid"
+)
+
+const (
+	defaultMockInterval = time.Second
+	defaultMockAnalytic = "faceCapture"
+)
+
+// MockDriver gera eventos sintéticos, sem câmera nem rede, para exercitar o
+// pipeline supervisor/engines de ponta a ponta (manufacturer "mock" no /info).
+//
+// O analytic é o 1º de CameraInfo.Analytics ou MOCK_ANALYTIC (default
+// faceCapture); o intervalo vem de MOCK_EVENT_INTERVAL_MS (default 1000) e
+// MOCK_SNAPSHOT=true anexa um JPEG pequeno gerado em memória.
+type MockDriver struct {
+	info          core.CameraInfo
+	analytic      string
+	interval      time.Duration
+	snapshot      bool
+	statusHandler func(StatusUpdate)
+}
+
+func NewMockDriver(info core.CameraInfo) (*MockDriver, error) {
+	d := &MockDriver{
+		info:     info,
+		analytic: strings.TrimSpace(os.Getenv("MOCK_ANALYTIC")),
+		interval: time.Duration(envconf.PositiveInt("MOCK_EVENT_INTERVAL_MS", int(defaultMockInterval/time.Millisecond))) * time.Millisecond,
+		snapshot: envconf.Bool("MOCK_SNAPSHOT", false),
+	}
+	for _, a := range info.Analytics {
+		if a = strings.TrimSpace(a); a != "" {
+			d.analytic = a
+			break
+		}
+	}
+	if d.analytic == "" {
+		d.analytic = defaultMockAnalytic
+	}
+	return d, nil
+}
+
+func init() {
+	RegisterDriver("mock", "any", func(info core.CameraInfo) (CameraDriver, error) {
+		return NewMockDriver(info)
+	})
+}
+
+// SetStatusHandler registra callback para mudanças de status de conexão.
+func (d *MockDriver) SetStatusHandler(fn func(StatusUpdate)) {
+	d.statusHandler = fn
+}
+
+// ActiveAnalytics retorna o analytic gerado pelo mock.
+func (d *MockDriver) ActiveAnalytics() []string {
+	return []string{d.analytic}
+}
+
+func (d *MockDriver) notifyStatus(update StatusUpdate) {
+	if d.statusHandler != nil {
+		d.statusHandler(update)
+	}
+}
+
+// Run emite um evento a cada intervalo até o ctx ser cancelado.
+func (d *MockDriver) Run(ctx context.Context, events chan<- core.AnalyticEvent) error {
+	log.Printf("[mock] gerando %s a cada %s para %s", d.analytic, d.interval, d.info.DeviceID)
+	d.notifyStatus(StatusUpdate{State: ConnectionStateOnline, Reason: "mock"})
+	defer d.notifyStatus(StatusUpdate{State: ConnectionStateOffline, Reason: "mock encerrado"})
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for seq := 1; ; seq++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		select {
+		case events <- d.event(seq, time.Now().UTC()):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (d *MockDriver) event(seq int, ts time.Time) core.AnalyticEvent {
+	evt := core.AnalyticEvent{
+		Timestamp:    ts,
+		EventID:      eventid.New("mock", ts),
+		CameraIP:     d.info.IP,
+		CameraName:   d.info.Name,
+		AnalyticType: d.analytic,
+		EventState:   core.EventStateActive,
+		Meta: map[string]interface{}{
+			"mock":      true,
+			"seq":       seq,
+			"channelID": defaultSnapshotChannel,
+		},
+
+		Tenant:     d.info.Tenant,
+		Building:   d.info.Building,
+		Floor:      d.info.Floor,
+		DeviceType: d.info.DeviceType,
+		DeviceID:   d.info.DeviceID,
+	}
+	if d.snapshot {
+		evt.SnapshotB64 = base64.StdEncoding.EncodeToString(mockSnapshotJPEG())
+	}
+	return evt
+}
+
+var (
+	mockJPEGOnce sync.Once
+	mockJPEG     []byte
+)
+
+// mockSnapshotJPEG é um JPEG 64x48 cinza, gerado uma vez.
+func mockSnapshotJPEG() []byte {
+	mockJPEGOnce.Do(func() {
+		img := image.NewGray(image.Rect(0, 0, 64, 48))
+		for i := range img.Pix {
+			img.Pix[i] = 0x80
+		}
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, nil); err == nil {
+			mockJPEG = buf.Bytes()
+		}
+	})
+	return mockJPEG
+}
@@ -0,0 +1,86 @@
+package drivers
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/storage"
+)
+
+func TestMockDriverFromEnv(t *testing.T) {
+	t.Setenv("MOCK_ANALYTIC", "VideoMotion")
+	cases := []struct {
+		interval, snapshot string
+		analytics          []string
+		wantInterval       time.Duration
+		wantSnapshot       bool
+		wantAnalytic       string
+	}{
+		{"", "", nil, defaultMockInterval, false, "VideoMotion"},
+		{"250", "on", []string{" ", "faceCapture"}, 250 * time.Millisecond, true, "faceCapture"},
+		{"0", "talvez", nil, defaultMockInterval, false, "VideoMotion"},
+		{"abc", "1", nil, defaultMockInterval, true, "VideoMotion"},
+	}
+	for _, tc := range cases {
+		t.Setenv("MOCK_EVENT_INTERVAL_MS", tc.interval)
+		t.Setenv("MOCK_SNAPSHOT", tc.snapshot)
+		d, err := NewMockDriver(core.CameraInfo{Analytics: tc.analytics})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.interval != tc.wantInterval || d.snapshot != tc.wantSnapshot || d.analytic != tc.wantAnalytic {
+			t.Errorf("MOCK_EVENT_INTERVAL_MS=%q MOCK_SNAPSHOT=%q: interval=%s snapshot=%t analytic=%q",
+				tc.interval, tc.snapshot, d.interval, d.snapshot, d.analytic)
+		}
+	}
+}
+
+func TestMockDriverRunTicksUntilCancel(t *testing.T) {
+	t.Setenv("MOCK_ANALYTIC", "")
+	t.Setenv("MOCK_EVENT_INTERVAL_MS", "10")
+	t.Setenv("MOCK_SNAPSHOT", "true")
+	d, err := NewMockDriver(core.CameraInfo{Tenant: "t", DeviceID: "mock1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var states []ConnectionState
+	d.SetStatusHandler(func(u StatusUpdate) { states = append(states, u.State) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan core.AnalyticEvent)
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx, events) }()
+
+	for want := 1; want <= 3; want++ {
+		select {
+		case evt := <-events:
+			if evt.AnalyticType != defaultMockAnalytic || evt.DeviceID != "mock1" || evt.Tenant != "t" || evt.Meta["seq"] != want {
+				t.Fatalf("evento %d = %+v", want, evt)
+			}
+			img, _ := base64.StdEncoding.DecodeString(evt.SnapshotB64)
+			if err := storage.ValidateSnapshot(img); err != nil || len(img) == 0 {
+				t.Fatalf("snapshot do mock inválido: %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("evento %d não chegou", want)
+		}
+	}
+
+	// cancelado com o envio pendente (ninguém lendo events): Run sai mesmo assim
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run não saiu após o cancelamento")
+	}
+	if len(states) != 2 || states[0] != ConnectionStateOnline || states[1] != ConnectionStateOffline {
+		t.Fatalf("status = %v, esperava online e offline", states)
+	}
+}
@@ -0,0 +1,98 @@
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+// memStore guarda as chaves salvas e devolve uma URL fake.
+type memStore struct{ keys []string }
+
+func (m *memStore) SaveSnapshot(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	m.keys = append(m.keys, key)
+	return "http://minio/" + key, nil
+}
+
+func TestSplitPrimaryImagePicksLargest(t *testing.T) {
+	images := []snapshotImage{
+		{data: []byte("face1"), contentType: "image/jpeg"},
+		{data: []byte("cena completa"), contentType: "image/jpeg"},
+		{data: []byte("face2"), contentType: "image/jpeg"},
+	}
+	primary, extras := splitPrimaryImage(images)
+	if string(primary.data) != "cena completa" {
+		t.Fatalf("principal = %q", primary.data)
+	}
+	if len(extras) != 2 || string(extras[0].data) != "face1" || string(extras[1].data) != "face2" {
+		t.Fatalf("recortes = %v", extras)
+	}
+}
+
+func TestStoreExtraSnapshots(t *testing.T) {
+	store := &memStore{}
+	extras := []snapshotImage{{data: []byte("a"), contentType: "image/jpeg"}, {data: []byte("b"), contentType: "image/jpeg"}}
+
+	meta := storeExtraSnapshots(context.Background(), store, "t/cam/evt_1.jpg", "http://minio/t/cam/evt_1.jpg", extras, nil)
+
+	wantThumbs := []string{"http://minio/t/cam/evt_1_thumb_1.jpg", "http://minio/t/cam/evt_1_thumb_2.jpg"}
+	if !reflect.DeepEqual(meta["face_thumb_urls"], wantThumbs) {
+		t.Fatalf("face_thumb_urls = %v", meta["face_thumb_urls"])
+	}
+	all := append([]string{"http://minio/t/cam/evt_1.jpg"}, wantThumbs...)
+	if !reflect.DeepEqual(meta["snapshot_urls"], all) {
+		t.Fatalf("snapshot_urls = %v", meta["snapshot_urls"])
+	}
+}
+
+// TestHikvisionCollectsImagePartsPerEvent simula o alertStream: um evento JSON
+// seguido de duas partes image/* (cena + recorte) vira um evento só.
+func TestHikvisionCollectsImagePartsPerEvent(t *testing.T) {
+	t.Setenv("HIK_SNAPSHOT_COLLECT_WINDOW", "50ms")
+	scene := bytes.Repeat([]byte("S"), 64)
+	face := []byte("face")
+
+	info := cameraServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		part := func(ct string, data []byte) {
+			p, _ := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {ct}})
+			p.Write(data)
+		}
+		part("application/json", []byte(`{"eventType":"faceCapture","eventState":"active","channelID":1,"dateTime":"2024-05-01T10:00:00Z"}`))
+		part("image/jpeg", face)
+		part("image/jpeg", scene)
+		mw.Close()
+
+		w.Header().Set("Content-Type", fmt.Sprintf("multipart/mixed; boundary=%s", mw.Boundary()))
+		w.Write(buf.Bytes())
+	})
+	info.Manufacturer = "hikvision"
+	drv, err := NewHikvisionDriver(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := make(chan core.AnalyticEvent, 4)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = drv.(*HikvisionDriver).runOnce(ctx, events)
+
+	if len(events) != 1 {
+		t.Fatalf("%d eventos, esperava 1 com as duas imagens", len(events))
+	}
+	evt := <-events
+	img, _ := base64.StdEncoding.DecodeString(evt.SnapshotB64)
+	if !bytes.Equal(img, scene) {
+		t.Fatalf("snapshot principal = %q, esperava a cena (maior imagem)", img)
+	}
+}
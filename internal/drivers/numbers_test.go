@@ -0,0 +1,22 @@
+package drivers
+
+import "testing"
+
+func TestDecodeJSONPreservesNumbers(t *testing.T) {
+	var raw map[string]interface{}
+	if err := decodeJSON([]byte(`{"big": 9007199254740993, "score": 0.875, "ch": 2}`), &raw); err != nil {
+		t.Fatal(err)
+	}
+	if got := getNumber(raw, "big"); got != int64(9007199254740993) {
+		t.Fatalf("inteiro grande = %v (%T)", got, got)
+	}
+	if got := getNumber(raw, "score"); got != 0.875 {
+		t.Fatalf("score fracionário = %v (%T)", got, got)
+	}
+	if got, ok := firstFloat(raw, []string{"faceScore", "score"}); !ok || got != 0.875 {
+		t.Fatalf("firstFloat = %v, %t", got, ok)
+	}
+	if got := getNumber(raw, "ch"); got != int64(2) {
+		t.Fatalf("canal = %v (%T)", got, got)
+	}
+}
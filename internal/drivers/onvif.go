@@ -0,0 +1,552 @@
+// internal/drivers/onvif.go
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/eventid"
+	"github.com/sua-org/cam-bus/internal/logthrottle"
+	"github.com/sua-org/cam-bus/internal/netproxy"
+	"github.com/sua-org/cam-bus/internal/tracing"
+)
+
+const (
+	onvifDeviceServicePath = "/onvif/device_service"
+	onvifMessageLimit      = 20
+	// a assinatura PullPoint é criada/renovada com pelo menos esse prazo
+	onvifMinTermination = 60 * time.Second
+)
+
+// OnvifDriver recebe eventos de câmeras genéricas via ONVIF PullPoint
+// (CreatePullPointSubscription + PullMessages) e mapeia os tópicos conhecidos
+// (core.OnvifEventTopics) para AnalyticEvent.
+type OnvifDriver struct {
+	httpCamera
+	heartbeat time.Duration     // timeout do PullMessages (DRIVER_SUBSCRIBE_HEARTBEAT)
+	backoff   *reconnectBackoff // espera entre reconexões (CAMERA_RECONNECT_*)
+}
+
+func NewOnvifDriver(info core.CameraInfo) (CameraDriver, error) {
+	return newOnvifDriver(info)
+}
+
+func newOnvifDriver(info core.CameraInfo) (*OnvifDriver, error) {
+	var httpClient *http.Client
+
+	if info.UseTLS {
+		tlsCfg, err := cameraTLSConfig(info)
+		if err != nil {
+			return nil, err
+		}
+		httpClient = &http.Client{Transport: netproxy.Transport(tlsCfg)}
+		if info.CertFingerprint != "" {
+			log.Printf("[onvif] TLS com certificado fixado habilitado para %s (%s)", info.Name, info.IP)
+		} else {
+			log.Printf("[onvif] TLS inseguro habilitado para %s (%s)", info.Name, info.IP)
+		}
+	} else {
+		httpClient = netproxy.Client(0)
+	}
+
+	session, err := newSessionAuth(info)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OnvifDriver{
+		httpCamera: httpCamera{info: info, client: httpClient, session: session},
+		heartbeat:  subscribeHeartbeat(info, defaultOnvifHeartbeat),
+		backoff:    newReconnectBackoff(),
+	}, nil
+}
+
+func init() {
+	// fabricante "onvif", qualquer modelo
+	RegisterDriver("onvif", "any", func(info core.CameraInfo) (CameraDriver, error) {
+		return NewOnvifDriver(info)
+	})
+}
+
+// ActiveAnalytics retorna a lista efetiva de analytics filtrados pelo driver.
+func (d *OnvifDriver) ActiveAnalytics() []string {
+	return d.selectedAnalytics()
+}
+
+// selectedAnalytics aplica info.Analytics como filtro de tópicos, com as
+// mesmas regras do Dahua: "ALL"/"*" = todos; nada válido = fallback
+// ONVIF_FALLBACK_ANALYTICS (default MotionDetection).
+func (d *OnvifDriver) selectedAnalytics() []string {
+	sel := onvifAnalytics(d.info.Analytics)
+	for _, name := range sel.Unsupported {
+		log.Printf("[onvif] camera %s: analytics '%s' não é suportado, ignorando", d.info.DeviceID, name)
+	}
+	if sel.Fallback && len(sel.Selected) > 0 {
+		log.Printf("[onvif] camera %s: nenhum analytics válido no /info, usando fallback %s",
+			d.info.DeviceID, strings.Join(sel.Selected, ","))
+	}
+	return sel.Selected
+}
+
+// onvifAnalytics são as regras de selectedAnalytics.
+func onvifAnalytics(analytics []string) AnalyticsSelection {
+	sel := filterAnalytics(analytics, onvifAnalyticName, core.OnvifEventTypes)
+	if len(sel.Selected) == 0 {
+		sel.Fallback, sel.FallbackEnv = true, "ONVIF_FALLBACK_ANALYTICS"
+		sel.Selected = fallbackAnalytics(sel.FallbackEnv, []string{"MotionDetection"}, core.OnvifEventTypeSet)
+		for i, name := range sel.Selected {
+			sel.Selected[i], _ = onvifAnalyticName(name)
+		}
+	}
+	return sel
+}
+
+// onvifAnalyticName devolve o nome canônico (chave de OnvifEventTopics).
+func onvifAnalyticName(name string) (string, bool) {
+	for canonical := range core.OnvifEventTopics {
+		if strings.EqualFold(canonical, name) {
+			return canonical, true
+		}
+	}
+	return "", false
+}
+
+func (d *OnvifDriver) Run(ctx context.Context, events chan<- core.AnalyticEvent) error {
+	log.Printf("[onvif] starting driver for %s (%s)", d.info.Name, d.info.IP)
+
+	if len(d.selectedAnalytics()) == 0 {
+		return waitMisconfigured(ctx, d.notifyStatus, "nenhum analytics válido no /info (fallback ONVIF_FALLBACK_ANALYTICS vazio)")
+	}
+
+	return runReconnecting(ctx, d.backoff, "onvif", d.info, d.notifyStatus, func(ctx context.Context) error {
+		return d.runOnce(ctx, events)
+	})
+}
+
+func (d *OnvifDriver) runOnce(ctx context.Context, events chan<- core.AnalyticEvent) error {
+	analytics := d.selectedAnalytics()
+	d.notifyStatus(StatusUpdate{State: ConnectionStateConnecting, Reason: "consultando capabilities"})
+
+	var caps onvifCapabilitiesResponse
+	if err := d.soapCall(ctx, d.baseURL()+onvifDeviceServicePath, onvifActionGetCapabilities,
+		`<tds:GetCapabilities><tds:Category>All</tds:Category></tds:GetCapabilities>`, &caps); err != nil {
+		d.notifyStatus(StatusUpdate{State: ConnectionStateNotEstablished, Reason: err.Error()})
+		return fmt.Errorf("GetCapabilities: %w", err)
+	}
+	eventsURL := d.rebaseURL(strings.TrimSpace(caps.Events.XAddr))
+	if eventsURL == "" {
+		d.notifyStatus(StatusUpdate{State: ConnectionStateNotEstablished, Reason: "câmera sem serviço de eventos ONVIF"})
+		return fmt.Errorf("câmera não anunciou o serviço de eventos")
+	}
+	snapshotURL := d.snapshotURI(ctx, d.rebaseURL(strings.TrimSpace(caps.Media.XAddr)))
+
+	termination := max(onvifMinTermination, 4*d.heartbeat)
+	var sub onvifSubscriptionResponse
+	if err := d.soapCall(ctx, eventsURL, onvifActionCreatePullPoint, fmt.Sprintf(
+		`<tev:CreatePullPointSubscription><tev:InitialTerminationTime>PT%dS</tev:InitialTerminationTime></tev:CreatePullPointSubscription>`,
+		int(termination/time.Second)), &sub); err != nil {
+		d.notifyStatus(StatusUpdate{State: ConnectionStateNotEstablished, Reason: err.Error()})
+		return fmt.Errorf("CreatePullPointSubscription: %w", err)
+	}
+	subURL := d.rebaseURL(strings.TrimSpace(sub.Address))
+	if subURL == "" {
+		d.notifyStatus(StatusUpdate{State: ConnectionStateNotEstablished, Reason: "assinatura sem endereço"})
+		return fmt.Errorf("CreatePullPointSubscription sem SubscriptionReference")
+	}
+	defer d.unsubscribe(subURL)
+
+	d.notifyStatus(StatusUpdate{
+		State:  ConnectionStateOnline,
+		Reason: fmt.Sprintf("pullpoint [%s]", strings.Join(analytics, ",")),
+	})
+	log.Printf("[onvif] PullPoint criado para %s (%s): %s", d.info.Name, d.info.IP, subURL)
+
+	renewAt := time.Now().Add(termination / 2)
+	pullBody := fmt.Sprintf(
+		`<tev:PullMessages><tev:Timeout>PT%dS</tev:Timeout><tev:MessageLimit>%d</tev:MessageLimit></tev:PullMessages>`,
+		heartbeatSeconds(d.heartbeat), onvifMessageLimit)
+
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if time.Now().After(renewAt) {
+			if err := d.soapCall(ctx, subURL, onvifActionRenew, fmt.Sprintf(
+				`<wsnt:Renew><wsnt:TerminationTime>PT%dS</wsnt:TerminationTime></wsnt:Renew>`,
+				int(termination/time.Second)), nil); err != nil {
+				d.notifyStatus(StatusUpdate{State: ConnectionStateOffline, Reason: err.Error()})
+				return fmt.Errorf("Renew: %w", err)
+			}
+			renewAt = time.Now().Add(termination / 2)
+		}
+
+		// o PullMessages segura até heartbeat; sem resposta bem depois disso, a
+		// câmera travou (mesmo papel do watchdog dos streams multipart)
+		pullCtx, cancel := context.WithTimeout(ctx, d.heartbeat+max(watchdogTimeout(d.heartbeat), 10*time.Second))
+		var msgs onvifPullMessagesResponse
+		err := d.soapCall(pullCtx, subURL, onvifActionPullMessages, pullBody, &msgs)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			d.notifyStatus(StatusUpdate{State: ConnectionStateOffline, Reason: err.Error()})
+			return fmt.Errorf("PullMessages: %w", err)
+		}
+
+		for _, n := range msgs.Notifications {
+			evt := d.parseNotification(n, analytics)
+			if evt == nil {
+				continue
+			}
+			d.emit(ctx, events, evt, snapshotURL)
+			if ctx.Err() != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// parseNotification converte uma NotificationMessage em AnalyticEvent; nil
+// quando o tópico não está no filtro, é o estado inicial (Initialized) ou a
+// notificação é de fim (IsMotion=false etc.), como o Dahua com action!=Start.
+func (d *OnvifDriver) parseNotification(n onvifNotification, analytics []string) *core.AnalyticEvent {
+	topic := onvifTopicPath(n.Topic)
+	analytic := ""
+	for _, name := range analytics {
+		if prefix := core.OnvifEventTopics[name]; prefix != "" && strings.HasPrefix(strings.ToLower(topic), strings.ToLower(prefix)) {
+			analytic = name
+			break
+		}
+	}
+	if analytic == "" {
+		return nil
+	}
+	if strings.EqualFold(n.Message.PropertyOperation, "Initialized") {
+		return nil
+	}
+
+	source := simpleItemsMap(n.Message.Source)
+	data := simpleItemsMap(n.Message.Data)
+	state := core.EventStateActive
+	for _, item := range n.Message.Data {
+		if v := strings.ToLower(strings.TrimSpace(item.Value)); v == "true" || v == "false" {
+			if v == "false" {
+				state = core.EventStateInactive
+			}
+			break
+		}
+	}
+	if state != core.EventStateActive {
+		return nil
+	}
+
+	ts := time.Now().UTC()
+	meta := map[string]interface{}{
+		"topic":              topic,
+		"property_operation": n.Message.PropertyOperation,
+		"source":             source,
+		"data":               data,
+	}
+	if n.Message.UtcTime != "" {
+		meta["utc_time"] = n.Message.UtcTime
+	}
+
+	return &core.AnalyticEvent{
+		Timestamp:    ts,
+		EventID:      eventid.Resolve("", "onvif", ts),
+		CameraIP:     d.info.IP,
+		CameraName:   d.info.Name,
+		AnalyticType: analytic,
+		EventState:   state,
+		Meta:         meta,
+
+		Tenant:     d.info.Tenant,
+		Building:   d.info.Building,
+		Floor:      d.info.Floor,
+		DeviceType: d.info.DeviceType,
+		DeviceID:   d.info.DeviceID,
+	}
+}
+
+// emit baixa o snapshot do perfil de mídia, salva como o Dahua e envia o evento.
+func (d *OnvifDriver) emit(ctx context.Context, events chan<- core.AnalyticEvent, evt *core.AnalyticEvent, snapshotURL string) {
+	evtCtx, span := tracing.Start(ctx, "driver.receive")
+	span.SetAttr("camera.id", d.info.DeviceID)
+	span.SetAttr("analytic.type", evt.AnalyticType)
+	span.SetAttr("event.id", evt.EventID)
+
+	if snapshotURL != "" {
+		img, ct, err := fetchImage(evtCtx, d.doDigest, snapshotURL)
+		if err != nil {
+			logthrottle.Printf("onvif:snapshot:"+d.info.IP, "[onvif] erro ao buscar snapshot: %v", err)
+		}
+		d.attachSnapshot(evtCtx, "onvif", evt, img, ct)
+	}
+	evt.Meta = tracing.Inject(evtCtx, evt.Meta)
+	span.End()
+
+	select {
+	case events <- *evt:
+	case <-ctx.Done():
+	}
+}
+
+// discoverSnapshotURL consulta as capabilities só para achar o snapshot do
+// perfil de mídia (usado quando os eventos não vêm do PullPoint).
+func (d *OnvifDriver) discoverSnapshotURL(ctx context.Context) string {
+	var caps onvifCapabilitiesResponse
+	if err := d.soapCall(ctx, d.baseURL()+onvifDeviceServicePath, onvifActionGetCapabilities,
+		`<tds:GetCapabilities><tds:Category>Media</tds:Category></tds:GetCapabilities>`, &caps); err != nil {
+		log.Printf("[onvif] %s (%s): GetCapabilities falhou (%v), eventos sem snapshot", d.info.Name, d.info.IP, err)
+		return ""
+	}
+	return d.snapshotURI(ctx, d.rebaseURL(strings.TrimSpace(caps.Media.XAddr)))
+}
+
+// snapshotURI pega o GetSnapshotUri do primeiro perfil de mídia. Falha não é
+// fatal: os eventos seguem sem imagem.
+func (d *OnvifDriver) snapshotURI(ctx context.Context, mediaURL string) string {
+	if mediaURL == "" {
+		log.Printf("[onvif] %s (%s): câmera sem serviço de mídia, eventos sem snapshot", d.info.Name, d.info.IP)
+		return ""
+	}
+	var profiles onvifProfilesResponse
+	if err := d.soapCall(ctx, mediaURL, onvifActionGetProfiles, `<trt:GetProfiles/>`, &profiles); err != nil || len(profiles.Profiles) == 0 {
+		log.Printf("[onvif] %s (%s): GetProfiles falhou (%v), eventos sem snapshot", d.info.Name, d.info.IP, err)
+		return ""
+	}
+	var token bytes.Buffer
+	_ = xml.EscapeText(&token, []byte(profiles.Profiles[0].Token))
+	var snap onvifSnapshotURIResponse
+	if err := d.soapCall(ctx, mediaURL, onvifActionGetSnapshotURI, fmt.Sprintf(
+		`<trt:GetSnapshotUri><trt:ProfileToken>%s</trt:ProfileToken></trt:GetSnapshotUri>`, token.String()), &snap); err != nil {
+		log.Printf("[onvif] %s (%s): GetSnapshotUri falhou (%v), eventos sem snapshot", d.info.Name, d.info.IP, err)
+		return ""
+	}
+	return d.rebaseURL(strings.TrimSpace(snap.URI))
+}
+
+func (d *OnvifDriver) unsubscribe(subURL string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := d.soapCall(ctx, subURL, onvifActionUnsubscribe, `<wsnt:Unsubscribe/>`, nil); err != nil {
+		logthrottle.Printf("onvif:unsubscribe:"+d.info.IP, "[onvif] erro no Unsubscribe de %s: %v", d.info.Name, err)
+	}
+}
+
+func (d *OnvifDriver) baseURL() string {
+	scheme := "http"
+	if d.info.UseTLS {
+		scheme = "https"
+	}
+	host := d.info.IP
+	if d.info.Port != 0 {
+		host = fmt.Sprintf("%s:%d", host, d.info.Port)
+	}
+	return scheme + "://" + host
+}
+
+// rebaseURL troca o host dos XAddrs/URIs anunciados pela câmera pelo IP do
+// /info (atrás de NAT a câmera anuncia o IP interno), mantendo porta e path.
+func (d *OnvifDriver) rebaseURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	if port := u.Port(); port != "" {
+		u.Host = net.JoinHostPort(d.info.IP, port)
+	} else {
+		u.Host = d.info.IP
+	}
+	return u.String()
+}
+
+// ----------------------------------
+// SOAP
+// ----------------------------------
+
+const (
+	onvifActionGetCapabilities = "http://www.onvif.org/ver10/device/wsdl/GetCapabilities"
+	onvifActionGetProfiles     = "http://www.onvif.org/ver10/media/wsdl/GetProfiles"
+	onvifActionGetSnapshotURI  = "http://www.onvif.org/ver10/media/wsdl/GetSnapshotUri"
+	onvifActionCreatePullPoint = "http://www.onvif.org/ver10/events/wsdl/EventPortType/CreatePullPointSubscriptionRequest"
+	onvifActionPullMessages    = "http://www.onvif.org/ver10/events/wsdl/PullPointSubscription/PullMessagesRequest"
+	onvifActionRenew           = "http://docs.oasis-open.org/wsn/bw-2/SubscriptionManager/RenewRequest"
+	onvifActionUnsubscribe     = "http://docs.oasis-open.org/wsn/bw-2/SubscriptionManager/UnsubscribeRequest"
+)
+
+const onvifEnvelopeFmt = `<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"` +
+	` xmlns:wsa="http://www.w3.org/2005/08/addressing"` +
+	` xmlns:tds="http://www.onvif.org/ver10/device/wsdl"` +
+	` xmlns:trt="http://www.onvif.org/ver10/media/wsdl"` +
+	` xmlns:tev="http://www.onvif.org/ver10/events/wsdl"` +
+	` xmlns:wsnt="http://docs.oasis-open.org/wsn/b-2">` +
+	`<s:Header>%s<wsa:Action>%s</wsa:Action><wsa:To>%s</wsa:To></s:Header>` +
+	`<s:Body>%s</s:Body></s:Envelope>`
+
+// soapCall faz a chamada SOAP 1.2 com WS-Security UsernameToken e, se a
+// câmera responder 401, HTTP Digest (digestRequest). out recebe o primeiro
+// elemento do Body; nil ignora a resposta.
+func (d *OnvifDriver) soapCall(ctx context.Context, endpoint, action, body string, out interface{}) error {
+	var to bytes.Buffer
+	_ = xml.EscapeText(&to, []byte(endpoint))
+	envelope := fmt.Sprintf(onvifEnvelopeFmt, d.wsSecurityHeader(), action, to.String(), body)
+
+	resp, err := d.doDigest(ctx, http.MethodPost, endpoint, bytes.NewReader([]byte(envelope)),
+		fmt.Sprintf(`application/soap+xml; charset=utf-8; action="%s"`, action))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var env onvifEnvelope
+	parseErr := xml.Unmarshal(data, &env)
+	if parseErr == nil && env.Body.Fault != nil {
+		return fmt.Errorf("SOAP fault (%d): %s", resp.StatusCode, env.Body.Fault.String())
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if parseErr != nil {
+		return fmt.Errorf("resposta SOAP inválida: %w", parseErr)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := xml.Unmarshal(env.Body.Content, out); err != nil {
+		return fmt.Errorf("resposta SOAP inválida: %w", err)
+	}
+	return nil
+}
+
+// wsSecurityHeader monta o UsernameToken com PasswordDigest
+// (Base64(SHA1(nonce + created + senha))), exigido pela maioria das câmeras.
+func (d *OnvifDriver) wsSecurityHeader() string {
+	if d.info.Username == "" {
+		return ""
+	}
+	nonce := []byte(randomHex(16))
+	created := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	h := sha1.New()
+	h.Write(nonce)
+	h.Write([]byte(created))
+	h.Write([]byte(d.info.Password))
+	digest := base64.StdEncoding.EncodeToString(h.Sum(nil))
+
+	var user bytes.Buffer
+	_ = xml.EscapeText(&user, []byte(d.info.Username))
+	return `<Security s:mustUnderstand="1" xmlns="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd">` +
+		`<UsernameToken><Username>` + user.String() + `</Username>` +
+		`<Password Type="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0#PasswordDigest">` + digest + `</Password>` +
+		`<Nonce EncodingType="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-soap-message-security-1.0#Base64Binary">` +
+		base64.StdEncoding.EncodeToString(nonce) + `</Nonce>` +
+		`<Created xmlns="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd">` + created + `</Created>` +
+		`</UsernameToken></Security>`
+}
+
+// Respostas: encoding/xml casa pelo nome local quando a tag não tem namespace,
+// então os prefixos (tds:, tt:, wsnt:...) variam à vontade entre fabricantes.
+type onvifEnvelope struct {
+	Body struct {
+		Fault   *onvifFault `xml:"Fault"`
+		Content []byte      `xml:",innerxml"`
+	} `xml:"Body"`
+}
+
+type onvifFault struct {
+	Code    string `xml:"Code>Value"`
+	Subcode string `xml:"Code>Subcode>Value"`
+	Reason  string `xml:"Reason>Text"`
+}
+
+func (f *onvifFault) String() string {
+	parts := []string{}
+	for _, p := range []string{f.Code, f.Subcode, f.Reason} {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, " / ")
+}
+
+type onvifCapabilitiesResponse struct {
+	Events struct {
+		XAddr string `xml:"XAddr"`
+	} `xml:"Capabilities>Events"`
+	Media struct {
+		XAddr string `xml:"XAddr"`
+	} `xml:"Capabilities>Media"`
+}
+
+type onvifProfilesResponse struct {
+	Profiles []struct {
+		Token string `xml:"token,attr"`
+	} `xml:"Profiles"`
+}
+
+type onvifSnapshotURIResponse struct {
+	URI string `xml:"MediaUri>Uri"`
+}
+
+type onvifSubscriptionResponse struct {
+	Address string `xml:"SubscriptionReference>Address"`
+}
+
+type onvifPullMessagesResponse struct {
+	Notifications []onvifNotification `xml:"NotificationMessage"`
+}
+
+type onvifNotification struct {
+	Topic   string `xml:"Topic"`
+	Message struct {
+		UtcTime           string            `xml:"UtcTime,attr"`
+		PropertyOperation string            `xml:"PropertyOperation,attr"`
+		Source            []onvifSimpleItem `xml:"Source>SimpleItem"`
+		Data              []onvifSimpleItem `xml:"Data>SimpleItem"`
+	} `xml:"Message>Message"`
+}
+
+type onvifSimpleItem struct {
+	Name  string `xml:"Name,attr"`
+	Value string `xml:"Value,attr"`
+}
+
+func simpleItemsMap(items []onvifSimpleItem) map[string]interface{} {
+	if len(items) == 0 {
+		return nil
+	}
+	m := make(map[string]interface{}, len(items))
+	for _, it := range items {
+		m[it.Name] = it.Value
+	}
+	return m
+}
+
+var onvifTopicNSRx = regexp.MustCompile(`[\w.-]+:`)
+
+// onvifTopicPath tira os prefixos de namespace: "tns1:RuleEngine/tnsaxis:X" -> "RuleEngine/X".
+func onvifTopicPath(topic string) string {
+	return onvifTopicNSRx.ReplaceAllString(strings.TrimSpace(topic), "")
+}
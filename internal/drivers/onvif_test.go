@@ -0,0 +1,251 @@
+package drivers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func onvifNotificationXML(topic, operation string, data ...string) string {
+	items := ""
+	for i := 0; i+1 < len(data); i += 2 {
+		items += fmt.Sprintf(`<tt:SimpleItem Name="%s" Value="%s"/>`, data[i], data[i+1])
+	}
+	return `<wsnt:NotificationMessage>` +
+		`<wsnt:Topic Dialect="http://www.onvif.org/ver10/tev/topicExpression/ConcreteSet">` + topic + `</wsnt:Topic>` +
+		`<wsnt:Message><tt:Message UtcTime="2024-03-01T12:00:00Z" PropertyOperation="` + operation + `">` +
+		`<tt:Source><tt:SimpleItem Name="VideoSourceConfigurationToken" Value="vsc1"/></tt:Source>` +
+		`<tt:Data>` + items + `</tt:Data>` +
+		`</tt:Message></wsnt:Message></wsnt:NotificationMessage>`
+}
+
+func onvifResponse(body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>` +
+		`<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope" xmlns:tds="http://www.onvif.org/ver10/device/wsdl"` +
+		` xmlns:tt="http://www.onvif.org/ver10/schema" xmlns:wsnt="http://docs.oasis-open.org/wsn/b-2" xmlns:tev="http://www.onvif.org/ver10/events/wsdl">` +
+		`<env:Body>` + body + `</env:Body></env:Envelope>`
+}
+
+func newTestOnvif(t *testing.T, info core.CameraInfo) *OnvifDriver {
+	t.Helper()
+	info.Manufacturer = "onvif"
+	d, err := newOnvifDriver(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestOnvifParseNotificationTopics(t *testing.T) {
+	d := newTestOnvif(t, core.CameraInfo{DeviceID: "cam1", IP: "10.0.0.9", Tenant: "t"})
+	analytics := []string{"FaceDetection", "MotionDetection"}
+
+	cases := []struct {
+		name string
+		xml  string
+		want string // "" = sem evento
+	}{
+		{"face", onvifNotificationXML("tns1:RuleEngine/FaceDetector/Face", "Changed", "IsFace", "true"), "FaceDetection"},
+		{"motion", onvifNotificationXML("tns1:VideoAnalytics/MotionDetection", "Changed", "State", "true"), "MotionDetection"},
+		{"prefixo de outro fabricante", onvifNotificationXML("tns1:VideoAnalytics/tnsaxis:MotionDetection", "Changed", "State", "true"), "MotionDetection"},
+		{"fim do motion", onvifNotificationXML("tns1:VideoAnalytics/MotionDetection", "Changed", "State", "false"), ""},
+		{"estado inicial", onvifNotificationXML("tns1:RuleEngine/FaceDetector/Face", "Initialized", "IsFace", "true"), ""},
+		{"fora do filtro", onvifNotificationXML("tns1:RuleEngine/LineDetector/Crossed", "Changed", "State", "true"), ""},
+		{"tópico desconhecido", onvifNotificationXML("tns1:Device/Trigger/DigitalInput", "Changed", "LogicalState", "true"), ""},
+	}
+	for _, tc := range cases {
+		var n onvifNotification
+		if err := xml.Unmarshal([]byte(tc.xml), &n); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		evt := d.parseNotification(n, analytics)
+		if tc.want == "" {
+			if evt != nil {
+				t.Errorf("%s: evento %+v, esperava nil", tc.name, evt)
+			}
+			continue
+		}
+		if evt == nil {
+			t.Errorf("%s: sem evento, esperava %s", tc.name, tc.want)
+			continue
+		}
+		if evt.AnalyticType != tc.want || evt.EventState != core.EventStateActive || evt.DeviceID != "cam1" || evt.Tenant != "t" {
+			t.Errorf("%s: evento %+v", tc.name, evt)
+		}
+		source, _ := evt.Meta["source"].(map[string]interface{})
+		if source["VideoSourceConfigurationToken"] != "vsc1" || evt.Meta["utc_time"] != "2024-03-01T12:00:00Z" {
+			t.Errorf("%s: Meta = %v", tc.name, evt.Meta)
+		}
+	}
+}
+
+func TestOnvifAnalyticsFilter(t *testing.T) {
+	sel := onvifAnalytics([]string{"facedetection", "VideoMotion", "MOTIONDETECTION"})
+	if strings.Join(sel.Selected, ",") != "FaceDetection,MotionDetection" || sel.Fallback {
+		t.Fatalf("seleção = %+v", sel)
+	}
+	if len(sel.Unsupported) != 1 || sel.Unsupported[0] != "VideoMotion" {
+		t.Fatalf("não suportados = %v", sel.Unsupported)
+	}
+
+	t.Setenv("ONVIF_FALLBACK_ANALYTICS", "facedetection")
+	if sel := onvifAnalytics(nil); !sel.Fallback || strings.Join(sel.Selected, ",") != "FaceDetection" {
+		t.Fatalf("fallback = %+v, esperava o nome canônico", sel)
+	}
+	t.Setenv("ONVIF_FALLBACK_ANALYTICS", "none")
+	if sel := onvifAnalytics([]string{"VideoMotion"}); len(sel.Selected) != 0 {
+		t.Fatalf("fallback none = %+v", sel)
+	}
+}
+
+// onvifCamera é um dispositivo ONVIF mínimo: anuncia os serviços com um IP
+// "interno" (o driver precisa trocar pelo do /info), entrega uma notificação
+// de face no primeiro PullMessages e anota as actions recebidas.
+type onvifCamera struct {
+	mu      sync.Mutex
+	actions []string
+	bodies  []string
+	pulls   int
+}
+
+func (c *onvifCamera) handler(t *testing.T, port *string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/snap.jpg" {
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write([]byte("jpeg"))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		ct := r.Header.Get("Content-Type")
+		action := ct[strings.Index(ct, `action="`)+len(`action="`) : len(ct)-1]
+
+		c.mu.Lock()
+		c.actions = append(c.actions, action[strings.LastIndex(action, "/")+1:])
+		c.bodies = append(c.bodies, string(body))
+		if action == onvifActionPullMessages {
+			c.pulls++
+		}
+		pulls := c.pulls
+		c.mu.Unlock()
+
+		internal := "http://192.168.1.64:" + *port
+		w.Header().Set("Content-Type", "application/soap+xml")
+		switch action {
+		case onvifActionGetCapabilities:
+			io.WriteString(w, onvifResponse(`<tds:GetCapabilitiesResponse><tds:Capabilities>`+
+				`<tt:Events><tt:XAddr>`+internal+`/onvif/events</tt:XAddr></tt:Events>`+
+				`<tt:Media><tt:XAddr>`+internal+`/onvif/media</tt:XAddr></tt:Media>`+
+				`</tds:Capabilities></tds:GetCapabilitiesResponse>`))
+		case onvifActionGetProfiles:
+			io.WriteString(w, onvifResponse(`<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"><trt:Profiles token="perfil_1"/></trt:GetProfilesResponse>`))
+		case onvifActionGetSnapshotURI:
+			io.WriteString(w, onvifResponse(`<trt:GetSnapshotUriResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"><trt:MediaUri><tt:Uri>`+internal+`/snap.jpg</tt:Uri></trt:MediaUri></trt:GetSnapshotUriResponse>`))
+		case onvifActionCreatePullPoint:
+			io.WriteString(w, onvifResponse(`<tev:CreatePullPointSubscriptionResponse><tev:SubscriptionReference>`+
+				`<wsa5:Address xmlns:wsa5="http://www.w3.org/2005/08/addressing">`+internal+`/onvif/subscription?id=7</wsa5:Address>`+
+				`</tev:SubscriptionReference></tev:CreatePullPointSubscriptionResponse>`))
+		case onvifActionPullMessages:
+			msgs := ""
+			if pulls == 1 {
+				msgs = onvifNotificationXML("tns1:VideoAnalytics/MotionDetection", "Changed", "State", "false") +
+					onvifNotificationXML("tns1:RuleEngine/FaceDetector/Face", "Changed", "IsFace", "true")
+			} else {
+				time.Sleep(20 * time.Millisecond)
+			}
+			io.WriteString(w, onvifResponse(`<tev:PullMessagesResponse><tev:CurrentTime>2024-03-01T12:00:00Z</tev:CurrentTime>`+msgs+`</tev:PullMessagesResponse>`))
+		case onvifActionUnsubscribe:
+			io.WriteString(w, onvifResponse(`<wsnt:UnsubscribeResponse/>`))
+		default:
+			t.Errorf("action inesperada %q", action)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}
+}
+
+func (c *onvifCamera) calls() ([]string, []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.actions...), append([]string(nil), c.bodies...)
+}
+
+func TestOnvifPullPointAgainstServer(t *testing.T) {
+	cam := &onvifCamera{}
+	var port string
+	info := cameraServer(t, cam.handler(t, &port))
+	port = fmt.Sprint(info.Port)
+	info.Username, info.Password = "admin", "segredo"
+	info.Analytics = []string{"FaceDetection", "MotionDetection"}
+	d := newTestOnvif(t, info)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan core.AnalyticEvent, 4)
+	done := make(chan error, 1)
+	go func() { done <- d.runOnce(ctx, events) }()
+
+	var evt core.AnalyticEvent
+	select {
+	case evt = <-events:
+	case <-time.After(3 * time.Second):
+		t.Fatal("nenhum evento do PullPoint")
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("runOnce = %v", err)
+	}
+
+	if evt.AnalyticType != "FaceDetection" || evt.CameraIP != info.IP {
+		t.Fatalf("evento = %+v", evt)
+	}
+	if img, _ := base64.StdEncoding.DecodeString(evt.SnapshotB64); string(img) != "jpeg" {
+		t.Fatalf("snapshot do perfil de mídia não anexado: %q", evt.SnapshotB64)
+	}
+	select {
+	case extra := <-events:
+		t.Fatalf("o fim do motion não deveria virar evento: %+v", extra)
+	default:
+	}
+
+	actions, bodies := cam.calls()
+	want := []string{"GetCapabilities", "GetProfiles", "GetSnapshotUri", "CreatePullPointSubscriptionRequest", "PullMessagesRequest"}
+	if len(actions) < len(want)+1 || strings.Join(actions[:len(want)], ",") != strings.Join(want, ",") {
+		t.Fatalf("actions = %v", actions)
+	}
+	if actions[len(actions)-1] != "UnsubscribeRequest" {
+		t.Fatalf("a assinatura deveria ser removida ao sair: %v", actions)
+	}
+	if !strings.Contains(bodies[2], "<trt:ProfileToken>perfil_1</trt:ProfileToken>") {
+		t.Fatalf("GetSnapshotUri sem o token do perfil: %s", bodies[2])
+	}
+	if !strings.Contains(bodies[0], "<Username>admin</Username>") || strings.Contains(bodies[0], "segredo") {
+		t.Fatalf("WS-Security deveria levar o usuário e só o digest da senha: %s", bodies[0])
+	}
+	if !strings.Contains(bodies[4], "<tev:MessageLimit>20</tev:MessageLimit>") ||
+		!strings.Contains(bodies[4], "<wsa:To>http://"+info.IP+":"+port+"/onvif/subscription?id=7</wsa:To>") {
+		t.Fatalf("PullMessages deveria ir ao endereço da assinatura com o IP do /info: %s", bodies[4])
+	}
+}
+
+func TestOnvifSOAPFault(t *testing.T) {
+	info := cameraServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, onvifResponse(`<env:Fault><env:Code><env:Value>env:Sender</env:Value>`+
+			`<env:Subcode><env:Value>ter:NotAuthorized</env:Value></env:Subcode></env:Code>`+
+			`<env:Reason><env:Text xml:lang="en">Sender not authorized</env:Text></env:Reason></env:Fault>`))
+	})
+	d := newTestOnvif(t, info)
+
+	err := d.runOnce(context.Background(), make(chan core.AnalyticEvent))
+	if err == nil || !strings.Contains(err.Error(), "GetCapabilities") ||
+		!strings.Contains(err.Error(), "env:Sender / ter:NotAuthorized / Sender not authorized") {
+		t.Fatalf("runOnce = %v, esperava o SOAP fault", err)
+	}
+}
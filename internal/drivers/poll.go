@@ -0,0 +1,385 @@
+// internal/drivers/poll.go
+package drivers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/eventid"
+	"github.com/sua-org/cam-bus/internal/logthrottle"
+	"github.com/sua-org/cam-bus/internal/netproxy"
+	"github.com/sua-org/cam-bus/internal/tracing"
+)
+
+// Analytics sintéticos do event_transport=poll.
+const (
+	AnalyticPollMotion   = "motion"
+	AnalyticPollSnapshot = "snapshot"
+)
+
+const (
+	defaultPollInterval = 10 * time.Second
+	maxMotionBody       = 4096
+	defaultMotionKey    = "motion"
+)
+
+// PollDriver atende câmeras sem assinatura de eventos (event_transport=poll):
+// a cada intervalo consulta o CGI de movimento (motion_path) e/ou baixa o
+// snapshot, gerando eventos "motion" (início/fim, na mudança de estado) e
+// "snapshot" (um por consulta).
+type PollDriver struct {
+	httpCamera
+
+	interval    time.Duration
+	snapshotURL string // vazio = sem snapshot
+	motionURL   string // vazio = sem motion
+	motionKey   string // chave lida na resposta do motion (motion_key)
+	analytics   []string
+
+	motionActive bool
+}
+
+func NewPollDriver(info core.CameraInfo) (CameraDriver, error) {
+	httpClient := netproxy.Client(0)
+	if info.UseTLS {
+		tlsCfg, err := cameraTLSConfig(info)
+		if err != nil {
+			return nil, err
+		}
+		httpClient = &http.Client{Transport: netproxy.Transport(tlsCfg)}
+	}
+	session, err := newSessionAuth(info)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &PollDriver{
+		httpCamera:  httpCamera{info: info, client: httpClient, session: session},
+		interval:    pollInterval(info),
+		snapshotURL: pollSnapshotURL(info),
+		motionURL:   pollURL(info, info.MotionPath),
+		motionKey:   motionKey(info),
+	}
+	if d.snapshotURL == "" && d.motionURL == "" {
+		return nil, fmt.Errorf("event_transport=poll exige snapshot_path ou motion_path para %q", info.Manufacturer)
+	}
+	d.analytics = d.selectAnalytics()
+	return d, nil
+}
+
+// pollInterval: poll_interval_seconds da câmera, senão POLL_INTERVAL_SECONDS
+// (default 10).
+func pollInterval(info core.CameraInfo) time.Duration {
+	if info.PollIntervalSeconds > 0 {
+		return time.Duration(info.PollIntervalSeconds) * time.Second
+	}
+	raw := strings.TrimSpace(os.Getenv("POLL_INTERVAL_SECONDS"))
+	if raw == "" {
+		return defaultPollInterval
+	}
+	if sec, err := strconv.Atoi(raw); err == nil && sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	log.Printf("[poll] POLL_INTERVAL_SECONDS inválido (%q), usando %s", raw, defaultPollInterval)
+	return defaultPollInterval
+}
+
+func pollBaseURL(info core.CameraInfo) string {
+	scheme := "http"
+	if info.UseTLS {
+		scheme = "https"
+	}
+	host := info.IP
+	if info.Port != 0 {
+		host = fmt.Sprintf("%s:%d", info.IP, info.Port)
+	}
+	return scheme + "://" + host
+}
+
+// pollURL resolve um caminho do /info (snapshot_path, motion_path) contra a
+// câmera; URLs absolutas passam direto.
+func pollURL(info core.CameraInfo, path string) string {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return ""
+	}
+	if strings.Contains(path, "://") {
+		return path
+	}
+	return pollBaseURL(info) + "/" + strings.TrimLeft(path, "/")
+}
+
+// pollSnapshotURL usa snapshot_path ou, sem ele, o snapshot padrão dos
+// fabricantes conhecidos; "" quando não há como saber.
+func pollSnapshotURL(info core.CameraInfo) string {
+	if u := pollURL(info, info.SnapshotPath); u != "" {
+		return u
+	}
+	base := pollBaseURL(info)
+	switch normalize(info.Manufacturer) {
+	case "dahua":
+		scheme, host, _ := strings.Cut(base, "://")
+		return dahuaSnapshotURL(scheme, host, info.SnapshotChannel, info.SnapshotType)
+	case "hikvision":
+		return hikvisionPictureURL(base, info.SnapshotChannel)
+	case "axis":
+		channel := info.SnapshotChannel
+		if channel <= 0 {
+			channel = defaultSnapshotChannel
+		}
+		return fmt.Sprintf("%s/axis-cgi/jpg/image.cgi?camera=%d", base, channel)
+	}
+	return ""
+}
+
+// selectAnalytics filtra info.Analytics por motion/snapshot (pollAnalytics).
+func (d *PollDriver) selectAnalytics() []string {
+	sel := pollAnalytics(d.info.Analytics, d.motionURL != "", d.snapshotURL != "")
+	for _, name := range sel.Unsupported {
+		log.Printf("[poll] camera %s: analytics '%s' não é suportado (motion exige motion_path), ignorando", d.info.DeviceID, name)
+	}
+	return sel.Selected
+}
+
+// pollAnalytics: motion/snapshot ("ALL"/"*" = os dois); sem nada válido,
+// motion quando há motion_path, senão snapshot. Cada analytic só vale se a
+// URL correspondente existir.
+func pollAnalytics(analytics []string, hasMotion, hasSnapshot bool) AnalyticsSelection {
+	available := map[string]bool{
+		AnalyticPollMotion:   hasMotion,
+		AnalyticPollSnapshot: hasSnapshot,
+	}
+	var sel AnalyticsSelection
+	add := func(name string) {
+		for _, s := range sel.Selected {
+			if s == name {
+				return
+			}
+		}
+		sel.Selected = append(sel.Selected, name)
+	}
+	for _, a := range analytics {
+		name := strings.ToLower(strings.TrimSpace(a))
+		switch {
+		case name == "":
+		case name == "all" || name == "*":
+			sel.All = true
+			for _, n := range []string{AnalyticPollMotion, AnalyticPollSnapshot} {
+				if available[n] {
+					add(n)
+				}
+			}
+		case available[name]:
+			add(name)
+		default:
+			sel.Unsupported = append(sel.Unsupported, a)
+		}
+	}
+	if len(sel.Selected) == 0 {
+		sel.Fallback = true
+		if hasMotion {
+			sel.Selected = []string{AnalyticPollMotion}
+		} else {
+			sel.Selected = []string{AnalyticPollSnapshot}
+		}
+	}
+	return sel
+}
+
+// ActiveAnalytics retorna os analytics gerados pelo polling.
+func (d *PollDriver) ActiveAnalytics() []string {
+	return d.analytics
+}
+
+// Run consulta a câmera a cada intervalo (a 1ª consulta é imediata) até o ctx
+// ser cancelado. Falhas só mudam o status; o polling continua no mesmo ritmo.
+func (d *PollDriver) Run(ctx context.Context, events chan<- core.AnalyticEvent) error {
+	log.Printf("[poll] starting driver for %s (%s): %s a cada %s",
+		d.info.Name, d.info.IP, strings.Join(d.analytics, ","), d.interval)
+	d.notifyStatus(StatusUpdate{State: ConnectionStateConnecting, Reason: "iniciando polling"})
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	online := false
+	for {
+		err := d.pollOnce(ctx, events)
+		if ctx.Err() != nil {
+			return nil
+		}
+		switch {
+		case err != nil:
+			logthrottle.Printf("poll:run:"+d.info.IP, "[poll] erro em %s: %v", d.info.Name, err)
+			if online {
+				d.notifyStatus(StatusUpdate{State: ConnectionStateOffline, Reason: err.Error()})
+			} else {
+				d.notifyStatus(StatusUpdate{State: ConnectionStateNotEstablished, Reason: err.Error()})
+			}
+			online = false
+		case !online:
+			online = true
+			d.notifyStatus(StatusUpdate{
+				State:  ConnectionStateOnline,
+				Reason: fmt.Sprintf("polling [%s] a cada %s", strings.Join(d.analytics, ","), d.interval),
+			})
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollOnce faz uma rodada de consultas: o motion só gera evento quando o
+// estado muda; o snapshot gera um evento por rodada.
+func (d *PollDriver) pollOnce(ctx context.Context, events chan<- core.AnalyticEvent) error {
+	for _, analytic := range d.analytics {
+		switch analytic {
+		case AnalyticPollMotion:
+			active, err := d.fetchMotion(ctx)
+			if err != nil {
+				return fmt.Errorf("motion: %w", err)
+			}
+			if active == d.motionActive {
+				continue
+			}
+			d.motionActive = active
+			state := core.EventStateInactive
+			if active {
+				state = core.EventStateActive
+			}
+			d.emit(ctx, events, d.newEvent(AnalyticPollMotion, state), active)
+		case AnalyticPollSnapshot:
+			img, ct, err := fetchImage(ctx, d.doDigest, d.snapshotURL)
+			if err != nil {
+				return fmt.Errorf("snapshot: %w", err)
+			}
+			evt := d.newEvent(AnalyticPollSnapshot, core.EventStateActive)
+			d.attachSnapshot(ctx, "poll", evt, img, ct)
+			d.send(ctx, events, evt)
+		}
+	}
+	return nil
+}
+
+// fetchMotion lê o CGI de movimento e interpreta a resposta com parseMotionState.
+func (d *PollDriver) fetchMotion(ctx context.Context) (bool, error) {
+	ctxReq, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	resp, err := d.doDigest(ctxReq, http.MethodGet, d.motionURL, nil, "")
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxMotionBody))
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return parseMotionState(body, d.motionKey), nil
+}
+
+// parseMotionState lê a chave key (motion_key) numa resposta "chave=valor" ou
+// "chave: valor", uma por linha, sem diferenciar caixa; "root.motion" também
+// casa com "motion". Uma resposta de uma linha só, sem chave, vale como o
+// próprio valor. Há movimento se o valor for 1/true/on/yes/active.
+func parseMotionState(body []byte, key string) bool {
+	key = strings.ToLower(strings.TrimSpace(key))
+	var lines []string
+	for _, line := range strings.Split(string(body), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	for _, line := range lines {
+		name, value, found := strings.Cut(line, "=")
+		if !found {
+			name, value, found = strings.Cut(line, ":")
+		}
+		if !found {
+			if len(lines) == 1 && motionValue(line) {
+				return true
+			}
+			continue
+		}
+		name = strings.ToLower(strings.Trim(name, " \t\r\"',;{}"))
+		if name == key || strings.HasSuffix(name, "."+key) {
+			return motionValue(value)
+		}
+	}
+	return false
+}
+
+func motionValue(raw string) bool {
+	switch strings.ToLower(strings.Trim(raw, " \t\r\"',;{}")) {
+	case "1", "true", "on", "yes", "active":
+		return true
+	}
+	return false
+}
+
+// motionKey: motion_key do /info, senão defaultMotionKey.
+func motionKey(info core.CameraInfo) string {
+	if key := strings.TrimSpace(info.MotionKey); key != "" {
+		return key
+	}
+	return defaultMotionKey
+}
+
+func (d *PollDriver) newEvent(analytic, state string) *core.AnalyticEvent {
+	ts := time.Now().UTC()
+	return &core.AnalyticEvent{
+		Timestamp:    ts,
+		EventID:      eventid.New("poll", ts),
+		CameraIP:     d.info.IP,
+		CameraName:   d.info.Name,
+		AnalyticType: analytic,
+		EventState:   state,
+		Meta: map[string]interface{}{
+			"event_transport": EventTransportPoll,
+			"channelID":       defaultSnapshotChannel,
+		},
+
+		Tenant:     d.info.Tenant,
+		Building:   d.info.Building,
+		Floor:      d.info.Floor,
+		DeviceType: d.info.DeviceType,
+		DeviceID:   d.info.DeviceID,
+	}
+}
+
+// emit envia o evento de motion, com snapshot no início quando há snapshot_path.
+func (d *PollDriver) emit(ctx context.Context, events chan<- core.AnalyticEvent, evt *core.AnalyticEvent, withSnapshot bool) {
+	if withSnapshot && d.snapshotURL != "" {
+		img, ct, err := fetchImage(ctx, d.doDigest, d.snapshotURL)
+		if err != nil {
+			logthrottle.Printf("poll:snapshot:"+d.info.IP, "[poll] erro ao buscar snapshot: %v", err)
+		} else {
+			d.attachSnapshot(ctx, "poll", evt, img, ct)
+		}
+	}
+	d.send(ctx, events, evt)
+}
+
+func (d *PollDriver) send(ctx context.Context, events chan<- core.AnalyticEvent, evt *core.AnalyticEvent) {
+	evtCtx, span := tracing.Start(ctx, "driver.receive")
+	span.SetAttr("camera.id", d.info.DeviceID)
+	span.SetAttr("analytic.type", evt.AnalyticType)
+	span.SetAttr("event.id", evt.EventID)
+	evt.Meta = tracing.Inject(evtCtx, evt.Meta)
+	span.End()
+
+	select {
+	case events <- *evt:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,44 @@
+package drivers
+
+import (
+	"testing"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func TestParseMotionStateMatchesConfiguredKey(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		key  string
+		want bool
+	}{
+		{"chave=valor", "motion=1\n", "motion", true},
+		{"chave: valor", "Motion: true\r\n", "motion", true},
+		{"inativo", "motion=0\n", "motion", false},
+		{"outra chave ligada", "recording=1\nmotion=0\n", "motion", false},
+		{"só outra chave", "alarm_in=on\nrecording=1\n", "motion", false},
+		{"chave configurada", "md=0\nalarm=1\n", "alarm", true},
+		{"nome com pontos", "root.MotionDetect=yes\n", "motiondetect", true},
+		{"json", "{\"motion\": \"active\"}", "motion", true},
+		{"só o valor", "1\n", "motion", true},
+		{"valores soltos", "0\n1\n", "motion", false},
+		{"vazio", "", "motion", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseMotionState([]byte(tc.body), tc.key); got != tc.want {
+				t.Fatalf("parseMotionState(%q, %q) = %v, esperava %v", tc.body, tc.key, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMotionKeyDefault(t *testing.T) {
+	if got := motionKey(core.CameraInfo{}); got != defaultMotionKey {
+		t.Fatalf("motionKey sem motion_key = %q, esperava %q", got, defaultMotionKey)
+	}
+	if got := motionKey(core.CameraInfo{MotionKey: " md "}); got != "md" {
+		t.Fatalf("motionKey = %q, esperava md", got)
+	}
+}
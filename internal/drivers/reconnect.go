@@ -0,0 +1,111 @@
+// internal/drivers/reconnect.go
+package drivers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/envconf"
+	"github.com/sua-org/cam-bus/internal/logthrottle"
+)
+
+const (
+	defaultReconnectMin = 5 * time.Second
+	defaultReconnectMax = 5 * time.Minute
+
+	// conexão que durou pelo menos isso volta o backoff para o mínimo
+	reconnectStableAfter = time.Minute
+)
+
+// reconnectBackoff é o intervalo entre tentativas de reconexão de um driver:
+// começa em CAMERA_RECONNECT_MIN_SECONDS, dobra a cada falha até
+// CAMERA_RECONNECT_MAX_SECONDS (com jitter de ±20%) e volta ao mínimo depois
+// de uma conexão estável. Um por instância de driver.
+type reconnectBackoff struct {
+	min, max time.Duration
+	next     time.Duration
+
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) bool
+	jitter func() float64 // [0,1)
+}
+
+func newReconnectBackoff() *reconnectBackoff {
+	minWait := envconf.Seconds("CAMERA_RECONNECT_MIN_SECONDS", defaultReconnectMin)
+	maxWait := envconf.Seconds("CAMERA_RECONNECT_MAX_SECONDS", defaultReconnectMax)
+	if maxWait < minWait {
+		log.Printf("[drivers] CAMERA_RECONNECT_MAX_SECONDS menor que o mínimo, usando %s", minWait)
+		maxWait = minWait
+	}
+	return &reconnectBackoff{
+		min:    minWait,
+		max:    maxWait,
+		next:   minWait,
+		now:    time.Now,
+		sleep:  sleepContext,
+		jitter: rand.Float64,
+	}
+}
+
+// start marca o início de uma tentativa (runOnce).
+func (b *reconnectBackoff) start() time.Time {
+	return b.now()
+}
+
+// failed devolve quanto esperar depois de uma tentativa iniciada em started;
+// se ela ficou conectada por reconnectStableAfter, recomeça do mínimo.
+func (b *reconnectBackoff) failed(started time.Time) time.Duration {
+	if b.now().Sub(started) >= reconnectStableAfter {
+		b.next = b.min
+	}
+	wait := b.next
+	b.next = min(b.next*2, b.max)
+
+	// jitter de ±20% para câmeras do mesmo NVR não reconectarem juntas
+	wait += time.Duration((b.jitter()*0.4 - 0.2) * float64(wait))
+	return max(wait, 0)
+}
+
+// wait dorme d ou até o ctx acabar; false = ctx cancelado.
+func (b *reconnectBackoff) wait(ctx context.Context, d time.Duration) bool {
+	return b.sleep(ctx, d)
+}
+
+// runReconnecting é o laço de reconexão comum aos drivers: chama once até
+// ele voltar nil ou o ctx acabar; a cada erro loga (com throttle), espera o
+// backoff e avisa ConnectionStateConnecting com Reconnect. tag é o prefixo
+// dos logs ("axis", "onvif"...).
+func runReconnecting(ctx context.Context, b *reconnectBackoff, tag string, info core.CameraInfo, notify func(StatusUpdate), once func(context.Context) error) error {
+	for {
+		started := b.start()
+		err := once(ctx)
+		if err == nil || ctx.Err() != nil {
+			return nil
+		}
+		wait := b.failed(started)
+		logthrottle.Printf(tag+":run:"+info.IP, "[%s] error for %s: %v, retrying in %s", tag, info.Name, err, wait.Round(time.Second))
+		if !b.wait(ctx, wait) {
+			return nil
+		}
+		notify(StatusUpdate{
+			State:     ConnectionStateConnecting,
+			Reason:    fmt.Sprintf("reconectando após erro: %v", err),
+			Reconnect: true,
+		})
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,156 @@
+package drivers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+// fakeBackoff usa relógio manual e jitter fixo; sleep só avança o relógio e
+// guarda as esperas.
+func fakeBackoff(minWait, maxWait time.Duration, jitter float64) (*reconnectBackoff, *time.Time, *[]time.Duration) {
+	now := time.Unix(1700000000, 0)
+	var waits []time.Duration
+	b := &reconnectBackoff{
+		min:    minWait,
+		max:    maxWait,
+		next:   minWait,
+		now:    func() time.Time { return now },
+		jitter: func() float64 { return jitter },
+	}
+	b.sleep = func(ctx context.Context, d time.Duration) bool {
+		waits = append(waits, d)
+		now = now.Add(d)
+		return ctx.Err() == nil
+	}
+	return b, &now, &waits
+}
+
+func TestReconnectBackoffDoublesUpToMax(t *testing.T) {
+	b, _, _ := fakeBackoff(5*time.Second, 40*time.Second, 0.5) // 0.5 = sem jitter
+
+	want := []time.Duration{5, 10, 20, 40, 40, 40}
+	for i, w := range want {
+		if got := b.failed(b.start()); got != w*time.Second {
+			t.Fatalf("falha %d: espera %s, esperava %s", i+1, got, w*time.Second)
+		}
+	}
+}
+
+func TestReconnectBackoffJitterBounds(t *testing.T) {
+	for jitter, want := range map[float64]time.Duration{
+		0:     8 * time.Second,  // -20%
+		0.999: 12 * time.Second, // ~+20%
+	} {
+		b, _, _ := fakeBackoff(10*time.Second, time.Minute, jitter)
+		got := b.failed(b.start())
+		if diff := got - want; diff < -10*time.Millisecond || diff > 10*time.Millisecond {
+			t.Fatalf("jitter %v: espera %s, esperava ~%s", jitter, got, want)
+		}
+	}
+}
+
+func TestReconnectBackoffResetsAfterStableConnection(t *testing.T) {
+	b, now, _ := fakeBackoff(5*time.Second, 5*time.Minute, 0.5)
+
+	for i := 0; i < 3; i++ {
+		b.failed(b.start())
+	}
+	// conexão curta: continua dobrando
+	started := b.start()
+	*now = now.Add(reconnectStableAfter - time.Second)
+	if got := b.failed(started); got != 40*time.Second {
+		t.Fatalf("depois de conexão curta espera %s, esperava 40s", got)
+	}
+	// conexão estável: volta ao mínimo
+	started = b.start()
+	*now = now.Add(reconnectStableAfter)
+	if got := b.failed(started); got != 5*time.Second {
+		t.Fatalf("depois de conexão estável espera %s, esperava o mínimo", got)
+	}
+	if got := b.failed(b.start()); got != 10*time.Second {
+		t.Fatalf("depois do reset espera %s, esperava voltar a dobrar de 5s", got)
+	}
+}
+
+func TestReconnectBackoffFromEnv(t *testing.T) {
+	t.Setenv("CAMERA_RECONNECT_MIN_SECONDS", "2")
+	t.Setenv("CAMERA_RECONNECT_MAX_SECONDS", "30")
+	if b := newReconnectBackoff(); b.min != 2*time.Second || b.max != 30*time.Second || b.next != b.min {
+		t.Fatalf("backoff = min %s max %s next %s", b.min, b.max, b.next)
+	}
+
+	t.Setenv("CAMERA_RECONNECT_MIN_SECONDS", "abc")
+	t.Setenv("CAMERA_RECONNECT_MAX_SECONDS", "0")
+	if b := newReconnectBackoff(); b.min != defaultReconnectMin || b.max != defaultReconnectMax {
+		t.Fatalf("inválidos deveriam usar os defaults, veio min %s max %s", b.min, b.max)
+	}
+
+	t.Setenv("CAMERA_RECONNECT_MIN_SECONDS", "60")
+	t.Setenv("CAMERA_RECONNECT_MAX_SECONDS", "10")
+	if b := newReconnectBackoff(); b.max != time.Minute {
+		t.Fatalf("max menor que o mínimo deveria subir para o mínimo, veio %s", b.max)
+	}
+}
+
+func TestRunReconnectingRetriesUntilSuccess(t *testing.T) {
+	b, _, waits := fakeBackoff(time.Second, time.Minute, 0.5)
+	var updates []StatusUpdate
+	calls := 0
+
+	err := runReconnecting(context.Background(), b, "test", core.CameraInfo{Name: "cam1", IP: "10.0.0.1"},
+		func(u StatusUpdate) { updates = append(updates, u) },
+		func(context.Context) error {
+			calls++
+			if calls < 3 {
+				return errors.New("connection refused")
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Fatalf("once chamado %d vezes, esperava 3", calls)
+	}
+	if len(*waits) != 2 || (*waits)[0] != time.Second || (*waits)[1] != 2*time.Second {
+		t.Fatalf("esperas = %v, esperava [1s 2s]", *waits)
+	}
+	if len(updates) != 2 || updates[0].State != ConnectionStateConnecting || !updates[0].Reconnect {
+		t.Fatalf("status = %+v", updates)
+	}
+}
+
+func TestRunReconnectingStopsWithContext(t *testing.T) {
+	b, _, waits := fakeBackoff(time.Second, time.Minute, 0.5)
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+
+	err := runReconnecting(ctx, b, "test", core.CameraInfo{}, func(StatusUpdate) {}, func(context.Context) error {
+		calls++
+		if calls == 2 {
+			cancel() // erro causado pelo cancelamento não conta como falha
+		}
+		return errors.New("falhou")
+	})
+	if err != nil || calls != 2 || len(*waits) != 1 {
+		t.Fatalf("err=%v chamadas=%d esperas=%v", err, calls, *waits)
+	}
+
+	// cancelado durante a espera: sai sem tentar de novo
+	b, _, _ = fakeBackoff(time.Second, time.Minute, 0.5)
+	b.sleep = func(context.Context, time.Duration) bool { return false }
+	calls = 0
+	_ = runReconnecting(context.Background(), b, "test", core.CameraInfo{}, func(StatusUpdate) {
+		t.Fatal("não deveria avisar reconexão depois do ctx acabar")
+	}, func(context.Context) error {
+		calls++
+		return errors.New("falhou")
+	})
+	if calls != 1 {
+		t.Fatalf("chamadas = %d, esperava parar na espera", calls)
+	}
+}
@@ -0,0 +1,328 @@
+// internal/drivers/rtsp_client.go
+package drivers
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	rtspUserAgent      = "cam-bus"
+	rtspMaxFrame       = 1 << 20 // limite de um documento de metadata remontado
+	defaultRTSPTimeout = 60 * time.Second
+)
+
+// rtspClient é um cliente RTSP mínimo para ler uma trilha via TCP
+// interleaved (RTP/AVP/TCP): DESCRIBE, SETUP, PLAY e keepalive com
+// GET_PARAMETER. Autentica com Digest (digestAuthorization) ou Basic.
+type rtspClient struct {
+	conn      net.Conn
+	r         *bufio.Reader
+	url       string // sem credenciais
+	user      string
+	pass      string
+	challenge []string // WWW-Authenticate do último 401, reaproveitado nas próximas requisições
+	nonces    digestNonces
+
+	wmu     sync.Mutex
+	cseq    int
+	session string
+}
+
+type rtspResponse struct {
+	status int
+	reason string
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// dialRTSP conecta no servidor do rawURL. Credenciais no URL têm precedência
+// sobre user/pass; tlsCfg só é usado em rtsps://.
+func dialRTSP(ctx context.Context, rawURL, user, pass string, tlsCfg *tls.Config) (*rtspClient, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("rtsp_url inválido: %w", err)
+	}
+	if u.User != nil {
+		user = u.User.Username()
+		if p, ok := u.User.Password(); ok {
+			pass = p
+		}
+		u.User = nil
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		port := "554"
+		if u.Scheme == "rtsps" {
+			port = "322"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "rtsps" {
+		if tlsCfg == nil {
+			tlsCfg = &tls.Config{InsecureSkipVerify: true} // rede interna, como o HTTP das câmeras
+		}
+		cfg := tlsCfg.Clone()
+		if cfg.ServerName == "" {
+			cfg.ServerName = u.Hostname()
+		}
+		tconn := tls.Client(conn, cfg)
+		if err := tconn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tconn
+	}
+
+	return &rtspClient{
+		conn: conn,
+		r:    bufio.NewReaderSize(conn, 64*1024),
+		url:  u.String(),
+		user: user,
+		pass: pass,
+	}, nil
+}
+
+func (c *rtspClient) Close() error {
+	return c.conn.Close()
+}
+
+// Do envia a requisição e espera a resposta, refazendo uma vez com
+// Authorization no 401. Frames interleaved que cheguem antes são descartados.
+func (c *rtspClient) Do(method, uri string, header map[string]string) (*rtspResponse, error) {
+	for attempt := 0; ; attempt++ {
+		if err := c.write(method, uri, header); err != nil {
+			return nil, err
+		}
+		resp, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		if resp.status == 401 && attempt == 0 && c.user != "" {
+			c.challenge = resp.header.Values("Www-Authenticate")
+			continue
+		}
+		if resp.status != 200 {
+			return resp, fmt.Errorf("%s status %d %s", method, resp.status, resp.reason)
+		}
+		if s := resp.header.Get("Session"); s != "" {
+			c.session, _, _ = strings.Cut(s, ";")
+		}
+		return resp, nil
+	}
+}
+
+// KeepAlive manda GET_PARAMETER sem esperar a resposta (o loop de leitura a
+// descarta); pode ser chamado de outra goroutine.
+func (c *rtspClient) KeepAlive() error {
+	return c.write("GET_PARAMETER", c.url, nil)
+}
+
+func (c *rtspClient) write(method, uri string, header map[string]string) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	c.cseq++
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s RTSP/1.0\r\n", method, uri)
+	fmt.Fprintf(&b, "CSeq: %d\r\n", c.cseq)
+	fmt.Fprintf(&b, "User-Agent: %s\r\n", rtspUserAgent)
+	if auth := c.authorization(method, uri); auth != "" {
+		fmt.Fprintf(&b, "Authorization: %s\r\n", auth)
+	}
+	if c.session != "" {
+		fmt.Fprintf(&b, "Session: %s\r\n", c.session)
+	}
+	for k, v := range header {
+		fmt.Fprintf(&b, "%s: %s\r\n", k, v)
+	}
+	b.WriteString("\r\n")
+	_, err := io.WriteString(c.conn, b.String())
+	return err
+}
+
+func (c *rtspClient) authorization(method, uri string) string {
+	if len(c.challenge) == 0 {
+		return ""
+	}
+	for _, ch := range c.challenge {
+		if strings.HasPrefix(strings.ToLower(ch), "digest ") {
+			if auth, err := digestAuthorization(&c.nonces, ch, c.user, c.pass, method, uri); err == nil {
+				return auth
+			}
+		}
+	}
+	for _, ch := range c.challenge {
+		if strings.HasPrefix(strings.ToLower(ch), "basic") {
+			return "Basic " + base64.StdEncoding.EncodeToString([]byte(c.user+":"+c.pass))
+		}
+	}
+	return ""
+}
+
+// Next devolve o próximo frame interleaved (canal + payload) ou, se chegar
+// uma resposta RTSP (keepalive), resp != nil.
+func (c *rtspClient) Next() (channel int, payload []byte, resp *rtspResponse, err error) {
+	b, err := c.r.Peek(1)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if b[0] != '$' {
+		resp, err := c.readResponseOnly()
+		return 0, nil, resp, err
+	}
+	var hdr [4]byte
+	if _, err := io.ReadFull(c.r, hdr[:]); err != nil {
+		return 0, nil, nil, err
+	}
+	payload = make([]byte, binary.BigEndian.Uint16(hdr[2:]))
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return 0, nil, nil, err
+	}
+	return int(hdr[1]), payload, nil, nil
+}
+
+func (c *rtspClient) readResponse() (*rtspResponse, error) {
+	for {
+		_, _, resp, err := c.Next()
+		if err != nil {
+			return nil, err
+		}
+		if resp != nil {
+			return resp, nil
+		}
+	}
+}
+
+func (c *rtspClient) readResponseOnly() (*rtspResponse, error) {
+	tp := textproto.NewReader(c.r)
+	line, err := tp.ReadLine()
+	if err != nil {
+		return nil, err
+	}
+	proto, rest, _ := strings.Cut(line, " ")
+	if !strings.HasPrefix(proto, "RTSP/") {
+		return nil, fmt.Errorf("resposta RTSP inválida: %q", line)
+	}
+	code, reason, _ := strings.Cut(rest, " ")
+	status, err := strconv.Atoi(code)
+	if err != nil {
+		return nil, fmt.Errorf("status RTSP inválido: %q", line)
+	}
+	header, err := tp.ReadMIMEHeader()
+	if err != nil && header == nil {
+		return nil, err
+	}
+	resp := &rtspResponse{status: status, reason: reason, header: header}
+	if n, _ := strconv.Atoi(header.Get("Content-Length")); n > 0 {
+		resp.body = make([]byte, n)
+		if _, err := io.ReadFull(c.r, resp.body); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// sessionTimeout lê o timeout=N do header Session (default 60s).
+func sessionTimeout(resp *rtspResponse) time.Duration {
+	_, params, _ := strings.Cut(resp.header.Get("Session"), ";")
+	for _, p := range strings.Split(params, ";") {
+		k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+		if strings.EqualFold(k, "timeout") {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				return time.Duration(n) * time.Second
+			}
+		}
+	}
+	return defaultRTSPTimeout
+}
+
+// sdpMetadataControl acha a trilha application com rtpmap vnd.onvif.metadata
+// no SDP e resolve o a=control contra base (Content-Base ou URL do DESCRIBE).
+func sdpMetadataControl(sdp, base string) (string, bool) {
+	var (
+		inApp, isMeta bool
+		control       string
+	)
+	flush := func() (string, bool) {
+		if !inApp || !isMeta {
+			return "", false
+		}
+		return resolveRTSPControl(base, control), true
+	}
+	for _, line := range strings.Split(sdp, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "m="):
+			if ctrl, ok := flush(); ok {
+				return ctrl, true
+			}
+			inApp = strings.HasPrefix(line, "m=application")
+			isMeta, control = false, ""
+		case strings.HasPrefix(line, "a=rtpmap:"):
+			if strings.Contains(strings.ToLower(line), "vnd.onvif.metadata") {
+				isMeta = true
+			}
+		case strings.HasPrefix(line, "a=control:"):
+			control = strings.TrimPrefix(line, "a=control:")
+		}
+	}
+	return flush()
+}
+
+func resolveRTSPControl(base, control string) string {
+	control = strings.TrimSpace(control)
+	if control == "" || control == "*" {
+		return base
+	}
+	if strings.HasPrefix(strings.ToLower(control), "rtsp://") || strings.HasPrefix(strings.ToLower(control), "rtsps://") {
+		return control
+	}
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	return base + control
+}
+
+// rtpPayload devolve o payload de um pacote RTP e o marker bit (fim do
+// documento de metadata).
+func rtpPayload(pkt []byte) ([]byte, bool, error) {
+	if len(pkt) < 12 || pkt[0]>>6 != 2 {
+		return nil, false, fmt.Errorf("pacote RTP inválido")
+	}
+	marker := pkt[1]&0x80 != 0
+	offset := 12 + 4*int(pkt[0]&0x0f)
+	if pkt[0]&0x10 != 0 { // extensão
+		if len(pkt) < offset+4 {
+			return nil, false, fmt.Errorf("extensão RTP truncada")
+		}
+		offset += 4 + 4*int(binary.BigEndian.Uint16(pkt[offset+2:]))
+	}
+	end := len(pkt)
+	if pkt[0]&0x20 != 0 && end > offset { // padding
+		end -= int(pkt[end-1])
+	}
+	if offset > end {
+		return nil, false, fmt.Errorf("pacote RTP truncado")
+	}
+	return pkt[offset:end], marker, nil
+}
@@ -0,0 +1,261 @@
+// internal/drivers/rtsp_metadata.go
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/logthrottle"
+)
+
+// Valores de CameraInfo.EventTransport.
+const (
+	EventTransportHTTP = "http"
+	EventTransportRTSP = "rtsp"
+	EventTransportPoll = "poll"
+)
+
+const rtspSetupTimeout = 15 * time.Second
+
+// ValidEventTransport diz se v (já normalizado) é um event_transport aceito.
+func ValidEventTransport(v string) bool {
+	return v == "" || v == EventTransportHTTP || v == EventTransportRTSP || v == EventTransportPoll
+}
+
+// RTSPMetadataDriver lê os eventos da trilha application/vnd.onvif.metadata
+// do RTSPURL da câmera (event_transport=rtsp) em vez do canal HTTP. Filtro de
+// analytics, mapeamento de tópicos e snapshot são os do driver ONVIF.
+type RTSPMetadataDriver struct {
+	info          core.CameraInfo
+	onvif         *OnvifDriver
+	tlsCfg        *tls.Config
+	statusHandler func(StatusUpdate)
+	heartbeat     time.Duration     // intervalo do keepalive RTSP (DRIVER_SUBSCRIBE_HEARTBEAT)
+	backoff       *reconnectBackoff // espera entre reconexões (CAMERA_RECONNECT_*)
+}
+
+func NewRTSPMetadataDriver(info core.CameraInfo) (CameraDriver, error) {
+	if strings.TrimSpace(info.RTSPURL) == "" {
+		return nil, fmt.Errorf("event_transport=rtsp exige rtsp_url")
+	}
+	onvif, err := newOnvifDriver(info)
+	if err != nil {
+		return nil, err
+	}
+	var tlsCfg *tls.Config
+	if info.UseTLS {
+		if tlsCfg, err = cameraTLSConfig(info); err != nil {
+			return nil, err
+		}
+	}
+	return &RTSPMetadataDriver{
+		info:      info,
+		onvif:     onvif,
+		tlsCfg:    tlsCfg,
+		heartbeat: subscribeHeartbeat(info, defaultRTSPMetadataHeartbeat),
+		backoff:   newReconnectBackoff(),
+	}, nil
+}
+
+// SetStatusHandler registra callback para mudanças de status de conexão.
+func (d *RTSPMetadataDriver) SetStatusHandler(fn func(StatusUpdate)) {
+	d.statusHandler = fn
+}
+
+// ActiveAnalytics retorna os analytics filtrados (nomes ONVIF).
+func (d *RTSPMetadataDriver) ActiveAnalytics() []string {
+	return d.onvif.selectedAnalytics()
+}
+
+// LastRTT retorna o RTT da última requisição HTTP (snapshot) à câmera.
+func (d *RTSPMetadataDriver) LastRTT() time.Duration {
+	return d.onvif.LastRTT()
+}
+
+// AverageRTT retorna a média móvel do RTT das requisições HTTP à câmera.
+func (d *RTSPMetadataDriver) AverageRTT() time.Duration {
+	return d.onvif.AverageRTT()
+}
+
+func (d *RTSPMetadataDriver) notifyStatus(update StatusUpdate) {
+	if d.statusHandler != nil {
+		d.statusHandler(update)
+	}
+}
+
+func (d *RTSPMetadataDriver) Run(ctx context.Context, events chan<- core.AnalyticEvent) error {
+	log.Printf("[rtsp-metadata] starting driver for %s (%s)", d.info.Name, d.info.IP)
+
+	if len(d.onvif.selectedAnalytics()) == 0 {
+		return waitMisconfigured(ctx, d.notifyStatus, "nenhum analytics válido no /info (fallback ONVIF_FALLBACK_ANALYTICS vazio)")
+	}
+
+	return runReconnecting(ctx, d.backoff, "rtsp-metadata", d.info, d.notifyStatus, func(ctx context.Context) error {
+		return d.runOnce(ctx, events)
+	})
+}
+
+func (d *RTSPMetadataDriver) runOnce(ctx context.Context, events chan<- core.AnalyticEvent) error {
+	analytics := d.onvif.selectedAnalytics()
+	d.notifyStatus(StatusUpdate{State: ConnectionStateConnecting, Reason: "abrindo RTSP metadata"})
+
+	setupCtx, cancel := context.WithTimeout(ctx, rtspSetupTimeout)
+	c, err := dialRTSP(setupCtx, d.info.RTSPURL, d.info.Username, d.info.Password, d.tlsCfg)
+	cancel()
+	if err != nil {
+		d.notifyStatus(StatusUpdate{State: ConnectionStateNotEstablished, Reason: err.Error()})
+		return fmt.Errorf("rtsp connect: %w", err)
+	}
+	defer c.Close()
+	stop := context.AfterFunc(ctx, func() { _ = c.Close() })
+	defer stop()
+
+	playURL, keepalive, err := d.setup(c)
+	if err != nil {
+		d.notifyStatus(StatusUpdate{State: ConnectionStateNotEstablished, Reason: err.Error()})
+		return err
+	}
+
+	snapCtx, cancelSnap := context.WithTimeout(ctx, 10*time.Second)
+	snapshotURL := d.onvif.discoverSnapshotURL(snapCtx)
+	cancelSnap()
+
+	d.notifyStatus(StatusUpdate{
+		State:  ConnectionStateOnline,
+		Reason: fmt.Sprintf("rtsp metadata [%s]", strings.Join(analytics, ",")),
+	})
+	log.Printf("[rtsp-metadata] lendo metadata de %s (%s): %s", d.info.Name, d.info.IP, playURL)
+
+	go func() {
+		ticker := time.NewTicker(keepalive)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.KeepAlive(); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	// sem nenhum frame nem resposta de keepalive por heartbeat×DRIVER_WATCHDOG_FACTOR, reconecta
+	timeout := watchdogTimeout(keepalive)
+	var doc []byte
+	for {
+		if timeout > 0 {
+			_ = c.conn.SetReadDeadline(time.Now().Add(timeout))
+		}
+		channel, pkt, _, err := c.Next()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			d.notifyStatus(StatusUpdate{State: ConnectionStateOffline, Reason: err.Error()})
+			return fmt.Errorf("error reading metadata: %w", err)
+		}
+		if pkt == nil || channel != 0 {
+			continue // resposta do keepalive ou RTCP
+		}
+
+		payload, marker, err := rtpPayload(pkt)
+		if err != nil {
+			logthrottle.Printf("rtsp-metadata:rtp:"+d.info.IP, "[rtsp-metadata] %s: %v", d.info.Name, err)
+			continue
+		}
+		doc = append(doc, payload...)
+		if len(doc) > rtspMaxFrame {
+			logthrottle.Printf("rtsp-metadata:size:"+d.info.IP, "[rtsp-metadata] %s: documento de metadata acima de %d bytes, descartado", d.info.Name, rtspMaxFrame)
+			doc = doc[:0]
+			continue
+		}
+		if !marker {
+			continue
+		}
+
+		notifications, err := parseMetadataStream(doc)
+		doc = doc[:0]
+		if err != nil {
+			logthrottle.Printf("rtsp-metadata:xml:"+d.info.IP, "[rtsp-metadata] %s: metadata inválido: %v", d.info.Name, err)
+		}
+		for _, n := range notifications {
+			evt := d.onvif.parseNotification(n, analytics)
+			if evt == nil {
+				continue
+			}
+			evt.Meta["event_transport"] = EventTransportRTSP
+			d.onvif.emit(ctx, events, evt, snapshotURL)
+			if ctx.Err() != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// setup faz DESCRIBE/SETUP/PLAY da trilha de metadata e devolve o URL do
+// PLAY e o intervalo do keepalive (menor entre heartbeat e timeout da sessão/2).
+func (d *RTSPMetadataDriver) setup(c *rtspClient) (string, time.Duration, error) {
+	_ = c.conn.SetDeadline(time.Now().Add(rtspSetupTimeout))
+	defer c.conn.SetDeadline(time.Time{})
+
+	resp, err := c.Do("DESCRIBE", c.url, map[string]string{"Accept": "application/sdp"})
+	if err != nil {
+		return "", 0, fmt.Errorf("DESCRIBE: %w", err)
+	}
+	base := c.url
+	if cb := strings.TrimSpace(resp.header.Get("Content-Base")); cb != "" {
+		base = cb
+	}
+	control, ok := sdpMetadataControl(string(resp.body), base)
+	if !ok {
+		return "", 0, fmt.Errorf("SDP sem trilha application/vnd.onvif.metadata")
+	}
+
+	resp, err = c.Do("SETUP", control, map[string]string{"Transport": "RTP/AVP/TCP;unicast;interleaved=0-1"})
+	if err != nil {
+		return "", 0, fmt.Errorf("SETUP: %w", err)
+	}
+	keepalive := min(d.heartbeat, sessionTimeout(resp)/2)
+
+	if _, err := c.Do("PLAY", base, map[string]string{"Range": "npt=0.000-"}); err != nil {
+		return "", 0, fmt.Errorf("PLAY: %w", err)
+	}
+	return base, max(keepalive, time.Second), nil
+}
+
+// onvifMetadataStream é o documento tt:MetadataStream da trilha de metadata;
+// só os eventos (tt:Event) interessam, VideoAnalytics/PTZ são ignorados.
+type onvifMetadataStream struct {
+	Events []struct {
+		Notifications []onvifNotification `xml:"NotificationMessage"`
+	} `xml:"Event"`
+}
+
+// parseMetadataStream extrai as NotificationMessage de um ou mais documentos
+// tt:MetadataStream concatenados (um por marker RTP).
+func parseMetadataStream(data []byte) ([]onvifNotification, error) {
+	var out []onvifNotification
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		var ms onvifMetadataStream
+		if err := dec.Decode(&ms); err != nil {
+			if err == io.EOF {
+				return out, nil
+			}
+			return out, err
+		}
+		for _, ev := range ms.Events {
+			out = append(out, ev.Notifications...)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package drivers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "rtsp_metadata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// rtpPacket monta um pacote RTP (PT 107) com CSRCs, extensão e padding
+// opcionais, como mandam algumas câmeras.
+func rtpPacket(seq uint16, marker bool, payload []byte, csrcs int, ext []byte, padding int) []byte {
+	var b bytes.Buffer
+	first := byte(2<<6) | byte(csrcs)
+	if ext != nil {
+		first |= 0x10
+	}
+	if padding > 0 {
+		first |= 0x20
+	}
+	second := byte(107)
+	if marker {
+		second |= 0x80
+	}
+	b.Write([]byte{first, second})
+	binary.Write(&b, binary.BigEndian, seq)
+	binary.Write(&b, binary.BigEndian, uint32(90000))
+	binary.Write(&b, binary.BigEndian, uint32(0x1234abcd))
+	for i := 0; i < csrcs; i++ {
+		binary.Write(&b, binary.BigEndian, uint32(i+1))
+	}
+	if ext != nil {
+		binary.Write(&b, binary.BigEndian, uint16(0xbede))
+		binary.Write(&b, binary.BigEndian, uint16(len(ext)/4))
+		b.Write(ext)
+	}
+	b.Write(payload)
+	if padding > 0 {
+		b.Write(make([]byte, padding-1))
+		b.WriteByte(byte(padding))
+	}
+	return b.Bytes()
+}
+
+// interleaved embrulha o pacote no frame RTSP "$" canal tamanho.
+func interleaved(channel byte, pkt []byte) []byte {
+	hdr := []byte{'$', channel, 0, 0}
+	binary.BigEndian.PutUint16(hdr[2:], uint16(len(pkt)))
+	return append(hdr, pkt...)
+}
+
+func TestParseMetadataStreamFixture(t *testing.T) {
+	notifications, err := parseMetadataStream(readFixture(t, "metadata.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notifications) != 2 {
+		t.Fatalf("notificações = %d, esperava 2", len(notifications))
+	}
+	n := notifications[0]
+	if n.Topic != "tns1:RuleEngine/CellMotionDetector/Motion" {
+		t.Fatalf("topic = %q", n.Topic)
+	}
+	if n.Message.UtcTime != "2024-03-11T14:02:07.381843Z" || n.Message.PropertyOperation != "Changed" {
+		t.Fatalf("message = %+v", n.Message)
+	}
+	if src := simpleItemsMap(n.Message.Source); src["Rule"] != "MyMotionDetectorRule" {
+		t.Fatalf("source = %v", src)
+	}
+	if data := simpleItemsMap(n.Message.Data); data["IsMotion"] != "true" {
+		t.Fatalf("data = %v", data)
+	}
+	if notifications[1].Topic != "tns1:RuleEngine/FieldDetector/ObjectsInside" {
+		t.Fatalf("segunda notificação = %q", notifications[1].Topic)
+	}
+}
+
+func TestParseMetadataStreamConcatenatedAndAnalyticsOnly(t *testing.T) {
+	// dois documentos no mesmo buffer: o de VideoAnalytics não traz eventos
+	data := append(readFixture(t, "metadata_analytics_only.xml"), readFixture(t, "metadata.xml")...)
+	notifications, err := parseMetadataStream(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notifications) != 2 {
+		t.Fatalf("notificações = %d, esperava só as 2 do documento de eventos", len(notifications))
+	}
+
+	// documento cortado: devolve o que já foi lido e o erro
+	full := readFixture(t, "metadata.xml")
+	got, err := parseMetadataStream(append(append([]byte{}, full...), full[:len(full)/2]...))
+	if err == nil || len(got) != 2 {
+		t.Fatalf("documento truncado: %d notificações, err=%v", len(got), err)
+	}
+}
+
+func TestSDPMetadataControl(t *testing.T) {
+	cases := []struct {
+		fixture, base, want string
+		ok                  bool
+	}{
+		// control absoluto
+		{"axis.sdp", "rtsp://10.0.0.21:554/axis-media/media.amp?video=1&audio=0&event=on",
+			"rtsp://10.0.0.21:554/axis-media/media.amp/stream=1?video=1&audio=0&event=on", true},
+		// control relativo ao Content-Base, depois das trilhas de vídeo e áudio
+		{"hikvision.sdp", "rtsp://10.0.0.30:554/Streaming/Channels/101/",
+			"rtsp://10.0.0.30:554/Streaming/Channels/101/trackID=metadata", true},
+		{"hikvision.sdp", "rtsp://10.0.0.30:554/Streaming/Channels/101",
+			"rtsp://10.0.0.30:554/Streaming/Channels/101/trackID=metadata", true},
+		{"video_only.sdp", "rtsp://10.0.0.40/live", "", false},
+	}
+	for _, tc := range cases {
+		got, ok := sdpMetadataControl(string(readFixture(t, tc.fixture)), tc.base)
+		if ok != tc.ok || got != tc.want {
+			t.Errorf("%s: control=%q ok=%t, esperava %q %t", tc.fixture, got, ok, tc.want, tc.ok)
+		}
+	}
+
+	// trilha application sem rtpmap de metadata ONVIF não serve
+	sdp := "v=0\r\nm=application 0 RTP/AVP 100\r\na=rtpmap:100 GPMD/90000\r\na=control:trackID=2\r\n"
+	if _, ok := sdpMetadataControl(sdp, "rtsp://cam/"); ok {
+		t.Fatal("application sem vnd.onvif.metadata não deveria ser escolhida")
+	}
+}
+
+func TestRTPPayload(t *testing.T) {
+	body := []byte("<tt:MetadataStream/>")
+
+	payload, marker, err := rtpPayload(rtpPacket(1, true, body, 0, nil, 0))
+	if err != nil || !marker || !bytes.Equal(payload, body) {
+		t.Fatalf("pacote simples: %q marker=%t err=%v", payload, marker, err)
+	}
+
+	payload, marker, err = rtpPayload(rtpPacket(2, false, body, 2, []byte{1, 2, 3, 4, 5, 6, 7, 8}, 4))
+	if err != nil || marker || !bytes.Equal(payload, body) {
+		t.Fatalf("com CSRC/extensão/padding: %q marker=%t err=%v", payload, marker, err)
+	}
+
+	version1 := rtpPacket(3, true, body, 0, nil, 0)
+	version1[0] = 0x40
+	extTruncated := rtpPacket(4, true, nil, 0, nil, 0)
+	extTruncated[0] |= 0x10
+	csrcTruncated := rtpPacket(5, true, nil, 0, nil, 0)
+	csrcTruncated[0] |= 0x0f
+	padTruncated := rtpPacket(6, true, []byte("x"), 0, nil, 0)
+	padTruncated[0] |= 0x20
+	padTruncated[len(padTruncated)-1] = 200
+
+	for name, bad := range map[string][]byte{
+		"curto":               {0x80, 0x6b, 0, 1},
+		"versão 1":            version1,
+		"extensão truncada":   extTruncated,
+		"CSRC além do fim":    csrcTruncated,
+		"padding além do fim": padTruncated,
+	} {
+		if _, _, err := rtpPayload(bad); err == nil {
+			t.Errorf("%s: esperava erro", name)
+		}
+	}
+}
+
+func TestRTSPClientNextInterleaved(t *testing.T) {
+	doc := readFixture(t, "metadata.xml")
+	half := len(doc) / 2
+
+	var stream bytes.Buffer
+	stream.Write(interleaved(0, rtpPacket(10, false, doc[:half], 0, nil, 0)))
+	stream.Write(interleaved(1, []byte{0x80, 0xc8, 0, 6})) // RTCP sender report
+	stream.WriteString("RTSP/1.0 200 OK\r\nCSeq: 7\r\nContent-Length: 4\r\n\r\nok\r\n")
+	stream.Write(interleaved(0, rtpPacket(11, true, doc[half:], 1, nil, 0)))
+
+	c := &rtspClient{r: bufio.NewReader(&stream)}
+	var assembled []byte
+	var responses, rtcp int
+	for {
+		channel, pkt, resp, err := c.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case resp != nil:
+			responses++
+			if resp.status != 200 || string(resp.body) != "ok\r\n" {
+				t.Fatalf("resposta intercalada = %+v", resp)
+			}
+		case channel == 1:
+			rtcp++
+		default:
+			payload, marker, err := rtpPayload(pkt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assembled = append(assembled, payload...)
+			if marker {
+				notifications, err := parseMetadataStream(assembled)
+				if err != nil || len(notifications) != 2 {
+					t.Fatalf("documento remontado: %d notificações, err=%v", len(notifications), err)
+				}
+			}
+		}
+	}
+	if responses != 1 || rtcp != 1 || !bytes.Equal(assembled, doc) {
+		t.Fatalf("respostas=%d rtcp=%d remontado=%d/%d bytes", responses, rtcp, len(assembled), len(doc))
+	}
+}
+
+// fakeRTSPServer responde DESCRIBE/SETUP/PLAY numa ponta do net.Pipe,
+// pedindo Basic no primeiro DESCRIBE, e devolve as requisições recebidas.
+func fakeRTSPServer(t *testing.T, conn net.Conn, sdp []byte) <-chan []textproto.MIMEHeader {
+	t.Helper()
+	out := make(chan []textproto.MIMEHeader, 1)
+	go func() {
+		defer close(out)
+		var reqs []textproto.MIMEHeader
+		tp := textproto.NewReader(bufio.NewReader(conn))
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				out <- reqs
+				return
+			}
+			hdr, _ := tp.ReadMIMEHeader()
+			method, _, _ := strings.Cut(line, " ")
+			hdr.Set("X-Method", method)
+			reqs = append(reqs, hdr)
+			cseq := hdr.Get("Cseq")
+
+			var resp string
+			switch {
+			case method == "DESCRIBE" && hdr.Get("Authorization") == "":
+				resp = fmt.Sprintf("RTSP/1.0 401 Unauthorized\r\nCSeq: %s\r\nWWW-Authenticate: Basic realm=\"cam\"\r\n\r\n", cseq)
+			case method == "DESCRIBE":
+				resp = fmt.Sprintf("RTSP/1.0 200 OK\r\nCSeq: %s\r\nContent-Base: rtsp://10.0.0.30:554/Streaming/Channels/101/\r\nContent-Type: application/sdp\r\nContent-Length: %d\r\n\r\n%s", cseq, len(sdp), sdp)
+			case method == "SETUP":
+				resp = fmt.Sprintf("RTSP/1.0 200 OK\r\nCSeq: %s\r\nSession: 7A3F91;timeout=30\r\nTransport: RTP/AVP/TCP;unicast;interleaved=0-1\r\n\r\n", cseq)
+			default:
+				resp = fmt.Sprintf("RTSP/1.0 200 OK\r\nCSeq: %s\r\nSession: 7A3F91\r\n\r\n", cseq)
+			}
+			if _, err := io.WriteString(conn, resp); err != nil {
+				out <- reqs
+				return
+			}
+			if method == "PLAY" {
+				conn.Close()
+			}
+		}
+	}()
+	return out
+}
+
+func TestRTSPMetadataSetupHandshake(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	reqsCh := fakeRTSPServer(t, server, readFixture(t, "hikvision.sdp"))
+
+	c := &rtspClient{
+		conn: client,
+		r:    bufio.NewReader(client),
+		url:  "rtsp://10.0.0.30:554/Streaming/Channels/101",
+		user: "admin",
+		pass: "segredo",
+	}
+	d := &RTSPMetadataDriver{heartbeat: time.Minute}
+	playURL, keepalive, err := d.setup(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if playURL != "rtsp://10.0.0.30:554/Streaming/Channels/101/" {
+		t.Fatalf("PLAY em %q, esperava o Content-Base", playURL)
+	}
+	if keepalive != 15*time.Second {
+		t.Fatalf("keepalive = %s, esperava metade do timeout da sessão", keepalive)
+	}
+
+	reqs := <-reqsCh
+	methods := make([]string, len(reqs))
+	for i, r := range reqs {
+		methods[i] = r.Get("X-Method")
+	}
+	if strings.Join(methods, ",") != "DESCRIBE,DESCRIBE,SETUP,PLAY" {
+		t.Fatalf("requisições = %v", methods)
+	}
+	wantAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte("admin:segredo"))
+	if reqs[1].Get("Authorization") != wantAuth || reqs[2].Get("Authorization") != wantAuth {
+		t.Fatal("depois do 401 todas as requisições deveriam levar Authorization")
+	}
+	if !strings.Contains(reqs[2].Get("Transport"), "interleaved=0-1") {
+		t.Fatalf("SETUP sem interleaved: %q", reqs[2].Get("Transport"))
+	}
+	if reqs[3].Get("Session") != "7A3F91" {
+		t.Fatalf("PLAY com Session %q, esperava o id sem o timeout", reqs[3].Get("Session"))
+	}
+}
@@ -0,0 +1,110 @@
+// internal/drivers/rtsp_snapshot.go
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/envconf"
+	"github.com/sua-org/cam-bus/internal/uplink/container"
+)
+
+const (
+	rtspSnapshotTimeout = 10 * time.Second
+	maxFFmpegStderr     = 500
+)
+
+// rtspSnapshotFallback lê SNAPSHOT_RTSP_FALLBACK (default: false): quando o
+// evento não traz imagem e o snapshot HTTP da câmera falha, captura um frame
+// do RTSPURL com ffmpeg.
+func rtspSnapshotFallback() bool {
+	return envconf.Bool("SNAPSHOT_RTSP_FALLBACK", false)
+}
+
+// rtspSnapshotArgs monta os argumentos do ffmpeg para um único JPEG no stdout.
+func rtspSnapshotArgs(rtspURL string, timeout time.Duration) []string {
+	return []string{
+		"-hide_banner",
+		"-loglevel", "error",
+		"-rtsp_transport", "tcp",
+		"-rw_timeout", strconv.FormatInt(timeout.Microseconds(), 10),
+		"-i", rtspURL,
+		"-an",
+		"-frames:v", "1",
+		"-f", "image2pipe",
+		"-c:v", "mjpeg",
+		"-q:v", "2",
+		"pipe:1",
+	}
+}
+
+// rtspSnapshotURL devolve o RTSPURL com usuário/senha da câmera quando a URL
+// não traz credenciais.
+func rtspSnapshotURL(info core.CameraInfo) (string, error) {
+	raw := strings.TrimSpace(info.RTSPURL)
+	if raw == "" {
+		return "", errors.New("câmera sem rtsp_url")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("rtsp_url inválida: %w", err)
+	}
+	if u.User == nil && info.Username != "" {
+		u.User = url.UserPassword(info.Username, info.Password)
+	}
+	return u.String(), nil
+}
+
+// fetchRTSPSnapshot captura um frame do RTSPURL (último recurso de snapshot).
+func fetchRTSPSnapshot(ctx context.Context, info core.CameraInfo) ([]byte, string, error) {
+	rtspURL, err := rtspSnapshotURL(info)
+	if err != nil {
+		return nil, "", err
+	}
+	bin, prefix, err := container.FFmpegCommand()
+	if err != nil {
+		return nil, "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, rtspSnapshotTimeout)
+	defer cancel()
+	args := append(prefix, rtspSnapshotArgs(rtspURL, rtspSnapshotTimeout)...)
+	cmd := exec.CommandContext(ctx, bin, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := redactRTSPPassword(strings.TrimSpace(stderr.String()), rtspURL)
+		if len(msg) > maxFFmpegStderr {
+			msg = msg[:maxFFmpegStderr]
+		}
+		return nil, "", fmt.Errorf("ffmpeg: %w: %s", err, msg)
+	}
+	if stdout.Len() == 0 {
+		return nil, "", errors.New("ffmpeg não devolveu nenhum frame")
+	}
+	return stdout.Bytes(), "image/jpeg", nil
+}
+
+// redactRTSPPassword tira a senha da URL das mensagens do ffmpeg (que costumam
+// repetir a URL de entrada).
+func redactRTSPPassword(msg, rtspURL string) string {
+	u, err := url.Parse(rtspURL)
+	if err != nil || u.User == nil {
+		return msg
+	}
+	pass, ok := u.User.Password()
+	if !ok || pass == "" {
+		return msg
+	}
+	msg = strings.ReplaceAll(msg, url.QueryEscape(pass), "***")
+	return strings.ReplaceAll(msg, pass, "***")
+}
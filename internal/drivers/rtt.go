@@ -0,0 +1,39 @@
+// internal/drivers/rtt.go
+package drivers
+
+import (
+	"sync"
+	"time"
+)
+
+// rttSmoothing é o peso da última amostra na média móvel exponencial.
+const rttSmoothing = 0.2
+
+// rttTracker guarda o RTT HTTP da última requisição à câmera
+// e uma média móvel (EWMA) para suavizar picos isolados.
+type rttTracker struct {
+	mu   sync.Mutex
+	last time.Duration
+	avg  time.Duration
+}
+
+func (t *rttTracker) observe(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.last = d
+	if t.avg == 0 {
+		t.avg = d
+		return
+	}
+	t.avg = time.Duration(rttSmoothing*float64(d) + (1-rttSmoothing)*float64(t.avg))
+}
+
+func (t *rttTracker) values() (last, avg time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.last, t.avg
+}
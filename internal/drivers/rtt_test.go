@@ -0,0 +1,66 @@
+package drivers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRTTTrackerEWMA(t *testing.T) {
+	var tr rttTracker
+	tr.observe(100 * time.Millisecond)
+	if last, avg := tr.values(); last != 100*time.Millisecond || avg != 100*time.Millisecond {
+		t.Fatalf("1ª amostra: last=%s avg=%s, esperava 100ms/100ms", last, avg)
+	}
+	tr.observe(200 * time.Millisecond)
+	if last, avg := tr.values(); last != 200*time.Millisecond || avg != 120*time.Millisecond {
+		t.Fatalf("2ª amostra: last=%s avg=%s, esperava 200ms/120ms", last, avg)
+	}
+	tr.observe(0) // amostra inválida é ignorada
+	if last, _ := tr.values(); last != 200*time.Millisecond {
+		t.Fatalf("observe(0) alterou last: %s", last)
+	}
+}
+
+func TestHTTPCameraMeasuresRTT(t *testing.T) {
+	const delay = 30 * time.Millisecond
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := &httpCamera{client: srv.Client()}
+	if c.LastRTT() != 0 || c.AverageRTT() != 0 {
+		t.Fatal("RTT deveria começar zerado")
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := c.doDigest(context.Background(), http.MethodGet, srv.URL, nil, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if got := c.LastRTT(); got < delay {
+		t.Fatalf("LastRTT = %s, esperava >= %s", got, delay)
+	}
+	if got := c.AverageRTT(); got < delay {
+		t.Fatalf("AverageRTT = %s, esperava >= %s", got, delay)
+	}
+}
+
+func TestHTTPCameraSkipsRTTOnError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	c := &httpCamera{client: &http.Client{Timeout: time.Second}}
+	if _, err := c.doDigest(context.Background(), http.MethodGet, url, nil, ""); err == nil {
+		t.Fatal("esperava erro de conexão")
+	}
+	if c.LastRTT() != 0 {
+		t.Fatalf("falha de conexão não deve virar amostra de RTT: %s", c.LastRTT())
+	}
+}
@@ -0,0 +1,231 @@
+// internal/drivers/session_auth.go
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+// Valores de CameraInfo.AuthMode.
+const (
+	AuthModeDigest  = "digest"
+	AuthModeSession = "session"
+)
+
+const (
+	defaultSessionLoginPath = "/login"
+	sessionFormatForm       = "form"
+	sessionFormatJSON       = "json"
+)
+
+// ValidAuthMode diz se v (já normalizado) é um auth_mode aceito.
+func ValidAuthMode(v string) bool {
+	return v == "" || v == AuthModeDigest || v == AuthModeSession
+}
+
+// ValidSessionFormat diz se v (já normalizado) é um session_login.format aceito.
+func ValidSessionFormat(v string) bool {
+	return v == "" || v == sessionFormatForm || v == sessionFormatJSON
+}
+
+// sessionAuth faz o login de auth_mode=session e guarda o cookie de sessão
+// num CookieJar; toda requisição leva os cookies e, no 401 ou com o jar
+// vazio (cookie expirado), refaz o login uma vez.
+type sessionAuth struct {
+	info     core.CameraInfo
+	cfg      core.SessionLogin
+	base     *url.URL
+	loginURL string
+
+	mu  sync.Mutex
+	jar *cookiejar.Jar
+}
+
+// newSessionAuth devolve nil quando a câmera não usa auth_mode=session.
+func newSessionAuth(info core.CameraInfo) (*sessionAuth, error) {
+	if !strings.EqualFold(strings.TrimSpace(info.AuthMode), AuthModeSession) {
+		return nil, nil
+	}
+	var cfg core.SessionLogin
+	if info.SessionLogin != nil {
+		cfg = *info.SessionLogin
+	}
+	if cfg.Path == "" {
+		cfg.Path = defaultSessionLoginPath
+	}
+	if cfg.UserField == "" {
+		cfg.UserField = "username"
+	}
+	if cfg.PassField == "" {
+		cfg.PassField = "password"
+	}
+	cfg.Format = strings.ToLower(strings.TrimSpace(cfg.Format))
+	if cfg.Format == "" {
+		cfg.Format = sessionFormatForm
+	}
+	if !ValidSessionFormat(cfg.Format) {
+		return nil, fmt.Errorf("session_login.format %q inválido (form ou json)", cfg.Format)
+	}
+
+	base, err := url.Parse(cameraBaseURL(info))
+	if err != nil {
+		return nil, err
+	}
+	ref, err := url.Parse(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("session_login.path inválido: %w", err)
+	}
+	jar, _ := cookiejar.New(nil)
+	return &sessionAuth{
+		info:     info,
+		cfg:      cfg,
+		base:     base,
+		loginURL: base.ResolveReference(ref).String(),
+		jar:      jar,
+	}, nil
+}
+
+// cameraBaseURL é scheme://ip[:port] da câmera conforme o /info.
+func cameraBaseURL(info core.CameraInfo) string {
+	scheme := "http"
+	if info.UseTLS {
+		scheme = "https"
+	}
+	host := info.IP
+	if info.Port != 0 {
+		host = fmt.Sprintf("%s:%d", host, info.Port)
+	}
+	return scheme + "://" + host
+}
+
+// request tem a mesma assinatura do digestRequest (sem as credenciais).
+func (s *sessionAuth) request(
+	ctx context.Context,
+	do func(*http.Request) (*http.Response, error),
+	method, rawURL string,
+	body io.Reader,
+	contentType string,
+) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		jar, err := s.session(ctx, do, attempt > 0)
+		if err != nil {
+			return nil, err
+		}
+
+		if attempt > 0 {
+			body = replayBody(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+		if err != nil {
+			return nil, err
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		req.Header.Set("Connection", "keep-alive")
+		for _, c := range jar.Cookies(req.URL) {
+			req.AddCookie(c)
+		}
+
+		resp, err := do(req)
+		if err != nil {
+			return nil, err
+		}
+		if cookies := resp.Cookies(); len(cookies) > 0 {
+			jar.SetCookies(req.URL, cookies) // câmera pode renovar o cookie a cada resposta
+		}
+		if resp.StatusCode != http.StatusUnauthorized || attempt > 0 {
+			return resp, nil
+		}
+		_ = resp.Body.Close()
+		log.Printf("[drivers] sessão expirada em %s (%s), refazendo login", s.info.Name, s.info.IP)
+	}
+}
+
+// cookieHeader devolve o header Cookie da sessão (logando se preciso), para
+// conexões que não passam pelo request (WebSocket).
+func (s *sessionAuth) cookieHeader(ctx context.Context, do func(*http.Request) (*http.Response, error), relogin bool) (string, error) {
+	jar, err := s.session(ctx, do, relogin)
+	if err != nil {
+		return "", err
+	}
+	var parts []string
+	for _, c := range jar.Cookies(s.base) {
+		parts = append(parts, c.Name+"="+c.Value)
+	}
+	return strings.Join(parts, "; "), nil
+}
+
+// session devolve o jar com sessão válida: faz login se o jar não tem cookie
+// para a câmera (nunca logou ou expirou) ou se force.
+func (s *sessionAuth) session(ctx context.Context, do func(*http.Request) (*http.Response, error), force bool) (*cookiejar.Jar, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !force && len(s.jar.Cookies(s.base)) > 0 {
+		return s.jar, nil
+	}
+	jar, _ := cookiejar.New(nil)
+	if err := s.login(ctx, do, jar); err != nil {
+		return nil, err
+	}
+	s.jar = jar
+	return jar, nil
+}
+
+func (s *sessionAuth) login(ctx context.Context, do func(*http.Request) (*http.Response, error), jar *cookiejar.Jar) error {
+	fields := map[string]string{}
+	for k, v := range s.cfg.Extra {
+		fields[k] = v
+	}
+	fields[s.cfg.UserField] = s.info.Username
+	fields[s.cfg.PassField] = s.info.Password
+
+	var (
+		body        []byte
+		contentType string
+	)
+	if s.cfg.Format == sessionFormatJSON {
+		body, _ = json.Marshal(fields)
+		contentType = "application/json"
+	} else {
+		form := url.Values{}
+		for k, v := range fields {
+			form.Set(k, v)
+		}
+		body = []byte(form.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.loginURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := do(req)
+	if err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("login status %d", resp.StatusCode)
+	}
+	jar.SetCookies(req.URL, resp.Cookies())
+	if len(jar.Cookies(s.base)) == 0 {
+		return fmt.Errorf("login sem cookie de sessão")
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+// sessionServer é uma câmera com login por cookie: .../login emite sid=N e
+// as outras rotas só aceitam o sid vigente (expire invalida a sessão,
+// reject recusa qualquer uma).
+type sessionServer struct {
+	mu       sync.Mutex
+	sessions int
+	valid    string
+	logins   []map[string]string
+	loginCT  []string
+	calls    []string // "<cookie> <body>" de cada requisição fora do login
+	noCookie bool
+	reject   bool
+	status   int
+}
+
+func (s *sessionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+
+	if strings.HasSuffix(r.URL.Path, "/login") {
+		fields := map[string]string{}
+		s.loginCT = append(s.loginCT, r.Header.Get("Content-Type"))
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			_ = json.Unmarshal(body, &fields)
+		} else {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			_ = r.ParseForm()
+			for k := range r.PostForm {
+				fields[k] = r.PostForm.Get(k)
+			}
+		}
+		s.logins = append(s.logins, fields)
+		if s.status != 0 {
+			w.WriteHeader(s.status)
+			return
+		}
+		if !s.noCookie {
+			s.sessions++
+			s.valid = fmt.Sprintf("s%d", s.sessions)
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: s.valid, Path: "/"})
+		}
+		return
+	}
+
+	cookie := ""
+	if c, err := r.Cookie("sid"); err == nil {
+		cookie = c.Value
+	}
+	s.calls = append(s.calls, cookie+" "+string(body))
+	if cookie == "" || cookie != s.valid || s.reject {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	io.WriteString(w, "ok")
+}
+
+func (s *sessionServer) expire() {
+	s.mu.Lock()
+	s.valid = "expirada"
+	s.mu.Unlock()
+}
+
+func newTestSession(t *testing.T, cfg *core.SessionLogin) (*sessionServer, func(method, body string) int) {
+	t.Helper()
+	srv := &sessionServer{}
+	info := cameraServer(t, srv.ServeHTTP)
+	info.Username, info.Password = "admin", "s&gredo"
+	info.AuthMode = " Session "
+	info.SessionLogin = cfg
+	s, err := newSessionAuth(info)
+	if err != nil || s == nil {
+		t.Fatalf("newSessionAuth = %v, %v", s, err)
+	}
+	target := fmt.Sprintf("%s/ISAPI/dados", cameraBaseURL(info))
+	call := func(method, body string) int {
+		t.Helper()
+		var rd io.Reader
+		if body != "" {
+			rd = bytes.NewReader([]byte(body))
+		}
+		resp, err := s.request(context.Background(), http.DefaultClient.Do, method, target, rd, "text/plain")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	return srv, call
+}
+
+func TestSessionAuthFormLoginReusesCookie(t *testing.T) {
+	srv, call := newTestSession(t, &core.SessionLogin{Path: "/api/login", Extra: map[string]string{"lang": "pt"}})
+
+	for i := 0; i < 3; i++ {
+		if code := call(http.MethodGet, ""); code != http.StatusOK {
+			t.Fatalf("requisição %d: status %d", i, code)
+		}
+	}
+	if len(srv.logins) != 1 {
+		t.Fatalf("%d logins, esperava 1 com o cookie reaproveitado", len(srv.logins))
+	}
+	if srv.loginCT[0] != "application/x-www-form-urlencoded" {
+		t.Fatalf("Content-Type do login = %q", srv.loginCT[0])
+	}
+	want := map[string]string{"username": "admin", "password": "s&gredo", "lang": "pt"}
+	if !reflect.DeepEqual(srv.logins[0], want) {
+		t.Fatalf("campos do login = %v, esperava %v", srv.logins[0], want)
+	}
+	if !reflect.DeepEqual(srv.calls, []string{"s1 ", "s1 ", "s1 "}) {
+		t.Fatalf("cookies enviados = %q", srv.calls)
+	}
+}
+
+func TestSessionAuthJSONLogin(t *testing.T) {
+	srv, call := newTestSession(t, &core.SessionLogin{
+		Path: "/api/login", Format: "JSON", UserField: "user", PassField: "pass",
+		Extra: map[string]string{"api_key": "k1", "user": "ignorado"},
+	})
+	if code := call(http.MethodGet, ""); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if srv.loginCT[0] != "application/json" {
+		t.Fatalf("Content-Type do login = %q", srv.loginCT[0])
+	}
+	// o usuário do /info vence um Extra com o mesmo nome
+	want := map[string]string{"user": "admin", "pass": "s&gredo", "api_key": "k1"}
+	if !reflect.DeepEqual(srv.logins[0], want) {
+		t.Fatalf("campos do login = %v, esperava %v", srv.logins[0], want)
+	}
+}
+
+func TestSessionAuthReloginOn401(t *testing.T) {
+	srv, call := newTestSession(t, &core.SessionLogin{Path: "/api/login"})
+	if code := call(http.MethodPost, "<a/>"); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+
+	srv.expire()
+	if code := call(http.MethodPost, "<b/>"); code != http.StatusOK {
+		t.Fatalf("após expirar: status %d", code)
+	}
+	if len(srv.logins) != 2 {
+		t.Fatalf("%d logins, esperava o relogin no 401", len(srv.logins))
+	}
+	// o corpo é reenviado na nova tentativa
+	if !reflect.DeepEqual(srv.calls, []string{"s1 <a/>", "s1 <b/>", "s2 <b/>"}) {
+		t.Fatalf("requisições = %q", srv.calls)
+	}
+
+	// 401 mesmo com sessão nova: não fica em loop
+	srv.mu.Lock()
+	srv.reject, srv.calls = true, nil
+	srv.mu.Unlock()
+	if code := call(http.MethodGet, ""); code != http.StatusUnauthorized {
+		t.Fatalf("status %d, esperava o 401 devolvido após um relogin", code)
+	}
+	if len(srv.calls) != 2 {
+		t.Fatalf("%d tentativas, esperava 2", len(srv.calls))
+	}
+}
+
+func TestSessionAuthLoginFailures(t *testing.T) {
+	srv := &sessionServer{status: http.StatusForbidden}
+	info := cameraServer(t, srv.ServeHTTP)
+	info.AuthMode = "session"
+	s, err := newSessionAuth(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := cameraBaseURL(info) + "/x"
+	if _, err := s.request(context.Background(), http.DefaultClient.Do, http.MethodGet, target, nil, ""); err == nil || !strings.Contains(err.Error(), "login status 403") {
+		t.Fatalf("erro = %v", err)
+	}
+
+	srv.status, srv.noCookie = 0, true
+	if _, err := s.request(context.Background(), http.DefaultClient.Do, http.MethodGet, target, nil, ""); err == nil || !strings.Contains(err.Error(), "sem cookie") {
+		t.Fatalf("erro = %v", err)
+	}
+	if len(srv.calls) != 0 {
+		t.Fatalf("sem sessão nada deveria chegar à câmera: %q", srv.calls)
+	}
+}
+
+func TestNewSessionAuthModes(t *testing.T) {
+	if s, err := newSessionAuth(core.CameraInfo{AuthMode: "digest"}); s != nil || err != nil {
+		t.Fatalf("digest: %v, %v", s, err)
+	}
+	if _, err := newSessionAuth(core.CameraInfo{AuthMode: "session", SessionLogin: &core.SessionLogin{Format: "xml"}}); err == nil {
+		t.Fatal("format xml deveria ser rejeitado")
+	}
+	s, err := newSessionAuth(core.CameraInfo{AuthMode: "session", IP: "10.0.0.1", Port: 8443, UseTLS: true})
+	if err != nil || s.loginURL != "https://10.0.0.1:8443/login" || s.cfg.Format != sessionFormatForm {
+		t.Fatalf("defaults: %+v, %v", s, err)
+	}
+}
@@ -0,0 +1,298 @@
+// internal/drivers/snapshots.go
+package drivers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/logthrottle"
+	"github.com/sua-org/cam-bus/internal/storage"
+)
+
+const defaultSnapshotCollectWindow = 200 * time.Millisecond
+
+// snapshotImage é uma parte image/* recebida para o evento pendente.
+type snapshotImage struct {
+	data        []byte
+	contentType string
+}
+
+// multipartPart é uma parte já lida do stream (ou o erro que encerrou o stream).
+type multipartPart struct {
+	contentType string
+	data        []byte
+	err         error
+}
+
+// readMultipartParts lê as partes numa goroutine para o loop do driver poder
+// esperar novas imagens com timeout. O canal fecha após o primeiro erro.
+func readMultipartParts(ctx context.Context, mr *multipart.Reader) <-chan multipartPart {
+	out := make(chan multipartPart)
+	go func() {
+		defer close(out)
+		for {
+			part, err := mr.NextPart()
+			var p multipartPart
+			if err != nil {
+				p.err = err
+			} else {
+				p.contentType = part.Header.Get("Content-Type")
+				p.data, err = io.ReadAll(part)
+				_ = part.Close()
+				if err != nil {
+					log.Printf("[drivers] error reading %s part: %v", p.contentType, err)
+					continue
+				}
+			}
+			select {
+			case out <- p:
+			case <-ctx.Done():
+				return
+			}
+			if p.err != nil {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// snapshotCollectWindow lê HIK_SNAPSHOT_COLLECT_WINDOW (default: 200ms):
+// quanto esperar por mais partes image/* do mesmo evento. 0 = só a primeira.
+func snapshotCollectWindow() time.Duration {
+	raw := strings.TrimSpace(os.Getenv("HIK_SNAPSHOT_COLLECT_WINDOW"))
+	if raw == "" {
+		return defaultSnapshotCollectWindow
+	}
+	if d, err := time.ParseDuration(raw); err == nil && d >= 0 {
+		return d
+	}
+	log.Printf("[drivers] HIK_SNAPSHOT_COLLECT_WINDOW inválido (%q), usando %s", raw, defaultSnapshotCollectWindow)
+	return defaultSnapshotCollectWindow
+}
+
+// validSnapshot devolve img, ou nil se SNAPSHOT_VALIDATE rejeitar os bytes
+// (imagem corrompida/truncada): o evento segue sem snapshot e sem FindFace.
+func validSnapshot(driver, ip string, img []byte) []byte {
+	if len(img) == 0 {
+		return img
+	}
+	if err := storage.ValidateSnapshot(img); err != nil {
+		logthrottle.Printf(driver+":invalid-snapshot:"+ip, "[%s] snapshot inválido descartado (%s, %d bytes): %v", driver, ip, len(img), err)
+		return nil
+	}
+	return img
+}
+
+// validSnapshotImages filtra as partes image/* com validSnapshot.
+func validSnapshotImages(driver, ip string, images []snapshotImage) []snapshotImage {
+	out := images[:0]
+	for _, img := range images {
+		if img.data = validSnapshot(driver, ip, img.data); len(img.data) > 0 {
+			out = append(out, img)
+		}
+	}
+	return out
+}
+
+// splitPrimaryImage escolhe a maior imagem (cena completa) como principal;
+// as outras são os recortes de face.
+func splitPrimaryImage(images []snapshotImage) (snapshotImage, []snapshotImage) {
+	best := 0
+	for i, img := range images {
+		if len(img.data) > len(images[best].data) {
+			best = i
+		}
+	}
+	extras := make([]snapshotImage, 0, len(images)-1)
+	for i, img := range images {
+		if i != best {
+			extras = append(extras, img)
+		}
+	}
+	return images[best], extras
+}
+
+// storeExtraSnapshots salva os recortes ao lado do snapshot principal
+// (<key>_thumb_N.jpg) e preenche Meta["face_thumb_urls"]/["snapshot_urls"].
+func storeExtraSnapshots(
+	ctx context.Context,
+	store storage.ImageStore,
+	primaryKey, primaryURL string,
+	extras []snapshotImage,
+	meta map[string]interface{},
+) map[string]interface{} {
+	base := strings.TrimSuffix(primaryKey, ".jpg")
+
+	var thumbs []string
+	for i, img := range extras {
+		ctxUp, cancel := context.WithTimeout(ctx, 5*time.Second)
+		url, err := storage.Save(ctxUp, store, fmt.Sprintf("%s_thumb_%d.jpg", base, i+1), img.data, img.contentType)
+		cancel()
+		if err != nil {
+			log.Printf("[drivers] erro ao salvar recorte %d no MinIO: %v", i+1, err)
+			continue
+		}
+		thumbs = append(thumbs, url)
+	}
+
+	if meta == nil {
+		meta = map[string]interface{}{}
+	}
+	all := make([]string, 0, len(thumbs)+1)
+	if primaryURL != "" {
+		all = append(all, primaryURL)
+	}
+	all = append(all, thumbs...)
+	if len(thumbs) > 0 {
+		meta["face_thumb_urls"] = thumbs
+	}
+	if len(all) > 0 {
+		meta["snapshot_urls"] = all
+	}
+	return meta
+}
+
+// defaultSnapshotChannel é o canal usado quando o evento não indica a lente.
+const defaultSnapshotChannel = 1
+
+// eventChannel lê o canal (1-based) do Meta do evento, tentando as chaves na
+// ordem. Valores ausentes ou inválidos caem em defaultSnapshotChannel.
+func eventChannel(meta map[string]interface{}, keys ...string) int {
+	for _, k := range keys {
+		if ch, ok := toChannel(meta[k]); ok && ch > 0 {
+			return ch
+		}
+	}
+	return defaultSnapshotChannel
+}
+
+// toChannel aceita os tipos numéricos que os parsers deixam no Meta
+// (int64 do getNumber da Hikvision, float64 do JSON, strings do texto).
+func toChannel(v interface{}) (int, bool) {
+	switch x := v.(type) {
+	case int:
+		return x, true
+	case int64:
+		return int(x), true
+	case int32:
+		return int(x), true
+	case uint:
+		return int(x), true
+	case uint32:
+		return int(x), true
+	case uint64:
+		return int(x), true
+	case float64:
+		return int(x), true
+	case json.Number:
+		n, err := x.Int64()
+		return int(n), err == nil
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(x))
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// metaEventImageURL guarda no Meta a URL da imagem mandada pela câmera no
+// próprio evento (ex.: backgroundImageUrl), já resolvida para URL absoluta.
+const metaEventImageURL = "event_image_url"
+
+// eventImageURLKeys são os campos em que as câmeras mandam a URL da imagem do evento.
+var eventImageURLKeys = []string{"backgroundImageUrl", "backgroundImageURL", "pictureURL", "pictureUrl", "bkgUrl"}
+
+// eventImageURL procura a URL da imagem no evento decodificado, inclusive em
+// objetos/listas aninhados (ex.: faceCapture[].backgroundImageUrl).
+func eventImageURL(v interface{}) string {
+	return findEventImageURL(v, 0)
+}
+
+func findEventImageURL(v interface{}, depth int) string {
+	if depth > 4 {
+		return ""
+	}
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for _, k := range eventImageURLKeys {
+			if s, ok := x[k].(string); ok && strings.TrimSpace(s) != "" {
+				return strings.TrimSpace(s)
+			}
+		}
+		for _, child := range x {
+			if u := findEventImageURL(child, depth+1); u != "" {
+				return u
+			}
+		}
+	case []interface{}:
+		for _, child := range x {
+			if u := findEventImageURL(child, depth+1); u != "" {
+				return u
+			}
+		}
+	}
+	return ""
+}
+
+// resolveEventImageURL torna a URL absoluta em relação a baseURL da câmera.
+// URLs de outro host são recusadas: o fetch usa as credenciais da câmera.
+func resolveEventImageURL(baseURL, raw string) (string, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	abs := base.ResolveReference(ref)
+	if abs.Scheme != "http" && abs.Scheme != "https" {
+		return "", fmt.Errorf("esquema não suportado em %q", raw)
+	}
+	if !strings.EqualFold(abs.Hostname(), base.Hostname()) {
+		return "", fmt.Errorf("host %q diferente da câmera", abs.Hostname())
+	}
+	return abs.String(), nil
+}
+
+// digestFunc é o doDigest de cada driver.
+type digestFunc func(ctx context.Context, method, rawURL string, body io.Reader, contentType string) (*http.Response, error)
+
+// fetchImage baixa uma imagem da câmera com o cliente autenticado do driver.
+func fetchImage(ctx context.Context, do digestFunc, rawURL string) ([]byte, string, error) {
+	ctxReq, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	resp, err := do(ctxReq, http.MethodGet, rawURL, nil, "")
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, "", fmt.Errorf("image status %d: %s", resp.StatusCode, string(b))
+	}
+	ctype := resp.Header.Get("Content-Type")
+	if ctype == "" {
+		ctype = "image/jpeg"
+	}
+	img, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(img) == 0 {
+		return nil, "", fmt.Errorf("imagem vazia")
+	}
+	return img, ctype, nil
+}
@@ -0,0 +1,86 @@
+package drivers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func TestEventChannelNumericTypes(t *testing.T) {
+	cases := []struct {
+		name string
+		v    interface{}
+		want int
+	}{
+		{"int", 3, 3},
+		{"int64", int64(4), 4},
+		{"int32", int32(5), 5},
+		{"uint", uint(6), 6},
+		{"float64", float64(7), 7},
+		{"json.Number", json.Number("8"), 8},
+		{"string", " 9 ", 9},
+		{"zero", int64(0), defaultSnapshotChannel},
+		{"negativo", -2, defaultSnapshotChannel},
+		{"inválido", "abc", defaultSnapshotChannel},
+		{"ausente", nil, defaultSnapshotChannel},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			meta := map[string]interface{}{}
+			if tc.v != nil {
+				meta["channelID"] = tc.v
+			}
+			if got := eventChannel(meta, "channelID"); got != tc.want {
+				t.Fatalf("eventChannel(%#v) = %d, esperava %d", tc.v, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEventChannelKeyOrder(t *testing.T) {
+	meta := map[string]interface{}{"channelID": int64(0), "dynChannelID": int64(2)}
+	if got := eventChannel(meta, "channelID", "dynChannelID"); got != 2 {
+		t.Fatalf("esperava cair em dynChannelID, veio %d", got)
+	}
+}
+
+// O parser JSON da Hikvision guarda channelID como int64 (getNumber); o
+// snapshot tem que sair desse canal, não do padrão.
+func TestHikvisionSnapshotURLFollowsEventChannel(t *testing.T) {
+	drv, err := NewHikvisionDriver(core.CameraInfo{IP: "10.0.0.10", Manufacturer: "hikvision"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	evt, err := drv.(*HikvisionDriver).parseJSONEvent([]byte(`{"eventType":"faceCapture","channelID":3,"dateTime":"2024-01-01T00:00:00Z"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	channel := eventChannel(evt.Meta, "channelID", "dynChannelID")
+	if channel != 3 {
+		t.Fatalf("canal = %d (Meta[channelID] = %#v), esperava 3", channel, evt.Meta["channelID"])
+	}
+	want := "http://10.0.0.10/ISAPI/Streaming/channels/301/picture"
+	if got := hikvisionPictureURL("http://10.0.0.10", channel); got != want {
+		t.Fatalf("URL = %s, esperava %s", got, want)
+	}
+	if got := hikvisionPictureURL("http://10.0.0.10", eventChannel(nil, "channelID")); got != "http://10.0.0.10/ISAPI/Streaming/channels/101/picture" {
+		t.Fatalf("sem canal: %s", got)
+	}
+}
+
+func TestDahuaSnapshotURLChannel(t *testing.T) {
+	cases := []struct {
+		channel, snapType int
+		want              string
+	}{
+		{2, 0, "http://cam/cgi-bin/snapshot.cgi?channel=2"},
+		{0, 0, "http://cam/cgi-bin/snapshot.cgi?channel=1"},
+		{3, 1, "http://cam/cgi-bin/snapshot.cgi?channel=3&type=1"},
+	}
+	for _, tc := range cases {
+		if got := dahuaSnapshotURL("http", "cam", tc.channel, tc.snapType); got != tc.want {
+			t.Errorf("dahuaSnapshotURL(%d, %d) = %s, esperava %s", tc.channel, tc.snapType, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package drivers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+// colonHex formata o fingerprint como os navegadores mostram (AB:CD:...).
+func colonHex(b []byte) string {
+	parts := make([]string, len(b))
+	for i, c := range b {
+		parts[i] = strings.ToUpper(hex.EncodeToString([]byte{c}))
+	}
+	return strings.Join(parts, ":")
+}
+
+func getWithPin(t *testing.T, url, pin string) error {
+	t.Helper()
+	cfg, err := cameraTLSConfig(core.CameraInfo{UseTLS: true, CertFingerprint: pin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func TestCameraTLSPinMatch(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	sum := sha256.Sum256(srv.Certificate().Raw)
+
+	for _, pin := range []string{
+		colonHex(sum[:]),
+		hex.EncodeToString(sum[:]),
+		"sha256:" + strings.ToUpper(hex.EncodeToString(sum[:])),
+	} {
+		if err := getWithPin(t, srv.URL, pin); err != nil {
+			t.Fatalf("pin %q: %v", pin, err)
+		}
+	}
+}
+
+func TestCameraTLSPinMismatch(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	other := sha256.Sum256([]byte("outro certificado"))
+	err := getWithPin(t, srv.URL, hex.EncodeToString(other[:]))
+	if err == nil || !strings.Contains(err.Error(), "fingerprint") {
+		t.Fatalf("pin errado deveria recusar a conexão, veio %v", err)
+	}
+}
+
+func TestCameraTLSWithoutPinIsInsecure(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	if err := getWithPin(t, srv.URL, ""); err != nil {
+		t.Fatalf("sem pin o cert self-signed deveria ser aceito: %v", err)
+	}
+}
+
+func TestInvalidFingerprintRejected(t *testing.T) {
+	for _, raw := range []string{"zz", "abcd", "sha256:12"} {
+		if _, err := normalizeFingerprint(raw); err == nil {
+			t.Fatalf("normalizeFingerprint(%q) deveria falhar", raw)
+		}
+	}
+	if _, err := NewDahuaDriver(core.CameraInfo{UseTLS: true, CertFingerprint: "abcd"}); err == nil {
+		t.Fatal("driver com cert_fingerprint inválido deveria falhar na criação")
+	}
+}
@@ -0,0 +1,92 @@
+// internal/drivers/validate.go
+package drivers
+
+import (
+	"strings"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+// AnalyticsSelection é o resultado das regras de analytics de um driver
+// (selectedEventTypes/selectedEventCodes/selectedAnalytics), sem os logs.
+type AnalyticsSelection struct {
+	// Selected são os analytics que o driver vai assinar.
+	Selected []string
+	// Unsupported são os itens do /info que o driver ignora.
+	Unsupported []string
+	// All indica "ALL"/"*" no /info.
+	All bool
+	// Fallback indica que nada válido veio do /info e Selected saiu do
+	// fallback (FallbackEnv; vazio quando o fallback não é configurável).
+	// Fallback com Selected vazio = o driver não assina nada.
+	Fallback    bool
+	FallbackEnv string
+}
+
+// filterAnalytics aplica a regra comum: itens vazios são pulados, "ALL"/"*"
+// seleciona all (quando all != nil) e o resto passa por canonical.
+func filterAnalytics(analytics []string, canonical func(string) (string, bool), all []string) AnalyticsSelection {
+	var sel AnalyticsSelection
+	for _, a := range analytics {
+		name := strings.TrimSpace(a)
+		if name == "" {
+			continue
+		}
+		if all != nil && (strings.EqualFold(name, "all") || name == "*") {
+			sel.All = true
+			sel.Selected = all
+			return sel
+		}
+		if c, ok := canonical(name); ok {
+			sel.Selected = append(sel.Selected, c)
+		} else {
+			sel.Unsupported = append(sel.Unsupported, name)
+		}
+	}
+	return sel
+}
+
+// inSet aceita o nome como veio quando está no set (chaves em minúsculas).
+func inSet(set map[string]struct{}) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		_, ok := set[strings.ToLower(name)]
+		return name, ok
+	}
+}
+
+// SelectAnalytics aplica ao /info as mesmas regras do driver que o supervisor
+// vai criar. known=false quando não há lista de eventos para o fabricante.
+// Os fallbacks (*_FALLBACK_ANALYTICS) são lidos do ambiente atual.
+func SelectAnalytics(info core.CameraInfo) (sel AnalyticsSelection, known bool) {
+	manufacturer := normalize(info.Manufacturer)
+	switch transport := strings.TrimSpace(info.EventTransport); {
+	case strings.EqualFold(transport, EventTransportRTSP):
+		// metadata RTSP usa os tópicos ONVIF, qualquer que seja o fabricante
+		manufacturer = "onvif"
+	case strings.EqualFold(transport, EventTransportPoll):
+		manufacturer = EventTransportPoll
+	}
+
+	switch manufacturer {
+	case "hikvision":
+		return hikvisionAnalytics(info.Analytics), true
+	case "dahua":
+		return dahuaAnalytics(info.Analytics), true
+	case "onvif":
+		return onvifAnalytics(info.Analytics), true
+	case "axis":
+		return axisAnalytics(info.Analytics), true
+	case EventTransportPoll:
+		return pollAnalytics(info.Analytics, pollURL(info, info.MotionPath) != "", pollSnapshotURL(info) != ""), true
+	default:
+		return AnalyticsSelection{}, false
+	}
+}
+
+// UnsupportedAnalytics devolve os analytics do /info que o driver do fabricante
+// vai ignorar (SelectAnalytics). known=false quando não há lista de eventos
+// para o fabricante.
+func UnsupportedAnalytics(info core.CameraInfo) (unsupported []string, known bool) {
+	sel, known := SelectAnalytics(info)
+	return sel.Unsupported, known
+}
@@ -0,0 +1,75 @@
+package drivers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func TestSelectAnalyticsMatchesDriver(t *testing.T) {
+	cases := []struct {
+		name string
+		info core.CameraInfo
+	}{
+		{"hikvision", core.CameraInfo{Manufacturer: "hikvision", Analytics: []string{"faceCapture", "bogus"}}},
+		{"hikvision fallback", core.CameraInfo{Manufacturer: "hikvision"}},
+		{"dahua all", core.CameraInfo{Manufacturer: "dahua", Analytics: []string{"ALL"}}},
+		{"dahua", core.CameraInfo{Manufacturer: "dahua", Analytics: []string{"FaceDetection", "bogus"}}},
+		{"onvif", core.CameraInfo{Manufacturer: "onvif", Analytics: []string{"motiondetection"}}},
+		{"axis fallback", core.CameraInfo{Manufacturer: "axis", Analytics: []string{"bogus"}}},
+		{"poll", core.CameraInfo{Manufacturer: "hikvision", EventTransport: EventTransportPoll, Analytics: []string{"motion", "snapshot"}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.info.IP = "10.0.0.10"
+			drv, err := GetDriver(tc.info)
+			if err != nil {
+				t.Fatalf("GetDriver: %v", err)
+			}
+			sel, known := SelectAnalytics(tc.info)
+			if !known {
+				t.Fatal("fabricante deveria ser conhecido")
+			}
+			active := drv.(AnalyticsReporter).ActiveAnalytics()
+			if !reflect.DeepEqual(sel.Selected, active) {
+				t.Fatalf("SelectAnalytics = %v, driver assina %v", sel.Selected, active)
+			}
+		})
+	}
+}
+
+func TestSelectAnalyticsRules(t *testing.T) {
+	sel, _ := SelectAnalytics(core.CameraInfo{Manufacturer: "hikvision", Analytics: []string{" ", "bogus", "faceCapture"}})
+	if sel.Fallback || !reflect.DeepEqual(sel.Unsupported, []string{"bogus"}) {
+		t.Fatalf("hikvision: %+v", sel)
+	}
+
+	sel, _ = SelectAnalytics(core.CameraInfo{Manufacturer: "hikvision", Analytics: []string{"ALL"}})
+	if sel.All || !sel.Fallback || sel.FallbackEnv != "HIK_FALLBACK_ANALYTICS" {
+		t.Fatalf("hikvision não aceita ALL: %+v", sel)
+	}
+
+	sel, _ = SelectAnalytics(core.CameraInfo{Manufacturer: "dahua", Analytics: []string{"*"}})
+	if !sel.All || len(sel.Selected) != len(core.DahuaEventTypes) {
+		t.Fatalf("dahua *: %+v", sel)
+	}
+
+	t.Setenv("DAHUA_FALLBACK_ANALYTICS", "none")
+	sel, _ = SelectAnalytics(core.CameraInfo{Manufacturer: "dahua"})
+	if !sel.Fallback || len(sel.Selected) != 0 {
+		t.Fatalf("dahua com fallback vazio: %+v", sel)
+	}
+
+	if _, known := SelectAnalytics(core.CameraInfo{Manufacturer: "acme"}); known {
+		t.Fatal("fabricante sem lista de eventos deveria ser desconhecido")
+	}
+}
+
+func TestUnsupportedAnalyticsUsesDriverRules(t *testing.T) {
+	// ONVIF canoniza sem diferenciar maiúsculas, como o driver
+	unsupported, known := UnsupportedAnalytics(core.CameraInfo{Manufacturer: "onvif", Analytics: []string{"MOTIONDETECTION", "bogus"}})
+	if !known || !reflect.DeepEqual(unsupported, []string{"bogus"}) {
+		t.Fatalf("UnsupportedAnalytics = %v, %v", unsupported, known)
+	}
+}
@@ -0,0 +1,86 @@
+// internal/drivers/watchdog.go
+package drivers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/envconf"
+)
+
+const (
+	defaultHikvisionHeartbeat    = 30 * time.Second
+	defaultDahuaHeartbeat        = 5 * time.Second
+	defaultOnvifHeartbeat        = 10 * time.Second // timeout do PullMessages
+	defaultAxisHeartbeat         = 30 * time.Second // intervalo dos pings do WebSocket
+	defaultRTSPMetadataHeartbeat = 30 * time.Second // keepalive da sessão RTSP
+	defaultWatchdogFactor        = 3
+)
+
+// subscribeHeartbeat resolve o heartbeat pedido à câmera na assinatura de
+// eventos: subscribe_heartbeat_seconds do /info, senão
+// DRIVER_SUBSCRIBE_HEARTBEAT (duração Go ou segundos), senão o default do driver.
+func subscribeHeartbeat(info core.CameraInfo, def time.Duration) time.Duration {
+	if info.SubscribeHeartbeatSeconds > 0 {
+		return time.Duration(info.SubscribeHeartbeatSeconds) * time.Second
+	}
+	d := envconf.Duration("DRIVER_SUBSCRIBE_HEARTBEAT", def)
+	if d < time.Second {
+		log.Printf("[drivers] DRIVER_SUBSCRIBE_HEARTBEAT abaixo de 1s (%s), usando %s", d, def)
+		return def
+	}
+	return d
+}
+
+// watchdogTimeout é quanto o stream pode ficar sem dados antes de ser dado
+// como morto: heartbeat × DRIVER_WATCHDOG_FACTOR (default 3; 0 desliga).
+func watchdogTimeout(heartbeat time.Duration) time.Duration {
+	return heartbeat * time.Duration(envconf.Int("DRIVER_WATCHDOG_FACTOR", defaultWatchdogFactor))
+}
+
+// heartbeatSeconds formata o heartbeat para a assinatura (mínimo 1s).
+func heartbeatSeconds(heartbeat time.Duration) int {
+	return max(int(heartbeat/time.Second), 1)
+}
+
+// watchdogBody fecha o corpo do stream se nenhum byte chegar dentro de
+// timeout; a leitura pendente então falha com o motivo do watchdog.
+type watchdogBody struct {
+	io.ReadCloser
+	timer   *time.Timer
+	timeout time.Duration
+	expired atomic.Bool
+}
+
+// withWatchdog envolve body; timeout <= 0 devolve body sem watchdog.
+func withWatchdog(body io.ReadCloser, timeout time.Duration) io.ReadCloser {
+	if timeout <= 0 {
+		return body
+	}
+	w := &watchdogBody{ReadCloser: body, timeout: timeout}
+	w.timer = time.AfterFunc(timeout, func() {
+		w.expired.Store(true)
+		_ = body.Close()
+	})
+	return w
+}
+
+func (w *watchdogBody) Read(p []byte) (int, error) {
+	n, err := w.ReadCloser.Read(p)
+	if n > 0 {
+		w.timer.Reset(w.timeout)
+	}
+	if err != nil && w.expired.Load() {
+		err = fmt.Errorf("sem dados/heartbeat da câmera há %s", w.timeout)
+	}
+	return n, err
+}
+
+func (w *watchdogBody) Close() error {
+	w.timer.Stop()
+	return w.ReadCloser.Close()
+}
@@ -0,0 +1,85 @@
+package drivers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func TestSubscribeHeartbeat(t *testing.T) {
+	def := 30 * time.Second
+	for raw, want := range map[string]time.Duration{
+		"":      def,
+		"45s":   45 * time.Second,
+		"90":    90 * time.Second,
+		"2m":    2 * time.Minute,
+		"500ms": def,
+		"0":     def,
+		"abc":   def,
+	} {
+		t.Setenv("DRIVER_SUBSCRIBE_HEARTBEAT", raw)
+		if got := subscribeHeartbeat(core.CameraInfo{}, def); got != want {
+			t.Errorf("DRIVER_SUBSCRIBE_HEARTBEAT=%q: %s, esperava %s", raw, got, want)
+		}
+	}
+	// subscribe_heartbeat_seconds do /info vence o env
+	t.Setenv("DRIVER_SUBSCRIBE_HEARTBEAT", "45s")
+	if got := subscribeHeartbeat(core.CameraInfo{SubscribeHeartbeatSeconds: 7}, def); got != 7*time.Second {
+		t.Fatalf("heartbeat do /info = %s", got)
+	}
+}
+
+func TestWatchdogTimeout(t *testing.T) {
+	for raw, want := range map[string]time.Duration{"": 30 * time.Second, "5": 50 * time.Second, "0": 0, "-1": 30 * time.Second, "abc": 30 * time.Second} {
+		t.Setenv("DRIVER_WATCHDOG_FACTOR", raw)
+		if got := watchdogTimeout(10 * time.Second); got != want {
+			t.Errorf("DRIVER_WATCHDOG_FACTOR=%q: %s, esperava %s", raw, got, want)
+		}
+	}
+}
+
+func TestHikvisionSubscribeUsesConfiguredHeartbeat(t *testing.T) {
+	t.Setenv("DRIVER_SUBSCRIBE_HEARTBEAT", "45")
+	drv, err := NewHikvisionDriver(core.CameraInfo{IP: "10.0.0.1", DeviceID: "cam1", Manufacturer: "hikvision", Analytics: []string{"faceCapture"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	xml := string(drv.(*HikvisionDriver).buildSubscribeEventXML())
+	if !strings.Contains(xml, "<heartbeat>45</heartbeat>") {
+		t.Fatalf("subscribeEvent sem o heartbeat configurado: %s", xml)
+	}
+}
+
+func TestDahuaWatchdogFollowsHeartbeat(t *testing.T) {
+	t.Setenv("DRIVER_SUBSCRIBE_HEARTBEAT", "1")
+	t.Setenv("DRIVER_WATCHDOG_FACTOR", "1")
+
+	queries := make(chan string, 1)
+	info := cameraServer(t, func(w http.ResponseWriter, r *http.Request) {
+		queries <- r.URL.RawQuery
+		// stream aberto que nunca manda nada (nem heartbeat)
+		w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary=myboundary")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	d := newTestDahua(t, info)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	err := d.runOnce(ctx, make(chan core.AnalyticEvent, 1))
+	if err == nil || !strings.Contains(err.Error(), "sem dados/heartbeat da câmera há 1s") {
+		t.Fatalf("runOnce = %v, esperava o watchdog de 1s", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Fatalf("watchdog demorou %s", elapsed)
+	}
+	if q := <-queries; !strings.Contains(q, "heartbeat=1") {
+		t.Fatalf("attach sem o heartbeat configurado: %s", q)
+	}
+}
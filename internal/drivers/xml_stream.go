@@ -0,0 +1,64 @@
+// internal/drivers/xml_stream.go
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+)
+
+const (
+	xmlAlertEnd = "</EventNotificationAlert>"
+
+	// documento sem fechamento além disso = stream corrompido, reconecta
+	maxXMLDocumentSize = 1 << 20
+)
+
+// readXMLDocuments é o equivalente de readMultipartParts para firmwares
+// Hikvision que mandam o subscribeEvent como application/xml contínuo: corta
+// o stream em cada </EventNotificationAlert> e entrega cada documento como
+// uma parte do tipo contentType. Assim o loop do driver é o mesmo nos dois casos.
+func readXMLDocuments(ctx context.Context, r io.Reader, contentType string) <-chan multipartPart {
+	out := make(chan multipartPart)
+	go func() {
+		defer close(out)
+		send := func(p multipartPart) bool {
+			select {
+			case out <- p:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var buf []byte
+		chunk := make([]byte, 32*1024)
+		for {
+			n, err := r.Read(chunk)
+			buf = append(buf, chunk[:n]...)
+
+			for {
+				i := bytes.Index(buf, []byte(xmlAlertEnd))
+				if i < 0 {
+					break
+				}
+				end := i + len(xmlAlertEnd)
+				doc := append([]byte(nil), bytes.TrimSpace(buf[:end])...)
+				buf = append(buf[:0], buf[end:]...)
+				if !send(multipartPart{contentType: contentType, data: doc}) {
+					return
+				}
+			}
+
+			if err == nil && len(buf) > maxXMLDocumentSize {
+				err = fmt.Errorf("documento XML sem %s em %d bytes", xmlAlertEnd, len(buf))
+			}
+			if err != nil {
+				send(multipartPart{err: err})
+				return
+			}
+		}
+	}()
+	return out
+}
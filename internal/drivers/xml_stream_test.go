@@ -0,0 +1,109 @@
+package drivers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func xmlAlert(channel int, eventType string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<EventNotificationAlert version="2.0" xmlns="http://www.isapi.org/ver20/XMLSchema">
+<ipAddress>10.0.0.1</ipAddress>
+<channelID>%d</channelID>
+<dateTime>2024-03-01T12:00:00-03:00</dateTime>
+<eventType>%s</eventType>
+<eventState>active</eventState>
+<eventDescription>%s alarm</eventDescription>
+</EventNotificationAlert>`, channel, eventType, eventType)
+}
+
+// collectXMLDocuments lê as partes até o erro que encerra o stream.
+func collectXMLDocuments(t *testing.T, r io.Reader) ([]string, error) {
+	t.Helper()
+	var docs []string
+	for part := range readXMLDocuments(context.Background(), r, "application/xml") {
+		if part.err != nil {
+			return docs, part.err
+		}
+		if part.contentType != "application/xml" {
+			t.Fatalf("contentType = %q", part.contentType)
+		}
+		docs = append(docs, string(part.data))
+	}
+	t.Fatal("canal fechou sem o erro de fim do stream")
+	return nil, nil
+}
+
+func TestReadXMLDocumentsConcatenated(t *testing.T) {
+	stream := xmlAlert(1, "linedetection") + "\r\n" + xmlAlert(2, "fielddetection") + "\n<EventNotif"
+	d := newTestHikvision(t, core.CameraInfo{IP: "10.0.0.1", DeviceID: "c1"})
+
+	// tudo num Read só e byte a byte (documento cortado entre leituras)
+	for name, r := range map[string]io.Reader{
+		"inteiro":     strings.NewReader(stream),
+		"byte a byte": iotest.OneByteReader(strings.NewReader(stream)),
+	} {
+		docs, err := collectXMLDocuments(t, r)
+		if !errors.Is(err, io.EOF) {
+			t.Fatalf("%s: erro final = %v, esperava io.EOF", name, err)
+		}
+		if len(docs) != 2 {
+			t.Fatalf("%s: %d documentos, esperava 2: %q", name, len(docs), docs)
+		}
+
+		var got []string
+		for _, doc := range docs {
+			if !strings.HasPrefix(doc, "<?xml") || !strings.HasSuffix(doc, xmlAlertEnd) {
+				t.Fatalf("%s: documento mal cortado: %q", name, doc)
+			}
+			evt, err := d.parseXMLEvent([]byte(doc))
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			got = append(got, fmt.Sprintf("%s@%v", evt.AnalyticType, evt.Meta["channelID"]))
+		}
+		if strings.Join(got, ",") != "linedetection@1,fielddetection@2" {
+			t.Fatalf("%s: eventos = %v", name, got)
+		}
+	}
+}
+
+func TestReadXMLDocumentsOversized(t *testing.T) {
+	garbage := strings.NewReader(strings.Repeat("<a>lixo</a>", maxXMLDocumentSize/10))
+	docs, err := collectXMLDocuments(t, io.MultiReader(strings.NewReader(xmlAlert(1, "faceCapture")), garbage))
+	if len(docs) != 1 || err == nil || !strings.Contains(err.Error(), "sem "+xmlAlertEnd) {
+		t.Fatalf("docs=%d err=%v, esperava o documento válido e o erro de tamanho", len(docs), err)
+	}
+}
+
+func TestReadXMLDocumentsStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	parts := readXMLDocuments(ctx, pr, "application/xml")
+
+	go pw.Write([]byte(xmlAlert(1, "faceCapture") + xmlAlert(2, "faceCapture")))
+	if p := <-parts; p.err != nil || len(p.data) == 0 {
+		t.Fatalf("primeira parte = %+v", p)
+	}
+	cancel()
+	select {
+	case _, ok := <-parts:
+		if ok {
+			// a segunda pode já estar pronta; depois dela o canal fecha
+			if _, ok = <-parts; ok {
+				t.Fatal("canal deveria fechar após o cancelamento")
+			}
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("leitor não parou após o cancelamento")
+	}
+}
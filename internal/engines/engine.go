@@ -2,8 +2,10 @@ package engines
 
 import (
     "context"
+    "time"
 
     "github.com/sua-org/cam-bus/internal/core"
+    "github.com/sua-org/cam-bus/internal/faceengine"
 )
 
 // Engine é um pós-processador de eventos.
@@ -23,3 +25,41 @@ type Engine interface {
     // - error     => erro (o supervisor decide se loga e segue)
     Process(ctx context.Context, evt core.AnalyticEvent) ([]core.AnalyticEvent, error)
 }
+
+// LoadReporter é opcional: engines que falam com serviços externos podem
+// expor carga atual (requisições em andamento e fila) para o status do collector.
+type LoadReporter interface {
+    InFlight() int64
+    QueueDepth() int64
+}
+
+// EngineLoad é o snapshot de carga de uma engine.
+type EngineLoad struct {
+    InFlight   int64 `json:"in_flight"`
+    QueueDepth int64 `json:"queue_depth"`
+}
+
+// HealthReporter é opcional: engines com checagem ativa (keepalive) expõem
+// a última alcançabilidade do serviço externo.
+type HealthReporter interface {
+    Health() EngineHealth
+}
+
+// EngineHealth é o último resultado da checagem de uma engine.
+type EngineHealth struct {
+    Reachable bool
+    CheckedAt time.Time
+    Error     string
+}
+
+// Closer é opcional: engines com goroutines próprias (ex.: keepalive) as
+// encerram no shutdown do supervisor.
+type Closer interface {
+    Close()
+}
+
+// Recognizer é opcional: engines de face que aceitam reconhecimento avulso de
+// uma imagem (diagnóstico via POST /recognize), sem gerar evento.
+type Recognizer interface {
+    Recognize(ctx context.Context, img []byte) (*faceengine.Recognition, error)
+}
@@ -26,6 +26,18 @@ func (e *FindFaceEngine) Name() string { return "findface" }
 
 func (e *FindFaceEngine) Enabled() bool { return e != nil && e.fe != nil && e.fe.Enabled() }
 
+func (e *FindFaceEngine) InFlight() int64 { return e.fe.InFlight() }
+
+func (e *FindFaceEngine) QueueDepth() int64 { return e.fe.QueueDepth() }
+
+// Close encerra o keepalive do FindFace.
+func (e *FindFaceEngine) Close() { e.fe.Close() }
+
+func (e *FindFaceEngine) Health() EngineHealth {
+    h := e.fe.Health()
+    return EngineHealth{Reachable: h.Reachable, CheckedAt: h.CheckedAt, Error: h.Error}
+}
+
 func (e *FindFaceEngine) Process(ctx context.Context, evt core.AnalyticEvent) ([]core.AnalyticEvent, error) {
     if !e.Enabled() {
         return nil, nil
@@ -36,3 +48,7 @@ func (e *FindFaceEngine) Process(ctx context.Context, evt core.AnalyticEvent) ([
     }
     return []core.AnalyticEvent{*out}, nil
 }
+
+func (e *FindFaceEngine) Recognize(ctx context.Context, img []byte) (*faceengine.Recognition, error) {
+    return e.fe.Recognize(ctx, img)
+}
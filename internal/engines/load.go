@@ -3,11 +3,16 @@ package engines
 import (
     "log"
     "os"
-    "strconv"
     "strings"
     "time"
+
+    "github.com/sua-org/cam-bus/internal/envconf"
 )
 
+// defaultMaxDerivedPerEvent limita os derivados de um único evento de origem
+// (ex.: faceCapture com muitas faces). 0 em ENGINE_MAX_DERIVED_PER_EVENT desliga.
+const defaultMaxDerivedPerEvent = 50
+
 // LoadFromEnv carrega as engines habilitadas.
 //
 // Preferencial: ENGINES="findface,plater" (comma-separated)
@@ -22,7 +27,7 @@ func LoadFromEnv() *Manager {
         }
     }
 
-    timeout := envDurationSeconds("ENGINE_TIMEOUT_SECONDS", 10*time.Second)
+    timeout := envconf.Seconds("ENGINE_TIMEOUT_SECONDS", 10*time.Second)
 
     var list []Engine
     for _, n := range names {
@@ -41,6 +46,7 @@ func LoadFromEnv() *Manager {
     }
 
     m := NewManager(list, timeout)
+    m.maxDerived = envconf.Int("ENGINE_MAX_DERIVED_PER_EVENT", defaultMaxDerivedPerEvent)
     if m.Enabled() {
         log.Printf("[engines] habilitadas: %s", strings.Join(m.Names(), ","))
     } else {
@@ -65,15 +71,3 @@ func parseCSV(v string) []string {
     }
     return out
 }
-
-func envDurationSeconds(key string, def time.Duration) time.Duration {
-    v := strings.TrimSpace(os.Getenv(key))
-    if v == "" {
-        return def
-    }
-    sec, err := strconv.Atoi(v)
-    if err != nil || sec <= 0 {
-        return def
-    }
-    return time.Duration(sec) * time.Second
-}
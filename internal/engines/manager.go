@@ -9,6 +9,8 @@ import (
     "time"
 
     "github.com/sua-org/cam-bus/internal/core"
+    "github.com/sua-org/cam-bus/internal/logthrottle"
+    "github.com/sua-org/cam-bus/internal/tracing"
 )
 
 type Manager struct {
@@ -16,6 +18,9 @@ type Manager struct {
 
     // timeout padrão para cada engine
     perEngineTimeout time.Duration
+
+    // máximo de eventos derivados por evento de origem (0 = sem limite)
+    maxDerived int
 }
 
 func NewManager(engines []Engine, perEngineTimeout time.Duration) *Manager {
@@ -61,6 +66,66 @@ func (m *Manager) Has(name string) bool {
     return false
 }
 
+// Recognizer devolve a primeira engine que implementa Recognizer (nil se nenhuma).
+func (m *Manager) Recognizer() Recognizer {
+    if m == nil {
+        return nil
+    }
+    for _, e := range m.engines {
+        if r, ok := e.(Recognizer); ok {
+            return r
+        }
+    }
+    return nil
+}
+
+// Load retorna a carga atual das engines que implementam LoadReporter.
+func (m *Manager) Load() map[string]EngineLoad {
+    if m == nil {
+        return nil
+    }
+    out := make(map[string]EngineLoad)
+    for _, e := range m.engines {
+        lr, ok := e.(LoadReporter)
+        if !ok {
+            continue
+        }
+        out[e.Name()] = EngineLoad{InFlight: lr.InFlight(), QueueDepth: lr.QueueDepth()}
+    }
+    return out
+}
+
+// Health retorna a última checagem das engines que implementam HealthReporter
+// (somente as que já checaram ao menos uma vez).
+func (m *Manager) Health() map[string]EngineHealth {
+    if m == nil {
+        return nil
+    }
+    out := make(map[string]EngineHealth)
+    for _, e := range m.engines {
+        hr, ok := e.(HealthReporter)
+        if !ok {
+            continue
+        }
+        if h := hr.Health(); !h.CheckedAt.IsZero() {
+            out[e.Name()] = h
+        }
+    }
+    return out
+}
+
+// Close encerra as engines que implementam Closer.
+func (m *Manager) Close() {
+    if m == nil {
+        return
+    }
+    for _, e := range m.engines {
+        if c, ok := e.(Closer); ok {
+            c.Close()
+        }
+    }
+}
+
 // ProcessAll roda todas as engines em sequência e retorna todos os eventos derivados.
 // Nunca dá panic (proteção de recover por engine).
 func (m *Manager) ProcessAll(ctx context.Context, evt core.AnalyticEvent) ([]core.AnalyticEvent, error) {
@@ -75,7 +140,8 @@ func (m *Manager) ProcessAll(ctx context.Context, evt core.AnalyticEvent) ([]cor
         }
 
         // Timeout por engine para não travar o pipeline
-        ctxEng, cancel := context.WithTimeout(ctx, m.perEngineTimeout)
+        ctxEng, span := tracing.Start(ctx, "engine."+e.Name())
+        ctxEng, cancel := context.WithTimeout(ctxEng, m.perEngineTimeout)
         derived, err := func() (res []core.AnalyticEvent, err error) {
             defer func() {
                 if r := recover(); r != nil {
@@ -86,6 +152,9 @@ func (m *Manager) ProcessAll(ctx context.Context, evt core.AnalyticEvent) ([]cor
             return e.Process(ctxEng, evt)
         }()
         cancel()
+        span.RecordError(err)
+        span.SetAttr("engine.derived", fmt.Sprintf("%d", len(derived)))
+        span.End()
 
         if err != nil {
             // por enquanto: loga e segue (não falha o worker)
@@ -96,5 +165,14 @@ func (m *Manager) ProcessAll(ctx context.Context, evt core.AnalyticEvent) ([]cor
             out = append(out, derived...)
         }
     }
+
+    // Proteção do publish: resposta multi-face anômala ou engine com bug não
+    // pode inundar o broker a partir de um único evento.
+    if m.maxDerived > 0 && len(out) > m.maxDerived {
+        logthrottle.Printf("engines:max-derived",
+            "[engines] %d eventos derivados de %s/%s (%s) excedem ENGINE_MAX_DERIVED_PER_EVENT=%d; descartando %d",
+            len(out), evt.DeviceID, evt.AnalyticType, evt.EventID, m.maxDerived, len(out)-m.maxDerived)
+        out = out[:m.maxDerived]
+    }
     return out, nil
 }
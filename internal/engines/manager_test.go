@@ -0,0 +1,91 @@
+package engines
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+// fanoutEngine devolve n derivados por evento, com EventID "<nome>-<i>".
+type fanoutEngine struct {
+	name string
+	n    int
+}
+
+func (e fanoutEngine) Name() string  { return e.name }
+func (e fanoutEngine) Enabled() bool { return true }
+func (e fanoutEngine) Process(_ context.Context, evt core.AnalyticEvent) ([]core.AnalyticEvent, error) {
+	out := make([]core.AnalyticEvent, e.n)
+	for i := range out {
+		out[i] = core.AnalyticEvent{EventID: fmt.Sprintf("%s-%d", e.name, i), AnalyticType: "faceRecognized", DeviceID: evt.DeviceID}
+	}
+	return out, nil
+}
+
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+func eventIDs(evts []core.AnalyticEvent) string {
+	ids := make([]string, len(evts))
+	for i, e := range evts {
+		ids[i] = e.EventID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestProcessAllTruncatesDerived(t *testing.T) {
+	src := core.AnalyticEvent{EventID: "src-1", AnalyticType: "faceCapture", DeviceID: "cam1"}
+	engines := []Engine{fanoutEngine{"a", 3}, fanoutEngine{"b", 3}}
+
+	cases := []struct {
+		max  int
+		want string
+		warn bool
+	}{
+		{4, "a-0,a-1,a-2,b-0", true},
+		{6, "a-0,a-1,a-2,b-0,b-1,b-2", false},
+		{0, "a-0,a-1,a-2,b-0,b-1,b-2", false},
+	}
+	for _, tc := range cases {
+		buf := captureLog(t)
+		m := NewManager(engines, 0)
+		m.maxDerived = tc.max
+
+		out, err := m.ProcessAll(context.Background(), src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := eventIDs(out); got != tc.want {
+			t.Errorf("max=%d: derivados %s, esperava %s", tc.max, got, tc.want)
+		}
+		warned := strings.Contains(buf.String(), "ENGINE_MAX_DERIVED_PER_EVENT")
+		if warned != tc.warn {
+			t.Errorf("max=%d: aviso = %t, esperava %t (log: %q)", tc.max, warned, tc.warn, buf.String())
+		}
+		if tc.warn && !strings.Contains(buf.String(), "6 eventos derivados de cam1/faceCapture (src-1) excedem ENGINE_MAX_DERIVED_PER_EVENT=4; descartando 2") {
+			t.Errorf("aviso sem os detalhes do evento: %q", buf.String())
+		}
+	}
+}
+
+func TestLoadFromEnvMaxDerived(t *testing.T) {
+	t.Setenv("ENGINES", "")
+	t.Setenv("FACE_ENGINE", "")
+	for raw, want := range map[string]int{"": defaultMaxDerivedPerEvent, "10": 10, "0": 0, "-3": defaultMaxDerivedPerEvent, "abc": defaultMaxDerivedPerEvent} {
+		t.Setenv("ENGINE_MAX_DERIVED_PER_EVENT", raw)
+		if got := LoadFromEnv().maxDerived; got != want {
+			t.Errorf("ENGINE_MAX_DERIVED_PER_EVENT=%q: %d, esperava %d", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// internal/envconf/envconf.go
+package envconf
+
+import (
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration lê uma duração Go ("30s", "1m") ou segundos inteiros ("30").
+// Vazio usa def; "0" devolve 0 (desligado, para quem usa 0 assim); negativo
+// ou inválido loga e usa def.
+func Duration(key string, def time.Duration) time.Duration {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+		return d
+	}
+	if sec, err := strconv.Atoi(v); err == nil && sec >= 0 {
+		return time.Duration(sec) * time.Second
+	}
+	log.Printf("[config] valor inválido em %s=%q, usando default %s", key, v, def)
+	return def
+}
+
+// Seconds lê segundos inteiros > 0 (variáveis *_SECONDS); vazio, zero ou
+// inválido usa def.
+func Seconds(key string, def time.Duration) time.Duration {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	sec, err := strconv.Atoi(v)
+	if err != nil || sec <= 0 {
+		log.Printf("[config] valor inválido em %s=%q, usando default %s", key, v, def)
+		return def
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// Int lê um inteiro >= 0; vazio ou inválido usa def.
+func Int(key string, def int) int {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		log.Printf("[config] valor inválido em %s=%q, usando default %d", key, v, def)
+		return def
+	}
+	return n
+}
+
+// PositiveInt lê um inteiro > 0; vazio, zero ou inválido usa def.
+func PositiveInt(key string, def int) int {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		if err != nil || n < 0 {
+			log.Printf("[config] valor inválido em %s=%q, usando default %d", key, v, def)
+		}
+		return def
+	}
+	return n
+}
+
+// Float lê um número >= 0 ("2.5"); vazio ou inválido usa def.
+func Float(key string, def float64) float64 {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+		log.Printf("[config] valor inválido em %s=%q, usando default %g", key, v, def)
+		return def
+	}
+	return f
+}
+
+// Bool aceita 1/true/yes/y/on e 0/false/no/n/off (sem caixa); vazio ou
+// inválido usa def.
+func Bool(key string, def bool) bool {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return def
+	}
+	switch strings.ToLower(raw) {
+	case "1", "true", "yes", "y", "on":
+		return true
+	case "0", "false", "no", "n", "off":
+		return false
+	}
+	log.Printf("[config] valor inválido em %s=%q, usando default %t", key, raw, def)
+	return def
+}
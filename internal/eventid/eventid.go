@@ -0,0 +1,133 @@
+// internal/eventid/eventid.go
+package eventid
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Strategy define como IDs são gerados quando a câmera não manda um
+// (EVENT_ID_STRATEGY=native|uuid|ksuid, default native).
+type Strategy string
+
+const (
+	// StrategyNative mantém o formato histórico <prefixo>-<UnixNano>.
+	StrategyNative Strategy = "native"
+	// StrategyUUID gera <collector>-<uuid v4>.
+	StrategyUUID Strategy = "uuid"
+	// StrategyKSUID gera <collector>-<ksuid> (ordenável por tempo).
+	StrategyKSUID Strategy = "ksuid"
+)
+
+var (
+	loadOnce  sync.Once
+	strategy  Strategy
+	collector string
+)
+
+func load() {
+	loadOnce.Do(func() {
+		strategy = StrategyNative
+		switch s := Strategy(strings.ToLower(strings.TrimSpace(os.Getenv("EVENT_ID_STRATEGY")))); s {
+		case "":
+		case StrategyNative, StrategyUUID, StrategyKSUID:
+			strategy = s
+		default:
+			log.Printf("[eventid] EVENT_ID_STRATEGY inválido (%q), usando native", s)
+		}
+		collector = collectorID()
+	})
+}
+
+// collectorID identifica esta instância: CAMBUS_COLLECTOR_ID, ou
+// hostname + CAMBUS_SHARD.
+func collectorID() string {
+	if id := strings.TrimSpace(os.Getenv("CAMBUS_COLLECTOR_ID")); id != "" {
+		return sanitize(id)
+	}
+	host, _ := os.Hostname()
+	if host == "" {
+		host = "cambus"
+	}
+	if shard := strings.TrimSpace(os.Getenv("CAMBUS_SHARD")); shard != "" {
+		host += "." + shard
+	}
+	return sanitize(host)
+}
+
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// Current devolve a estratégia configurada.
+func Current() Strategy {
+	load()
+	return strategy
+}
+
+// Collector devolve o id do collector embutido nos IDs gerados.
+func Collector() string {
+	load()
+	return collector
+}
+
+// Resolve prefere o id nativo da câmera; sem ele, gera um conforme a estratégia.
+func Resolve(native, prefix string, ts time.Time) string {
+	if native = strings.TrimSpace(native); native != "" {
+		return native
+	}
+	return New(prefix, ts)
+}
+
+// New gera um ID de evento. prefix/ts só são usados na estratégia native.
+func New(prefix string, ts time.Time) string {
+	switch Current() {
+	case StrategyUUID:
+		return collector + "-" + uuid.NewString()
+	case StrategyKSUID:
+		return collector + "-" + newKSUID(time.Now())
+	default:
+		if ts.IsZero() {
+			ts = time.Now()
+		}
+		return fmt.Sprintf("%s-%d", prefix, ts.UnixNano())
+	}
+}
+
+// KSUID: 4 bytes de segundos desde 2014-05-13 + 16 bytes aleatórios, em base62 (27 chars).
+const (
+	ksuidEpoch  = 1400000000
+	ksuidLength = 27
+	base62      = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+func newKSUID(now time.Time) string {
+	var raw [20]byte
+	binary.BigEndian.PutUint32(raw[:4], uint32(now.Unix()-ksuidEpoch))
+	_, _ = rand.Read(raw[4:])
+
+	n := new(big.Int).SetBytes(raw[:])
+	base := big.NewInt(62)
+	mod := new(big.Int)
+	out := make([]byte, ksuidLength)
+	for i := ksuidLength - 1; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		out[i] = base62[mod.Int64()]
+	}
+	return string(out)
+}
@@ -0,0 +1,63 @@
+package eventid
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// withStrategy força a releitura do ambiente com a estratégia dada.
+func withStrategy(t *testing.T, s string) {
+	t.Helper()
+	t.Setenv("EVENT_ID_STRATEGY", s)
+	t.Setenv("CAMBUS_COLLECTOR_ID", "edge 01/sp")
+	loadOnce = sync.Once{}
+	t.Cleanup(func() { loadOnce = sync.Once{} })
+}
+
+func TestNativeKeepsHistoricFormat(t *testing.T) {
+	withStrategy(t, "")
+	ts := time.Unix(0, 1700000000123456789)
+	if got := New("faceCapture", ts); got != "faceCapture-1700000000123456789" {
+		t.Fatalf("native = %q", got)
+	}
+	if got := Resolve(" 42 ", "x", ts); got != "42" {
+		t.Fatalf("Resolve deveria preferir o id da câmera: %q", got)
+	}
+}
+
+func TestUUIDStrategy(t *testing.T) {
+	withStrategy(t, "UUID")
+	if Current() != StrategyUUID || Collector() != "edge_01_sp" {
+		t.Fatalf("strategy=%s collector=%q", Current(), Collector())
+	}
+	re := regexp.MustCompile(`^edge_01_sp-[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	a, b := New("x", time.Time{}), New("x", time.Time{})
+	if !re.MatchString(a) || a == b {
+		t.Fatalf("uuid = %q / %q", a, b)
+	}
+}
+
+func TestKSUIDStrategy(t *testing.T) {
+	withStrategy(t, "ksuid")
+	id := strings.TrimPrefix(New("x", time.Time{}), "edge_01_sp-")
+	if len(id) != ksuidLength || strings.Trim(id, base62) != "" {
+		t.Fatalf("ksuid = %q", id)
+	}
+
+	// KSUIDs de segundos diferentes ordenam pelo tempo
+	older := newKSUID(time.Unix(1700000000, 0))
+	newer := newKSUID(time.Unix(1700000001, 0))
+	if older >= newer {
+		t.Fatalf("ksuid fora de ordem: %s >= %s", older, newer)
+	}
+}
+
+func TestInvalidStrategyFallsBackToNative(t *testing.T) {
+	withStrategy(t, "snowflake")
+	if Current() != StrategyNative {
+		t.Fatalf("strategy = %s", Current())
+	}
+}
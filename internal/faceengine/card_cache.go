@@ -0,0 +1,94 @@
+// internal/faceengine/card_cache.go
+package faceengine
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/envconf"
+	ff "github.com/sua-org/cam-bus/internal/findface"
+)
+
+// cardCacheMaxEntries limita o cache; cheio, as entradas vencidas são
+// descartadas e, se não bastar, sai a que vence primeiro.
+const cardCacheMaxEntries = 10000
+
+// cardCache guarda card, nome e foto do cadastro por cardID durante
+// FINDFACE_CARD_CACHE_TTL: a mesma pessoa reconhecida várias vezes seguidas
+// (entradas movimentadas) não repete GetCard + GetFaceObjectForCard.
+type cardCache struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[int]cachedCard
+}
+
+type cachedCard struct {
+	card     *ff.Card
+	name     string
+	photoURL string
+	expires  time.Time
+}
+
+// cardCacheFromEnv devolve nil sem FINDFACE_CARD_CACHE_TTL (duração Go ou
+// segundos; default: consulta o FindFace a cada match, como antes).
+func cardCacheFromEnv() *cardCache {
+	d := envconf.Duration("FINDFACE_CARD_CACHE_TTL", 0)
+	if d <= 0 {
+		return nil
+	}
+	log.Printf("[faceengine] cache de cards FindFace habilitado (ttl=%s)", d)
+	return newCardCache(d)
+}
+
+func newCardCache(ttl time.Duration) *cardCache {
+	return &cardCache{ttl: ttl, maxEntries: cardCacheMaxEntries, now: time.Now, entries: map[int]cachedCard{}}
+}
+
+func (c *cardCache) get(cardID int) (cachedCard, bool) {
+	if c == nil {
+		return cachedCard{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.entries[cardID]
+	if !ok || !c.now().Before(cached.expires) {
+		return cachedCard{}, false
+	}
+	return cached, true
+}
+
+func (c *cardCache) put(cardID int, card *ff.Card, name, photoURL string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if _, ok := c.entries[cardID]; !ok && len(c.entries) >= c.maxEntries {
+		for id, cached := range c.entries {
+			if !now.Before(cached.expires) {
+				delete(c.entries, id)
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			c.evictOldest()
+		}
+	}
+	c.entries[cardID] = cachedCard{card: card, name: name, photoURL: photoURL, expires: now.Add(c.ttl)}
+}
+
+// evictOldest tira a entrada que vence primeiro (a gravada há mais tempo,
+// já que o ttl é o mesmo para todas). Chamar com c.mu.
+func (c *cardCache) evictOldest() {
+	oldest, first := 0, true
+	for id, cached := range c.entries {
+		if first || cached.expires.Before(c.entries[oldest].expires) {
+			oldest, first = id, false
+		}
+	}
+	delete(c.entries, oldest)
+}
@@ -0,0 +1,168 @@
+package faceengine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ff "github.com/sua-org/cam-bus/internal/findface"
+)
+
+// cardServer é um FindFace de mentira que conta as consultas de card por id.
+// faceEvent (JSON) é o evento devolvido por /events/faces/ para quem cria um
+// evento em /events/faces/add/.
+type cardServer struct {
+	mu        sync.Mutex
+	getCard   map[string]int
+	failCard  bool
+	faceEvent string
+}
+
+func (s *cardServer) calls(id int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getCard[fmt.Sprint(id)]
+}
+
+func (s *cardServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/cards/humans/"):
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/cards/humans/"), "/")
+		s.mu.Lock()
+		s.getCard[id]++
+		fail := s.failCard
+		s.mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"id": %s, "name": "Pessoa %s"}`, id, id)
+	case r.URL.Path == "/events/faces/add/":
+		io.WriteString(w, `{"id": "ff-1"}`)
+	case r.URL.Path == "/events/faces/":
+		s.mu.Lock()
+		evt := s.faceEvent
+		s.mu.Unlock()
+		fmt.Fprintf(w, `{"count": 1, "results": [%s]}`, evt)
+	case r.URL.Path == "/objects/faces/":
+		fmt.Fprintf(w, `{"count": 1, "results": [{"id": "f1", "source_photo": "http://ff/photo/%s.jpg"}]}`, r.URL.Query().Get("card"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newCardCacheEngine(t *testing.T, ttl time.Duration) (*Engine, *cardServer, *time.Time) {
+	t.Helper()
+	stub := &cardServer{getCard: map[string]int{}}
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+
+	now := time.Unix(1700000000, 0)
+	cards := newCardCache(ttl)
+	cards.now = func() time.Time { return now }
+	return &Engine{client: ff.New(srv.URL, "token", "", 0, ""), cards: cards}, stub, &now
+}
+
+func TestCardCacheHitAndMiss(t *testing.T) {
+	e, stub, _ := newCardCacheEngine(t, time.Minute)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		card, name, photo := e.describeCard(ctx, 7)
+		if card == nil || card.ID != 7 || name != "Pessoa 7" || photo != "http://ff/photo/7.jpg" {
+			t.Fatalf("consulta %d: card=%+v name=%q photo=%q", i, card, name, photo)
+		}
+	}
+	if n := stub.calls(7); n != 1 {
+		t.Fatalf("GetCard(7) chamado %d vezes, esperava só a primeira", n)
+	}
+
+	e.describeCard(ctx, 8)
+	if n := stub.calls(8); n != 1 {
+		t.Fatalf("outro card deveria ir ao FindFace, GetCard(8) = %d", n)
+	}
+}
+
+func TestCardCacheSkipsFailedLookups(t *testing.T) {
+	e, stub, _ := newCardCacheEngine(t, time.Minute)
+	stub.failCard = true
+
+	for i := 0; i < 2; i++ {
+		if card, _, _ := e.describeCard(context.Background(), 7); card != nil {
+			t.Fatalf("card = %+v, esperava nil com o GetCard falhando", card)
+		}
+	}
+	if n := stub.calls(7); n != 2 {
+		t.Fatalf("consulta com erro não deveria ficar em cache, GetCard = %d", n)
+	}
+}
+
+func TestCardCacheExpiresAfterTTL(t *testing.T) {
+	e, stub, now := newCardCacheEngine(t, time.Minute)
+	ctx := context.Background()
+
+	e.describeCard(ctx, 7)
+	*now = now.Add(time.Minute - time.Second)
+	e.describeCard(ctx, 7)
+	if n := stub.calls(7); n != 1 {
+		t.Fatalf("dentro do ttl GetCard = %d, esperava 1", n)
+	}
+	*now = now.Add(time.Second)
+	e.describeCard(ctx, 7)
+	if n := stub.calls(7); n != 2 {
+		t.Fatalf("depois do ttl GetCard = %d, esperava nova consulta", n)
+	}
+}
+
+func TestCardCacheBoundedSize(t *testing.T) {
+	e, stub, now := newCardCacheEngine(t, time.Hour)
+	e.cards.maxEntries = 3
+	ctx := context.Background()
+
+	for id := 1; id <= 4; id++ {
+		e.describeCard(ctx, id)
+		*now = now.Add(time.Second)
+	}
+	if n := len(e.cards.entries); n != 3 {
+		t.Fatalf("entradas = %d, esperava o limite (3)", n)
+	}
+	// o mais antigo saiu; os outros continuam no cache
+	for id := 2; id <= 4; id++ {
+		e.describeCard(ctx, id)
+		if n := stub.calls(id); n != 1 {
+			t.Fatalf("card %d deveria continuar em cache, GetCard = %d", id, n)
+		}
+	}
+	e.describeCard(ctx, 1)
+	if n := stub.calls(1); n != 2 {
+		t.Fatalf("card 1 deveria ter sido evictado, GetCard = %d", n)
+	}
+}
+
+func TestCardCacheFromEnv(t *testing.T) {
+	for _, raw := range []string{"", "0", "abc", "-1m"} {
+		t.Setenv("FINDFACE_CARD_CACHE_TTL", raw)
+		if c := cardCacheFromEnv(); c != nil {
+			t.Fatalf("FINDFACE_CARD_CACHE_TTL=%q deveria desligar o cache", raw)
+		}
+	}
+	for raw, want := range map[string]time.Duration{"5m": 5 * time.Minute, "60": time.Minute, " 90s ": 90 * time.Second} {
+		t.Setenv("FINDFACE_CARD_CACHE_TTL", raw)
+		if c := cardCacheFromEnv(); c == nil || c.ttl != want || c.maxEntries != cardCacheMaxEntries {
+			t.Fatalf("FINDFACE_CARD_CACHE_TTL=%q: cache = %+v, esperava ttl %s", raw, c, want)
+		}
+	}
+
+	// sem cache, get/put em nil não quebram
+	var c *cardCache
+	c.put(1, &ff.Card{ID: 1}, "x", "")
+	if _, ok := c.get(1); ok {
+		t.Fatal("cache nil não guarda nada")
+	}
+}
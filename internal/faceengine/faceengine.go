@@ -9,15 +9,45 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/logthrottle"
 	ff "github.com/sua-org/cam-bus/internal/findface"
+	"github.com/sua-org/cam-bus/internal/netproxy"
+	"github.com/sua-org/cam-bus/internal/storage"
 )
 
 // Engine é a fachada de alto nível para o FindFace.
 type Engine struct {
 	client *ff.Client
+
+	// pending conta eventos de face dentro do pipeline (aguardando ou em processamento).
+	pending atomic.Int64
+
+	// keepalive != nil quando FINDFACE_KEEPALIVE_INTERVAL está ligado.
+	keepalive *keepalive
+	// stopKeepalive encerra o loop do keepalive (Close).
+	stopKeepalive context.CancelFunc
+
+	// looksLikeThreshold > 0 liga o faceLikelyRecognized (FINDFACE_LOOKSLIKE_THRESHOLD).
+	looksLikeThreshold float64
+
+	// categories resolve ff_person_category pelas watch lists (FINDFACE_WATCHLIST_CATEGORIES).
+	categories *watchListCategories
+
+	// cards != nil guarda nome/foto por card (FINDFACE_CARD_CACHE_TTL).
+	cards *cardCache
+
+	// schedule != nil limita o reconhecimento a janelas (FACE_RECOGNITION_SCHEDULE).
+	schedule *recognitionSchedule
+
+	// minConfidence > 0 descarta matches com confiança menor (FINDFACE_MIN_CONFIDENCE).
+	minConfidence float64
+
+	// emitUnmatched publica faceDetected para rostos sem match (FINDFACE_EMIT_UNMATCHED).
+	emitUnmatched bool
 }
 
 // NewFromEnv inicializa o engine de face usando o client do FindFace.
@@ -42,7 +72,39 @@ func NewFromEnv() *Engine {
 	log.Printf("[faceengine] iniciado com FindFace em %s (camera_id=%d)",
 		client.BaseURL, client.CameraID)
 
-	return &Engine{client: client}
+	e := &Engine{
+		client:             client,
+		looksLikeThreshold: looksLikeThresholdFromEnv(),
+		categories:         watchListCategoriesFromEnv(),
+		cards:              cardCacheFromEnv(),
+		schedule:           recognitionScheduleFromEnv(),
+		minConfidence:      minConfidenceFromEnv(),
+		emitUnmatched:      emitUnmatchedFromEnv(),
+	}
+	if interval := keepaliveIntervalFromEnv(); interval > 0 {
+		e.keepalive = &keepalive{}
+		ctx, cancel := context.WithCancel(context.Background())
+		e.stopKeepalive = cancel
+		go e.runKeepalive(ctx, interval, strings.TrimSpace(os.Getenv("FINDFACE_KEEPALIVE_PATH")))
+		log.Printf("[faceengine] keepalive FindFace habilitado (intervalo=%s)", interval)
+	}
+	return e
+}
+
+// InFlight retorna quantas requisições ao FindFace estão em andamento.
+func (e *Engine) InFlight() int64 {
+	if e == nil {
+		return 0
+	}
+	return e.client.InFlight()
+}
+
+// QueueDepth retorna quantos eventos de face estão no pipeline do engine.
+func (e *Engine) QueueDepth() int64 {
+	if e == nil {
+		return 0
+	}
+	return e.pending.Load()
 }
 
 // Enabled retorna true se o engine está ativo.
@@ -55,8 +117,10 @@ func (e *Engine) Enabled() bool {
 // - carrega o snapshot (SnapshotB64 ou SnapshotURL);
 // - envia para o FindFace via CreateFaceEventFromBytes;
 // - consulta detalhes do evento + card;
-// - se houver match, devolve um novo AnalyticEvent com AnalyticType = "faceRecognized";
-// - se não houver match ou der "zero faces", retorna (nil, nil).
+// - se houver match com confiança >= FINDFACE_MIN_CONFIDENCE, devolve um novo
+//   AnalyticEvent com AnalyticType = "faceRecognized";
+// - sem match (ou abaixo do limiar) devolve faceLikelyRecognized/faceDetected
+//   quando configurados, senão (nil, nil); "zero faces" retorna (nil, nil).
 func (e *Engine) ProcessFaceCapture(
 	ctx context.Context,
 	evt core.AnalyticEvent,
@@ -71,6 +135,14 @@ func (e *Engine) ProcessFaceCapture(
 		return nil, nil
 	}
 
+	// fora da janela de FACE_RECOGNITION_SCHEDULE a imagem não sai do cam-bus
+	if !e.schedule.allowed(evt.DeviceID, time.Now()) {
+		return nil, nil
+	}
+
+	e.pending.Add(1)
+	defer e.pending.Add(-1)
+
 	// 1) tenta primeiro via SnapshotB64 (Hikvision e Dahua agora preenchem isso)
 	var img []byte
 	if evt.SnapshotB64 != "" {
@@ -84,12 +156,12 @@ func (e *Engine) ProcessFaceCapture(
 
 	// 2) fallback: tenta baixar SnapshotURL se não tiver base64
 	if len(img) == 0 && evt.SnapshotURL != "" {
-		httpCli := &http.Client{Timeout: 5 * time.Second}
+		httpCli := netproxy.Client(5 * time.Second)
 		req, err := http.NewRequestWithContext(ctx, http.MethodGet, evt.SnapshotURL, nil)
 		if err == nil {
 			resp, err := httpCli.Do(req)
 			if err != nil {
-				log.Printf("[faceengine] erro HTTP ao baixar SnapshotURL: %v", err)
+				logthrottle.Printf("faceengine:snapshot-url", "[faceengine] erro HTTP ao baixar SnapshotURL: %v", err)
 			} else {
 				defer resp.Body.Close()
 				if resp.StatusCode == http.StatusOK {
@@ -109,18 +181,22 @@ func (e *Engine) ProcessFaceCapture(
 		log.Printf("[faceengine] %s sem snapshot, nada para enviar ao FindFace", evt.AnalyticType)
 		return nil, nil
 	}
+	// SnapshotURL não passou pela validação do driver (SNAPSHOT_VALIDATE)
+	if err := storage.ValidateSnapshot(img); err != nil {
+		logthrottle.Printf("faceengine:invalid-snapshot", "[faceengine] snapshot inválido (evt_id=%s), FindFace ignorado: %v", evt.EventID, err)
+		return nil, nil
+	}
 
 	// 3) Cria evento de face no FindFace
 	res, err := e.client.CreateFaceEventFromBytes(ctx, img, "snapshot.jpg")
 	if err != nil {
 		// Se for "Zero objects(type=\"face\") detected...", tratamos como “sem rosto”
-		if strings.Contains(err.Error(), `Zero objects(type="face")`) ||
-			strings.Contains(err.Error(), `Zero objects(type=\"face\")`) {
+		if isZeroFaces(err) {
 			log.Printf("[faceengine] FindFace retornou zero faces para o snapshot (event_id? unknown, evt_id=%s)", evt.EventID)
 			return nil, nil
 		}
 
-		log.Printf("[faceengine] erro ao criar evento de face no FindFace: %v", err)
+		logthrottle.Printf("faceengine:create", "[faceengine] erro ao criar evento de face no FindFace: %v", err)
 		return nil, err
 	}
 	if res == nil || strings.TrimSpace(res.EventID) == "" {
@@ -132,55 +208,31 @@ func (e *Engine) ProcessFaceCapture(
 	// 4) Consulta detalhes do evento de face
 	fevent, err := e.client.GetFaceEvent(ctx, res.EventID)
 	if err != nil {
-		log.Printf("[faceengine] erro ao consultar GetFaceEvent(%s): %v", res.EventID, err)
+		logthrottle.Printf("faceengine:get-event", "[faceengine] erro ao consultar GetFaceEvent(%s): %v", res.EventID, err)
 		// não tratamos como erro fatal de pipeline, só logamos
 		return nil, nil
 	}
 
 	if !fevent.Matched || fevent.MatchedCard == nil {
-		// evento sem match em nenhum card
-		return nil, nil
+		// sem match confirmado: pode ainda ser um "possível match" (looks-like)
+		if likely := e.likelyRecognized(ctx, evt, fevent); likely != nil {
+			return likely, nil
+		}
+		return e.unmatched(evt, fevent, unmatchedNoMatch), nil
 	}
 
-    // 5) Consulta card (pessoa) correspondente
-    cardID := *fevent.MatchedCard
-    card, err := e.client.GetCard(ctx, cardID)
-    if err != nil {
-        log.Printf("[faceengine] erro ao consultar GetCard(%d): %v", cardID, err)
-    }
-
-    // Nome da pessoa
-    personName := ""
-    if card != nil {
-        personName = e.client.GetCardName(card)
-    }
-
-    // 5.1) Tenta buscar um objeto de face ligado a esse card (foto cadastrada na base)
-    var personPhotoURL string
-    faceObj, err := e.client.GetFaceObjectForCard(ctx, cardID)
-    if err != nil {
-        log.Printf("[faceengine] erro ao consultar GetFaceObjectForCard(%d): %v", cardID, err)
-    } else if faceObj != nil {
-        // Prioriza source_photo (foto inteira); se não tiver, cai no thumbnail
-        if strings.TrimSpace(faceObj.SourcePhoto) != "" {
-            personPhotoURL = strings.TrimSpace(faceObj.SourcePhoto)
-        } else if strings.TrimSpace(faceObj.Thumbnail) != "" {
-            personPhotoURL = strings.TrimSpace(faceObj.Thumbnail)
-        }
-    }
-
-    // 5.2) Fallback: tenta extrair URL de foto diretamente do card (features/meta)
-    if personPhotoURL == "" && card != nil {
-        if url := e.client.GetCardPhotoURL(card); url != "" {
-            personPhotoURL = url
-        }
-    }
+	// match com confiança abaixo de FINDFACE_MIN_CONFIDENCE: trata como sem match
+	conf := confidenceOf(fevent)
+	if e.belowMinConfidence(conf) {
+		log.Printf("[faceengine] match descartado: event=%s card=%v conf=%.4f < FINDFACE_MIN_CONFIDENCE %.4f",
+			fevent.ID, *fevent.MatchedCard, conf, e.minConfidence)
+		return e.unmatched(evt, fevent, unmatchedLowConfidence), nil
+	}
 
-    // Confiança
-    conf := fevent.Confidence
-    if fevent.LooksLikeConf != nil {
-        conf = *fevent.LooksLikeConf
-    }
+    // 5) Consulta card (pessoa) correspondente + foto cadastrada
+    cardID := *fevent.MatchedCard
+    card, personName, personPhotoURL := e.describeCard(ctx, cardID)
+    category := e.personCategory(ctx, fevent, card)
 
     // 6) Monta evento "faceRecognized" reaproveitando o contexto do evento original.
     recognized := evt
@@ -194,14 +246,17 @@ func (e *Engine) ProcessFaceCapture(
     recognized.Meta["ff_card_id"] = cardID
     recognized.Meta["ff_person_name"] = personName
     recognized.Meta["ff_confidence"] = conf
+    if category != "" {
+        recognized.Meta["ff_person_category"] = category
+    }
 
     // FOTO DO CADASTRO (base FindFace)
     if personPhotoURL != "" {
         recognized.Meta["ff_person_photo_url"] = personPhotoURL
     }
 
-    log.Printf("[faceengine] faceRecognized: event=%s card=%v name=%q conf=%.4f photo=%q",
-        fevent.ID, cardID, personName, conf, personPhotoURL)
+    log.Printf("[faceengine] faceRecognized: event=%s card=%v name=%q category=%q conf=%.4f photo=%q",
+        fevent.ID, cardID, personName, category, conf, personPhotoURL)
 
     return &recognized, nil
 }
@@ -0,0 +1,119 @@
+// internal/faceengine/keepalive.go
+package faceengine
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Health é o último resultado do keepalive do FindFace.
+type Health struct {
+	Reachable bool
+	CheckedAt time.Time
+	Error     string
+}
+
+type keepalive struct {
+	mu   sync.Mutex
+	last Health
+}
+
+func (k *keepalive) record(err error, at time.Time) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.last = Health{Reachable: err == nil, CheckedAt: at.UTC()}
+	if err != nil {
+		k.last.Error = err.Error()
+	}
+}
+
+func (k *keepalive) snapshot() Health {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.last
+}
+
+// keepaliveIntervalFromEnv lê FINDFACE_KEEPALIVE_INTERVAL em segundos (0/vazio = desligado).
+func keepaliveIntervalFromEnv() time.Duration {
+	raw := strings.TrimSpace(os.Getenv("FINDFACE_KEEPALIVE_INTERVAL"))
+	if raw == "" {
+		return 0
+	}
+	if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+		return d
+	}
+	if sec, err := strconv.Atoi(raw); err == nil && sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	log.Printf("[faceengine] FINDFACE_KEEPALIVE_INTERVAL=%q inválido, keepalive desligado", raw)
+	return 0
+}
+
+// maxKeepaliveTimeout limita o timeout de cada ping, mesmo com intervalos longos.
+const maxKeepaliveTimeout = 10 * time.Second
+
+// keepaliveTimeout fica abaixo do intervalo para que um ping lento não
+// encavale com o próximo tick.
+func keepaliveTimeout(interval time.Duration) time.Duration {
+	timeout := interval / 2
+	if timeout > maxKeepaliveTimeout {
+		timeout = maxKeepaliveTimeout
+	}
+	return timeout
+}
+
+// runKeepalive pinga o FindFace periodicamente para manter conexões quentes
+// atrás de load balancers que derrubam conexões ociosas. Para quando ctx é
+// cancelado (Engine.Close).
+func (e *Engine) runKeepalive(ctx context.Context, interval time.Duration, path string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	timeout := keepaliveTimeout(interval)
+	wasReachable := true
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := e.client.Ping(pingCtx, path)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		e.keepalive.record(err, time.Now())
+		switch {
+		case err != nil && wasReachable:
+			log.Printf("[faceengine] keepalive FindFace falhou: %v", err)
+		case err == nil && !wasReachable:
+			log.Printf("[faceengine] keepalive FindFace recuperado")
+		}
+		wasReachable = err == nil
+	}
+}
+
+// Close encerra o keepalive. Seguro para chamar mais de uma vez.
+func (e *Engine) Close() {
+	if e == nil || e.stopKeepalive == nil {
+		return
+	}
+	e.stopKeepalive()
+}
+
+// Health retorna o último resultado do keepalive (zero se desligado).
+func (e *Engine) Health() Health {
+	if e == nil || e.keepalive == nil {
+		return Health{}
+	}
+	return e.keepalive.snapshot()
+}
@@ -0,0 +1,99 @@
+package faceengine
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ff "github.com/sua-org/cam-bus/internal/findface"
+)
+
+func newKeepaliveEngine(t *testing.T, handler http.HandlerFunc) *Engine {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return &Engine{client: ff.New(srv.URL, "token", "", 0, ""), keepalive: &keepalive{}}
+}
+
+func TestKeepalivePingsOnInterval(t *testing.T) {
+	var hits atomic.Int64
+	e := newKeepaliveEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		e.runKeepalive(ctx, 20*time.Millisecond, "/ping")
+		close(done)
+	}()
+
+	time.Sleep(110 * time.Millisecond)
+	cancel()
+	<-done
+
+	if n := hits.Load(); n < 3 {
+		t.Fatalf("esperava ao menos 3 pings em ~5 intervalos, recebeu %d", n)
+	}
+	if h := e.Health(); !h.Reachable || h.CheckedAt.IsZero() {
+		t.Fatalf("health = %+v, esperava alcançável", h)
+	}
+}
+
+func TestKeepaliveRecordsFailure(t *testing.T) {
+	e := newKeepaliveEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.runKeepalive(ctx, 10*time.Millisecond, "/ping")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for e.Health().CheckedAt.IsZero() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	h := e.Health()
+	if h.Reachable || h.Error == "" {
+		t.Fatalf("health = %+v, esperava falha registrada", h)
+	}
+}
+
+func TestKeepaliveStopsOnClose(t *testing.T) {
+	var hits atomic.Int64
+	e := newKeepaliveEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	e.stopKeepalive = cancel
+
+	done := make(chan struct{})
+	go func() {
+		e.runKeepalive(ctx, 10*time.Millisecond, "/ping")
+		close(done)
+	}()
+	e.Close()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("runKeepalive não parou após Close")
+	}
+	before := hits.Load()
+	time.Sleep(40 * time.Millisecond)
+	if hits.Load() != before {
+		t.Fatal("keepalive continuou pingando após Close")
+	}
+}
+
+func TestKeepaliveTimeoutBelowInterval(t *testing.T) {
+	for _, interval := range []time.Duration{time.Second, 30 * time.Second, 5 * time.Minute} {
+		if got := keepaliveTimeout(interval); got >= interval || got > maxKeepaliveTimeout || got <= 0 {
+			t.Errorf("keepaliveTimeout(%s) = %s", interval, got)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// internal/faceengine/looks_like.go
+package faceengine
+
+import (
+	"context"
+	"log"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/envconf"
+	ff "github.com/sua-org/cam-bus/internal/findface"
+)
+
+// AnalyticLikelyRecognized é o evento de "possível match": o FindFace não
+// confirmou o match, mas o looks_like_confidence passou do limiar configurado.
+const AnalyticLikelyRecognized = "faceLikelyRecognized"
+
+// looksLikeThresholdFromEnv lê FINDFACE_LOOKSLIKE_THRESHOLD (0..1). Vazio ou 0
+// desliga o faceLikelyRecognized (comportamento anterior: sem match, sem evento).
+func looksLikeThresholdFromEnv() float64 {
+	v := envconf.Float("FINDFACE_LOOKSLIKE_THRESHOLD", 0)
+	if v > 1 {
+		log.Printf("[faceengine] FINDFACE_LOOKSLIKE_THRESHOLD fora de 0..1 (%g), faceLikelyRecognized desligado", v)
+		return 0
+	}
+	return v
+}
+
+// looksLikeCard devolve o card do possível match: looks_like_card, ou o
+// matched_card que alguns FindFace preenchem mesmo com matched=false.
+func looksLikeCard(fevent *ff.FaceEvent) (int, bool) {
+	if fevent.LooksLikeCard != nil {
+		return *fevent.LooksLikeCard, true
+	}
+	if fevent.MatchedCard != nil {
+		return *fevent.MatchedCard, true
+	}
+	return 0, false
+}
+
+// likelyRecognized monta o faceLikelyRecognized para um evento sem match
+// confirmado; nil quando o limiar está desligado ou não foi atingido.
+func (e *Engine) likelyRecognized(ctx context.Context, evt core.AnalyticEvent, fevent *ff.FaceEvent) *core.AnalyticEvent {
+	if e.looksLikeThreshold <= 0 || fevent.LooksLikeConf == nil || *fevent.LooksLikeConf < e.looksLikeThreshold {
+		return nil
+	}
+	cardID, ok := looksLikeCard(fevent)
+	if !ok {
+		return nil
+	}
+	conf := *fevent.LooksLikeConf
+	card, personName, personPhotoURL := e.describeCard(ctx, cardID)
+
+	likely := evt
+	likely.AnalyticType = AnalyticLikelyRecognized
+	// Meta novo, como no unmatched: o faceCapture original ainda é publicado
+	likely.Meta = make(map[string]interface{}, len(evt.Meta)+8)
+	for k, v := range evt.Meta {
+		likely.Meta[k] = v
+	}
+	likely.Meta["ff_event_id"] = fevent.ID
+	likely.Meta["ff_matched"] = false
+	likely.Meta["ff_looks_like_card_id"] = cardID
+	likely.Meta["ff_looks_like_confidence"] = conf
+	likely.Meta["ff_looks_like_threshold"] = e.looksLikeThreshold
+	likely.Meta["ff_person_name"] = personName
+	if card != nil {
+		// matched_lists do evento sem match não é da pessoa sugerida: só o card
+		if category := e.personCategory(ctx, &ff.FaceEvent{}, card); category != "" {
+			likely.Meta["ff_person_category"] = category
+		}
+	}
+	if personPhotoURL != "" {
+		likely.Meta["ff_person_photo_url"] = personPhotoURL
+	}
+
+	log.Printf("[faceengine] faceLikelyRecognized: event=%s card=%d name=%q looks_like=%.4f (limiar %.4f)",
+		fevent.ID, cardID, personName, conf, e.looksLikeThreshold)
+	return &likely
+}
@@ -0,0 +1,113 @@
+package faceengine
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"image/jpeg"
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	ff "github.com/sua-org/cam-bus/internal/findface"
+)
+
+// faceCaptureEvent é um faceCapture com um JPEG válido no SnapshotB64.
+func faceCaptureEvent(t *testing.T) core.AnalyticEvent {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return core.AnalyticEvent{
+		AnalyticType: "faceCapture",
+		DeviceID:     "c1",
+		EventID:      "evt-1",
+		SnapshotB64:  base64.StdEncoding.EncodeToString(buf.Bytes()),
+		Meta:         map[string]interface{}{},
+	}
+}
+
+// processWithFaceEvent roda o ProcessFaceCapture contra um FindFace que
+// devolve faceEvent para o snapshot enviado.
+func processWithFaceEvent(t *testing.T, e *Engine, stub *cardServer, faceEvent string) *core.AnalyticEvent {
+	t.Helper()
+	stub.mu.Lock()
+	stub.faceEvent = faceEvent
+	stub.mu.Unlock()
+	out, err := e.ProcessFaceCapture(context.Background(), faceCaptureEvent(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestLooksLikeThresholdFromEnv(t *testing.T) {
+	for raw, want := range map[string]float64{
+		"":     0,
+		"0":    0,
+		"0.75": 0.75,
+		"1":    1,
+		"1.5":  0,
+		"-0.2": 0,
+		"abc":  0,
+	} {
+		t.Setenv("FINDFACE_LOOKSLIKE_THRESHOLD", raw)
+		if got := looksLikeThresholdFromEnv(); got != want {
+			t.Errorf("FINDFACE_LOOKSLIKE_THRESHOLD=%q: %g, esperava %g", raw, got, want)
+		}
+	}
+}
+
+func TestProcessFaceCaptureConfidenceBands(t *testing.T) {
+	e, stub, _ := newCardCacheEngine(t, time.Minute)
+	e.looksLikeThreshold = 0.7
+
+	// match confirmado
+	got := processWithFaceEvent(t, e, stub, `{"id": "ff-1", "matched": true, "matched_card": 7, "confidence": 0.91}`)
+	if got == nil || got.AnalyticType != "faceRecognized" || got.Meta["ff_card_id"] != 7 || got.Meta["ff_person_name"] != "Pessoa 7" {
+		t.Fatalf("match: %+v", got)
+	}
+
+	// sem match, mas looks_like acima do limiar
+	got = processWithFaceEvent(t, e, stub, `{"id": "ff-1", "matched": false, "looks_like_confidence": 0.82, "looks_like_card": 5}`)
+	if got == nil || got.AnalyticType != AnalyticLikelyRecognized {
+		t.Fatalf("looks-like: %+v", got)
+	}
+	if got.Meta["ff_looks_like_card_id"] != 5 || got.Meta["ff_looks_like_confidence"] != 0.82 ||
+		got.Meta["ff_looks_like_threshold"] != 0.7 || got.Meta["ff_matched"] != false || got.Meta["ff_person_name"] != "Pessoa 5" {
+		t.Fatalf("Meta do faceLikelyRecognized = %v", got.Meta)
+	}
+
+	// abaixo do limiar: nada publicado (FINDFACE_EMIT_UNMATCHED desligado)
+	if got = processWithFaceEvent(t, e, stub, `{"id": "ff-1", "matched": false, "looks_like_confidence": 0.55, "looks_like_card": 5}`); got != nil {
+		t.Fatalf("abaixo do limiar: %+v, esperava nil", got)
+	}
+}
+
+func TestLikelyRecognizedEdges(t *testing.T) {
+	e, _, _ := newCardCacheEngine(t, time.Minute)
+	evt := faceCaptureEvent(t)
+	conf := 0.9
+	card := 3
+
+	// alguns FindFace preenchem matched_card mesmo com matched=false
+	if got := e.likelyRecognized(context.Background(), evt, &ff.FaceEvent{ID: "ff-1", LooksLikeConf: &conf, MatchedCard: &card}); got != nil {
+		t.Fatal("com o limiar desligado não deveria haver faceLikelyRecognized")
+	}
+	e.looksLikeThreshold = 0.7
+	got := e.likelyRecognized(context.Background(), evt, &ff.FaceEvent{ID: "ff-1", LooksLikeConf: &conf, MatchedCard: &card})
+	if got == nil || got.Meta["ff_looks_like_card_id"] != 3 {
+		t.Fatalf("fallback para matched_card: %+v", got)
+	}
+	if got := e.likelyRecognized(context.Background(), evt, &ff.FaceEvent{ID: "ff-1", LooksLikeConf: &conf}); got != nil {
+		t.Fatal("sem card sugerido não há faceLikelyRecognized")
+	}
+	if got := e.likelyRecognized(context.Background(), evt, &ff.FaceEvent{ID: "ff-1", MatchedCard: &card}); got != nil {
+		t.Fatal("sem looks_like_confidence não há faceLikelyRecognized")
+	}
+	if len(evt.Meta) != 0 {
+		t.Fatalf("Meta do faceCapture original alterado: %v", evt.Meta)
+	}
+}
@@ -0,0 +1,72 @@
+// internal/faceengine/min_confidence.go
+package faceengine
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/envconf"
+	ff "github.com/sua-org/cam-bus/internal/findface"
+)
+
+// AnalyticFaceDetected é o evento de rosto sem reconhecimento (sem match ou
+// abaixo de FINDFACE_MIN_CONFIDENCE), publicado só com FINDFACE_EMIT_UNMATCHED.
+const AnalyticFaceDetected = "faceDetected"
+
+// Motivos em Meta["ff_unmatched_reason"] do faceDetected.
+const (
+	unmatchedNoMatch       = "no_match"
+	unmatchedLowConfidence = "below_min_confidence"
+)
+
+// minConfidenceFromEnv lê FINDFACE_MIN_CONFIDENCE (0..1). Vazio ou 0 desliga o
+// filtro (comportamento anterior: todo match vira faceRecognized).
+func minConfidenceFromEnv() float64 {
+	raw := strings.TrimSpace(os.Getenv("FINDFACE_MIN_CONFIDENCE"))
+	if raw == "" {
+		return 0
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 || v > 1 {
+		log.Printf("[faceengine] FINDFACE_MIN_CONFIDENCE inválido (%q), filtro de confiança desligado", raw)
+		return 0
+	}
+	return v
+}
+
+// emitUnmatchedFromEnv lê FINDFACE_EMIT_UNMATCHED (default false).
+func emitUnmatchedFromEnv() bool {
+	return envconf.Bool("FINDFACE_EMIT_UNMATCHED", false)
+}
+
+// belowMinConfidence diz se o match deve ser descartado pelo limiar.
+func (e *Engine) belowMinConfidence(conf float64) bool {
+	return e.minConfidence > 0 && conf < e.minConfidence
+}
+
+// unmatched monta o faceDetected de um rosto não reconhecido; nil quando
+// FINDFACE_EMIT_UNMATCHED está desligado.
+func (e *Engine) unmatched(evt core.AnalyticEvent, fevent *ff.FaceEvent, reason string) *core.AnalyticEvent {
+	if !e.emitUnmatched {
+		return nil
+	}
+	detected := evt
+	detected.AnalyticType = AnalyticFaceDetected
+	// Meta novo: o mapa de evt é o do faceCapture original, que o supervisor
+	// ainda publica
+	detected.Meta = make(map[string]interface{}, len(evt.Meta)+5)
+	for k, v := range evt.Meta {
+		detected.Meta[k] = v
+	}
+	detected.Meta["ff_event_id"] = fevent.ID
+	detected.Meta["ff_matched"] = false
+	detected.Meta["ff_confidence"] = confidenceOf(fevent)
+	detected.Meta["ff_unmatched_reason"] = reason
+	if e.minConfidence > 0 {
+		detected.Meta["ff_min_confidence"] = e.minConfidence
+	}
+	return &detected
+}
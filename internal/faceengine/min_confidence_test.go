@@ -0,0 +1,91 @@
+package faceengine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	ff "github.com/sua-org/cam-bus/internal/findface"
+)
+
+func TestUnmatchedDoesNotMutateOriginalMeta(t *testing.T) {
+	e := &Engine{emitUnmatched: true, minConfidence: 0.8}
+	evt := core.AnalyticEvent{
+		AnalyticType: "faceCapture",
+		Meta:         map[string]interface{}{"channelID": 1},
+	}
+
+	detected := e.unmatched(evt, &ff.FaceEvent{ID: "ff-1", Confidence: 0.5}, unmatchedLowConfidence)
+	if detected == nil {
+		t.Fatal("unmatched devolveu nil com FINDFACE_EMIT_UNMATCHED ligado")
+	}
+	if detected.AnalyticType != AnalyticFaceDetected {
+		t.Fatalf("AnalyticType = %q, esperava %q", detected.AnalyticType, AnalyticFaceDetected)
+	}
+	if detected.Meta["ff_unmatched_reason"] != unmatchedLowConfidence || detected.Meta["channelID"] != 1 {
+		t.Fatalf("Meta do faceDetected = %v", detected.Meta)
+	}
+	if len(evt.Meta) != 1 {
+		t.Fatalf("Meta do faceCapture original foi alterado: %v", evt.Meta)
+	}
+}
+
+func TestUnmatchedDisabled(t *testing.T) {
+	e := &Engine{}
+	if got := e.unmatched(core.AnalyticEvent{}, &ff.FaceEvent{ID: "ff-1"}, unmatchedNoMatch); got != nil {
+		t.Fatalf("unmatched = %+v, esperava nil sem FINDFACE_EMIT_UNMATCHED", got)
+	}
+}
+
+func TestEmitUnmatchedFromEnv(t *testing.T) {
+	for raw, want := range map[string]bool{"": false, "true": true, "1": true, "on": true, "false": false, "off": false, "talvez": false} {
+		t.Setenv("FINDFACE_EMIT_UNMATCHED", raw)
+		if got := emitUnmatchedFromEnv(); got != want {
+			t.Errorf("FINDFACE_EMIT_UNMATCHED=%q: %t, esperava %t", raw, got, want)
+		}
+	}
+}
+
+func TestBelowMinConfidence(t *testing.T) {
+	e := &Engine{minConfidence: 0.8}
+	if !e.belowMinConfidence(0.79) || e.belowMinConfidence(0.8) || e.belowMinConfidence(0.95) {
+		t.Fatal("limiar 0.8: só confiança menor deveria ser descartada")
+	}
+	if (&Engine{}).belowMinConfidence(0.01) {
+		t.Fatal("sem FINDFACE_MIN_CONFIDENCE nenhum match é descartado")
+	}
+}
+
+func TestProcessFaceCaptureMinConfidence(t *testing.T) {
+	e, stub, _ := newCardCacheEngine(t, time.Minute)
+	e.minConfidence = 0.8
+
+	// acima do limiar: faceRecognized normal
+	got := processWithFaceEvent(t, e, stub, `{"id": "ff-1", "matched": true, "matched_card": 7, "confidence": 0.85}`)
+	if got == nil || got.AnalyticType != "faceRecognized" {
+		t.Fatalf("acima do limiar: %+v", got)
+	}
+
+	// abaixo do limiar sem FINDFACE_EMIT_UNMATCHED: descartado
+	below := `{"id": "ff-1", "matched": true, "matched_card": 7, "confidence": 0.6}`
+	if got := processWithFaceEvent(t, e, stub, below); got != nil {
+		t.Fatalf("abaixo do limiar: %+v, esperava nil", got)
+	}
+
+	// com FINDFACE_EMIT_UNMATCHED vira faceDetected com o motivo
+	e.emitUnmatched = true
+	got = processWithFaceEvent(t, e, stub, below)
+	if got == nil || got.AnalyticType != AnalyticFaceDetected ||
+		got.Meta["ff_unmatched_reason"] != unmatchedLowConfidence || got.Meta["ff_min_confidence"] != 0.8 || got.Meta["ff_confidence"] != 0.6 {
+		t.Fatalf("abaixo do limiar com emit: %+v", got)
+	}
+	if _, ok := got.Meta["ff_card_id"]; ok {
+		t.Fatalf("faceDetected não deveria expor o card descartado: %v", got.Meta)
+	}
+
+	// sem match nenhum
+	got = processWithFaceEvent(t, e, stub, `{"id": "ff-1", "matched": false, "confidence": 0.3}`)
+	if got == nil || got.Meta["ff_unmatched_reason"] != unmatchedNoMatch {
+		t.Fatalf("sem match com emit: %+v", got)
+	}
+}
@@ -0,0 +1,128 @@
+// internal/faceengine/recognize.go
+package faceengine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	ff "github.com/sua-org/cam-bus/internal/findface"
+	"github.com/sua-org/cam-bus/internal/logthrottle"
+)
+
+// Recognition é o resultado completo de um reconhecimento avulso (diagnóstico),
+// sem montar nem publicar evento.
+type Recognition struct {
+	FaceDetected   bool          `json:"face_detected"`
+	Matched        bool          `json:"matched"`
+	FFEventID      string        `json:"ff_event_id,omitempty"`
+	CardID         *int          `json:"card_id,omitempty"`
+	PersonName     string        `json:"person_name,omitempty"`
+	PersonPhotoURL string        `json:"person_photo_url,omitempty"`
+	PersonCategory string        `json:"person_category,omitempty"`
+	Confidence     float64       `json:"confidence"`
+	FaceEvent      *ff.FaceEvent `json:"face_event,omitempty"`
+	Card           *ff.Card      `json:"card,omitempty"`
+}
+
+// Recognize envia a imagem ao FindFace e devolve match e card. Diferente de
+// ProcessFaceCapture, erros do FindFace são devolvidos em vez de só logados.
+// Imagem sem rosto devolve FaceDetected=false e erro nil.
+func (e *Engine) Recognize(ctx context.Context, img []byte) (*Recognition, error) {
+	if !e.Enabled() {
+		return nil, fmt.Errorf("face engine desabilitado")
+	}
+	if len(img) == 0 {
+		return nil, fmt.Errorf("imagem vazia")
+	}
+
+	res, err := e.client.CreateFaceEventFromBytes(ctx, img, "snapshot.jpg")
+	if err != nil {
+		if isZeroFaces(err) {
+			return &Recognition{}, nil
+		}
+		return nil, fmt.Errorf("criar evento de face: %w", err)
+	}
+	if res == nil || strings.TrimSpace(res.EventID) == "" {
+		return nil, fmt.Errorf("FindFace não retornou id do evento de face")
+	}
+
+	fevent, err := e.client.GetFaceEvent(ctx, res.EventID)
+	if err != nil {
+		return nil, fmt.Errorf("consultar evento de face %s: %w", res.EventID, err)
+	}
+
+	rec := &Recognition{
+		FaceDetected: true,
+		Matched:      fevent.Matched && fevent.MatchedCard != nil,
+		FFEventID:    fevent.ID,
+		Confidence:   confidenceOf(fevent),
+		FaceEvent:    fevent,
+	}
+	if !rec.Matched {
+		return rec, nil
+	}
+	cardID := *fevent.MatchedCard
+	rec.CardID = &cardID
+	rec.Card, rec.PersonName, rec.PersonPhotoURL = e.describeCard(ctx, cardID)
+	rec.PersonCategory = e.personCategory(ctx, fevent, rec.Card)
+	return rec, nil
+}
+
+// describeCard busca o card do match, o nome da pessoa e a foto cadastrada
+// (source_photo do objeto de face, thumbnail ou URL nas features do card).
+// Com FINDFACE_CARD_CACHE_TTL, consultas completas sem erro ficam em cache.
+func (e *Engine) describeCard(ctx context.Context, cardID int) (*ff.Card, string, string) {
+	if cached, ok := e.cards.get(cardID); ok {
+		return cached.card, cached.name, cached.photoURL
+	}
+
+	complete := true
+	card, err := e.client.GetCard(ctx, cardID)
+	if err != nil {
+		complete = false
+		logthrottle.Printf("faceengine:get-card", "[faceengine] erro ao consultar GetCard(%d): %v", cardID, err)
+	}
+
+	personName := ""
+	if card != nil {
+		personName = e.client.GetCardName(card)
+	}
+
+	// Prioriza source_photo (foto inteira); se não tiver, cai no thumbnail
+	var personPhotoURL string
+	faceObj, err := e.client.GetFaceObjectForCard(ctx, cardID)
+	if err != nil {
+		complete = false
+		logthrottle.Printf("faceengine:get-face-object", "[faceengine] erro ao consultar GetFaceObjectForCard(%d): %v", cardID, err)
+	} else if faceObj != nil {
+		if strings.TrimSpace(faceObj.SourcePhoto) != "" {
+			personPhotoURL = strings.TrimSpace(faceObj.SourcePhoto)
+		} else if strings.TrimSpace(faceObj.Thumbnail) != "" {
+			personPhotoURL = strings.TrimSpace(faceObj.Thumbnail)
+		}
+	}
+
+	// Fallback: tenta extrair URL de foto diretamente do card (features/meta)
+	if personPhotoURL == "" && card != nil {
+		personPhotoURL = e.client.GetCardPhotoURL(card)
+	}
+	if complete && card != nil {
+		e.cards.put(cardID, card, personName, personPhotoURL)
+	}
+	return card, personName, personPhotoURL
+}
+
+// confidenceOf prefere looks_like_confidence quando o FindFace manda.
+func confidenceOf(fevent *ff.FaceEvent) float64 {
+	if fevent.LooksLikeConf != nil {
+		return *fevent.LooksLikeConf
+	}
+	return fevent.Confidence
+}
+
+// isZeroFaces detecta o erro "Zero objects(type="face") detected" do FindFace.
+func isZeroFaces(err error) bool {
+	return strings.Contains(err.Error(), `Zero objects(type="face")`) ||
+		strings.Contains(err.Error(), `Zero objects(type=\"face\")`)
+}
@@ -0,0 +1,250 @@
+// internal/faceengine/schedule.go
+package faceengine
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// recognitionSchedule limita o reconhecimento facial a janelas de dia/hora
+// (FACE_RECOGNITION_SCHEDULE, com override por device_id em
+// FACE_RECOGNITION_SCHEDULE_CAMERAS). Fora da janela o faceCapture segue
+// publicado, mas não vai ao FindFace nem gera faceRecognized.
+type recognitionSchedule struct {
+	def     *scheduleSpec            // nil = sempre ligado
+	cameras map[string]*scheduleSpec // device_id -> janela
+	loc     *time.Location
+
+	mu    sync.Mutex
+	state map[string]bool // último estado logado por câmera (fronteiras on/off)
+}
+
+// scheduleSpec é "always", "never" ou janelas separadas por ";", cada uma
+// "[dias] HH:MM-HH:MM" (ex.: "mon-fri 18:00-06:00; sat,sun 00:00-24:00").
+// Janela com fim <= início atravessa a meia-noite e pertence ao dia do início.
+type scheduleSpec struct {
+	raw     string
+	never   bool
+	windows []scheduleWindow
+}
+
+type scheduleWindow struct {
+	days       [7]bool // indexado por time.Weekday
+	start, end int     // minutos desde 00:00; end pode ser 1440
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+	"dom": time.Sunday, "seg": time.Monday, "ter": time.Tuesday, "qua": time.Wednesday,
+	"qui": time.Thursday, "sex": time.Friday, "sab": time.Saturday,
+}
+
+// recognitionScheduleFromEnv devolve nil quando nada está configurado
+// (reconhecimento sempre ligado, comportamento anterior). Spec inválida
+// desliga o reconhecimento (falha fechada: é regra de privacidade).
+func recognitionScheduleFromEnv() *recognitionSchedule {
+	raw := strings.TrimSpace(os.Getenv("FACE_RECOGNITION_SCHEDULE"))
+	camsPath := strings.TrimSpace(os.Getenv("FACE_RECOGNITION_SCHEDULE_CAMERAS"))
+	if raw == "" && camsPath == "" {
+		return nil
+	}
+
+	s := &recognitionSchedule{loc: time.Local, state: map[string]bool{}}
+	if tz := strings.TrimSpace(os.Getenv("FACE_RECOGNITION_TZ")); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			log.Printf("[faceengine] FACE_RECOGNITION_TZ inválido (%q), usando horário local: %v", tz, err)
+		} else {
+			s.loc = loc
+		}
+	}
+	if raw != "" {
+		s.def = mustScheduleSpec("FACE_RECOGNITION_SCHEDULE", raw)
+	}
+
+	if camsPath != "" {
+		var cams map[string]string
+		data, err := os.ReadFile(camsPath)
+		if err == nil {
+			err = yaml.Unmarshal(data, &cams)
+		}
+		if err != nil {
+			// sem saber as janelas das câmeras, desliga tudo que não tem default explícito
+			log.Printf("[faceengine] FACE_RECOGNITION_SCHEDULE_CAMERAS=%s inválido: %v (reconhecimento desligado)", camsPath, err)
+			s.def = &scheduleSpec{raw: "never", never: true}
+		}
+		s.cameras = make(map[string]*scheduleSpec, len(cams))
+		for cam, spec := range cams {
+			if cam = strings.TrimSpace(cam); cam != "" {
+				s.cameras[cam] = mustScheduleSpec("FACE_RECOGNITION_SCHEDULE_CAMERAS["+cam+"]", spec)
+			}
+		}
+	}
+
+	def := "always"
+	if s.def != nil {
+		def = s.def.raw
+	}
+	log.Printf("[faceengine] janela de reconhecimento facial: %q (%d câmeras com override, tz=%s)", def, len(s.cameras), s.loc)
+	return s
+}
+
+func mustScheduleSpec(key, raw string) *scheduleSpec {
+	spec, err := parseScheduleSpec(raw)
+	if err != nil {
+		log.Printf("[faceengine] %s inválido: %v (reconhecimento desligado)", key, err)
+		return &scheduleSpec{raw: "never", never: true}
+	}
+	return spec
+}
+
+func parseScheduleSpec(raw string) (*scheduleSpec, error) {
+	spec := &scheduleSpec{raw: strings.TrimSpace(raw)}
+	switch strings.ToLower(spec.raw) {
+	case "", "always":
+		return nil, nil
+	case "never":
+		spec.never = true
+		return spec, nil
+	}
+	for _, part := range strings.Split(spec.raw, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		w, err := parseScheduleWindow(part)
+		if err != nil {
+			return nil, err
+		}
+		spec.windows = append(spec.windows, w)
+	}
+	if len(spec.windows) == 0 {
+		return nil, fmt.Errorf("nenhuma janela em %q", raw)
+	}
+	return spec, nil
+}
+
+func parseScheduleWindow(part string) (scheduleWindow, error) {
+	var w scheduleWindow
+	fields := strings.Fields(part)
+	hours := fields[len(fields)-1]
+	days := strings.Join(fields[:len(fields)-1], "")
+
+	if days == "" || days == "*" {
+		for i := range w.days {
+			w.days[i] = true
+		}
+	} else {
+		for _, d := range strings.Split(strings.ToLower(days), ",") {
+			from, to, isRange := strings.Cut(d, "-")
+			a, ok := weekdayNames[from]
+			if !ok {
+				return w, fmt.Errorf("dia inválido %q em %q", from, part)
+			}
+			b := a
+			if isRange {
+				if b, ok = weekdayNames[to]; !ok {
+					return w, fmt.Errorf("dia inválido %q em %q", to, part)
+				}
+			}
+			for i := a; ; i = (i + 1) % 7 {
+				w.days[i] = true
+				if i == b {
+					break
+				}
+			}
+		}
+	}
+
+	start, end, ok := strings.Cut(hours, "-")
+	if !ok {
+		return w, fmt.Errorf("horário sem início-fim em %q", part)
+	}
+	var err error
+	if w.start, err = parseClock(start); err != nil || w.start == 24*60 {
+		return w, fmt.Errorf("início inválido em %q", part)
+	}
+	if w.end, err = parseClock(end); err != nil {
+		return w, fmt.Errorf("fim inválido em %q", part)
+	}
+	return w, nil
+}
+
+// parseClock lê HH:MM (ou HH) em minutos; aceita 24:00 como fim do dia.
+func parseClock(v string) (int, error) {
+	h, m, _ := strings.Cut(strings.TrimSpace(v), ":")
+	hh, err := strconv.Atoi(h)
+	if err != nil {
+		return 0, err
+	}
+	mm := 0
+	if m != "" {
+		if mm, err = strconv.Atoi(m); err != nil {
+			return 0, err
+		}
+	}
+	if hh < 0 || mm < 0 || mm > 59 || hh > 24 || (hh == 24 && mm != 0) {
+		return 0, fmt.Errorf("horário fora do intervalo: %q", v)
+	}
+	return hh*60 + mm, nil
+}
+
+// contains diz se t (já no fuso da agenda) cai em alguma janela.
+func (s *scheduleSpec) contains(t time.Time) bool {
+	if s == nil {
+		return true
+	}
+	if s.never {
+		return false
+	}
+	now := t.Hour()*60 + t.Minute()
+	today := t.Weekday()
+	yesterday := (today + 6) % 7
+	for _, w := range s.windows {
+		if w.end > w.start {
+			if w.days[today] && now >= w.start && now < w.end {
+				return true
+			}
+			continue
+		}
+		// atravessa a meia-noite: parte da noite de hoje ou madrugada de ontem
+		if (w.days[today] && now >= w.start) || (w.days[yesterday] && now < w.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed diz se o reconhecimento está liberado para a câmera agora e loga
+// cada fronteira (entrada/saída da janela) por câmera.
+func (s *recognitionSchedule) allowed(deviceID string, now time.Time) bool {
+	if s == nil {
+		return true
+	}
+	spec, ok := s.cameras[deviceID]
+	if !ok {
+		spec = s.def
+	}
+	in := spec.contains(now.In(s.loc))
+
+	s.mu.Lock()
+	last, seen := s.state[deviceID]
+	s.state[deviceID] = in
+	s.mu.Unlock()
+	if !seen || last != in {
+		state := "fora da janela: faceCapture não vai ao FindFace"
+		if in {
+			state = "dentro da janela: reconhecimento ligado"
+		}
+		log.Printf("[faceengine] câmera %s %s", deviceID, state)
+	}
+	return in
+}
@@ -0,0 +1,133 @@
+package faceengine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// at é um horário UTC em março de 2024 (dia 4 é uma segunda-feira).
+func at(day, hour, min int) time.Time {
+	return time.Date(2024, 3, day, hour, min, 0, 0, time.UTC)
+}
+
+func TestScheduleSpecWindows(t *testing.T) {
+	cases := []struct {
+		spec string
+		when time.Time
+		want bool
+	}{
+		// noturno em dia útil, fim de semana inteiro
+		{"mon-fri 18:00-06:00; sat,sun 00:00-24:00", at(4, 17, 59), false},
+		{"mon-fri 18:00-06:00; sat,sun 00:00-24:00", at(4, 18, 0), true},
+		{"mon-fri 18:00-06:00; sat,sun 00:00-24:00", at(5, 5, 59), true}, // madrugada de terça é da janela de segunda
+		{"mon-fri 18:00-06:00; sat,sun 00:00-24:00", at(5, 6, 0), false},
+		{"mon-fri 18:00-06:00; sat,sun 00:00-24:00", at(9, 5, 0), true},   // sábado
+		{"mon-fri 18:00-06:00; sat,sun 00:00-24:00", at(3, 23, 59), true}, // domingo
+		{"mon-fri 18:00-06:00; sat,sun 00:00-24:00", at(4, 3, 0), false},  // domingo não tem a janela noturna
+		// faixa de dias que dá a volta na semana, dias em português, hora sem minutos
+		{"fri-mon 08:00-12:00", at(3, 9, 0), true},
+		{"fri-mon 08:00-12:00", at(6, 9, 0), false},
+		{"seg,qua 8-12", at(6, 11, 59), true},
+		{"seg,qua 8-12", at(6, 12, 0), false},
+		// sem dias = todos
+		{"22:00-02:00", at(7, 1, 30), true},
+		{"22:00-02:00", at(7, 12, 0), false},
+		{"never", at(4, 12, 0), false},
+	}
+	for _, tc := range cases {
+		spec, err := parseScheduleSpec(tc.spec)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.spec, err)
+		}
+		if got := spec.contains(tc.when); got != tc.want {
+			t.Errorf("%q em %s: %t, esperava %t", tc.spec, tc.when.Format("Mon 15:04"), got, tc.want)
+		}
+	}
+
+	for _, raw := range []string{"", "always", " ALWAYS "} {
+		if spec, err := parseScheduleSpec(raw); spec != nil || err != nil {
+			t.Errorf("%q: %+v, %v, esperava sempre ligado", raw, spec, err)
+		}
+	}
+}
+
+func TestScheduleSpecInvalid(t *testing.T) {
+	for _, raw := range []string{"xyz 08:00-10:00", "mon-xyz 08:00-10:00", "08:00", "25:00-26:00", "24:00-02:00", "08:60-09:00", ";"} {
+		if _, err := parseScheduleSpec(raw); err == nil {
+			t.Errorf("%q deveria ser inválido", raw)
+		}
+	}
+	// spec inválida falha fechada
+	if spec := mustScheduleSpec("FACE_RECOGNITION_SCHEDULE", "seg 8h-12h"); spec.contains(at(4, 9, 0)) {
+		t.Fatal("spec inválida deveria desligar o reconhecimento")
+	}
+}
+
+func writeScheduleCameras(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "schedule.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRecognitionScheduleCameraOverrides(t *testing.T) {
+	t.Setenv("FACE_RECOGNITION_TZ", "UTC")
+	t.Setenv("FACE_RECOGNITION_SCHEDULE", "mon-fri 08:00-18:00")
+	t.Setenv("FACE_RECOGNITION_SCHEDULE_CAMERAS", writeScheduleCameras(t, `
+cam-doca: never
+cam-portaria: always
+cam-garagem: "22:00-06:00"
+cam-ruim: "sempre"
+`))
+	s := recognitionScheduleFromEnv()
+	if s == nil || s.loc != time.UTC {
+		t.Fatalf("agenda = %+v", s)
+	}
+
+	monNoon := at(4, 12, 0)
+	satNoon := at(9, 12, 0)
+	tueNight := at(5, 2, 0)
+	cases := []struct {
+		camera string
+		when   time.Time
+		want   bool
+	}{
+		{"cam-qualquer", monNoon, true},
+		{"cam-qualquer", satNoon, false},
+		{"cam-doca", monNoon, false},
+		{"cam-portaria", satNoon, true},
+		{"cam-garagem", monNoon, false},
+		{"cam-garagem", tueNight, true},
+		{"cam-ruim", monNoon, false},
+	}
+	for _, tc := range cases {
+		if got := s.allowed(tc.camera, tc.when); got != tc.want {
+			t.Errorf("%s em %s: %t, esperava %t", tc.camera, tc.when.Format("Mon 15:04"), got, tc.want)
+		}
+	}
+
+	// a agenda avalia no fuso configurado, não no do horário recebido
+	brt := time.FixedZone("BRT", -3*3600)
+	if !s.allowed("cam-qualquer", time.Date(2024, 3, 4, 6, 30, 0, 0, brt)) {
+		t.Fatal("06:30 BRT = 09:30 UTC deveria estar dentro da janela")
+	}
+}
+
+func TestRecognitionScheduleFromEnvDefaults(t *testing.T) {
+	t.Setenv("FACE_RECOGNITION_SCHEDULE", "")
+	t.Setenv("FACE_RECOGNITION_SCHEDULE_CAMERAS", "")
+	if s := recognitionScheduleFromEnv(); s != nil || !s.allowed("cam", time.Now()) {
+		t.Fatalf("sem configuração deveria ser sempre ligado: %+v", s)
+	}
+
+	// arquivo de câmeras ilegível desliga quem não tem override
+	t.Setenv("FACE_RECOGNITION_SCHEDULE", "always")
+	t.Setenv("FACE_RECOGNITION_SCHEDULE_CAMERAS", writeScheduleCameras(t, "cam-a: [isto, nao, e, string]"))
+	if s := recognitionScheduleFromEnv(); s.allowed("cam-a", at(4, 12, 0)) || s.allowed("cam-b", at(4, 12, 0)) {
+		t.Fatal("FACE_RECOGNITION_SCHEDULE_CAMERAS inválido deveria desligar o reconhecimento")
+	}
+}
@@ -0,0 +1,146 @@
+// internal/faceengine/watchlist_category.go
+package faceengine
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	ff "github.com/sua-org/cam-bus/internal/findface"
+	"github.com/sua-org/cam-bus/internal/logthrottle"
+)
+
+const defaultWatchListCacheTTL = 10 * time.Minute
+
+// categoryRule mapeia nomes de watch list (substring, sem caixa) para uma
+// categoria (ex.: blocklist, vip, employee).
+type categoryRule struct {
+	category string
+	patterns []string
+}
+
+// watchListCategories resolve a categoria da pessoa a partir das watch lists
+// do match. Os nomes das listas ficam em cache por FINDFACE_WATCHLIST_CACHE_TTL.
+type watchListCategories struct {
+	rules []categoryRule // em ordem de prioridade (FINDFACE_WATCHLIST_CATEGORIES)
+	ttl   time.Duration
+
+	mu    sync.Mutex
+	names map[int]cachedWatchList
+}
+
+type cachedWatchList struct {
+	name    string
+	expires time.Time
+}
+
+// watchListCategoriesFromEnv lê FINDFACE_WATCHLIST_CATEGORIES no formato
+// "blocklist=Bloqueados|Blacklist;vip=VIP;employee=Funcionários". A 1ª regra
+// que casar com alguma lista vence; sem regra (ou sem casar), a categoria é o
+// nome da lista em minúsculas.
+func watchListCategoriesFromEnv() *watchListCategories {
+	c := &watchListCategories{
+		rules: parseCategoryRules(os.Getenv("FINDFACE_WATCHLIST_CATEGORIES")),
+		ttl:   defaultWatchListCacheTTL,
+		names: map[int]cachedWatchList{},
+	}
+	if raw := strings.TrimSpace(os.Getenv("FINDFACE_WATCHLIST_CACHE_TTL")); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+			c.ttl = d
+		} else {
+			log.Printf("[faceengine] FINDFACE_WATCHLIST_CACHE_TTL inválido (%q), usando %s", raw, defaultWatchListCacheTTL)
+		}
+	}
+	return c
+}
+
+func parseCategoryRules(raw string) []categoryRule {
+	var rules []categoryRule
+	for _, part := range strings.Split(raw, ";") {
+		category, patterns, ok := strings.Cut(part, "=")
+		category = strings.ToLower(strings.TrimSpace(category))
+		if !ok || category == "" {
+			if strings.TrimSpace(part) != "" {
+				log.Printf("[faceengine] FINDFACE_WATCHLIST_CATEGORIES: regra inválida %q (use categoria=lista|lista)", part)
+			}
+			continue
+		}
+		rule := categoryRule{category: category}
+		for _, p := range strings.Split(patterns, "|") {
+			if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+				rule.patterns = append(rule.patterns, p)
+			}
+		}
+		if len(rule.patterns) == 0 {
+			rule.patterns = []string{category}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// categorize devolve a categoria para os nomes de listas (já resolvidos).
+func (c *watchListCategories) categorize(names []string) string {
+	for _, rule := range c.rules {
+		for _, name := range names {
+			lname := strings.ToLower(name)
+			for _, p := range rule.patterns {
+				if strings.Contains(lname, p) {
+					return rule.category
+				}
+			}
+		}
+	}
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			return strings.ToLower(name)
+		}
+	}
+	return ""
+}
+
+// listName devolve o nome da watch list, do cache ou do FindFace.
+func (c *watchListCategories) listName(ctx context.Context, client *ff.Client, id int) string {
+	now := time.Now()
+	c.mu.Lock()
+	cached, ok := c.names[id]
+	c.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.name
+	}
+
+	wl, err := client.GetWatchList(ctx, id)
+	if err != nil {
+		logthrottle.Printf("faceengine:get-watch-list", "[faceengine] erro ao consultar GetWatchList(%d): %v", id, err)
+		return cached.name // nome vencido é melhor que nada
+	}
+	c.mu.Lock()
+	c.names[id] = cachedWatchList{name: wl.Name, expires: now.Add(c.ttl)}
+	c.mu.Unlock()
+	return wl.Name
+}
+
+// personCategory resolve a categoria do match: usa matched_lists do evento e,
+// sem elas, as watch_lists do card.
+func (e *Engine) personCategory(ctx context.Context, fevent *ff.FaceEvent, card *ff.Card) string {
+	if e.categories == nil {
+		return ""
+	}
+	lists := fevent.MatchedLists
+	if len(lists) == 0 && card != nil {
+		lists = card.WatchLists
+	}
+	var names []string
+	for _, id := range lists {
+		if id <= 0 {
+			continue // -1 = "Unmatched" no FindFace
+		}
+		if name := e.categories.listName(ctx, e.client, id); name != "" {
+			names = append(names, name)
+		}
+	}
+	return e.categories.categorize(names)
+}
@@ -14,7 +14,13 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/sua-org/cam-bus/internal/envconf"
+	"github.com/sua-org/cam-bus/internal/netproxy"
+	"github.com/sua-org/cam-bus/internal/tracing"
 )
 
 // Client representa um cliente simples para o FindFace Multi.
@@ -26,6 +32,17 @@ type Client struct {
 	NameField   string // chave em features que contém o "nome" (ex: "name")
 
 	HTTP *http.Client
+
+	inFlight atomic.Int64 // requisições HTTP em andamento (até o Close do body)
+
+	// Semáforos independentes para criação de eventos (POST multipart) e
+	// consultas (GET). nil = sem limite.
+	createSem chan struct{}
+	lookupSem chan struct{}
+
+	// Retry de falhas transitórias (SetRetry). 0 = uma tentativa só.
+	maxRetries   int
+	retryBackoff time.Duration
 }
 
 // CreateFaceEventResponse guarda o que recebemos do /events/faces/add.
@@ -42,6 +59,7 @@ type FaceEvent struct {
 	MatchedLists  []int    `json:"matched_lists"`
 	Confidence    float64  `json:"confidence"`
 	LooksLikeConf *float64 `json:"looks_like_confidence"`
+	LooksLikeCard *int     `json:"looks_like_card,omitempty"`
 	Thumbnail     string   `json:"thumbnail"`
 	Fullframe     string   `json:"fullframe"`
 }
@@ -52,6 +70,15 @@ type Card struct {
 	Name     *string                `json:"name,omitempty"` // se existir direto no root
 	Features map[string]interface{} `json:"features"`
 	Meta     map[string]interface{} `json:"meta"`
+
+	WatchLists []int `json:"watch_lists,omitempty"` // listas (watch lists) do card
+}
+
+// WatchList representa (parcialmente) uma watch list do FindFace.
+type WatchList struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Comment string `json:"comment,omitempty"`
 }
 
 // FaceObject representa (parcialmente) um objeto de face em /objects/faces/.
@@ -76,10 +103,98 @@ func New(baseURL, apiToken, eventsToken string, cameraID int, nameField string)
 		EventsToken: eventsToken,
 		CameraID:    cameraID,
 		NameField:   nameField,
-		HTTP: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		HTTP:        netproxy.Client(30 * time.Second),
+	}
+}
+
+// InFlight retorna quantas requisições ao FindFace estão em andamento.
+func (c *Client) InFlight() int64 {
+	if c == nil {
+		return 0
+	}
+	return c.inFlight.Load()
+}
+
+// do executa a requisição contabilizando in-flight até o body ser fechado.
+// Uploads (POST) usam o semáforo de create; o resto, o de lookup. A vaga
+// só é liberada quando o body é fechado.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	sem := c.lookupSem
+	if req.Method == http.MethodPost {
+		sem = c.createSem
+	}
+	if sem != nil {
+		select {
+		case sem <- struct{}{}:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
 	}
+	release := func() {
+		c.inFlight.Add(-1)
+		if sem != nil {
+			<-sem
+		}
+	}
+
+	ctx, span := tracing.Start(req.Context(), "findface.http")
+	span.SetAttr("http.method", req.Method)
+	span.SetAttr("http.path", req.URL.Path)
+	req = req.WithContext(ctx)
+	tracing.InjectHeader(ctx, req.Header)
+
+	c.inFlight.Add(1)
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		span.End()
+		release()
+		return nil, err
+	}
+	span.SetAttr("http.status_code", strconv.Itoa(resp.StatusCode))
+	resp.Body = &inFlightBody{ReadCloser: resp.Body, done: func() {
+		span.End()
+		release()
+	}}
+	return resp, nil
+}
+
+// Ping faz um GET barato na API (path relativo a BaseURL) só para manter a
+// conexão aquecida e checar alcançabilidade. Qualquer status < 500 conta como ok.
+func (c *Client) Ping(ctx context.Context, path string) error {
+	if path == "" {
+		path = "/cameras/?limit=1"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao criar request ping: %w", err)
+	}
+	req.Header.Set("Authorization", "Token "+c.APIToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("erro ao chamar ping: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body) // drena para reaproveitar a conexão
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("ping status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+type inFlightBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (b *inFlightBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
 }
 
 // NewFromEnv cria um client lendo variáveis de ambiente:
@@ -90,6 +205,8 @@ func New(baseURL, apiToken, eventsToken string, cameraID int, nameField string)
 //   FINDFACE_EVENTS_TOKEN     (token de criação de eventos do external detector)
 //   FINDFACE_CAMERA_ID        (id da câmera no FindFace, ex: 47)
 //   FINDFACE_NAME_FIELD       (chave dentro de features com o nome da pessoa, default: "name")
+//   FINDFACE_MAX_RETRIES      (novas tentativas em erro de rede/5xx, default 0)
+//   FINDFACE_RETRY_BACKOFF_MS (espera antes da 1ª nova tentativa, dobra a cada uma; default 200)
 func NewFromEnv() (*Client, error) {
 	baseURL := os.Getenv("FINDFACE_BASE_URL")
 	if baseURL == "" {
@@ -120,7 +237,30 @@ func NewFromEnv() (*Client, error) {
 	}
 
 	nameField := os.Getenv("FINDFACE_NAME_FIELD") // opcional
-	return New(baseURL, apiToken, eventsToken, cameraID, nameField), nil
+	c := New(baseURL, apiToken, eventsToken, cameraID, nameField)
+	c.SetConcurrency(
+		envconf.Int("FINDFACE_CREATE_CONCURRENCY", 0),
+		envconf.Int("FINDFACE_LOOKUP_CONCURRENCY", 0),
+	)
+	c.SetRetry(
+		envconf.Int("FINDFACE_MAX_RETRIES", 0),
+		time.Duration(envconf.Int("FINDFACE_RETRY_BACKOFF_MS", 0))*time.Millisecond,
+	)
+	return c, nil
+}
+
+// SetConcurrency limita quantas criações de evento (create) e consultas
+// (lookup) podem rodar ao mesmo tempo. 0 = sem limite.
+func (c *Client) SetConcurrency(create, lookup int) {
+	c.createSem = newSemaphore(create)
+	c.lookupSem = newSemaphore(lookup)
+}
+
+func newSemaphore(n int) chan struct{} {
+	if n <= 0 {
+		return nil
+	}
+	return make(chan struct{}, n)
 }
 
 // CreateFaceEventFromFile envia uma imagem para /events/faces/add/.
@@ -184,7 +324,7 @@ func (c *Client) CreateFaceEventFromFile(ctx context.Context, imagePath string)
 	// Aqui vai o token de API (global)
 	req.Header.Set("Authorization", "Token "+c.APIToken)
 
-	resp, err := c.HTTP.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao chamar faces/add: %w", err)
 	}
@@ -255,7 +395,7 @@ func (c *Client) CreateFaceEventFromBytes(ctx context.Context, img []byte, filen
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Authorization", "Token "+c.APIToken)
 
-	resp, err := c.HTTP.Do(req)
+	resp, err := c.doRetry(req)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao chamar faces/add: %w", err)
 	}
@@ -280,7 +420,9 @@ func parseCreateFaceEventResponse(bodyBytes []byte) *CreateFaceEventResponse {
 	}
 
 	var anyJSON interface{}
-	if err := json.Unmarshal(bodyBytes, &anyJSON); err != nil {
+	dec := json.NewDecoder(bytes.NewReader(bodyBytes))
+	dec.UseNumber()
+	if err := dec.Decode(&anyJSON); err != nil {
 		// resposta não-JSON, mas sucesso HTTP -> devolvemos corpo bruto mesmo assim
 		return &CreateFaceEventResponse{
 			EventID: "",
@@ -321,7 +463,7 @@ func (c *Client) GetFaceEvent(ctx context.Context, eventID string) (*FaceEvent,
 	req.Header.Set("Authorization", "Token "+c.APIToken)
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.HTTP.Do(req)
+	resp, err := c.doRetry(req)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao chamar GetFaceEvent: %w", err)
 	}
@@ -364,7 +506,7 @@ func (c *Client) GetCard(ctx context.Context, cardID int) (*Card, error) {
 	req.Header.Set("Authorization", "Token "+c.APIToken)
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.HTTP.Do(req)
+	resp, err := c.doRetry(req)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao chamar GetCard: %w", err)
 	}
@@ -387,6 +529,41 @@ func (c *Client) GetCard(ctx context.Context, cardID int) (*Card, error) {
 	return &card, nil
 }
 
+// GetWatchList busca uma watch list pelo ID.
+// Endpoint: GET /watch-lists/{id}/
+func (c *Client) GetWatchList(ctx context.Context, listID int) (*WatchList, error) {
+	urlReq := fmt.Sprintf("%s/watch-lists/%d/", c.BaseURL, listID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlReq, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar request GetWatchList: %w", err)
+	}
+	req.Header.Set("Authorization", "Token "+c.APIToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao chamar GetWatchList: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler resposta GetWatchList: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GetWatchList status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var wl WatchList
+	if err := json.Unmarshal(bodyBytes, &wl); err != nil {
+		return nil, fmt.Errorf("erro ao parsear JSON GetWatchList: %w (body=%s)", err, string(bodyBytes))
+	}
+
+	return &wl, nil
+}
+
 // GetCardName retorna um "nome amigável" para o card,
 // usando (nesta ordem):
 // 1) card.Name
@@ -532,8 +709,16 @@ func toStringID(v interface{}) string {
 	switch t := v.(type) {
 	case string:
 		return t
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return strconv.FormatInt(i, 10)
+		}
+		return t.String()
 	case float64:
-		return strconv.FormatInt(int64(t), 10)
+		if t == float64(int64(t)) {
+			return strconv.FormatInt(int64(t), 10)
+		}
+		return strconv.FormatFloat(t, 'f', -1, 64)
 	case int:
 		return strconv.Itoa(t)
 	case int64:
@@ -565,7 +750,7 @@ func (c *Client) GetFaceObjectForCard(ctx context.Context, cardID int) (*FaceObj
     req.Header.Set("Authorization", "Token "+c.APIToken)
     req.Header.Set("Accept", "application/json")
 
-    resp, err := c.HTTP.Do(req)
+    resp, err := c.do(req)
     if err != nil {
         return nil, fmt.Errorf("erro ao chamar GetFaceObjectForCard: %w", err)
     }
@@ -0,0 +1,75 @@
+package findface
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// waitFor espera cond ficar verdadeira (até 1s).
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout esperando %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestInFlightAroundSlowRequest(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(`{"id": 7}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "token", "", 0, "")
+	if c.InFlight() != 0 {
+		t.Fatalf("InFlight inicial = %d", c.InFlight())
+	}
+
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := c.GetCard(context.Background(), 7)
+			done <- err
+		}()
+	}
+	waitFor(t, "2 requisições em andamento", func() bool { return c.InFlight() == 2 })
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := c.InFlight(); got != 0 {
+		t.Fatalf("InFlight após as respostas = %d, esperava 0", got)
+	}
+}
+
+func TestInFlightDecrementsOnError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	c := New(url, "token", "", 0, "")
+	if _, err := c.GetCard(context.Background(), 7); err == nil {
+		t.Fatal("esperava erro de conexão")
+	}
+	if got := c.InFlight(); got != 0 {
+		t.Fatalf("InFlight após erro = %d, esperava 0", got)
+	}
+}
+
+func TestInFlightNilClient(t *testing.T) {
+	var c *Client
+	if c.InFlight() != 0 {
+		t.Fatal("cliente nil deveria reportar 0")
+	}
+}
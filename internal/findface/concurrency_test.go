@@ -0,0 +1,84 @@
+package findface
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCreateAndLookupLimitsAreIndependent(t *testing.T) {
+	release := make(chan struct{})
+	var lookups, creates atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			creates.Add(1)
+			w.Write([]byte(`{"id": 1}`))
+			return
+		}
+		lookups.Add(1)
+		<-release
+		w.Write([]byte(`{"id": 7}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "token", "", 0, "")
+	c.SetConcurrency(1, 1)
+
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := c.GetCard(context.Background(), 7)
+			done <- err
+		}()
+	}
+	waitFor(t, "primeira consulta no servidor", func() bool { return lookups.Load() == 1 })
+	time.Sleep(30 * time.Millisecond)
+	if got := lookups.Load(); got != 1 {
+		t.Fatalf("%d consultas simultâneas, limite era 1", got)
+	}
+
+	// com as consultas travadas, a criação segue pelo próprio semáforo
+	if _, err := c.CreateFaceEventFromBytes(context.Background(), []byte("jpeg"), ""); err != nil {
+		t.Fatalf("create bloqueado pelas consultas: %v", err)
+	}
+	if creates.Load() != 1 {
+		t.Fatal("create não chegou ao servidor")
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if lookups.Load() != 2 {
+		t.Fatalf("segunda consulta não rodou: %d", lookups.Load())
+	}
+}
+
+func TestLookupLimitHonorsContext(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	c := New(srv.URL, "token", "", 0, "")
+	c.SetConcurrency(0, 1)
+	go c.GetCard(context.Background(), 1)
+	waitFor(t, "vaga ocupada", func() bool { return c.InFlight() == 1 })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetCard(ctx, 2); err == nil {
+		t.Fatal("consulta na fila deveria respeitar o timeout do contexto")
+	}
+	if got := c.InFlight(); got != 1 {
+		t.Fatalf("InFlight = %d, a consulta cancelada não deveria contar", got)
+	}
+}
@@ -0,0 +1,28 @@
+package findface
+
+import "testing"
+
+func TestCreateFaceEventKeepsLargeIDs(t *testing.T) {
+	// 2^53+1 não cabe em float64 sem perder o último dígito.
+	resp := parseCreateFaceEventResponse([]byte(`{"id": 9007199254740993}`))
+	if resp.EventID != "9007199254740993" {
+		t.Fatalf("EventID = %q, esperava 9007199254740993", resp.EventID)
+	}
+}
+
+func TestToStringIDNumbers(t *testing.T) {
+	cases := []struct {
+		in   interface{}
+		want string
+	}{
+		{float64(42), "42"},
+		{1.5, "1.5"},
+		{"abc", "abc"},
+		{int64(7), "7"},
+	}
+	for _, tc := range cases {
+		if got := toStringID(tc.in); got != tc.want {
+			t.Errorf("toStringID(%v) = %q, esperava %q", tc.in, got, tc.want)
+		}
+	}
+}
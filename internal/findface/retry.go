@@ -0,0 +1,94 @@
+// internal/findface/retry.go
+package findface
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+const defaultRetryBackoff = 200 * time.Millisecond
+
+// SetRetry liga novas tentativas (até maxRetries além da primeira) para falhas
+// transitórias do FindFace em CreateFaceEventFromBytes, GetFaceEvent e GetCard.
+// As consultas (GET) repetem em erro de rede e status 5xx; a criação (POST, que
+// não é idempotente) só quando o FindFace certamente não gravou o evento: falha
+// ao conectar ou 502/503/504. A espera começa em backoff e dobra a cada
+// tentativa. 4xx nunca é repetido. 0 = sem retry.
+func (c *Client) SetRetry(maxRetries int, backoff time.Duration) {
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	c.maxRetries = maxRetries
+	c.retryBackoff = backoff
+}
+
+// doRetry é o do com a política de SetRetry. Não espera além do deadline do
+// contexto: sem tempo para a próxima tentativa, devolve a última resposta.
+func (c *Client) doRetry(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	delay := c.retryBackoff
+	for attempt := 0; ; attempt++ {
+		resp, err := c.do(req)
+		if attempt >= c.maxRetries || !retryable(req.Method, resp, err) || ctx.Err() != nil {
+			return resp, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return resp, err
+		}
+		// corpo do POST (multipart) refeito para a próxima tentativa
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+			req.Body = body
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// retryable decide se vale outra tentativa. GET: erro de rede (não
+// cancelamento) ou 5xx. POST: só erro antes de a requisição sair (conexão) ou
+// gateway/indisponível, para não duplicar o evento criado.
+func retryable(method string, resp *http.Response, err error) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		return method != http.MethodPost || notSent(err)
+	}
+	if method != http.MethodPost {
+		return resp.StatusCode >= 500
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// notSent: a requisição não chegou ao servidor (DNS ou conexão recusada).
+func notSent(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
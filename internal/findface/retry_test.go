@@ -0,0 +1,161 @@
+package findface
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubTransport responde cada chamada com a próxima resposta da lista (a
+// última se repete) e guarda os corpos recebidos.
+type stubTransport struct {
+	mu        sync.Mutex
+	responses []stubResponse
+	calls     int
+	bodies    []string
+}
+
+type stubResponse struct {
+	status int
+	body   string
+	err    error
+}
+
+func (s *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if req.Body != nil {
+		b, _ := io.ReadAll(req.Body)
+		s.bodies = append(s.bodies, string(b))
+	}
+	r := s.responses[min(s.calls, len(s.responses)-1)]
+	s.calls++
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &http.Response{
+		StatusCode: r.status,
+		Body:       io.NopCloser(strings.NewReader(r.body)),
+		Header:     http.Header{},
+		Request:    req,
+	}, nil
+}
+
+func (s *stubTransport) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func newRetryClient(stub *stubTransport, retries int, backoff time.Duration) *Client {
+	c := New("http://findface.local", "token", "events", 1, "")
+	c.HTTP = &http.Client{Transport: stub}
+	c.SetRetry(retries, backoff)
+	return c
+}
+
+var dialErr = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+func TestRetryGetSucceedsAfterTransientFailures(t *testing.T) {
+	stub := &stubTransport{responses: []stubResponse{
+		{status: http.StatusServiceUnavailable},
+		{err: errors.New("connection reset by peer")},
+		{status: http.StatusOK, body: `{"id": 7, "name": "Ana"}`},
+	}}
+	c := newRetryClient(stub, 2, time.Millisecond)
+
+	card, err := c.GetCard(context.Background(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if card.ID != 7 || stub.count() != 3 {
+		t.Fatalf("card=%+v chamadas=%d, esperava sucesso na 3ª", card, stub.count())
+	}
+}
+
+func TestRetryStopsAtMaxRetries(t *testing.T) {
+	stub := &stubTransport{responses: []stubResponse{{status: http.StatusBadGateway}}}
+	c := newRetryClient(stub, 2, time.Millisecond)
+
+	if _, err := c.GetCard(context.Background(), 7); err == nil {
+		t.Fatal("esperava erro depois de esgotar as tentativas")
+	}
+	if stub.count() != 3 {
+		t.Fatalf("chamadas = %d, esperava 1 + 2 retries", stub.count())
+	}
+}
+
+func TestRetryNeverRepeats4xx(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusNotFound, http.StatusTooManyRequests} {
+		stub := &stubTransport{responses: []stubResponse{{status: status}}}
+		c := newRetryClient(stub, 3, time.Millisecond)
+		if _, err := c.GetCard(context.Background(), 7); err == nil {
+			t.Fatalf("status %d deveria dar erro", status)
+		}
+		if stub.count() != 1 {
+			t.Fatalf("status %d repetido %d vezes", status, stub.count())
+		}
+	}
+}
+
+func TestRetryCreateOnlyWhenNotProcessed(t *testing.T) {
+	img := []byte("jpeg")
+
+	// 503 não grava o evento: repete e reenvia o multipart inteiro
+	stub := &stubTransport{responses: []stubResponse{
+		{status: http.StatusServiceUnavailable},
+		{err: dialErr},
+		{status: http.StatusCreated, body: `{"id": "e1"}`},
+	}}
+	c := newRetryClient(stub, 3, time.Millisecond)
+	if _, err := c.CreateFaceEventFromBytes(context.Background(), img, ""); err != nil {
+		t.Fatal(err)
+	}
+	if stub.count() != 3 {
+		t.Fatalf("chamadas = %d, esperava 3", stub.count())
+	}
+	for i, b := range stub.bodies {
+		if b == "" || b != stub.bodies[0] {
+			t.Fatalf("corpo da tentativa %d diferente da primeira", i+1)
+		}
+	}
+
+	// 500 ou conexão caída no meio podem já ter criado o evento: não repete
+	for _, r := range []stubResponse{
+		{status: http.StatusInternalServerError},
+		{err: io.ErrUnexpectedEOF},
+	} {
+		stub := &stubTransport{responses: []stubResponse{r, {status: http.StatusCreated, body: `{"id": "e1"}`}}}
+		c := newRetryClient(stub, 3, time.Millisecond)
+		if _, err := c.CreateFaceEventFromBytes(context.Background(), img, ""); err == nil {
+			t.Fatalf("%+v deveria devolver o erro sem repetir", r)
+		}
+		if stub.count() != 1 {
+			t.Fatalf("%+v: criação repetida %d vezes", r, stub.count())
+		}
+	}
+}
+
+func TestRetryRespectsDeadline(t *testing.T) {
+	stub := &stubTransport{responses: []stubResponse{{status: http.StatusServiceUnavailable}}}
+	c := newRetryClient(stub, 5, time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.GetCard(ctx, 7); err == nil {
+		t.Fatal("esperava o 503 da única tentativa")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("esperou %s, além do deadline", elapsed)
+	}
+	if stub.count() != 1 {
+		t.Fatalf("chamadas = %d, o backoff não cabia no deadline", stub.count())
+	}
+}
@@ -0,0 +1,237 @@
+// internal/logging/logging.go
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Level é o nível mínimo das mensagens (LOG_LEVEL).
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return "info"
+}
+
+// ParseLevel aceita debug, info, warn/warning e error (sem caixa).
+func ParseLevel(raw string) (Level, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "debug":
+		return LevelDebug, true
+	case "info":
+		return LevelInfo, true
+	case "warn", "warning":
+		return LevelWarn, true
+	case "error":
+		return LevelError, true
+	}
+	return LevelInfo, false
+}
+
+// Fields são os campos estruturados da linha (camera, tenant, analytic,
+// event_id...). Só aparecem no formato JSON: no texto a mensagem já os traz.
+type Fields map[string]interface{}
+
+// Logger filtra por nível e escreve em texto (como o log padrão) ou JSON.
+// Os métodos são seguros para uso concorrente.
+type Logger struct {
+	level atomic.Int32
+	json  bool
+
+	mu     sync.Mutex
+	now    func() time.Time
+	output func(calldepth int, line string) // texto
+	write  func(b []byte)                   // JSON
+}
+
+// New cria um Logger com o nível e o formato dados.
+func New(level Level, jsonFormat bool) *Logger {
+	l := &Logger{
+		json:   jsonFormat,
+		now:    time.Now,
+		output: func(calldepth int, line string) { _ = log.Output(calldepth+1, line) },
+		write:  func(b []byte) { _, _ = log.Writer().Write(b) },
+	}
+	l.level.Store(int32(level))
+	return l
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultLogger *Logger
+)
+
+// Default usa LOG_LEVEL (default info) e LOG_FORMAT (text ou json). O
+// ambiente é lido na primeira chamada, depois do .env carregado.
+func Default() *Logger {
+	defaultOnce.Do(func() {
+		defaultLogger = newFromEnv()
+	})
+	return defaultLogger
+}
+
+func newFromEnv() *Logger {
+	level := LevelInfo
+	if raw := strings.TrimSpace(os.Getenv("LOG_LEVEL")); raw != "" {
+		lv, ok := ParseLevel(raw)
+		if !ok {
+			log.Printf("[logging] LOG_LEVEL inválido (%q), usando info", raw)
+		}
+		level = lv
+	}
+	jsonFormat := false
+	switch format := strings.ToLower(strings.TrimSpace(os.Getenv("LOG_FORMAT"))); format {
+	case "", "text":
+	case "json":
+		jsonFormat = true
+	default:
+		log.Printf("[logging] LOG_FORMAT inválido (%q), usando text", format)
+	}
+	return New(level, jsonFormat)
+}
+
+// SetLevel troca o nível em tempo de execução.
+func (l *Logger) SetLevel(level Level) {
+	l.level.Store(int32(level))
+}
+
+// Level devolve o nível atual.
+func (l *Logger) Level() Level {
+	return Level(l.level.Load())
+}
+
+// Enabled diz se mensagens do nível seriam escritas (evita montar campos caros).
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.Level()
+}
+
+func (l *Logger) logf(calldepth int, level Level, fields Fields, format string, args ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	if !l.json {
+		// info sai igual ao log.Printf de antes; os outros níveis ganham a tag
+		if level != LevelInfo {
+			msg = strings.ToUpper(level.String()) + " " + msg
+		}
+		l.output(calldepth+1, msg)
+		return
+	}
+
+	entry := make(map[string]interface{}, len(fields)+4)
+	for k, v := range fields {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		entry[k] = v
+	}
+	entry["ts"] = l.now().UTC().Format(time.RFC3339Nano)
+	entry["level"] = level.String()
+	if component, rest, ok := splitComponent(msg); ok {
+		entry["component"] = component
+		msg = rest
+	}
+	entry["msg"] = msg
+	b, err := json.Marshal(entry)
+	if err != nil {
+		b, _ = json.Marshal(map[string]string{"ts": entry["ts"].(string), "level": level.String(), "msg": msg})
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.write(append(b, '\n'))
+}
+
+// splitComponent separa o prefixo "[supervisor] " das mensagens do repo;
+// prefixos com espaço ("[worker <câmera>]") ficam na mensagem.
+func splitComponent(msg string) (component, rest string, ok bool) {
+	if !strings.HasPrefix(msg, "[") {
+		return "", msg, false
+	}
+	end := strings.Index(msg, "] ")
+	if end <= 1 || strings.ContainsAny(msg[1:end], " \t") {
+		return "", msg, false
+	}
+	return msg[1:end], msg[end+2:], true
+}
+
+// Entry é um Logger com campos fixos (With).
+type Entry struct {
+	l      *Logger
+	fields Fields
+}
+
+// With devolve um Entry com os campos dados.
+func (l *Logger) With(fields Fields) Entry {
+	return Entry{l: l, fields: fields}
+}
+
+func (e Entry) Debugf(format string, args ...interface{}) {
+	e.l.logf(2, LevelDebug, e.fields, format, args...)
+}
+
+func (e Entry) Infof(format string, args ...interface{}) {
+	e.l.logf(2, LevelInfo, e.fields, format, args...)
+}
+
+func (e Entry) Warnf(format string, args ...interface{}) {
+	e.l.logf(2, LevelWarn, e.fields, format, args...)
+}
+
+func (e Entry) Errorf(format string, args ...interface{}) {
+	e.l.logf(2, LevelError, e.fields, format, args...)
+}
+
+// Funções do Default, no lugar de log.Printf.
+
+func Debugf(format string, args ...interface{}) {
+	Default().logf(2, LevelDebug, nil, format, args...)
+}
+
+func Infof(format string, args ...interface{}) {
+	Default().logf(2, LevelInfo, nil, format, args...)
+}
+
+func Warnf(format string, args ...interface{}) {
+	Default().logf(2, LevelWarn, nil, format, args...)
+}
+
+func Errorf(format string, args ...interface{}) {
+	Default().logf(2, LevelError, nil, format, args...)
+}
+
+// With usa o Default.
+func With(fields Fields) Entry {
+	return Default().With(fields)
+}
+
+// Camera são os campos comuns das linhas de uma câmera.
+func Camera(key, tenant string) Fields {
+	return Fields{"camera": key, "tenant": tenant}
+}
+
+// Event são os campos de uma linha sobre um evento de câmera.
+func Event(key, tenant, analytic, eventID string) Fields {
+	return Fields{"camera": key, "tenant": tenant, "analytic": analytic, "event_id": eventID}
+}
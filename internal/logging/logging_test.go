@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureLogger devolve um Logger que guarda as linhas em vez de escrever no log.
+func captureLogger(level Level, jsonFormat bool) (*Logger, *[]string) {
+	var lines []string
+	l := New(level, jsonFormat)
+	l.now = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) }
+	l.output = func(_ int, line string) { lines = append(lines, line) }
+	l.write = func(b []byte) { lines = append(lines, string(b)) }
+	return l, &lines
+}
+
+// Default só pode ser lido aqui: o ambiente vale na primeira chamada.
+func TestDefaultReadsEnvOnFirstUse(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("LOG_FORMAT", "json")
+
+	l := Default()
+	if got := l.Level(); got != LevelWarn {
+		t.Fatalf("Default().Level() = %s, esperava warn (LOG_LEVEL lido depois do init)", got)
+	}
+	if !l.json {
+		t.Fatalf("Default() ignorou LOG_FORMAT=json")
+	}
+	if Default() != l {
+		t.Fatalf("Default() deve devolver sempre o mesmo Logger")
+	}
+}
+
+func TestDebugSuppressedAtInfo(t *testing.T) {
+	l, lines := captureLogger(LevelInfo, false)
+	e := l.With(Camera("t/b/f/cam/c1", "t"))
+
+	e.Debugf("[worker %s] published event", "c1")
+	if len(*lines) != 0 {
+		t.Fatalf("debug escrito no nível info: %q", *lines)
+	}
+	e.Infof("[supervisor] camera %s online", "c1")
+	e.Warnf("[supervisor] fila cheia")
+	e.Errorf("[supervisor] driver caiu")
+	want := []string{"[supervisor] camera c1 online", "WARN [supervisor] fila cheia", "ERROR [supervisor] driver caiu"}
+	if strings.Join(*lines, "|") != strings.Join(want, "|") {
+		t.Fatalf("linhas = %q, esperava %q", *lines, want)
+	}
+
+	// SetLevel em tempo de execução (admin /loglevel)
+	l.SetLevel(LevelDebug)
+	e.Debugf("[worker %s] published event", "c1")
+	if last := (*lines)[len(*lines)-1]; last != "DEBUG [worker c1] published event" {
+		t.Fatalf("debug após SetLevel = %q", last)
+	}
+	l.SetLevel(LevelError)
+	e.Warnf("[supervisor] fila cheia")
+	if len(*lines) != 4 || l.Enabled(LevelWarn) || !l.Enabled(LevelError) {
+		t.Fatalf("warn escrito no nível error: %q", *lines)
+	}
+}
+
+func TestJSONFormatFields(t *testing.T) {
+	l, lines := captureLogger(LevelInfo, true)
+	l.With(Event("t/b/f/cam/c1", "t", "faceCapture", "e1")).Debugf("[supervisor] descartado")
+	l.With(Fields{"camera": "c1", "err": errors.New("timeout")}).Warnf("[supervisor] erro ao publicar %s", "e1")
+	l.With(nil).Infof("[worker t/b/f/cam/c1] conectado")
+	if len(*lines) != 2 {
+		t.Fatalf("linhas = %q, esperava o debug suprimido", *lines)
+	}
+
+	var warn map[string]interface{}
+	if err := json.Unmarshal([]byte((*lines)[0]), &warn); err != nil {
+		t.Fatal(err)
+	}
+	if warn["level"] != "warn" || warn["component"] != "supervisor" || warn["msg"] != "erro ao publicar e1" ||
+		warn["camera"] != "c1" || warn["err"] != "timeout" || warn["ts"] != "2024-03-01T12:00:00Z" {
+		t.Fatalf("linha JSON = %v", warn)
+	}
+
+	// prefixo com espaço fica na mensagem
+	var info map[string]interface{}
+	if err := json.Unmarshal([]byte((*lines)[1]), &info); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := info["component"]; ok || info["msg"] != "[worker t/b/f/cam/c1] conectado" {
+		t.Fatalf("linha JSON = %v", info)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for raw, want := range map[string]Level{"debug": LevelDebug, " INFO ": LevelInfo, "warning": LevelWarn, "Warn": LevelWarn, "error": LevelError} {
+		if got, ok := ParseLevel(raw); !ok || got != want {
+			t.Errorf("ParseLevel(%q) = %s/%t, esperava %s", raw, got, ok, want)
+		}
+	}
+	if got, ok := ParseLevel("verbose"); ok || got != LevelInfo {
+		t.Errorf("ParseLevel(verbose) = %s/%t, esperava info/false", got, ok)
+	}
+}
+
+func TestNewFromEnvInvalidFallsBack(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "verbose")
+	t.Setenv("LOG_FORMAT", "xml")
+	l := newFromEnv()
+	if l.Level() != LevelInfo || l.json {
+		t.Fatalf("level=%s json=%t, esperava info em texto", l.Level(), l.json)
+	}
+}
@@ -0,0 +1,162 @@
+// internal/logthrottle/logthrottle.go
+package logthrottle
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logger deduplica mensagens repetidas: a primeira ocorrência de uma chave é
+// logada na hora; as seguintes dentro da janela são só contadas. Ao fim da
+// janela um flush periódico loga o resumo ("N ocorrências ... nos últimos M")
+// e descarta as chaves paradas, então uma rajada que cessa também é reportada
+// e chaves com IP/container não acumulam.
+type Logger struct {
+	window time.Duration
+	now    func() time.Time
+	output func(string)
+
+	mu      sync.Mutex
+	entries map[string]*entry
+
+	flushOnce sync.Once
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+type entry struct {
+	windowStart time.Time
+	suppressed  int
+	lastMsg     string
+}
+
+// New cria um Logger com a janela informada. window <= 0 desliga o throttle.
+func New(window time.Duration) *Logger {
+	return &Logger{
+		window:  window,
+		now:     time.Now,
+		output:  func(msg string) { _ = log.Output(3, msg) },
+		entries: make(map[string]*entry),
+		stop:    make(chan struct{}),
+	}
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultLogger *Logger
+)
+
+// Default usa LOG_THROTTLE_SECONDS (default: 60s; 0 desliga). O ambiente é
+// lido na primeira chamada, depois do .env carregado.
+func Default() *Logger {
+	defaultOnce.Do(func() {
+		defaultLogger = New(windowFromEnv())
+	})
+	return defaultLogger
+}
+
+func windowFromEnv() time.Duration {
+	raw := strings.TrimSpace(os.Getenv("LOG_THROTTLE_SECONDS"))
+	if raw == "" {
+		return time.Minute
+	}
+	sec, err := strconv.Atoi(raw)
+	if err != nil || sec < 0 {
+		log.Printf("[logthrottle] valor inválido em LOG_THROTTLE_SECONDS=%q, usando 60s", raw)
+		return time.Minute
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// Printf loga a mensagem respeitando o throttle da chave. A chave agrupa
+// mensagens "iguais" mesmo que o texto varie (ex.: erro com timestamp).
+func (l *Logger) Printf(key, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if l == nil || l.window <= 0 {
+		log.Print(msg)
+		return
+	}
+	l.flushOnce.Do(func() { go l.runFlush() })
+
+	now := l.now()
+
+	l.mu.Lock()
+	e, ok := l.entries[key]
+	if ok && now.Sub(e.windowStart) < l.window {
+		e.suppressed++
+		e.lastMsg = msg
+		l.mu.Unlock()
+		return
+	}
+
+	var summary string
+	if ok {
+		summary = e.summary(key, now)
+	}
+	l.entries[key] = &entry{windowStart: now}
+	l.mu.Unlock()
+
+	if summary != "" {
+		l.output(summary)
+	}
+	l.output(msg)
+}
+
+// Stop encerra o flush periódico (o Default roda até o fim do processo).
+func (l *Logger) Stop() {
+	if l == nil {
+		return
+	}
+	l.stopOnce.Do(func() { close(l.stop) })
+}
+
+func (l *Logger) runFlush() {
+	ticker := time.NewTicker(l.window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			l.flush(l.now())
+		}
+	}
+}
+
+// flush loga o resumo das chaves cuja janela terminou e as descarta.
+func (l *Logger) flush(now time.Time) {
+	var summaries []string
+	l.mu.Lock()
+	for key, e := range l.entries {
+		if now.Sub(e.windowStart) < l.window {
+			continue
+		}
+		if summary := e.summary(key, now); summary != "" {
+			summaries = append(summaries, summary)
+		}
+		delete(l.entries, key)
+	}
+	l.mu.Unlock()
+
+	for _, summary := range summaries {
+		l.output(summary)
+	}
+}
+
+func (e *entry) summary(key string, now time.Time) string {
+	if e.suppressed == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d ocorrências suprimidas de %q nos últimos %s (última: %s)",
+		e.suppressed, key, now.Sub(e.windowStart).Truncate(time.Second), e.lastMsg)
+}
+
+// Printf usa o Logger Default.
+func Printf(key, format string, args ...interface{}) {
+	Default().Printf(key, format, args...)
+}
@@ -0,0 +1,82 @@
+package logthrottle
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestLogger devolve um Logger com relógio manual e saída capturada.
+// O flush periódico fica parado; os testes chamam flush direto.
+func newTestLogger(window time.Duration) (*Logger, *time.Time, *[]string) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var out []string
+	l := New(window)
+	l.now = func() time.Time { return now }
+	l.output = func(msg string) { out = append(out, msg) }
+	l.flushOnce.Do(func() {})
+	return l, &now, &out
+}
+
+func TestPrintfCoalescesWithinWindow(t *testing.T) {
+	l, now, out := newTestLogger(time.Minute)
+
+	for i := 0; i < 5; i++ {
+		l.Printf("cam1", "erro %d", i)
+		*now = now.Add(time.Second)
+	}
+	if len(*out) != 1 || (*out)[0] != "erro 0" {
+		t.Fatalf("dentro da janela esperava só a primeira mensagem, veio %q", *out)
+	}
+	l.Printf("cam2", "outra chave")
+	if len(*out) != 2 {
+		t.Fatalf("chave diferente não deveria ser suprimida: %q", *out)
+	}
+
+	*now = now.Add(time.Minute)
+	l.Printf("cam1", "erro 5")
+	if len(*out) != 4 {
+		t.Fatalf("nova janela deveria logar resumo + mensagem, veio %q", *out)
+	}
+	if !strings.Contains((*out)[2], "4 ocorrências") || !strings.Contains((*out)[2], "erro 4") {
+		t.Fatalf("resumo = %q", (*out)[2])
+	}
+	if (*out)[3] != "erro 5" {
+		t.Fatalf("mensagem após o resumo = %q", (*out)[3])
+	}
+}
+
+func TestFlushReportsAndEvictsIdleKeys(t *testing.T) {
+	l, now, out := newTestLogger(time.Minute)
+	l.Printf("burst", "falha")
+	l.Printf("burst", "falha")
+	l.Printf("quiet", "uma vez")
+
+	l.flush(now.Add(30 * time.Second))
+	if len(l.entries) != 2 {
+		t.Fatalf("flush antes do fim da janela não deveria descartar chaves: %d", len(l.entries))
+	}
+
+	l.flush(now.Add(time.Minute))
+	if len(l.entries) != 0 {
+		t.Fatalf("chaves paradas deveriam ser descartadas: %d", len(l.entries))
+	}
+	var summaries []string
+	for _, msg := range *out {
+		if strings.Contains(msg, "suprimidas") {
+			summaries = append(summaries, msg)
+		}
+	}
+	if len(summaries) != 1 || !strings.Contains(summaries[0], `"burst"`) {
+		t.Fatalf("esperava um resumo para burst, veio %q", summaries)
+	}
+}
+
+func TestWindowFromEnv(t *testing.T) {
+	for raw, want := range map[string]time.Duration{"": time.Minute, "5": 5 * time.Second, "0": 0, "x": time.Minute, "-3": time.Minute} {
+		t.Setenv("LOG_THROTTLE_SECONDS", raw)
+		if got := windowFromEnv(); got != want {
+			t.Errorf("LOG_THROTTLE_SECONDS=%q: janela %s, esperava %s", raw, got, want)
+		}
+	}
+}
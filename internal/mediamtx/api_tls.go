@@ -0,0 +1,65 @@
+// internal/mediamtx/api_tls.go
+package mediamtx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/envconf"
+	"github.com/sua-org/cam-bus/internal/netproxy"
+)
+
+const apiClientTimeout = 5 * time.Second
+
+// apiHTTPClientFromEnv monta o cliente da API do MediaMTX (reload, probe e
+// PATCH de config). Para bases https:// valem <prefix>_API_CA (PEM com a CA
+// ou o próprio certificado autoassinado) e <prefix>_API_INSECURE (sem
+// verificação; só para teste).
+func apiHTTPClientFromEnv(prefix string) *http.Client {
+	tlsCfg, err := apiTLSConfigFromEnv(prefix)
+	if err != nil {
+		log.Printf("[mediamtx] %v; usando a verificação TLS padrão", err)
+		tlsCfg = nil
+	}
+	return &http.Client{
+		Timeout:   apiClientTimeout,
+		Transport: netproxy.Transport(tlsCfg),
+	}
+}
+
+// apiTLSConfigFromEnv devolve nil quando nenhuma das variáveis está definida
+// (TLS padrão do Go, com as CAs do sistema).
+func apiTLSConfigFromEnv(prefix string) (*tls.Config, error) {
+	caPath := strings.TrimSpace(os.Getenv(prefix + "_API_CA"))
+	insecure := envconf.Bool(prefix+"_API_INSECURE", false)
+	if caPath == "" && !insecure {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if insecure {
+		log.Printf("[mediamtx] %s_API_INSECURE=true: certificado da API não é verificado", prefix)
+		cfg.InsecureSkipVerify = true
+	}
+	if caPath != "" {
+		pem, err := os.ReadFile(caPath)
+		if err != nil {
+			return nil, fmt.Errorf("ler %s_API_CA=%s: %w", prefix, caPath, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s_API_CA=%s sem certificado PEM válido", prefix, caPath)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
@@ -0,0 +1,110 @@
+package mediamtx
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tlsAPI sobe uma API https autoassinada e grava o certificado dela em PEM.
+func tlsAPI(t *testing.T, handler http.HandlerFunc) (*httptest.Server, string) {
+	t.Helper()
+	srv := httptest.NewTLSServer(handler)
+	t.Cleanup(srv.Close)
+	caPath := filepath.Join(t.TempDir(), "mtx-ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caPath, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return srv, caPath
+}
+
+func TestAPITLSConfigFromEnv(t *testing.T) {
+	_, caPath := tlsAPI(t, func(http.ResponseWriter, *http.Request) {})
+
+	if cfg, err := apiTLSConfigFromEnv("MTX_TEST"); cfg != nil || err != nil {
+		t.Fatalf("sem variáveis: %+v, %v", cfg, err)
+	}
+
+	t.Setenv("MTX_TEST_API_CA", caPath)
+	cfg, err := apiTLSConfigFromEnv("MTX_TEST")
+	if err != nil || cfg.RootCAs == nil || cfg.InsecureSkipVerify {
+		t.Fatalf("com CA: %+v, %v", cfg, err)
+	}
+
+	t.Setenv("MTX_TEST_API_CA", "")
+	t.Setenv("MTX_TEST_API_INSECURE", "true")
+	if cfg, err := apiTLSConfigFromEnv("MTX_TEST"); err != nil || !cfg.InsecureSkipVerify || cfg.RootCAs != nil {
+		t.Fatalf("insecure: %+v, %v", cfg, err)
+	}
+
+	t.Setenv("MTX_TEST_API_INSECURE", "")
+	t.Setenv("MTX_TEST_API_CA", filepath.Join(t.TempDir(), "nao-existe.pem"))
+	if _, err := apiTLSConfigFromEnv("MTX_TEST"); err == nil {
+		t.Fatal("CA inexistente deveria falhar")
+	}
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(notPEM, []byte("não é PEM"), 0o600)
+	t.Setenv("MTX_TEST_API_CA", notPEM)
+	if _, err := apiTLSConfigFromEnv("MTX_TEST"); err == nil || !strings.Contains(err.Error(), "sem certificado PEM") {
+		t.Fatalf("PEM inválido: %v", err)
+	}
+}
+
+func TestAPIHTTPClientTrustsConfiguredCA(t *testing.T) {
+	srv, caPath := tlsAPI(t, func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	get := func() error {
+		resp, err := apiHTTPClientFromEnv("MTX_TEST").Get(srv.URL + "/v3/config/global/get")
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	if err := get(); err == nil {
+		t.Fatal("sem MTX_TEST_API_CA o certificado autoassinado deveria ser recusado")
+	}
+	t.Setenv("MTX_TEST_API_CA", caPath)
+	if err := get(); err != nil {
+		t.Fatalf("com MTX_TEST_API_CA: %v", err)
+	}
+	t.Setenv("MTX_TEST_API_CA", "")
+	t.Setenv("MTX_TEST_API_INSECURE", "1")
+	if err := get(); err != nil {
+		t.Fatalf("com MTX_TEST_API_INSECURE: %v", err)
+	}
+
+	// CA inválida: loga e cai na verificação padrão, sem desligar o TLS
+	t.Setenv("MTX_TEST_API_INSECURE", "")
+	t.Setenv("MTX_TEST_API_CA", filepath.Join(t.TempDir(), "nao-existe.pem"))
+	if err := get(); err == nil {
+		t.Fatal("CA inexistente não pode liberar o certificado autoassinado")
+	}
+}
+
+func TestGeneratorAPIOverHTTPS(t *testing.T) {
+	var got []string
+	srv, caPath := tlsAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		got = append(got, r.Method+" "+r.URL.Path+" "+user+":"+pass)
+		w.WriteHeader(http.StatusOK)
+	})
+	t.Setenv("MTX_PROXY_CONFIG_PATH", filepath.Join(t.TempDir(), "mediamtx.yml"))
+	t.Setenv("MTX_PROXY_RELOAD_URL", srv.URL)
+	t.Setenv("MTX_PROXY_RELOAD_USER", "api")
+	t.Setenv("MTX_PROXY_RELOAD_PASS", "segredo")
+	t.Setenv("MTX_PROXY_API_CA", caPath)
+
+	g := NewGeneratorFromEnv()
+	if err := g.doJSON(context.Background(), http.MethodPatch, "v3/config/global/patch", map[string]bool{"api": true}); err != nil {
+		t.Fatalf("PATCH via https: %v", err)
+	}
+	if len(got) != 1 || got[0] != "PATCH /v3/config/global/patch api:segredo" {
+		t.Fatalf("requisições = %v", got)
+	}
+}
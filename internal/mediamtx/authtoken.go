@@ -0,0 +1,175 @@
+// internal/mediamtx/authtoken.go
+package mediamtx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/envconf"
+	"github.com/sua-org/cam-bus/internal/netproxy"
+)
+
+const (
+	defaultAuthTokenTTL    = 5 * time.Minute
+	defaultAuthTokenMargin = 30 * time.Second
+)
+
+// authTokenProvider busca um bearer token de curta duração para a API do
+// MediaMTX, via comando externo (AUTH_CMD) ou endpoint HTTP (AUTH_URL), e o
+// mantém em cache até perto da expiração.
+type authTokenProvider struct {
+	cmd        string
+	url        string
+	ttl        time.Duration
+	margin     time.Duration
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// tokenResponse cobre os formatos mais comuns de serviços de auth
+// (OAuth2 access_token/expires_in ou token/expires_at).
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	Token       string `json:"token"`
+	ExpiresIn   int64  `json:"expires_in"`
+	ExpiresAt   string `json:"expires_at"`
+}
+
+// newAuthTokenProviderFromEnv lê <prefix>_AUTH_CMD / <prefix>_AUTH_URL.
+// <prefix>_AUTH_TTL (default: 5m) vale quando a resposta não informa expiração;
+// <prefix>_AUTH_REFRESH_MARGIN (default: 30s) antecipa a renovação.
+// Retorna nil quando nenhum dos dois está configurado.
+func newAuthTokenProviderFromEnv(prefix string) *authTokenProvider {
+	cmd := strings.TrimSpace(os.Getenv(prefix + "_AUTH_CMD"))
+	endpoint := strings.TrimSpace(os.Getenv(prefix + "_AUTH_URL"))
+	if cmd == "" && endpoint == "" {
+		return nil
+	}
+	if cmd != "" && endpoint != "" {
+		log.Printf("[mediamtx] %s_AUTH_CMD e %s_AUTH_URL definidos, usando o comando", prefix, prefix)
+		endpoint = ""
+	}
+	return &authTokenProvider{
+		cmd:        cmd,
+		url:        endpoint,
+		ttl:        envconf.Duration(prefix+"_AUTH_TTL", defaultAuthTokenTTL),
+		margin:     envconf.Duration(prefix+"_AUTH_REFRESH_MARGIN", defaultAuthTokenMargin),
+		httpClient: netproxy.Client(5 * time.Second),
+		now:        time.Now,
+	}
+}
+
+// Token devolve o token em cache ou busca um novo se estiver perto de expirar.
+func (p *authTokenProvider) Token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != "" && p.now().Add(p.margin).Before(p.expiresAt) {
+		return p.token, nil
+	}
+
+	token, expiresAt, err := p.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	p.token = token
+	p.expiresAt = expiresAt
+	return token, nil
+}
+
+// Invalidate descarta o token em cache (ex.: após um 401 da API).
+func (p *authTokenProvider) Invalidate() {
+	p.mu.Lock()
+	p.token = ""
+	p.expiresAt = time.Time{}
+	p.mu.Unlock()
+}
+
+func (p *authTokenProvider) fetch(ctx context.Context) (string, time.Time, error) {
+	var (
+		raw []byte
+		err error
+	)
+	if p.cmd != "" {
+		raw, err = exec.CommandContext(ctx, "sh", "-c", p.cmd).Output()
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("auth cmd: %w", err)
+		}
+	} else {
+		raw, err = p.fetchURL(ctx)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+	}
+	return p.parse(raw)
+}
+
+func (p *authTokenProvider) fetchURL(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create auth request: %w", err)
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request auth url: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, fmt.Errorf("read auth response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("auth url status %s", resp.Status)
+	}
+	return body, nil
+}
+
+// parse aceita JSON (tokenResponse) ou o token puro em texto.
+func (p *authTokenProvider) parse(raw []byte) (string, time.Time, error) {
+	text := strings.TrimSpace(string(raw))
+	if text == "" {
+		return "", time.Time{}, fmt.Errorf("auth token vazio")
+	}
+
+	now := p.now()
+	if !strings.HasPrefix(text, "{") {
+		return text, now.Add(p.ttl), nil
+	}
+
+	var tr tokenResponse
+	if err := json.Unmarshal([]byte(text), &tr); err != nil {
+		return "", time.Time{}, fmt.Errorf("decode auth response: %w", err)
+	}
+	token := tr.AccessToken
+	if token == "" {
+		token = tr.Token
+	}
+	if token == "" {
+		return "", time.Time{}, fmt.Errorf("auth response sem token")
+	}
+
+	expiresAt := now.Add(p.ttl)
+	switch {
+	case tr.ExpiresIn > 0:
+		expiresAt = now.Add(time.Duration(tr.ExpiresIn) * time.Second)
+	case tr.ExpiresAt != "":
+		if t, err := time.Parse(time.RFC3339, tr.ExpiresAt); err == nil {
+			expiresAt = t
+		}
+	}
+	return token, expiresAt, nil
+}
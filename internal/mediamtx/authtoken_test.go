@@ -0,0 +1,100 @@
+package mediamtx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAuthTokenRefreshesNearExpiry(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		fmt.Fprintf(w, `{"access_token":"tok-%d","expires_in":60}`, n)
+	}))
+	defer srv.Close()
+
+	t.Setenv("MTX_TEST_AUTH_URL", srv.URL)
+	t.Setenv("MTX_TEST_AUTH_REFRESH_MARGIN", "10s")
+	p := newAuthTokenProviderFromEnv("MTX_TEST")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+
+	token := func() string {
+		t.Helper()
+		tok, err := p.Token(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+
+	if got := token(); got != "tok-1" {
+		t.Fatalf("token = %q", got)
+	}
+	now = now.Add(45 * time.Second)
+	if got := token(); got != "tok-1" || calls.Load() != 1 {
+		t.Fatalf("token em cache renovado cedo demais: %q (%d chamadas)", got, calls.Load())
+	}
+	now = now.Add(10 * time.Second) // dentro da margem de 10s
+	if got := token(); got != "tok-2" {
+		t.Fatalf("token perto da expiração não renovado: %q", got)
+	}
+
+	p.Invalidate()
+	if got := token(); got != "tok-3" {
+		t.Fatalf("Invalidate não forçou nova busca: %q", got)
+	}
+}
+
+func TestAuthTokenFromCommand(t *testing.T) {
+	t.Setenv("MTX_TEST_AUTH_CMD", "echo plain-token")
+	t.Setenv("MTX_TEST_AUTH_TTL", "2m")
+	p := newAuthTokenProviderFromEnv("MTX_TEST")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+
+	tok, err := p.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok != "plain-token" || !p.expiresAt.Equal(now.Add(2*time.Minute)) {
+		t.Fatalf("token=%q expira=%s, esperava TTL padrão de 2m", tok, p.expiresAt)
+	}
+}
+
+func TestAuthTokenParse(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := &authTokenProvider{ttl: time.Minute, now: func() time.Time { return now }}
+
+	tok, exp, err := p.parse([]byte(`{"token":"abc","expires_at":"2024-01-01T01:00:00Z"}`))
+	if err != nil || tok != "abc" || !exp.Equal(now.Add(time.Hour)) {
+		t.Fatalf("expires_at: %q %s %v", tok, exp, err)
+	}
+	for _, raw := range []string{"", "  ", `{"expires_in":60}`, `{"token":`} {
+		if _, _, err := p.parse([]byte(raw)); err == nil {
+			t.Errorf("parse(%q) deveria falhar", raw)
+		}
+	}
+}
+
+func TestAuthTokenProviderDisabled(t *testing.T) {
+	if p := newAuthTokenProviderFromEnv("MTX_NADA"); p != nil {
+		t.Fatal("sem AUTH_CMD/AUTH_URL não deveria haver provider")
+	}
+}
+
+func TestApplyAPIAuthFallsBackToStaticToken(t *testing.T) {
+	t.Setenv("MTX_TEST_AUTH_CMD", "exit 1")
+	g := &Generator{authProvider: newAuthTokenProviderFromEnv("MTX_TEST"), reloadAuthToken: "static"}
+
+	req := httptest.NewRequest(http.MethodGet, "http://mediamtx/v3/config/paths/list", nil)
+	g.applyAPIAuth(req)
+	if got := req.Header.Get("Authorization"); got != "Bearer static" {
+		t.Fatalf("Authorization = %q, esperava o token estático", got)
+	}
+}
@@ -0,0 +1,102 @@
+package mediamtx
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+// fakeAPI é a API do MediaMTX que aceita tudo e guarda as chamadas.
+type fakeAPI struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.calls = append(f.calls, r.Method+" "+r.URL.Path)
+	f.mu.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+// newTestGenerator cria o gerador do proxy escrevendo em um diretório
+// temporário e aplicando via a API fake.
+func newTestGenerator(t *testing.T) (*Generator, *fakeAPI) {
+	t.Helper()
+	api := &fakeAPI{}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	t.Setenv("MTX_PROXY_CONFIG_PATH", filepath.Join(t.TempDir(), "mediamtx.yml"))
+	t.Setenv("MTX_PROXY_RELOAD_URL", srv.URL)
+	g := NewGeneratorFromEnv()
+	if g == nil {
+		t.Fatal("NewGeneratorFromEnv devolveu nil")
+	}
+	return g, api
+}
+
+func testCameras() []core.CameraInfo {
+	return []core.CameraInfo{
+		{Tenant: "t", Building: "b", DeviceID: "cam-c", RTSPURL: "rtsp://10.0.0.3/stream"},
+		{Tenant: "t", Building: "a", DeviceID: "cam-a", RTSPURL: "rtsp://10.0.0.1/stream"},
+		{Tenant: "t", Building: "b", DeviceID: "cam-b", RTSPURL: "rtsp://10.0.0.2/stream"},
+		// mesmo path de cam-a: fica o da câmera que ordena primeiro
+		{Tenant: "t", Building: "z", DeviceID: "cam-dup", ProxyPath: "cam-a", RTSPURL: "rtsp://10.0.0.9/stream"},
+	}
+}
+
+func TestSyncYAMLIsDeterministic(t *testing.T) {
+	cams := testCameras()
+	reversed := make([]core.CameraInfo, len(cams))
+	for i, c := range cams {
+		reversed[len(cams)-1-i] = c
+	}
+
+	var outputs [][]byte
+	for _, order := range [][]core.CameraInfo{cams, reversed, cams} {
+		g, _ := newTestGenerator(t)
+		if err := g.Sync(order); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(g.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs = append(outputs, data)
+	}
+	for i := 1; i < len(outputs); i++ {
+		if !bytes.Equal(outputs[0], outputs[i]) {
+			t.Fatalf("YAML da sync %d difere da 1ª:\n%s\n---\n%s", i+1, outputs[0], outputs[i])
+		}
+	}
+	if !bytes.Contains(outputs[0], []byte("rtsp://10.0.0.1/stream")) || bytes.Contains(outputs[0], []byte("10.0.0.9")) {
+		t.Fatalf("path duplicado deveria ficar com cam-a:\n%s", outputs[0])
+	}
+}
+
+func TestSyncSameCamerasIsNoop(t *testing.T) {
+	g, api := newTestGenerator(t)
+	if err := g.Sync(testCameras()); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := os.ReadFile(g.path)
+	calls := len(api.calls)
+
+	if err := g.Sync(testCameras()); err != nil {
+		t.Fatal(err)
+	}
+	second, _ := os.ReadFile(g.path)
+	if !bytes.Equal(first, second) {
+		t.Fatalf("2ª sync alterou o YAML:\n%s\n---\n%s", first, second)
+	}
+	if len(api.calls) != calls {
+		t.Fatalf("2ª sync sem mudança chamou a API: %v", api.calls[calls:])
+	}
+}
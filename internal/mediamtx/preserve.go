@@ -0,0 +1,54 @@
+// internal/mediamtx/preserve.go
+package mediamtx
+
+import (
+	"log"
+	"os"
+	"path"
+	"strings"
+)
+
+// pathPreserveList guarda os paths que o Sync não gerencia (MTX_PRESERVE_PATHS):
+// nomes exatos ou globs (path.Match), separados por vírgula. Ex.:
+// "test-stream,nvr-*". Servem para paths estáticos adicionados à mão no YAML.
+type pathPreserveList []string
+
+func preservePathsFromEnv() pathPreserveList {
+	var out pathPreserveList
+	for _, raw := range strings.Split(os.Getenv("MTX_PRESERVE_PATHS"), ",") {
+		pattern := strings.TrimSpace(raw)
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			log.Printf("[mediamtx] MTX_PRESERVE_PATHS: padrão inválido %q: %v", pattern, err)
+			continue
+		}
+		out = append(out, pattern)
+	}
+	return out
+}
+
+func (l pathPreserveList) matches(name string) bool {
+	for _, pattern := range l {
+		if pattern == name {
+			return true
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// keep copia os paths preservados da config existente para a nova.
+func (l pathPreserveList) keep(existing, cfg map[string]PathConfig) {
+	if len(l) == 0 {
+		return
+	}
+	for name, pathCfg := range existing {
+		if l.matches(name) {
+			cfg[name] = pathCfg
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package mediamtx
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestPreservePathsFromEnv(t *testing.T) {
+	t.Setenv("MTX_PRESERVE_PATHS", " test-stream , nvr-*,,[bad")
+	l := preservePathsFromEnv()
+	if len(l) != 2 {
+		t.Fatalf("padrões = %v, esperava o inválido descartado", l)
+	}
+	for name, want := range map[string]bool{"test-stream": true, "nvr-01": true, "nvr": false, "cam-a": false} {
+		if got := l.matches(name); got != want {
+			t.Errorf("matches(%q) = %t, esperava %t", name, got, want)
+		}
+	}
+}
+
+func TestSyncKeepsPreservedPaths(t *testing.T) {
+	t.Setenv("MTX_PRESERVE_PATHS", "test-stream,nvr-*")
+	g, api := newTestGenerator(t)
+	initial := `paths:
+  test-stream:
+    source: rtsp://static/test
+  nvr-01:
+    source: rtsp://static/nvr
+  stale-cam:
+    source: rtsp://10.0.0.99/stream
+`
+	if err := os.WriteFile(g.path, []byte(initial), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := g.Sync(testCameras()); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(g.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Paths["test-stream"].Source != "rtsp://static/test" || cfg.Paths["nvr-01"].Source != "rtsp://static/nvr" {
+		t.Fatalf("paths preservados perdidos: %v", cfg.Paths)
+	}
+	if _, ok := cfg.Paths["stale-cam"]; ok {
+		t.Fatal("path não gerenciado e não preservado deveria sair")
+	}
+	if _, ok := cfg.Paths["cam-a"]; !ok {
+		t.Fatal("paths das câmeras deveriam ser gerados")
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	for _, call := range api.calls {
+		if strings.Contains(call, "test-stream") || strings.Contains(call, "nvr-01") {
+			t.Errorf("API tocou em path preservado: %s", call)
+		}
+	}
+}
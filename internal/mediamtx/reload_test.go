@@ -0,0 +1,93 @@
+package mediamtx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"sync/atomic"
+	"testing"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+const missingPID = 99999999
+
+func TestValidateReloadProcess(t *testing.T) {
+	self, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := self.Name()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := validateReloadProcess(os.Getpid(), ""); err != nil {
+		t.Fatalf("PID próprio sem nome: %v", err)
+	}
+	if err := validateReloadProcess(os.Getpid(), name); err != nil {
+		t.Fatalf("PID próprio com nome %q: %v", name, err)
+	}
+	if err := validateReloadProcess(os.Getpid(), "mediamtx"); err == nil {
+		t.Fatal("nome diferente deveria falhar (PID reaproveitado)")
+	}
+	if err := validateReloadProcess(missingPID, ""); err == nil {
+		t.Fatal("PID inexistente deveria falhar")
+	}
+}
+
+func TestParsePIDEnv(t *testing.T) {
+	for raw, want := range map[string]int{"": 0, "123": 123, " 42 ": 42, "-1": 0, "abc": 0} {
+		t.Setenv("MTX_TEST_PID", raw)
+		if got := parsePIDEnv("MTX_TEST_PID"); got != want {
+			t.Errorf("parsePIDEnv(%q) = %d, esperava %d", raw, got, want)
+		}
+	}
+}
+
+// hupProof sobe um processo que ignora SIGHUP, no papel do MediaMTX.
+func hupProof(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command("sh", "-c", `trap "" HUP; sleep 30`)
+	if err := cmd.Start(); err != nil {
+		t.Skipf("sem sh para o teste: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	return cmd.Process.Pid
+}
+
+func TestReloadViaSignalRetriesUntilProbeOK(t *testing.T) {
+	var probes atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if probes.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	g := &Generator{
+		reloadPID:      hupProof(t),
+		reloadRetries:  3,
+		reloadProbeURL: srv.URL,
+		httpClient:     srv.Client(),
+	}
+	if err := g.reloadViaSignal(); err != nil {
+		t.Fatalf("reload deveria passar na 2ª tentativa: %v", err)
+	}
+	if got := probes.Load(); got != 2 {
+		t.Fatalf("%d probes, esperava 2", got)
+	}
+}
+
+func TestReloadViaSignalGivesUp(t *testing.T) {
+	g := &Generator{reloadPID: missingPID, reloadRetries: 2}
+	if err := g.reloadViaSignal(); err == nil {
+		t.Fatal("PID inexistente deveria falhar depois das tentativas")
+	}
+}
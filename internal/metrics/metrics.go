@@ -0,0 +1,194 @@
+// internal/metrics/metrics.go
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Kind diferencia contadores cumulativos de gauges.
+type Kind int
+
+const (
+	KindCounter Kind = iota
+	KindGauge
+)
+
+// Point é uma amostra de uma métrica com seus atributos.
+type Point struct {
+	Attrs map[string]string
+	Value float64
+}
+
+// Metric é o estado de um instrumento lido pelo callback do SDK a cada coleta.
+type Metric struct {
+	Name        string
+	Description string
+	Unit        string
+	Kind        Kind
+	Points      []Point
+}
+
+// GaugeFunc é chamada na coleta para ler o valor atual (ex.: câmeras online).
+type GaugeFunc func() []Point
+
+type instrument struct {
+	description string
+	unit        string
+	kind        Kind
+	gauge       GaugeFunc
+	values      map[string]*Point // counters: chave = atributos serializados
+}
+
+// Registry guarda os instrumentos do cam-bus; o SDK OpenTelemetry os lê via
+// NewMeterProvider. Um Registry nil é válido e ignora tudo, para o supervisor
+// não precisar checar se métricas estão ligadas.
+type Registry struct {
+	mu          sync.Mutex
+	instruments map[string]*instrument
+}
+
+// NewRegistry cria um registry vazio.
+func NewRegistry() *Registry {
+	return &Registry{instruments: make(map[string]*instrument)}
+}
+
+// Counter registra um contador monotônico.
+func (r *Registry) Counter(name, description, unit string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.instruments[name]; ok {
+		return
+	}
+	r.instruments[name] = &instrument{
+		description: description,
+		unit:        unit,
+		kind:        KindCounter,
+		values:      make(map[string]*Point),
+	}
+}
+
+// Gauge registra um gauge lido via callback na coleta.
+func (r *Registry) Gauge(name, description, unit string, fn GaugeFunc) {
+	if r == nil || fn == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.instruments[name] = &instrument{
+		description: description,
+		unit:        unit,
+		kind:        KindGauge,
+		gauge:       fn,
+	}
+}
+
+// Add incrementa um contador registrado; nomes desconhecidos são ignorados.
+func (r *Registry) Add(name string, delta float64, attrs map[string]string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	inst, ok := r.instruments[name]
+	if !ok || inst.kind != KindCounter {
+		return
+	}
+	key := attrsKey(attrs)
+	p, ok := inst.values[key]
+	if !ok {
+		p = &Point{Attrs: copyAttrs(attrs)}
+		inst.values[key] = p
+	}
+	p.Value += delta
+}
+
+// Names devolve os nomes dos instrumentos registrados, ordenados.
+func (r *Registry) Names() []string {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]string, 0, len(r.instruments))
+	for name := range r.instruments {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Collect lê todos os instrumentos. Gauges são chamados fora do lock.
+func (r *Registry) Collect() []Metric {
+	if r == nil {
+		return nil
+	}
+
+	type gaugeRef struct {
+		name string
+		inst *instrument
+	}
+	var gauges []gaugeRef
+	var out []Metric
+
+	r.mu.Lock()
+	for name, inst := range r.instruments {
+		if inst.kind == KindGauge {
+			gauges = append(gauges, gaugeRef{name: name, inst: inst})
+			continue
+		}
+		m := Metric{Name: name, Description: inst.description, Unit: inst.unit, Kind: KindCounter}
+		for _, p := range inst.values {
+			m.Points = append(m.Points, Point{Attrs: copyAttrs(p.Attrs), Value: p.Value})
+		}
+		out = append(out, m)
+	}
+	r.mu.Unlock()
+
+	for _, g := range gauges {
+		out = append(out, Metric{
+			Name:        g.name,
+			Description: g.inst.description,
+			Unit:        g.inst.unit,
+			Kind:        KindGauge,
+			Points:      g.inst.gauge(),
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func attrsKey(attrs map[string]string) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(attrs[k])
+		b.WriteByte(';')
+	}
+	return b.String()
+}
+
+func copyAttrs(attrs map[string]string) map[string]string {
+	if attrs == nil {
+		return nil
+	}
+	out := make(map[string]string, len(attrs))
+	for k, v := range attrs {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,228 @@
+// internal/metrics/otlp.go
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+
+	"github.com/sua-org/cam-bus/internal/netproxy"
+)
+
+const (
+	defaultExportInterval = 30 * time.Second
+	defaultPrometheusAddr = ":9464"
+	scopeName             = "github.com/sua-org/cam-bus"
+)
+
+// NewMeterProvider cria o MeterProvider do SDK OpenTelemetry com os readers
+// dados e registra nele os instrumentos do registry. Instrumentos registrados
+// depois não são exportados.
+func NewMeterProvider(reg *Registry, serviceName string, readers ...sdkmetric.Reader) (*sdkmetric.MeterProvider, error) {
+	opts := []sdkmetric.Option{
+		sdkmetric.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	}
+	for _, r := range readers {
+		opts = append(opts, sdkmetric.WithReader(r))
+	}
+	provider := sdkmetric.NewMeterProvider(opts...)
+	if err := reg.register(provider.Meter(scopeName)); err != nil {
+		_ = provider.Shutdown(context.Background())
+		return nil, err
+	}
+	return provider, nil
+}
+
+// register cria um instrumento observável por instrumento do registry e um
+// callback que lê o registry (Collect) a cada coleta do SDK.
+func (r *Registry) register(meter metric.Meter) error {
+	if r == nil {
+		return nil
+	}
+
+	observables := make(map[string]metric.Float64Observable)
+	var all []metric.Observable
+	for _, m := range r.Collect() {
+		var (
+			inst metric.Float64Observable
+			err  error
+		)
+		if m.Kind == KindCounter {
+			inst, err = meter.Float64ObservableCounter(m.Name, metric.WithDescription(m.Description), metric.WithUnit(m.Unit))
+		} else {
+			inst, err = meter.Float64ObservableGauge(m.Name, metric.WithDescription(m.Description), metric.WithUnit(m.Unit))
+		}
+		if err != nil {
+			return fmt.Errorf("instrumento %s: %w", m.Name, err)
+		}
+		observables[m.Name] = inst
+		all = append(all, inst)
+	}
+	if len(all) == 0 {
+		return nil
+	}
+
+	_, err := meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		for _, m := range r.Collect() {
+			inst, ok := observables[m.Name]
+			if !ok {
+				continue
+			}
+			for _, p := range m.Points {
+				o.ObserveFloat64(inst, p.Value, metric.WithAttributes(attributesOf(p.Attrs)...))
+			}
+		}
+		return nil
+	}, all...)
+	return err
+}
+
+func attributesOf(attrs map[string]string) []attribute.KeyValue {
+	out := make([]attribute.KeyValue, 0, len(attrs))
+	for k, v := range attrs {
+		out = append(out, attribute.String(k, v))
+	}
+	return out
+}
+
+// ServiceNameFromEnv lê OTEL_SERVICE_NAME (default: cam-bus).
+func ServiceNameFromEnv() string {
+	if name := strings.TrimSpace(os.Getenv("OTEL_SERVICE_NAME")); name != "" {
+		return name
+	}
+	return "cam-bus"
+}
+
+// otlpReaderFromEnv cria o reader periódico com o exporter OTLP/HTTP. O
+// exporter segue as variáveis padrão do OpenTelemetry
+// (OTEL_EXPORTER_OTLP_METRICS_ENDPOINT ou OTEL_EXPORTER_OTLP_ENDPOINT,
+// OTEL_EXPORTER_OTLP_HEADERS); exigimos o endpoint explícito para não
+// mandar para localhost por engano.
+func otlpReaderFromEnv(ctx context.Context) (sdkmetric.Reader, error) {
+	if strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT")) == "" &&
+		strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")) == "" {
+		return nil, errors.New("OTEL_EXPORTER_OTLP_ENDPOINT não definido")
+	}
+	exp, err := otlpmetrichttp.New(ctx, otlpmetrichttp.WithProxy(netproxy.Func))
+	if err != nil {
+		return nil, err
+	}
+
+	interval := defaultExportInterval
+	if raw := strings.TrimSpace(os.Getenv("METRICS_EXPORT_INTERVAL")); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+			interval = d
+		} else {
+			log.Printf("[metrics] METRICS_EXPORT_INTERVAL inválido (%q), usando %s", raw, interval)
+		}
+	}
+	return sdkmetric.NewPeriodicReader(exp, sdkmetric.WithInterval(interval)), nil
+}
+
+// servePrometheus expõe /metrics para scrape até o ctx acabar.
+func servePrometheus(ctx context.Context, addr string, gatherer prom.Gatherer) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("[metrics] endpoint Prometheus em %s falhou: %v", addr, err)
+	}
+}
+
+// StartFromEnv liga o export conforme METRICS_EXPORTER=none|otlp|prometheus|both
+// (default: none). otlp envia via OTLP/HTTP a cada METRICS_EXPORT_INTERVAL
+// (default: 30s); prometheus expõe /metrics em METRICS_PROMETHEUS_ADDR
+// (default: :9464); both faz os dois. No fim do ctx o provider é encerrado,
+// com um último envio OTLP; a função devolvida espera esse envio (não faz nada
+// com o export desligado).
+func StartFromEnv(ctx context.Context, reg *Registry) (wait func()) {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("METRICS_EXPORTER")))
+	var useOTLP, usePrometheus bool
+	switch mode {
+	case "", "none":
+		return func() {}
+	case "otlp":
+		useOTLP = true
+	case "prometheus":
+		usePrometheus = true
+	case "both":
+		useOTLP, usePrometheus = true, true
+	default:
+		log.Printf("[metrics] METRICS_EXPORTER inválido (%q), export desabilitado", mode)
+		return func() {}
+	}
+
+	var readers []sdkmetric.Reader
+	if useOTLP {
+		reader, err := otlpReaderFromEnv(ctx)
+		if err != nil {
+			log.Printf("[metrics] OTLP desabilitado: %v", err)
+		} else {
+			readers = append(readers, reader)
+		}
+	}
+
+	var gatherer *prom.Registry
+	if usePrometheus {
+		gatherer = prom.NewRegistry()
+		reader, err := otelprom.New(otelprom.WithRegisterer(gatherer))
+		if err != nil {
+			log.Printf("[metrics] Prometheus desabilitado: %v", err)
+			gatherer = nil
+		} else {
+			readers = append(readers, reader)
+		}
+	}
+	if len(readers) == 0 {
+		return func() {}
+	}
+
+	provider, err := NewMeterProvider(reg, ServiceNameFromEnv(), readers...)
+	if err != nil {
+		log.Printf("[metrics] export desabilitado: %v", err)
+		return func() {}
+	}
+	if gatherer != nil {
+		addr := strings.TrimSpace(os.Getenv("METRICS_PROMETHEUS_ADDR"))
+		if addr == "" {
+			addr = defaultPrometheusAddr
+		}
+		go servePrometheus(ctx, addr, gatherer)
+		log.Printf("[metrics] scrape Prometheus em %s/metrics", addr)
+	}
+	log.Printf("[metrics] export %s habilitado (instrumentos=%d)", mode, len(reg.Names()))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := provider.Shutdown(shutdownCtx); err != nil {
+			log.Printf("[metrics] erro encerrando export: %v", err)
+		}
+	}()
+	return func() { <-done }
+}
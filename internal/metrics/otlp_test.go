@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// fakeExporter guarda o último lote recebido do PeriodicReader.
+type fakeExporter struct {
+	mu   sync.Mutex
+	last *metricdata.ResourceMetrics
+}
+
+func (f *fakeExporter) Temporality(k sdkmetric.InstrumentKind) metricdata.Temporality {
+	return sdkmetric.DefaultTemporalitySelector(k)
+}
+
+func (f *fakeExporter) Aggregation(k sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(k)
+}
+
+func (f *fakeExporter) Export(_ context.Context, rm *metricdata.ResourceMetrics) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.last = rm
+	return nil
+}
+
+func (f *fakeExporter) ForceFlush(context.Context) error { return nil }
+func (f *fakeExporter) Shutdown(context.Context) error   { return nil }
+
+func testRegistry() *Registry {
+	reg := NewRegistry()
+	reg.Counter("cambus.events.published", "Eventos publicados", "{event}")
+	reg.Gauge("cambus.cameras", "Câmeras por estado", "{camera}", func() []Point {
+		return []Point{{Attrs: map[string]string{"status": "online"}, Value: 3}}
+	})
+	reg.Add("cambus.events.published", 2, map[string]string{"analytic_type": "faceCapture"})
+	return reg
+}
+
+func TestMeterProviderExportsRegisteredInstruments(t *testing.T) {
+	exp := &fakeExporter{}
+	reg := testRegistry()
+	provider, err := NewMeterProvider(reg, "cam-bus-test", sdkmetric.NewPeriodicReader(exp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	exp.mu.Lock()
+	rm := exp.last
+	exp.mu.Unlock()
+	if rm == nil {
+		t.Fatal("exporter não recebeu nada")
+	}
+	if name, _ := rm.Resource.Set().Value("service.name"); name.AsString() != "cam-bus-test" {
+		t.Fatalf("service.name = %q", name.AsString())
+	}
+
+	var names []string
+	values := map[string]float64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			names = append(names, m.Name)
+			switch data := m.Data.(type) {
+			case metricdata.Sum[float64]:
+				if !data.IsMonotonic || data.Temporality != metricdata.CumulativeTemporality {
+					t.Errorf("%s: esperava soma monotônica cumulativa", m.Name)
+				}
+				for _, dp := range data.DataPoints {
+					values[m.Name] += dp.Value
+				}
+			case metricdata.Gauge[float64]:
+				for _, dp := range data.DataPoints {
+					values[m.Name] += dp.Value
+				}
+			default:
+				t.Errorf("%s: tipo inesperado %T", m.Name, m.Data)
+			}
+		}
+	}
+	sort.Strings(names)
+	if want := reg.Names(); len(names) != len(want) || names[0] != want[0] || names[1] != want[1] {
+		t.Fatalf("instrumentos exportados = %v, registrados = %v", names, want)
+	}
+	if values["cambus.events.published"] != 2 || values["cambus.cameras"] != 3 {
+		t.Fatalf("valores = %v", values)
+	}
+}
+
+func TestMeterProviderPrometheusAndOTLPTogether(t *testing.T) {
+	exp := &fakeExporter{}
+	gatherer := prom.NewRegistry()
+	promReader, err := otelprom.New(otelprom.WithRegisterer(gatherer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider, err := NewMeterProvider(testRegistry(), "cam-bus-test", sdkmetric.NewPeriodicReader(exp), promReader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	families, err := gatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]bool{}
+	for _, f := range families {
+		got[f.GetName()] = true
+	}
+	for _, want := range []string{"cambus_events_published_total", "cambus_cameras"} {
+		if !got[want] {
+			t.Errorf("Prometheus sem %s (tem %v)", want, got)
+		}
+	}
+
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	exp.mu.Lock()
+	defer exp.mu.Unlock()
+	if exp.last == nil || len(exp.last.ScopeMetrics) == 0 {
+		t.Fatal("exporter OTLP não recebeu as métricas com prometheus ligado")
+	}
+}
@@ -2,49 +2,251 @@
 package mqttclient
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"log"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/sua-org/cam-bus/internal/envconf"
 )
 
 type Client struct {
     client mqtt.Client
+    qos    byte // QoS de Publish/Subscribe chamados com DefaultQoS
+
+    mu         sync.Mutex
+    connected  bool // já houve a 1ª conexão (as seguintes são reconexões)
+    onConnect  []func()
+    onLost     []func(error)
+    subs       map[string]subscription // refeitas na reconexão com clean session
+    persistent bool
 }
 
+type subscription struct {
+    qos     byte
+    handler func(topic string, payload []byte)
+}
+
+// DefaultQoS, passado no lugar do QoS em Publish/Subscribe, usa o QoS do
+// Config (MQTT_QOS).
+const DefaultQoS byte = 0xFF
+
 type Config struct {
     Host     string
     Port     int
     Username string
     Password string
     ClientID string
+
+    // TLS com o broker (ssl://). CACert vazio = CAs do sistema; ClientCert e
+    // ClientKey (PEM) juntos habilitam mTLS; TLSInsecure não verifica o
+    // certificado do broker.
+    UseTLS      bool
+    CACert      string
+    ClientCert  string
+    ClientKey   string
+    TLSInsecure bool
+
+    // QoS usado por Publish/Subscribe com DefaultQoS (0, 1 ou 2).
+    QoS byte
+
+    // PersistentSession desliga o clean session: o broker guarda assinaturas e
+    // mensagens QoS>0 durante a reconexão. Exige ClientID estável e único.
+    PersistentSession bool
+
+    // Last Will: o broker publica WillPayload em WillTopic quando a conexão
+    // cai sem Disconnect (crash, rede). WillTopic vazio = sem will.
+    WillTopic    string
+    WillPayload  []byte
+    WillQoS      byte
+    WillRetained bool
 }
 
+const (
+    defaultPort    = 1883
+    defaultTLSPort = 8883
+)
+
 func NewClientFromEnv(defaultClientID string) (*Client, error) {
+    return NewClient(ConfigFromEnv(defaultClientID))
+}
+
+// ConfigFromEnv lê MQTT_* para o Config, para quem precisa ajustar algo (ex.:
+// o will) antes do NewClient.
+func ConfigFromEnv(defaultClientID string) Config {
     host := getenv("MQTT_HOST", "localhost")
-    port := getenvInt("MQTT_PORT", 1883)
+    useTLS := envconf.Bool("MQTT_USE_TLS", false)
+    port := envconf.PositiveInt("MQTT_PORT", 0) // 0 = 1883, ou 8883 com TLS
     user := os.Getenv("MQTT_USERNAME")
     pass := os.Getenv("MQTT_PASSWORD")
 
     cfg := Config{
-        Host:     host,
-        Port:     port,
-        Username: user,
-        Password: pass,
-        ClientID: getenv("MQTT_CLIENT_ID", defaultClientID),
+        Host:        host,
+        Port:        port,
+        Username:    user,
+        Password:    pass,
+        ClientID:    getenv("MQTT_CLIENT_ID", defaultClientID),
+        UseTLS:      useTLS,
+        CACert:      os.Getenv("MQTT_CA_CERT"),
+        ClientCert:  os.Getenv("MQTT_CLIENT_CERT"),
+        ClientKey:   os.Getenv("MQTT_CLIENT_KEY"),
+        TLSInsecure: envconf.Bool("MQTT_TLS_INSECURE", false),
+        QoS:         getenvQoS("MQTT_QOS", 1),
+    }
+    // inválido = clean session (default), nunca uma sessão persistente acidental
+    if !envconf.Bool("MQTT_CLEAN_SESSION", true) {
+        cfg.PersistentSession = true
+        if os.Getenv("MQTT_CLIENT_ID") == "" {
+            log.Printf("[mqtt] MQTT_CLEAN_SESSION=false sem MQTT_CLIENT_ID: sessão persistente presa ao client id padrão %q (use um id estável e único por instância)", cfg.ClientID)
+        }
     }
 
-    return NewClient(cfg)
+    return cfg
 }
 
 func NewClient(cfg Config) (*Client, error) {
-    broker := fmt.Sprintf("tcp://%s:%d", cfg.Host, cfg.Port)
+    opts, err := newClientOptions(cfg)
+    if err != nil {
+        return nil, err
+    }
+
+    c := &Client{
+        qos:        cfg.QoS,
+        subs:       make(map[string]subscription),
+        persistent: cfg.PersistentSession,
+    }
+    opts.SetOnConnectHandler(func(mqtt.Client) { c.handleConnect() })
+    opts.SetConnectionLostHandler(func(_ mqtt.Client, err error) { c.handleConnectionLost(err) })
+
+    c.client = mqtt.NewClient(opts)
+    token := c.client.Connect()
+    if ok := token.WaitTimeout(10 * time.Second); !ok {
+        return nil, fmt.Errorf("mqtt connect timeout")
+    }
+    if err := token.Error(); err != nil {
+        return nil, fmt.Errorf("mqtt connect error: %w", err)
+    }
+
+    return c, nil
+}
+
+// Wrap usa um cliente paho já conectado (ex.: um fake em testes) com o QoS e a
+// sessão de cfg. Os handlers de conexão do cfg não são instalados: quem criou
+// o cliente repassa os eventos com NotifyConnect/NotifyConnectionLost.
+func Wrap(client mqtt.Client, cfg Config) *Client {
+	return &Client{
+		client:     client,
+		qos:        cfg.QoS,
+		subs:       make(map[string]subscription),
+		persistent: cfg.PersistentSession,
+	}
+}
+
+// OnConnect registra fn para cada reconexão ao broker (a conexão inicial, feita
+// no NewClient, não dispara). Roda fora da goroutine do paho.
+func (c *Client) OnConnect(fn func()) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.onConnect = append(c.onConnect, fn)
+}
+
+// OnConnectionLost registra fn para cada queda da conexão com o broker.
+func (c *Client) OnConnectionLost(fn func(error)) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.onLost = append(c.onLost, fn)
+}
+
+// IsConnected diz se a conexão com o broker está ativa agora.
+func (c *Client) IsConnected() bool {
+    return c.client != nil && c.client.IsConnectionOpen()
+}
+
+// NotifyConnect repassa uma conexão do cliente passado ao Wrap, como o
+// OnConnectHandler do paho faz no NewClient.
+func (c *Client) NotifyConnect() {
+    c.handleConnect()
+}
+
+// NotifyConnectionLost repassa uma queda do cliente passado ao Wrap.
+func (c *Client) NotifyConnectionLost(err error) {
+    c.handleConnectionLost(err)
+}
+
+func (c *Client) handleConnect() {
+    c.mu.Lock()
+    reconnect := c.connected
+    c.connected = true
+    handlers := append([]func(){}, c.onConnect...)
+    var subs map[string]subscription
+    if reconnect && !c.persistent {
+        subs = make(map[string]subscription, len(c.subs))
+        for topic, sub := range c.subs {
+            subs[topic] = sub
+        }
+    }
+    c.mu.Unlock()
+    if !reconnect {
+        return
+    }
+    log.Printf("[mqtt] reconectado ao broker")
+
+    // o paho chama o handler na goroutine de conexão; tokens não podem ser
+    // esperados aqui
+    go func() {
+        // com clean session o broker esqueceu as assinaturas
+        for topic, sub := range subs {
+            if err := c.subscribe(topic, sub); err != nil {
+                log.Printf("[mqtt] erro ao reassinar %s: %v", topic, err)
+            }
+        }
+        for _, fn := range handlers {
+            fn()
+        }
+    }()
+}
+
+func (c *Client) handleConnectionLost(err error) {
+    log.Printf("[mqtt] conexão com o broker perdida: %v", err)
+    c.mu.Lock()
+    handlers := append([]func(error){}, c.onLost...)
+    c.mu.Unlock()
+    for _, fn := range handlers {
+        fn(err)
+    }
+}
+
+func newClientOptions(cfg Config) (*mqtt.ClientOptions, error) {
+    scheme, port := "tcp", cfg.Port
+    if cfg.UseTLS {
+        scheme = "ssl"
+        if port <= 0 {
+            port = defaultTLSPort
+        }
+    } else if port <= 0 {
+        port = defaultPort
+    }
+    broker := fmt.Sprintf("%s://%s:%d", scheme, cfg.Host, port)
 
     opts := mqtt.NewClientOptions()
     opts.AddBroker(broker)
+    if cfg.UseTLS {
+        tlsCfg, err := buildTLSConfig(cfg)
+        if err != nil {
+            return nil, err
+        }
+        opts.SetTLSConfig(tlsCfg)
+    }
     opts.SetClientID(cfg.ClientID)
-    opts.SetCleanSession(true)
+    opts.SetCleanSession(!cfg.PersistentSession)
+    // sessão persistente: reenvia o que ficou pendente antes da queda
+    opts.SetResumeSubs(cfg.PersistentSession)
     opts.SetAutoReconnect(true)
     opts.SetConnectTimeout(5 * time.Second)
     opts.SetKeepAlive(30 * time.Second)
@@ -53,28 +255,39 @@ func NewClient(cfg Config) (*Client, error) {
         opts.SetUsername(cfg.Username)
         opts.SetPassword(cfg.Password)
     }
-
-    cli := mqtt.NewClient(opts)
-    token := cli.Connect()
-    if ok := token.WaitTimeout(10 * time.Second); !ok {
-        return nil, fmt.Errorf("mqtt connect timeout")
-    }
-    if err := token.Error(); err != nil {
-        return nil, fmt.Errorf("mqtt connect error: %w", err)
+    if cfg.WillTopic != "" {
+        opts.SetBinaryWill(cfg.WillTopic, cfg.WillPayload, cfg.WillQoS, cfg.WillRetained)
     }
 
-    return &Client{client: cli}, nil
+    return opts, nil
 }
 
 func (c *Client) Publish(topic string, qos byte, retained bool, payload []byte) error {
+    if qos == DefaultQoS {
+        qos = c.qos
+    }
     token := c.client.Publish(topic, qos, retained, payload)
     token.Wait()
     return token.Error()
 }
 
 func (c *Client) Subscribe(topic string, qos byte, handler func(topic string, payload []byte)) error {
-    token := c.client.Subscribe(topic, qos, func(_ mqtt.Client, msg mqtt.Message) {
-        handler(msg.Topic(), msg.Payload())
+    if qos == DefaultQoS {
+        qos = c.qos
+    }
+    sub := subscription{qos: qos, handler: handler}
+    if err := c.subscribe(topic, sub); err != nil {
+        return err
+    }
+    c.mu.Lock()
+    c.subs[topic] = sub
+    c.mu.Unlock()
+    return nil
+}
+
+func (c *Client) subscribe(topic string, sub subscription) error {
+    token := c.client.Subscribe(topic, sub.qos, func(_ mqtt.Client, msg mqtt.Message) {
+        sub.handler(msg.Topic(), msg.Payload())
     })
     token.Wait()
     return token.Error()
@@ -93,13 +306,47 @@ func getenv(key, def string) string {
     return def
 }
 
-func getenvInt(key string, def int) int {
-    if v := os.Getenv(key); v != "" {
-        var x int
-        fmt.Sscanf(v, "%d", &x)
-        if x > 0 {
-            return x
+// buildTLSConfig monta o *tls.Config do broker a partir de CACert,
+// ClientCert/ClientKey e TLSInsecure.
+func buildTLSConfig(cfg Config) (*tls.Config, error) {
+    tlsCfg := &tls.Config{
+        MinVersion:         tls.VersionTLS12,
+        ServerName:         cfg.Host,
+        InsecureSkipVerify: cfg.TLSInsecure,
+    }
+    if cfg.CACert != "" {
+        pem, err := os.ReadFile(cfg.CACert)
+        if err != nil {
+            return nil, fmt.Errorf("mqtt: erro lendo MQTT_CA_CERT: %w", err)
+        }
+        pool := x509.NewCertPool()
+        if !pool.AppendCertsFromPEM(pem) {
+            return nil, fmt.Errorf("mqtt: nenhum certificado PEM válido em %s", cfg.CACert)
         }
+        tlsCfg.RootCAs = pool
+    }
+    if (cfg.ClientCert == "") != (cfg.ClientKey == "") {
+        return nil, fmt.Errorf("mqtt: MQTT_CLIENT_CERT e MQTT_CLIENT_KEY precisam vir juntos")
+    }
+    if cfg.ClientCert != "" {
+        cert, err := tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
+        if err != nil {
+            return nil, fmt.Errorf("mqtt: erro carregando certificado de cliente: %w", err)
+        }
+        tlsCfg.Certificates = []tls.Certificate{cert}
+    }
+    return tlsCfg, nil
+}
+
+// getenvQoS lê um QoS MQTT (0, 1 ou 2); inválido = def.
+func getenvQoS(key string, def byte) byte {
+    v := strings.TrimSpace(os.Getenv(key))
+    switch v {
+    case "":
+        return def
+    case "0", "1", "2":
+        return v[0] - '0'
     }
+    log.Printf("[mqtt] %s inválido (%q), usando %d", key, v, def)
     return def
 }
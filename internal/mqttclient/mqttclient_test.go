@@ -0,0 +1,294 @@
+package mqttclient
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+func TestClientOptionsWill(t *testing.T) {
+	opts, err := newClientOptions(Config{
+		Host:         "broker",
+		ClientID:     "cam-bus",
+		WillTopic:    "cams/_collector/availability",
+		WillPayload:  []byte("offline"),
+		WillQoS:      1,
+		WillRetained: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.WillEnabled || opts.WillTopic != "cams/_collector/availability" {
+		t.Fatalf("will = %t %q", opts.WillEnabled, opts.WillTopic)
+	}
+	if !bytes.Equal(opts.WillPayload, []byte("offline")) || opts.WillQos != 1 || !opts.WillRetained {
+		t.Fatalf("will payload=%q qos=%d retained=%t", opts.WillPayload, opts.WillQos, opts.WillRetained)
+	}
+}
+
+func TestClientOptionsFromEnvQoSAndSession(t *testing.T) {
+	cases := []struct {
+		qos, clean string
+		wantQoS    byte
+		wantClean  bool
+	}{
+		{qos: "", clean: "", wantQoS: 1, wantClean: true},
+		{qos: "2", clean: "false", wantQoS: 2, wantClean: false},
+		{qos: "0", clean: "true", wantQoS: 0, wantClean: true},
+		{qos: "5", clean: "talvez", wantQoS: 1, wantClean: true},
+	}
+	for _, tc := range cases {
+		t.Setenv("MQTT_CLIENT_ID", "cam-bus-1")
+		t.Setenv("MQTT_QOS", tc.qos)
+		t.Setenv("MQTT_CLEAN_SESSION", tc.clean)
+		cfg := ConfigFromEnv("cam-bus")
+		opts, err := newClientOptions(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.QoS != tc.wantQoS || Wrap(&subscribeRecorder{}, cfg).qos != tc.wantQoS {
+			t.Errorf("MQTT_QOS=%q: qos %d, esperava %d", tc.qos, cfg.QoS, tc.wantQoS)
+		}
+		if opts.CleanSession != tc.wantClean || opts.ResumeSubs == tc.wantClean || cfg.PersistentSession == tc.wantClean {
+			t.Errorf("MQTT_CLEAN_SESSION=%q: clean=%t resume=%t, esperava clean=%t", tc.clean, opts.CleanSession, opts.ResumeSubs, tc.wantClean)
+		}
+		if opts.ClientID != "cam-bus-1" {
+			t.Errorf("client id = %q", opts.ClientID)
+		}
+	}
+}
+
+func TestClientOptionsWithoutWill(t *testing.T) {
+	opts, err := newClientOptions(Config{Host: "broker", ClientID: "cam-bus"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.WillEnabled {
+		t.Fatalf("sem WillTopic não deveria haver will, veio %q", opts.WillTopic)
+	}
+	if len(opts.Servers) != 1 || opts.Servers[0].String() != "tcp://broker:1883" {
+		t.Fatalf("broker = %v", opts.Servers)
+	}
+}
+
+// writeTestCert gera um certificado autoassinado e grava cert e chave (PEM)
+// em arquivos temporários.
+func writeTestCert(t *testing.T) (certPath, keyPath string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "cam-bus-test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certPath = filepath.Join(dir, "cert.pem")
+	keyPath = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	certPath, keyPath := writeTestCert(t)
+
+	tlsCfg, err := buildTLSConfig(Config{Host: "broker", CACert: certPath, ClientCert: certPath, ClientKey: keyPath, TLSInsecure: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsCfg.RootCAs == nil || tlsCfg.ServerName != "broker" || !tlsCfg.InsecureSkipVerify || len(tlsCfg.Certificates) != 1 {
+		t.Fatalf("tls = %+v", tlsCfg)
+	}
+
+	// sem CA: CAs do sistema
+	tlsCfg, err = buildTLSConfig(Config{Host: "broker"})
+	if err != nil || tlsCfg.RootCAs != nil || tlsCfg.InsecureSkipVerify {
+		t.Fatalf("tls sem CA = %+v, err=%v", tlsCfg, err)
+	}
+}
+
+func TestBuildTLSConfigErrors(t *testing.T) {
+	certPath, keyPath := writeTestCert(t)
+	notPEM := filepath.Join(t.TempDir(), "ca.txt")
+	if err := os.WriteFile(notPEM, []byte("não é PEM"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"CA inexistente", Config{CACert: filepath.Join(t.TempDir(), "nada.pem")}, "MQTT_CA_CERT"},
+		{"CA sem PEM", Config{CACert: notPEM}, "nenhum certificado"},
+		{"cert sem chave", Config{ClientCert: certPath}, "juntos"},
+		{"chave sem cert", Config{ClientKey: keyPath}, "juntos"},
+		{"par inválido", Config{ClientCert: certPath, ClientKey: notPEM}, "certificado de cliente"},
+	}
+	for _, tc := range cases {
+		if _, err := buildTLSConfig(tc.cfg); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v, esperava %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestClientOptionsTLSBroker(t *testing.T) {
+	opts, err := newClientOptions(Config{Host: "broker", ClientID: "cam-bus", UseTLS: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts.Servers) != 1 || opts.Servers[0].String() != "ssl://broker:8883" {
+		t.Fatalf("broker = %v, esperava ssl:// na porta 8883", opts.Servers)
+	}
+	if opts.TLSConfig == nil || opts.TLSConfig.ServerName != "broker" {
+		t.Fatalf("TLS config = %+v", opts.TLSConfig)
+	}
+
+	opts, err = newClientOptions(Config{Host: "broker", ClientID: "cam-bus", UseTLS: true, Port: 9883})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Servers[0].String() != "ssl://broker:9883" {
+		t.Fatalf("porta explícita ignorada: %v", opts.Servers)
+	}
+
+	if _, err := newClientOptions(Config{Host: "broker", UseTLS: true, ClientCert: "cert.pem"}); err == nil {
+		t.Fatal("erro do TLS deveria voltar no newClientOptions")
+	}
+}
+
+type doneToken struct{}
+
+func (doneToken) Wait() bool                     { return true }
+func (doneToken) WaitTimeout(time.Duration) bool { return true }
+func (doneToken) Done() <-chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
+func (doneToken) Error() error { return nil }
+
+// subscribeRecorder é um cliente paho que só registra os Subscribe; os demais
+// métodos não são usados pelos testes de conexão.
+type subscribeRecorder struct {
+	mqtt.Client
+	mu     sync.Mutex
+	topics []string
+}
+
+func (r *subscribeRecorder) Subscribe(topic string, qos byte, callback mqtt.MessageHandler) mqtt.Token {
+	r.mu.Lock()
+	r.topics = append(r.topics, topic)
+	r.mu.Unlock()
+	return doneToken{}
+}
+
+func (r *subscribeRecorder) subscribed() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.topics...)
+}
+
+// connectHooks liga um OnConnect que avisa no canal devolvido.
+func connectHooks(c *Client) <-chan struct{} {
+	fired := make(chan struct{}, 4)
+	c.OnConnect(func() { fired <- struct{}{} })
+	return fired
+}
+
+func waitHook(t *testing.T, fired <-chan struct{}, want bool) {
+	t.Helper()
+	select {
+	case <-fired:
+		if !want {
+			t.Fatal("OnConnect disparou na conexão inicial")
+		}
+	case <-time.After(100 * time.Millisecond):
+		if want {
+			t.Fatal("OnConnect não disparou na reconexão")
+		}
+	}
+}
+
+func TestHandleConnectResubscribesWithCleanSession(t *testing.T) {
+	rec := &subscribeRecorder{}
+	c := Wrap(rec, Config{QoS: 1})
+	fired := connectHooks(c)
+
+	c.NotifyConnect()
+	waitHook(t, fired, false)
+	if err := c.Subscribe("cams/+/info", DefaultQoS, func(string, []byte) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	c.NotifyConnect()
+	waitHook(t, fired, true)
+	// o hook roda depois das reassinaturas
+	if got := rec.subscribed(); len(got) != 2 || got[1] != "cams/+/info" {
+		t.Fatalf("assinaturas = %v, esperava a reassinatura após reconectar", got)
+	}
+}
+
+func TestHandleConnectKeepsPersistentSession(t *testing.T) {
+	rec := &subscribeRecorder{}
+	c := Wrap(rec, Config{QoS: 1, PersistentSession: true})
+	fired := connectHooks(c)
+
+	c.NotifyConnect()
+	waitHook(t, fired, false)
+	if err := c.Subscribe("cams/+/info", DefaultQoS, func(string, []byte) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	c.NotifyConnect()
+	waitHook(t, fired, true)
+	if got := rec.subscribed(); len(got) != 1 {
+		t.Fatalf("assinaturas = %v: com sessão persistente o broker guarda as assinaturas", got)
+	}
+}
+
+func TestHandleConnectionLostRunsHooks(t *testing.T) {
+	c := Wrap(&subscribeRecorder{}, Config{})
+	var got []error
+	c.OnConnectionLost(func(err error) { got = append(got, err) })
+	c.OnConnectionLost(func(err error) { got = append(got, err) })
+
+	lost := errors.New("EOF")
+	c.NotifyConnectionLost(lost)
+	if len(got) != 2 || got[0] != lost || got[1] != lost {
+		t.Fatalf("hooks de queda = %v", got)
+	}
+}
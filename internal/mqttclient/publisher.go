@@ -0,0 +1,176 @@
+// internal/mqttclient/publisher.go
+package mqttclient
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/envconf"
+	"github.com/sua-org/cam-bus/internal/logthrottle"
+)
+
+// ErrPublishQueueFull é devolvido pelo Publisher com MQTT_PUBLISH_ON_FULL=drop
+// quando a fila está cheia (a mensagem é descartada e conta em Dropped).
+var ErrPublishQueueFull = errors.New("fila de publicação MQTT cheia")
+
+// ErrPublisherClosed é devolvido depois do Close.
+var ErrPublisherClosed = errors.New("publisher MQTT encerrado")
+
+// PublishFunc é a assinatura de Client.Publish.
+type PublishFunc func(topic string, qos byte, retained bool, payload []byte) error
+
+const publisherDrainTimeout = 5 * time.Second
+
+type outboundMessage struct {
+	topic    string
+	qos      byte
+	retained bool
+	payload  []byte
+}
+
+// Publisher desacopla quem publica da espera pelo broker: Publish só enfileira
+// e uma goroutine drena a fila chamando publish, na ordem de chegada. Com a
+// fila cheia, descarta (drop) ou espera vaga (block).
+type Publisher struct {
+	publish PublishFunc
+	queue   chan outboundMessage
+	block   bool
+
+	// closing é fechado pelo Close; quem espera vaga (block) desiste por ele,
+	// então Close nunca fica preso atrás de um Publish com o broker parado.
+	closing      chan struct{}
+	closeOnce    sync.Once
+	done         chan struct{}
+	drainTimeout time.Duration
+
+	dropped atomic.Int64
+	failed  atomic.Int64
+}
+
+// NewPublisher cria o publisher com fila de size mensagens e já inicia a
+// drenagem.
+func NewPublisher(publish PublishFunc, size int, block bool) *Publisher {
+	if size <= 0 {
+		size = 1
+	}
+	p := &Publisher{
+		publish:      publish,
+		queue:        make(chan outboundMessage, size),
+		block:        block,
+		closing:      make(chan struct{}),
+		done:         make(chan struct{}),
+		drainTimeout: publisherDrainTimeout,
+	}
+	go p.run()
+	return p
+}
+
+// NewPublisherFromEnv devolve nil (publicação síncrona, como antes) sem
+// MQTT_PUBLISH_QUEUE_SIZE; MQTT_PUBLISH_ON_FULL escolhe drop (default) ou block.
+func NewPublisherFromEnv(c *Client) *Publisher {
+	size := envconf.PositiveInt("MQTT_PUBLISH_QUEUE_SIZE", 0)
+	if size == 0 {
+		return nil
+	}
+	block := false
+	switch mode := strings.ToLower(strings.TrimSpace(os.Getenv("MQTT_PUBLISH_ON_FULL"))); mode {
+	case "", "drop":
+	case "block":
+		block = true
+	default:
+		log.Printf("[mqtt] MQTT_PUBLISH_ON_FULL inválido (%q), usando drop", mode)
+	}
+	policy := "drop"
+	if block {
+		policy = "block"
+	}
+	log.Printf("[mqtt] fila de publicação: %d mensagens, cheia = %s", size, policy)
+	return NewPublisher(c.Publish, size, block)
+}
+
+// Publish enfileira a mensagem. O erro é só do enfileiramento; falhas do
+// broker são logadas pela drenagem e contadas em Failed.
+func (p *Publisher) Publish(topic string, qos byte, retained bool, payload []byte) error {
+	select {
+	case <-p.closing:
+		return ErrPublisherClosed
+	default:
+	}
+	msg := outboundMessage{topic: topic, qos: qos, retained: retained, payload: payload}
+	if p.block {
+		select {
+		case p.queue <- msg:
+			return nil
+		case <-p.closing:
+			return ErrPublisherClosed
+		}
+	}
+	select {
+	case p.queue <- msg:
+		return nil
+	default:
+		p.dropped.Add(1)
+		return ErrPublishQueueFull
+	}
+}
+
+// run drena a fila até o Close; depois publica o que sobrou e encerra.
+func (p *Publisher) run() {
+	defer close(p.done)
+	for {
+		select {
+		case msg := <-p.queue:
+			p.send(msg)
+		case <-p.closing:
+			for {
+				select {
+				case msg := <-p.queue:
+					p.send(msg)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (p *Publisher) send(msg outboundMessage) {
+	if err := p.publish(msg.topic, msg.qos, msg.retained, msg.payload); err != nil {
+		p.failed.Add(1)
+		logthrottle.Printf("mqtt:publisher", "[mqtt] erro ao publicar em %s: %v", msg.topic, err)
+	}
+}
+
+// Dropped é o total de mensagens descartadas com a fila cheia.
+func (p *Publisher) Dropped() int64 {
+	return p.dropped.Load()
+}
+
+// Failed é o total de mensagens que a drenagem não conseguiu publicar.
+func (p *Publisher) Failed() int64 {
+	return p.failed.Load()
+}
+
+// Len é a quantidade de mensagens esperando na fila.
+func (p *Publisher) Len() int {
+	return len(p.queue)
+}
+
+// Close para de aceitar mensagens e espera a fila esvaziar (até 5s).
+func (p *Publisher) Close() {
+	if p == nil {
+		return
+	}
+	p.closeOnce.Do(func() { close(p.closing) })
+
+	select {
+	case <-p.done:
+	case <-time.After(p.drainTimeout):
+		log.Printf("[mqtt] %d mensagens ainda na fila ao encerrar", len(p.queue))
+	}
+}
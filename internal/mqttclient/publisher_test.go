@@ -0,0 +1,170 @@
+package mqttclient
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stalledBroker segura cada publish até release ser fechado.
+type stalledBroker struct {
+	mu      sync.Mutex
+	topics  []string
+	started chan struct{}
+	release chan struct{}
+	err     error
+}
+
+func newStalledBroker() *stalledBroker {
+	return &stalledBroker{started: make(chan struct{}, 16), release: make(chan struct{})}
+}
+
+func (b *stalledBroker) publish(topic string, qos byte, retained bool, payload []byte) error {
+	b.started <- struct{}{}
+	<-b.release
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.topics = append(b.topics, topic)
+	return b.err
+}
+
+func (b *stalledBroker) published() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.topics...)
+}
+
+func TestPublisherDropModeDiscardsWhenFull(t *testing.T) {
+	b := newStalledBroker()
+	p := NewPublisher(b.publish, 2, false)
+
+	if err := p.Publish("t/1", 0, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	<-b.started // t/1 está preso no broker; a fila fica livre
+	for _, topic := range []string{"t/2", "t/3"} {
+		if err := p.Publish(topic, 0, false, nil); err != nil {
+			t.Fatalf("Publish(%s) = %v", topic, err)
+		}
+	}
+	if err := p.Publish("t/4", 0, false, nil); !errors.Is(err, ErrPublishQueueFull) {
+		t.Fatalf("com a fila cheia esperava ErrPublishQueueFull, veio %v", err)
+	}
+	if p.Dropped() != 1 || p.Len() != 2 {
+		t.Fatalf("dropped=%d len=%d", p.Dropped(), p.Len())
+	}
+
+	close(b.release)
+	p.Close()
+	got := b.published()
+	if len(got) != 3 || got[0] != "t/1" || got[1] != "t/2" || got[2] != "t/3" {
+		t.Fatalf("publicados = %v, esperava t/1..t/3 em ordem", got)
+	}
+	if err := p.Publish("t/5", 0, false, nil); !errors.Is(err, ErrPublisherClosed) {
+		t.Fatalf("depois do Close esperava ErrPublisherClosed, veio %v", err)
+	}
+}
+
+func TestPublisherBlockModeWaitsForRoom(t *testing.T) {
+	b := newStalledBroker()
+	p := NewPublisher(b.publish, 1, true)
+
+	if err := p.Publish("t/1", 0, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	<-b.started
+	if err := p.Publish("t/2", 0, false, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	res := make(chan error, 1)
+	go func() { res <- p.Publish("t/3", 0, false, nil) }()
+	select {
+	case err := <-res:
+		t.Fatalf("Publish deveria esperar vaga, voltou %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(b.release)
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Publish não voltou depois de liberar a fila")
+	}
+	p.Close()
+	if got := b.published(); len(got) != 3 {
+		t.Fatalf("publicados = %v", got)
+	}
+	if p.Dropped() != 0 {
+		t.Fatalf("block não deveria descartar, dropped=%d", p.Dropped())
+	}
+}
+
+func TestPublisherCloseDoesNotHangOnStalledBroker(t *testing.T) {
+	b := newStalledBroker()
+	defer close(b.release)
+	p := NewPublisher(b.publish, 1, true)
+	p.drainTimeout = 50 * time.Millisecond
+
+	_ = p.Publish("t/1", 0, false, nil)
+	<-b.started
+	_ = p.Publish("t/2", 0, false, nil)
+	res := make(chan error, 1)
+	go func() { res <- p.Publish("t/3", 0, false, nil) }()
+	time.Sleep(20 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		p.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close travou com o broker parado e um Publish esperando vaga")
+	}
+	select {
+	case err := <-res:
+		if !errors.Is(err, ErrPublisherClosed) {
+			t.Fatalf("Publish bloqueado deveria desistir com ErrPublisherClosed, veio %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Publish bloqueado não voltou depois do Close")
+	}
+}
+
+func TestPublisherCountsBrokerFailures(t *testing.T) {
+	b := newStalledBroker()
+	b.err = errors.New("broker fora")
+	close(b.release)
+	p := NewPublisher(b.publish, 4, false)
+	for i := 0; i < 3; i++ {
+		if err := p.Publish("t", 0, false, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.Close()
+	if p.Failed() != 3 {
+		t.Fatalf("failed = %d, esperava 3", p.Failed())
+	}
+}
+
+func TestNewPublisherFromEnv(t *testing.T) {
+	for _, raw := range []string{"", "0", "abc", "-5"} {
+		t.Setenv("MQTT_PUBLISH_QUEUE_SIZE", raw)
+		if p := NewPublisherFromEnv(&Client{}); p != nil {
+			t.Fatalf("MQTT_PUBLISH_QUEUE_SIZE=%q deveria manter a publicação síncrona", raw)
+		}
+	}
+	t.Setenv("MQTT_PUBLISH_QUEUE_SIZE", "8")
+	t.Setenv("MQTT_PUBLISH_ON_FULL", "block")
+	p := NewPublisherFromEnv(&Client{})
+	if p == nil || !p.block || cap(p.queue) != 8 {
+		t.Fatalf("publisher = %+v", p)
+	}
+	p.Close()
+}
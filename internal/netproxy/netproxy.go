@@ -0,0 +1,100 @@
+// internal/netproxy/netproxy.go
+package netproxy
+
+import (
+	"crypto/tls"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Proxy HTTP de saída para todas as chamadas externas (FindFace, MinIO,
+// MediaMTX API, câmeras, OTLP). Segue HTTP_PROXY/HTTPS_PROXY/NO_PROXY; com
+// CAMBUS_HTTP_PROXY definido, ele vale para http e https (NO_PROXY continua
+// valendo).
+
+var (
+	once      sync.Once
+	proxyFunc func(*url.URL) (*url.URL, error)
+)
+
+func load() {
+	once.Do(func() {
+		proxyFunc = newProxyFunc(os.Getenv)
+	})
+}
+
+// newProxyFunc monta a resolução de proxy a partir de getenv (os.Getenv fora
+// dos testes), com as mesmas variáveis do httpproxy.FromEnvironment mais o
+// CAMBUS_HTTP_PROXY.
+func newProxyFunc(getenv func(string) string) func(*url.URL) (*url.URL, error) {
+	cfg := &httpproxy.Config{
+		HTTPProxy:  getenvAny(getenv, "HTTP_PROXY", "http_proxy"),
+		HTTPSProxy: getenvAny(getenv, "HTTPS_PROXY", "https_proxy"),
+		NoProxy:    getenvAny(getenv, "NO_PROXY", "no_proxy"),
+		CGI:        getenv("REQUEST_METHOD") != "",
+	}
+	if override := strings.TrimSpace(getenv("CAMBUS_HTTP_PROXY")); override != "" {
+		if _, err := url.Parse(override); err != nil {
+			log.Printf("[netproxy] CAMBUS_HTTP_PROXY inválido (%v), usando HTTP_PROXY/HTTPS_PROXY", err)
+		} else {
+			cfg.HTTPProxy = override
+			cfg.HTTPSProxy = override
+		}
+	}
+	if cfg.HTTPProxy != "" || cfg.HTTPSProxy != "" {
+		log.Printf("[netproxy] proxy de saída: http=%s https=%s no_proxy=%q",
+			redact(cfg.HTTPProxy), redact(cfg.HTTPSProxy), cfg.NoProxy)
+	}
+	return cfg.ProxyFunc()
+}
+
+func getenvAny(getenv func(string) string, names ...string) string {
+	for _, name := range names {
+		if v := getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Func resolve o proxy de uma requisição; serve de http.Transport.Proxy.
+// O ambiente é lido na primeira chamada (depois do .env carregado).
+func Func(req *http.Request) (*url.URL, error) {
+	load()
+	return proxyFunc(req.URL)
+}
+
+// Transport clona o http.DefaultTransport com o proxy configurado e, se
+// tlsCfg != nil, a config TLS dada (câmeras com cert fixado/inseguro).
+func Transport(tlsCfg *tls.Config) *http.Transport {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.Proxy = Func
+	if tlsCfg != nil {
+		tr.TLSClientConfig = tlsCfg
+	}
+	return tr
+}
+
+// Client devolve um http.Client com o Transport acima (timeout 0 = sem limite).
+func Client(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: Transport(nil)}
+}
+
+func redact(raw string) string {
+	if raw == "" {
+		return "-"
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	u.User = url.User(u.User.Username())
+	return u.String()
+}
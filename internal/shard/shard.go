@@ -0,0 +1,150 @@
+// internal/shard/shard.go
+package shard
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultBalance é o fator de folga do consistent hashing com carga limitada:
+// nenhum shard recebe mais que Balance × (sua fatia justa do peso total).
+const DefaultBalance = 1.25
+
+// Node é um shard com capacidade relativa (default 1).
+type Node struct {
+	Name     string
+	Capacity float64
+}
+
+// Item é uma câmera com seu peso de carga (default 1).
+type Item struct {
+	Key    string
+	Weight float64
+}
+
+// ParseNodes lê "shard-1,shard-2:2,shard-3" (capacidade opcional após ':').
+func ParseNodes(raw string) ([]Node, error) {
+	var nodes []Node
+	seen := map[string]struct{}{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, capRaw, hasCap := strings.Cut(part, ":")
+		name = strings.TrimSpace(name)
+		node := Node{Name: name, Capacity: 1}
+		if hasCap {
+			c, err := strconv.ParseFloat(strings.TrimSpace(capRaw), 64)
+			if err != nil || c <= 0 {
+				return nil, fmt.Errorf("capacidade inválida para %q: %q", name, capRaw)
+			}
+			node.Capacity = c
+		}
+		if _, dup := seen[name]; dup {
+			return nil, fmt.Errorf("shard duplicado: %q", name)
+		}
+		seen[name] = struct{}{}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// Assign distribui os itens entre os nodes com rendezvous hashing ponderado
+// e carga limitada: itens mais pesados escolhem primeiro, cada um vai para o
+// node de maior score que ainda tem espaço. O resultado é determinístico para
+// o mesmo conjunto de itens/nodes, então todas as instâncias chegam à mesma
+// divisão sem coordenação. balance <= 1 usa DefaultBalance.
+func Assign(items []Item, nodes []Node, balance float64) map[string]string {
+	out := make(map[string]string, len(items))
+	if len(nodes) == 0 {
+		return out
+	}
+	if balance <= 1 {
+		balance = DefaultBalance
+	}
+
+	sorted := make([]Item, len(items))
+	copy(sorted, items)
+	var total, totalCap float64
+	for i := range sorted {
+		if sorted[i].Weight <= 0 {
+			sorted[i].Weight = 1
+		}
+		total += sorted[i].Weight
+	}
+	for _, n := range nodes {
+		totalCap += nodeCapacity(n)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Weight != sorted[j].Weight {
+			return sorted[i].Weight > sorted[j].Weight
+		}
+		return sorted[i].Key < sorted[j].Key
+	})
+
+	load := make(map[string]float64, len(nodes))
+	for _, it := range sorted {
+		ranked := rank(it.Key, nodes)
+		chosen := ranked[0].Name
+		for _, n := range ranked {
+			limit := balance * total * nodeCapacity(n) / totalCap
+			if load[n.Name]+it.Weight <= limit {
+				chosen = n.Name
+				break
+			}
+		}
+		load[chosen] += it.Weight
+		out[it.Key] = chosen
+	}
+	return out
+}
+
+// Owner devolve o shard dono de key dentro do conjunto items.
+func Owner(key string, items []Item, nodes []Node) string {
+	return Assign(items, nodes, DefaultBalance)[key]
+}
+
+// rank ordena os nodes pelo score rendezvous ponderado de key (maior primeiro).
+func rank(key string, nodes []Node) []Node {
+	type scored struct {
+		node  Node
+		score float64
+	}
+	list := make([]scored, len(nodes))
+	for i, n := range nodes {
+		list[i] = scored{node: n, score: score(key, n)}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].score != list[j].score {
+			return list[i].score > list[j].score
+		}
+		return list[i].node.Name < list[j].node.Name
+	})
+	out := make([]Node, len(list))
+	for i, s := range list {
+		out[i] = s.node
+	}
+	return out
+}
+
+// score = -capacidade / ln(h), h uniforme em (0,1): rendezvous ponderado.
+func score(key string, n Node) float64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(n.Name))
+	u := (float64(h.Sum64()>>11) + 0.5) / float64(uint64(1)<<53)
+	return -nodeCapacity(n) / math.Log(u)
+}
+
+func nodeCapacity(n Node) float64 {
+	if n.Capacity <= 0 {
+		return 1
+	}
+	return n.Capacity
+}
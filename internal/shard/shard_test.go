@@ -0,0 +1,104 @@
+package shard
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParseNodes(t *testing.T) {
+	nodes, err := ParseNodes(" shard-1, shard-2:2 ,,shard-3:0.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Node{{"shard-1", 1}, {"shard-2", 2}, {"shard-3", 0.5}}
+	if !reflect.DeepEqual(nodes, want) {
+		t.Fatalf("ParseNodes = %v, esperava %v", nodes, want)
+	}
+	for _, raw := range []string{"a,a", "a:x", "a:-1"} {
+		if _, err := ParseNodes(raw); err == nil {
+			t.Errorf("ParseNodes(%q) deveria falhar", raw)
+		}
+	}
+}
+
+func items(n int) []Item {
+	out := make([]Item, n)
+	for i := range out {
+		out[i] = Item{Key: fmt.Sprintf("cam-%03d", i), Weight: 1}
+	}
+	return out
+}
+
+func loads(assign map[string]string, its []Item) map[string]float64 {
+	out := map[string]float64{}
+	for _, it := range its {
+		w := it.Weight
+		if w <= 0 {
+			w = 1
+		}
+		out[assign[it.Key]] += w
+	}
+	return out
+}
+
+func TestAssignRespectsCapacityAndBalance(t *testing.T) {
+	nodes := []Node{{"a", 1}, {"b", 2}, {"c", 1}}
+	its := items(200)
+	assign := Assign(its, nodes, DefaultBalance)
+	if len(assign) != len(its) {
+		t.Fatalf("%d câmeras atribuídas de %d", len(assign), len(its))
+	}
+
+	load := loads(assign, its)
+	for _, n := range nodes {
+		limit := DefaultBalance * 200 * n.Capacity / 4
+		if load[n.Name] > limit {
+			t.Errorf("shard %s com carga %.0f acima do limite %.1f", n.Name, load[n.Name], limit)
+		}
+	}
+	if load["b"] <= load["a"] || load["b"] <= load["c"] {
+		t.Fatalf("shard com capacidade 2 deveria receber mais: %v", load)
+	}
+}
+
+func TestAssignHonorsWeights(t *testing.T) {
+	nodes := []Node{{"a", 1}, {"b", 1}}
+	its := append(items(10), Item{Key: "nvr-grande", Weight: 10})
+	load := loads(Assign(its, nodes, DefaultBalance), its)
+	if load["a"] > 1.25*20/2 || load["b"] > 1.25*20/2 {
+		t.Fatalf("peso ignorado na divisão: %v", load)
+	}
+}
+
+func TestAssignIsDeterministicAndStable(t *testing.T) {
+	nodes := []Node{{"a", 1}, {"b", 1}, {"c", 1}}
+	its := items(60)
+	first := Assign(its, nodes, 0)
+	shuffled := make([]Item, len(its))
+	for i := range its {
+		shuffled[i] = its[len(its)-1-i]
+	}
+	if !reflect.DeepEqual(first, Assign(shuffled, nodes, 0)) {
+		t.Fatal("a divisão depende da ordem das câmeras")
+	}
+
+	// adicionar uma câmera não deve embaralhar as demais
+	more := Assign(append(its, Item{Key: "cam-nova", Weight: 1}), nodes, 0)
+	moved := 0
+	for k, owner := range first {
+		if more[k] != owner {
+			moved++
+		}
+	}
+	if moved > len(its)/10 {
+		t.Fatalf("%d de %d câmeras mudaram de shard com uma câmera nova", moved, len(its))
+	}
+
+	if got := Owner("cam-001", its, nodes); got != first["cam-001"] {
+		t.Fatalf("Owner = %s, Assign = %s", got, first["cam-001"])
+	}
+	if len(Assign(its, nil, 0)) != 0 {
+		t.Fatal("sem nodes nada é atribuído")
+	}
+}
@@ -0,0 +1,106 @@
+// internal/storage/breaker.go
+package storage
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBreakerFailures = 5
+	defaultBreakerCooldown = 30 * time.Second
+)
+
+// ErrStorageDegraded é devolvido por Save enquanto o circuit breaker do store
+// está aberto: o upload nem é tentado.
+var ErrStorageDegraded = errors.New("storage degradado (circuit breaker aberto)")
+
+// breaker abre após STORAGE_BREAKER_FAILURES falhas seguidas e fica aberto por
+// STORAGE_BREAKER_COOLDOWN; depois libera uma tentativa (half-open) e fecha no
+// primeiro sucesso.
+type breaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+var (
+	breakersMu   sync.Mutex
+	breakers     = map[ImageStore]*breaker{}
+	breakerOnce  sync.Once
+	breakerLimit int
+	breakerCool  time.Duration
+)
+
+func loadBreakerConfig() {
+	breakerOnce.Do(func() {
+		breakerLimit = envPositiveInt("STORAGE_BREAKER_FAILURES", defaultBreakerFailures)
+		breakerCool = time.Duration(envPositiveInt("STORAGE_BREAKER_COOLDOWN_SECONDS", int(defaultBreakerCooldown/time.Second))) * time.Second
+	})
+}
+
+func breakerFor(store ImageStore) *breaker {
+	loadBreakerConfig()
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	b, ok := breakers[store]
+	if !ok {
+		b = &breaker{}
+		breakers[store] = b
+	}
+	return b
+}
+
+// allow diz se um upload pode ser tentado agora.
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openUntil.IsZero() {
+		return true
+	}
+	if now.Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *breaker) record(err error, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	wasOpen := !b.openUntil.IsZero()
+	b.probing = false
+	if err == nil {
+		b.failures = 0
+		b.openUntil = time.Time{}
+		if wasOpen {
+			log.Printf("[storage] storage recuperado, circuit breaker fechado")
+		}
+		return
+	}
+	b.failures++
+	if wasOpen || b.failures >= breakerLimit {
+		b.openUntil = now.Add(breakerCool)
+		if !wasOpen {
+			log.Printf("[storage] %d falhas seguidas, circuit breaker aberto por %s: %v", b.failures, breakerCool, err)
+		}
+	}
+}
+
+func (b *breaker) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.openUntil.IsZero()
+}
+
+// Degraded indica se o circuit breaker do store está aberto (ou em teste após
+// o cooldown). Store nil não conta como degradado.
+func Degraded(store ImageStore) bool {
+	if store == nil {
+		return false
+	}
+	return breakerFor(store).open()
+}
@@ -0,0 +1,67 @@
+// internal/storage/downscale.go
+package storage
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"log"
+	"sync"
+
+	"github.com/sua-org/cam-bus/internal/envconf"
+)
+
+var (
+	storageMaxWidthOnce sync.Once
+	storageMaxWidth     int
+)
+
+// StorageMaxWidth lê SNAPSHOT_MAX_WIDTH (default: 0 = desligado).
+func StorageMaxWidth() int {
+	storageMaxWidthOnce.Do(func() {
+		storageMaxWidth = envconf.PositiveInt("SNAPSHOT_MAX_WIDTH", 0)
+	})
+	return storageMaxWidth
+}
+
+// DownscaleForStorage reduz o JPEG para no máximo SNAPSHOT_MAX_WIDTH de
+// largura (mantendo a proporção) e recodifica com SNAPSHOT_JPEG_QUALITY. Só
+// vale para a cópia gravada no store: o evento continua com os bytes
+// originais em RawSnapshot/SnapshotB64 para o face engine. Sem a env, com
+// imagem não-JPEG, já dentro do limite ou que não decodifica, devolve os
+// bytes como vieram.
+func DownscaleForStorage(data []byte, contentType string) ([]byte, string) {
+	maxWidth := StorageMaxWidth()
+	if maxWidth <= 0 || !isJPEG(data) {
+		return data, contentType
+	}
+	src, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		log.Printf("[storage] falha ao decodificar JPEG para redução, gravando original: %v", err)
+		return data, contentType
+	}
+	out, err := downscaleToWidth(src, maxWidth)
+	if err != nil {
+		log.Printf("[storage] falha ao recodificar JPEG, gravando original: %v", err)
+		return data, contentType
+	}
+	if out == nil {
+		return data, contentType
+	}
+	return out, "image/jpeg"
+}
+
+// downscaleToWidth devolve nil quando src já cabe em maxWidth.
+func downscaleToWidth(src image.Image, maxWidth int) ([]byte, error) {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxWidth {
+		return nil, nil
+	}
+	h = max(h*maxWidth/w, 1)
+	return encodeJPEG(scaleDown(src, maxWidth, h))
+}
+
+func isJPEG(data []byte) bool {
+	return len(data) >= 3 && data[0] == 0xFF && data[1] == 0xD8 && data[2] == 0xFF
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"sync"
+	"testing"
+)
+
+func useMaxWidth(t *testing.T, raw string) {
+	t.Helper()
+	t.Setenv("SNAPSHOT_MAX_WIDTH", raw)
+	storageMaxWidthOnce = sync.Once{}
+	t.Cleanup(func() {
+		storageMaxWidthOnce = sync.Once{}
+		storageMaxWidth = 0
+	})
+}
+
+func TestDownscaleForStorage(t *testing.T) {
+	useMaxWidth(t, "64")
+	src := testJPEG(t, 200, 100)
+
+	out, ct := DownscaleForStorage(src, "image/jpeg")
+	if ct != "image/jpeg" {
+		t.Fatalf("content-type = %q", ct)
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("saída não decodifica: %v", err)
+	}
+	if cfg.Width != 64 || cfg.Height != 32 {
+		t.Fatalf("dimensões = %dx%d, esperava 64x32", cfg.Width, cfg.Height)
+	}
+
+	// já dentro do limite: bytes originais
+	small := testJPEG(t, 48, 48)
+	if out, _ := DownscaleForStorage(small, "image/jpeg"); !bytes.Equal(out, small) {
+		t.Fatal("JPEG dentro do limite deveria passar sem recodificar")
+	}
+	// não-JPEG e JPEG corrompido passam como vieram
+	png := []byte("\x89PNG\r\n\x1a\n...")
+	if out, ct := DownscaleForStorage(png, "image/png"); !bytes.Equal(out, png) || ct != "image/png" {
+		t.Fatalf("não-JPEG alterado: %q %q", out, ct)
+	}
+	broken := src[:40]
+	if out, _ := DownscaleForStorage(broken, "image/jpeg"); !bytes.Equal(out, broken) {
+		t.Fatal("JPEG que não decodifica deveria ser gravado como veio")
+	}
+}
+
+func TestDownscaleKeepsMinimumHeight(t *testing.T) {
+	out, err := downscaleToWidth(image.NewRGBA(image.Rect(0, 0, 400, 1)), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(out))
+	if err != nil || cfg.Width != 10 || cfg.Height != 1 {
+		t.Fatalf("dimensões = %+v (%v), esperava 10x1", cfg, err)
+	}
+}
+
+func TestStorageMaxWidthFromEnv(t *testing.T) {
+	for raw, want := range map[string]int{"": 0, "1280": 1280, " 640 ": 640, "0": 0, "-5": 0, "largo": 0} {
+		useMaxWidth(t, raw)
+		if got := StorageMaxWidth(); got != want {
+			t.Errorf("SNAPSHOT_MAX_WIDTH=%q: %d, esperava %d", raw, got, want)
+		}
+	}
+
+	useMaxWidth(t, "")
+	src := testJPEG(t, 200, 100)
+	if out, _ := DownscaleForStorage(src, "image/jpeg"); !bytes.Equal(out, src) {
+		t.Fatal("sem SNAPSHOT_MAX_WIDTH a imagem deveria passar intacta")
+	}
+}
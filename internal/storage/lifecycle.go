@@ -0,0 +1,92 @@
+// internal/storage/lifecycle.go
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+)
+
+// retentionRuleID identifica a regra criada pelo cam-bus; regras com outros IDs
+// (configuradas à mão no bucket) são preservadas.
+const retentionRuleID = "cam-bus-snapshot-retention"
+
+// lifecycleClient é a parte do *minio.Client usada para a retenção.
+type lifecycleClient interface {
+	GetBucketLifecycle(ctx context.Context, bucketName string) (*lifecycle.Configuration, error)
+	SetBucketLifecycle(ctx context.Context, bucketName string, config *lifecycle.Configuration) error
+}
+
+// retentionRule expira, depois de days dias, os objetos sob o prefixo do store
+// (o bucket inteiro quando o prefixo é vazio).
+func retentionRule(prefix string, days int) lifecycle.Rule {
+	rule := lifecycle.Rule{
+		ID:         retentionRuleID,
+		Status:     "Enabled",
+		Expiration: lifecycle.Expiration{Days: lifecycle.ExpirationDays(days)},
+	}
+	if prefix != "" {
+		rule.RuleFilter = lifecycle.Filter{Prefix: prefix + "/"}
+	}
+	return rule
+}
+
+// mergeRetentionRule devolve a configuração com a regra do cam-bus no lugar da
+// anterior; changed=false quando a regra já está igual (nada a gravar).
+func mergeRetentionRule(existing *lifecycle.Configuration, rule lifecycle.Rule) (*lifecycle.Configuration, bool) {
+	cfg := lifecycle.NewConfiguration()
+	found, changed := false, false
+	if existing != nil {
+		for _, r := range existing.Rules {
+			if r.ID != rule.ID {
+				cfg.Rules = append(cfg.Rules, r)
+				continue
+			}
+			if found {
+				changed = true // ID duplicado: fica só uma
+				continue
+			}
+			found = true
+			if r.Status == rule.Status &&
+				r.Expiration.Days == rule.Expiration.Days &&
+				r.Expiration.Date.IsZero() &&
+				r.RuleFilter.Prefix == rule.RuleFilter.Prefix &&
+				r.Prefix == "" {
+				cfg.Rules = append(cfg.Rules, r)
+				continue
+			}
+			cfg.Rules = append(cfg.Rules, rule)
+			changed = true
+		}
+	}
+	if !found {
+		cfg.Rules = append(cfg.Rules, rule)
+		changed = true
+	}
+	return cfg, changed
+}
+
+// applyRetention garante a regra de expiração no bucket, sem regravar a
+// configuração quando ela já está aplicada.
+func applyRetention(ctx context.Context, cli lifecycleClient, bucket, prefix string, days int) error {
+	existing, err := cli.GetBucketLifecycle(ctx, bucket)
+	if err != nil {
+		if minio.ToErrorResponse(err).Code != "NoSuchLifecycleConfiguration" {
+			return fmt.Errorf("erro lendo lifecycle do bucket %s: %w", bucket, err)
+		}
+		existing = nil
+	}
+	cfg, changed := mergeRetentionRule(existing, retentionRule(prefix, days))
+	if !changed {
+		log.Printf("[minio] retenção de %d dias já configurada no bucket %s", days, bucket)
+		return nil
+	}
+	if err := cli.SetBucketLifecycle(ctx, bucket, cfg); err != nil {
+		return fmt.Errorf("erro configurando lifecycle do bucket %s: %w", bucket, err)
+	}
+	log.Printf("[minio] retenção de %d dias configurada no bucket %s (prefixo %q)", days, bucket, prefix)
+	return nil
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+)
+
+// fakeLifecycle guarda a configuração do bucket como o MinIO faria.
+type fakeLifecycle struct {
+	cfg     *lifecycle.Configuration
+	getErr  error
+	setErr  error
+	setCall int
+}
+
+func (f *fakeLifecycle) GetBucketLifecycle(ctx context.Context, bucket string) (*lifecycle.Configuration, error) {
+	if f.getErr != nil {
+		return nil, f.getErr
+	}
+	return f.cfg, nil
+}
+
+func (f *fakeLifecycle) SetBucketLifecycle(ctx context.Context, bucket string, cfg *lifecycle.Configuration) error {
+	f.setCall++
+	if f.setErr != nil {
+		return f.setErr
+	}
+	f.cfg = cfg
+	return nil
+}
+
+func noLifecycle() error {
+	return minio.ErrorResponse{Code: "NoSuchLifecycleConfiguration"}
+}
+
+func TestApplyRetentionSetsExpiration(t *testing.T) {
+	cli := &fakeLifecycle{getErr: noLifecycle()}
+	if err := applyRetention(context.Background(), cli, "snaps", "site1", 30); err != nil {
+		t.Fatal(err)
+	}
+	if cli.setCall != 1 || len(cli.cfg.Rules) != 1 {
+		t.Fatalf("set=%d regras=%+v", cli.setCall, cli.cfg)
+	}
+	rule := cli.cfg.Rules[0]
+	if rule.ID != retentionRuleID || rule.Status != "Enabled" || rule.Expiration.Days != 30 || rule.RuleFilter.Prefix != "site1/" {
+		t.Fatalf("regra = %+v, esperava expirar site1/ em 30 dias", rule)
+	}
+
+	// mesma retenção: não regrava
+	cli.getErr = nil
+	if err := applyRetention(context.Background(), cli, "snaps", "site1", 30); err != nil || cli.setCall != 1 {
+		t.Fatalf("regra igual regravada: set=%d err=%v", cli.setCall, err)
+	}
+
+	// retenção alterada: troca a regra do cam-bus
+	if err := applyRetention(context.Background(), cli, "snaps", "site1", 7); err != nil || cli.setCall != 2 {
+		t.Fatalf("set=%d err=%v", cli.setCall, err)
+	}
+	if len(cli.cfg.Rules) != 1 || cli.cfg.Rules[0].Expiration.Days != 7 {
+		t.Fatalf("regras = %+v, esperava uma regra de 7 dias", cli.cfg.Rules)
+	}
+}
+
+func TestApplyRetentionKeepsForeignRules(t *testing.T) {
+	manual := lifecycle.Rule{ID: "manual", Status: "Enabled", Expiration: lifecycle.Expiration{Days: 365}, RuleFilter: lifecycle.Filter{Prefix: "backup/"}}
+	stale := retentionRule("site1", 30)
+	cfg := lifecycle.NewConfiguration()
+	cfg.Rules = []lifecycle.Rule{manual, stale, stale}
+	cli := &fakeLifecycle{cfg: cfg}
+
+	if err := applyRetention(context.Background(), cli, "snaps", "", 14); err != nil {
+		t.Fatal(err)
+	}
+	if len(cli.cfg.Rules) != 2 || cli.cfg.Rules[0].ID != "manual" || cli.cfg.Rules[0].Expiration.Days != 365 {
+		t.Fatalf("regras = %+v, esperava a manual preservada e uma só do cam-bus", cli.cfg.Rules)
+	}
+	rule := cli.cfg.Rules[1]
+	if rule.ID != retentionRuleID || rule.Expiration.Days != 14 || rule.RuleFilter.Prefix != "" {
+		t.Fatalf("regra = %+v, esperava o bucket inteiro em 14 dias", rule)
+	}
+}
+
+func TestMergeRetentionRuleDedupesIdenticalRule(t *testing.T) {
+	rule := retentionRule("site1", 30)
+	cfg := lifecycle.NewConfiguration()
+	cfg.Rules = []lifecycle.Rule{rule, rule}
+	merged, changed := mergeRetentionRule(cfg, rule)
+	if !changed || len(merged.Rules) != 1 {
+		t.Fatalf("changed=%t regras=%d, esperava o ID duplicado removido", changed, len(merged.Rules))
+	}
+}
+
+func TestApplyRetentionErrors(t *testing.T) {
+	cli := &fakeLifecycle{getErr: errors.New("AccessDenied")}
+	if err := applyRetention(context.Background(), cli, "snaps", "", 30); err == nil || cli.setCall != 0 {
+		t.Fatalf("erro de leitura deveria abortar sem gravar: set=%d err=%v", cli.setCall, err)
+	}
+	cli = &fakeLifecycle{getErr: noLifecycle(), setErr: errors.New("AccessDenied")}
+	if err := applyRetention(context.Background(), cli, "snaps", "", 30); err == nil {
+		t.Fatal("erro de gravação deveria ser devolvido")
+	}
+}
@@ -5,48 +5,112 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/sua-org/cam-bus/internal/netproxy"
+	"github.com/sua-org/cam-bus/internal/tracing"
 )
 
 type ImageStore interface {
 	SaveSnapshot(ctx context.Context, key string, data []byte, contentType string) (string, error)
 }
 
+// Save grava o snapshot no store dentro de um span "storage.upload". Com o
+// circuit breaker do store aberto devolve ErrStorageDegraded sem tentar.
+// Com SNAPSHOT_MAX_WIDTH, grava a versão reduzida (DownscaleForStorage).
+func Save(ctx context.Context, store ImageStore, key string, data []byte, contentType string) (string, error) {
+	ctx, span := tracing.Start(ctx, "storage.upload")
+	defer span.End()
+	data, contentType = DownscaleForStorage(data, contentType)
+	span.SetAttr("storage.key", key)
+	span.SetAttr("storage.bytes", strconv.Itoa(len(data)))
+
+	b := breakerFor(store)
+	if !b.allow(time.Now()) {
+		span.RecordError(ErrStorageDegraded)
+		return "", ErrStorageDegraded
+	}
+	url, err := store.SaveSnapshot(ctx, key, data, contentType)
+	b.record(err, time.Now())
+	span.RecordError(err)
+	return url, err
+}
+
+// ImageLoader é opcional: stores que conseguem ler de volta um snapshot salvo
+// (diagnóstico, ex.: POST /recognize com a chave do MinIO).
+type ImageLoader interface {
+	LoadSnapshot(ctx context.Context, key string) ([]byte, string, error)
+}
+
+// Load lê o snapshot salvo em key, se o store suportar leitura.
+func Load(ctx context.Context, store ImageStore, key string) ([]byte, string, error) {
+	loader, ok := store.(ImageLoader)
+	if !ok {
+		return nil, "", fmt.Errorf("store não suporta leitura de snapshots")
+	}
+	return loader.LoadSnapshot(ctx, key)
+}
+
 type MinioStore struct {
 	client  *minio.Client
 	bucket  string
 	prefix  string
 	baseURL *url.URL
 	useSSL  bool
+
+	// presigner != nil = MINIO_PRESIGN: SaveSnapshot devolve URL assinada
+	// válida por presignTTL no lugar da URL pública.
+	presigner  presigner
+	presignTTL time.Duration
+}
+
+// presigner é a parte do *minio.Client usada para assinar URLs de leitura.
+type presigner interface {
+	PresignedGetObject(ctx context.Context, bucket, object string, expires time.Duration, reqParams url.Values) (*url.URL, error)
 }
 
+const (
+	defaultPresignTTL = 24 * time.Hour
+	// limite do SigV4 para URLs assinadas
+	maxPresignTTL = 7 * 24 * time.Hour
+)
+
 // Global simples pra driver usar sem ter que passar dependência em tudo
 var DefaultStore ImageStore
 
 func NewMinioStoreFromEnv() (*MinioStore, error) {
-	endpoint := getenv("MINIO_ENDPOINT", "localhost:9000")
-	accessKey := os.Getenv("MINIO_ACCESS_KEY")
-	secretKey := os.Getenv("MINIO_SECRET_KEY")
-	bucket := getenv("MINIO_BUCKET", "rtls-snapshots")
-	prefix := getenv("MINIO_PREFIX", "")
-	useSSL := getenv("MINIO_USE_SSL", "false") == "true"
-	base := getenv("MINIO_PUBLIC_BASE_URL", "")
-	publicRead := getenv("MINIO_PUBLIC_READ", "false") == "true"
+	return newMinioStoreFromEnvPrefix("MINIO_")
+}
+
+// newMinioStoreFromEnvPrefix lê <prefix>ENDPOINT, <prefix>ACCESS_KEY, etc.
+func newMinioStoreFromEnvPrefix(envPrefix string) (*MinioStore, error) {
+	endpoint := getenv(envPrefix+"ENDPOINT", "localhost:9000")
+	accessKey := os.Getenv(envPrefix + "ACCESS_KEY")
+	secretKey := os.Getenv(envPrefix + "SECRET_KEY")
+	bucket := getenv(envPrefix+"BUCKET", "rtls-snapshots")
+	prefix := getenv(envPrefix+"PREFIX", "")
+	useSSL := getenv(envPrefix+"USE_SSL", "false") == "true"
+	base := getenv(envPrefix+"PUBLIC_BASE_URL", "")
+	publicRead := getenv(envPrefix+"PUBLIC_READ", "false") == "true"
+	presign := getenv(envPrefix+"PRESIGN", "false") == "true"
+	retentionDays := envPositiveInt(envPrefix+"RETENTION_DAYS", 0)
 
 	if accessKey == "" || secretKey == "" {
-		return nil, fmt.Errorf("MINIO_ACCESS_KEY / MINIO_SECRET_KEY não configurados")
+		return nil, fmt.Errorf("%sACCESS_KEY / %sSECRET_KEY não configurados", envPrefix, envPrefix)
 	}
 
 	cli, err := minio.New(endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
-		Secure: useSSL,
+		Creds:     credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure:    useSSL,
+		Transport: netproxy.Transport(nil),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("erro criando cliente MinIO: %w", err)
@@ -76,23 +140,66 @@ func NewMinioStoreFromEnv() (*MinioStore, error) {
 		}
 	}
 
+	// Retenção opcional: falha só é logada, o store funciona sem ela
+	if retentionDays > 0 {
+		if err := applyRetention(ctx, cli, bucket, strings.Trim(prefix, "/"), retentionDays); err != nil {
+			log.Printf("[minio] %v", err)
+		}
+	}
+
 	var u *url.URL
 	if base != "" {
 		u, err = url.Parse(base)
 		if err != nil {
-			return nil, fmt.Errorf("MINIO_PUBLIC_BASE_URL inválida: %w", err)
+			return nil, fmt.Errorf("%sPUBLIC_BASE_URL inválida: %w", envPrefix, err)
 		}
 	}
 
 	log.Printf("[minio] conectado ao endpoint %s, bucket=%s", endpoint, bucket)
 
-	return &MinioStore{
+	store := &MinioStore{
 		client:  cli,
 		bucket:  bucket,
 		prefix:  strings.Trim(prefix, "/"),
 		baseURL: u,
 		useSSL:  useSSL,
-	}, nil
+	}
+	if presign {
+		store.presigner = cli
+		store.presignTTL = presignTTLFromEnv(envPrefix + "PRESIGN_TTL")
+		if u != nil {
+			log.Printf("[minio] %sPRESIGN ligado: %sPUBLIC_BASE_URL é ignorado (a assinatura cobre o host do endpoint)", envPrefix, envPrefix)
+		}
+		log.Printf("[minio] URLs de snapshot assinadas, válidas por %s", store.presignTTL)
+	}
+	return store, nil
+}
+
+// presignTTLFromEnv lê a validade das URLs assinadas (duração Go ou segundos;
+// default 24h), limitada aos 7 dias aceitos pelo S3.
+func presignTTLFromEnv(key string) time.Duration {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return defaultPresignTTL
+	}
+	ttl, err := time.ParseDuration(raw)
+	if err != nil {
+		sec, errSec := strconv.Atoi(raw)
+		if errSec != nil {
+			ttl = 0
+		} else {
+			ttl = time.Duration(sec) * time.Second
+		}
+	}
+	if ttl < time.Second {
+		log.Printf("[minio] %s inválido (%q), usando %s", key, raw, defaultPresignTTL)
+		return defaultPresignTTL
+	}
+	if ttl > maxPresignTTL {
+		log.Printf("[minio] %s=%s acima do limite do S3, usando %s", key, ttl, maxPresignTTL)
+		return maxPresignTTL
+	}
+	return ttl
 }
 
 func (s *MinioStore) SaveSnapshot(ctx context.Context, key string, data []byte, contentType string) (string, error) {
@@ -118,6 +225,15 @@ func (s *MinioStore) SaveSnapshot(ctx context.Context, key string, data []byte,
 		return "", fmt.Errorf("erro ao enviar objeto pro MinIO: %w", err)
 	}
 
+	// Bucket privado: URL assinada, que o HA e o face engine conseguem baixar
+	if s.presigner != nil {
+		u, err := s.presigner.PresignedGetObject(ctx, s.bucket, objectKey, s.presignTTL, nil)
+		if err != nil {
+			return "", fmt.Errorf("erro ao assinar URL do MinIO: %w", err)
+		}
+		return u.String(), nil
+	}
+
 	// Se for configurado um baseURL público, usamos ele
 	if s.baseURL != nil {
 		u := *s.baseURL
@@ -137,6 +253,25 @@ func (s *MinioStore) SaveSnapshot(ctx context.Context, key string, data []byte,
 	return fmt.Sprintf("%s://%s/%s/%s", scheme, s.client.EndpointURL().Host, s.bucket, objectKey), nil
 }
 
+// LoadSnapshot lê o objeto key (relativo ao prefixo do store).
+func (s *MinioStore) LoadSnapshot(ctx context.Context, key string) ([]byte, string, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, joinObjectKey(s.prefix, key), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, "", fmt.Errorf("erro ao ler objeto do MinIO: %w", err)
+	}
+	defer obj.Close()
+
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		return nil, "", fmt.Errorf("erro ao ler objeto do MinIO: %w", err)
+	}
+	info, err := obj.Stat()
+	if err != nil {
+		return data, "", nil
+	}
+	return data, info.ContentType, nil
+}
+
 func getenv(k, def string) string {
 	v := os.Getenv(k)
 	if v == "" {
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 aceita MakeBucket, GetBucketLocation e PutObject e guarda os objetos.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]string
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Query().Has("location"):
+		io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/"></LocationConstraint>`)
+	case r.Method == http.MethodPut && strings.Count(strings.Trim(r.URL.Path, "/"), "/") > 0:
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.objects[r.URL.Path] = string(body)
+		s.mu.Unlock()
+		w.Header().Set("ETag", `"d41d8cd98f00b204e9800998ecf8427e"`)
+	default: // MakeBucket
+	}
+}
+
+func newFakeS3Store(t *testing.T, env map[string]string) (*MinioStore, *fakeS3, string) {
+	t.Helper()
+	stub := &fakeS3{objects: map[string]string{}}
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	t.Setenv("MINIO_ENDPOINT", host)
+	t.Setenv("MINIO_ACCESS_KEY", "cambus")
+	t.Setenv("MINIO_SECRET_KEY", "cambus-secret")
+	t.Setenv("MINIO_BUCKET", "snaps")
+	t.Setenv("MINIO_PREFIX", "site1")
+	for _, k := range []string{"MINIO_PUBLIC_BASE_URL", "MINIO_PRESIGN", "MINIO_PRESIGN_TTL", "MINIO_PUBLIC_READ", "MINIO_RETENTION_DAYS", "HTTP_PROXY", "http_proxy"} {
+		t.Setenv(k, "")
+	}
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+
+	store, err := NewMinioStoreFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, stub, host
+}
+
+func TestSaveSnapshotPresignedURL(t *testing.T) {
+	store, stub, host := newFakeS3Store(t, map[string]string{
+		"MINIO_PRESIGN":         "true",
+		"MINIO_PRESIGN_TTL":     "2h",
+		"MINIO_PUBLIC_BASE_URL": "https://cdn.example.com/snaps",
+	})
+
+	raw, err := store.SaveSnapshot(context.Background(), "cam1/evt.jpg", []byte("jpeg"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// corpo vem em chunks assinados (aws-chunked) sobre http
+	if !strings.Contains(stub.objects["/snaps/site1/cam1/evt.jpg"], "jpeg") {
+		t.Fatalf("objetos = %v", stub.objects)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// assinada para o host do endpoint; PUBLIC_BASE_URL é ignorado
+	if u.Host != host || u.Path != "/snaps/site1/cam1/evt.jpg" {
+		t.Fatalf("URL assinada = %s", raw)
+	}
+	q := u.Query()
+	if q.Get("X-Amz-Signature") == "" || q.Get("X-Amz-Expires") != "7200" || !strings.HasPrefix(q.Get("X-Amz-Credential"), "cambus/") {
+		t.Fatalf("URL sem assinatura SigV4 válida por 2h: %s", raw)
+	}
+}
+
+func TestSaveSnapshotPublicURL(t *testing.T) {
+	store, _, _ := newFakeS3Store(t, map[string]string{"MINIO_PUBLIC_BASE_URL": "https://cdn.example.com/snaps/"})
+	got, err := store.SaveSnapshot(context.Background(), "cam1/evt.jpg", []byte("jpeg"), "image/jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "https://cdn.example.com/snaps/site1/cam1/evt.jpg" {
+		t.Fatalf("URL pública = %s", got)
+	}
+
+	// sem PUBLIC_BASE_URL nem PRESIGN: URL bruta do endpoint
+	store, _, host := newFakeS3Store(t, map[string]string{"MINIO_PRESIGN": "false"})
+	got, err = store.SaveSnapshot(context.Background(), "cam1/evt.jpg", []byte("jpeg"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "http://"+host+"/snaps/site1/cam1/evt.jpg" {
+		t.Fatalf("URL do endpoint = %s", got)
+	}
+	if strings.Contains(got, "X-Amz-") {
+		t.Fatalf("sem MINIO_PRESIGN a URL não deveria ser assinada: %s", got)
+	}
+}
+
+func TestPresignTTLFromEnv(t *testing.T) {
+	for raw, want := range map[string]time.Duration{
+		"":      defaultPresignTTL,
+		"90m":   90 * time.Minute,
+		"3600":  time.Hour,
+		"abc":   defaultPresignTTL,
+		"0":     defaultPresignTTL,
+		"500ms": defaultPresignTTL,
+		"720h":  maxPresignTTL,
+	} {
+		t.Setenv("MINIO_PRESIGN_TTL", raw)
+		if got := presignTTLFromEnv("MINIO_PRESIGN_TTL"); got != want {
+			t.Errorf("MINIO_PRESIGN_TTL=%q: %s, esperava %s", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// internal/storage/policy.go
+package storage
+
+import (
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// SnapshotPolicy decide quais snapshots vão para o MinIO (SNAPSHOT_STORE_POLICY).
+type SnapshotPolicy string
+
+const (
+	// PolicyAll salva todo snapshot direto no driver (comportamento original).
+	PolicyAll SnapshotPolicy = "all"
+	// PolicyMatched salva só quando as engines reconheceram o rosto (faceRecognized).
+	PolicyMatched SnapshotPolicy = "matched"
+	// PolicyUnmatched salva só quando não houve faceRecognized.
+	PolicyUnmatched SnapshotPolicy = "unmatched"
+	// PolicyNone nunca salva snapshots.
+	PolicyNone SnapshotPolicy = "none"
+)
+
+var (
+	defaultPolicyOnce sync.Once
+	defaultPolicy     SnapshotPolicy
+)
+
+// DefaultPolicy é a política global usada por drivers e supervisor. O
+// ambiente é lido na primeira chamada, depois do .env carregado.
+func DefaultPolicy() SnapshotPolicy {
+	defaultPolicyOnce.Do(func() {
+		defaultPolicy = PolicyFromEnv()
+	})
+	return defaultPolicy
+}
+
+// PolicyFromEnv lê SNAPSHOT_STORE_POLICY (default: all).
+func PolicyFromEnv() SnapshotPolicy {
+	raw := strings.ToLower(strings.TrimSpace(os.Getenv("SNAPSHOT_STORE_POLICY")))
+	switch SnapshotPolicy(raw) {
+	case "":
+		return PolicyAll
+	case PolicyAll, PolicyMatched, PolicyUnmatched, PolicyNone:
+		return SnapshotPolicy(raw)
+	default:
+		log.Printf("[storage] SNAPSHOT_STORE_POLICY=%q inválido, usando %s", raw, PolicyAll)
+		return PolicyAll
+	}
+}
+
+// StoreInDriver indica se o driver deve salvar o snapshot assim que recebe.
+func (p SnapshotPolicy) StoreInDriver() bool {
+	return p == PolicyAll
+}
+
+// Deferred indica que o driver só guarda os bytes e a decisão fica para
+// depois das engines.
+func (p SnapshotPolicy) Deferred() bool {
+	return p == PolicyMatched || p == PolicyUnmatched
+}
+
+// ShouldStore decide, após as engines, se o snapshot deve ser salvo.
+func (p SnapshotPolicy) ShouldStore(matched bool) bool {
+	switch p {
+	case PolicyAll:
+		return true
+	case PolicyMatched:
+		return matched
+	case PolicyUnmatched:
+		return !matched
+	default:
+		return false
+	}
+}
@@ -0,0 +1,48 @@
+package storage
+
+import "testing"
+
+func TestPolicyFromEnv(t *testing.T) {
+	cases := map[string]SnapshotPolicy{
+		"":          PolicyAll,
+		"all":       PolicyAll,
+		" Matched ": PolicyMatched,
+		"UNMATCHED": PolicyUnmatched,
+		"none":      PolicyNone,
+		"sempre":    PolicyAll,
+	}
+	for raw, want := range cases {
+		t.Setenv("SNAPSHOT_STORE_POLICY", raw)
+		if got := PolicyFromEnv(); got != want {
+			t.Errorf("SNAPSHOT_STORE_POLICY=%q: %s, esperava %s", raw, got, want)
+		}
+	}
+}
+
+func TestPolicyDecisions(t *testing.T) {
+	cases := []struct {
+		policy          SnapshotPolicy
+		inDriver        bool
+		deferred        bool
+		matched, missed bool // ShouldStore(true), ShouldStore(false)
+	}{
+		{PolicyAll, true, false, true, true},
+		{PolicyMatched, false, true, true, false},
+		{PolicyUnmatched, false, true, false, true},
+		{PolicyNone, false, false, false, false},
+	}
+	for _, tc := range cases {
+		if got := tc.policy.StoreInDriver(); got != tc.inDriver {
+			t.Errorf("%s.StoreInDriver() = %t", tc.policy, got)
+		}
+		if got := tc.policy.Deferred(); got != tc.deferred {
+			t.Errorf("%s.Deferred() = %t", tc.policy, got)
+		}
+		if got := tc.policy.ShouldStore(true); got != tc.matched {
+			t.Errorf("%s.ShouldStore(matched) = %t", tc.policy, got)
+		}
+		if got := tc.policy.ShouldStore(false); got != tc.missed {
+			t.Errorf("%s.ShouldStore(sem match) = %t", tc.policy, got)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+// internal/storage/processors.go
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SnapshotProcessor é um estágio do pipeline de snapshots (SNAPSHOT_PIPELINE).
+type SnapshotProcessor func([]byte) ([]byte, error)
+
+const (
+	defaultResizeMaxDim = 1280
+	defaultJPEGQuality  = 85
+)
+
+var (
+	processorsMu sync.RWMutex
+	processors   = map[string]SnapshotProcessor{
+		"strip_exif": stripEXIF,
+		"resize":     resizeSnapshot,
+		"watermark":  watermarkSnapshot,
+	}
+
+	pipelineOnce sync.Once
+	pipeline     []namedProcessor
+)
+
+type namedProcessor struct {
+	name string
+	fn   SnapshotProcessor
+}
+
+// RegisterProcessor registra um estágio nomeado para uso em SNAPSHOT_PIPELINE
+// (ex.: "annotate"). Deve ser chamado antes do primeiro ProcessSnapshot.
+func RegisterProcessor(name string, fn SnapshotProcessor) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || fn == nil {
+		return
+	}
+	processorsMu.Lock()
+	defer processorsMu.Unlock()
+	processors[name] = fn
+}
+
+// loadPipeline lê SNAPSHOT_PIPELINE (ex.: "strip_exif,resize,watermark"), na
+// ordem dada. Vazio = nenhum estágio (snapshot segue como veio da câmera).
+func loadPipeline() []namedProcessor {
+	var out []namedProcessor
+	processorsMu.RLock()
+	defer processorsMu.RUnlock()
+	for _, raw := range strings.Split(os.Getenv("SNAPSHOT_PIPELINE"), ",") {
+		name := strings.ToLower(strings.TrimSpace(raw))
+		if name == "" {
+			continue
+		}
+		fn, ok := processors[name]
+		if !ok {
+			log.Printf("[storage] SNAPSHOT_PIPELINE: estágio desconhecido %q, ignorando", name)
+			continue
+		}
+		out = append(out, namedProcessor{name: name, fn: fn})
+	}
+	return out
+}
+
+// ProcessSnapshot roda o pipeline antes do snapshot ser salvo/codificado em
+// base64. Um estágio com erro é pulado (segue com os bytes anteriores). O
+// content-type é recalculado quando algum estágio muda a imagem.
+func ProcessSnapshot(data []byte, contentType string) ([]byte, string) {
+	pipelineOnce.Do(func() { pipeline = loadPipeline() })
+	if len(pipeline) == 0 || len(data) == 0 {
+		return data, contentType
+	}
+	out := data
+	for _, stage := range pipeline {
+		next, err := stage.fn(out)
+		if err != nil {
+			log.Printf("[storage] estágio %s do SNAPSHOT_PIPELINE falhou: %v", stage.name, err)
+			continue
+		}
+		if len(next) > 0 {
+			out = next
+		}
+	}
+	if !bytes.Equal(out, data) {
+		contentType = http.DetectContentType(out)
+	}
+	return out, contentType
+}
+
+// stripEXIF remove os segmentos APP1 (EXIF/XMP) de um JPEG sem recodificar.
+// Outros formatos passam intactos.
+func stripEXIF(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return data, nil
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return nil, fmt.Errorf("jpeg: marcador inválido em %d", i)
+		}
+		marker := data[i+1]
+		if marker == 0xDA { // SOS: o resto é dado de imagem
+			break
+		}
+		size := int(data[i+2])<<8 | int(data[i+3])
+		end := i + 2 + size
+		if size < 2 || end > len(data) {
+			return nil, fmt.Errorf("jpeg: segmento truncado em %d", i)
+		}
+		if marker != 0xE1 {
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	return append(out, data[i:]...), nil
+}
+
+// resizeSnapshot reduz a imagem para que o maior lado caiba em
+// SNAPSHOT_RESIZE_MAX_DIM (default 1280), recodificando em JPEG.
+func resizeSnapshot(data []byte) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	maxDim := envPositiveInt("SNAPSHOT_RESIZE_MAX_DIM", defaultResizeMaxDim)
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxDim && h <= maxDim {
+		return data, nil
+	}
+	if w >= h {
+		w, h = maxDim, h*maxDim/w
+	} else {
+		w, h = w*maxDim/h, maxDim
+	}
+	return encodeJPEG(scaleDown(src, max(w, 1), max(h, 1)))
+}
+
+// scaleDown reduz por média de área (box filter), suficiente para snapshots.
+func scaleDown(src image.Image, w, h int) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0 := b.Min.Y + y*b.Dy()/h
+		y1 := max(b.Min.Y+(y+1)*b.Dy()/h, y0+1)
+		for x := 0; x < w; x++ {
+			x0 := b.Min.X + x*b.Dx()/w
+			x1 := max(b.Min.X+(x+1)*b.Dx()/w, x0+1)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8), G: uint8(g / n >> 8), B: uint8(bl / n >> 8), A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
+
+var (
+	watermarkOnce sync.Once
+	watermarkImg  image.Image
+)
+
+// watermarkSnapshot aplica a imagem de SNAPSHOT_WATERMARK_FILE (PNG com
+// transparência) no canto inferior direito. Sem arquivo, não faz nada.
+func watermarkSnapshot(data []byte) ([]byte, error) {
+	watermarkOnce.Do(func() {
+		path := strings.TrimSpace(os.Getenv("SNAPSHOT_WATERMARK_FILE"))
+		if path == "" {
+			log.Printf("[storage] estágio watermark sem SNAPSHOT_WATERMARK_FILE, ignorando")
+			return
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			log.Printf("[storage] SNAPSHOT_WATERMARK_FILE: %v", err)
+			return
+		}
+		defer f.Close()
+		img, _, err := image.Decode(f)
+		if err != nil {
+			log.Printf("[storage] SNAPSHOT_WATERMARK_FILE: %v", err)
+			return
+		}
+		watermarkImg = img
+	})
+	if watermarkImg == nil {
+		return data, nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	b := src.Bounds()
+	dst := image.NewRGBA(b)
+	draw.Draw(dst, b, src, b.Min, draw.Src)
+
+	const margin = 8
+	wm := watermarkImg.Bounds()
+	at := image.Pt(b.Max.X-wm.Dx()-margin, b.Max.Y-wm.Dy()-margin)
+	draw.Draw(dst, image.Rectangle{Min: at, Max: at.Add(wm.Size())}, watermarkImg, wm.Min, draw.Over)
+	return encodeJPEG(dst)
+}
+
+func encodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	quality := envPositiveInt("SNAPSHOT_JPEG_QUALITY", defaultJPEGQuality)
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: min(quality, 100)}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func envPositiveInt(key string, def int) int {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v <= 0 {
+		log.Printf("[storage] %s inválido (%q), usando %d", key, raw, def)
+		return def
+	}
+	return v
+}
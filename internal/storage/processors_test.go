@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// usePipeline recarrega SNAPSHOT_PIPELINE no próximo ProcessSnapshot.
+func usePipeline(t *testing.T, raw string) {
+	t.Helper()
+	t.Setenv("SNAPSHOT_PIPELINE", raw)
+	pipelineOnce = sync.Once{}
+	pipeline = nil
+	t.Cleanup(func() {
+		pipelineOnce = sync.Once{}
+		pipeline = nil
+	})
+}
+
+func appendStage(suffix string) SnapshotProcessor {
+	return func(data []byte) ([]byte, error) {
+		return append(append([]byte{}, data...), suffix...), nil
+	}
+}
+
+func testJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessSnapshotRunsStagesInOrder(t *testing.T) {
+	RegisterProcessor("test_a", appendStage("-a"))
+	RegisterProcessor(" Test_B ", appendStage("-b"))
+	usePipeline(t, " test_b, TEST_A ,desconhecido,")
+
+	got, ct := ProcessSnapshot([]byte("img"), "image/jpeg")
+	if string(got) != "img-b-a" {
+		t.Fatalf("saída = %q, esperava a ordem do SNAPSHOT_PIPELINE", got)
+	}
+	if ct != http.DetectContentType(got) {
+		t.Fatalf("content-type = %q, deveria ser recalculado", ct)
+	}
+	if len(pipeline) != 2 {
+		t.Fatalf("estágios = %d, o desconhecido deveria ser ignorado", len(pipeline))
+	}
+}
+
+func TestProcessSnapshotSkipsFailingStage(t *testing.T) {
+	var seen []string
+	RegisterProcessor("test_fail", func(data []byte) ([]byte, error) {
+		seen = append(seen, string(data))
+		return []byte("lixo"), errors.New("falhou")
+	})
+	RegisterProcessor("test_c", appendStage("-c"))
+	RegisterProcessor("test_empty", func([]byte) ([]byte, error) { return nil, nil })
+	usePipeline(t, "test_c,test_fail,test_empty,test_c")
+
+	got, _ := ProcessSnapshot([]byte("img"), "image/jpeg")
+	if string(got) != "img-c-c" {
+		t.Fatalf("saída = %q: estágio com erro ou vazio deveria manter os bytes anteriores", got)
+	}
+	if len(seen) != 1 || seen[0] != "img-c" {
+		t.Fatalf("estágio com erro recebeu %q", seen)
+	}
+}
+
+func TestProcessSnapshotWithoutPipeline(t *testing.T) {
+	usePipeline(t, "")
+	data := []byte("img")
+	got, ct := ProcessSnapshot(data, "image/png")
+	if &got[0] != &data[0] || ct != "image/png" {
+		t.Fatalf("sem pipeline o snapshot deveria passar intacto, ct=%q", ct)
+	}
+
+	RegisterProcessor("test_noop", func(data []byte) ([]byte, error) { return data, nil })
+	usePipeline(t, "test_noop")
+	if _, ct := ProcessSnapshot(data, "image/png"); ct != "image/png" {
+		t.Fatalf("estágio que não muda a imagem não deveria trocar o content-type, veio %q", ct)
+	}
+}
+
+func TestStripEXIF(t *testing.T) {
+	img := testJPEG(t, 4, 4)
+	exif := []byte{0xFF, 0xE1, 0x00, 0x08, 'E', 'x', 'i', 'f', 0, 0}
+	withEXIF := append(append(append([]byte{}, img[:2]...), exif...), img[2:]...)
+
+	got, err := stripEXIF(withEXIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, img) {
+		t.Fatal("APP1 deveria ser removido sem mexer no resto do JPEG")
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(got)); err != nil {
+		t.Fatalf("JPEG sem EXIF não decodifica: %v", err)
+	}
+
+	png := []byte("\x89PNG\r\n\x1a\n")
+	if got, err := stripEXIF(png); err != nil || !bytes.Equal(got, png) {
+		t.Fatal("formato que não é JPEG deveria passar intacto")
+	}
+	if _, err := stripEXIF([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0x40, 0x00}); err == nil {
+		t.Fatal("segmento truncado deveria dar erro")
+	}
+}
+
+func TestResizeSnapshot(t *testing.T) {
+	t.Setenv("SNAPSHOT_RESIZE_MAX_DIM", "40")
+
+	small := testJPEG(t, 30, 20)
+	if got, err := resizeSnapshot(small); err != nil || !bytes.Equal(got, small) {
+		t.Fatalf("imagem dentro do limite deveria passar intacta, err=%v", err)
+	}
+
+	got, err := resizeSnapshot(testJPEG(t, 100, 50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(got))
+	if err != nil || cfg.Width != 40 || cfg.Height != 20 {
+		t.Fatalf("redimensionada para %dx%d (err=%v), esperava 40x20", cfg.Width, cfg.Height, err)
+	}
+
+	if _, err := resizeSnapshot([]byte("não é imagem")); err == nil || !strings.Contains(err.Error(), "image") {
+		t.Fatalf("bytes inválidos deveriam dar erro de decode, veio %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+// internal/storage/profiles.go
+package storage
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	profilesMu sync.RWMutex
+	profiles   = map[string]ImageStore{}
+
+	// unknownProfiles guarda os perfis desconhecidos já avisados, para logar
+	// uma vez por perfil em vez de a cada snapshot.
+	unknownProfiles sync.Map
+)
+
+// RegisterProfile registra um backend de snapshot nomeado (storage_profile no /info).
+func RegisterProfile(name string, store ImageStore) {
+	name = normalizeProfile(name)
+	if name == "" || store == nil {
+		return
+	}
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	profiles[name] = store
+	unknownProfiles.Delete(name)
+}
+
+// LoadProfilesFromEnv cria os perfis listados em STORAGE_PROFILES (ex.: "acme,globex").
+// Cada perfil lê STORAGE_PROFILE_<NOME>_ENDPOINT, _ACCESS_KEY, _SECRET_KEY, _BUCKET,
+// _PREFIX, _USE_SSL, _PUBLIC_BASE_URL, _PUBLIC_READ, _PRESIGN, _PRESIGN_TTL e
+// _RETENTION_DAYS (mesma semântica das MINIO_*).
+func LoadProfilesFromEnv() {
+	raw := strings.TrimSpace(os.Getenv("STORAGE_PROFILES"))
+	if raw == "" {
+		return
+	}
+	for _, name := range strings.Split(raw, ",") {
+		name = normalizeProfile(name)
+		if name == "" {
+			continue
+		}
+		envPrefix := fmt.Sprintf("STORAGE_PROFILE_%s_", strings.ToUpper(strings.ReplaceAll(name, "-", "_")))
+		store, err := newMinioStoreFromEnvPrefix(envPrefix)
+		if err != nil {
+			log.Printf("[storage] perfil %q não inicializado: %v", name, err)
+			continue
+		}
+		RegisterProfile(name, store)
+		log.Printf("[storage] perfil %q registrado", name)
+	}
+}
+
+// StoreFor resolve o store da câmera: perfil registrado ou, se vazio/desconhecido,
+// o DefaultStore.
+func StoreFor(profile string) ImageStore {
+	name := normalizeProfile(profile)
+	if name == "" {
+		return DefaultStore
+	}
+	profilesMu.RLock()
+	store, ok := profiles[name]
+	profilesMu.RUnlock()
+	if !ok {
+		if _, warned := unknownProfiles.LoadOrStore(name, struct{}{}); !warned {
+			log.Printf("[storage] storage_profile %q não registrado, usando store padrão", profile)
+		}
+		return DefaultStore
+	}
+	return store
+}
+
+func normalizeProfile(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
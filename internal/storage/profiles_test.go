@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+)
+
+type fakeStore struct{ name string }
+
+func (f *fakeStore) SaveSnapshot(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	return f.name + "/" + key, nil
+}
+
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prevOut, prevFlags := log.Writer(), log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(prevOut)
+		log.SetFlags(prevFlags)
+	})
+	return &buf
+}
+
+func TestStoreForResolvesRegisteredProfile(t *testing.T) {
+	acme := &fakeStore{name: "acme"}
+	RegisterProfile(" ACME ", acme)
+
+	if got := StoreFor("acme"); got != acme {
+		t.Fatalf("StoreFor(acme) = %v, esperava o perfil registrado", got)
+	}
+	if got := StoreFor("  Acme"); got != acme {
+		t.Fatalf("StoreFor normaliza caixa/espaços: got %v", got)
+	}
+}
+
+func TestStoreForFallsBackToDefault(t *testing.T) {
+	prev := DefaultStore
+	def := &fakeStore{name: "default"}
+	DefaultStore = def
+	t.Cleanup(func() { DefaultStore = prev })
+
+	if got := StoreFor(""); got != def {
+		t.Fatalf("StoreFor(\"\") = %v, esperava DefaultStore", got)
+	}
+	if got := StoreFor("globex-missing"); got != def {
+		t.Fatalf("StoreFor(desconhecido) = %v, esperava DefaultStore", got)
+	}
+}
+
+func TestStoreForWarnsOncePerUnknownProfile(t *testing.T) {
+	buf := captureLog(t)
+
+	for i := 0; i < 5; i++ {
+		StoreFor("initech")
+	}
+	StoreFor("umbrella")
+
+	out := buf.String()
+	if n := strings.Count(out, `"initech"`); n != 1 {
+		t.Fatalf("aviso de initech logado %d vezes, esperava 1:\n%s", n, out)
+	}
+	if n := strings.Count(out, `"umbrella"`); n != 1 {
+		t.Fatalf("aviso de umbrella logado %d vezes, esperava 1:\n%s", n, out)
+	}
+}
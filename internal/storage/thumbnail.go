@@ -0,0 +1,74 @@
+// internal/storage/thumbnail.go
+package storage
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"log"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/sua-org/cam-bus/internal/envconf"
+)
+
+const defaultThumbnailWidth = 320
+
+var (
+	thumbnailsOnce    sync.Once
+	thumbnailsEnabled bool
+	thumbnailWidth    int
+)
+
+// ThumbnailsEnabled lê MINIO_GENERATE_THUMBNAILS (default: false) e a largura
+// MINIO_THUMBNAIL_WIDTH (default 320).
+func ThumbnailsEnabled() bool {
+	thumbnailsOnce.Do(func() {
+		thumbnailsEnabled = envconf.Bool("MINIO_GENERATE_THUMBNAILS", false)
+		thumbnailWidth = envconf.PositiveInt("MINIO_THUMBNAIL_WIDTH", defaultThumbnailWidth)
+	})
+	return thumbnailsEnabled
+}
+
+// ThumbnailKey é a chave da miniatura ao lado do snapshot:
+// ".../evt_123.jpg" -> ".../evt_123.thumb.jpg".
+func ThumbnailKey(key string) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + ".thumb.jpg"
+}
+
+// SaveWithThumbnail grava o snapshot com Save e, com MINIO_GENERATE_THUMBNAILS,
+// uma miniatura JPEG em ThumbnailKey(key). Falha na miniatura só é logada:
+// o snapshot principal já foi gravado e thumbURL volta vazio.
+func SaveWithThumbnail(ctx context.Context, store ImageStore, key string, data []byte, contentType string) (url, thumbURL string, err error) {
+	url, err = Save(ctx, store, key, data, contentType)
+	if err != nil || !ThumbnailsEnabled() {
+		return url, "", err
+	}
+	thumb, terr := makeThumbnail(data, thumbnailWidth)
+	if terr != nil {
+		log.Printf("[storage] erro ao gerar miniatura de %s: %v", key, terr)
+		return url, "", nil
+	}
+	thumbURL, terr = Save(ctx, store, ThumbnailKey(key), thumb, "image/jpeg")
+	if terr != nil {
+		log.Printf("[storage] erro ao salvar miniatura de %s: %v", key, terr)
+		return url, "", nil
+	}
+	return url, thumbURL, nil
+}
+
+// makeThumbnail reduz a imagem para width de largura (imagens menores só são
+// recodificadas) e devolve JPEG.
+func makeThumbnail(data []byte, width int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= width {
+		return encodeJPEG(src)
+	}
+	return encodeJPEG(scaleDown(src, width, max(h*width/w, 1)))
+}
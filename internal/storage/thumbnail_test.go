@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/jpeg"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingStore guarda o que foi salvo e falha nas chaves em failKeys.
+type recordingStore struct {
+	mu       sync.Mutex
+	saved    map[string][]byte
+	types    map[string]string
+	failKeys map[string]bool
+}
+
+func newRecordingStore(failKeys ...string) *recordingStore {
+	s := &recordingStore{saved: map[string][]byte{}, types: map[string]string{}, failKeys: map[string]bool{}}
+	for _, k := range failKeys {
+		s.failKeys[k] = true
+	}
+	return s
+}
+
+func (s *recordingStore) SaveSnapshot(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	if s.failKeys[key] {
+		return "", errors.New("bucket indisponível")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saved[key] = data
+	s.types[key] = contentType
+	return "http://minio/" + key, nil
+}
+
+func useThumbnails(t *testing.T, enabled, width string) {
+	t.Helper()
+	t.Setenv("MINIO_GENERATE_THUMBNAILS", enabled)
+	t.Setenv("MINIO_THUMBNAIL_WIDTH", width)
+	thumbnailsOnce = sync.Once{}
+	t.Cleanup(func() {
+		thumbnailsOnce = sync.Once{}
+		thumbnailsEnabled = false
+		thumbnailWidth = 0
+	})
+}
+
+func TestThumbnailKey(t *testing.T) {
+	for key, want := range map[string]string{
+		"t/c1/evt_123.jpg": "t/c1/evt_123.thumb.jpg",
+		"t/c1/evt_123.png": "t/c1/evt_123.thumb.jpg",
+		"evt_123":          "evt_123.thumb.jpg",
+	} {
+		if got := ThumbnailKey(key); got != want {
+			t.Errorf("ThumbnailKey(%q) = %q, esperava %q", key, got, want)
+		}
+	}
+}
+
+func TestSaveWithThumbnail(t *testing.T) {
+	useThumbnails(t, "true", "80")
+	store := newRecordingStore()
+	data := testJPEG(t, 320, 160)
+
+	url, thumbURL, err := SaveWithThumbnail(context.Background(), store, "t/c1/evt_1.jpg", data, "image/jpeg")
+	if err != nil || url != "http://minio/t/c1/evt_1.jpg" || thumbURL != "http://minio/t/c1/evt_1.thumb.jpg" {
+		t.Fatalf("url=%q thumb=%q err=%v", url, thumbURL, err)
+	}
+	if !bytes.Equal(store.saved["t/c1/evt_1.jpg"], data) {
+		t.Fatal("o snapshot principal deveria ser gravado como veio")
+	}
+	thumb := store.saved["t/c1/evt_1.thumb.jpg"]
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(thumb))
+	if err != nil || cfg.Width != 80 || cfg.Height != 40 || store.types["t/c1/evt_1.thumb.jpg"] != "image/jpeg" {
+		t.Fatalf("miniatura %dx%d (%q, %v), esperava JPEG 80x40", cfg.Width, cfg.Height, store.types["t/c1/evt_1.thumb.jpg"], err)
+	}
+}
+
+func TestSaveWithThumbnailFailuresKeepSnapshot(t *testing.T) {
+	useThumbnails(t, "on", "")
+	logs := captureLog(t)
+
+	// falha ao gravar a miniatura: snapshot continua salvo, thumbURL vazio
+	store := newRecordingStore("t/c1/evt_1.thumb.jpg")
+	url, thumbURL, err := SaveWithThumbnail(context.Background(), store, "t/c1/evt_1.jpg", testJPEG(t, 64, 64), "image/jpeg")
+	if err != nil || url == "" || thumbURL != "" {
+		t.Fatalf("url=%q thumb=%q err=%v", url, thumbURL, err)
+	}
+	if !strings.Contains(logs.String(), "erro ao salvar miniatura") {
+		t.Fatalf("log = %q", logs.String())
+	}
+
+	// imagem que não decodifica: sem miniatura, sem erro
+	store = newRecordingStore()
+	url, thumbURL, err = SaveWithThumbnail(context.Background(), store, "t/c1/evt_2.jpg", []byte("não é imagem"), "image/jpeg")
+	if err != nil || url == "" || thumbURL != "" || len(store.saved) != 1 {
+		t.Fatalf("url=%q thumb=%q err=%v salvos=%d", url, thumbURL, err, len(store.saved))
+	}
+
+	// falha no snapshot principal: erro e nenhuma miniatura
+	store = newRecordingStore("t/c1/evt_3.jpg")
+	if _, thumbURL, err = SaveWithThumbnail(context.Background(), store, "t/c1/evt_3.jpg", testJPEG(t, 64, 64), "image/jpeg"); err == nil || thumbURL != "" || len(store.saved) != 0 {
+		t.Fatalf("thumb=%q err=%v salvos=%d", thumbURL, err, len(store.saved))
+	}
+}
+
+func TestSaveWithThumbnailDisabled(t *testing.T) {
+	useThumbnails(t, "", "")
+	store := newRecordingStore()
+	if _, thumbURL, err := SaveWithThumbnail(context.Background(), store, "evt.jpg", testJPEG(t, 640, 480), "image/jpeg"); err != nil || thumbURL != "" || len(store.saved) != 1 {
+		t.Fatalf("thumb=%q err=%v salvos=%d", thumbURL, err, len(store.saved))
+	}
+	if thumbnailWidth != defaultThumbnailWidth {
+		t.Fatalf("MINIO_THUMBNAIL_WIDTH default = %d", thumbnailWidth)
+	}
+}
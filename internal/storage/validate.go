@@ -0,0 +1,68 @@
+// internal/storage/validate.go
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sua-org/cam-bus/internal/envconf"
+)
+
+var (
+	validateOnce    sync.Once
+	validateEnabled bool
+
+	rejectedSnapshots atomic.Int64
+)
+
+// ValidationEnabled lê SNAPSHOT_VALIDATE (default: false).
+func ValidationEnabled() bool {
+	validateOnce.Do(func() {
+		validateEnabled = envconf.Bool("SNAPSHOT_VALIDATE", false)
+	})
+	return validateEnabled
+}
+
+// ValidateSnapshot confere, com SNAPSHOT_VALIDATE=true, se os bytes são uma
+// imagem decodificável (image.DecodeConfig) e, para JPEG, se não estão
+// truncados (sem o marcador EOI). Câmeras às vezes mandam JPEG cortado, que
+// não renderiza no HA e quebra o FindFace. Cada rejeição conta em
+// RejectedSnapshots. Com a validação desligada, sempre nil.
+func ValidateSnapshot(data []byte) error {
+	if !ValidationEnabled() {
+		return nil
+	}
+	if err := checkImage(data); err != nil {
+		rejectedSnapshots.Add(1)
+		return err
+	}
+	return nil
+}
+
+// RejectedSnapshots é o total de snapshots rejeitados desde o início.
+func RejectedSnapshots() int64 {
+	return rejectedSnapshots.Load()
+}
+
+func checkImage(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("snapshot vazio")
+	}
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("imagem inválida: %w", err)
+	}
+	if format == "jpeg" {
+		// DecodeConfig só lê o cabeçalho; JPEG truncado passa por ele.
+		// Alguns firmwares completam com zeros depois do EOI.
+		tail := bytes.TrimRight(data, "\x00")
+		if !bytes.HasSuffix(tail, []byte{0xFF, 0xD9}) {
+			return errors.New("JPEG truncado (sem marcador EOI)")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func useValidation(t *testing.T, raw string) {
+	t.Helper()
+	t.Setenv("SNAPSHOT_VALIDATE", raw)
+	validateOnce = sync.Once{}
+	t.Cleanup(func() {
+		validateOnce = sync.Once{}
+		validateEnabled = false
+	})
+}
+
+func TestValidateSnapshot(t *testing.T) {
+	useValidation(t, "true")
+	jpg := testJPEG(t, 64, 64)
+
+	var pngBuf bytes.Buffer
+	img := image.NewGray(image.Rect(0, 0, 4, 4))
+	img.Set(1, 1, color.White)
+	if err := png.Encode(&pngBuf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		data []byte
+		err  string // "" = válido
+	}{
+		{"jpeg", jpg, ""},
+		{"jpeg com zeros depois do EOI", append(append([]byte{}, jpg...), 0, 0, 0), ""},
+		{"png", pngBuf.Bytes(), ""},
+		{"jpeg truncado", jpg[:len(jpg)-20], "sem marcador EOI"},
+		{"html de erro", []byte("<html>401 Unauthorized</html>"), "imagem inválida"},
+		{"vazio", nil, "vazio"},
+	}
+	before := RejectedSnapshots()
+	rejected := int64(0)
+	for _, tc := range cases {
+		err := ValidateSnapshot(tc.data)
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("%s: %v, esperava válido", tc.name, err)
+			}
+			continue
+		}
+		rejected++
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: erro %v, esperava %q", tc.name, err, tc.err)
+		}
+	}
+	if got := RejectedSnapshots() - before; got != rejected {
+		t.Fatalf("RejectedSnapshots contou %d, esperava %d", got, rejected)
+	}
+}
+
+func TestValidateSnapshotDisabled(t *testing.T) {
+	useValidation(t, "")
+	before := RejectedSnapshots()
+	if err := ValidateSnapshot([]byte("não é imagem")); err != nil {
+		t.Fatalf("sem SNAPSHOT_VALIDATE nada é rejeitado: %v", err)
+	}
+	if RejectedSnapshots() != before {
+		t.Fatal("sem SNAPSHOT_VALIDATE o contador não deveria mudar")
+	}
+}
@@ -0,0 +1,78 @@
+// internal/supervisor/admin_cameras.go
+package supervisor
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/admin"
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/uplink"
+)
+
+// adminCamera é um worker em GET /cameras: o estado ao vivo do driver, como no
+// status MQTT. Câmeras sem worker ficam só no GET /inventory.
+type adminCamera struct {
+	Key           string          `json:"key"`
+	Camera        core.CameraInfo `json:"camera"` // senha e credenciais de URL redigidas
+	Status        string          `json:"status"`
+	StatusSince   *time.Time      `json:"status_since,omitempty"`
+	StatusReason  string          `json:"status_reason,omitempty"`
+	EverConnected bool            `json:"ever_connected"`
+	LastEventAt   *time.Time      `json:"last_event_at,omitempty"`
+	Analytics     []string        `json:"active_analytics"`
+	Reconnects    int             `json:"reconnects"`
+	LastReconnect *time.Time      `json:"last_reconnect_at,omitempty"`
+	LastRTTMs     int64           `json:"last_rtt_ms,omitempty"`
+	AvgRTTMs      int64           `json:"avg_rtt_ms,omitempty"`
+	ExitReason    string          `json:"exit_reason,omitempty"`
+	ExitedAt      *time.Time      `json:"exited_at,omitempty"`
+	RestartCount  int             `json:"restart_count"`
+	LastError     string          `json:"last_error,omitempty"`
+}
+
+func (s *Supervisor) adminCameras() []adminCamera {
+	workers := s.snapshotWorkers()
+	out := make([]adminCamera, 0, len(workers))
+	for _, w := range workers {
+		analytics := w.Analytics
+		if analytics == nil {
+			analytics = []string{}
+		}
+		out = append(out, adminCamera{
+			Key:           s.keyFor(w.Info),
+			Camera:        redactCameraInfo(w.Info),
+			Status:        string(w.Status),
+			StatusSince:   timePtr(w.StatusSince),
+			StatusReason:  w.StatusReason,
+			EverConnected: w.EverConnected,
+			LastEventAt:   timePtr(w.LastEventAt),
+			Analytics:     analytics,
+			Reconnects:    w.Reconnects,
+			LastReconnect: timePtr(w.LastReconnect),
+			LastRTTMs:     w.LastRTT.Milliseconds(),
+			AvgRTTMs:      w.AvgRTT.Milliseconds(),
+			ExitReason:    w.ExitReason,
+			ExitedAt:      timePtr(w.ExitedAt),
+			RestartCount:  w.RestartCount,
+			LastError:     w.LastError,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// camerasHandler atende GET /cameras.
+func (s *Supervisor) camerasHandler(w http.ResponseWriter, r *http.Request) {
+	admin.WriteJSON(w, http.StatusOK, s.adminCameras())
+}
+
+// uplinksHandler atende GET /uplinks (lista vazia sem gerenciador de uplink).
+func (s *Supervisor) uplinksHandler(w http.ResponseWriter, r *http.Request) {
+	uplinks := s.uplink.Uplinks()
+	if uplinks == nil {
+		uplinks = []uplink.UplinkInfo{}
+	}
+	admin.WriteJSON(w, http.StatusOK, uplinks)
+}
@@ -0,0 +1,40 @@
+// internal/supervisor/admin_loglevel.go
+package supervisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/sua-org/cam-bus/internal/admin"
+	"github.com/sua-org/cam-bus/internal/logging"
+)
+
+// logLevelBody é o corpo de GET/PUT /log-level.
+type logLevelBody struct {
+	Level string `json:"level"`
+}
+
+// logLevelHandler atende GET /log-level.
+func logLevelHandler(w http.ResponseWriter, r *http.Request) {
+	admin.WriteJSON(w, http.StatusOK, logLevelBody{Level: logging.Default().Level().String()})
+}
+
+// setLogLevelHandler atende PUT /log-level ({"level": "debug"}), trocando o
+// nível sem reiniciar o processo. Vale até o próximo restart (LOG_LEVEL).
+func setLogLevelHandler(w http.ResponseWriter, r *http.Request) {
+	var body logLevelBody
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&body); err != nil {
+		admin.WriteError(w, http.StatusBadRequest, fmt.Errorf("corpo inválido: %w", err))
+		return
+	}
+	level, ok := logging.ParseLevel(body.Level)
+	if !ok {
+		admin.WriteError(w, http.StatusBadRequest, fmt.Errorf("nível inválido %q (debug, info, warn, error)", body.Level))
+		return
+	}
+	prev := logging.Default().Level()
+	logging.Default().SetLevel(level)
+	logging.Infof("[admin] nível de log: %s -> %s", prev, level)
+	admin.WriteJSON(w, http.StatusOK, logLevelBody{Level: level.String()})
+}
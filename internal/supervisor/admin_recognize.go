@@ -0,0 +1,125 @@
+// internal/supervisor/admin_recognize.go
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/admin"
+	"github.com/sua-org/cam-bus/internal/engines"
+	"github.com/sua-org/cam-bus/internal/storage"
+)
+
+const maxRecognizeImageBytes = 10 << 20
+
+// recognizeRequest é o corpo JSON de POST /recognize com a chave no MinIO.
+type recognizeRequest struct {
+	Key            string `json:"key"`
+	StorageProfile string `json:"storage_profile,omitempty"`
+}
+
+// recognizeHandler roda o reconhecimento de face sobre uma imagem enviada
+// (multipart "image" ou corpo image/*) ou um snapshot salvo (JSON {"key": ...}
+// ou ?key=), devolvendo match e card sem publicar evento.
+func recognizeHandler(rec engines.Recognizer, load func(ctx context.Context, profile, key string) ([]byte, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if rec == nil {
+			admin.WriteError(w, http.StatusServiceUnavailable, errors.New("nenhuma engine de reconhecimento habilitada"))
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+		r.Body = http.MaxBytesReader(w, r.Body, maxRecognizeImageBytes)
+
+		img, status, err := recognizeImage(ctx, r, load)
+		if err != nil {
+			admin.WriteError(w, status, err)
+			return
+		}
+		result, err := rec.Recognize(ctx, img)
+		if err != nil {
+			admin.WriteError(w, http.StatusBadGateway, err)
+			return
+		}
+		admin.WriteJSON(w, http.StatusOK, result)
+	}
+}
+
+func recognizeImage(ctx context.Context, r *http.Request, load func(ctx context.Context, profile, key string) ([]byte, error)) ([]byte, int, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var req recognizeRequest
+	switch {
+	case mediaType == "multipart/form-data":
+		file, _, err := r.FormFile("image")
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("campo multipart \"image\": %w", err)
+		}
+		defer file.Close()
+		img, err := io.ReadAll(file)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		return nonEmptyImage(img)
+	case strings.HasPrefix(mediaType, "image/") || mediaType == "application/octet-stream":
+		img, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		return nonEmptyImage(img)
+	case mediaType == "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("json inválido: %w", err)
+		}
+	}
+	if req.Key == "" {
+		req.Key = r.URL.Query().Get("key")
+		req.StorageProfile = r.URL.Query().Get("storage_profile")
+	}
+	req.Key = strings.TrimLeft(strings.TrimSpace(req.Key), "/")
+	if req.Key == "" {
+		return nil, http.StatusBadRequest, errors.New("envie uma imagem (multipart \"image\" ou corpo image/*) ou a chave do snapshot (\"key\")")
+	}
+	img, err := load(ctx, req.StorageProfile, req.Key)
+	if err != nil {
+		return nil, http.StatusNotFound, fmt.Errorf("snapshot %q: %w", req.Key, err)
+	}
+	return nonEmptyImage(img)
+}
+
+func nonEmptyImage(img []byte) ([]byte, int, error) {
+	if len(img) == 0 {
+		return nil, http.StatusBadRequest, errors.New("imagem vazia")
+	}
+	return img, http.StatusOK, nil
+}
+
+// loadStoredSnapshot lê um snapshot do store do perfil (vazio = padrão).
+func loadStoredSnapshot(ctx context.Context, profile, key string) ([]byte, error) {
+	store := storage.StoreFor(profile)
+	if store == nil {
+		return nil, errors.New("storage não configurado")
+	}
+	img, _, err := storage.Load(ctx, store, key)
+	return img, err
+}
+
+// registerAdminRoutes registra as rotas do supervisor no servidor de admin.
+func (s *Supervisor) registerAdminRoutes() {
+	if s.admin == nil {
+		return
+	}
+	s.admin.Handle("POST /recognize", recognizeHandler(s.engines.Recognizer(), loadStoredSnapshot))
+	s.admin.HandleFunc("GET /inventory", s.inventoryHandler)
+	s.admin.HandleFunc("GET /cameras", s.camerasHandler)
+	s.admin.HandleFunc("GET /uplinks", s.uplinksHandler)
+	s.admin.HandleFunc("GET /log-level", logLevelHandler)
+	s.admin.HandleFunc("PUT /log-level", setLogLevelHandler)
+}
@@ -0,0 +1,140 @@
+package supervisor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sua-org/cam-bus/internal/faceengine"
+)
+
+// fakeRecognizer casa só a imagem "rosto-conhecido" e guarda o que recebeu.
+type fakeRecognizer struct {
+	got []byte
+	err error
+}
+
+func (f *fakeRecognizer) Recognize(ctx context.Context, img []byte) (*faceengine.Recognition, error) {
+	f.got = img
+	if f.err != nil {
+		return nil, f.err
+	}
+	if string(img) != "rosto-conhecido" {
+		return &faceengine.Recognition{FaceDetected: true, Confidence: 0.41}, nil
+	}
+	card := 7
+	return &faceengine.Recognition{FaceDetected: true, Matched: true, CardID: &card, PersonName: "Fulano", Confidence: 0.93}, nil
+}
+
+func snapshotLoader(snaps map[string]string) func(ctx context.Context, profile, key string) ([]byte, error) {
+	return func(ctx context.Context, profile, key string) ([]byte, error) {
+		img, ok := snaps[profile+"|"+key]
+		if !ok {
+			return nil, errors.New("não encontrado")
+		}
+		return []byte(img), nil
+	}
+}
+
+func serveRecognize(t *testing.T, h http.Handler, req *http.Request) (int, faceengine.Recognition, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var out faceengine.Recognition
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec.Code, out, rec.Body.String()
+}
+
+func TestRecognizeHandlerMatched(t *testing.T) {
+	fake := &fakeRecognizer{}
+	h := recognizeHandler(fake, snapshotLoader(map[string]string{"arquivo|cam1/evt.jpg": "rosto-conhecido"}))
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("image", "face.jpg")
+	part.Write([]byte("rosto-conhecido"))
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/recognize", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	code, out, raw := serveRecognize(t, h, req)
+	if code != http.StatusOK || !out.Matched || out.CardID == nil || *out.CardID != 7 || out.PersonName != "Fulano" {
+		t.Fatalf("multipart: status %d corpo %s", code, raw)
+	}
+
+	// chave do snapshot no JSON, com perfil de storage e barra inicial
+	req = httptest.NewRequest(http.MethodPost, "/recognize", strings.NewReader(`{"key":"/cam1/evt.jpg","storage_profile":"arquivo"}`))
+	req.Header.Set("Content-Type", "application/json")
+	code, out, raw = serveRecognize(t, h, req)
+	if code != http.StatusOK || !out.Matched || string(fake.got) != "rosto-conhecido" {
+		t.Fatalf("json key: status %d corpo %s", code, raw)
+	}
+}
+
+func TestRecognizeHandlerUnmatched(t *testing.T) {
+	fake := &fakeRecognizer{}
+	h := recognizeHandler(fake, snapshotLoader(map[string]string{"|cam1/outro.jpg": "desconhecido"}))
+
+	req := httptest.NewRequest(http.MethodPost, "/recognize", strings.NewReader("rosto-qualquer"))
+	req.Header.Set("Content-Type", "image/jpeg")
+	code, out, raw := serveRecognize(t, h, req)
+	if code != http.StatusOK || out.Matched || !out.FaceDetected || out.CardID != nil || out.Confidence != 0.41 {
+		t.Fatalf("corpo image/*: status %d corpo %s", code, raw)
+	}
+	if string(fake.got) != "rosto-qualquer" {
+		t.Fatalf("recognizer recebeu %q", fake.got)
+	}
+
+	code, out, raw = serveRecognize(t, h, httptest.NewRequest(http.MethodPost, "/recognize?key=cam1/outro.jpg", nil))
+	if code != http.StatusOK || out.Matched || string(fake.got) != "desconhecido" {
+		t.Fatalf("?key=: status %d corpo %s", code, raw)
+	}
+}
+
+func TestRecognizeHandlerBadInput(t *testing.T) {
+	h := recognizeHandler(&fakeRecognizer{}, snapshotLoader(nil))
+
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		target      string
+		want        int
+	}{
+		{name: "sem imagem nem chave", target: "/recognize", want: http.StatusBadRequest},
+		{name: "imagem vazia", contentType: "image/jpeg", target: "/recognize", want: http.StatusBadRequest},
+		{name: "json inválido", contentType: "application/json", body: "{", target: "/recognize", want: http.StatusBadRequest},
+		{name: "multipart sem campo image", contentType: "multipart/form-data; boundary=x", body: "--x--\r\n", target: "/recognize", want: http.StatusBadRequest},
+		{name: "snapshot inexistente", target: "/recognize?key=cam1/nada.jpg", want: http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, tc.target, strings.NewReader(tc.body))
+		if tc.contentType != "" {
+			req.Header.Set("Content-Type", tc.contentType)
+		}
+		code, _, raw := serveRecognize(t, h, req)
+		if code != tc.want || !strings.Contains(raw, `"error"`) {
+			t.Errorf("%s: status %d corpo %s, esperava %d", tc.name, code, raw, tc.want)
+		}
+	}
+
+	// erro do FindFace vira 502; sem engine, 503
+	failing := recognizeHandler(&fakeRecognizer{err: errors.New("findface fora")}, snapshotLoader(nil))
+	req := httptest.NewRequest(http.MethodPost, "/recognize", strings.NewReader("img"))
+	req.Header.Set("Content-Type", "image/png")
+	if code, _, raw := serveRecognize(t, failing, req); code != http.StatusBadGateway {
+		t.Fatalf("erro da engine: status %d corpo %s", code, raw)
+	}
+	if code, _, _ := serveRecognize(t, recognizeHandler(nil, snapshotLoader(nil)), httptest.NewRequest(http.MethodPost, "/recognize", nil)); code != http.StatusServiceUnavailable {
+		t.Fatalf("sem recognizer: status %d", code)
+	}
+}
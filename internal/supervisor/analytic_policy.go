@@ -0,0 +1,133 @@
+// internal/supervisor/analytic_policy.go
+package supervisor
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+const defaultEventQoS byte = 1
+
+// analyticPolicyFile é o arquivo de ANALYTIC_POLICY (YAML ou JSON). Chaves de
+// analytic sem diferenciar maiúsculas, "*" vale para qualquer analytic; a seção
+// cameras (por device_id) tem precedência sobre analytics. Ex.:
+//
+//	analytics:
+//	  "*":            {qos: 1}
+//	  faceRecognized: {qos: 2, retained: true}
+//	  motion:         {qos: 0, snapshot: false}
+//	cameras:
+//	  cam-portaria:
+//	    faceCapture: {qos: 0}
+type analyticPolicyFile struct {
+	Analytics map[string]analyticPolicy            `yaml:"analytics"`
+	Cameras   map[string]map[string]analyticPolicy `yaml:"cameras"`
+}
+
+// analyticPolicy é a entrega de um analytic; campos ausentes herdam do nível
+// seguinte (câmera+analytic, câmera+*, analytic, *, default).
+type analyticPolicy struct {
+	QoS      *int  `yaml:"qos"`
+	Retained *bool `yaml:"retained"`
+	Snapshot *bool `yaml:"snapshot"` // false: sem SnapshotURL/base64/tópico de snapshot
+}
+
+// deliveryPolicy é a política resolvida usada no publish.
+type deliveryPolicy struct {
+	qos      byte
+	retained bool
+	snapshot bool
+}
+
+type analyticPolicies struct {
+	analytics map[string]analyticPolicy
+	cameras   map[string]map[string]analyticPolicy
+}
+
+func analyticPoliciesFromEnv() analyticPolicies {
+	path := strings.TrimSpace(os.Getenv("ANALYTIC_POLICY"))
+	if path == "" {
+		return analyticPolicies{}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("[supervisor] ANALYTIC_POLICY: erro lendo %s: %v (usando QoS 1, sem retain)", path, err)
+		return analyticPolicies{}
+	}
+	var file analyticPolicyFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		log.Printf("[supervisor] ANALYTIC_POLICY: erro no parse de %s: %v (usando QoS 1, sem retain)", path, err)
+		return analyticPolicies{}
+	}
+
+	p := analyticPolicies{analytics: normalizeAnalyticPolicies(file.Analytics, "")}
+	for cam, rules := range file.Cameras {
+		cam = strings.TrimSpace(cam)
+		if cam == "" {
+			continue
+		}
+		if p.cameras == nil {
+			p.cameras = map[string]map[string]analyticPolicy{}
+		}
+		p.cameras[cam] = normalizeAnalyticPolicies(rules, cam)
+	}
+	log.Printf("[supervisor] ANALYTIC_POLICY: %d regras de analytic, %d câmeras (%s)", len(p.analytics), len(p.cameras), path)
+	return p
+}
+
+func normalizeAnalyticPolicies(in map[string]analyticPolicy, camera string) map[string]analyticPolicy {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make(map[string]analyticPolicy, len(in))
+	for analytic, pol := range in {
+		if pol.QoS != nil && (*pol.QoS < 0 || *pol.QoS > 2) {
+			log.Printf("[supervisor] ANALYTIC_POLICY: qos %d inválido em %s%s, ignorando o qos", *pol.QoS, cameraPrefix(camera), analytic)
+			pol.QoS = nil
+		}
+		out[strings.ToLower(strings.TrimSpace(analytic))] = pol
+	}
+	return out
+}
+
+func cameraPrefix(camera string) string {
+	if camera == "" {
+		return ""
+	}
+	return camera + "/"
+}
+
+// forEvent resolve a política do evento para a câmera.
+func (p analyticPolicies) forEvent(info core.CameraInfo, analyticType string) deliveryPolicy {
+	out := deliveryPolicy{qos: defaultEventQoS, snapshot: true}
+	analytic := strings.ToLower(analyticType)
+
+	// do menos para o mais específico: cada nível sobrescreve o que definir
+	levels := []analyticPolicy{p.analytics["*"], p.analytics[analytic]}
+	if cam := p.cameras[info.DeviceID]; cam != nil {
+		levels = append(levels, cam["*"], cam[analytic])
+	}
+	for _, l := range levels {
+		if l.QoS != nil {
+			out.qos = byte(*l.QoS)
+		}
+		if l.Retained != nil {
+			out.retained = *l.Retained
+		}
+		if l.Snapshot != nil {
+			out.snapshot = *l.Snapshot
+		}
+	}
+	return out
+}
+
+// stripSnapshot tira as referências ao snapshot do evento de saída.
+func stripSnapshot(evtOut *core.AnalyticEvent) {
+	evtOut.SnapshotURL = ""
+	evtOut.SnapshotB64 = ""
+}
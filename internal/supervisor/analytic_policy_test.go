@@ -0,0 +1,135 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+const analyticPolicyYAML = `
+analytics:
+  "*":            {qos: 0}
+  faceRecognized: {qos: 2, retained: true}
+  Motion:         {snapshot: false}
+  faceCapture:    {qos: 7}
+cameras:
+  cam-portaria:
+    "*":         {retained: true}
+    faceCapture: {qos: 1, snapshot: false}
+`
+
+func writeAnalyticPolicy(t *testing.T, content string) analyticPolicies {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ANALYTIC_POLICY", path)
+	return analyticPoliciesFromEnv()
+}
+
+func TestAnalyticPolicyForEvent(t *testing.T) {
+	p := writeAnalyticPolicy(t, analyticPolicyYAML)
+	other := core.CameraInfo{DeviceID: "cam-doca"}
+	portaria := core.CameraInfo{DeviceID: "cam-portaria"}
+
+	cases := []struct {
+		name     string
+		info     core.CameraInfo
+		analytic string
+		want     deliveryPolicy
+	}{
+		{"só o *", other, "lineCrossing", deliveryPolicy{qos: 0, snapshot: true}},
+		{"analytic específico", other, "faceRecognized", deliveryPolicy{qos: 2, retained: true, snapshot: true}},
+		{"chave sem diferenciar maiúsculas", other, "motion", deliveryPolicy{qos: 0, snapshot: false}},
+		{"qos inválido ignorado", other, "faceCapture", deliveryPolicy{qos: 0, snapshot: true}},
+		{"câmera *", portaria, "lineCrossing", deliveryPolicy{qos: 0, retained: true, snapshot: true}},
+		{"câmera + analytic", portaria, "faceCapture", deliveryPolicy{qos: 1, retained: true, snapshot: false}},
+		{"câmera herda o analytic", portaria, "faceRecognized", deliveryPolicy{qos: 2, retained: true, snapshot: true}},
+	}
+	for _, tc := range cases {
+		if got := p.forEvent(tc.info, tc.analytic); got != tc.want {
+			t.Errorf("%s: política = %+v, esperava %+v", tc.name, got, tc.want)
+		}
+	}
+
+	if got := (analyticPolicies{}).forEvent(other, "faceCapture"); got != (deliveryPolicy{qos: defaultEventQoS, snapshot: true}) {
+		t.Fatalf("sem ANALYTIC_POLICY: %+v", got)
+	}
+}
+
+func TestAnalyticPolicyFromEnvInvalidFile(t *testing.T) {
+	if p := writeAnalyticPolicy(t, "analytics: [nao, e, mapa]"); p.analytics != nil || p.cameras != nil {
+		t.Fatalf("YAML inválido deveria cair no default: %+v", p)
+	}
+	t.Setenv("ANALYTIC_POLICY", filepath.Join(t.TempDir(), "nao-existe.yaml"))
+	if p := analyticPoliciesFromEnv(); p.analytics != nil {
+		t.Fatalf("arquivo ausente deveria cair no default: %+v", p)
+	}
+}
+
+func TestPublishEventUsesAnalyticPolicy(t *testing.T) {
+	fake, client := newFakeMQTT()
+	s := &Supervisor{
+		mqtt:           client,
+		baseTopic:      "cams",
+		snapshotB64:    snapshotB64Off,
+		snapshotTopic:  snapshotTopicPolicy{enabled: true, maxBytes: 1024},
+		analyticPolicy: writeAnalyticPolicy(t, analyticPolicyYAML),
+	}
+	info := core.CameraInfo{Tenant: "t", Building: "b", Floor: "f", DeviceType: "cam", DeviceID: "cam-doca"}
+	key := s.keyFor(info)
+	img := base64.StdEncoding.EncodeToString([]byte("\xff\xd8jpeg\xff\xd9"))
+
+	s.publishEvent(context.Background(), key, info, core.AnalyticEvent{
+		EventID: "e1", AnalyticType: "motion", SnapshotURL: "http://minio/snap.jpg", SnapshotB64: img,
+	})
+	s.publishDerivedEvents(context.Background(), key, info, []core.AnalyticEvent{{
+		EventID: "e2", AnalyticType: "faceRecognized", SnapshotURL: "http://minio/face.jpg", SnapshotB64: img,
+	}})
+	s.publishEvent(context.Background(), key, info, core.AnalyticEvent{EventID: "e3", AnalyticType: "lineCrossing"})
+
+	event := func(analytic string) (fakeMessage, core.AnalyticEvent) {
+		t.Helper()
+		var found []fakeMessage
+		for _, m := range fake.messages("/" + analytic + "/") {
+			if !strings.HasSuffix(m.topic, "/snapshot") {
+				found = append(found, m)
+			}
+		}
+		if len(found) != 1 {
+			t.Fatalf("%s: %d eventos publicados, esperava 1", analytic, len(found))
+		}
+		var out core.AnalyticEvent
+		if err := json.Unmarshal(found[0].payload, &out); err != nil {
+			t.Fatal(err)
+		}
+		return found[0], out
+	}
+
+	msg, out := event("motion")
+	if msg.qos != 0 || msg.retained {
+		t.Fatalf("motion: qos=%d retained=%t", msg.qos, msg.retained)
+	}
+	if out.SnapshotURL != "" || out.Meta["snapshot_topic"] != nil || len(fake.messages("/motion/")) != 1 {
+		t.Fatalf("motion com snapshot: false não deveria levar snapshot: %+v", out)
+	}
+
+	msg, out = event("faceRecognized")
+	if msg.qos != 2 || !msg.retained {
+		t.Fatalf("faceRecognized (derivado): qos=%d retained=%t", msg.qos, msg.retained)
+	}
+	if out.SnapshotURL != "http://minio/face.jpg" || out.Meta["snapshot_topic"] == nil {
+		t.Fatalf("faceRecognized deveria manter o snapshot: %+v", out)
+	}
+
+	if msg, _ = event("lineCrossing"); msg.qos != 0 || msg.retained {
+		t.Fatalf("lineCrossing: qos=%d retained=%t, esperava o *", msg.qos, msg.retained)
+	}
+}
@@ -0,0 +1,97 @@
+// internal/supervisor/availability.go
+package supervisor
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+// Payloads dos tópicos de disponibilidade (collector e câmeras).
+const (
+	AvailabilityOnline  = "online"
+	AvailabilityOffline = "offline"
+)
+
+// CollectorAvailabilityTopic é o tópico retained de disponibilidade desta
+// instância: CAMBUS_AVAILABILITY_TOPIC ou <base>/<CAMBUS_SHARD>/collector/availability
+// ("default" sem shard). O main o usa como Last Will (offline) e o supervisor
+// publica online nele no start.
+func CollectorAvailabilityTopic(baseTopic string) string {
+	if topic := strings.TrimSpace(os.Getenv("CAMBUS_AVAILABILITY_TOPIC")); topic != "" {
+		return topic
+	}
+	shard := strings.TrimSpace(os.Getenv("CAMBUS_SHARD"))
+	if shard == "" {
+		shard = "default"
+	}
+	return fmt.Sprintf("%s/%s/collector/availability", strings.TrimSuffix(baseTopic, "/"), shard)
+}
+
+func (s *Supervisor) publishAvailability(payload string) {
+	if err := s.mqtt.Publish(s.collectorAvailabilityTopic, 1, true, []byte(payload)); err != nil {
+		log.Printf("[supervisor] erro ao publicar %s em %s: %v", payload, s.collectorAvailabilityTopic, err)
+		return
+	}
+	log.Printf("[supervisor] collector %s -> %s", payload, s.collectorAvailabilityTopic)
+}
+
+// availabilityTopic é o tópico retained de disponibilidade da câmera, ao lado
+// do /status: online enquanto há worker rodando, offline quando o cam-bus para
+// de gerenciá-la (disable, tombstone, troca de shard, shutdown).
+func (s *Supervisor) availabilityTopic(info core.CameraInfo) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s/%s/availability",
+		s.baseTopic,
+		info.Tenant,
+		info.Building,
+		info.Floor,
+		info.DeviceType,
+		info.DeviceID,
+	)
+}
+
+func (s *Supervisor) publishCameraAvailability(info core.CameraInfo, payload string) {
+	topic := s.availabilityTopic(info)
+	if err := s.mqtt.Publish(topic, 1, true, []byte(payload)); err != nil {
+		log.Printf("[supervisor] erro ao publicar %s em %s: %v", payload, topic, err)
+	}
+}
+
+// watchConnection registra os hooks de conexão do MQTT: loga as quedas e, na
+// reconexão, volta a publicar online (o Last Will já marcou offline), a
+// disponibilidade das câmeras e o discovery do HA, que podem ter se perdido
+// com o broker.
+func (s *Supervisor) watchConnection() {
+	s.mqtt.OnConnectionLost(func(err error) {
+		log.Printf("[supervisor] MQTT desconectado (%v); publicações vão falhar até a reconexão", err)
+	})
+	s.mqtt.OnConnect(func() {
+		log.Printf("[supervisor] MQTT reconectado, republicando disponibilidade e discovery")
+		s.publishAvailability(AvailabilityOnline)
+		s.republishCameraAvailability()
+		s.republishHADiscovery()
+	})
+}
+
+func (s *Supervisor) republishCameraAvailability() {
+	s.mu.Lock()
+	infos := make([]core.CameraInfo, 0, len(s.workers))
+	for _, w := range s.workers {
+		infos = append(infos, w.info)
+	}
+	s.mu.Unlock()
+	for _, info := range infos {
+		s.publishCameraAvailability(info, AvailabilityOnline)
+	}
+}
+
+func (s *Supervisor) republishHADiscovery() {
+	for _, info := range s.snapshotCameraInfos() {
+		if err := s.publishHADiscovery(info); err != nil {
+			log.Printf("[supervisor] erro ao republicar discovery para %s: %v", s.keyFor(info), err)
+		}
+	}
+}
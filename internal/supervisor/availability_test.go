@@ -0,0 +1,107 @@
+package supervisor
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func TestWatchConnectionRepublishesOnReconnect(t *testing.T) {
+	fake, client := newFakeMQTT()
+	s := &Supervisor{
+		mqtt:                       client,
+		baseTopic:                  "cams",
+		collectorAvailabilityTopic: "cams/default/collector/availability",
+		haDiscoveryEnabled:         true,
+		haDiscoveryPrefix:          "homeassistant",
+		workers:                    map[string]*cameraWorker{},
+		cameras:                    map[string]core.CameraInfo{},
+	}
+	info := discoveryCamera()
+	s.cameras[s.keyFor(info)] = info
+	s.workers[s.keyFor(info)] = &cameraWorker{info: info}
+	s.watchConnection()
+
+	// conexão inicial (no NewClient) não republica nada
+	client.NotifyConnect()
+	time.Sleep(50 * time.Millisecond)
+	if msgs := fake.messages(""); len(msgs) != 0 {
+		t.Fatalf("conexão inicial publicou %d mensagens", len(msgs))
+	}
+
+	client.NotifyConnectionLost(errors.New("EOF"))
+	client.NotifyConnect()
+	waitFor(t, "republicação após reconectar", func() bool {
+		return len(fake.messages("homeassistant/")) > 0
+	})
+
+	collector := fake.messages("/collector/availability")
+	if len(collector) != 1 || string(collector[0].payload) != AvailabilityOnline || !collector[0].retained {
+		t.Fatalf("collector availability = %+v", collector)
+	}
+	camera := fake.messages("cams/t/b/f/cam/c1/availability")
+	if len(camera) != 1 || string(camera[0].payload) != AvailabilityOnline || !camera[0].retained {
+		t.Fatalf("availability da câmera = %+v", camera)
+	}
+	for _, m := range fake.messages("homeassistant/") {
+		if !m.retained {
+			t.Errorf("discovery %s republicado sem retain", m.topic)
+		}
+	}
+}
+
+func TestCameraAvailabilityFollowsWorker(t *testing.T) {
+	t.Setenv("MOCK_EVENT_INTERVAL_MS", "60000")
+	fake, client := newFakeMQTT()
+	s := &Supervisor{
+		mqtt:      client,
+		baseTopic: "cams",
+		workers:   map[string]*cameraWorker{},
+	}
+	info := core.CameraInfo{Tenant: "t", Building: "b", Floor: "f", DeviceType: "cam", DeviceID: "c1", Manufacturer: "mock"}
+	topic := "cams/t/b/f/cam/c1/availability"
+
+	s.startOrUpdateCamera(info)
+	msgs := fake.messages(topic)
+	if len(msgs) != 1 || string(msgs[0].payload) != AvailabilityOnline || !msgs[0].retained || msgs[0].qos != 1 {
+		t.Fatalf("availability no start = %+v, esperava online retained", msgs)
+	}
+
+	// mesma config: nada novo
+	s.startOrUpdateCamera(info)
+	if n := len(fake.messages(topic)); n != 1 {
+		t.Fatalf("update sem mudança publicou availability (%d mensagens)", n)
+	}
+
+	s.cleanupCamera(info, true)
+	msgs = fake.messages(topic)
+	if len(msgs) != 2 || string(msgs[1].payload) != AvailabilityOffline || !msgs[1].retained {
+		t.Fatalf("availability no cleanup = %+v, esperava offline retained", msgs)
+	}
+	s.mu.Lock()
+	_, running := s.workers[s.keyFor(info)]
+	s.mu.Unlock()
+	if running {
+		t.Fatal("worker continua registrado após o cleanup")
+	}
+}
+
+func TestCameraAvailabilityOfflineWhenRestartHasNoDriver(t *testing.T) {
+	t.Setenv("MOCK_EVENT_INTERVAL_MS", "60000")
+	fake, client := newFakeMQTT()
+	s := &Supervisor{mqtt: client, baseTopic: "cams", workers: map[string]*cameraWorker{}}
+	info := core.CameraInfo{Tenant: "t", Building: "b", Floor: "f", DeviceType: "cam", DeviceID: "c1", Manufacturer: "mock"}
+	s.startOrUpdateCamera(info)
+	t.Cleanup(func() { s.stopCamera(s.keyFor(info)) })
+
+	// troca para um fabricante sem driver: o worker anterior para e não há substituto
+	updated := info
+	updated.Manufacturer = "desconhecido"
+	s.startOrUpdateCamera(updated)
+	msgs := fake.messages("cams/t/b/f/cam/c1/availability")
+	if len(msgs) != 2 || string(msgs[0].payload) != AvailabilityOnline || string(msgs[1].payload) != AvailabilityOffline {
+		t.Fatalf("availability = %+v, esperava online e depois offline", msgs)
+	}
+}
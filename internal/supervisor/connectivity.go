@@ -0,0 +1,92 @@
+// internal/supervisor/connectivity.go
+package supervisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/drivers"
+	"github.com/sua-org/cam-bus/internal/eventid"
+)
+
+const (
+	analyticCameraOnline  = "cameraOnline"
+	analyticCameraOffline = "cameraOffline"
+)
+
+// connectivityTransition detecta entrada/saída de "online" e devolve a
+// publicação do evento correspondente (nil se não houve transição ou se
+// EMIT_CONNECTIVITY_EVENTS está desligado). Chamado com s.mu travado; a
+// função devolvida deve rodar depois do unlock.
+func (s *Supervisor) connectivityTransition(w *cameraWorker, next drivers.ConnectionState, reason string, now time.Time) func() {
+	if !s.emitConnectivity {
+		return nil
+	}
+	wasOnline := w.status == drivers.ConnectionStateOnline
+	isOnline := next == drivers.ConnectionStateOnline
+	if wasOnline == isOnline {
+		return nil
+	}
+
+	info := w.info
+	prevSince := w.statusSince
+	recovered := w.everConnected
+	return func() {
+		if err := s.publishConnectivityEvent(info, isOnline, reason, prevSince, recovered, now); err != nil {
+			log.Printf("[supervisor] erro ao publicar evento de conectividade de %s: %v", s.keyFor(info), err)
+		}
+	}
+}
+
+func (s *Supervisor) publishConnectivityEvent(
+	info core.CameraInfo,
+	online bool,
+	reason string,
+	prevSince time.Time,
+	recovered bool,
+	now time.Time,
+) error {
+	analytic := analyticCameraOffline
+	if online {
+		analytic = analyticCameraOnline
+	}
+
+	meta := map[string]interface{}{
+		"reason": reason,
+	}
+	if online {
+		// recovered=false quando é a primeira conexão do worker
+		meta["recovered"] = recovered
+	}
+	if !prevSince.IsZero() {
+		meta["previous_state_seconds"] = int64(now.Sub(prevSince).Seconds())
+	}
+
+	evt := core.AnalyticEvent{
+		Timestamp:    now,
+		EventID:      eventid.New(analytic+"-"+info.DeviceID, now),
+		CameraIP:     info.IP,
+		CameraName:   info.Name,
+		AnalyticType: analytic,
+		Tenant:       info.Tenant,
+		Building:     info.Building,
+		Floor:        info.Floor,
+		DeviceType:   info.DeviceType,
+		DeviceID:     info.DeviceID,
+		Meta:         meta,
+	}
+
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", analytic, err)
+	}
+	topic := s.eventTopic(info, analytic)
+	if err := s.mqtt.Publish(topic, 1, false, payload); err != nil {
+		return fmt.Errorf("publish %s to %s: %w", analytic, topic, err)
+	}
+	log.Printf("[supervisor] %s publicado -> %s", analytic, topic)
+	return nil
+}
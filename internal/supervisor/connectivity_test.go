@@ -0,0 +1,60 @@
+package supervisor
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/drivers"
+)
+
+func TestConnectivityTransitionEvents(t *testing.T) {
+	fake, client := newFakeMQTT()
+	s := &Supervisor{mqtt: client, baseTopic: "cams", workers: map[string]*cameraWorker{}, emitConnectivity: true}
+	info := core.CameraInfo{Tenant: "t", Building: "b", Floor: "f", DeviceType: "cam", DeviceID: "c1"}
+	key := s.keyFor(info)
+	s.workers[key] = &cameraWorker{info: info, status: drivers.ConnectionStateConnecting}
+
+	s.updateWorkerStatus(key, drivers.StatusUpdate{State: drivers.ConnectionStateOnline})
+	s.updateWorkerStatus(key, drivers.StatusUpdate{State: drivers.ConnectionStateOnline})
+	s.updateWorkerStatus(key, drivers.StatusUpdate{State: drivers.ConnectionStateOffline, Reason: "timeout"})
+	s.updateWorkerStatus(key, drivers.StatusUpdate{State: drivers.ConnectionStateConnecting, Reconnect: true})
+	s.updateWorkerStatus(key, drivers.StatusUpdate{State: drivers.ConnectionStateOnline})
+
+	online := fake.messages("/" + analyticCameraOnline)
+	offline := fake.messages("/" + analyticCameraOffline)
+	if len(online) != 2 || len(offline) != 1 {
+		t.Fatalf("%d online e %d offline, esperava 2 e 1 (só transições)", len(online), len(offline))
+	}
+
+	meta := func(m fakeMessage) map[string]interface{} {
+		var evt core.AnalyticEvent
+		if err := json.Unmarshal(m.payload, &evt); err != nil {
+			t.Fatal(err)
+		}
+		return evt.Meta
+	}
+	if got := meta(online[0])["recovered"]; got != false {
+		t.Fatalf("primeira conexão: recovered=%v, esperava false", got)
+	}
+	if got := meta(offline[0])["reason"]; got != "timeout" {
+		t.Fatalf("offline: reason=%v", got)
+	}
+	if got := meta(online[1])["recovered"]; got != true {
+		t.Fatalf("reconexão: recovered=%v, esperava true", got)
+	}
+}
+
+func TestConnectivityEventsDisabled(t *testing.T) {
+	fake, client := newFakeMQTT()
+	s := &Supervisor{mqtt: client, baseTopic: "cams", workers: map[string]*cameraWorker{}}
+	info := core.CameraInfo{Tenant: "t", Building: "b", Floor: "f", DeviceType: "cam", DeviceID: "c1"}
+	key := s.keyFor(info)
+	s.workers[key] = &cameraWorker{info: info}
+
+	s.updateWorkerStatus(key, drivers.StatusUpdate{State: drivers.ConnectionStateOnline})
+	s.updateWorkerStatus(key, drivers.StatusUpdate{State: drivers.ConnectionStateOffline})
+	if n := len(fake.messages("/cameraO")); n != 0 {
+		t.Fatalf("eventos de conectividade desligados, mas %d publicados", n)
+	}
+}
@@ -0,0 +1,132 @@
+// internal/supervisor/dedup.go
+package supervisor
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/envconf"
+)
+
+// dedupMaxEntries limita as chaves guardadas; acima disso as mais antigas saem
+// antes de vencer (na pior das hipóteses passa uma duplicata).
+const dedupMaxEntries = 10000
+
+// dedupVolatileMeta são campos do Meta que mudam entre as cópias de um mesmo
+// evento (horário de chegada, trace, URLs montadas com o EventID gerado).
+var dedupVolatileMeta = map[string]bool{
+	"received_at": true,
+	"traceparent": true,
+	"tracestate":  true,
+}
+
+// eventDedup descarta eventos repetidos da mesma câmera dentro de
+// CAMBUS_DEDUP_WINDOW_MS (ex.: faceCapture duplicado pela Hikvision em partes
+// repetidas do multipart). Um evento é duplicado se o EventID ou o conteúdo
+// (analytic, estado, horário da câmera e Meta sem os campos voláteis) já foi
+// visto na janela; o conteúdo cobre as câmeras sem id nativo, em que o EventID
+// é gerado.
+type eventDedup struct {
+	window     time.Duration
+	maxEntries int
+
+	mu    sync.Mutex
+	seen  map[string]time.Time // chave -> primeira vez vista
+	order []dedupEntry         // chaves na ordem de inserção, para vencer/evictar
+}
+
+type dedupEntry struct {
+	key string
+	at  time.Time
+}
+
+// newEventDedupFromEnv devolve nil sem CAMBUS_DEDUP_WINDOW_MS (default:
+// publica tudo, como antes).
+func newEventDedupFromEnv() *eventDedup {
+	ms := envconf.PositiveInt("CAMBUS_DEDUP_WINDOW_MS", 0)
+	if ms == 0 {
+		return nil
+	}
+	d := newEventDedup(time.Duration(ms) * time.Millisecond)
+	log.Printf("[supervisor] deduplicação de eventos: janela de %s por câmera", d.window)
+	return d
+}
+
+func newEventDedup(window time.Duration) *eventDedup {
+	return &eventDedup{window: window, maxEntries: dedupMaxEntries, seen: make(map[string]time.Time)}
+}
+
+// duplicate registra o evento e diz se ele já tinha sido visto na janela. A
+// janela conta da primeira ocorrência: uma repetição contínua passa uma vez
+// por janela.
+func (d *eventDedup) duplicate(cameraKey string, evt core.AnalyticEvent, now time.Time) bool {
+	if d == nil {
+		return false
+	}
+	prefix := cameraKey + "|" + evt.AnalyticType + "|"
+	keys := []string{prefix + "c:" + eventContentHash(evt)}
+	if id := strings.TrimSpace(evt.EventID); id != "" {
+		keys = append(keys, prefix+"id:"+id)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.expire(now)
+	for _, k := range keys {
+		if _, ok := d.seen[k]; ok {
+			return true
+		}
+	}
+	for len(d.seen)+len(keys) > d.maxEntries && len(d.order) > 0 {
+		d.evictOldest()
+	}
+	for _, k := range keys {
+		d.seen[k] = now
+		d.order = append(d.order, dedupEntry{key: k, at: now})
+	}
+	return false
+}
+
+// expire remove do início da fila as chaves que já saíram da janela.
+func (d *eventDedup) expire(now time.Time) {
+	for len(d.order) > 0 && now.Sub(d.order[0].at) >= d.window {
+		d.evictOldest()
+	}
+}
+
+func (d *eventDedup) evictOldest() {
+	e := d.order[0]
+	d.order[0] = dedupEntry{}
+	d.order = d.order[1:]
+	if at, ok := d.seen[e.key]; ok && at.Equal(e.at) {
+		delete(d.seen, e.key)
+	}
+}
+
+// eventContentHash resume o conteúdo do evento. O Timestamp (horário da câmera)
+// entra: dois eventos iguais em instantes diferentes não são duplicatas. O
+// snapshot fica de fora: com snapshot buscado por evento, as duas cópias trazem
+// imagens diferentes.
+func eventContentHash(evt core.AnalyticEvent) string {
+	meta := make(map[string]interface{}, len(evt.Meta))
+	for k, v := range evt.Meta {
+		if dedupVolatileMeta[k] || strings.HasSuffix(k, "_url") || strings.HasSuffix(k, "_urls") {
+			continue
+		}
+		meta[k] = v
+	}
+	h := sha1.New()
+	h.Write([]byte(evt.AnalyticType + "|" + evt.EventState + "|" + strconv.FormatInt(evt.Timestamp.UnixNano(), 10) + "|"))
+	// json.Marshal ordena as chaves do mapa: mesmo Meta, mesmo hash
+	if b, err := json.Marshal(meta); err == nil {
+		h.Write(b)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,105 @@
+package supervisor
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func faceCapture(id string, ts time.Time) core.AnalyticEvent {
+	return core.AnalyticEvent{
+		AnalyticType: "faceCapture",
+		EventID:      id,
+		EventState:   "active",
+		Timestamp:    ts,
+		Meta:         map[string]interface{}{"channel": 1, "received_at": time.Now().String()},
+	}
+}
+
+func TestEventDedupDropsRepeatedEvent(t *testing.T) {
+	d := newEventDedup(time.Second)
+	now := time.Unix(1700000000, 0)
+	ts := now.Add(-time.Second)
+
+	if d.duplicate("cam1", faceCapture("hik-1", ts), now) {
+		t.Fatal("primeira ocorrência não é duplicata")
+	}
+	if !d.duplicate("cam1", faceCapture("hik-1", ts), now.Add(100*time.Millisecond)) {
+		t.Fatal("mesmo EventID na janela deveria ser duplicata")
+	}
+	// id gerado diferente, mesmo conteúdo e horário da câmera (parte repetida do multipart)
+	if !d.duplicate("cam1", faceCapture("xml-2", ts), now.Add(200*time.Millisecond)) {
+		t.Fatal("mesmo conteúdo e horário na janela deveria ser duplicata")
+	}
+	if d.duplicate("cam2", faceCapture("hik-1", ts), now.Add(200*time.Millisecond)) {
+		t.Fatal("a janela é por câmera")
+	}
+	if d.duplicate("cam1", faceCapture("hik-1", ts), now.Add(time.Second)) {
+		t.Fatal("depois da janela o evento volta a passar")
+	}
+}
+
+func TestEventDedupKeepsDistinctEvents(t *testing.T) {
+	d := newEventDedup(time.Second)
+	now := time.Unix(1700000000, 0)
+
+	if d.duplicate("cam1", faceCapture("hik-1", now), now) {
+		t.Fatal("primeira ocorrência não é duplicata")
+	}
+	if d.duplicate("cam1", faceCapture("hik-2", now.Add(300*time.Millisecond)), now.Add(300*time.Millisecond)) {
+		t.Fatal("faceCapture com outro EventID e outro horário não é duplicata")
+	}
+
+	count := func(ts time.Time) core.AnalyticEvent {
+		return core.AnalyticEvent{AnalyticType: "PeopleCounting", EventID: fmt.Sprintf("pc-%d", ts.UnixNano()), Timestamp: ts, Meta: map[string]interface{}{"count": 3}}
+	}
+	if d.duplicate("cam1", count(now), now) {
+		t.Fatal("primeira contagem não é duplicata")
+	}
+	if d.duplicate("cam1", count(now.Add(500*time.Millisecond)), now.Add(500*time.Millisecond)) {
+		t.Fatal("mesma contagem em outro instante não é duplicata")
+	}
+
+	other := faceCapture("hik-3", now)
+	other.AnalyticType = "FaceDetection"
+	if d.duplicate("cam1", other, now) {
+		t.Fatal("analytic diferente não é duplicata")
+	}
+}
+
+func TestEventDedupEvictsOldestWhenFull(t *testing.T) {
+	d := newEventDedup(time.Minute)
+	d.maxEntries = 4 // 2 eventos (id + conteúdo cada)
+	now := time.Unix(1700000000, 0)
+
+	for i := 0; i < 3; i++ {
+		at := now.Add(time.Duration(i) * time.Millisecond)
+		if d.duplicate("cam1", faceCapture(fmt.Sprintf("e%d", i), at), at) {
+			t.Fatalf("evento %d não é duplicata", i)
+		}
+	}
+	if len(d.seen) != 4 {
+		t.Fatalf("chaves guardadas = %d, esperava 4", len(d.seen))
+	}
+	if d.duplicate("cam1", faceCapture("e0", now), now.Add(time.Second)) {
+		t.Fatal("o evento mais antigo deveria ter sido evictado")
+	}
+	if !d.duplicate("cam1", faceCapture("e2", now.Add(2*time.Millisecond)), now.Add(time.Second)) {
+		t.Fatal("o evento mais recente deveria continuar na janela")
+	}
+}
+
+func TestEventDedupFromEnv(t *testing.T) {
+	for _, raw := range []string{"", "0", "abc"} {
+		t.Setenv("CAMBUS_DEDUP_WINDOW_MS", raw)
+		if d := newEventDedupFromEnv(); d != nil {
+			t.Fatalf("CAMBUS_DEDUP_WINDOW_MS=%q deveria desligar a deduplicação", raw)
+		}
+	}
+	t.Setenv("CAMBUS_DEDUP_WINDOW_MS", "1500")
+	if d := newEventDedupFromEnv(); d == nil || d.window != 1500*time.Millisecond {
+		t.Fatalf("dedup = %+v", d)
+	}
+}
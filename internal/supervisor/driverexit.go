@@ -0,0 +1,170 @@
+// internal/supervisor/driverexit.go
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/drivers"
+)
+
+// driverExitReason classifica o retorno de drv.Run.
+type driverExitReason string
+
+const (
+	driverExitCanceled   driverExitReason = "canceled"   // ctx cancelado: stop/restart pedido pelo supervisor
+	driverExitError      driverExitReason = "error"      // Run devolveu erro fatal
+	driverExitUnexpected driverExitReason = "unexpected" // Run devolveu nil sem cancelamento (bug no driver)
+	driverExitPanic      driverExitReason = "panic"      // Run entrou em panic (recuperado em runDriver)
+)
+
+const (
+	defaultDriverRestartDelay    = 10 * time.Second
+	defaultDriverRestartMaxDelay = 5 * time.Minute
+)
+
+// driverRestartState leva as falhas consecutivas do worker que saiu para o
+// worker que o substitui no restart.
+type driverRestartState struct {
+	count     int
+	lastError string
+}
+
+// driverPanicError é o erro de runDriver quando drv.Run entra em panic.
+type driverPanicError struct {
+	value interface{}
+}
+
+func (e *driverPanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.value)
+}
+
+// runDriver roda drv.Run recuperando panics (como engines.Manager.ProcessAll):
+// o stack vai para o log e o panic volta como *driverPanicError, para o worker
+// cair no restart em vez de morrer sem status. Panics em goroutines próprias do
+// driver não passam por aqui.
+func runDriver(ctx context.Context, key string, drv drivers.CameraDriver, out chan<- core.AnalyticEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[worker %s] panic no driver: %v\n%s", key, r, string(debug.Stack()))
+			err = &driverPanicError{value: r}
+		}
+	}()
+	return drv.Run(ctx, out)
+}
+
+func classifyDriverExit(ctx context.Context, err error) driverExitReason {
+	var panicErr *driverPanicError
+	switch {
+	case errors.As(err, &panicErr):
+		return driverExitPanic
+	case ctx.Err() != nil:
+		return driverExitCanceled
+	case err != nil:
+		return driverExitError
+	default:
+		return driverExitUnexpected
+	}
+}
+
+// handleDriverExit registra o motivo da saída do driver no worker e, para
+// saídas inesperadas/erro, agenda um restart (DRIVER_EXIT_RESTART, default
+// true). A espera começa em DRIVER_EXIT_RESTART_DELAY e dobra a cada falha
+// consecutiva até DRIVER_EXIT_RESTART_MAX_DELAY; depois de
+// DRIVER_EXIT_RESTART_MAX restarts sem o driver conectar o worker fica parado
+// como not_established. Sem restart o status publicado mostra o motivo.
+func (s *Supervisor) handleDriverExit(key string, worker *cameraWorker, reason driverExitReason, runErr error) {
+	var emit func()
+	defer func() {
+		if emit != nil {
+			emit()
+		}
+	}()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// worker já foi parado ou substituído: nada a fazer
+	if s.workers[key] != worker {
+		return
+	}
+
+	now := time.Now().UTC()
+	statusReason := fmt.Sprintf("driver encerrado (%s)", reason)
+	var panicErr *driverPanicError
+	switch {
+	case errors.As(runErr, &panicErr):
+		statusReason = fmt.Sprintf("driver encerrado (%s): %v", reason, panicErr.value)
+	case runErr != nil:
+		statusReason = fmt.Sprintf("driver encerrado (%s): %v", reason, runErr)
+	}
+
+	state := drivers.ConnectionStateOffline
+	if reason == driverExitPanic {
+		state = drivers.ConnectionStateNotEstablished
+	}
+	restart := reason != driverExitCanceled && s.driverRestart
+	attempt := worker.restartCount + 1
+	if reason != driverExitCanceled {
+		worker.lastError = statusReason
+		switch {
+		case !restart:
+			statusReason += ", sem restart"
+		case s.driverRestartMax > 0 && attempt > s.driverRestartMax:
+			restart = false
+			state = drivers.ConnectionStateNotEstablished
+			statusReason += fmt.Sprintf(", desistindo após %d restarts", worker.restartCount)
+			log.Printf("[supervisor] camera %s: driver saiu (%s) após %d restarts, desistindo (DRIVER_EXIT_RESTART_MAX)", key, reason, worker.restartCount)
+		}
+	}
+
+	emit = s.connectivityTransition(worker, state, statusReason, now)
+	worker.status = state
+	worker.statusReason = statusReason
+	worker.statusSince = now
+	worker.exitReason = string(reason)
+	worker.exitedAt = now
+
+	if !restart {
+		return
+	}
+
+	delay := s.driverRestartBackoff(attempt)
+	log.Printf("[supervisor] camera %s: driver saiu (%s), reiniciando em %s (tentativa %d)", key, reason, delay, attempt)
+	s.metrics.Add(metricDriverRestarts, 1, map[string]string{"manufacturer": strings.ToLower(worker.info.Manufacturer)})
+	restartState := driverRestartState{count: attempt, lastError: worker.lastError}
+	time.AfterFunc(delay, func() {
+		s.mu.Lock()
+		if s.workers[key] != worker {
+			s.mu.Unlock()
+			return
+		}
+		delete(s.workers, key)
+		s.driverRestarts[key] = restartState
+		info := worker.info
+		s.mu.Unlock()
+
+		if current, ok := s.activeCameraInfo(key); ok {
+			info = current
+		}
+		s.startOrUpdateCamera(info)
+	})
+}
+
+// driverRestartBackoff devolve a espera antes do restart número attempt
+// (1 = primeiro): DRIVER_EXIT_RESTART_DELAY dobrando até o teto.
+func (s *Supervisor) driverRestartBackoff(attempt int) time.Duration {
+	delay := s.driverRestartDelay
+	for i := 1; i < attempt && delay < s.driverRestartMaxDelay; i++ {
+		delay *= 2
+	}
+	if s.driverRestartMaxDelay > 0 && delay > s.driverRestartMaxDelay {
+		delay = s.driverRestartMaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,279 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/drivers"
+)
+
+func TestClassifyDriverExit(t *testing.T) {
+	live := context.Background()
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cases := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want driverExitReason
+	}{
+		{"nil sem cancelamento", live, nil, driverExitUnexpected},
+		{"nil com ctx cancelado", canceled, nil, driverExitCanceled},
+		{"erro com ctx cancelado", canceled, errors.New("conn reset"), driverExitCanceled},
+		{"erro", live, errors.New("auth"), driverExitError},
+		{"panic", canceled, &driverPanicError{value: "boom"}, driverExitPanic},
+	}
+	for _, tc := range cases {
+		if got := classifyDriverExit(tc.ctx, tc.err); got != tc.want {
+			t.Errorf("%s: %s, esperava %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func newExitSupervisor(restart bool) (*Supervisor, string, *cameraWorker) {
+	_, client := newFakeMQTT()
+	s := &Supervisor{
+		mqtt:               client,
+		baseTopic:          "cams",
+		workers:            map[string]*cameraWorker{},
+		driverRestarts:     map[string]driverRestartState{},
+		driverRestart:      restart,
+		driverRestartDelay: time.Hour, // o restart em si não dispara no teste
+	}
+	info := core.CameraInfo{Tenant: "t", Building: "b", Floor: "f", DeviceType: "cam", DeviceID: "c1"}
+	key := s.keyFor(info)
+	w := &cameraWorker{info: info, status: drivers.ConnectionStateOnline}
+	s.workers[key] = w
+	return s, key, w
+}
+
+func TestHandleDriverExitCanceledKeepsQuiet(t *testing.T) {
+	s, key, w := newExitSupervisor(true)
+	s.handleDriverExit(key, w, driverExitCanceled, nil)
+
+	if w.exitReason != string(driverExitCanceled) || w.lastError != "" {
+		t.Fatalf("saída cancelada: reason=%q lastError=%q", w.exitReason, w.lastError)
+	}
+	if strings.Contains(w.statusReason, "reinici") || w.status != drivers.ConnectionStateOffline {
+		t.Fatalf("status = %s (%s)", w.status, w.statusReason)
+	}
+}
+
+func TestHandleDriverExitUnexpectedWithoutRestart(t *testing.T) {
+	s, key, w := newExitSupervisor(false)
+	s.handleDriverExit(key, w, driverExitUnexpected, nil)
+
+	if w.exitReason != string(driverExitUnexpected) || w.exitedAt.IsZero() {
+		t.Fatalf("saída inesperada não registrada: %+v", w)
+	}
+	if !strings.Contains(w.statusReason, "unexpected") || !strings.Contains(w.statusReason, "sem restart") {
+		t.Fatalf("statusReason = %q", w.statusReason)
+	}
+	if w.lastError == "" {
+		t.Fatal("lastError deveria guardar o motivo")
+	}
+}
+
+func TestHandleDriverExitIgnoresReplacedWorker(t *testing.T) {
+	s, key, w := newExitSupervisor(true)
+	s.workers[key] = &cameraWorker{info: w.info}
+	s.handleDriverExit(key, w, driverExitError, errors.New("x"))
+	if w.exitReason != "" {
+		t.Fatal("worker substituído não deveria ser atualizado")
+	}
+}
+
+func TestDriverRestartBackoff(t *testing.T) {
+	s := &Supervisor{driverRestartDelay: 10 * time.Second, driverRestartMaxDelay: time.Minute}
+	want := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute}
+	for i, w := range want {
+		if got := s.driverRestartBackoff(i + 1); got != w {
+			t.Errorf("tentativa %d: %s, esperava %s", i+1, got, w)
+		}
+	}
+}
+
+// scriptedDriver falha (ou entra em panic) nas primeiras runs e depois fica
+// online até o ctx ser cancelado. onRun roda no início de cada Run.
+type scriptedDriver struct {
+	script *driverScript
+	notify func(drivers.StatusUpdate)
+}
+
+type driverScript struct {
+	mu       sync.Mutex
+	failures int
+	panics   bool
+	onRun    func()
+	starts   []time.Time
+}
+
+func (d *scriptedDriver) SetStatusHandler(fn func(drivers.StatusUpdate)) { d.notify = fn }
+
+func (d *scriptedDriver) Run(ctx context.Context, events chan<- core.AnalyticEvent) error {
+	sc := d.script
+	sc.mu.Lock()
+	sc.starts = append(sc.starts, time.Now())
+	n := len(sc.starts)
+	onRun := sc.onRun
+	sc.mu.Unlock()
+	if onRun != nil {
+		onRun()
+	}
+	if n <= sc.failures {
+		if sc.panics {
+			panic("boom")
+		}
+		return errors.New("auth falhou")
+	}
+	d.notify(drivers.StatusUpdate{State: drivers.ConnectionStateOnline})
+	<-ctx.Done()
+	return nil
+}
+
+func (sc *driverScript) runs() []time.Time {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return append([]time.Time(nil), sc.starts...)
+}
+
+// newRestartSupervisor registra o script como driver do fabricante e devolve a
+// câmera que o usa; restartCounts guarda o restartCount do worker a cada Run.
+func newRestartSupervisor(t *testing.T, manufacturer string, sc *driverScript, delay, maxDelay time.Duration, max int) (*Supervisor, core.CameraInfo, func() []int) {
+	t.Helper()
+	drivers.RegisterDriver(manufacturer, "any", func(info core.CameraInfo) (drivers.CameraDriver, error) {
+		return &scriptedDriver{script: sc}, nil
+	})
+	_, client := newFakeMQTT()
+	s := &Supervisor{
+		mqtt:                  client,
+		baseTopic:             "cams",
+		workers:               map[string]*cameraWorker{},
+		driverRestarts:        map[string]driverRestartState{},
+		driverRestart:         true,
+		driverRestartDelay:    delay,
+		driverRestartMaxDelay: maxDelay,
+		driverRestartMax:      max,
+	}
+	info := core.CameraInfo{Tenant: "t", Building: "b", Floor: "f", DeviceType: "cam", DeviceID: "c1", Manufacturer: manufacturer}
+	key := s.keyFor(info)
+
+	var mu sync.Mutex
+	var counts []int
+	sc.onRun = func() {
+		s.mu.Lock()
+		n := s.workers[key].restartCount
+		s.mu.Unlock()
+		mu.Lock()
+		counts = append(counts, n)
+		mu.Unlock()
+	}
+	t.Cleanup(func() {
+		s.mu.Lock()
+		for _, w := range s.workers {
+			w.cancel()
+		}
+		s.mu.Unlock()
+	})
+	return s, info, func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]int(nil), counts...)
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout esperando %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDriverRestartBacksOffUntilOnline(t *testing.T) {
+	sc := &driverScript{failures: 3}
+	s, info, restartCounts := newRestartSupervisor(t, "flaky-backoff", sc, 20*time.Millisecond, 50*time.Millisecond, 0)
+	key := s.keyFor(info)
+	s.startOrUpdateCamera(info)
+
+	waitFor(t, "driver online", func() bool {
+		w, ok := s.workerSnapshot(key)
+		return ok && w.Status == drivers.ConnectionStateOnline
+	})
+	starts := sc.runs()
+	if len(starts) != 4 {
+		t.Fatalf("runs = %d, esperava 3 falhas + 1 sucesso", len(starts))
+	}
+	// espera dobra a cada falha e para no teto
+	for i, min := range []time.Duration{20 * time.Millisecond, 40 * time.Millisecond, 50 * time.Millisecond} {
+		if gap := starts[i+1].Sub(starts[i]); gap < min {
+			t.Errorf("restart %d após %s, esperava ao menos %s", i+1, gap, min)
+		}
+	}
+	// a contagem passa de um worker para o seguinte e zera ao ficar online
+	if got := restartCounts(); len(got) != 4 || got[0] != 0 || got[1] != 1 || got[2] != 2 || got[3] != 3 {
+		t.Fatalf("restartCount por run = %v, esperava [0 1 2 3]", got)
+	}
+	w, _ := s.workerSnapshot(key)
+	if w.RestartCount != 0 || !w.EverConnected {
+		t.Fatalf("worker online = %+v, esperava restartCount zerado", w)
+	}
+}
+
+func TestDriverRestartGivesUpAfterMax(t *testing.T) {
+	sc := &driverScript{failures: 100}
+	s, info, restartCounts := newRestartSupervisor(t, "flaky-giveup", sc, 5*time.Millisecond, 10*time.Millisecond, 2)
+	key := s.keyFor(info)
+	s.startOrUpdateCamera(info)
+
+	waitFor(t, "desistência", func() bool {
+		w, ok := s.workerSnapshot(key)
+		return ok && w.Status == drivers.ConnectionStateNotEstablished
+	})
+	time.Sleep(50 * time.Millisecond) // nenhum restart depois de desistir
+	if n := len(sc.runs()); n != 3 {
+		t.Fatalf("runs = %d, esperava 1 + DRIVER_EXIT_RESTART_MAX=2", n)
+	}
+	if got := restartCounts(); len(got) != 3 || got[2] != 2 {
+		t.Fatalf("restartCount por run = %v", got)
+	}
+	w, _ := s.workerSnapshot(key)
+	if !strings.Contains(w.StatusReason, "desistindo após 2 restarts") || !strings.Contains(w.LastError, "auth falhou") {
+		t.Fatalf("worker = %+v", w)
+	}
+}
+
+func TestDriverPanicRestartsAsNotEstablished(t *testing.T) {
+	sc := &driverScript{failures: 1, panics: true}
+	s, info, restartCounts := newRestartSupervisor(t, "flaky-panic", sc, 200*time.Millisecond, time.Second, 0)
+	key := s.keyFor(info)
+	s.startOrUpdateCamera(info)
+
+	waitFor(t, "saída por panic", func() bool {
+		w, ok := s.workerSnapshot(key)
+		return ok && w.ExitReason == string(driverExitPanic)
+	})
+	w, _ := s.workerSnapshot(key)
+	if w.Status != drivers.ConnectionStateNotEstablished || !strings.Contains(w.StatusReason, "panic") ||
+		!strings.Contains(w.LastError, "boom") || strings.Contains(w.StatusReason, "sem restart") {
+		t.Fatalf("worker após panic = %+v", w)
+	}
+
+	// restart agendado: o driver volta a rodar e fica online
+	waitFor(t, "restart após panic", func() bool {
+		w, ok := s.workerSnapshot(key)
+		return ok && w.Status == drivers.ConnectionStateOnline
+	})
+	if got := restartCounts(); len(got) != 2 || got[1] != 1 {
+		t.Fatalf("restartCount por run = %v, esperava [0 1]", got)
+	}
+}
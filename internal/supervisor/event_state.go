@@ -0,0 +1,55 @@
+// internal/supervisor/event_state.go
+package supervisor
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+// inactivePolicy decide o que fazer com eventos de fim (EventState=inactive),
+// via EVENT_INACTIVE_POLICY:
+//   - publish (default): publica como antes, com EventState no topo do payload;
+//   - tag: publica com Meta["event_end"]=true e não roda as engines;
+//   - suppress: descarta o evento.
+type inactivePolicy string
+
+const (
+	inactivePublish  inactivePolicy = "publish"
+	inactiveTag      inactivePolicy = "tag"
+	inactiveSuppress inactivePolicy = "suppress"
+)
+
+func inactivePolicyFromEnv() inactivePolicy {
+	raw := strings.ToLower(strings.TrimSpace(os.Getenv("EVENT_INACTIVE_POLICY")))
+	switch p := inactivePolicy(raw); p {
+	case "":
+		return inactivePublish
+	case inactivePublish, inactiveTag, inactiveSuppress:
+		return p
+	}
+	log.Printf("[supervisor] EVENT_INACTIVE_POLICY inválido (%q), usando publish", raw)
+	return inactivePublish
+}
+
+// apply diz se o evento deve ser publicado e se as engines rodam para ele.
+func (p inactivePolicy) apply(evt *core.AnalyticEvent) (publish, runEngines bool) {
+	if evt.EventState != core.EventStateInactive {
+		return true, true
+	}
+	switch p {
+	case inactiveSuppress:
+		return false, false
+	case inactiveTag:
+		meta := cloneMeta(evt.Meta)
+		if meta == nil {
+			meta = map[string]interface{}{}
+		}
+		meta["event_end"] = true
+		evt.Meta = meta
+		return true, false
+	}
+	return true, true
+}
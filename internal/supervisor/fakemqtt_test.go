@@ -0,0 +1,116 @@
+package supervisor
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/sua-org/cam-bus/internal/mqttclient"
+)
+
+// fakeMQTT implementa o mqtt.Client do paho guardando as publicações, para
+// testar o supervisor sem broker.
+type fakeMQTT struct {
+	mu        sync.Mutex
+	published []fakeMessage
+	handlers  map[string]mqtt.MessageHandler
+}
+
+type fakeMessage struct {
+	topic    string
+	qos      byte
+	retained bool
+	payload  []byte
+}
+
+func (m fakeMessage) Duplicate() bool   { return false }
+func (m fakeMessage) Qos() byte         { return m.qos }
+func (m fakeMessage) Retained() bool    { return m.retained }
+func (m fakeMessage) Topic() string     { return m.topic }
+func (m fakeMessage) MessageID() uint16 { return 0 }
+func (m fakeMessage) Payload() []byte   { return m.payload }
+func (m fakeMessage) Ack()              {}
+
+type doneToken struct{}
+
+func (doneToken) Wait() bool                     { return true }
+func (doneToken) WaitTimeout(time.Duration) bool { return true }
+func (doneToken) Done() <-chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
+func (doneToken) Error() error { return nil }
+
+// newFakeMQTT devolve o fake e o mqttclient.Client que publica nele.
+func newFakeMQTT() (*fakeMQTT, *mqttclient.Client) {
+	f := &fakeMQTT{handlers: make(map[string]mqtt.MessageHandler)}
+	return f, mqttclient.Wrap(f, mqttclient.Config{QoS: 1})
+}
+
+func (f *fakeMQTT) IsConnected() bool      { return true }
+func (f *fakeMQTT) IsConnectionOpen() bool { return true }
+func (f *fakeMQTT) Connect() mqtt.Token    { return doneToken{} }
+func (f *fakeMQTT) Disconnect(uint)        {}
+
+func (f *fakeMQTT) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+	var b []byte
+	switch p := payload.(type) {
+	case []byte:
+		b = append([]byte(nil), p...)
+	case string:
+		b = []byte(p)
+	}
+	f.mu.Lock()
+	f.published = append(f.published, fakeMessage{topic: topic, qos: qos, retained: retained, payload: b})
+	f.mu.Unlock()
+	return doneToken{}
+}
+
+func (f *fakeMQTT) Subscribe(topic string, qos byte, callback mqtt.MessageHandler) mqtt.Token {
+	f.mu.Lock()
+	f.handlers[topic] = callback
+	f.mu.Unlock()
+	return doneToken{}
+}
+
+func (f *fakeMQTT) SubscribeMultiple(filters map[string]byte, callback mqtt.MessageHandler) mqtt.Token {
+	for topic, qos := range filters {
+		f.Subscribe(topic, qos, callback)
+	}
+	return doneToken{}
+}
+
+func (f *fakeMQTT) Unsubscribe(topics ...string) mqtt.Token {
+	f.mu.Lock()
+	for _, t := range topics {
+		delete(f.handlers, t)
+	}
+	f.mu.Unlock()
+	return doneToken{}
+}
+
+func (f *fakeMQTT) AddRoute(string, mqtt.MessageHandler) {}
+
+func (f *fakeMQTT) OptionsReader() mqtt.ClientOptionsReader { return mqtt.ClientOptionsReader{} }
+
+// messages devolve as publicações cujo tópico contém substr.
+func (f *fakeMQTT) messages(substr string) []fakeMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []fakeMessage
+	for _, m := range f.published {
+		if strings.Contains(m.topic, substr) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func (f *fakeMQTT) reset() {
+	f.mu.Lock()
+	f.published = nil
+	f.mu.Unlock()
+}
@@ -0,0 +1,215 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/engines"
+)
+
+// namedEngine só existe para o Manager reportar a engine (ex.: findface).
+type namedEngine string
+
+func (e namedEngine) Name() string  { return string(e) }
+func (e namedEngine) Enabled() bool { return true }
+func (e namedEngine) Process(context.Context, core.AnalyticEvent) ([]core.AnalyticEvent, error) {
+	return nil, nil
+}
+
+func discoveryCamera() core.CameraInfo {
+	return core.CameraInfo{
+		Tenant: "t", Building: "b", Floor: "f", DeviceType: "cam", DeviceID: "c1",
+		Analytics: []string{"PeopleCounting"},
+	}
+}
+
+func TestHADiscoveryUsesConfiguredPrefix(t *testing.T) {
+	fake, client := newFakeMQTT()
+	s := &Supervisor{mqtt: client, baseTopic: "cams", haDiscoveryEnabled: true, haDiscoveryPrefix: "ha/custom"}
+
+	if err := s.publishHADiscovery(discoveryCamera()); err != nil {
+		t.Fatal(err)
+	}
+	msgs := fake.messages("/config")
+	if len(msgs) == 0 {
+		t.Fatal("nenhuma entidade de discovery publicada")
+	}
+	for _, m := range msgs {
+		if !strings.HasPrefix(m.topic, "ha/custom/sensor/") {
+			t.Errorf("tópico %q fora do prefixo configurado", m.topic)
+		}
+		if !m.retained {
+			t.Errorf("discovery %q deveria ser retained", m.topic)
+		}
+	}
+	if len(fake.messages("homeassistant/")) != 0 {
+		t.Fatal("não deveria publicar no prefixo padrão")
+	}
+}
+
+func TestHADiscoveryDisabled(t *testing.T) {
+	fake, client := newFakeMQTT()
+	s := &Supervisor{mqtt: client, baseTopic: "cams", haDiscoveryEnabled: false, haDiscoveryPrefix: "homeassistant"}
+
+	if err := s.publishHADiscovery(discoveryCamera()); err != nil {
+		t.Fatal(err)
+	}
+	if msgs := fake.messages(""); len(msgs) != 0 {
+		t.Fatalf("discovery desabilitado publicou %d mensagens", len(msgs))
+	}
+}
+
+func TestHAPeopleCountDiscoveryConfig(t *testing.T) {
+	fake, client := newFakeMQTT()
+	s := &Supervisor{mqtt: client, baseTopic: "cams", haDiscoveryEnabled: true, haDiscoveryPrefix: "homeassistant"}
+	info := discoveryCamera()
+	info.Analytics = []string{"PeopleCounting", "VideoMotion", "crowddetection"}
+
+	if err := s.publishHADiscovery(info); err != nil {
+		t.Fatal(err)
+	}
+	msgs := fake.messages("/config")
+	if len(msgs) != 2 {
+		t.Fatalf("publicadas %d entidades, esperava uma por analítico de contagem", len(msgs))
+	}
+	wantTopics := []string{
+		"homeassistant/sensor/rtls_t_b_f_c1_peoplecounting_count/config",
+		"homeassistant/sensor/rtls_t_b_f_c1_crowddetection_count/config",
+	}
+	wantState := []string{s.eventTopic(info, "PeopleCounting"), s.eventTopic(info, "crowddetection")}
+	for i, m := range msgs {
+		if m.topic != wantTopics[i] || !m.retained {
+			t.Fatalf("discovery %d em %q (retained=%t), esperava %q", i, m.topic, m.retained, wantTopics[i])
+		}
+		var cfg map[string]interface{}
+		if err := json.Unmarshal(m.payload, &cfg); err != nil {
+			t.Fatal(err)
+		}
+		if cfg["state_topic"] != wantState[i] || cfg["json_attributes_topic"] != wantState[i] ||
+			cfg["value_template"] != "{{ value_json.Meta.people_count | default(none) }}" ||
+			cfg["state_class"] != "measurement" || cfg["unit_of_measurement"] != "pessoas" {
+			t.Fatalf("config %s = %v", m.topic, cfg)
+		}
+		uniqueID := strings.TrimSuffix(strings.TrimPrefix(m.topic, "homeassistant/sensor/"), "/config")
+		if cfg["unique_id"] != uniqueID {
+			t.Fatalf("unique_id = %v, esperava %q", cfg["unique_id"], uniqueID)
+		}
+		device, _ := cfg["device"].(map[string]interface{})
+		if ids, _ := device["identifiers"].([]interface{}); len(ids) != 1 || ids[0] != "rtls_camera_rtls_t_b_f_c1" {
+			t.Fatalf("device = %v", cfg["device"])
+		}
+	}
+}
+
+func TestHAPeopleCountDiscoverySkipsCamerasWithoutCounting(t *testing.T) {
+	fake, client := newFakeMQTT()
+	s := &Supervisor{mqtt: client, baseTopic: "cams", haDiscoveryEnabled: true, haDiscoveryPrefix: "homeassistant"}
+	info := discoveryCamera()
+	info.Analytics = []string{"VideoMotion", "faceCapture"}
+
+	if err := s.publishHADiscovery(info); err != nil {
+		t.Fatal(err)
+	}
+	if msgs := fake.messages("/config"); len(msgs) != 0 {
+		t.Fatalf("câmera sem contagem publicou %d entidades", len(msgs))
+	}
+}
+
+func TestHADiscoveryEntitiesCarryAvailability(t *testing.T) {
+	fake, client := newFakeMQTT()
+	s := &Supervisor{
+		mqtt:                       client,
+		baseTopic:                  "cams",
+		collectorAvailabilityTopic: "cams/default/collector/availability",
+		haDiscoveryEnabled:         true,
+		haDiscoveryPrefix:          "homeassistant",
+		engines:                    engines.NewManager([]engines.Engine{namedEngine("findface")}, time.Second),
+	}
+	info := discoveryCamera()
+	info.Analytics = []string{"faceCapture", "PeopleCounting"}
+
+	if err := s.publishHADiscovery(info); err != nil {
+		t.Fatal(err)
+	}
+	msgs := fake.messages("/config")
+	if len(msgs) != 9 {
+		t.Fatalf("publicadas %d entidades, esperava 8 de face e 1 de contagem", len(msgs))
+	}
+	for _, m := range msgs {
+		var cfg struct {
+			Availability []struct {
+				Topic string `json:"topic"`
+			} `json:"availability"`
+			Mode         string `json:"availability_mode"`
+			Available    string `json:"payload_available"`
+			NotAvailable string `json:"payload_not_available"`
+		}
+		if err := json.Unmarshal(m.payload, &cfg); err != nil {
+			t.Fatal(err)
+		}
+		if len(cfg.Availability) != 2 ||
+			cfg.Availability[0].Topic != "cams/t/b/f/cam/c1/availability" ||
+			cfg.Availability[1].Topic != "cams/default/collector/availability" {
+			t.Errorf("%s: availability = %+v, esperava a câmera e o collector", m.topic, cfg.Availability)
+		}
+		if cfg.Mode != "all" || cfg.Available != AvailabilityOnline || cfg.NotAvailable != AvailabilityOffline {
+			t.Errorf("%s: mode=%q available=%q not_available=%q", m.topic, cfg.Mode, cfg.Available, cfg.NotAvailable)
+		}
+	}
+}
+
+func TestHASnapshotURLTemplateFromEnv(t *testing.T) {
+	cases := []struct {
+		from, to string
+		want     string
+	}{
+		{"", "", "{{ value_json.SnapshotURL }}"},
+		{"", "https://ha.example.com", "{{ value_json.SnapshotURL }}"},
+		{" http://minio:9000 ", "https://fotos.example.com", "{{ value_json.SnapshotURL | replace('http://minio:9000', 'https://fotos.example.com') }}"},
+		{"http://minio:9000/", "", "{{ value_json.SnapshotURL | replace('http://minio:9000/', '') }}"},
+		{`http://it's\minio`, "https://x", `{{ value_json.SnapshotURL | replace('http://it\'s\\minio', 'https://x') }}`},
+	}
+	for _, tc := range cases {
+		t.Setenv("HA_SNAPSHOT_URL_FROM", tc.from)
+		t.Setenv("HA_SNAPSHOT_URL_TO", tc.to)
+		if got := haSnapshotURLTemplate(haSnapshotURLRewriteFromEnv()); got != tc.want {
+			t.Errorf("FROM=%q TO=%q: %s, esperava %s", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
+
+func TestHASnapshotEntityUsesURLRewrite(t *testing.T) {
+	fake, client := newFakeMQTT()
+	s := &Supervisor{
+		mqtt:               client,
+		baseTopic:          "cams",
+		haDiscoveryEnabled: true,
+		haDiscoveryPrefix:  "homeassistant",
+		haSnapshotURLFrom:  "http://minio:9000",
+		haSnapshotURLTo:    "https://fotos.example.com",
+		engines:            engines.NewManager([]engines.Engine{namedEngine("findface")}, time.Second),
+	}
+	info := discoveryCamera()
+	info.Analytics = []string{"faceCapture"}
+	if err := s.publishHADiscovery(info); err != nil {
+		t.Fatal(err)
+	}
+	msgs := fake.messages("homeassistant/image/rtls_t_b_f_c1_face_snapshot/config")
+	if len(msgs) != 1 {
+		t.Fatalf("entidade de snapshot publicada %d vezes", len(msgs))
+	}
+	var cfg map[string]interface{}
+	if err := json.Unmarshal(msgs[0].payload, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg["url_template"] != "{{ value_json.SnapshotURL | replace('http://minio:9000', 'https://fotos.example.com') }}" {
+		t.Fatalf("url_template = %v", cfg["url_template"])
+	}
+	if cfg["url_topic"] != s.faceStateTopic(info) {
+		t.Fatalf("url_topic = %v", cfg["url_topic"])
+	}
+}
@@ -0,0 +1,77 @@
+// internal/supervisor/heartbeat.go
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/eventid"
+	"github.com/sua-org/cam-bus/internal/logging"
+)
+
+// heartbeatAnalytic é o analítico usado no tópico de eventos para o keepalive.
+const heartbeatAnalytic = "heartbeat"
+
+// runHeartbeat publica periodicamente um evento "heartbeat" da câmera no
+// tópico de eventos, para consumidores que só escutam events detectarem silêncio.
+func (s *Supervisor) runHeartbeat(ctx context.Context, key string, info core.CameraInfo) {
+	ticker := time.NewTicker(s.heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-ticker.C:
+			snap, ok := s.workerSnapshot(key)
+			if !ok {
+				return
+			}
+			if err := s.publishHeartbeat(info, snap, t.UTC()); err != nil {
+				logging.With(logging.Camera(key, info.Tenant)).Errorf("[worker %s] erro ao publicar heartbeat: %v", key, err)
+			}
+		}
+	}
+}
+
+func (s *Supervisor) publishHeartbeat(info core.CameraInfo, snap workerSnapshot, now time.Time) error {
+	meta := map[string]interface{}{
+		"status":         string(snap.Status),
+		"ever_connected": snap.EverConnected,
+		"reconnects":     snap.Reconnects,
+	}
+	if !snap.StatusSince.IsZero() {
+		meta["status_since"] = snap.StatusSince.UTC().Format(time.RFC3339)
+	}
+	if !snap.LastEventAt.IsZero() {
+		meta["last_event_at"] = snap.LastEventAt.UTC().Format(time.RFC3339)
+		meta["last_event_age_seconds"] = int64(now.Sub(snap.LastEventAt).Seconds())
+	}
+
+	evt := core.AnalyticEvent{
+		Timestamp:    now,
+		EventID:      eventid.New("heartbeat-"+info.DeviceID, now),
+		CameraIP:     info.IP,
+		CameraName:   info.Name,
+		AnalyticType: heartbeatAnalytic,
+		Tenant:       info.Tenant,
+		Building:     info.Building,
+		Floor:        info.Floor,
+		DeviceType:   info.DeviceType,
+		DeviceID:     info.DeviceID,
+		Meta:         meta,
+	}
+
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("marshal heartbeat: %w", err)
+	}
+	topic := s.eventTopic(info, heartbeatAnalytic)
+	if err := s.mqtt.Publish(topic, 0, false, payload); err != nil {
+		return fmt.Errorf("publish heartbeat to %s: %w", topic, err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/drivers"
+)
+
+func TestHeartbeatPublishesOnInterval(t *testing.T) {
+	fake, client := newFakeMQTT()
+	s := &Supervisor{
+		mqtt:              client,
+		baseTopic:         "cams",
+		workers:           map[string]*cameraWorker{},
+		heartbeatInterval: 20 * time.Millisecond,
+	}
+	info := core.CameraInfo{Tenant: "t", Building: "b", Floor: "f", DeviceType: "cam", DeviceID: "c1", IP: "10.0.0.1"}
+	key := s.keyFor(info)
+	since := time.Now().Add(-time.Minute)
+	s.workers[key] = &cameraWorker{
+		info:          info,
+		status:        drivers.ConnectionStateOnline,
+		statusSince:   since,
+		everConnected: true,
+		lastEventAt:   time.Now().Add(-10 * time.Second),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.runHeartbeat(ctx, key, info)
+		close(done)
+	}()
+
+	time.Sleep(110 * time.Millisecond)
+	cancel()
+	<-done
+
+	msgs := fake.messages("/heartbeat/")
+	if n := len(msgs); n < 3 || n > 6 {
+		t.Fatalf("%d heartbeats em ~110ms com intervalo 20ms", n)
+	}
+	count := len(msgs)
+	time.Sleep(50 * time.Millisecond)
+	if len(fake.messages("/heartbeat/")) != count {
+		t.Fatal("heartbeat continuou depois do cancelamento")
+	}
+
+	m := msgs[0]
+	if m.topic != "cams/t/b/f/cam/c1/heartbeat/events" || m.retained || m.qos != 0 {
+		t.Fatalf("publicação %s retained=%v qos=%d", m.topic, m.retained, m.qos)
+	}
+	var evt core.AnalyticEvent
+	if err := json.Unmarshal(m.payload, &evt); err != nil {
+		t.Fatal(err)
+	}
+	if evt.AnalyticType != heartbeatAnalytic || evt.DeviceID != "c1" || evt.CameraIP != "10.0.0.1" || evt.EventID == "" {
+		t.Fatalf("evento heartbeat = %+v", evt)
+	}
+	if evt.Meta["status"] != "online" || evt.Meta["ever_connected"] != true {
+		t.Fatalf("Meta = %v", evt.Meta)
+	}
+	if age, _ := evt.Meta["last_event_age_seconds"].(float64); age < 10 {
+		t.Fatalf("last_event_age_seconds = %v, esperava >= 10", evt.Meta["last_event_age_seconds"])
+	}
+}
+
+func TestHeartbeatStopsWhenWorkerRemoved(t *testing.T) {
+	fake, client := newFakeMQTT()
+	s := &Supervisor{mqtt: client, baseTopic: "cams", workers: map[string]*cameraWorker{}, heartbeatInterval: 10 * time.Millisecond}
+	info := core.CameraInfo{DeviceID: "c1"}
+
+	done := make(chan struct{})
+	go func() {
+		s.runHeartbeat(context.Background(), s.keyFor(info), info)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("runHeartbeat não saiu sem o worker")
+	}
+	if len(fake.messages("heartbeat")) != 0 {
+		t.Fatal("heartbeat publicado para worker inexistente")
+	}
+}
@@ -0,0 +1,185 @@
+// internal/supervisor/inventory.go
+package supervisor
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/admin"
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+const redactedSecret = "***"
+
+// inventoryEntry é uma câmera no export de GET /inventory.
+type inventoryEntry struct {
+	Key          string           `json:"key"`
+	Camera       core.CameraInfo  `json:"camera"` // senha e credenciais de URL redigidas
+	Status       string           `json:"status"`
+	StatusSince  *time.Time       `json:"status_since,omitempty"`
+	StatusReason string           `json:"status_reason,omitempty"`
+	LastEventAt  *time.Time       `json:"last_event_at,omitempty"`
+	Analytics    []string         `json:"active_analytics,omitempty"`
+	Reconnects   int              `json:"reconnects"`
+	Uplink       *inventoryUplink `json:"uplink,omitempty"`
+	MediaMTXPath string           `json:"mediamtx_path,omitempty"`
+	CentralPath  string           `json:"mediamtx_central_path,omitempty"`
+}
+
+type inventoryUplink struct {
+	Active         bool   `json:"active"`
+	ProxyPath      string `json:"proxy_path,omitempty"`
+	CentralHost    string `json:"central_host,omitempty"`
+	CentralPath    string `json:"central_path,omitempty"`
+	CentralSRTPort int    `json:"central_srt_port,omitempty"`
+	State          string `json:"state,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// inventory monta o export a partir dos mapas do supervisor e dos geradores
+// do MediaMTX. Câmeras sem worker (desabilitadas, aguardando start) aparecem
+// com status "stopped".
+func (s *Supervisor) inventory() []inventoryEntry {
+	s.mu.Lock()
+	out := make([]inventoryEntry, 0, len(s.cameras))
+	for key, info := range s.cameras {
+		entry := inventoryEntry{
+			Key:          key,
+			Camera:       redactCameraInfo(info),
+			Status:       "stopped",
+			MediaMTXPath: s.mtxGen.PathFor(info),
+			CentralPath:  s.mtxCentralGen.PathFor(info),
+		}
+		if w, ok := s.workers[key]; ok {
+			entry.Status = string(w.status)
+			entry.StatusSince = timePtr(w.statusSince)
+			entry.StatusReason = w.statusReason
+			entry.LastEventAt = timePtr(w.lastEventAt)
+			entry.Analytics = append([]string(nil), w.analytics...)
+			entry.Reconnects = w.reconnects
+		}
+		state, active := s.uplinkStates[key]
+		status, hasStatus := s.uplinkStatus[key]
+		if active || hasStatus {
+			entry.Uplink = &inventoryUplink{Active: active}
+			if active {
+				entry.Uplink.ProxyPath = state.proxyPath
+				entry.Uplink.CentralHost = state.centralHost
+				entry.Uplink.CentralPath = state.centralPath
+				entry.Uplink.CentralSRTPort = state.centralSRTPort
+			}
+			if hasStatus {
+				entry.Uplink.State = status.State
+				entry.Uplink.Error = status.Error
+			}
+		}
+		out = append(out, entry)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// redactCameraInfo tira a senha, credenciais embutidas em URLs (RTSP, snapshot
+// e motion do poll) e os campos fixos do login de sessão.
+func redactCameraInfo(info core.CameraInfo) core.CameraInfo {
+	if info.Password != "" {
+		info.Password = redactedSecret
+	}
+	info.RTSPURL = redactURLCredentials(info.RTSPURL)
+	info.SnapshotPath = redactURLCredentials(info.SnapshotPath)
+	info.MotionPath = redactURLCredentials(info.MotionPath)
+	if info.SessionLogin != nil {
+		login := *info.SessionLogin
+		if len(login.Extra) > 0 {
+			login.Extra = make(map[string]string, len(info.SessionLogin.Extra))
+			for k := range info.SessionLogin.Extra {
+				login.Extra[k] = redactedSecret
+			}
+		}
+		info.SessionLogin = &login
+	}
+	return info
+}
+
+// redactURLCredentials troca a senha do userinfo por "***"; userinfo só com
+// usuário (token no lugar do usuário) é trocado inteiro. O "***" vai literal
+// (url.UserPassword o escaparia como %2A%2A%2A).
+func redactURLCredentials(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil || u.Scheme == "" {
+		return raw
+	}
+	userinfo := redactedSecret
+	if _, hasPass := u.User.Password(); hasPass {
+		userinfo = url.User(u.User.Username()).String() + ":" + redactedSecret
+	}
+	u.User = nil
+	return strings.Replace(u.String(), "://", "://"+userinfo+"@", 1)
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// inventoryHandler atende GET /inventory?format=json|csv (default json).
+func (s *Supervisor) inventoryHandler(w http.ResponseWriter, r *http.Request) {
+	entries := s.inventory()
+	switch strings.ToLower(r.URL.Query().Get("format")) {
+	case "", "json":
+		admin.WriteJSON(w, http.StatusOK, entries)
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="cam-bus-inventory.csv"`)
+		writeInventoryCSV(w, entries)
+	default:
+		admin.WriteError(w, http.StatusBadRequest, fmt.Errorf("format deve ser json ou csv"))
+	}
+}
+
+var inventoryCSVHeader = []string{
+	"key", "tenant", "building", "floor", "device_type", "device_id", "name",
+	"manufacturer", "model", "ip", "port", "use_tls", "enabled", "shard",
+	"status", "status_since", "status_reason", "last_event_at", "active_analytics",
+	"reconnects", "rtsp_url", "mediamtx_path", "mediamtx_central_path",
+	"uplink_active", "uplink_state", "uplink_central_host", "uplink_central_path",
+}
+
+func writeInventoryCSV(w http.ResponseWriter, entries []inventoryEntry) {
+	cw := csv.NewWriter(w)
+	_ = cw.Write(inventoryCSVHeader)
+	for _, e := range entries {
+		c := e.Camera
+		var upActive, upState, upHost, upPath string
+		if e.Uplink != nil {
+			upActive = strconv.FormatBool(e.Uplink.Active)
+			upState, upHost, upPath = e.Uplink.State, e.Uplink.CentralHost, e.Uplink.CentralPath
+		}
+		_ = cw.Write([]string{
+			e.Key, c.Tenant, c.Building, c.Floor, c.DeviceType, c.DeviceID, c.Name,
+			c.Manufacturer, c.Model, c.IP, strconv.Itoa(c.Port), strconv.FormatBool(c.UseTLS),
+			strconv.FormatBool(c.Enabled), c.Shard,
+			e.Status, formatTimePtr(e.StatusSince), e.StatusReason, formatTimePtr(e.LastEventAt),
+			strings.Join(e.Analytics, ";"), strconv.Itoa(e.Reconnects), c.RTSPURL,
+			e.MediaMTXPath, e.CentralPath, upActive, upState, upHost, upPath,
+		})
+	}
+	cw.Flush()
+}
+
+func formatTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,145 @@
+// internal/supervisor/last_face.go
+package supervisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/envconf"
+)
+
+const defaultLastFaceTTL = 24 * time.Hour
+
+// lastFaceRetainer guarda o último faceRecognized de cada câmera como retained
+// em <...>/<id>/faceRecognized/last (FACE_LAST_RETAINED), para os sensores do
+// HA mostrarem a última pessoa reconhecida depois de um restart. O evento ao
+// vivo continua não-retained. Passado FACE_LAST_RETAINED_TTL_SECONDS do
+// Timestamp do evento, o retained é apagado (payload vazio).
+type lastFaceRetainer struct {
+	ttl     time.Duration
+	publish func(topic string, payload []byte) error
+	now     func() time.Time
+
+	mu     sync.Mutex
+	timers map[string]*time.Timer // tópico -> expiração agendada
+}
+
+// newLastFaceRetainerFromEnv devolve nil quando FACE_LAST_RETAINED está desligado.
+func newLastFaceRetainerFromEnv(publish func(topic string, payload []byte) error) *lastFaceRetainer {
+	if !envconf.Bool("FACE_LAST_RETAINED", false) {
+		return nil
+	}
+	r := &lastFaceRetainer{
+		ttl:     envconf.Seconds("FACE_LAST_RETAINED_TTL_SECONDS", defaultLastFaceTTL),
+		publish: publish,
+		now:     time.Now,
+		timers:  make(map[string]*time.Timer),
+	}
+	log.Printf("[supervisor] último faceRecognized retained por câmera (expira em %s)", r.ttl)
+	return r
+}
+
+func (s *Supervisor) lastFaceTopic(info core.CameraInfo) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s/%s/faceRecognized/last",
+		s.baseTopic, info.Tenant, info.Building, info.Floor, info.DeviceType, info.DeviceID)
+}
+
+// faceStateTopic é o tópico de onde os sensores de face do HA leem: o retained
+// do último reconhecimento quando ligado, senão o tópico ao vivo.
+func (s *Supervisor) faceStateTopic(info core.CameraInfo) string {
+	if s.lastFace != nil {
+		return s.lastFaceTopic(info)
+	}
+	return s.eventTopic(info, "faceRecognized")
+}
+
+// store publica o payload retained e agenda a expiração a partir de ts.
+func (r *lastFaceRetainer) store(topic string, ts time.Time, payload []byte) {
+	if r == nil {
+		return
+	}
+	if err := r.publish(topic, payload); err != nil {
+		log.Printf("[supervisor] erro ao publicar último faceRecognized em %s: %v", topic, err)
+		return
+	}
+	r.schedule(topic, ts)
+}
+
+// handleRetained recebe os retained já existentes no broker (inclusive de
+// antes do restart) para reagendar, ou apagar, os que já passaram do TTL.
+func (r *lastFaceRetainer) handleRetained(topic string, payload []byte) {
+	if len(payload) == 0 {
+		return // retained apagado (eco do clear)
+	}
+	var evt struct {
+		Timestamp time.Time `json:"Timestamp"`
+	}
+	if err := json.Unmarshal(payload, &evt); err != nil || evt.Timestamp.IsZero() {
+		log.Printf("[supervisor] último faceRecognized ilegível em %s, apagando", topic)
+		r.clear(topic)
+		return
+	}
+	r.schedule(topic, evt.Timestamp)
+}
+
+func (r *lastFaceRetainer) schedule(topic string, ts time.Time) {
+	if ts.IsZero() {
+		ts = r.now()
+	}
+	remaining := ts.Add(r.ttl).Sub(r.now())
+	if remaining <= 0 {
+		r.clear(topic)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t, ok := r.timers[topic]; ok {
+		t.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(remaining, func() {
+		r.mu.Lock()
+		current := r.timers[topic] == timer
+		if current {
+			delete(r.timers, topic)
+		}
+		r.mu.Unlock()
+		if current {
+			r.clear(topic)
+		}
+	})
+	r.timers[topic] = timer
+}
+
+func (r *lastFaceRetainer) clear(topic string) {
+	r.mu.Lock()
+	if t, ok := r.timers[topic]; ok {
+		t.Stop()
+		delete(r.timers, topic)
+	}
+	r.mu.Unlock()
+	if err := r.publish(topic, nil); err != nil {
+		log.Printf("[supervisor] erro ao expirar último faceRecognized em %s: %v", topic, err)
+		return
+	}
+	log.Printf("[supervisor] último faceRecognized expirado em %s", topic)
+}
+
+// stop cancela as expirações pendentes (shutdown); os retained ficam no
+// broker e são reagendados no próximo start via handleRetained.
+func (r *lastFaceRetainer) stop() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for topic, t := range r.timers {
+		t.Stop()
+		delete(r.timers, topic)
+	}
+}
@@ -0,0 +1,116 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func newLastFaceSupervisor(t *testing.T, ttl time.Duration) (*Supervisor, *fakeMQTT) {
+	t.Helper()
+	fake, client := newFakeMQTT()
+	s := &Supervisor{mqtt: client, baseTopic: "cams"}
+	s.lastFace = &lastFaceRetainer{
+		ttl:     ttl,
+		publish: func(topic string, payload []byte) error { return client.Publish(topic, 1, true, payload) },
+		now:     time.Now,
+		timers:  make(map[string]*time.Timer),
+	}
+	t.Cleanup(s.lastFace.stop)
+	return s, fake
+}
+
+func TestLastFaceRetainedAndExpired(t *testing.T) {
+	s, fake := newLastFaceSupervisor(t, 50*time.Millisecond)
+	info := core.CameraInfo{Tenant: "t", Building: "b", Floor: "f", DeviceType: "cam", DeviceID: "c1"}
+	key := s.keyFor(info)
+	last := "cams/t/b/f/cam/c1/faceRecognized/last"
+	if got := s.faceStateTopic(info); got != last {
+		t.Fatalf("faceStateTopic = %q, esperava %q", got, last)
+	}
+
+	s.publishDerivedEvents(context.Background(), key, info, []core.AnalyticEvent{
+		{EventID: "e1", AnalyticType: "faceRecognized", Timestamp: time.Now(), Meta: map[string]interface{}{"ff_card_id": 7}},
+		{EventID: "e2", AnalyticType: "faceRecognized", EventState: core.EventStateInactive, Timestamp: time.Now()},
+	})
+
+	msgs := fake.messages("/faceRecognized/last")
+	if len(msgs) != 1 || msgs[0].topic != last || !msgs[0].retained {
+		t.Fatalf("retained publicados = %+v, esperava só o evento ativo", msgs)
+	}
+	var evt core.AnalyticEvent
+	if err := json.Unmarshal(msgs[0].payload, &evt); err != nil || evt.EventID != "e1" {
+		t.Fatalf("payload retained = %s (%v)", msgs[0].payload, err)
+	}
+	// o evento ao vivo continua não-retained
+	for _, m := range fake.messages("/faceRecognized") {
+		if m.topic != last && m.retained {
+			t.Fatalf("evento ao vivo publicado como retained em %s", m.topic)
+		}
+	}
+
+	waitFor(t, "expiração do último faceRecognized", func() bool {
+		return len(fake.messages("/faceRecognized/last")) == 2
+	})
+	clear := fake.messages("/faceRecognized/last")[1]
+	if clear.topic != last || !clear.retained || len(clear.payload) != 0 {
+		t.Fatalf("clear = %+v, esperava payload vazio retained", clear)
+	}
+}
+
+func TestLastFaceHandleRetained(t *testing.T) {
+	s, fake := newLastFaceSupervisor(t, time.Hour)
+	r := s.lastFace
+	topic := "cams/t/b/f/cam/c1/faceRecognized/last"
+
+	// eco do próprio clear: ignorado
+	r.handleRetained(topic, nil)
+	// ainda dentro do TTL: só reagenda
+	fresh, _ := json.Marshal(core.AnalyticEvent{Timestamp: time.Now().Add(-time.Minute)})
+	r.handleRetained(topic, fresh)
+	if n := len(fake.messages(topic)); n != 0 {
+		t.Fatalf("publicados = %d, esperava nenhum", n)
+	}
+	r.mu.Lock()
+	_, scheduled := r.timers[topic]
+	r.mu.Unlock()
+	if !scheduled {
+		t.Fatal("retained dentro do TTL deveria ter a expiração reagendada")
+	}
+
+	// de antes do restart e já vencido: apagado na hora
+	stale, _ := json.Marshal(core.AnalyticEvent{Timestamp: time.Now().Add(-2 * time.Hour)})
+	r.handleRetained(topic, stale)
+	// ilegível: apagado
+	r.handleRetained(topic, []byte("lixo"))
+	msgs := fake.messages(topic)
+	if len(msgs) != 2 || len(msgs[0].payload) != 0 || len(msgs[1].payload) != 0 {
+		t.Fatalf("clears = %+v", msgs)
+	}
+}
+
+func TestLastFaceRetainerFromEnv(t *testing.T) {
+	t.Setenv("FACE_LAST_RETAINED", "")
+	if r := newLastFaceRetainerFromEnv(nil); r != nil {
+		t.Fatal("FACE_LAST_RETAINED desligado deveria devolver nil")
+	}
+	s := &Supervisor{baseTopic: "cams"}
+	info := core.CameraInfo{Tenant: "t", Building: "b", Floor: "f", DeviceType: "cam", DeviceID: "c1"}
+	if got, live := s.faceStateTopic(info), s.eventTopic(info, "faceRecognized"); got != live {
+		t.Fatalf("sem retainer os sensores leem o tópico ao vivo %q, veio %q", live, got)
+	}
+
+	t.Setenv("FACE_LAST_RETAINED", "true")
+	t.Setenv("FACE_LAST_RETAINED_TTL_SECONDS", "90")
+	r := newLastFaceRetainerFromEnv(nil)
+	if r == nil || r.ttl != 90*time.Second {
+		t.Fatalf("retainer = %+v", r)
+	}
+	t.Setenv("FACE_LAST_RETAINED_TTL_SECONDS", "-1")
+	if r := newLastFaceRetainerFromEnv(nil); r.ttl != defaultLastFaceTTL {
+		t.Fatalf("TTL inválido: %s, esperava o default", r.ttl)
+	}
+}
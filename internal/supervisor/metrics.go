@@ -0,0 +1,91 @@
+// internal/supervisor/metrics.go
+package supervisor
+
+import (
+	"strings"
+
+	"github.com/sua-org/cam-bus/internal/metrics"
+	"github.com/sua-org/cam-bus/internal/storage"
+)
+
+const (
+	metricEventsPublished = "cambus.events.published"
+	metricPublishErrors   = "cambus.events.publish_errors"
+	metricEventsDeduped   = "cambus.events.deduplicated"
+	metricEventsThrottled = "cambus.events.rate_limited"
+	metricReconnects      = "cambus.camera.reconnects"
+	metricDriverRestarts  = "cambus.driver.restarts"
+	metricCameras         = "cambus.cameras"
+	metricEngineInFlight  = "cambus.engine.in_flight"
+	metricEngineQueue     = "cambus.engine.queue_depth"
+	metricSnapshotsReject = "cambus.snapshots.rejected"
+	metricPublishQueue    = "cambus.mqtt.publish_queue"
+	metricPublishDropped  = "cambus.mqtt.publish_dropped"
+	metricPublishFailed   = "cambus.mqtt.publish_failed"
+)
+
+// registerMetrics registra os instrumentos de câmera/evento/engine exportados
+// via METRICS_EXPORTER. Os valores são os mesmos dos payloads de status.
+func (s *Supervisor) registerMetrics() {
+	reg := s.metrics
+	reg.Counter(metricEventsPublished, "Eventos publicados no MQTT", "{event}")
+	reg.Counter(metricPublishErrors, "Falhas ao publicar eventos no MQTT", "{event}")
+	reg.Counter(metricEventsDeduped, "Eventos duplicados descartados (CAMBUS_DEDUP_WINDOW_MS)", "{event}")
+	reg.Counter(metricEventsThrottled, "Eventos descartados acima do limite por câmera (CAMBUS_MAX_EVENTS_PER_SEC)", "{event}")
+	reg.Counter(metricReconnects, "Reconexões de drivers de câmera", "{reconnect}")
+	reg.Counter(metricDriverRestarts, "Restarts de drivers que saíram sem cancelamento (DRIVER_EXIT_RESTART)", "{restart}")
+
+	reg.Gauge(metricCameras, "Câmeras com worker ativo por estado de conexão", "{camera}", func() []metrics.Point {
+		counts := map[string]float64{}
+		for _, w := range s.snapshotWorkers() {
+			counts[string(w.Status)]++
+		}
+		out := make([]metrics.Point, 0, len(counts))
+		for status, n := range counts {
+			out = append(out, metrics.Point{Attrs: map[string]string{"status": status}, Value: n})
+		}
+		return out
+	})
+	reg.Gauge(metricEngineInFlight, "Requisições em andamento por engine", "{request}", func() []metrics.Point {
+		var out []metrics.Point
+		for name, load := range s.engines.Load() {
+			out = append(out, metrics.Point{Attrs: map[string]string{"engine": name}, Value: float64(load.InFlight)})
+		}
+		return out
+	})
+	reg.Gauge(metricEngineQueue, "Eventos aguardando em cada engine", "{event}", func() []metrics.Point {
+		var out []metrics.Point
+		for name, load := range s.engines.Load() {
+			out = append(out, metrics.Point{Attrs: map[string]string{"engine": name}, Value: float64(load.QueueDepth)})
+		}
+		return out
+	})
+	// acumulado desde o start (SNAPSHOT_VALIDATE); lido do storage na coleta
+	reg.Gauge(metricSnapshotsReject, "Snapshots corrompidos descartados pela validação", "{image}", func() []metrics.Point {
+		return []metrics.Point{{Value: float64(storage.RejectedSnapshots())}}
+	})
+	if p := s.eventPublisher; p != nil {
+		reg.Gauge(metricPublishQueue, "Eventos aguardando na fila de publicação MQTT", "{event}", func() []metrics.Point {
+			return []metrics.Point{{Value: float64(p.Len())}}
+		})
+		// acumulado desde o start (MQTT_PUBLISH_ON_FULL=drop)
+		reg.Gauge(metricPublishDropped, "Eventos descartados com a fila de publicação cheia", "{event}", func() []metrics.Point {
+			return []metrics.Point{{Value: float64(p.Dropped())}}
+		})
+		reg.Gauge(metricPublishFailed, "Eventos que a fila não conseguiu publicar no broker", "{event}", func() []metrics.Point {
+			return []metrics.Point{{Value: float64(p.Failed())}}
+		})
+	}
+}
+
+func (s *Supervisor) countPublished(analyticType, source string, err error) {
+	attrs := map[string]string{
+		"analytic_type": strings.TrimSpace(analyticType),
+		"source":        source,
+	}
+	if err != nil {
+		s.metrics.Add(metricPublishErrors, 1, attrs)
+		return
+	}
+	s.metrics.Add(metricEventsPublished, 1, attrs)
+}
@@ -0,0 +1,107 @@
+// internal/supervisor/mtx_debounce.go
+package supervisor
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/envconf"
+)
+
+const (
+	defaultMTXSyncDebounce = 300 * time.Millisecond
+	mtxSyncMaxWaitFactor   = 10
+)
+
+// debouncer junta chamadas em rajada numa só execução (trailing edge): fn roda
+// wait depois do último Trigger, mas nunca mais que maxWait depois do primeiro
+// da rajada. Triggers durante a execução agendam uma nova rodada.
+type debouncer struct {
+	wait    time.Duration
+	maxWait time.Duration
+	fn      func()
+
+	mu    sync.Mutex
+	timer *time.Timer
+	first time.Time
+	gen   int // invalida o timer que o Flush já cancelou
+
+	runMu sync.Mutex // uma execução de fn por vez
+}
+
+// mtxSyncDebounceFromEnv lê MTX_SYNC_DEBOUNCE (duração Go ou segundos;
+// default 300ms, 0 desliga).
+func mtxSyncDebounceFromEnv() time.Duration {
+	if _, ok := os.LookupEnv("MTX_SYNC_DEBOUNCE"); !ok {
+		return defaultMTXSyncDebounce
+	}
+	return envconf.Duration("MTX_SYNC_DEBOUNCE", 0)
+}
+
+func newDebouncer(wait time.Duration, fn func()) *debouncer {
+	return &debouncer{wait: wait, maxWait: wait * mtxSyncMaxWaitFactor, fn: fn}
+}
+
+// Trigger agenda fn; com wait <= 0 roda na hora (comportamento anterior).
+func (d *debouncer) Trigger() {
+	if d.wait <= 0 {
+		d.run()
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	if d.timer == nil {
+		d.first = now
+		gen := d.gen
+		d.timer = time.AfterFunc(d.wait, func() { d.fire(gen) })
+		return
+	}
+	delay := d.wait
+	if deadline := d.first.Add(d.maxWait); now.Add(delay).After(deadline) {
+		delay = max(deadline.Sub(now), 0)
+	}
+	d.timer.Reset(delay)
+}
+
+func (d *debouncer) fire(gen int) {
+	d.mu.Lock()
+	if gen != d.gen || d.timer == nil {
+		d.mu.Unlock()
+		return
+	}
+	d.timer = nil
+	d.mu.Unlock()
+	d.run()
+}
+
+// Flush cancela o timer e, se havia execução pendente, roda fn na hora,
+// esperando alguma que já esteja em andamento (shutdown).
+func (d *debouncer) Flush() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	pending := d.timer != nil
+	if pending {
+		d.timer.Stop()
+		d.timer = nil
+		d.gen++
+	}
+	d.mu.Unlock()
+	if pending {
+		d.run()
+		return
+	}
+	// espera uma execução disparada pelo timer que ainda esteja rodando
+	d.runMu.Lock()
+	d.runMu.Unlock()
+}
+
+func (d *debouncer) run() {
+	d.runMu.Lock()
+	defer d.runMu.Unlock()
+	d.fn()
+}
@@ -0,0 +1,130 @@
+package supervisor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/mediamtx"
+)
+
+func TestDebouncerCollapsesBurst(t *testing.T) {
+	var runs atomic.Int64
+	d := newDebouncer(30*time.Millisecond, func() { runs.Add(1) })
+
+	for i := 0; i < 20; i++ {
+		d.Trigger()
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := runs.Load(); n != 1 {
+		t.Fatalf("fn rodou %d vezes, esperava 1 para a rajada", n)
+	}
+
+	// Trigger depois da execução abre outra rodada
+	d.Trigger()
+	time.Sleep(100 * time.Millisecond)
+	if n := runs.Load(); n != 2 {
+		t.Fatalf("fn rodou %d vezes, esperava 2", n)
+	}
+}
+
+func TestDebouncerFlushRunsPending(t *testing.T) {
+	var runs atomic.Int64
+	d := newDebouncer(time.Hour, func() { runs.Add(1) })
+
+	d.Flush()
+	if n := runs.Load(); n != 0 {
+		t.Fatalf("Flush sem pendência rodou fn %d vezes", n)
+	}
+	d.Trigger()
+	d.Trigger()
+	d.Flush()
+	if n := runs.Load(); n != 1 {
+		t.Fatalf("Flush com pendência rodou fn %d vezes, esperava 1", n)
+	}
+	d.Flush()
+	if n := runs.Load(); n != 1 {
+		t.Fatal("segundo Flush não deveria rodar fn de novo")
+	}
+
+	var nilDebouncer *debouncer
+	nilDebouncer.Flush()
+}
+
+// newMTXSupervisor liga o supervisor a um Generator que escreve num arquivo
+// temporário (sem reload configurado) e conta os Syncs efetivos.
+func newMTXSupervisor(t *testing.T, wait time.Duration) (*Supervisor, string, *atomic.Int64) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mediamtx.yml")
+	t.Setenv("MTX_PROXY_CONFIG_PATH", path)
+	s := newStateSupervisor(t)
+	s.state = nil
+	s.mtxGen = mediamtx.NewGeneratorFromEnv()
+	var syncs atomic.Int64
+	s.mtxSync = newDebouncer(wait, func() {
+		syncs.Add(1)
+		s.syncMediaMTX()
+	})
+	return s, path, &syncs
+}
+
+func mtxCamera(id string) core.CameraInfo {
+	info := stateCamera(id)
+	info.RTSPURL = "rtsp://10.0.0.1/" + id
+	return info
+}
+
+func TestRefreshMediaMTXConfigCollapsesIntoOneSync(t *testing.T) {
+	s, path, syncs := newMTXSupervisor(t, 30*time.Millisecond)
+
+	for _, id := range []string{"c1", "c2", "c3", "c4", "c5"} {
+		info := mtxCamera(id)
+		s.mu.Lock()
+		s.cameras[s.keyFor(info)] = info
+		s.mu.Unlock()
+		s.refreshMediaMTXConfig()
+	}
+	time.Sleep(150 * time.Millisecond)
+
+	if n := syncs.Load(); n != 1 {
+		t.Fatalf("Syncs = %d, esperava 1 para 5 refreshes seguidos", n)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"c1", "c5"} {
+		if !strings.Contains(string(data), "rtsp://10.0.0.1/"+id) {
+			t.Fatalf("config sem a câmera %s:\n%s", id, data)
+		}
+	}
+}
+
+func TestShutdownFlushesMediaMTXSync(t *testing.T) {
+	s, path, syncs := newMTXSupervisor(t, time.Hour)
+	info := mtxCamera("c1")
+	s.cameras[s.keyFor(info)] = info
+	s.syncMediaMTX()
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "rtsp://10.0.0.1/c1") {
+		t.Fatalf("config inicial sem a câmera:\n%s", data)
+	}
+
+	// mesma sequência do Run: stopAll só arma o timer, o Flush aplica
+	s.stopAll()
+	if n := syncs.Load(); n != 0 {
+		t.Fatalf("com debounce o stopAll não deveria sincronizar na hora (syncs=%d)", n)
+	}
+	s.mtxSync.Flush()
+	if n := syncs.Load(); n != 1 {
+		t.Fatalf("Flush deveria rodar o Sync pendente (syncs=%d)", n)
+	}
+	data, _ = os.ReadFile(path)
+	if strings.Contains(string(data), "rtsp://10.0.0.1/c1") {
+		t.Fatalf("path da câmera parada continua na config:\n%s", data)
+	}
+}
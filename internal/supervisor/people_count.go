@@ -0,0 +1,118 @@
+// internal/supervisor/people_count.go
+package supervisor
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+// metaPeopleCount é a contagem normalizada dos analíticos de contagem de
+// pessoas/multidão, lida pelo sensor do Home Assistant.
+const metaPeopleCount = "people_count"
+
+// peopleCountAnalytics geram contagem (Hikvision PeopleCounting e afins,
+// Dahua CrowdDetection).
+var peopleCountAnalytics = []string{
+	"PeopleCounting",
+	"framesPeopleCounting",
+	"PeopleNumChange",
+	"CrowdDetection",
+}
+
+// peopleCountFields são os nomes (sem caixa) de contagem nos payloads das
+// câmeras, em ordem de preferência.
+var peopleCountFields = []string{"people_count", "peoplecount", "peoplenum", "humancount", "mannum", "number", "count"}
+
+func isPeopleCountAnalytic(analytic string) bool {
+	for _, a := range peopleCountAnalytics {
+		if strings.EqualFold(a, analytic) {
+			return true
+		}
+	}
+	return false
+}
+
+// peopleCountAnalyticsOf devolve os analíticos de contagem configurados na câmera.
+func peopleCountAnalyticsOf(info core.CameraInfo) []string {
+	var out []string
+	for _, a := range info.Analytics {
+		if isPeopleCountAnalytic(a) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// applyPeopleCount grava Meta["people_count"] nos eventos de contagem, a partir
+// do Meta ou do bloco "data" do evento (Dahua Data: PeopleNum, RegionList...).
+func applyPeopleCount(evt *core.AnalyticEvent) {
+	if !isPeopleCountAnalytic(evt.AnalyticType) || evt.Meta == nil {
+		return
+	}
+	if _, ok := evt.Meta[metaPeopleCount]; ok {
+		return
+	}
+	n, ok := peopleCountFrom(evt.Meta)
+	if !ok {
+		if data, isMap := evt.Meta["data"].(map[string]interface{}); isMap {
+			n, ok = peopleCountFrom(data)
+		}
+	}
+	if ok {
+		evt.Meta[metaPeopleCount] = n
+	}
+}
+
+// peopleCountFrom procura um campo de contagem no objeto; sem ele, soma as
+// contagens das regiões (RegionList).
+func peopleCountFrom(obj map[string]interface{}) (int, bool) {
+	for _, field := range peopleCountFields {
+		for k, v := range obj {
+			if !strings.EqualFold(k, field) {
+				continue
+			}
+			if n, ok := countValue(v); ok {
+				return n, true
+			}
+		}
+	}
+	for k, v := range obj {
+		if !strings.EqualFold(k, "RegionList") {
+			continue
+		}
+		regions, _ := v.([]interface{})
+		total, found := 0, false
+		for _, r := range regions {
+			region, _ := r.(map[string]interface{})
+			if n, ok := peopleCountFrom(region); ok {
+				total += n
+				found = true
+			}
+		}
+		return total, found
+	}
+	return 0, false
+}
+
+func countValue(v interface{}) (int, bool) {
+	switch x := v.(type) {
+	case int:
+		return x, true
+	case int64:
+		return int(x), true
+	case float64:
+		return int(x), true
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			return int(i), true
+		}
+	case string:
+		if i, err := strconv.Atoi(strings.TrimSpace(x)); err == nil {
+			return i, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,50 @@
+package supervisor
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func TestApplyPeopleCount(t *testing.T) {
+	cases := []struct {
+		name     string
+		analytic string
+		meta     map[string]interface{}
+		want     interface{}
+	}{
+		{"campo direto", "PeopleCounting", map[string]interface{}{"peopleNum": json.Number("4")}, 4},
+		{"preferência de campo", "PeopleCounting", map[string]interface{}{"count": 9, "PeopleCount": "3"}, 3},
+		{"bloco data", "CrowdDetection", map[string]interface{}{"data": map[string]interface{}{"HumanCount": 12.0}}, 12},
+		{"soma das regiões", "crowddetection", map[string]interface{}{"data": map[string]interface{}{"RegionList": []interface{}{
+			map[string]interface{}{"PeopleNum": 2},
+			map[string]interface{}{"Name": "sem contagem"},
+			map[string]interface{}{"PeopleNum": int64(5)},
+		}}}, 7},
+		{"já normalizado", "PeopleCounting", map[string]interface{}{"people_count": 1, "count": 8}, 1},
+		{"valor ilegível", "PeopleCounting", map[string]interface{}{"count": "muitos"}, nil},
+		{"analítico sem contagem", "VideoMotion", map[string]interface{}{"count": 2}, nil},
+	}
+	for _, tc := range cases {
+		evt := core.AnalyticEvent{AnalyticType: tc.analytic, Meta: tc.meta}
+		applyPeopleCount(&evt)
+		got, ok := evt.Meta[metaPeopleCount]
+		if tc.want == nil {
+			if ok {
+				t.Errorf("%s: people_count = %v, esperava ausente", tc.name, got)
+			}
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: people_count = %v, esperava %v", tc.name, got, tc.want)
+		}
+	}
+
+	// sem Meta não faz nada (nem entra em pânico)
+	evt := core.AnalyticEvent{AnalyticType: "PeopleCounting"}
+	applyPeopleCount(&evt)
+	if evt.Meta != nil {
+		t.Fatalf("Meta = %v", evt.Meta)
+	}
+}
@@ -0,0 +1,243 @@
+// internal/supervisor/pipeline.go
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/eventid"
+	"github.com/sua-org/cam-bus/internal/logging"
+	"github.com/sua-org/cam-bus/internal/storage"
+	"github.com/sua-org/cam-bus/internal/tracing"
+)
+
+// handleCameraEvent publica o evento original e os derivados das engines.
+// Com SNAPSHOT_STORE_POLICY=matched|unmatched as engines rodam antes, para
+// decidir se o snapshot é salvo e já publicar o evento original com a URL.
+func (s *Supervisor) handleCameraEvent(ctx context.Context, key string, info core.CameraInfo, evt core.AnalyticEvent) {
+	now := time.Now()
+	if s.dedup.duplicate(key, evt, now) {
+		s.metrics.Add(metricEventsDeduped, 1, map[string]string{"analytic_type": strings.TrimSpace(evt.AnalyticType)})
+		logging.With(logging.Event(key, info.Tenant, evt.AnalyticType, evt.EventID)).Debugf("[supervisor] camera %s: %s duplicado (EventID %s) descartado", key, evt.AnalyticType, evt.EventID)
+		return
+	}
+	if ok, dropped := s.rateLimit.allow(key, evt.AnalyticType, now); !ok {
+		s.metrics.Add(metricEventsThrottled, 1, map[string]string{"analytic_type": strings.TrimSpace(evt.AnalyticType)})
+		if dropped > 0 {
+			logging.With(logging.Event(key, info.Tenant, evt.AnalyticType, evt.EventID)).Warnf("[supervisor] camera %s: %d eventos descartados acima de CAMBUS_MAX_EVENTS_PER_SEC (último: %s)", key, dropped, evt.AnalyticType)
+		}
+		return
+	}
+	s.timestamps.applyReceived(&evt, now)
+	applyPeopleCount(&evt)
+
+	// Continua o trace aberto pelo driver (Meta["traceparent"]).
+	ctx, span := tracing.Start(tracing.Extract(ctx, evt.Meta), "pipeline.event")
+	defer span.End()
+	span.SetAttr("camera.id", info.DeviceID)
+	span.SetAttr("analytic.type", evt.AnalyticType)
+	span.SetAttr("event.id", evt.EventID)
+
+	publish, withEngines := s.inactive.apply(&evt)
+	if !publish {
+		return
+	}
+	if !withEngines {
+		s.publishEvent(ctx, key, info, evt)
+		return
+	}
+
+	if storage.DefaultPolicy().Deferred() && len(evt.RawSnapshot) > 0 && evt.SnapshotURL == "" {
+		derived := s.runEngines(ctx, evt)
+		if url, thumbURL := s.storeDeferredSnapshot(ctx, key, info, evt, hasRecognizedFace(derived)); url != "" {
+			evt.SnapshotURL = url
+			evt.ThumbnailURL = thumbURL
+			for i := range derived {
+				if derived[i].SnapshotURL == "" {
+					derived[i].SnapshotURL = url
+					derived[i].ThumbnailURL = thumbURL
+				}
+			}
+		}
+		s.publishEvent(ctx, key, info, evt)
+		s.publishDerivedEvents(ctx, key, info, derived)
+		return
+	}
+
+	// 1) publica evento original (faceCapture, FaceDetection, PeopleCounting, etc.)
+	s.publishEvent(ctx, key, info, evt)
+
+	// 2) Engines: geram eventos derivados (ex.: faceRecognized)
+	s.publishDerivedEvents(ctx, key, info, s.runEngines(ctx, evt))
+}
+
+func (s *Supervisor) runEngines(ctx context.Context, evt core.AnalyticEvent) []core.AnalyticEvent {
+	if s.engines == nil || !s.engines.Enabled() {
+		return nil
+	}
+	// Engines reaproveitam o evento original (inclusive o Meta); copiamos o mapa
+	// para que os campos dos derivados não vazem para o evento original.
+	ctx, span := tracing.Start(ctx, "engines.process")
+	defer span.End()
+
+	in := evt
+	in.Meta = cloneMeta(evt.Meta)
+	derived, _ := s.engines.ProcessAll(ctx, in)
+	processed := time.Now()
+	for i := range derived {
+		s.timestamps.applyEngine(&derived[i], processed)
+		withDerivedEventID(&derived[i], evt.EventID)
+		// derivados apontam para o span das engines, ligando-os ao evento original
+		derived[i].Meta = tracing.Inject(ctx, cloneMeta(derived[i].Meta))
+	}
+	span.SetAttr("engines.derived", strconv.Itoa(len(derived)))
+	return derived
+}
+
+// hasRecognizedFace decide o "matched" do SNAPSHOT_STORE_POLICY: só um
+// faceRecognized conta (faceLikelyRecognized/faceDetected não são match).
+func hasRecognizedFace(derived []core.AnalyticEvent) bool {
+	for _, d := range derived {
+		if d.AnalyticType == "faceRecognized" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Supervisor) storeDeferredSnapshot(ctx context.Context, key string, info core.CameraInfo, evt core.AnalyticEvent, matched bool) (url, thumbURL string) {
+	store := storage.StoreFor(info.StorageProfile)
+	if store == nil || !storage.DefaultPolicy().ShouldStore(matched) {
+		return "", ""
+	}
+	ctxUp, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	url, thumbURL, err := storage.SaveWithThumbnail(ctxUp, store, evt.SnapshotKey, evt.RawSnapshot, evt.SnapshotContentType)
+	if err != nil {
+		logging.With(logging.Event(key, info.Tenant, evt.AnalyticType, evt.EventID)).Errorf("[worker %s] erro ao salvar snapshot no MinIO: %v", key, err)
+		return "", ""
+	}
+	return url, thumbURL
+}
+
+func (s *Supervisor) publishEvent(ctx context.Context, key string, info core.CameraInfo, evt core.AnalyticEvent) {
+	_, span := tracing.Start(ctx, "mqtt.publish")
+	defer span.End()
+
+	// Faz uma cópia só para publicação, sem o base64 (para não explodir o MQTT),
+	// salvo PUBLISH_SNAPSHOT_B64 (ex.: storage degradado).
+	evtOut := evt
+	s.snapshotB64.apply(info, evt, &evtOut)
+	withAnalyticCategories(&evtOut)
+	s.withStreamMeta(&evtOut, info)
+	delivery := s.analyticPolicy.forEvent(info, evtOut.AnalyticType)
+
+	topic := s.eventTopicFor(info, evtOut.AnalyticType, evtOut.EventState)
+	if delivery.snapshot {
+		s.publishSnapshotBinary(key, topic, &evtOut, snapshotBytes(evt))
+	} else {
+		stripSnapshot(&evtOut)
+	}
+	payload, err := json.Marshal(evtOut)
+	if err != nil {
+		logging.With(logging.Event(key, info.Tenant, evt.AnalyticType, evt.EventID)).Errorf("[worker %s] error marshaling event: %v", key, err)
+		return
+	}
+	span.SetAttr("mqtt.topic", topic)
+	err = s.publishEventPayload(topic, delivery.qos, delivery.retained, payload)
+	span.RecordError(err)
+	s.countPublished(evtOut.AnalyticType, "camera", err)
+	if err != nil {
+		logging.With(logging.Event(key, info.Tenant, evt.AnalyticType, evt.EventID)).Errorf("[worker %s] error publishing to %s: %v", key, topic, err)
+		return
+	}
+	logging.With(logging.Event(key, info.Tenant, evt.AnalyticType, evt.EventID)).Debugf("[worker %s] published event to %s (event_id=%s)", key, topic, evt.EventID)
+}
+
+func (s *Supervisor) publishDerivedEvents(ctx context.Context, key string, info core.CameraInfo, derived []core.AnalyticEvent) {
+	for _, dEvt := range derived {
+		_, span := tracing.Start(ctx, "mqtt.publish")
+		outEvt := dEvt
+		s.snapshotB64.apply(info, dEvt, &outEvt)
+		withAnalyticCategories(&outEvt)
+		s.withStreamMeta(&outEvt, info)
+		delivery := s.analyticPolicy.forEvent(info, outEvt.AnalyticType)
+
+		outTopic := s.eventTopicFor(info, outEvt.AnalyticType, outEvt.EventState)
+		if delivery.snapshot {
+			s.publishSnapshotBinary(key, outTopic, &outEvt, snapshotBytes(dEvt))
+		} else {
+			stripSnapshot(&outEvt)
+		}
+		outPayload, err := json.Marshal(outEvt)
+		if err != nil {
+			logging.With(logging.Event(key, info.Tenant, outEvt.AnalyticType, outEvt.EventID)).Errorf("[worker %s] erro ao marshalar evento derivado (%s): %v", key, outEvt.AnalyticType, err)
+			span.RecordError(err)
+			span.End()
+			continue
+		}
+		span.SetAttr("mqtt.topic", outTopic)
+		err = s.publishEventPayload(outTopic, delivery.qos, delivery.retained, outPayload)
+		span.RecordError(err)
+		span.End()
+		s.countPublished(outEvt.AnalyticType, "engine", err)
+		if err != nil {
+			logging.With(logging.Event(key, info.Tenant, outEvt.AnalyticType, outEvt.EventID)).Errorf("[worker %s] erro ao publicar evento derivado (%s) em %s: %v", key, outEvt.AnalyticType, outTopic, err)
+			continue
+		}
+		logging.With(logging.Event(key, info.Tenant, outEvt.AnalyticType, outEvt.EventID)).Debugf("[worker %s] published derived event (%s) -> %s (event_id=%s)", key, outEvt.AnalyticType, outTopic, outEvt.EventID)
+		if outEvt.AnalyticType == "faceRecognized" && outEvt.EventState != core.EventStateInactive {
+			s.lastFace.store(s.lastFaceTopic(info), outEvt.Timestamp, outPayload)
+		}
+	}
+}
+
+// publishEventPayload publica um evento pela fila (MQTT_PUBLISH_QUEUE_SIZE) ou,
+// sem ela, direto no broker. Com a fila, o erro é só do enfileiramento.
+func (s *Supervisor) publishEventPayload(topic string, qos byte, retained bool, payload []byte) error {
+	if s.eventPublisher != nil {
+		return s.eventPublisher.Publish(topic, qos, retained, payload)
+	}
+	return s.mqtt.Publish(topic, qos, retained, payload)
+}
+
+// withDerivedEventID dá ao derivado um ID próprio quando EVENT_ID_STRATEGY não
+// é native (senão ele repete o EventID do original), guardando o original em
+// Meta["source_event_id"].
+func withDerivedEventID(evt *core.AnalyticEvent, sourceID string) {
+	if eventid.Current() == eventid.StrategyNative || evt.EventID != sourceID {
+		return
+	}
+	if evt.Meta == nil {
+		evt.Meta = map[string]interface{}{}
+	}
+	evt.Meta["source_event_id"] = sourceID
+	evt.EventID = eventid.New(evt.AnalyticType, evt.Timestamp)
+}
+
+// withAnalyticCategories adiciona Meta["analytic_categories"] para roteamento
+// por categoria. AnalyticType continua sendo o tipo principal.
+func withAnalyticCategories(evt *core.AnalyticEvent) {
+	meta := cloneMeta(evt.Meta)
+	if meta == nil {
+		meta = map[string]interface{}{}
+	}
+	meta["analytic_categories"] = core.EventCategories(*evt)
+	evt.Meta = meta
+}
+
+func cloneMeta(meta map[string]interface{}) map[string]interface{} {
+	if meta == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(meta))
+	for k, v := range meta {
+		out[k] = v
+	}
+	return out
+}
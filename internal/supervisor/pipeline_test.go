@@ -0,0 +1,41 @@
+package supervisor
+
+import (
+	"testing"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func TestHasRecognizedFace(t *testing.T) {
+	cases := []struct {
+		types []string
+		want  bool
+	}{
+		{nil, false},
+		{[]string{"faceDetected", "faceLikelyRecognized"}, false},
+		{[]string{"faceDetected", "faceRecognized"}, true},
+	}
+	for _, tc := range cases {
+		var derived []core.AnalyticEvent
+		for _, typ := range tc.types {
+			derived = append(derived, core.AnalyticEvent{AnalyticType: typ})
+		}
+		if got := hasRecognizedFace(derived); got != tc.want {
+			t.Errorf("%v: matched=%t, esperava %t", tc.types, got, tc.want)
+		}
+	}
+}
+
+func TestWithAnalyticCategoriesDoesNotMutateSource(t *testing.T) {
+	shared := map[string]interface{}{"channelID": 1}
+	evt := core.AnalyticEvent{AnalyticType: "faceCapture", Meta: shared}
+	withAnalyticCategories(&evt)
+
+	if _, ok := shared["analytic_categories"]; ok {
+		t.Fatal("Meta compartilhado com o driver foi alterado")
+	}
+	cats, ok := evt.Meta["analytic_categories"].([]string)
+	if !ok || len(cats) == 0 || cats[0] != core.CategoryFace {
+		t.Fatalf("analytic_categories = %v", evt.Meta["analytic_categories"])
+	}
+}
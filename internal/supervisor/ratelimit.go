@@ -0,0 +1,112 @@
+// internal/supervisor/ratelimit.go
+package supervisor
+
+import (
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/envconf"
+)
+
+// rateLimitWarnInterval espaça o aviso de descarte de cada câmera.
+const rateLimitWarnInterval = time.Minute
+
+// eventRateLimiter limita os eventos de cada (câmera, analytic) com token
+// bucket (CAMBUS_MAX_EVENTS_PER_SEC, rajada CAMBUS_MAX_EVENTS_BURST). Cada par
+// tem seu bucket: uma câmera em flapping não consome a cota das outras.
+type eventRateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*eventBucket // câmera|analytic
+	warns   map[string]*rateWarn    // câmera
+}
+
+type eventBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type rateWarn struct {
+	last    time.Time
+	dropped int
+}
+
+// newEventRateLimiterFromEnv devolve nil sem CAMBUS_MAX_EVENTS_PER_SEC
+// (default: sem limite). A rajada padrão é um segundo de eventos.
+func newEventRateLimiterFromEnv() *eventRateLimiter {
+	rate := envconf.Float("CAMBUS_MAX_EVENTS_PER_SEC", 0)
+	if rate <= 0 {
+		return nil
+	}
+	burst := envconf.PositiveInt("CAMBUS_MAX_EVENTS_BURST", int(math.Max(1, math.Ceil(rate))))
+	log.Printf("[supervisor] limite de eventos por câmera/analytic: %.2f/s (burst=%d)", rate, burst)
+	return newEventRateLimiter(rate, burst)
+}
+
+func newEventRateLimiter(rate float64, burst int) *eventRateLimiter {
+	return &eventRateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*eventBucket),
+		warns:   make(map[string]*rateWarn),
+	}
+}
+
+// allow consome um token do bucket de (cameraKey, analyticType). Quando o
+// evento é descartado e já passou rateLimitWarnInterval desde o último aviso
+// da câmera, report traz quantos foram descartados desde então (0 = não logar).
+func (l *eventRateLimiter) allow(cameraKey, analyticType string, now time.Time) (ok bool, report int) {
+	if l == nil {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := cameraKey + "|" + analyticType
+	b, found := l.buckets[key]
+	if !found {
+		b = &eventBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(l.burst, b.tokens+elapsed*l.rate)
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	w, found := l.warns[cameraKey]
+	if !found {
+		w = &rateWarn{}
+		l.warns[cameraKey] = w
+	}
+	w.dropped++
+	if w.last.IsZero() || now.Sub(w.last) >= rateLimitWarnInterval {
+		report = w.dropped
+		w.dropped = 0
+		w.last = now
+	}
+	return false, report
+}
+
+// forget descarta os buckets da câmera (cleanup).
+func (l *eventRateLimiter) forget(cameraKey string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	prefix := cameraKey + "|"
+	for key := range l.buckets {
+		if strings.HasPrefix(key, prefix) {
+			delete(l.buckets, key)
+		}
+	}
+	delete(l.warns, cameraKey)
+}
@@ -0,0 +1,107 @@
+package supervisor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterBurstAndRefill(t *testing.T) {
+	l := newEventRateLimiter(2, 3)
+	now := time.Unix(1700000000, 0)
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.allow("cam1", "VideoMotion", now); !ok {
+			t.Fatalf("evento %d deveria caber na rajada", i)
+		}
+	}
+	if ok, _ := l.allow("cam1", "VideoMotion", now); ok {
+		t.Fatal("acima da rajada o evento deveria ser descartado")
+	}
+
+	// 2/s: em 500ms volta um token
+	now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.allow("cam1", "VideoMotion", now); !ok {
+		t.Fatal("depois do refill deveria passar um evento")
+	}
+	if ok, _ := l.allow("cam1", "VideoMotion", now); ok {
+		t.Fatal("o refill devolveu só um token")
+	}
+
+	// parado por muito tempo, o bucket enche só até a rajada
+	now = now.Add(time.Hour)
+	passed := 0
+	for i := 0; i < 10; i++ {
+		if ok, _ := l.allow("cam1", "VideoMotion", now); ok {
+			passed++
+		}
+	}
+	if passed != 3 {
+		t.Fatalf("passaram %d eventos, esperava a rajada (3)", passed)
+	}
+}
+
+func TestRateLimiterIsolatesCameraAndAnalytic(t *testing.T) {
+	l := newEventRateLimiter(1, 1)
+	now := time.Unix(1700000000, 0)
+
+	if ok, _ := l.allow("cam1", "VideoMotion", now); !ok {
+		t.Fatal("primeiro evento deveria passar")
+	}
+	if ok, _ := l.allow("cam1", "VideoMotion", now); ok {
+		t.Fatal("segundo evento do mesmo par deveria ser descartado")
+	}
+	if ok, _ := l.allow("cam1", "faceCapture", now); !ok {
+		t.Fatal("outro analytic da mesma câmera tem bucket próprio")
+	}
+	if ok, _ := l.allow("cam2", "VideoMotion", now); !ok {
+		t.Fatal("outra câmera tem bucket próprio")
+	}
+
+	l.forget("cam1")
+	if ok, _ := l.allow("cam1", "VideoMotion", now); !ok {
+		t.Fatal("depois do forget a câmera começa com o bucket cheio")
+	}
+}
+
+func TestRateLimiterWarnsOncePerMinute(t *testing.T) {
+	l := newEventRateLimiter(1, 1)
+	now := time.Unix(1700000000, 0)
+	l.allow("cam1", "VideoMotion", now)
+
+	if _, report := l.allow("cam1", "VideoMotion", now); report != 1 {
+		t.Fatalf("primeiro descarte deveria avisar, report=%d", report)
+	}
+	for i := 0; i < 4; i++ {
+		if _, report := l.allow("cam1", "VideoMotion", now.Add(time.Duration(i)*time.Millisecond)); report != 0 {
+			t.Fatalf("descarte %d dentro do minuto não deveria avisar, report=%d", i, report)
+		}
+	}
+	// outro analytic da mesma câmera soma no mesmo aviso
+	l.allow("cam1", "faceCapture", now)
+	l.allow("cam1", "faceCapture", now)
+
+	later := now.Add(rateLimitWarnInterval)
+	l.buckets["cam1|VideoMotion"].tokens = 0
+	l.buckets["cam1|VideoMotion"].last = later
+	if _, report := l.allow("cam1", "VideoMotion", later); report != 6 {
+		t.Fatalf("aviso depois de um minuto deveria trazer 6 descartes, veio %d", report)
+	}
+}
+
+func TestRateLimiterFromEnv(t *testing.T) {
+	for _, raw := range []string{"", "0", "abc", "-1"} {
+		t.Setenv("CAMBUS_MAX_EVENTS_PER_SEC", raw)
+		if l := newEventRateLimiterFromEnv(); l != nil {
+			t.Fatalf("CAMBUS_MAX_EVENTS_PER_SEC=%q deveria desligar o limite", raw)
+		}
+	}
+	t.Setenv("CAMBUS_MAX_EVENTS_PER_SEC", "2.5")
+	t.Setenv("CAMBUS_MAX_EVENTS_BURST", "")
+	if l := newEventRateLimiterFromEnv(); l == nil || l.rate != 2.5 || l.burst != 3 {
+		t.Fatalf("limiter = %+v, esperava rate 2.5 e burst ceil(2.5)", l)
+	}
+	t.Setenv("CAMBUS_MAX_EVENTS_BURST", "10")
+	if l := newEventRateLimiterFromEnv(); l == nil || l.burst != 10 {
+		t.Fatalf("limiter = %+v, esperava burst 10", l)
+	}
+}
@@ -0,0 +1,71 @@
+package supervisor
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/drivers"
+)
+
+func TestReconnectsCountedAndPublished(t *testing.T) {
+	fake, client := newFakeMQTT()
+	s := &Supervisor{mqtt: client, baseTopic: "cams", workers: map[string]*cameraWorker{}}
+	info := core.CameraInfo{Tenant: "t", Building: "b", Floor: "f", DeviceType: "cam", DeviceID: "c1"}
+	key := s.keyFor(info)
+	s.workers[key] = &cameraWorker{info: info, status: drivers.ConnectionStateOnline}
+
+	s.updateWorkerStatus(key, drivers.StatusUpdate{State: drivers.ConnectionStateOffline, Reason: "timeout"})
+	for i := 0; i < 3; i++ {
+		s.updateWorkerStatus(key, drivers.StatusUpdate{State: drivers.ConnectionStateConnecting, Reconnect: true})
+	}
+	s.updateWorkerStatus(key, drivers.StatusUpdate{State: drivers.ConnectionStateOnline})
+
+	snap, ok := s.workerSnapshot(key)
+	if !ok {
+		t.Fatal("worker sumiu")
+	}
+	if snap.Reconnects != 3 {
+		t.Fatalf("reconnects=%d, esperava 3", snap.Reconnects)
+	}
+	if snap.LastReconnect.IsZero() {
+		t.Fatal("last_reconnect não registrado")
+	}
+
+	if err := s.publishCameraStatus(snap, time.Now(), true); err != nil {
+		t.Fatal(err)
+	}
+	msgs := fake.messages("/c1/status")
+	if len(msgs) != 1 {
+		t.Fatalf("%d status publicados, esperava 1", len(msgs))
+	}
+	var p map[string]interface{}
+	if err := json.Unmarshal(msgs[0].payload, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p["reconnects"] != float64(3) || p["last_reconnect_at"] == nil {
+		t.Fatalf("status sem reconexões: %v", p)
+	}
+}
+
+func TestStatusWithoutReconnectsOmitsTimestamp(t *testing.T) {
+	fake, client := newFakeMQTT()
+	s := &Supervisor{mqtt: client, baseTopic: "cams", workers: map[string]*cameraWorker{}}
+	info := core.CameraInfo{Tenant: "t", Building: "b", Floor: "f", DeviceType: "cam", DeviceID: "c1"}
+	key := s.keyFor(info)
+	s.workers[key] = &cameraWorker{info: info}
+	s.updateWorkerStatus(key, drivers.StatusUpdate{State: drivers.ConnectionStateOnline})
+
+	snap, _ := s.workerSnapshot(key)
+	if err := s.publishCameraStatus(snap, time.Now(), true); err != nil {
+		t.Fatal(err)
+	}
+	var p map[string]interface{}
+	if err := json.Unmarshal(fake.messages("/c1/status")[0].payload, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p["reconnects"] != float64(0) || p["last_reconnect_at"] != nil {
+		t.Fatalf("câmera sem reconexão: %v", p)
+	}
+}
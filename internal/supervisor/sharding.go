@@ -0,0 +1,179 @@
+// internal/supervisor/sharding.go
+package supervisor
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/shard"
+)
+
+// shardRouter divide as câmeras entre as instâncias listadas em CAMBUS_SHARDS
+// (ex.: "shard-1,shard-2:2") com consistent hashing ponderado pelo weight do
+// /info. Toda instância recebe todos os /info, então calcula a mesma divisão;
+// esta instância (CAMBUS_SHARD) só aplica as câmeras que são dela.
+// CAMBUS_SHARD_BALANCE (default 1.25) limita a carga máxima por shard.
+// Câmeras com "shard" fixado no /info não entram no balanceamento.
+type shardRouter struct {
+	self    string
+	nodes   []shard.Node
+	balance float64
+
+	mu      sync.Mutex
+	members map[string]core.CameraInfo
+	owned   map[string]bool
+}
+
+func newShardRouterFromEnv(self string) *shardRouter {
+	raw := strings.TrimSpace(os.Getenv("CAMBUS_SHARDS"))
+	if raw == "" {
+		return nil
+	}
+	if self == "" {
+		log.Printf("[supervisor] CAMBUS_SHARDS definido sem CAMBUS_SHARD, divisão por shard desligada")
+		return nil
+	}
+	nodes, err := shard.ParseNodes(raw)
+	if err != nil {
+		log.Printf("[supervisor] CAMBUS_SHARDS inválido (%v), divisão por shard desligada", err)
+		return nil
+	}
+	found := false
+	for _, n := range nodes {
+		if n.Name == self {
+			found = true
+		}
+	}
+	if !found {
+		log.Printf("[supervisor] CAMBUS_SHARD=%s não está em CAMBUS_SHARDS, divisão por shard desligada", self)
+		return nil
+	}
+
+	balance := shard.DefaultBalance
+	if v, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv("CAMBUS_SHARD_BALANCE")), 64); err == nil && v > 1 {
+		balance = v
+	}
+	log.Printf("[supervisor] divisão por shard: %s entre %d shards (balance=%.2f)", self, len(nodes), balance)
+	return &shardRouter{
+		self:    self,
+		nodes:   nodes,
+		balance: balance,
+		members: make(map[string]core.CameraInfo),
+		owned:   make(map[string]bool),
+	}
+}
+
+// shardChange é uma câmera que mudou de dono após recalcular a divisão.
+type shardChange struct {
+	info  core.CameraInfo
+	owned bool
+}
+
+// update registra/remove a câmera e devolve quem mudou de dono nesta instância.
+func (r *shardRouter) update(key string, info core.CameraInfo, remove bool) []shardChange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if remove || !info.Enabled {
+		delete(r.members, key)
+	} else {
+		r.members[key] = info
+	}
+
+	var items []shard.Item
+	for k, m := range r.members {
+		if m.Shard == "" {
+			items = append(items, shard.Item{Key: k, Weight: m.Weight})
+		}
+	}
+	assignment := shard.Assign(items, r.nodes, r.balance)
+
+	var changes []shardChange
+	for k, m := range r.members {
+		owner := m.Shard
+		if owner == "" {
+			owner = assignment[k]
+		}
+		owned := owner == r.self
+		if owned != r.owned[k] || k == key {
+			changes = append(changes, shardChange{info: m, owned: owned})
+		}
+		r.owned[k] = owned
+	}
+	if _, ok := r.members[key]; !ok {
+		delete(r.owned, key)
+	}
+	return changes
+}
+
+// owns calcula, sem registrar, quais das câmeras ficariam com esta instância
+// na divisão junto com as já conhecidas. Usado no seed do CAMBUS_STATE_FILE:
+// as câmeras só entram na divisão quando o /info chega.
+func (r *shardRouter) owns(infos map[string]core.CameraInfo) map[string]bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var items []shard.Item
+	for k, m := range r.members {
+		if _, seed := infos[k]; !seed && m.Shard == "" {
+			items = append(items, shard.Item{Key: k, Weight: m.Weight})
+		}
+	}
+	for k, m := range infos {
+		if m.Shard == "" {
+			items = append(items, shard.Item{Key: k, Weight: m.Weight})
+		}
+	}
+	assignment := shard.Assign(items, r.nodes, r.balance)
+
+	out := make(map[string]bool, len(infos))
+	for k, m := range infos {
+		owner := m.Shard
+		if owner == "" {
+			owner = assignment[k]
+		}
+		out[k] = owner == r.self
+	}
+	return out
+}
+
+// routeShardedInfo aplica o /info se a câmera é deste shard e move as demais
+// câmeras que trocaram de dono com a nova divisão.
+func (s *Supervisor) routeShardedInfo(info core.CameraInfo) {
+	key := s.keyFor(info)
+	if !info.Enabled {
+		// disable segue o fluxo normal (cleanup) e sai da divisão
+		s.removeShardMember(key)
+		s.applyCameraInfo(info)
+		return
+	}
+
+	s.applyShardChanges(key, s.shards.update(key, info, false))
+}
+
+// removeShardMember tira a câmera da divisão (tombstone/disable) e rebalanceia.
+func (s *Supervisor) removeShardMember(key string) {
+	s.applyShardChanges(key, s.shards.update(key, core.CameraInfo{}, true))
+}
+
+func (s *Supervisor) applyShardChanges(key string, changes []shardChange) {
+	for _, c := range changes {
+		ckey := s.keyFor(c.info)
+		switch {
+		case c.owned:
+			if ckey != key {
+				log.Printf("[supervisor] camera %s passou para este shard (%s)", ckey, s.shards.self)
+			}
+			s.applyCameraInfo(c.info)
+		default:
+			if _, running := s.activeCameraInfo(ckey); running {
+				log.Printf("[supervisor] camera %s pertence a outro shard, liberando", ckey)
+				s.cleanupCamera(c.info, false)
+			}
+		}
+	}
+}
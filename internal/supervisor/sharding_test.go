@@ -0,0 +1,69 @@
+package supervisor
+
+import (
+	"testing"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func TestShardRouterFromEnv(t *testing.T) {
+	t.Setenv("CAMBUS_SHARDS", "a,b")
+	if newShardRouterFromEnv("") != nil || newShardRouterFromEnv("c") != nil {
+		t.Fatal("instância fora de CAMBUS_SHARDS não deveria dividir")
+	}
+	t.Setenv("CAMBUS_SHARDS", "a,a")
+	if newShardRouterFromEnv("a") != nil {
+		t.Fatal("CAMBUS_SHARDS inválido deveria desligar a divisão")
+	}
+	t.Setenv("CAMBUS_SHARDS", "a,b:2")
+	t.Setenv("CAMBUS_SHARD_BALANCE", "1.5")
+	r := newShardRouterFromEnv("b")
+	if r == nil || len(r.nodes) != 2 || r.balance != 1.5 {
+		t.Fatalf("router = %+v", r)
+	}
+}
+
+func TestShardRouterPinnedAndRemoved(t *testing.T) {
+	t.Setenv("CAMBUS_SHARDS", "a,b")
+	ra := newShardRouterFromEnv("a")
+	rb := newShardRouterFromEnv("b")
+
+	for _, id := range []string{"c1", "c2", "c3", "c4", "c5", "c6"} {
+		info := core.CameraInfo{DeviceID: id, Enabled: true}
+		ownedA, ownedB := false, false
+		for _, ch := range ra.update(id, info, false) {
+			if ch.info.DeviceID == id {
+				ownedA = ch.owned
+			}
+		}
+		for _, ch := range rb.update(id, info, false) {
+			if ch.info.DeviceID == id {
+				ownedB = ch.owned
+			}
+		}
+		if ownedA == ownedB {
+			t.Fatalf("%s: a=%t b=%t, esperava exatamente um dono", id, ownedA, ownedB)
+		}
+	}
+	// depois de todas as câmeras, cada uma continua com um dono só
+	for k := range ra.members {
+		if ra.owned[k] == rb.owned[k] {
+			t.Fatalf("%s: a=%t b=%t após a divisão final", k, ra.owned[k], rb.owned[k])
+		}
+	}
+
+	pinned := core.CameraInfo{DeviceID: "fixa", Enabled: true, Shard: "b"}
+	for _, ch := range ra.update("fixa", pinned, false) {
+		if ch.info.DeviceID == "fixa" && ch.owned {
+			t.Fatal("câmera fixada em b não pode ficar com a")
+		}
+	}
+
+	ra.update("c1", core.CameraInfo{DeviceID: "c1"}, true)
+	if _, ok := ra.members["c1"]; ok {
+		t.Fatal("câmera removida continua no router")
+	}
+	if _, ok := ra.owned["c1"]; ok {
+		t.Fatal("câmera removida continua marcada")
+	}
+}
@@ -0,0 +1,64 @@
+// internal/supervisor/snapshot_b64.go
+package supervisor
+
+import (
+	"encoding/base64"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/storage"
+)
+
+// snapshotB64Policy decide se o base64 do snapshot vai no payload MQTT
+// (PUBLISH_SNAPSHOT_B64):
+//   - off: só SnapshotURL;
+//   - degraded (default): inclui o base64 enquanto o circuit breaker do
+//     storage da câmera está aberto, para o consumidor não ficar sem imagem;
+//   - always: sempre inclui.
+type snapshotB64Policy string
+
+const (
+	snapshotB64Off      snapshotB64Policy = "off"
+	snapshotB64Degraded snapshotB64Policy = "degraded"
+	snapshotB64Always   snapshotB64Policy = "always"
+)
+
+func snapshotB64PolicyFromEnv() snapshotB64Policy {
+	raw := strings.ToLower(strings.TrimSpace(os.Getenv("PUBLISH_SNAPSHOT_B64")))
+	switch p := snapshotB64Policy(raw); p {
+	case "":
+		return snapshotB64Degraded
+	case snapshotB64Off, snapshotB64Degraded, snapshotB64Always:
+		return p
+	}
+	log.Printf("[supervisor] PUBLISH_SNAPSHOT_B64 inválido (%q), usando degraded", raw)
+	return snapshotB64Degraded
+}
+
+// apply ajusta evtOut (cópia para publicação) a partir do evento original:
+// remove o base64 ou, se a política pedir, garante que ele esteja presente.
+// O store da câmera só é consultado em degraded e quando há imagem.
+func (p snapshotB64Policy) apply(info core.CameraInfo, evt core.AnalyticEvent, evtOut *core.AnalyticEvent) {
+	evtOut.SnapshotB64 = ""
+	if p == snapshotB64Off || (evt.SnapshotB64 == "" && len(evt.RawSnapshot) == 0) {
+		return
+	}
+	if p == snapshotB64Degraded && !storage.Degraded(storage.StoreFor(info.StorageProfile)) {
+		return
+	}
+	if evt.SnapshotB64 != "" {
+		evtOut.SnapshotB64 = evt.SnapshotB64
+	} else if len(evt.RawSnapshot) > 0 {
+		evtOut.SnapshotB64 = base64.StdEncoding.EncodeToString(evt.RawSnapshot)
+	}
+	if evtOut.SnapshotB64 != "" && p == snapshotB64Degraded {
+		meta := cloneMeta(evtOut.Meta)
+		if meta == nil {
+			meta = map[string]interface{}{}
+		}
+		meta["storage_degraded"] = true
+		evtOut.Meta = meta
+	}
+}
@@ -0,0 +1,97 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/storage"
+)
+
+// flakyStore falha enquanto failing estiver ligado.
+type flakyStore struct{ failing atomic.Bool }
+
+func (f *flakyStore) SaveSnapshot(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	if f.failing.Load() {
+		return "", errors.New("minio fora")
+	}
+	return "http://minio/" + key, nil
+}
+
+func TestSnapshotB64PolicyFromEnv(t *testing.T) {
+	cases := map[string]snapshotB64Policy{
+		"":         snapshotB64Degraded,
+		"off":      snapshotB64Off,
+		"ALWAYS":   snapshotB64Always,
+		"degraded": snapshotB64Degraded,
+		"talvez":   snapshotB64Degraded,
+	}
+	for raw, want := range cases {
+		t.Setenv("PUBLISH_SNAPSHOT_B64", raw)
+		if got := snapshotB64PolicyFromEnv(); got != want {
+			t.Errorf("PUBLISH_SNAPSHOT_B64=%q: %s, esperava %s", raw, got, want)
+		}
+	}
+}
+
+func TestSnapshotB64TogglesWithBreaker(t *testing.T) {
+	t.Setenv("STORAGE_BREAKER_FAILURES", "2")
+	t.Setenv("STORAGE_BREAKER_COOLDOWN_SECONDS", "1")
+
+	store := &flakyStore{}
+	storage.RegisterProfile("b64-toggle", store)
+	info := core.CameraInfo{DeviceID: "cam01", StorageProfile: "b64-toggle"}
+	evt := core.AnalyticEvent{RawSnapshot: []byte("jpeg"), Meta: map[string]interface{}{"a": 1}}
+
+	publish := func() core.AnalyticEvent {
+		out := evt
+		snapshotB64Degraded.apply(info, evt, &out)
+		return out
+	}
+
+	if out := publish(); out.SnapshotB64 != "" {
+		t.Fatal("storage saudável: evento deveria sair só com a URL")
+	}
+
+	store.failing.Store(true)
+	for i := 0; i < 2; i++ {
+		_, _ = storage.Save(context.Background(), store, "k.jpg", []byte("jpeg"), "image/jpeg")
+	}
+	out := publish()
+	if out.SnapshotB64 != "anBlZw==" || out.Meta["storage_degraded"] != true {
+		t.Fatalf("breaker aberto: esperava base64 e storage_degraded, veio %q %v", out.SnapshotB64, out.Meta)
+	}
+	if _, ok := evt.Meta["storage_degraded"]; ok {
+		t.Fatal("apply alterou o Meta do evento original")
+	}
+
+	// cooldown vencido, uma tentativa com sucesso fecha o breaker
+	store.failing.Store(false)
+	time.Sleep(1100 * time.Millisecond)
+	if _, err := storage.Save(context.Background(), store, "k.jpg", []byte("jpeg"), "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	if out := publish(); out.SnapshotB64 != "" {
+		t.Fatal("storage recuperado: evento deveria voltar a sair só com a URL")
+	}
+}
+
+func TestSnapshotB64OffAndAlways(t *testing.T) {
+	info := core.CameraInfo{DeviceID: "cam01"}
+	evt := core.AnalyticEvent{SnapshotB64: "eA=="}
+
+	out := evt
+	snapshotB64Off.apply(info, evt, &out)
+	if out.SnapshotB64 != "" {
+		t.Fatal("off deveria remover o base64")
+	}
+
+	out = evt
+	snapshotB64Always.apply(info, evt, &out)
+	if out.SnapshotB64 != "eA==" || out.Meta != nil {
+		t.Fatalf("always deveria manter o base64 sem storage_degraded: %+v", out)
+	}
+}
@@ -0,0 +1,73 @@
+// internal/supervisor/snapshot_topic.go
+package supervisor
+
+import (
+	"encoding/base64"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/envconf"
+	"github.com/sua-org/cam-bus/internal/logging"
+)
+
+const defaultSnapshotTopicMaxBytes = 512 * 1024
+
+// snapshotTopicPolicy controla a publicação dos bytes crus do snapshot em
+// <tópico do evento>/snapshot (PUBLISH_SNAPSHOT_TOPIC). O JSON do evento segue
+// no tópico normal e leva Meta["snapshot_topic"] + EventID para parear.
+type snapshotTopicPolicy struct {
+	enabled  bool
+	maxBytes int  // PUBLISH_SNAPSHOT_MAX_BYTES; snapshots maiores não são publicados
+	retain   bool // PUBLISH_SNAPSHOT_RETAIN
+}
+
+func snapshotTopicPolicyFromEnv() snapshotTopicPolicy {
+	return snapshotTopicPolicy{
+		enabled:  envconf.Bool("PUBLISH_SNAPSHOT_TOPIC", false),
+		maxBytes: envconf.PositiveInt("PUBLISH_SNAPSHOT_MAX_BYTES", defaultSnapshotTopicMaxBytes),
+		retain:   envconf.Bool("PUBLISH_SNAPSHOT_RETAIN", false),
+	}
+}
+
+// snapshotBytes devolve a imagem do evento (RawSnapshot ou SnapshotB64).
+func snapshotBytes(evt core.AnalyticEvent) []byte {
+	if len(evt.RawSnapshot) > 0 {
+		return evt.RawSnapshot
+	}
+	if evt.SnapshotB64 == "" {
+		return nil
+	}
+	data, err := base64.StdEncoding.DecodeString(evt.SnapshotB64)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// publishSnapshotBinary publica o snapshot cru antes do JSON do evento e marca
+// no Meta do evento de saída onde ele foi publicado. Retorna false quando nada
+// foi publicado (desligado, sem imagem ou acima do limite).
+func (s *Supervisor) publishSnapshotBinary(key, eventTopic string, evtOut *core.AnalyticEvent, img []byte) bool {
+	if !s.snapshotTopic.enabled || len(img) == 0 {
+		return false
+	}
+	if len(img) > s.snapshotTopic.maxBytes {
+		logging.With(logging.Event(key, evtOut.Tenant, evtOut.AnalyticType, evtOut.EventID)).Warnf("[worker %s] snapshot de %d bytes acima de PUBLISH_SNAPSHOT_MAX_BYTES=%d, não publicado (event_id=%s)",
+			key, len(img), s.snapshotTopic.maxBytes, evtOut.EventID)
+		return false
+	}
+
+	topic := eventTopic + "/snapshot"
+	if err := s.mqtt.Publish(topic, 1, s.snapshotTopic.retain, img); err != nil {
+		logging.With(logging.Event(key, evtOut.Tenant, evtOut.AnalyticType, evtOut.EventID)).Errorf("[worker %s] erro ao publicar snapshot em %s: %v", key, topic, err)
+		return false
+	}
+
+	meta := cloneMeta(evtOut.Meta)
+	if meta == nil {
+		meta = map[string]interface{}{}
+	}
+	meta["snapshot_topic"] = topic
+	meta["snapshot_bytes"] = len(img)
+	evtOut.Meta = meta
+	return true
+}
@@ -0,0 +1,86 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func TestSnapshotTopicDualPublish(t *testing.T) {
+	fake, client := newFakeMQTT()
+	s := &Supervisor{
+		mqtt:          client,
+		baseTopic:     "cams",
+		snapshotB64:   snapshotB64Off,
+		snapshotTopic: snapshotTopicPolicy{enabled: true, maxBytes: 1024, retain: true},
+	}
+	info := core.CameraInfo{Tenant: "t", Building: "b", Floor: "f", DeviceType: "cam", DeviceID: "c1"}
+	img := []byte("\xff\xd8jpeg\xff\xd9")
+	evt := core.AnalyticEvent{
+		EventID:      "e1",
+		AnalyticType: "faceCapture",
+		SnapshotB64:  base64.StdEncoding.EncodeToString(img),
+	}
+
+	s.publishEvent(context.Background(), s.keyFor(info), info, evt)
+
+	var raw, event []fakeMessage
+	for _, m := range fake.messages("/faceCapture") {
+		if strings.HasSuffix(m.topic, "/snapshot") {
+			raw = append(raw, m)
+		} else {
+			event = append(event, m)
+		}
+	}
+	if len(raw) != 1 || string(raw[0].payload) != string(img) || !raw[0].retained {
+		t.Fatalf("snapshot cru publicado = %+v", raw)
+	}
+	if len(event) != 1 {
+		t.Fatalf("%d eventos JSON, esperava 1", len(event))
+	}
+	var out core.AnalyticEvent
+	if err := json.Unmarshal(event[0].payload, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Meta["snapshot_topic"] != raw[0].topic || out.Meta["snapshot_bytes"] != float64(len(img)) {
+		t.Fatalf("Meta sem o par do snapshot: %v", out.Meta)
+	}
+	if out.SnapshotB64 != "" {
+		t.Fatal("JSON do evento não deveria levar o base64")
+	}
+}
+
+func TestSnapshotTopicSizeGate(t *testing.T) {
+	fake, client := newFakeMQTT()
+	s := &Supervisor{mqtt: client, baseTopic: "cams", snapshotTopic: snapshotTopicPolicy{enabled: true, maxBytes: 4}}
+	evt := core.AnalyticEvent{EventID: "e1", AnalyticType: "faceCapture"}
+
+	if s.publishSnapshotBinary("k", "cams/x/faceCapture", &evt, []byte("grande demais")) {
+		t.Fatal("snapshot acima de PUBLISH_SNAPSHOT_MAX_BYTES não deveria ser publicado")
+	}
+	if len(fake.messages("/snapshot")) != 0 || evt.Meta["snapshot_topic"] != nil {
+		t.Fatal("nada deveria ter sido publicado/marcado")
+	}
+
+	s.snapshotTopic.enabled = false
+	if s.publishSnapshotBinary("k", "cams/x/faceCapture", &evt, []byte("ok")) {
+		t.Fatal("PUBLISH_SNAPSHOT_TOPIC desligado")
+	}
+}
+
+func TestSnapshotTopicPolicyFromEnv(t *testing.T) {
+	t.Setenv("PUBLISH_SNAPSHOT_TOPIC", "true")
+	t.Setenv("PUBLISH_SNAPSHOT_MAX_BYTES", "abc")
+	p := snapshotTopicPolicyFromEnv()
+	if !p.enabled || p.maxBytes != defaultSnapshotTopicMaxBytes || p.retain {
+		t.Fatalf("política = %+v", p)
+	}
+	t.Setenv("PUBLISH_SNAPSHOT_MAX_BYTES", "2048")
+	if p := snapshotTopicPolicyFromEnv(); p.maxBytes != 2048 {
+		t.Fatalf("maxBytes = %d", p.maxBytes)
+	}
+}
@@ -0,0 +1,138 @@
+// internal/supervisor/startqueue.go
+package supervisor
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+// startQueue escalona o start de workers novos (CAMBUS_START_RATE por segundo,
+// com rajada CAMBUS_START_BURST) para não abrir centenas de conexões de uma vez
+// quando o broker entrega todos os /info retidos no boot.
+type startQueue struct {
+	rate  float64
+	burst int
+
+	mu      sync.Mutex
+	order   []string
+	pending map[string]core.CameraInfo
+	wake    chan struct{}
+}
+
+func newStartQueueFromEnv() *startQueue {
+	raw := strings.TrimSpace(os.Getenv("CAMBUS_START_RATE"))
+	if raw == "" {
+		return nil
+	}
+	rate, err := strconv.ParseFloat(raw, 64)
+	if err != nil || rate <= 0 {
+		log.Printf("[supervisor] CAMBUS_START_RATE=%q inválido, start escalonado desligado", raw)
+		return nil
+	}
+	burst := 1
+	if v := strings.TrimSpace(os.Getenv("CAMBUS_START_BURST")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			burst = n
+		} else {
+			log.Printf("[supervisor] CAMBUS_START_BURST=%q inválido, usando %d", v, burst)
+		}
+	}
+	log.Printf("[supervisor] start escalonado de câmeras: %.2f/s (burst=%d)", rate, burst)
+	return &startQueue{
+		rate:    rate,
+		burst:   burst,
+		pending: make(map[string]core.CameraInfo),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// enqueue agenda o start; se a câmera já está na fila, só atualiza a config
+// pendente (mantém a posição).
+func (q *startQueue) enqueue(key string, info core.CameraInfo) {
+	q.mu.Lock()
+	if _, ok := q.pending[key]; !ok {
+		q.order = append(q.order, key)
+	}
+	q.pending[key] = info
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// update troca a config pendente se a câmera ainda estiver na fila.
+func (q *startQueue) update(key string, info core.CameraInfo) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.pending[key]; !ok {
+		return false
+	}
+	q.pending[key] = info
+	return true
+}
+
+// remove tira a câmera da fila (disable/tombstone antes do start).
+func (q *startQueue) remove(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.pending, key)
+}
+
+func (q *startQueue) pop() (core.CameraInfo, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.order) > 0 {
+		key := q.order[0]
+		q.order = q.order[1:]
+		if info, ok := q.pending[key]; ok {
+			delete(q.pending, key)
+			return info, true
+		}
+	}
+	return core.CameraInfo{}, false
+}
+
+func (q *startQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// run consome a fila com token bucket: começa com burst tokens e repõe um
+// token a cada 1/rate segundos.
+func (q *startQueue) run(ctx context.Context, start func(core.CameraInfo)) {
+	interval := time.Duration(float64(time.Second) / q.rate)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	tokens := q.burst
+	for {
+		for tokens > 0 {
+			info, ok := q.pop()
+			if !ok {
+				break
+			}
+			tokens--
+			start(info)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if tokens < q.burst {
+				tokens++
+			}
+		case <-q.wake:
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package supervisor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func TestStartQueueFromEnv(t *testing.T) {
+	t.Setenv("CAMBUS_START_RATE", "")
+	if q := newStartQueueFromEnv(); q != nil {
+		t.Fatal("sem CAMBUS_START_RATE o start escalonado deveria ficar desligado")
+	}
+	t.Setenv("CAMBUS_START_RATE", "-2")
+	if q := newStartQueueFromEnv(); q != nil {
+		t.Fatal("rate inválido deveria desligar o start escalonado")
+	}
+	t.Setenv("CAMBUS_START_RATE", "2.5")
+	t.Setenv("CAMBUS_START_BURST", "4")
+	q := newStartQueueFromEnv()
+	if q == nil || q.rate != 2.5 || q.burst != 4 {
+		t.Fatalf("fila = %+v", q)
+	}
+}
+
+func TestStartQueueDedupAndRemove(t *testing.T) {
+	t.Setenv("CAMBUS_START_RATE", "1")
+	q := newStartQueueFromEnv()
+	q.enqueue("a", core.CameraInfo{DeviceID: "a", Name: "v1"})
+	q.enqueue("b", core.CameraInfo{DeviceID: "b"})
+	q.enqueue("a", core.CameraInfo{DeviceID: "a", Name: "v2"})
+	q.enqueue("c", core.CameraInfo{DeviceID: "c"})
+	q.remove("b")
+	if !q.update("c", core.CameraInfo{DeviceID: "c", Name: "novo"}) || q.update("b", core.CameraInfo{}) {
+		t.Fatal("update deveria valer só para câmeras ainda na fila")
+	}
+	if q.len() != 2 {
+		t.Fatalf("len = %d, esperava 2", q.len())
+	}
+
+	first, _ := q.pop()
+	second, _ := q.pop()
+	if first.DeviceID != "a" || first.Name != "v2" {
+		t.Fatalf("primeiro = %+v, esperava a/v2 mantendo a posição", first)
+	}
+	if second.DeviceID != "c" || second.Name != "novo" {
+		t.Fatalf("segundo = %+v", second)
+	}
+	if _, ok := q.pop(); ok {
+		t.Fatal("fila deveria estar vazia")
+	}
+}
+
+func TestStartQueueRateLimitsStarts(t *testing.T) {
+	t.Setenv("CAMBUS_START_RATE", "20") // um token a cada 50ms
+	t.Setenv("CAMBUS_START_BURST", "2")
+	q := newStartQueueFromEnv()
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		q.enqueue(id, core.CameraInfo{DeviceID: id})
+	}
+
+	var mu sync.Mutex
+	var started []time.Time
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	begin := time.Now()
+	go q.run(ctx, func(core.CameraInfo) {
+		mu.Lock()
+		started = append(started, time.Now())
+		mu.Unlock()
+	})
+
+	time.Sleep(20 * time.Millisecond)
+	mu.Lock()
+	burst := len(started)
+	mu.Unlock()
+	if burst != 2 {
+		t.Fatalf("rajada inicial = %d starts, esperava 2", burst)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		n := len(started)
+		mu.Unlock()
+		if n == 5 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("só %d de 5 starts", n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	// 3 starts além da rajada a 20/s levam pelo menos ~150ms.
+	mu.Lock()
+	elapsed := started[4].Sub(begin)
+	mu.Unlock()
+	if elapsed < 120*time.Millisecond {
+		t.Fatalf("5 starts em %s: rate não respeitado", elapsed)
+	}
+}
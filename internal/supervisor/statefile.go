@@ -0,0 +1,296 @@
+// internal/supervisor/statefile.go
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/drivers"
+	"github.com/sua-org/cam-bus/internal/envconf"
+)
+
+const (
+	defaultStateSaveInterval    = 30 * time.Second
+	defaultStateSaveDebounce    = time.Second
+	defaultStateReconcileWindow = 2 * time.Minute
+)
+
+// persistedState é o snapshot salvo em CAMBUS_STATE_FILE.
+type persistedState struct {
+	SavedAt  time.Time                  `json:"saved_at"`
+	Cameras  map[string]core.CameraInfo `json:"cameras"`
+	Statuses map[string]persistedStatus `json:"statuses,omitempty"`
+}
+
+type persistedStatus struct {
+	Status        drivers.ConnectionState `json:"status"`
+	StatusSince   time.Time               `json:"status_since"`
+	EverConnected bool                    `json:"ever_connected,omitempty"`
+	LastEventAt   time.Time               `json:"last_event_at,omitempty"`
+	Reconnects    int                     `json:"reconnects,omitempty"`
+}
+
+// stateStore configura a persistência opcional do supervisor:
+// CAMBUS_STATE_FILE (vazio = desligado), CAMBUS_STATE_SAVE_INTERVAL (default 30s),
+// CAMBUS_STATE_SAVE_DEBOUNCE (default 1s; save após mudança nas câmeras) e
+// CAMBUS_STATE_RECONCILE_WINDOW (default 2m): câmeras carregadas do arquivo
+// que não forem confirmadas por um /info retido nesse prazo são removidas;
+// 0/off as mantém (broker sem /info retido).
+type stateStore struct {
+	path            string
+	saveInterval    time.Duration
+	saveDebounce    time.Duration
+	reconcileWindow time.Duration // 0 = nunca remove câmeras não confirmadas
+
+	frozen atomic.Bool // shutdown: o stopAll não pode esvaziar o arquivo
+}
+
+func newStateStoreFromEnv() *stateStore {
+	path := strings.TrimSpace(os.Getenv("CAMBUS_STATE_FILE"))
+	if path == "" {
+		return nil
+	}
+	st := &stateStore{
+		path:            path,
+		saveInterval:    envconf.Duration("CAMBUS_STATE_SAVE_INTERVAL", 0),
+		saveDebounce:    defaultStateSaveDebounce,
+		reconcileWindow: defaultStateReconcileWindow,
+	}
+	if st.saveInterval <= 0 {
+		st.saveInterval = defaultStateSaveInterval
+	}
+	if _, ok := os.LookupEnv("CAMBUS_STATE_SAVE_DEBOUNCE"); ok {
+		st.saveDebounce = envconf.Duration("CAMBUS_STATE_SAVE_DEBOUNCE", 0)
+	}
+	switch raw := strings.TrimSpace(os.Getenv("CAMBUS_STATE_RECONCILE_WINDOW")); {
+	case raw == "":
+	case raw == "0" || strings.EqualFold(raw, "off"):
+		st.reconcileWindow = 0
+	default:
+		if d := envconf.Duration("CAMBUS_STATE_RECONCILE_WINDOW", 0); d > 0 {
+			st.reconcileWindow = d
+		}
+	}
+	return st
+}
+
+func (st *stateStore) load() (persistedState, error) {
+	var state persistedState
+	data, err := os.ReadFile(st.path)
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("decode %s: %w", st.path, err)
+	}
+	return state, nil
+}
+
+// save grava de forma atômica (tmp + rename). O arquivo tem credenciais das
+// câmeras, por isso 0600.
+func (st *stateStore) save(state persistedState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("encode state: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(st.path), filepath.Base(st.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), st.path)
+}
+
+// snapshotState monta o estado atual (câmeras conhecidas + último status).
+func (s *Supervisor) snapshotState() persistedState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := persistedState{
+		SavedAt:  time.Now().UTC(),
+		Cameras:  make(map[string]core.CameraInfo, len(s.cameras)),
+		Statuses: make(map[string]persistedStatus, len(s.workers)),
+	}
+	for key, info := range s.cameras {
+		state.Cameras[key] = info
+	}
+	for key, w := range s.workers {
+		state.Statuses[key] = persistedStatus{
+			Status:        w.status,
+			StatusSince:   w.statusSince,
+			EverConnected: w.everConnected,
+			LastEventAt:   w.lastEventAt,
+			Reconnects:    w.reconnects,
+		}
+	}
+	return state
+}
+
+func (s *Supervisor) saveState() {
+	if s.state == nil || s.state.frozen.Load() {
+		return
+	}
+	if err := s.state.save(s.snapshotState()); err != nil {
+		log.Printf("[supervisor] erro ao salvar estado em %s: %v", s.state.path, err)
+	}
+}
+
+// scheduleStateSave agenda um save (debounced) depois de uma mudança no mapa
+// de câmeras, sem esperar o CAMBUS_STATE_SAVE_INTERVAL.
+func (s *Supervisor) scheduleStateSave() {
+	if s.stateSync == nil {
+		return
+	}
+	s.stateSync.Trigger()
+}
+
+// finalSaveState grava o estado uma última vez e congela o arquivo: o
+// stopAll do shutdown remove as câmeras do mapa e não pode persistir isso.
+func (s *Supervisor) finalSaveState() {
+	if s.state == nil {
+		return
+	}
+	s.saveState()
+	s.state.frozen.Store(true)
+}
+
+// seedFromState carrega o arquivo de estado e sobe os workers antes dos /info
+// retidos chegarem. Deve rodar antes do subscribe.
+func (s *Supervisor) seedFromState(ctx context.Context) {
+	if s.state == nil {
+		return
+	}
+	state, err := s.state.load()
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[supervisor] erro ao carregar estado de %s: %v", s.state.path, err)
+		}
+		return
+	}
+
+	candidates := make(map[string]core.CameraInfo, len(state.Cameras))
+	for key, info := range state.Cameras {
+		if !info.Enabled || s.keyFor(info) != key {
+			continue
+		}
+		s.normalizeCameraInfo(&info)
+		candidates[key] = info
+	}
+	// CAMBUS_SHARDS: só sobe as câmeras que caem neste shard, como no /info
+	var owned map[string]bool
+	if s.shards != nil {
+		owned = s.shards.owns(candidates)
+	}
+
+	seeded := 0
+	for key, info := range candidates {
+		if owned != nil && !owned[key] {
+			continue
+		}
+		s.mu.Lock()
+		s.seeded[key] = info
+		if st, ok := state.Statuses[key]; ok {
+			s.restoredStatus[key] = st
+		}
+		s.mu.Unlock()
+		s.upsertCameraInfo(key, info)
+		s.scheduleCameraStart(key, info)
+		seeded++
+	}
+	if seeded == 0 {
+		return
+	}
+	s.refreshMediaMTXConfig()
+	if s.state.reconcileWindow <= 0 {
+		log.Printf("[supervisor] %d câmeras carregadas de %s (salvo em %s), mantidas até /info ou tombstone",
+			seeded, s.state.path, state.SavedAt.Format(time.RFC3339))
+		return
+	}
+	log.Printf("[supervisor] %d câmeras carregadas de %s (salvo em %s), aguardando /info por até %s",
+		seeded, s.state.path, state.SavedAt.Format(time.RFC3339), s.state.reconcileWindow)
+
+	time.AfterFunc(s.state.reconcileWindow, func() {
+		if ctx.Err() == nil {
+			s.dropUnconfirmedSeeds()
+		}
+	})
+}
+
+// restoreWorkerStatus repõe no worker recém-criado os contadores salvos da
+// câmera semeada (chamar com s.mu). Eles esperam em restoredStatus enquanto o
+// start está na fila (CAMBUS_START_RATE); o status de conexão em si volta a
+// ser "connecting" até o driver reportar.
+func (s *Supervisor) restoreWorkerStatus(key string, w *cameraWorker) {
+	st, ok := s.restoredStatus[key]
+	if !ok {
+		return
+	}
+	delete(s.restoredStatus, key)
+	w.everConnected = st.EverConnected
+	w.lastEventAt = st.LastEventAt
+	w.reconnects = st.Reconnects
+}
+
+// confirmSeed marca a câmera como confirmada por /info. Retorna true quando
+// a config recebida é igual à semeada (dá para pular o discovery do HA).
+func (s *Supervisor) confirmSeed(key string, info core.CameraInfo) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seededInfo, ok := s.seeded[key]
+	if !ok {
+		return false
+	}
+	delete(s.seeded, key)
+	return cameraInfoEqual(seededInfo, info)
+}
+
+// dropUnconfirmedSeeds remove câmeras do arquivo que não tiveram /info retido.
+func (s *Supervisor) dropUnconfirmedSeeds() {
+	s.mu.Lock()
+	pending := make([]core.CameraInfo, 0, len(s.seeded))
+	for key, info := range s.seeded {
+		pending = append(pending, info)
+		delete(s.seeded, key)
+	}
+	s.mu.Unlock()
+
+	for _, info := range pending {
+		log.Printf("[supervisor] camera %s do arquivo de estado sem /info, removendo", s.keyFor(info))
+		s.cleanupCamera(info, false)
+	}
+}
+
+func (s *Supervisor) runStateSaver(ctx context.Context) {
+	if s.state == nil {
+		return
+	}
+	ticker := time.NewTicker(s.state.saveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.saveState()
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package supervisor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/drivers"
+)
+
+func newStateSupervisor(t *testing.T) *Supervisor {
+	t.Helper()
+	t.Setenv("CAMBUS_STATE_FILE", filepath.Join(t.TempDir(), "state.json"))
+	t.Setenv("CAMBUS_STATE_RECONCILE_WINDOW", "off")
+	_, client := newFakeMQTT()
+	return &Supervisor{
+		mqtt:      client,
+		baseTopic: "cams",
+		workers:   map[string]*cameraWorker{},
+		cameras:   map[string]core.CameraInfo{},
+		seeded:    map[string]core.CameraInfo{},
+		state:     newStateStoreFromEnv(),
+
+		restoredStatus: map[string]persistedStatus{},
+		// fila parada: o seed só agenda, nenhum driver sobe no teste
+		starts: &startQueue{rate: 1, burst: 1, pending: map[string]core.CameraInfo{}, wake: make(chan struct{}, 1)},
+	}
+}
+
+func stateCamera(id string) core.CameraInfo {
+	return core.CameraInfo{Tenant: "t", Building: "b", Floor: "f", DeviceType: "cam", DeviceID: id, Enabled: true, Password: "segredo"}
+}
+
+func TestStateSaveAndReload(t *testing.T) {
+	s := newStateSupervisor(t)
+	info := stateCamera("c1")
+	key := s.keyFor(info)
+	s.cameras[key] = info
+	s.workers[key] = &cameraWorker{info: info, status: drivers.ConnectionStateOnline, everConnected: true, reconnects: 4}
+
+	s.saveState()
+	fi, err := os.Stat(s.state.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Fatalf("arquivo de estado com permissão %o, esperava 600", fi.Mode().Perm())
+	}
+
+	state, err := s.state.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := state.Cameras[key]; got.DeviceID != "c1" || got.Password != "segredo" {
+		t.Fatalf("câmera recarregada = %+v", got)
+	}
+	if st := state.Statuses[key]; !st.EverConnected || st.Reconnects != 4 {
+		t.Fatalf("status recarregado = %+v", st)
+	}
+}
+
+func TestSeedFromStateAndConfirm(t *testing.T) {
+	s := newStateSupervisor(t)
+	info := stateCamera("c1")
+	disabled := stateCamera("c2")
+	disabled.Enabled = false
+	if err := s.state.save(persistedState{
+		SavedAt: time.Now(),
+		Cameras: map[string]core.CameraInfo{s.keyFor(info): info, s.keyFor(disabled): disabled},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	s.seedFromState(context.Background())
+	key := s.keyFor(info)
+	if _, ok := s.activeCameraInfo(key); !ok || s.starts.len() != 1 {
+		t.Fatalf("câmera do arquivo não semeada (fila=%d)", s.starts.len())
+	}
+	if _, ok := s.activeCameraInfo(s.keyFor(disabled)); ok {
+		t.Fatal("câmera desabilitada não deveria ser semeada")
+	}
+
+	// o seed passa pela mesma normalização do /info
+	normalized := info
+	s.normalizeCameraInfo(&normalized)
+	if got, _ := s.activeCameraInfo(key); got.ProxyPath != "c1" {
+		t.Fatalf("seed sem normalização: proxy_path=%q", got.ProxyPath)
+	}
+	if !s.confirmSeed(key, normalized) {
+		t.Fatal("/info igual ao semeado deveria confirmar sem mudanças")
+	}
+	if s.confirmSeed(key, normalized) {
+		t.Fatal("seed já confirmado")
+	}
+}
+
+func TestSeedFromStateRespectsShards(t *testing.T) {
+	s := newStateSupervisor(t)
+	t.Setenv("CAMBUS_SHARDS", "a,b")
+	s.shards = newShardRouterFromEnv("a")
+	other := newShardRouterFromEnv("b")
+
+	cameras := map[string]core.CameraInfo{}
+	for _, id := range []string{"c1", "c2", "c3", "c4", "c5", "c6"} {
+		info := stateCamera(id)
+		cameras[s.keyFor(info)] = info
+	}
+	pinned := stateCamera("fixa")
+	pinned.Shard = "b"
+	cameras[s.keyFor(pinned)] = pinned
+	if err := s.state.save(persistedState{SavedAt: time.Now(), Cameras: cameras}); err != nil {
+		t.Fatal(err)
+	}
+
+	s.seedFromState(context.Background())
+	owned := other.owns(cameras)
+	for key := range cameras {
+		_, active := s.activeCameraInfo(key)
+		if active == owned[key] {
+			t.Fatalf("%s: semeada aqui=%t, dona em b=%t; esperava exatamente um dono", key, active, owned[key])
+		}
+	}
+	if _, ok := s.activeCameraInfo(s.keyFor(pinned)); ok {
+		t.Fatal("câmera fixada em b não pode ser semeada em a")
+	}
+	if len(s.shards.members) != 0 {
+		t.Fatal("o seed não deveria registrar câmeras na divisão antes do /info")
+	}
+}
+
+func TestRestoredStatusWaitsForWorker(t *testing.T) {
+	s := newStateSupervisor(t)
+	info := stateCamera("c1")
+	key := s.keyFor(info)
+	last := time.Now().Add(-time.Hour).UTC()
+	if err := s.state.save(persistedState{
+		SavedAt:  time.Now(),
+		Cameras:  map[string]core.CameraInfo{key: info},
+		Statuses: map[string]persistedStatus{key: {EverConnected: true, Reconnects: 3, LastEventAt: last}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	s.seedFromState(context.Background())
+	if s.starts.len() != 1 {
+		t.Fatal("com CAMBUS_START_RATE a câmera deveria esperar na fila")
+	}
+	if _, ok := s.restoredStatus[key]; !ok {
+		t.Fatal("contadores perdidos enquanto o start está na fila")
+	}
+
+	w := &cameraWorker{info: info}
+	s.restoreWorkerStatus(key, w)
+	if !w.everConnected || w.reconnects != 3 || !w.lastEventAt.Equal(last) {
+		t.Fatalf("worker = %+v, esperava os contadores do arquivo", w)
+	}
+	if _, ok := s.restoredStatus[key]; ok {
+		t.Fatal("contadores devem ser aplicados uma vez só")
+	}
+}
+
+func TestDropUnconfirmedSeeds(t *testing.T) {
+	s := newStateSupervisor(t)
+	info := stateCamera("c1")
+	key := s.keyFor(info)
+	s.cameras[key] = info
+	s.seeded[key] = info
+
+	s.dropUnconfirmedSeeds()
+	if _, ok := s.activeCameraInfo(key); ok {
+		t.Fatal("câmera sem /info deveria ser removida após a janela")
+	}
+}
+
+func TestFinalSaveStateFreezesFile(t *testing.T) {
+	s := newStateSupervisor(t)
+	s.stateSync = newDebouncer(0, s.saveState) // save síncrono a cada mudança
+	for _, id := range []string{"c1", "c2"} {
+		info := stateCamera(id)
+		s.cameras[s.keyFor(info)] = info
+	}
+
+	// mesma ordem do shutdown no Run
+	s.finalSaveState()
+	s.stopAll()
+	if len(s.cameras) != 0 {
+		t.Fatalf("stopAll deveria limpar o mapa, restaram %d", len(s.cameras))
+	}
+
+	state, err := s.state.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Cameras) != 2 {
+		t.Fatalf("arquivo com %d câmeras: o stopAll após o save final não pode esvaziá-lo", len(state.Cameras))
+	}
+}
+
+func TestStateSaveDebouncedOnCameraChange(t *testing.T) {
+	s := newStateSupervisor(t)
+	var saves atomic.Int64
+	s.stateSync = newDebouncer(30*time.Millisecond, func() {
+		saves.Add(1)
+		s.saveState()
+	})
+
+	c1, c2 := stateCamera("c1"), stateCamera("c2")
+	s.upsertCameraInfo(s.keyFor(c1), c1)
+	s.upsertCameraInfo(s.keyFor(c2), c2)
+	if _, err := os.Stat(s.state.path); !os.IsNotExist(err) {
+		t.Fatal("o save deveria esperar o debounce")
+	}
+	time.Sleep(100 * time.Millisecond)
+	state, err := s.state.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := saves.Load(); n != 1 || len(state.Cameras) != 2 {
+		t.Fatalf("saves=%d câmeras=%d, esperava 1 save com as 2 câmeras", n, len(state.Cameras))
+	}
+
+	// upsert sem mudança não agenda save
+	s.upsertCameraInfo(s.keyFor(c1), c1)
+	time.Sleep(60 * time.Millisecond)
+	if n := saves.Load(); n != 1 {
+		t.Fatalf("upsert igual gerou save (saves=%d)", n)
+	}
+
+	s.removeCameraInfo(s.keyFor(c1))
+	time.Sleep(100 * time.Millisecond)
+	state, _ = s.state.load()
+	if _, ok := state.Cameras[s.keyFor(c1)]; ok || saves.Load() != 2 {
+		t.Fatalf("remoção não persistida (saves=%d): %+v", saves.Load(), state.Cameras)
+	}
+}
+
+func TestStateReconcileWindowFromEnv(t *testing.T) {
+	t.Setenv("CAMBUS_STATE_FILE", filepath.Join(t.TempDir(), "state.json"))
+	for raw, want := range map[string]time.Duration{
+		"":    defaultStateReconcileWindow,
+		"0":   0,
+		"off": 0,
+		"OFF": 0,
+		"45s": 45 * time.Second,
+		"90":  90 * time.Second,
+		"abc": defaultStateReconcileWindow,
+	} {
+		t.Setenv("CAMBUS_STATE_RECONCILE_WINDOW", raw)
+		if got := newStateStoreFromEnv().reconcileWindow; got != want {
+			t.Errorf("CAMBUS_STATE_RECONCILE_WINDOW=%q: %s, esperava %s", raw, got, want)
+		}
+	}
+}
+
+func TestSeedKeptWithoutReconcileWindow(t *testing.T) {
+	for _, tc := range []struct {
+		window string
+		kept   bool
+	}{
+		{window: "0", kept: true},
+		{window: "30ms", kept: false},
+	} {
+		s := newStateSupervisor(t)
+		t.Setenv("CAMBUS_STATE_RECONCILE_WINDOW", tc.window)
+		s.state = newStateStoreFromEnv()
+		info := stateCamera("c1")
+		key := s.keyFor(info)
+		if err := s.state.save(persistedState{SavedAt: time.Now(), Cameras: map[string]core.CameraInfo{key: info}}); err != nil {
+			t.Fatal(err)
+		}
+
+		s.seedFromState(context.Background())
+		time.Sleep(100 * time.Millisecond)
+		if _, ok := s.activeCameraInfo(key); ok != tc.kept {
+			t.Errorf("CAMBUS_STATE_RECONCILE_WINDOW=%s: câmera sem /info ativa=%t, esperava %t", tc.window, ok, tc.kept)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// internal/supervisor/status_diff.go
+package supervisor
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/envconf"
+)
+
+const defaultStatusFullRefresh = 10 * time.Minute
+
+// statusVolatileFields mudam a cada ciclo e não contam como "mudança" do
+// status; só vão para o broker na próxima publicação (mudança ou refresh).
+var statusVolatileFields = []string{"timestamp", "last_event_at", "last_rtt_ms", "avg_rtt_ms"}
+
+// statusDiff reduz as escritas retained do status das câmeras
+// (STATUS_PUBLISH_CHANGED_ONLY): a cada ciclo só publica câmeras cujo status
+// mudou desde a última publicação e, a cada STATUS_FULL_REFRESH_SECONDS,
+// republica todas.
+type statusDiff struct {
+	fullRefresh time.Duration
+
+	mu       sync.Mutex
+	last     map[string]string // chave da câmera -> fingerprint publicado
+	lastFull time.Time
+}
+
+// newStatusDiffFromEnv devolve nil quando STATUS_PUBLISH_CHANGED_ONLY está
+// desligado (default: publica todas as câmeras a cada ciclo, como antes).
+func newStatusDiffFromEnv() *statusDiff {
+	if !envconf.Bool("STATUS_PUBLISH_CHANGED_ONLY", false) {
+		return nil
+	}
+	d := &statusDiff{
+		fullRefresh: envconf.Seconds("STATUS_FULL_REFRESH_SECONDS", defaultStatusFullRefresh),
+		last:        make(map[string]string),
+	}
+	log.Printf("[supervisor] status das câmeras só quando muda (refresh completo a cada %s)", d.fullRefresh)
+	return d
+}
+
+// beginCycle diz se este ciclo é um refresh completo. No refresh o estado é
+// zerado, o que também esquece câmeras removidas.
+func (d *statusDiff) beginCycle(now time.Time) bool {
+	if d == nil {
+		return true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.lastFull.IsZero() || now.Sub(d.lastFull) >= d.fullRefresh {
+		d.lastFull = now
+		d.last = make(map[string]string)
+		return true
+	}
+	return false
+}
+
+// changed diz se o payload difere do último publicado para a câmera.
+func (d *statusDiff) changed(key string, payload map[string]interface{}) (string, bool) {
+	if d == nil {
+		return "", true
+	}
+	fp := statusFingerprint(payload)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	last, ok := d.last[key]
+	return fp, !ok || last != fp
+}
+
+// published registra o fingerprint depois de publicar com sucesso.
+func (d *statusDiff) published(key, fingerprint string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	d.last[key] = fingerprint
+	d.mu.Unlock()
+}
+
+func statusFingerprint(payload map[string]interface{}) string {
+	stable := make(map[string]interface{}, len(payload))
+	for k, v := range payload {
+		stable[k] = v
+	}
+	for _, k := range statusVolatileFields {
+		delete(stable, k)
+	}
+	b, _ := json.Marshal(stable) // chaves ordenadas: determinístico
+	return string(b)
+}
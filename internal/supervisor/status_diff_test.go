@@ -0,0 +1,106 @@
+package supervisor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/drivers"
+)
+
+func TestStatusDiffSkipsUnchangedCameras(t *testing.T) {
+	fake, client := newFakeMQTT()
+	s := &Supervisor{
+		mqtt:       client,
+		baseTopic:  "cams",
+		statusDiff: &statusDiff{fullRefresh: 10 * time.Minute, last: map[string]string{}},
+	}
+	snap := func(id string, status drivers.ConnectionState, rtt time.Duration) workerSnapshot {
+		return workerSnapshot{
+			Info:    core.CameraInfo{Tenant: "t", Building: "b", Floor: "f", DeviceType: "cam", DeviceID: id},
+			Status:  status,
+			LastRTT: rtt,
+		}
+	}
+	t0 := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	cycle := func(now time.Time, snaps ...workerSnapshot) []string {
+		t.Helper()
+		fake.reset()
+		full := s.statusDiff.beginCycle(now)
+		for _, sn := range snaps {
+			if err := s.publishCameraStatus(sn, now, full); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var ids []string
+		for _, m := range fake.messages("/status") {
+			if !m.retained {
+				t.Fatalf("status de câmera deveria ser retained: %s", m.topic)
+			}
+			ids = append(ids, m.topic)
+		}
+		return ids
+	}
+
+	if got := cycle(t0, snap("c1", drivers.ConnectionStateOnline, 10*time.Millisecond), snap("c2", drivers.ConnectionStateOnline, 0)); len(got) != 2 {
+		t.Fatalf("primeiro ciclo (refresh completo): %v", got)
+	}
+
+	// só timestamp/RTT mudaram em c1; c2 caiu
+	got := cycle(t0.Add(30*time.Second),
+		snap("c1", drivers.ConnectionStateOnline, 80*time.Millisecond),
+		snap("c2", drivers.ConnectionStateOffline, 0))
+	if len(got) != 1 || got[0] != "cams/t/b/f/cam/c2/status" {
+		t.Fatalf("ciclo parcial: %v, esperava só a c2 que mudou", got)
+	}
+
+	if got := cycle(t0.Add(time.Minute),
+		snap("c1", drivers.ConnectionStateOnline, 80*time.Millisecond),
+		snap("c2", drivers.ConnectionStateOffline, 0)); len(got) != 0 {
+		t.Fatalf("sem mudança: %v", got)
+	}
+
+	// STATUS_FULL_REFRESH_SECONDS depois do último refresh republica todas
+	if got := cycle(t0.Add(10*time.Minute),
+		snap("c1", drivers.ConnectionStateOnline, 80*time.Millisecond),
+		snap("c2", drivers.ConnectionStateOffline, 0)); len(got) != 2 {
+		t.Fatalf("refresh completo: %v", got)
+	}
+}
+
+func TestStatusDiffDisabledPublishesEveryCycle(t *testing.T) {
+	var d *statusDiff
+	if !d.beginCycle(time.Now()) {
+		t.Fatal("sem STATUS_PUBLISH_CHANGED_ONLY todo ciclo é completo")
+	}
+	if _, changed := d.changed("k", map[string]interface{}{"status": "online"}); !changed {
+		t.Fatal("sem STATUS_PUBLISH_CHANGED_ONLY tudo conta como mudança")
+	}
+}
+
+func TestStatusFingerprintIgnoresVolatileFields(t *testing.T) {
+	a := statusFingerprint(map[string]interface{}{"status": "online", "timestamp": "t1", "last_event_at": "e1", "last_rtt_ms": 5, "avg_rtt_ms": 4})
+	b := statusFingerprint(map[string]interface{}{"status": "online", "timestamp": "t2", "last_event_at": "e2", "last_rtt_ms": 9, "avg_rtt_ms": 7})
+	if a != b {
+		t.Fatalf("fingerprints diferentes só por campos voláteis: %s / %s", a, b)
+	}
+	if c := statusFingerprint(map[string]interface{}{"status": "online", "reconnects": 1}); c == a {
+		t.Fatal("reconnects deveria contar como mudança")
+	}
+}
+
+func TestNewStatusDiffFromEnv(t *testing.T) {
+	t.Setenv("STATUS_PUBLISH_CHANGED_ONLY", "")
+	if d := newStatusDiffFromEnv(); d != nil {
+		t.Fatalf("desligado por default: %+v", d)
+	}
+	t.Setenv("STATUS_PUBLISH_CHANGED_ONLY", "true")
+	t.Setenv("STATUS_FULL_REFRESH_SECONDS", "60")
+	if d := newStatusDiffFromEnv(); d == nil || d.fullRefresh != time.Minute {
+		t.Fatalf("statusDiff = %+v", d)
+	}
+	t.Setenv("STATUS_FULL_REFRESH_SECONDS", "0")
+	if d := newStatusDiffFromEnv(); d.fullRefresh != defaultStatusFullRefresh {
+		t.Fatalf("refresh 0 deveria cair no default: %s", d.fullRefresh)
+	}
+}
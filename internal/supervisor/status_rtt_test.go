@@ -0,0 +1,53 @@
+package supervisor
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/drivers"
+)
+
+type fixedLatency struct{ last, avg time.Duration }
+
+func (f fixedLatency) LastRTT() time.Duration    { return f.last }
+func (f fixedLatency) AverageRTT() time.Duration { return f.avg }
+
+func TestCameraStatusCarriesRTT(t *testing.T) {
+	fake, client := newFakeMQTT()
+	s := &Supervisor{mqtt: client, baseTopic: "cams", workers: map[string]*cameraWorker{}}
+	info := core.CameraInfo{Tenant: "t", Building: "b", Floor: "f", DeviceType: "cam", DeviceID: "c1"}
+	s.workers[s.keyFor(info)] = &cameraWorker{
+		info:    info,
+		status:  drivers.ConnectionStateOnline,
+		latency: fixedLatency{last: 42 * time.Millisecond, avg: 37 * time.Millisecond},
+	}
+	other := core.CameraInfo{Tenant: "t", Building: "b", Floor: "f", DeviceType: "cam", DeviceID: "c2"}
+	s.workers[s.keyFor(other)] = &cameraWorker{info: other, status: drivers.ConnectionStateOnline}
+
+	for _, snap := range s.snapshotWorkers() {
+		if err := s.publishCameraStatus(snap, time.Now(), true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	payloadOf := func(id string) map[string]interface{} {
+		msgs := fake.messages("/" + id + "/status")
+		if len(msgs) != 1 {
+			t.Fatalf("%s: %d status publicados, esperava 1", id, len(msgs))
+		}
+		var p map[string]interface{}
+		if err := json.Unmarshal(msgs[0].payload, &p); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	p := payloadOf("c1")
+	if p["last_rtt_ms"] != float64(42) || p["avg_rtt_ms"] != float64(37) {
+		t.Fatalf("status sem RTT: %v", p)
+	}
+	if p := payloadOf("c2"); p["last_rtt_ms"] != nil || p["avg_rtt_ms"] != nil {
+		t.Fatalf("driver sem LatencyReporter não deve publicar RTT: %v", p)
+	}
+}
@@ -0,0 +1,54 @@
+package supervisor
+
+import (
+	"testing"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func TestConfigEqualitySplit(t *testing.T) {
+	base := core.CameraInfo{DeviceID: "c1", IP: "10.0.0.1", RecordRetentionMinutes: 5}
+
+	retention := base
+	retention.RecordRetentionMinutes = 10
+	if !driverConfigEqual(base, retention) || streamConfigEqual(base, retention) {
+		t.Fatal("mudar só a retenção não deveria afetar o driver")
+	}
+
+	ip := base
+	ip.IP = "10.0.0.2"
+	if driverConfigEqual(base, ip) {
+		t.Fatal("mudar o IP deveria reiniciar o driver")
+	}
+	if cameraInfoEqual(base, retention) || cameraInfoEqual(base, ip) || !cameraInfoEqual(base, base) {
+		t.Fatal("cameraInfoEqual deveria cobrir driver e streaming")
+	}
+}
+
+func TestRetentionOnlyChangeKeepsWorker(t *testing.T) {
+	_, client := newFakeMQTT()
+	s := &Supervisor{mqtt: client, baseTopic: "cams", workers: map[string]*cameraWorker{}}
+	info := core.CameraInfo{Tenant: "t", Building: "b", Floor: "f", DeviceType: "cam", DeviceID: "c1", RecordRetentionMinutes: 5}
+	key := s.keyFor(info)
+	canceled := 0
+	s.workers[key] = &cameraWorker{info: info, cancel: func() { canceled++ }}
+
+	updated := info
+	updated.RecordRetentionMinutes = 30
+	s.startOrUpdateCamera(updated)
+
+	w, ok := s.workers[key]
+	if !ok || canceled != 0 {
+		t.Fatalf("mudança só de retenção reiniciou o worker (cancel=%d)", canceled)
+	}
+	if w.info.RecordRetentionMinutes != 30 {
+		t.Fatalf("config do worker não atualizada: %d", w.info.RecordRetentionMinutes)
+	}
+
+	moved := updated
+	moved.IP = "10.0.0.9"
+	s.startOrUpdateCamera(moved)
+	if canceled != 1 {
+		t.Fatalf("mudança de IP deveria parar o worker (cancel=%d)", canceled)
+	}
+}
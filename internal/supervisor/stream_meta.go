@@ -0,0 +1,109 @@
+// internal/supervisor/stream_meta.go
+package supervisor
+
+import (
+	"log"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/envconf"
+	"github.com/sua-org/cam-bus/internal/uplink"
+)
+
+// streamMetaConfig monta o bloco "stream" (PUBLISH_STREAM_META) publicado no
+// status da câmera e em Meta["stream"] dos eventos, para UIs montarem o player
+// sem receber credenciais. As bases são as URLs públicas do MediaMTX:
+// STREAM_RTSP_BASE_URL, STREAM_HLS_BASE_URL e STREAM_WEBRTC_BASE_URL (proxy) e
+// STREAM_CENTRAL_RTSP_BASE_URL (central). Base vazia = URL omitida.
+type streamMetaConfig struct {
+	enabled         bool
+	rtspBase        string
+	hlsBase         string
+	webrtcBase      string
+	centralRTSPBase string
+}
+
+func streamMetaConfigFromEnv() streamMetaConfig {
+	return streamMetaConfig{
+		enabled:         envconf.Bool("PUBLISH_STREAM_META", false),
+		rtspBase:        publicBaseURL("STREAM_RTSP_BASE_URL"),
+		hlsBase:         publicBaseURL("STREAM_HLS_BASE_URL"),
+		webrtcBase:      publicBaseURL("STREAM_WEBRTC_BASE_URL"),
+		centralRTSPBase: publicBaseURL("STREAM_CENTRAL_RTSP_BASE_URL"),
+	}
+}
+
+// publicBaseURL lê a base e descarta usuário/senha, se houver.
+func publicBaseURL(key string) string {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		log.Printf("[supervisor] %s inválida (%q), ignorando", key, raw)
+		return ""
+	}
+	if u.User != nil {
+		log.Printf("[supervisor] %s com credenciais; elas não são publicadas", key)
+		u.User = nil
+	}
+	return strings.TrimSuffix(u.String(), "/")
+}
+
+// streamMeta descreve como chegar no vídeo da câmera (nil se desligado ou
+// se a câmera não passa pelo MediaMTX).
+func (s *Supervisor) cameraStreamMeta(info core.CameraInfo) map[string]interface{} {
+	cfg := s.streamMeta
+	if !cfg.enabled {
+		return nil
+	}
+	out := map[string]interface{}{}
+	if proxyPath := s.mtxGen.PathFor(info); proxyPath != "" {
+		out["proxy_path"] = proxyPath
+		out["protocol"] = "rtsp"
+		if cfg.rtspBase != "" {
+			out["rtsp_url"] = cfg.rtspBase + "/" + proxyPath
+		}
+		if cfg.hlsBase != "" {
+			out["hls_url"] = cfg.hlsBase + "/" + proxyPath + "/index.m3u8"
+		}
+		if cfg.webrtcBase != "" {
+			out["webrtc_url"] = cfg.webrtcBase + "/" + proxyPath
+		}
+	}
+
+	centralPath := s.mtxCentralGen.PathFor(info)
+	if centralPath == "" && strings.TrimSpace(info.CentralHost) != "" {
+		centralPath = strings.TrimSpace(info.CentralPath)
+		if centralPath == "" {
+			centralPath = uplink.CentralPathFor(info)
+		}
+	}
+	if centralPath != "" {
+		out["central_path"] = centralPath
+		if cfg.centralRTSPBase != "" {
+			out["central_rtsp_url"] = cfg.centralRTSPBase + "/" + centralPath
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// withStreamMeta adiciona Meta["stream"] ao evento publicado.
+func (s *Supervisor) withStreamMeta(evt *core.AnalyticEvent, info core.CameraInfo) {
+	stream := s.cameraStreamMeta(info)
+	if stream == nil {
+		return
+	}
+	meta := cloneMeta(evt.Meta)
+	if meta == nil {
+		meta = map[string]interface{}{}
+	}
+	meta["stream"] = stream
+	evt.Meta = meta
+}
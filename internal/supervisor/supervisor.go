@@ -8,16 +8,21 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"strconv"
+	"reflect"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/shirou/gopsutil/v3/process"
+	"github.com/sua-org/cam-bus/internal/admin"
 	"github.com/sua-org/cam-bus/internal/core"
 	"github.com/sua-org/cam-bus/internal/drivers"
 	"github.com/sua-org/cam-bus/internal/engines"
+	"github.com/sua-org/cam-bus/internal/envconf"
+	"github.com/sua-org/cam-bus/internal/logging"
 	"github.com/sua-org/cam-bus/internal/mediamtx"
+	"github.com/sua-org/cam-bus/internal/metrics"
 	"github.com/sua-org/cam-bus/internal/mqttclient"
 	"github.com/sua-org/cam-bus/internal/uplink"
 )
@@ -38,7 +43,68 @@ type Supervisor struct {
 	uplinkStatus   map[string]uplink.Status
 	workers        map[string]*cameraWorker
 	statusInterval time.Duration
-	proc           *process.Process // <- NOVO: processo do cam-bus para métricas
+	statusDiff     *statusDiff       // STATUS_PUBLISH_CHANGED_ONLY (nil = publica todas a cada ciclo)
+	lastFace       *lastFaceRetainer // FACE_LAST_RETAINED (nil = só o evento ao vivo)
+	proc           *process.Process  // <- NOVO: processo do cam-bus para métricas
+
+	// collectorAvailabilityTopic recebe online no start e offline no shutdown;
+	// no crash, o Last Will do cliente MQTT publica offline (CollectorAvailabilityTopic)
+	collectorAvailabilityTopic string
+
+	// eventPublisher enfileira as publicações de eventos (MQTT_PUBLISH_QUEUE_SIZE);
+	// nil = publica direto no mqtt, esperando o broker
+	eventPublisher *mqttclient.Publisher
+
+	// heartbeatInterval > 0 liga o evento "heartbeat" por câmera (CAMERA_HEARTBEAT_INTERVAL)
+	heartbeatInterval time.Duration
+
+	// Home Assistant MQTT Discovery (HA_DISCOVERY_ENABLED / HA_DISCOVERY_PREFIX)
+	haDiscoveryEnabled bool
+	haDiscoveryPrefix  string
+	// troca de host na URL do snapshot das entidades de imagem
+	// (HA_SNAPSHOT_URL_FROM -> HA_SNAPSHOT_URL_TO); vazio = URL sem alteração
+	haSnapshotURLFrom string
+	haSnapshotURLTo   string
+
+	// emitConnectivity publica cameraOnline/cameraOffline no tópico de eventos (EMIT_CONNECTIVITY_EVENTS)
+	emitConnectivity bool
+
+	// tombstoneWindow > 0 coalesce tombstone + /info rápidos (INFO_TOMBSTONE_COALESCE)
+	tombstoneWindow   time.Duration
+	pendingTombstones map[string]*time.Timer
+
+	// driverRestart reinicia workers cujo driver saiu sem cancelamento (DRIVER_EXIT_RESTART),
+	// com backoff exponencial e no máximo driverRestartMax tentativas seguidas (0 = sem limite)
+	driverRestart         bool
+	driverRestartDelay    time.Duration
+	driverRestartMaxDelay time.Duration
+	driverRestartMax      int
+	driverRestarts        map[string]driverRestartState // falhas levadas ao próximo worker
+
+	// state persiste câmeras/status em CAMBUS_STATE_FILE (nil = desligado);
+	// seeded guarda as câmeras carregadas que ainda aguardam /info e
+	// restoredStatus os contadores delas até o worker ser criado.
+	state          *stateStore
+	seeded         map[string]core.CameraInfo
+	restoredStatus map[string]persistedStatus
+
+	// shards != nil divide as câmeras entre instâncias (CAMBUS_SHARDS)
+	shards *shardRouter
+
+	timestamps     timestampPolicy
+	snapshotTopic  snapshotTopicPolicy
+	inactive       inactivePolicy    // EVENT_INACTIVE_POLICY
+	dedup          *eventDedup       // CAMBUS_DEDUP_WINDOW_MS (nil = sem deduplicação)
+	rateLimit      *eventRateLimiter // CAMBUS_MAX_EVENTS_PER_SEC (nil = sem limite)
+	snapshotB64    snapshotB64Policy // PUBLISH_SNAPSHOT_B64
+	topicSuffixes  topicSuffixRules  // TOPIC_SUFFIX_RULES
+	mtxSync        *debouncer        // coalesce refreshMediaMTXConfig (MTX_SYNC_DEBOUNCE)
+	stateSync      *debouncer        // coalesce saves do CAMBUS_STATE_FILE (nil = sem arquivo)
+	analyticPolicy analyticPolicies  // ANALYTIC_POLICY
+	streamMeta     streamMetaConfig  // PUBLISH_STREAM_META
+	metrics        *metrics.Registry // exportado via METRICS_EXPORTER
+	starts         *startQueue       // nil = start imediato (CAMBUS_START_RATE vazio)
+	admin          *admin.Server     // nil = ADMIN_ADDR vazio
 }
 
 type cameraWorker struct {
@@ -50,6 +116,13 @@ type cameraWorker struct {
 	statusReason  string
 	everConnected bool
 	analytics     []string
+	latency       drivers.LatencyReporter // nil se o driver não mede RTT
+	reconnects    int
+	lastReconnect time.Time
+	exitReason    string // motivo da última saída do driver (driverExitReason)
+	exitedAt      time.Time
+	restartCount  int    // restarts seguidos sem o driver conectar
+	lastError     string // motivo da última saída com erro/inesperada
 }
 
 type workerSnapshot struct {
@@ -60,6 +133,14 @@ type workerSnapshot struct {
 	StatusReason  string
 	EverConnected bool
 	Analytics     []string
+	LastRTT       time.Duration
+	AvgRTT        time.Duration
+	Reconnects    int
+	LastReconnect time.Time
+	ExitReason    string
+	ExitedAt      time.Time
+	RestartCount  int
+	LastError     string
 }
 
 type uplinkState struct {
@@ -79,21 +160,62 @@ func (s *Supervisor) snapshotWorkers() []workerSnapshot {
 
 	out := make([]workerSnapshot, 0, len(s.workers))
 	for _, w := range s.workers {
-		out = append(out, workerSnapshot{
-			Info:          w.info,
-			LastEventAt:   w.lastEventAt,
-			Status:        w.status,
-			StatusSince:   w.statusSince,
-			StatusReason:  w.statusReason,
-			EverConnected: w.everConnected,
-			Analytics:     w.analytics,
-		})
+		out = append(out, w.snapshot())
 	}
 	return out
 }
 
+func (s *Supervisor) workerSnapshot(key string) (workerSnapshot, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.workers[key]
+	if !ok {
+		return workerSnapshot{}, false
+	}
+	return w.snapshot(), true
+}
+
+// snapshot copia o estado do worker (chamar com s.mu).
+func (w *cameraWorker) snapshot() workerSnapshot {
+	snap := workerSnapshot{
+		Info:          w.info,
+		LastEventAt:   w.lastEventAt,
+		Status:        w.status,
+		StatusSince:   w.statusSince,
+		StatusReason:  w.statusReason,
+		EverConnected: w.everConnected,
+		Analytics:     w.analytics,
+		Reconnects:    w.reconnects,
+		LastReconnect: w.lastReconnect,
+		ExitReason:    w.exitReason,
+		ExitedAt:      w.exitedAt,
+		RestartCount:  w.restartCount,
+		LastError:     w.lastError,
+	}
+	if w.latency != nil {
+		snap.LastRTT = w.latency.LastRTT()
+		snap.AvgRTT = w.latency.AverageRTT()
+	}
+	return snap
+}
+
 // Atualiza última vez que recebemos evento dessa câmera
+// workerInfo devolve a CameraInfo corrente do worker (atualizada em mudanças
+// de stream/paths que não reiniciam o driver).
+func (s *Supervisor) workerInfo(w *cameraWorker) core.CameraInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return w.info
+}
+
 func (s *Supervisor) touchWorker(key string) {
+	var emit func()
+	defer func() {
+		if emit != nil {
+			emit()
+		}
+	}()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -101,15 +223,23 @@ func (s *Supervisor) touchWorker(key string) {
 		now := time.Now().UTC()
 		w.lastEventAt = now
 		if w.status != drivers.ConnectionStateOnline {
+			emit = s.connectivityTransition(w, drivers.ConnectionStateOnline, "evento recebido", now)
 			w.status = drivers.ConnectionStateOnline
 			w.statusSince = now
 			w.statusReason = ""
 		}
 		w.everConnected = true
+		w.restartCount = 0
 	}
 }
 
 func (s *Supervisor) updateWorkerStatus(key string, update drivers.StatusUpdate) {
+	var emit func()
+	defer func() {
+		if emit != nil {
+			emit()
+		}
+	}()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -119,11 +249,19 @@ func (s *Supervisor) updateWorkerStatus(key string, update drivers.StatusUpdate)
 	}
 
 	now := time.Now().UTC()
+	emit = s.connectivityTransition(w, update.State, update.Reason, now)
 	w.status = update.State
 	w.statusReason = update.Reason
 	w.statusSince = now
 	if update.State == drivers.ConnectionStateOnline {
 		w.everConnected = true
+		w.restartCount = 0
+	}
+	if update.Reconnect {
+		w.reconnects++
+		w.lastReconnect = now
+		s.metrics.Add(metricReconnects, 1, map[string]string{"manufacturer": strings.ToLower(w.info.Manufacturer)})
+		log.Printf("[supervisor] camera %s reconectando (total=%d)", key, w.reconnects)
 	}
 }
 
@@ -139,7 +277,34 @@ func New(mqtt *mqttclient.Client, baseTopic string) *Supervisor {
 
 	eng := engines.LoadFromEnv()
 	uplinkManager := uplink.NewManagerFromEnv()
-	statusInterval := envDurationSeconds("CAMBUS_STATUS_INTERVAL_SECONDS", 30*time.Second)
+	statusInterval := envconf.Seconds("CAMBUS_STATUS_INTERVAL_SECONDS", 30*time.Second)
+	heartbeatInterval := envconf.Duration("CAMERA_HEARTBEAT_INTERVAL", 0)
+	if heartbeatInterval > 0 {
+		log.Printf("[supervisor] heartbeat por câmera habilitado (intervalo=%s)", heartbeatInterval)
+	}
+	haDiscoveryEnabled := envconf.Bool("HA_DISCOVERY_ENABLED", true)
+	haDiscoveryPrefix := strings.Trim(strings.TrimSpace(os.Getenv("HA_DISCOVERY_PREFIX")), "/")
+	if haDiscoveryPrefix == "" {
+		haDiscoveryPrefix = "homeassistant"
+	}
+	if !haDiscoveryEnabled {
+		log.Printf("[supervisor] HA discovery desabilitado (HA_DISCOVERY_ENABLED=false)")
+	}
+	haSnapshotURLFrom, haSnapshotURLTo := haSnapshotURLRewriteFromEnv()
+	driverRestartDelay := envconf.Duration("DRIVER_EXIT_RESTART_DELAY", 0)
+	if driverRestartDelay <= 0 {
+		driverRestartDelay = defaultDriverRestartDelay
+	}
+	driverRestartMaxDelay := envconf.Duration("DRIVER_EXIT_RESTART_MAX_DELAY", 0)
+	if driverRestartMaxDelay <= 0 {
+		driverRestartMaxDelay = defaultDriverRestartMaxDelay
+	}
+	if driverRestartMaxDelay < driverRestartDelay {
+		driverRestartMaxDelay = driverRestartDelay
+	}
+	lastFace := newLastFaceRetainerFromEnv(func(topic string, payload []byte) error {
+		return mqtt.Publish(topic, 1, true, payload)
+	})
 	var procHandle *process.Process
 	if p, err := process.NewProcess(int32(os.Getpid())); err == nil {
 		procHandle = p
@@ -158,27 +323,62 @@ func New(mqtt *mqttclient.Client, baseTopic string) *Supervisor {
 		uplinkStatus:   make(map[string]uplink.Status),
 		workers:        make(map[string]*cameraWorker),
 		statusInterval: statusInterval,
+		statusDiff:     newStatusDiffFromEnv(),
+		lastFace:       lastFace,
 		proc:           procHandle,
+
+		collectorAvailabilityTopic: CollectorAvailabilityTopic(baseTopic),
+
+		eventPublisher: mqttclient.NewPublisherFromEnv(mqtt),
+
+		heartbeatInterval: heartbeatInterval,
+
+		haDiscoveryEnabled: haDiscoveryEnabled,
+		haDiscoveryPrefix:  haDiscoveryPrefix,
+		haSnapshotURLFrom:  haSnapshotURLFrom,
+		haSnapshotURLTo:    haSnapshotURLTo,
+
+		emitConnectivity: envconf.Bool("EMIT_CONNECTIVITY_EVENTS", false),
+
+		tombstoneWindow:   envconf.Duration("INFO_TOMBSTONE_COALESCE", 0),
+		pendingTombstones: make(map[string]*time.Timer),
+
+		driverRestart:         envconf.Bool("DRIVER_EXIT_RESTART", true),
+		driverRestartDelay:    driverRestartDelay,
+		driverRestartMaxDelay: driverRestartMaxDelay,
+		driverRestartMax:      envconf.Int("DRIVER_EXIT_RESTART_MAX", 0),
+		driverRestarts:        make(map[string]driverRestartState),
+
+		state:          newStateStoreFromEnv(),
+		seeded:         make(map[string]core.CameraInfo),
+		restoredStatus: make(map[string]persistedStatus),
+
+		shards: newShardRouterFromEnv(shard),
+
+		timestamps:     timestampPolicyFromEnv(),
+		snapshotTopic:  snapshotTopicPolicyFromEnv(),
+		inactive:       inactivePolicyFromEnv(),
+		dedup:          newEventDedupFromEnv(),
+		rateLimit:      newEventRateLimiterFromEnv(),
+		snapshotB64:    snapshotB64PolicyFromEnv(),
+		topicSuffixes:  topicSuffixRulesFromEnv(),
+		streamMeta:     streamMetaConfigFromEnv(),
+		analyticPolicy: analyticPoliciesFromEnv(),
+		starts:         newStartQueueFromEnv(),
+		metrics:        metrics.NewRegistry(),
+		admin:          admin.NewFromEnv(),
 	}
+	supervisor.mtxSync = newDebouncer(mtxSyncDebounceFromEnv(), supervisor.syncMediaMTX)
+	if supervisor.state != nil {
+		supervisor.stateSync = newDebouncer(supervisor.state.saveDebounce, supervisor.saveState)
+	}
+	supervisor.registerMetrics()
 	if supervisor.uplink != nil {
 		supervisor.uplink.SetStatusHook(supervisor.handleUplinkStatus)
 	}
 	return supervisor
 }
 
-func envDurationSeconds(key string, def time.Duration) time.Duration {
-	v := strings.TrimSpace(os.Getenv(key))
-	if v == "" {
-		return def
-	}
-	sec, err := strconv.Atoi(v)
-	if err != nil || sec <= 0 {
-		log.Printf("[supervisor] valor inválido em %s=%q, usando default %s", key, v, def)
-		return def
-	}
-	return time.Duration(sec) * time.Second
-}
-
 func hasAnalytic(info core.CameraInfo, name string) bool {
 	for _, a := range info.Analytics {
 		if strings.EqualFold(a, name) {
@@ -210,9 +410,78 @@ func slugForCamera(info core.CameraInfo) string {
 	return base
 }
 
-// publishHADiscovery publica entidades MQTT Discovery para o Home Assistant
-// para uma câmera que tenha analítico faceRecognized.
+// publishHADiscovery publica entidades MQTT Discovery para o Home Assistant:
+// reconhecimento de face (câmeras com analítico de face e FindFace) e
+// contagem de pessoas (câmeras com PeopleCounting/CrowdDetection).
 func (s *Supervisor) publishHADiscovery(info core.CameraInfo) error {
+	if !s.haDiscoveryEnabled {
+		return nil
+	}
+	if err := s.publishHAFaceDiscovery(info); err != nil {
+		return err
+	}
+	return s.publishHAPeopleCountDiscovery(info)
+}
+
+// haEntity monta a base de uma entidade de discovery: nome, unique_id, os
+// objetos device/origin comuns às entidades da câmera e a disponibilidade.
+// A entidade fica disponível só com a câmera e o collector online: o tópico
+// da câmera cobre disable/shutdown e o do collector (Last Will) cobre o crash.
+func (s *Supervisor) haEntity(info core.CameraInfo, name, uniqueID string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":      name,
+		"unique_id": uniqueID,
+		"availability": []map[string]interface{}{
+			{"topic": s.availabilityTopic(info)},
+			{"topic": s.collectorAvailabilityTopic},
+		},
+		"availability_mode":     "all",
+		"payload_available":     AvailabilityOnline,
+		"payload_not_available": AvailabilityOffline,
+		"device": map[string]interface{}{
+			"identifiers":  []string{"rtls_camera_" + slugForCamera(info)},
+			"name":         fmt.Sprintf("Câmera %s (%s %s, %s)", info.DeviceID, info.Building, info.Floor, info.Tenant),
+			"manufacturer": info.Manufacturer,
+			"model":        info.Model,
+		},
+		"origin": map[string]interface{}{
+			"name": "rtls-cam-bus",
+		},
+	}
+}
+
+// haSnapshotURLRewriteFromEnv lê HA_SNAPSHOT_URL_FROM/TO; TO sem FROM é ignorado.
+func haSnapshotURLRewriteFromEnv() (from, to string) {
+	from = strings.TrimSpace(os.Getenv("HA_SNAPSHOT_URL_FROM"))
+	to = strings.TrimSpace(os.Getenv("HA_SNAPSHOT_URL_TO"))
+	if from == "" && to != "" {
+		log.Printf("[supervisor] HA_SNAPSHOT_URL_TO sem HA_SNAPSHOT_URL_FROM, ignorado")
+		return "", ""
+	}
+	return from, to
+}
+
+// haSnapshotURLTemplate monta o url_template da entidade de imagem: a
+// SnapshotURL do evento, com from trocado por to quando from é definido (ex.:
+// host interno do MinIO -> host acessível pelo HA).
+func haSnapshotURLTemplate(from, to string) string {
+	if from == "" {
+		return "{{ value_json.SnapshotURL }}"
+	}
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return fmt.Sprintf("{{ value_json.SnapshotURL | replace('%s', '%s') }}", quote.Replace(from), quote.Replace(to))
+}
+
+// withFields copia fields sobre a base da entidade.
+func withFields(entity map[string]interface{}, fields map[string]interface{}) map[string]interface{} {
+	for k, v := range fields {
+		entity[k] = v
+	}
+	return entity
+}
+
+// publishHAFaceDiscovery publica as entidades de faceRecognized.
+func (s *Supervisor) publishHAFaceDiscovery(info core.CameraInfo) error {
 	if s.engines == nil || !s.engines.Has("findface") {
 		return nil
 	}
@@ -224,135 +493,118 @@ func (s *Supervisor) publishHADiscovery(info core.CameraInfo) error {
 	}
 
 	slug := slugForCamera(info)
-	deviceID := "rtls_camera_" + slug
 
-	// tópico dos eventos de faceRecognized
+	// tópico dos eventos de faceRecognized; os sensores leem do retained do
+	// último reconhecimento quando FACE_LAST_RETAINED está ligado
 	eventTopic := s.eventTopic(info, "faceRecognized")
-
-	// objeto comum de device
-	deviceObj := map[string]interface{}{
-		"identifiers":  []string{deviceID},
-		"name":         fmt.Sprintf("Câmera %s (%s %s, %s)", info.DeviceID, info.Building, info.Floor, info.Tenant),
-		"manufacturer": info.Manufacturer,
-		"model":        info.Model,
-	}
+	stateTopic := s.faceStateTopic(info)
 
 	// 1) Binary sensor: alerta FaceRecognized
-	binCfg := map[string]interface{}{
-		"name":                  fmt.Sprintf("FaceRecognized %s", info.DeviceID),
-		"unique_id":             slug + "_face_recognized",
+	binCfg := withFields(s.haEntity(info, fmt.Sprintf("FaceRecognized %s", info.DeviceID), slug+"_face_recognized"), map[string]interface{}{
 		"state_topic":           eventTopic,
 		"value_template":        "{% if value_json.AnalyticType == 'faceRecognized' and value_json.Meta.eventState == 'active' %}ON{% else %}OFF{% endif %}",
 		"payload_on":            "ON",
 		"payload_off":           "OFF",
 		"expire_after":          10,
 		"json_attributes_topic": eventTopic,
-		"device":                deviceObj,
-		"origin": map[string]interface{}{
-			"name": "rtls-cam-bus",
-		},
-	}
+	})
 	if err := s.publishDiscoveryConfig("binary_sensor", slug+"_face_recognized", binCfg); err != nil {
 		return err
 	}
 
 	// 2) Sensor: CPF / ID pessoa
-	personCfg := map[string]interface{}{
-		"name":           fmt.Sprintf("Face Recognition CPF %s", info.DeviceID),
-		"unique_id":      slug + "_face_person",
-		"state_topic":    eventTopic,
+	personCfg := withFields(s.haEntity(info, fmt.Sprintf("Face Recognition CPF %s", info.DeviceID), slug+"_face_person"), map[string]interface{}{
+		"state_topic":    stateTopic,
 		"value_template": "{{ value_json.Meta.ff_person_name }}",
 		"icon":           "mdi:account",
-		"device":         deviceObj,
-		"origin": map[string]interface{}{
-			"name": "rtls-cam-bus",
-		},
-	}
+	})
 	if err := s.publishDiscoveryConfig("sensor", slug+"_face_person", personCfg); err != nil {
 		return err
 	}
 
 	// 3) Sensor: mensagem amigável
-	msgCfg := map[string]interface{}{
-		"name":           fmt.Sprintf("Face Recognition Msg %s", info.DeviceID),
-		"unique_id":      slug + "_face_message",
-		"state_topic":    eventTopic,
+	msgCfg := withFields(s.haEntity(info, fmt.Sprintf("Face Recognition Msg %s", info.DeviceID), slug+"_face_message"), map[string]interface{}{
+		"state_topic":    stateTopic,
 		"value_template": "Reconhecido com a pessoa: {{ value_json.Meta.ff_person_name }}",
 		"icon":           "mdi:account-badge",
-		"device":         deviceObj,
-		"origin": map[string]interface{}{
-			"name": "rtls-cam-bus",
-		},
-	}
+	})
 	if err := s.publishDiscoveryConfig("sensor", slug+"_face_message", msgCfg); err != nil {
 		return err
 	}
 
 	// 4) Sensor: confiança
-	confCfg := map[string]interface{}{
-		"name":                fmt.Sprintf("Face Recognition Confiança %s", info.DeviceID),
-		"unique_id":           slug + "_face_confidence",
-		"state_topic":         eventTopic,
+	confCfg := withFields(s.haEntity(info, fmt.Sprintf("Face Recognition Confiança %s", info.DeviceID), slug+"_face_confidence"), map[string]interface{}{
+		"state_topic":         stateTopic,
 		"value_template":      "{{ (value_json.Meta.ff_confidence * 100) | round(1) }}",
 		"unit_of_measurement": "%",
 		"icon":                "mdi:shield-half-full",
-		"device":              deviceObj,
-		"origin": map[string]interface{}{
-			"name": "rtls-cam-bus",
-		},
-	}
+	})
 	if err := s.publishDiscoveryConfig("sensor", slug+"_face_confidence", confCfg); err != nil {
 		return err
 	}
 
 	// 5) Sensor: horário
-	timeCfg := map[string]interface{}{
-		"name":           fmt.Sprintf("Face Recognition Horário %s", info.DeviceID),
-		"unique_id":      slug + "_face_time",
-		"state_topic":    eventTopic,
+	timeCfg := withFields(s.haEntity(info, fmt.Sprintf("Face Recognition Horário %s", info.DeviceID), slug+"_face_time"), map[string]interface{}{
+		"state_topic":    stateTopic,
 		"device_class":   "timestamp",
 		"value_template": "{{ as_datetime(value_json.Timestamp) }}",
-		"device":         deviceObj,
-		"origin": map[string]interface{}{
-			"name": "rtls-cam-bus",
-		},
-	}
+	})
 	if err := s.publishDiscoveryConfig("sensor", slug+"_face_time", timeCfg); err != nil {
 		return err
 	}
 
-	// 6) Entidade de imagem: snapshot (corrigindo localhost -> minio)
-	imgCfg := map[string]interface{}{
-		"name":         fmt.Sprintf("Face Snapshot %s", info.DeviceID),
-		"unique_id":    slug + "_face_snapshot",
-		"url_topic":    eventTopic,
-		"url_template": "{{ value_json.SnapshotURL | replace('http://localhost:9000', 'http://minio:9000') }}",
-		"device":       deviceObj,
-		"origin": map[string]interface{}{
-			"name": "rtls-cam-bus",
-		},
-	}
+	// 6) Entidade de imagem: snapshot (com a troca de host de HA_SNAPSHOT_URL_FROM/TO)
+	imgCfg := withFields(s.haEntity(info, fmt.Sprintf("Face Snapshot %s", info.DeviceID), slug+"_face_snapshot"), map[string]interface{}{
+		"url_topic":    stateTopic,
+		"url_template": haSnapshotURLTemplate(s.haSnapshotURLFrom, s.haSnapshotURLTo),
+	})
 	if err := s.publishDiscoveryConfig("image", slug+"_face_snapshot", imgCfg); err != nil {
 		return err
 	}
 
 	// 7) Entidade de imagem: foto da base (card do FindFace)
-	dbImgCfg := map[string]interface{}{
-		"name":         fmt.Sprintf("Face DB Photo %s", info.DeviceID),
-		"unique_id":    slug + "_face_db_photo",
-		"url_topic":    eventTopic,
+	dbImgCfg := withFields(s.haEntity(info, fmt.Sprintf("Face DB Photo %s", info.DeviceID), slug+"_face_db_photo"), map[string]interface{}{
+		"url_topic":    stateTopic,
 		"url_template": "{{ value_json.Meta.ff_person_photo_url }}",
-		"device":       deviceObj,
-		"origin": map[string]interface{}{
-			"name": "rtls-cam-bus",
-		},
-	}
+	})
 	if err := s.publishDiscoveryConfig("image", slug+"_face_db_photo", dbImgCfg); err != nil {
 		return err
 	}
 
+	// 8) Sensor: categoria da pessoa (watch list: vip, employee, blocklist...)
+	categoryCfg := withFields(s.haEntity(info, fmt.Sprintf("Face Recognition Categoria %s", info.DeviceID), slug+"_face_category"), map[string]interface{}{
+		"state_topic":    stateTopic,
+		"value_template": "{{ value_json.Meta.ff_person_category | default('unknown') }}",
+		"icon":           "mdi:account-group",
+	})
+	if err := s.publishDiscoveryConfig("sensor", slug+"_face_category", categoryCfg); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// publishHAPeopleCountDiscovery publica um sensor numérico por analítico de
+// contagem configurado na câmera, lendo Meta.people_count do evento.
+func (s *Supervisor) publishHAPeopleCountDiscovery(info core.CameraInfo) error {
+	slug := slugForCamera(info)
+	for _, analytic := range peopleCountAnalyticsOf(info) {
+		objectID := slug + "_" + strings.ToLower(analytic) + "_count"
+		cfg := withFields(s.haEntity(info, fmt.Sprintf("Pessoas %s %s", analytic, info.DeviceID), objectID), map[string]interface{}{
+			"state_topic":           s.eventTopic(info, analytic),
+			"value_template":        "{{ value_json.Meta.people_count | default(none) }}",
+			"state_class":           "measurement",
+			"unit_of_measurement":   "pessoas",
+			"icon":                  "mdi:account-multiple",
+			"json_attributes_topic": s.eventTopic(info, analytic),
+		})
+		if err := s.publishDiscoveryConfig("sensor", objectID, cfg); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
 func (s *Supervisor) runStatusLoop(ctx context.Context) {
 	hostname, _ := os.Hostname()
 	ticker := time.NewTicker(s.statusInterval)
@@ -403,6 +655,7 @@ func (s *Supervisor) publishStatuses(hostname string, now time.Time) {
 	}
 
 	buildingMap := make(map[buildingKey]int)
+	full := s.statusDiff.beginCycle(now)
 
 	// 1) Status das câmeras
 	for _, w := range workers {
@@ -412,7 +665,7 @@ func (s *Supervisor) publishStatuses(hostname string, now time.Time) {
 		}
 		buildingMap[bk]++
 
-		if err := s.publishCameraStatus(w, now); err != nil {
+		if err := s.publishCameraStatus(w, now, full); err != nil {
 			log.Printf("[status] erro ao publicar status da câmera %s: %v", s.keyFor(w.Info), err)
 		}
 	}
@@ -456,6 +709,22 @@ func (s *Supervisor) publishCollectorStatusForBuilding(
 		"memory_percent":   memPercent,
 		"memory_rss_bytes": memRSSBytes,
 	}
+	if p := s.eventPublisher; p != nil {
+		payload["mqtt_publish_queue"] = p.Len()
+		payload["mqtt_publish_dropped"] = p.Dropped()
+		payload["mqtt_publish_failed"] = p.Failed()
+	}
+	if ff, ok := s.engines.Load()["findface"]; ok {
+		payload["findface_in_flight"] = ff.InFlight
+		payload["findface_queue_depth"] = ff.QueueDepth
+	}
+	if ff, ok := s.engines.Health()["findface"]; ok {
+		payload["findface_reachable"] = ff.Reachable
+		payload["findface_checked_at"] = ff.CheckedAt.Format(time.RFC3339)
+		if ff.Error != "" {
+			payload["findface_error"] = ff.Error
+		}
+	}
 
 	b, err := json.Marshal(payload)
 	if err != nil {
@@ -471,9 +740,13 @@ func (s *Supervisor) publishCollectorStatusForBuilding(
 	return nil
 }
 
+// publishCameraStatus publica o status retained da câmera; com
+// STATUS_PUBLISH_CHANGED_ONLY e fora do refresh completo (full=false), pula
+// câmeras sem mudança desde a última publicação.
 func (s *Supervisor) publishCameraStatus(
 	snap workerSnapshot,
 	now time.Time,
+	full bool,
 ) error {
 	payload := map[string]interface{}{
 		"tenant":      snap.Info.Tenant,
@@ -506,6 +779,35 @@ func (s *Supervisor) publishCameraStatus(
 	if snap.EverConnected {
 		payload["ever_connected"] = snap.EverConnected
 	}
+	if snap.LastRTT > 0 {
+		payload["last_rtt_ms"] = snap.LastRTT.Milliseconds()
+	}
+	if snap.AvgRTT > 0 {
+		payload["avg_rtt_ms"] = snap.AvgRTT.Milliseconds()
+	}
+	payload["reconnects"] = snap.Reconnects
+	if !snap.LastReconnect.IsZero() {
+		payload["last_reconnect_at"] = snap.LastReconnect.UTC().Format(time.RFC3339)
+	}
+	if snap.ExitReason != "" {
+		payload["driver_exit_reason"] = snap.ExitReason
+		payload["driver_exited_at"] = snap.ExitedAt.UTC().Format(time.RFC3339)
+	}
+	if snap.RestartCount > 0 {
+		payload["driver_restart_count"] = snap.RestartCount
+	}
+	if snap.LastError != "" {
+		payload["driver_last_error"] = snap.LastError
+	}
+	if stream := s.cameraStreamMeta(snap.Info); stream != nil {
+		payload["stream"] = stream
+	}
+
+	key := s.keyFor(snap.Info)
+	fingerprint, changed := s.statusDiff.changed(key, payload)
+	if !full && !changed {
+		return nil
+	}
 
 	b, err := json.Marshal(payload)
 	if err != nil {
@@ -516,13 +818,18 @@ func (s *Supervisor) publishCameraStatus(
 	if err := s.mqtt.Publish(topic, 1, true, b); err != nil {
 		return fmt.Errorf("publish camera status to %s: %w", topic, err)
 	}
+	s.statusDiff.published(key, fingerprint)
 
 	log.Printf("[status] camera status published -> %s", topic)
 	return nil
 }
 
+func (s *Supervisor) discoveryTopic(component, objectID string) string {
+	return fmt.Sprintf("%s/%s/%s/config", s.haDiscoveryPrefix, component, objectID)
+}
+
 func (s *Supervisor) publishDiscoveryConfig(component, objectID string, cfg map[string]interface{}) error {
-	topic := fmt.Sprintf("homeassistant/%s/%s/config", component, objectID)
+	topic := s.discoveryTopic(component, objectID)
 	payload, err := json.Marshal(cfg)
 	if err != nil {
 		return fmt.Errorf("marshal discovery %s: %w", topic, err)
@@ -544,19 +851,53 @@ func (s *Supervisor) Run(ctx context.Context) error {
 	uplinkTopic := fmt.Sprintf("%s/+/+/+/+/+/uplink/+", s.baseTopic)
 	log.Printf("[supervisor] subscribing to uplink topic: %s", uplinkTopic)
 
+	if s.starts != nil {
+		go s.starts.run(ctx, func(info core.CameraInfo) {
+			// a câmera pode ter sido removida enquanto esperava na fila
+			if _, ok := s.activeCameraInfo(s.keyFor(info)); !ok {
+				return
+			}
+			s.startOrUpdateCamera(info)
+		})
+	}
+	// CAMBUS_STATE_FILE: sobe as câmeras conhecidas antes dos /info retidos
+	s.seedFromState(ctx)
+
 	if err := s.mqtt.Subscribe(infoTopic, 1, s.handleInfoMessage); err != nil {
 		return fmt.Errorf("subscribe error: %w", err)
 	}
 	if err := s.mqtt.Subscribe(uplinkTopic, 1, s.handleUplinkMessage); err != nil {
 		return fmt.Errorf("subscribe uplink error: %w", err)
 	}
+	if s.lastFace != nil {
+		// retained de antes do restart: reagenda ou apaga os vencidos
+		lastFaceTopic := fmt.Sprintf("%s/+/+/+/+/+/faceRecognized/last", s.baseTopic)
+		if err := s.mqtt.Subscribe(lastFaceTopic, 1, s.lastFace.handleRetained); err != nil {
+			log.Printf("[supervisor] erro ao assinar %s: %v", lastFaceTopic, err)
+		}
+	}
+	s.publishAvailability(AvailabilityOnline)
+	s.watchConnection()
 	if s.statusInterval > 0 {
 		go s.runStatusLoop(ctx)
 	}
+	waitMetrics := metrics.StartFromEnv(ctx, s.metrics)
+	go s.runStateSaver(ctx)
+	s.registerAdminRoutes()
+	go s.admin.Run(ctx)
 
 	<-ctx.Done()
 	log.Printf("[supervisor] context canceled, stopping all workers")
+	s.finalSaveState()
 	s.stopAll()
+	// o Sync que tira os paths das câmeras paradas não pode ficar no timer
+	s.mtxSync.Flush()
+	s.eventPublisher.Close()
+	s.engines.Close()
+	s.lastFace.stop()
+	// shutdown limpo não dispara o Last Will
+	s.publishAvailability(AvailabilityOffline)
+	waitMetrics()
 	return nil
 }
 
@@ -609,8 +950,14 @@ func (s *Supervisor) handleInfoMessage(topic string, payload []byte) {
 			DeviceID:   devID,
 		}
 		key := s.keyFor(info)
+		if s.shards != nil {
+			s.removeShardMember(key)
+		}
+		if s.deferTombstone(key, info) {
+			return
+		}
 		log.Printf("[supervisor] camera %s removed via tombstone", key)
-		s.cleanupCamera(info)
+		s.cleanupCamera(info, false)
 		return
 	}
 
@@ -626,6 +973,19 @@ func (s *Supervisor) handleInfoMessage(topic string, payload []byte) {
 	info.DeviceType = devType
 	info.DeviceID = devID
 
+	s.normalizeCameraInfo(&info)
+
+	// CAMBUS_SHARDS: só aplica as câmeras que caem neste shard
+	if s.shards != nil {
+		s.routeShardedInfo(info)
+		return
+	}
+	s.applyCameraInfo(info)
+}
+
+// normalizeCameraInfo aplica defaults e corrige campos inválidos do /info
+// (também usado nas câmeras carregadas do CAMBUS_STATE_FILE).
+func (s *Supervisor) normalizeCameraInfo(info *core.CameraInfo) {
 	info.RTSPURL = strings.TrimSpace(info.RTSPURL)
 	info.ProxyPath = strings.TrimSpace(info.ProxyPath)
 	info.CentralHost = strings.TrimSpace(info.CentralHost)
@@ -639,14 +999,14 @@ func (s *Supervisor) handleInfoMessage(topic string, payload []byte) {
 		info.ProxyPath = defaultProxyPath
 	}
 	if info.CentralPath == "" {
-		info.CentralPath = uplink.CentralPathFor(info)
+		info.CentralPath = uplink.CentralPathFor(*info)
 	}
-	if s.uplink != nil && (s.uplink.IgnoreUplinkEnabled() || s.uplink.AlwaysOnEnabled(info)) {
+	if s.uplink != nil && (s.uplink.IgnoreUplinkEnabled() || s.uplink.AlwaysOnEnabled(*info)) {
 		if info.CentralHost == "" {
 			info.CentralHost = s.uplink.DefaultCentralHost()
 		}
 		if info.CentralPath == "" {
-			info.CentralPath = uplink.CentralPathFor(info)
+			info.CentralPath = uplink.CentralPathFor(*info)
 		}
 	}
 	if info.RecordRetentionMinutes < 0 {
@@ -662,18 +1022,53 @@ func (s *Supervisor) handleInfoMessage(topic string, payload []byte) {
 		log.Printf("[supervisor] pre_roll_seconds inválido para %s, usando 0", info.DeviceID)
 		info.PreRollSeconds = 0
 	}
+	if info.SubscribeHeartbeatSeconds < 0 {
+		log.Printf("[supervisor] subscribe_heartbeat_seconds inválido para %s, usando o padrão", info.DeviceID)
+		info.SubscribeHeartbeatSeconds = 0
+	}
+	info.EventTransport = strings.ToLower(strings.TrimSpace(info.EventTransport))
+	if !drivers.ValidEventTransport(info.EventTransport) {
+		log.Printf("[supervisor] event_transport %q inválido para %s, usando http", info.EventTransport, info.DeviceID)
+		info.EventTransport = ""
+	}
+	if info.PollIntervalSeconds < 0 {
+		log.Printf("[supervisor] poll_interval_seconds inválido para %s, usando o padrão", info.DeviceID)
+		info.PollIntervalSeconds = 0
+	}
+	if info.SnapshotChannel < 0 || info.SnapshotType < 0 {
+		log.Printf("[supervisor] snapshot_channel/snapshot_type inválido para %s, usando o padrão", info.DeviceID)
+		info.SnapshotChannel = max(info.SnapshotChannel, 0)
+		info.SnapshotType = max(info.SnapshotType, 0)
+	}
+	info.AuthMode = strings.ToLower(strings.TrimSpace(info.AuthMode))
+	if !drivers.ValidAuthMode(info.AuthMode) {
+		log.Printf("[supervisor] auth_mode %q inválido para %s, usando digest", info.AuthMode, info.DeviceID)
+		info.AuthMode = ""
+	}
+}
 
-	// TODO: filtro de shard, se quiser (shard por camera, etc.)
-
+// applyCameraInfo aplica um /info já normalizado: worker, uplink, MediaMTX e discovery.
+func (s *Supervisor) applyCameraInfo(info core.CameraInfo) {
 	key := s.keyFor(info)
 
+	// tombstone + /info em sequência (restart forçado pelo orquestrador):
+	// reinicia só o driver, mantendo uplink e MediaMTX.
+	if s.takePendingTombstone(key) && info.Enabled {
+		log.Printf("[supervisor] camera %s: tombstone + /info coalescidos, reiniciando só o worker", key)
+		s.stopCamera(key)
+	}
+
 	// Se a câmera estiver desabilitada, para worker
 	if !info.Enabled {
 		log.Printf("[supervisor] camera %s disabled via info topic, stopping worker", key)
-		s.cleanupCamera(info)
+		s.cleanupCamera(info, false)
 		return
 	}
 
+	if s.uplink != nil {
+		s.uplink.CancelScheduledStop(info)
+	}
+	sameAsSeed := s.confirmSeed(key, info)
 	s.upsertCameraInfo(key, info)
 
 	if state, ok := s.activeUplinkState(key); ok {
@@ -717,13 +1112,40 @@ func (s *Supervisor) handleInfoMessage(topic string, payload []byte) {
 		}
 	}
 
-	// Publica discovery para o Home Assistant (se tiver faceRecognized)
-	if err := s.publishHADiscovery(info); err != nil {
-		log.Printf("[supervisor] erro ao publicar discovery para %s: %v", key, err)
+	// Publica discovery para o Home Assistant (se tiver faceRecognized).
+	// Câmera semeada do arquivo de estado com a mesma config: discovery já está retido.
+	if !sameAsSeed {
+		if err := s.publishHADiscovery(info); err != nil {
+			log.Printf("[supervisor] erro ao publicar discovery para %s: %v", key, err)
+		}
 	}
 
 	// Por fim, inicia/atualiza o worker normalmente
-	s.startOrUpdateCamera(info)
+	s.scheduleCameraStart(key, info)
+}
+
+// scheduleCameraStart inicia o worker na hora ou, com CAMBUS_START_RATE, põe
+// câmeras novas na fila de start escalonado. Workers já rodando são
+// atualizados direto.
+func (s *Supervisor) scheduleCameraStart(key string, info core.CameraInfo) {
+	if s.starts == nil {
+		s.startOrUpdateCamera(info)
+		return
+	}
+	if s.starts.update(key, info) {
+		log.Printf("[supervisor] camera %s ainda na fila de start, config pendente atualizada", key)
+		return
+	}
+
+	s.mu.Lock()
+	_, running := s.workers[key]
+	s.mu.Unlock()
+	if running {
+		s.startOrUpdateCamera(info)
+		return
+	}
+	s.starts.enqueue(key, info)
+	log.Printf("[supervisor] camera %s enfileirada para start (fila=%d)", key, s.starts.len())
 }
 
 func (s *Supervisor) handleUplinkMessage(topic string, payload []byte) {
@@ -901,8 +1323,14 @@ func (s *Supervisor) keyFor(info core.CameraInfo) string {
 }
 
 // cameraInfoEqual compara se duas configs de câmera são equivalentes
-// para o propósito de decidir se precisamos reiniciar o driver.
+// em todos os campos que o supervisor usa (driver + streaming).
 func cameraInfoEqual(a, b core.CameraInfo) bool {
+	return driverConfigEqual(a, b) && streamConfigEqual(a, b)
+}
+
+// driverConfigEqual compara só os campos que afetam o driver da câmera
+// (conexão, analytics, identidade dos eventos). Diferença aqui => restart do worker.
+func driverConfigEqual(a, b core.CameraInfo) bool {
 	if a.Tenant != b.Tenant ||
 		a.Building != b.Building ||
 		a.Floor != b.Floor ||
@@ -916,14 +1344,21 @@ func cameraInfoEqual(a, b core.CameraInfo) bool {
 		a.Username != b.Username ||
 		a.Password != b.Password ||
 		a.UseTLS != b.UseTLS ||
+		a.CertFingerprint != b.CertFingerprint ||
+		a.StorageProfile != b.StorageProfile ||
+		a.SubscribeHeartbeatSeconds != b.SubscribeHeartbeatSeconds ||
+		a.EventTransport != b.EventTransport ||
+		a.PollIntervalSeconds != b.PollIntervalSeconds ||
+		a.SnapshotPath != b.SnapshotPath ||
+		a.MotionPath != b.MotionPath ||
+		a.MotionKey != b.MotionKey ||
+		a.AuthMode != b.AuthMode ||
+		a.SnapshotChannel != b.SnapshotChannel ||
+		a.SnapshotType != b.SnapshotType ||
+		!slices.Equal(a.Channels, b.Channels) ||
+		!reflect.DeepEqual(a.SessionLogin, b.SessionLogin) ||
 		a.Enabled != b.Enabled ||
-		a.Shard != b.Shard ||
-		a.RTSPURL != b.RTSPURL ||
-		a.ProxyPath != b.ProxyPath ||
-		a.CentralPath != b.CentralPath ||
-		a.RecordEnabled != b.RecordEnabled ||
-		a.RecordRetentionMinutes != b.RecordRetentionMinutes ||
-		a.PreRollSeconds != b.PreRollSeconds {
+		a.Shard != b.Shard {
 		return false
 	}
 
@@ -940,16 +1375,33 @@ func cameraInfoEqual(a, b core.CameraInfo) bool {
 	return true
 }
 
+// streamConfigEqual compara os campos que só afetam MediaMTX/uplink
+// (RTSP, paths, central, gravação). Diferença aqui => só re-sync do MediaMTX.
+func streamConfigEqual(a, b core.CameraInfo) bool {
+	return a.RTSPURL == b.RTSPURL &&
+		a.ProxyPath == b.ProxyPath &&
+		a.CentralHost == b.CentralHost &&
+		a.CentralSRTPort == b.CentralSRTPort &&
+		a.CentralPath == b.CentralPath &&
+		a.RecordEnabled == b.RecordEnabled &&
+		a.RecordRetentionMinutes == b.RecordRetentionMinutes &&
+		a.PreRollSeconds == b.PreRollSeconds
+}
+
 func (s *Supervisor) startOrUpdateCamera(info core.CameraInfo) {
 	key := s.keyFor(info)
 
 	s.mu.Lock()
 	shouldRefresh := false
+	availability := "" // publicado fora do lock
 	defer func() {
 		s.mu.Unlock()
 		if shouldRefresh {
 			go s.refreshMediaMTXConfig()
 		}
+		if availability != "" {
+			s.publishCameraAvailability(info, availability)
+		}
 	}()
 
 	if w, ok := s.workers[key]; ok {
@@ -958,6 +1410,13 @@ func (s *Supervisor) startOrUpdateCamera(info core.CameraInfo) {
 			log.Printf("[supervisor] camera %s already running with same config, ignoring update", key)
 			return
 		}
+		if driverConfigEqual(w.info, info) {
+			// Só mudou gravação/uplink/paths: driver continua, MediaMTX é re-sincronizado.
+			log.Printf("[supervisor] camera %s stream config changed, refreshing MediaMTX without restarting driver", key)
+			w.info = info
+			shouldRefresh = true
+			return
+		}
 
 		// Config mudou => reinicia worker.
 		log.Printf("[supervisor] camera %s config changed, restarting worker", key)
@@ -966,9 +1425,17 @@ func (s *Supervisor) startOrUpdateCamera(info core.CameraInfo) {
 		shouldRefresh = true
 	}
 
+	// falhas seguidas do worker anterior quando o start é um restart (handleDriverExit)
+	restarts := s.driverRestarts[key]
+	delete(s.driverRestarts, key)
+
 	drv, err := drivers.GetDriver(info)
 	if err != nil {
 		log.Printf("[supervisor] no driver for camera %s: %v", key, err)
+		if shouldRefresh {
+			// o worker anterior foi parado e não há substituto
+			availability = AvailabilityOffline
+		}
 		go s.refreshMediaMTXConfig()
 		return
 	}
@@ -984,10 +1451,17 @@ func (s *Supervisor) startOrUpdateCamera(info core.CameraInfo) {
 		statusSince:  time.Now().UTC(),
 		statusReason: "aguardando conexão",
 		analytics:    analytics,
+		restartCount: restarts.count,
+		lastError:    restarts.lastError,
+	}
+	if reporter, ok := drv.(drivers.LatencyReporter); ok {
+		worker.latency = reporter
 	}
+	s.restoreWorkerStatus(key, worker)
 
 	s.workers[key] = worker
 	shouldRefresh = true
+	availability = AvailabilityOnline
 
 	if statusAware, ok := drv.(drivers.StatusAwareDriver); ok {
 		statusAware.SetStatusHandler(func(update drivers.StatusUpdate) {
@@ -995,7 +1469,7 @@ func (s *Supervisor) startOrUpdateCamera(info core.CameraInfo) {
 		})
 	}
 
-	log.Printf("[supervisor] starting camera worker %s (%s %s, shard=%s)", key, info.Manufacturer, info.Model, info.Shard)
+	logging.With(logging.Camera(key, info.Tenant)).Infof("[supervisor] starting camera worker %s (%s %s, shard=%s)", key, info.Manufacturer, info.Model, info.Shard)
 
 	// Goroutine que roda o driver (Hikvision, etc.)
 	go func() {
@@ -1003,65 +1477,48 @@ func (s *Supervisor) startOrUpdateCamera(info core.CameraInfo) {
 			cancel()
 			close(eventsCh)
 		}()
-		if err := drv.Run(ctx, eventsCh); err != nil {
-			log.Printf("[worker %s] driver ended with error: %v", key, err)
-		} else {
-			log.Printf("[worker %s] driver ended gracefully", key)
+		err := runDriver(ctx, key, drv, eventsCh)
+		reason := classifyDriverExit(ctx, err)
+		logger := logging.With(logging.Camera(key, info.Tenant))
+		switch reason {
+		case driverExitPanic:
+			logger.Errorf("[worker %s] driver ended with %v", key, err)
+		case driverExitError:
+			logger.Errorf("[worker %s] driver ended with error: %v", key, err)
+		case driverExitUnexpected:
+			logger.Warnf("[worker %s] driver returned without cancel (unexpected exit)", key)
+		default:
+			logger.Infof("[worker %s] driver ended gracefully", key)
 		}
+		s.handleDriverExit(key, worker, reason, err)
 	}()
 
+	if s.heartbeatInterval > 0 {
+		go s.runHeartbeat(ctx, key, info)
+	}
+
 	// Goroutine que publica eventos no MQTT e aciona engines (pós-processadores)
 	go func() {
-		defer s.updateWorkerStatus(key, drivers.StatusUpdate{State: drivers.ConnectionStateOffline, Reason: "event stream encerrado"})
 		for evt := range eventsCh {
-			// 1) publica evento original (faceCapture, FaceDetection, PeopleCounting, etc.)
 			s.touchWorker(key)
-			// Faz uma cópia só para publicação, sem o base64 (para não explodir o MQTT).
-			evtOut := evt
-			evtOut.SnapshotB64 = ""
-
-			topic := s.eventTopic(info, evtOut.AnalyticType)
-			payload, err := json.Marshal(evtOut)
-			if err != nil {
-				log.Printf("[worker %s] error marshaling event: %v", key, err)
-			} else {
-				if err := s.mqtt.Publish(topic, 1, false, payload); err != nil {
-					log.Printf("[worker %s] error publishing to %s: %v", key, topic, err)
-				} else {
-					log.Printf("[worker %s] published event to %s (event_id=%s)", key, topic, evt.EventID)
-				}
-			}
-
-			// 2) Engines: geram eventos derivados (ex.: faceRecognized)
-			if s.engines != nil && s.engines.Enabled() {
-				derived, _ := s.engines.ProcessAll(ctx, evt)
-				for _, dEvt := range derived {
-					outEvt := dEvt
-					outEvt.SnapshotB64 = ""
-
-					outTopic := s.eventTopic(info, outEvt.AnalyticType)
-					outPayload, err := json.Marshal(outEvt)
-					if err != nil {
-						log.Printf("[worker %s] erro ao marshalar evento derivado (%s): %v", key, outEvt.AnalyticType, err)
-						continue
-					}
-					if err := s.mqtt.Publish(outTopic, 1, false, outPayload); err != nil {
-						log.Printf("[worker %s] erro ao publicar evento derivado (%s) em %s: %v", key, outEvt.AnalyticType, outTopic, err)
-						continue
-					}
-					log.Printf("[worker %s] published derived event (%s) -> %s (event_id=%s)", key, outEvt.AnalyticType, outTopic, outEvt.EventID)
-				}
-			}
+			// info atual: um /info que só muda paths/stream troca w.info sem reiniciar o driver
+			s.handleCameraEvent(ctx, key, s.workerInfo(worker), evt)
 		}
 	}()
 }
 
 func (s *Supervisor) eventTopic(info core.CameraInfo, analyticType string) string {
+	return s.eventTopicFor(info, analyticType, "")
+}
+
+// eventTopicFor monta o tópico do evento; o último nível vem de
+// TOPIC_SUFFIX_RULES conforme analytic/EventState (default "events").
+func (s *Supervisor) eventTopicFor(info core.CameraInfo, analyticType, state string) string {
 	analyticType = strings.TrimSpace(analyticType)
 	if analyticType == "" {
 		analyticType = "unknown"
 	}
-	return fmt.Sprintf("%s/%s/%s/%s/%s/%s/%s/events",
+	return fmt.Sprintf("%s/%s/%s/%s/%s/%s/%s/%s",
 		s.baseTopic,
 		info.Tenant,
 		info.Building,
@@ -1069,6 +1526,7 @@ func (s *Supervisor) eventTopic(info core.CameraInfo, analyticType string) strin
 		info.DeviceType,
 		info.DeviceID,
 		analyticType,
+		s.topicSuffixes.suffix(analyticType, state),
 	)
 }
 
@@ -1105,6 +1563,7 @@ func (s *Supervisor) stopCamera(key string) {
 }
 
 func (s *Supervisor) stopAll() {
+	s.stopPendingTombstones()
 	s.mu.Lock()
 	infosByKey := make(map[string]core.CameraInfo, len(s.cameras)+len(s.workers))
 	for key, info := range s.cameras {
@@ -1116,23 +1575,55 @@ func (s *Supervisor) stopAll() {
 	s.mu.Unlock()
 
 	for _, info := range infosByKey {
-		s.cleanupCamera(info)
+		s.cleanupCamera(info, true)
 	}
 }
 
-func (s *Supervisor) cleanupCamera(info core.CameraInfo) {
+// cleanupCamera para o worker e remove a câmera. Com immediate=false o uplink
+// respeita UPLINK_TEARDOWN_GRACE (disable/tombstone); no shutdown para na hora.
+func (s *Supervisor) cleanupCamera(info core.CameraInfo, immediate bool) {
 	key := s.keyFor(info)
 	log.Printf("[supervisor] cleanup camera %s (handleInfoMessage/stopAll)", key)
+	if s.starts != nil {
+		s.starts.remove(key)
+	}
+	s.mu.Lock()
+	delete(s.seeded, key)
+	delete(s.restoredStatus, key)
+	s.mu.Unlock()
 	s.stopCamera(key)
+	s.rateLimit.forget(key)
+	s.publishCameraAvailability(info, AvailabilityOffline)
 	s.removeCameraInfo(key)
-	s.clearUplinkState(key)
-	if s.uplink != nil {
+	switch {
+	case s.uplink == nil:
+		s.clearUplinkState(key)
+	case immediate:
+		s.uplink.CancelScheduledStop(info)
+		s.clearUplinkState(key)
 		s.uplink.StopByCamera(info)
+	default:
+		// O estado do uplink fica até o teardown efetivo, para que uma
+		// câmera que volte dentro do grace recupere central host/path.
+		s.uplink.ScheduleStopByCamera(info, func() {
+			s.clearUplinkState(key)
+			s.refreshMediaMTXConfig()
+		})
 	}
 	s.refreshMediaMTXConfig()
 }
 
+// refreshMediaMTXConfig pede um Sync dos MediaMTX. Pedidos em rajada (várias
+// câmeras subindo/caindo) viram um Sync só com o conjunto final de câmeras,
+// MTX_SYNC_DEBOUNCE depois do último pedido (0 = Sync imediato).
 func (s *Supervisor) refreshMediaMTXConfig() {
+	if s.mtxGen == nil && s.mtxCentralGen == nil {
+		return
+	}
+	s.mtxSync.Trigger()
+}
+
+func (s *Supervisor) syncMediaMTX() {
 	if s.mtxGen != nil {
 		infos := s.snapshotCameraInfosForMediaMTX()
 		if err := s.mtxGen.Sync(infos); err != nil {
@@ -1247,8 +1738,12 @@ func (s *Supervisor) snapshotCameraInfos() []core.CameraInfo {
 
 func (s *Supervisor) upsertCameraInfo(key string, info core.CameraInfo) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	prev, existed := s.cameras[key]
 	s.cameras[key] = info
+	s.mu.Unlock()
+	if !existed || !cameraInfoEqual(prev, info) {
+		s.scheduleStateSave()
+	}
 }
 
 func (s *Supervisor) activeUplinkState(key string) (uplinkState, bool) {
@@ -1261,10 +1756,21 @@ func (s *Supervisor) activeUplinkState(key string) (uplinkState, bool) {
 	return state, true
 }
 
-func (s *Supervisor) removeCameraInfo(key string) {
+func (s *Supervisor) activeCameraInfo(key string) (core.CameraInfo, bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	info, ok := s.cameras[key]
+	return info, ok
+}
+
+func (s *Supervisor) removeCameraInfo(key string) {
+	s.mu.Lock()
+	_, existed := s.cameras[key]
 	delete(s.cameras, key)
+	s.mu.Unlock()
+	if existed {
+		s.scheduleStateSave()
+	}
 }
 
 func (s *Supervisor) setUplinkState(key string, req uplink.Request) {
@@ -0,0 +1,94 @@
+// internal/supervisor/timestamps.go
+package supervisor
+
+import (
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/envconf"
+)
+
+// timestampSource define qual horário vira o Timestamp do evento publicado
+// (EVENT_TIMESTAMP_SOURCE=camera|received|engine).
+type timestampSource string
+
+const (
+	timestampCamera   timestampSource = "camera"
+	timestampReceived timestampSource = "received"
+	timestampEngine   timestampSource = "engine"
+
+	defaultTimestampMaxSkew = 24 * time.Hour
+)
+
+type timestampPolicy struct {
+	source  timestampSource
+	maxSkew time.Duration // camera_ts mais distante que isso de received_at é implausível
+}
+
+func timestampPolicyFromEnv() timestampPolicy {
+	p := timestampPolicy{
+		source:  timestampCamera,
+		maxSkew: envconf.Seconds("EVENT_TIMESTAMP_MAX_SKEW_SECONDS", defaultTimestampMaxSkew),
+	}
+	raw := strings.ToLower(strings.TrimSpace(os.Getenv("EVENT_TIMESTAMP_SOURCE")))
+	switch timestampSource(raw) {
+	case "":
+	case timestampCamera, timestampReceived, timestampEngine:
+		p.source = timestampSource(raw)
+	default:
+		log.Printf("[supervisor] EVENT_TIMESTAMP_SOURCE=%q inválido, usando %s", raw, timestampCamera)
+	}
+	return p
+}
+
+// applyReceived ajusta o evento original na chegada ao supervisor. Registra
+// received_at no Meta e escolhe o Timestamp: camera_ts (se plausível) ou received.
+func (p timestampPolicy) applyReceived(evt *core.AnalyticEvent, received time.Time) {
+	if evt.Meta == nil {
+		evt.Meta = map[string]interface{}{}
+	}
+	evt.Meta["received_at"] = received.UTC().Format(time.RFC3339Nano)
+
+	evt.Timestamp = received.UTC()
+	if p.source != timestampCamera {
+		return
+	}
+	if cameraTS, ok := p.cameraTimestamp(evt.Meta, received); ok {
+		evt.Timestamp = cameraTS
+	}
+}
+
+// applyEngine ajusta eventos derivados: com source=engine o Timestamp passa a
+// ser o horário em que a engine terminou o processamento.
+func (p timestampPolicy) applyEngine(evt *core.AnalyticEvent, processed time.Time) {
+	if p.source != timestampEngine {
+		return
+	}
+	if evt.Meta == nil {
+		evt.Meta = map[string]interface{}{}
+	}
+	evt.Meta["engine_ts"] = processed.UTC().Format(time.RFC3339Nano)
+	evt.Timestamp = processed.UTC()
+}
+
+func (p timestampPolicy) cameraTimestamp(meta map[string]interface{}, received time.Time) (time.Time, bool) {
+	raw, _ := meta["camera_ts"].(string)
+	if raw == "" {
+		return time.Time{}, false
+	}
+	ts, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return time.Time{}, false
+	}
+	skew := received.Sub(ts)
+	if skew < 0 {
+		skew = -skew
+	}
+	if p.maxSkew > 0 && skew > p.maxSkew {
+		return time.Time{}, false
+	}
+	return ts.UTC(), true
+}
@@ -0,0 +1,79 @@
+package supervisor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func TestTimestampPolicyFromEnv(t *testing.T) {
+	for raw, want := range map[string]timestampSource{"": timestampCamera, "RECEIVED": timestampReceived, "engine": timestampEngine, "gps": timestampCamera} {
+		t.Setenv("EVENT_TIMESTAMP_SOURCE", raw)
+		if got := timestampPolicyFromEnv().source; got != want {
+			t.Errorf("EVENT_TIMESTAMP_SOURCE=%q: %s, esperava %s", raw, got, want)
+		}
+	}
+}
+
+func TestApplyReceivedUsesPlausibleCameraTime(t *testing.T) {
+	received := time.Date(2024, 5, 1, 10, 0, 5, 0, time.UTC)
+	camera := received.Add(-5 * time.Second)
+	p := timestampPolicy{source: timestampCamera, maxSkew: time.Hour}
+
+	evt := core.AnalyticEvent{Meta: map[string]interface{}{"camera_ts": camera.Format(time.RFC3339Nano)}}
+	p.applyReceived(&evt, received)
+	if !evt.Timestamp.Equal(camera) {
+		t.Fatalf("Timestamp = %s, esperava o da câmera %s", evt.Timestamp, camera)
+	}
+	if evt.Meta["received_at"] != received.Format(time.RFC3339Nano) {
+		t.Fatalf("received_at = %v", evt.Meta["received_at"])
+	}
+}
+
+func TestApplyReceivedFallsBackOnImplausibleCameraTime(t *testing.T) {
+	received := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	p := timestampPolicy{source: timestampCamera, maxSkew: time.Hour}
+
+	for _, cameraTS := range []string{
+		"2000-01-01T00:00:00Z", // relógio da câmera resetado
+		"2024-05-01T12:00:00Z", // no futuro além do skew
+		"não-é-data",
+	} {
+		evt := core.AnalyticEvent{Meta: map[string]interface{}{"camera_ts": cameraTS}}
+		p.applyReceived(&evt, received)
+		if !evt.Timestamp.Equal(received) {
+			t.Errorf("camera_ts=%s: Timestamp = %s, esperava received", cameraTS, evt.Timestamp)
+		}
+	}
+
+	evt := core.AnalyticEvent{}
+	p.applyReceived(&evt, received)
+	if !evt.Timestamp.Equal(received) || evt.Meta["received_at"] == nil {
+		t.Fatalf("evento sem Meta: %s %v", evt.Timestamp, evt.Meta)
+	}
+}
+
+func TestTimestampSourceReceivedAndEngine(t *testing.T) {
+	received := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	processed := received.Add(300 * time.Millisecond)
+	cameraTS := received.Add(-time.Second).Format(time.RFC3339Nano)
+
+	recv := timestampPolicy{source: timestampReceived, maxSkew: time.Hour}
+	evt := core.AnalyticEvent{Meta: map[string]interface{}{"camera_ts": cameraTS}}
+	recv.applyReceived(&evt, received)
+	if !evt.Timestamp.Equal(received) {
+		t.Fatalf("source=received: Timestamp = %s", evt.Timestamp)
+	}
+	recv.applyEngine(&evt, processed)
+	if !evt.Timestamp.Equal(received) || evt.Meta["engine_ts"] != nil {
+		t.Fatalf("source=received não deveria usar o horário da engine: %s", evt.Timestamp)
+	}
+
+	eng := timestampPolicy{source: timestampEngine, maxSkew: time.Hour}
+	derived := core.AnalyticEvent{}
+	eng.applyEngine(&derived, processed)
+	if !derived.Timestamp.Equal(processed) || derived.Meta["engine_ts"] == nil {
+		t.Fatalf("source=engine: Timestamp = %s, Meta = %v", derived.Timestamp, derived.Meta)
+	}
+}
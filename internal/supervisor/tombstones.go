@@ -0,0 +1,64 @@
+// internal/supervisor/tombstones.go
+package supervisor
+
+import (
+	"log"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+// deferTombstone segura o cleanup de um tombstone por INFO_TOMBSTONE_COALESCE.
+// Se um /info da mesma câmera chegar antes, takePendingTombstone cancela o
+// cleanup e a câmera é atualizada no lugar (sem teardown de uplink/MediaMTX).
+// Retorna false quando a janela está desligada ou a câmera não está ativa.
+func (s *Supervisor) deferTombstone(key string, info core.CameraInfo) bool {
+	if s.tombstoneWindow <= 0 {
+		return false
+	}
+	if _, ok := s.activeCameraInfo(key); !ok {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, pending := s.pendingTombstones[key]; pending {
+		return true
+	}
+	s.pendingTombstones[key] = time.AfterFunc(s.tombstoneWindow, func() {
+		s.mu.Lock()
+		_, still := s.pendingTombstones[key]
+		delete(s.pendingTombstones, key)
+		s.mu.Unlock()
+		if !still {
+			return
+		}
+		log.Printf("[supervisor] camera %s removed via tombstone (sem /info em %s)", key, s.tombstoneWindow)
+		s.cleanupCamera(info, false)
+	})
+	log.Printf("[supervisor] tombstone de %s aguardando %s por um novo /info", key, s.tombstoneWindow)
+	return true
+}
+
+// takePendingTombstone cancela um tombstone pendente; true = havia um.
+func (s *Supervisor) takePendingTombstone(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.pendingTombstones[key]
+	if !ok {
+		return false
+	}
+	t.Stop()
+	delete(s.pendingTombstones, key)
+	return true
+}
+
+// stopPendingTombstones descarta os timers (shutdown: stopAll limpa tudo).
+func (s *Supervisor) stopPendingTombstones() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, t := range s.pendingTombstones {
+		t.Stop()
+		delete(s.pendingTombstones, key)
+	}
+}
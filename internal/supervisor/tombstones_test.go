@@ -0,0 +1,88 @@
+package supervisor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func newTombstoneSupervisor(window time.Duration) (*Supervisor, *fakeMQTT, core.CameraInfo, string) {
+	fake, client := newFakeMQTT()
+	s := &Supervisor{
+		mqtt:              client,
+		baseTopic:         "cams",
+		workers:           map[string]*cameraWorker{},
+		cameras:           map[string]core.CameraInfo{},
+		seeded:            map[string]core.CameraInfo{},
+		tombstoneWindow:   window,
+		pendingTombstones: map[string]*time.Timer{},
+	}
+	info := core.CameraInfo{Tenant: "t", Building: "b", Floor: "f", DeviceType: "cam", DeviceID: "c1", Enabled: true}
+	key := s.keyFor(info)
+	s.cameras[key] = info
+	return s, fake, info, key
+}
+
+func TestTombstoneCoalescedByInfo(t *testing.T) {
+	s, _, info, key := newTombstoneSupervisor(50 * time.Millisecond)
+
+	if !s.deferTombstone(key, info) {
+		t.Fatal("tombstone de câmera ativa deveria ser adiado")
+	}
+	if !s.deferTombstone(key, info) {
+		t.Fatal("segundo tombstone na janela deveria ser absorvido")
+	}
+	if !s.takePendingTombstone(key) {
+		t.Fatal("/info dentro da janela deveria achar o tombstone pendente")
+	}
+	if s.takePendingTombstone(key) {
+		t.Fatal("tombstone já consumido")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if _, ok := s.activeCameraInfo(key); !ok {
+		t.Fatal("tombstone coalescido não deveria remover a câmera")
+	}
+}
+
+func TestTombstoneCleansUpAfterWindow(t *testing.T) {
+	s, fake, info, key := newTombstoneSupervisor(20 * time.Millisecond)
+
+	if !s.deferTombstone(key, info) {
+		t.Fatal("tombstone deveria ser adiado")
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := s.activeCameraInfo(key); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("câmera não removida após a janela")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(fake.messages("/availability")) == 0 {
+		t.Fatal("cleanup deveria publicar a câmera offline")
+	}
+}
+
+func TestTombstoneNotDeferred(t *testing.T) {
+	s, _, info, key := newTombstoneSupervisor(0)
+	if s.deferTombstone(key, info) {
+		t.Fatal("com INFO_TOMBSTONE_COALESCE=0 o tombstone é imediato")
+	}
+
+	s.tombstoneWindow = time.Minute
+	unknown := info
+	unknown.DeviceID = "outra"
+	if s.deferTombstone(s.keyFor(unknown), unknown) {
+		t.Fatal("tombstone de câmera inativa não precisa esperar")
+	}
+
+	s.deferTombstone(key, info)
+	s.stopPendingTombstones()
+	if s.takePendingTombstone(key) {
+		t.Fatal("stopPendingTombstones deveria descartar os timers")
+	}
+}
@@ -0,0 +1,68 @@
+// internal/supervisor/topic_suffix.go
+package supervisor
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+const defaultEventTopicSuffix = "events"
+
+// topicSuffixRules escolhe o último nível do tópico de eventos por analytic e
+// EventState (TOPIC_SUFFIX_RULES). Formato: regras separadas por vírgula,
+// "<analytic>[:<estado>]=<sufixo>", com "*" valendo para qualquer analytic. Ex.:
+//
+//	TOPIC_SUFFIX_RULES="intrusion=alerts,*:inactive=state,faceRecognized=matches/critical"
+//
+// Precedência: analytic+estado, analytic, *+estado, *; sem regra = "events".
+type topicSuffixRules map[string]string
+
+func topicSuffixRulesFromEnv() topicSuffixRules {
+	raw := strings.TrimSpace(os.Getenv("TOPIC_SUFFIX_RULES"))
+	if raw == "" {
+		return nil
+	}
+	rules := topicSuffixRules{}
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		match, suffix, ok := strings.Cut(item, "=")
+		suffix = strings.Trim(strings.TrimSpace(suffix), "/")
+		match = strings.ToLower(strings.TrimSpace(match))
+		if !ok || match == "" || suffix == "" || strings.ContainsAny(suffix, "+#") {
+			log.Printf("[supervisor] TOPIC_SUFFIX_RULES: regra inválida %q, ignorando", item)
+			continue
+		}
+		analytic, state, _ := strings.Cut(match, ":")
+		rules[topicSuffixKey(analytic, state)] = suffix
+	}
+	return rules
+}
+
+func topicSuffixKey(analytic, state string) string {
+	return strings.TrimSpace(analytic) + ":" + strings.TrimSpace(state)
+}
+
+// suffix devolve o sufixo do tópico para o analytic/estado do evento.
+func (r topicSuffixRules) suffix(analyticType, state string) string {
+	if len(r) == 0 {
+		return defaultEventTopicSuffix
+	}
+	analytic := strings.ToLower(analyticType)
+	state = strings.ToLower(state)
+	candidates := []string{
+		topicSuffixKey(analytic, state),
+		topicSuffixKey(analytic, ""),
+		topicSuffixKey("*", state),
+		topicSuffixKey("*", ""),
+	}
+	for _, key := range candidates {
+		if s, ok := r[key]; ok {
+			return s
+		}
+	}
+	return defaultEventTopicSuffix
+}
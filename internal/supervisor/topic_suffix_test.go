@@ -0,0 +1,52 @@
+package supervisor
+
+import (
+	"testing"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+func TestTopicSuffixRules(t *testing.T) {
+	t.Setenv("TOPIC_SUFFIX_RULES", " intrusion=alerts, *:inactive=state ,faceRecognized=/matches/critical/,"+
+		"intrusion:inactive=alerts/cleared,sem-sufixo=,=x,bad=a/#,lixo")
+	rules := topicSuffixRulesFromEnv()
+	if len(rules) != 4 {
+		t.Fatalf("regras = %v, esperava só as 4 válidas", rules)
+	}
+
+	cases := []struct {
+		analytic, state, want string
+	}{
+		{"intrusion", "", "alerts"},
+		{"Intrusion", "active", "alerts"},
+		{"intrusion", "Inactive", "alerts/cleared"}, // analytic+estado vence analytic
+		{"VideoMotion", "inactive", "state"},        // *+estado
+		{"faceRecognized", "", "matches/critical"},  // barras das pontas removidas
+		{"faceRecognized", "inactive", "matches/critical"},
+		{"VideoMotion", "active", "events"}, // sem regra
+		{"bad", "", "events"},               // curinga MQTT no sufixo é inválido
+	}
+	for _, tc := range cases {
+		if got := rules.suffix(tc.analytic, tc.state); got != tc.want {
+			t.Errorf("suffix(%q, %q) = %q, esperava %q", tc.analytic, tc.state, got, tc.want)
+		}
+	}
+}
+
+func TestTopicSuffixDefault(t *testing.T) {
+	t.Setenv("TOPIC_SUFFIX_RULES", "")
+	rules := topicSuffixRulesFromEnv()
+	if rules != nil || rules.suffix("intrusion", "inactive") != defaultEventTopicSuffix {
+		t.Fatalf("sem TOPIC_SUFFIX_RULES o sufixo deveria ser %q", defaultEventTopicSuffix)
+	}
+
+	t.Setenv("TOPIC_SUFFIX_RULES", "*=all")
+	s := &Supervisor{baseTopic: "cams", topicSuffixes: topicSuffixRulesFromEnv()}
+	info := core.CameraInfo{Tenant: "t", Building: "b", Floor: "f", DeviceType: "cam", DeviceID: "c1"}
+	if got := s.eventTopicFor(info, " intrusion ", "active"); got != "cams/t/b/f/cam/c1/intrusion/all" {
+		t.Fatalf("tópico = %q", got)
+	}
+	if got := s.eventTopic(info, ""); got != "cams/t/b/f/cam/c1/unknown/all" {
+		t.Fatalf("tópico sem analytic = %q", got)
+	}
+}
@@ -0,0 +1,78 @@
+// internal/tracing/export.go
+package tracing
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/sua-org/cam-bus/internal/netproxy"
+)
+
+// NewTracerProvider cria o TracerProvider do SDK com envio em lote para exp.
+func NewTracerProvider(exp sdktrace.SpanExporter, serviceName string) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+}
+
+// InitFromEnv liga o tracing quando OTEL_TRACES_ENABLED=true, exportando via
+// OTLP/HTTP para OTEL_EXPORTER_OTLP_TRACES_ENDPOINT ou
+// OTEL_EXPORTER_OTLP_ENDPOINT + /v1/traces (headers em
+// OTEL_EXPORTER_OTLP_HEADERS). No fim do ctx os spans pendentes são enviados;
+// a função devolvida espera esse envio (não faz nada com o tracing desligado).
+func InitFromEnv(ctx context.Context) (wait func()) {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("OTEL_TRACES_ENABLED"))) {
+	case "1", "true", "yes", "on":
+	default:
+		return func() {}
+	}
+
+	exp, err := otlpExporterFromEnv(ctx)
+	if err != nil {
+		log.Printf("[tracing] tracing desabilitado: %v", err)
+		return func() {}
+	}
+
+	serviceName := strings.TrimSpace(os.Getenv("OTEL_SERVICE_NAME"))
+	if serviceName == "" {
+		serviceName = "cam-bus"
+	}
+	provider := NewTracerProvider(exp, serviceName)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagator)
+	log.Printf("[tracing] tracing habilitado, exportando via OTLP/HTTP")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := provider.Shutdown(shutdownCtx); err != nil {
+			log.Printf("[tracing] erro encerrando export: %v", err)
+		}
+	}()
+	return func() { <-done }
+}
+
+// otlpExporterFromEnv exige o endpoint explícito para não mandar spans para
+// localhost por engano; o resto segue as variáveis padrão do OpenTelemetry.
+func otlpExporterFromEnv(ctx context.Context) (*otlptrace.Exporter, error) {
+	if strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")) == "" &&
+		strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")) == "" {
+		return nil, errors.New("OTEL_EXPORTER_OTLP_ENDPOINT não definido")
+	}
+	return otlptracehttp.New(ctx, otlptracehttp.WithProxy(netproxy.Func))
+}
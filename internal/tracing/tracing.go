@@ -0,0 +1,95 @@
+// internal/tracing/tracing.go
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MetaKey é a chave em AnalyticEvent.Meta que leva o contexto do trace
+// (formato W3C traceparent) do driver até os eventos derivados.
+const MetaKey = "traceparent"
+
+const scopeName = "github.com/sua-org/cam-bus"
+
+// propagator é o W3C trace context usado no Meta dos eventos e nos headers HTTP.
+var propagator = propagation.TraceContext{}
+
+// Span embrulha o span do OpenTelemetry com os atalhos usados no pipeline.
+// Com tracing desligado o span é no-op, então o código instrumentado não
+// precisa checar.
+type Span struct {
+	trace.Span
+}
+
+// Start cria um span filho do span em ctx (ou raiz de um novo trace) no
+// TracerProvider global (configurado por InitFromEnv; no-op sem ele).
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	ctx, span := otel.Tracer(scopeName).Start(ctx, name)
+	return ctx, &Span{Span: span}
+}
+
+// SetAttr adiciona um atributo string ao span.
+func (s *Span) SetAttr(key, value string) {
+	s.SetAttributes(attribute.String(key, value))
+}
+
+// RecordError marca o span com erro (nil é ignorado).
+func (s *Span) RecordError(err error, opts ...trace.EventOption) {
+	if err == nil {
+		return
+	}
+	s.Span.RecordError(err, opts...)
+	s.SetStatus(codes.Error, err.Error())
+}
+
+// metaCarrier adapta AnalyticEvent.Meta ao TextMapCarrier do OpenTelemetry.
+type metaCarrier map[string]interface{}
+
+func (m metaCarrier) Get(key string) string {
+	v, _ := m[key].(string)
+	return v
+}
+
+func (m metaCarrier) Set(key, value string) { m[key] = value }
+
+func (m metaCarrier) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Inject grava o span corrente de ctx em meta[MetaKey], criando o mapa se
+// preciso. Sem span no ctx, meta volta como está.
+func Inject(ctx context.Context, meta map[string]interface{}) map[string]interface{} {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return meta
+	}
+	if meta == nil {
+		meta = map[string]interface{}{}
+	}
+	propagator.Inject(ctx, metaCarrier(meta))
+	return meta
+}
+
+// Extract devolve ctx com o span remoto lido de meta[MetaKey], para que os
+// spans seguintes continuem o mesmo trace.
+func Extract(ctx context.Context, meta map[string]interface{}) context.Context {
+	if meta == nil {
+		return ctx
+	}
+	return propagator.Extract(ctx, metaCarrier(meta))
+}
+
+// InjectHeader propaga o span corrente de ctx como header traceparent.
+func InjectHeader(ctx context.Context, header http.Header) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
@@ -0,0 +1,127 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/sua-org/cam-bus/internal/storage"
+	"github.com/sua-org/cam-bus/internal/tracing"
+)
+
+type nopStore struct{}
+
+func (nopStore) SaveSnapshot(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	return "http://minio/" + key, nil
+}
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return rec
+}
+
+func spansByName(rec *tracetest.SpanRecorder) map[string]sdktrace.ReadOnlySpan {
+	out := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range rec.Ended() {
+		out[s.Name()] = s
+	}
+	return out
+}
+
+// TestEventSpanHierarchy segue um evento como o pipeline faz: o driver abre
+// driver.receive e sobe o snapshot; o contexto viaja em Meta até o
+// supervisor, que abre pipeline.event e publica no MQTT.
+func TestEventSpanHierarchy(t *testing.T) {
+	rec := recordSpans(t)
+
+	// driver
+	evtCtx, receive := tracing.Start(context.Background(), "driver.receive")
+	receive.SetAttr("camera.id", "cam01")
+	if _, err := storage.Save(evtCtx, nopStore{}, "cam01/evt.jpg", []byte("jpeg"), "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	meta := tracing.Inject(evtCtx, nil)
+	receive.End()
+
+	// supervisor (outra goroutine, só o Meta atravessa)
+	ctx, event := tracing.Start(tracing.Extract(context.Background(), meta), "pipeline.event")
+	_, publish := tracing.Start(ctx, "mqtt.publish")
+	publish.SetAttr("mqtt.topic", "cams/acme/events")
+	publish.End()
+	event.End()
+
+	spans := spansByName(rec)
+	for _, name := range []string{"driver.receive", "storage.upload", "pipeline.event", "mqtt.publish"} {
+		if _, ok := spans[name]; !ok {
+			t.Fatalf("span %s não registrado (tem %v)", name, spans)
+		}
+	}
+
+	root := spans["driver.receive"]
+	if root.Parent().IsValid() {
+		t.Fatal("driver.receive deveria ser raiz do trace")
+	}
+	parentOf := map[string]string{
+		"storage.upload": "driver.receive",
+		"pipeline.event": "driver.receive",
+		"mqtt.publish":   "pipeline.event",
+	}
+	for child, parent := range parentOf {
+		c, p := spans[child], spans[parent]
+		if c.SpanContext().TraceID() != root.SpanContext().TraceID() {
+			t.Errorf("%s fora do trace do driver.receive", child)
+		}
+		if c.Parent().SpanID() != p.SpanContext().SpanID() {
+			t.Errorf("pai de %s = %s, esperava %s", child, c.Parent().SpanID(), parent)
+		}
+	}
+	if !spans["pipeline.event"].Parent().IsRemote() {
+		t.Error("pipeline.event deveria ter pai remoto (extraído do Meta)")
+	}
+}
+
+func TestInjectUsesW3CTraceparent(t *testing.T) {
+	recordSpans(t)
+
+	if meta := tracing.Inject(context.Background(), nil); meta != nil {
+		t.Fatalf("sem span, Inject não deveria criar Meta: %v", meta)
+	}
+
+	ctx, span := tracing.Start(context.Background(), "driver.receive")
+	defer span.End()
+	meta := tracing.Inject(ctx, map[string]interface{}{"x": 1})
+	want := "00-" + span.SpanContext().TraceID().String() + "-" + span.SpanContext().SpanID().String() + "-01"
+	if got := meta[tracing.MetaKey]; got != want || meta["x"] != 1 {
+		t.Fatalf("meta = %v, esperava traceparent %s", meta, want)
+	}
+
+	header := http.Header{}
+	tracing.InjectHeader(ctx, header)
+	if got := header.Get("traceparent"); got != want {
+		t.Fatalf("header traceparent = %q, esperava %q", got, want)
+	}
+}
+
+func TestRecordErrorSetsStatus(t *testing.T) {
+	rec := recordSpans(t)
+
+	_, span := tracing.Start(context.Background(), "mqtt.publish")
+	span.RecordError(nil)
+	span.RecordError(errors.New("broker fora"))
+	span.End()
+
+	got := rec.Ended()[0]
+	if got.Status().Code != codes.Error || got.Status().Description != "broker fora" {
+		t.Fatalf("status = %+v", got.Status())
+	}
+}
@@ -483,3 +483,22 @@ func parseArgsEnv(key string, def []string) []string {
 	}
 	return def
 }
+
+// FFmpegCommand descobre como rodar o ffmpeg fora do uplink (ex.: snapshot
+// pelo RTSP): binário local (FFMPEG_BIN ou "ffmpeg" no PATH) e, sem ele, o
+// mesmo container do uplink (UPLINK_DOCKER_BIN/UPLINK_DOCKER_IMAGE) com
+// "docker run --rm". Devolve o executável e os argumentos que vêm antes dos
+// argumentos do ffmpeg.
+func FFmpegCommand() (string, []string, error) {
+	bin := getenv("FFMPEG_BIN", "ffmpeg")
+	if path, err := exec.LookPath(bin); err == nil {
+		return path, nil, nil
+	}
+	dockerBin := getenv("UPLINK_DOCKER_BIN", defaultDockerBin)
+	path, err := exec.LookPath(dockerBin)
+	if err != nil {
+		return "", nil, fmt.Errorf("ffmpeg não encontrado (%s) e docker indisponível (%s)", bin, dockerBin)
+	}
+	image := getenv("UPLINK_DOCKER_IMAGE", defaultDockerImage)
+	return path, []string{"run", "--rm", "--network", "host", image}, nil
+}
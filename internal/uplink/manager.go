@@ -7,21 +7,27 @@ import (
 	"log"
 	"net/url"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/sua-org/cam-bus/internal/core"
+	"github.com/sua-org/cam-bus/internal/envconf"
+	"github.com/sua-org/cam-bus/internal/logging"
+	"github.com/sua-org/cam-bus/internal/logthrottle"
 	"github.com/sua-org/cam-bus/internal/uplink/container"
 )
 
 const (
-	defaultProxyRTSPBase = "rtsp://localhost:8554"
-	defaultSRTPacketSize = 1316
-	defaultSRTPort       = 8890
-	defaultSRTLatencyMS  = 200
-	defaultReconcileSecs = 15
+	defaultProxyRTSPBase       = "rtsp://localhost:8554"
+	defaultSRTPacketSize       = 1316
+	defaultSRTPort             = 8890
+	defaultSRTLatencyMS        = 200
+	defaultReconcileSecs       = 15
+	defaultReconcileMaxMisses  = 3
+	defaultMaxConcurrentStarts = 2
 
 	uplinkModeContainer   = "container"
 	uplinkModeMediaMTX    = "mediamtx"
@@ -35,13 +41,28 @@ type Manager struct {
 	mode               string
 	containerManager   *container.Manager
 	reconcileInterval  time.Duration
+	reconcileAutoStop  bool
+	reconcileMaxMisses int
 	reconcileStop      chan struct{}
 	alwaysOn           bool
 	alwaysOnPaths      map[string]struct{}
 	ignoreUplink       bool
+	teardownGrace      time.Duration
 	mu                 sync.Mutex
 	uplinks            map[string]*uplinkProcess
+	pendingTeardowns   map[string]*time.Timer
 	statusHook         atomic.Value
+
+	// startSlots limita docker run simultâneos (UPLINK_MAX_CONCURRENT_STARTS);
+	// starting marca as câmeras com docker run em andamento (fora do mu).
+	startSlots chan struct{}
+	starting   map[string]*pendingStart
+}
+
+// pendingStart é um docker run em andamento; canceled = chegou Stop no meio.
+type pendingStart struct {
+	done     chan struct{}
+	canceled bool
 }
 
 type uplinkProcess struct {
@@ -50,6 +71,7 @@ type uplinkProcess struct {
 	container       string
 	containerID     string
 	containerStatus string
+	nonRunningCount int // observações consecutivas do reconciler fora de "running"
 	ttlTimer        *time.Timer
 	alwaysOn        bool
 	// startCount increments for every Start request; stopCount increments for every Stop request.
@@ -68,9 +90,9 @@ type Request struct {
 
 func NewManagerFromEnv() *Manager {
 	alwaysOnPaths := parseListEnv(os.Getenv("UPLINK_ALWAYS_ON_PATHS"))
-	alwaysOn := getenvBool("UPLINK_ALWAYS_ON", false)
+	alwaysOn := envconf.Bool("UPLINK_ALWAYS_ON", false)
 	defaultCentralHost := strings.TrimSpace(os.Getenv("UPLINK_CENTRAL_HOST"))
-	defaultSRTPort := getenvInt("UPLINK_CENTRAL_SRT_PORT", defaultSRTPort)
+	defaultSRTPort := envconf.PositiveInt("UPLINK_CENTRAL_SRT_PORT", defaultSRTPort)
 	if alwaysOn && defaultCentralHost == "" {
 		centralHost, centralPort := parseCentralURL(os.Getenv("MEDIAMTX_CENTRAL_URL"))
 		if centralHost != "" {
@@ -86,12 +108,18 @@ func NewManagerFromEnv() *Manager {
 		defaultSRTPort:     defaultSRTPort,
 		mode:               normalizeMode(os.Getenv("UPLINK_MODE")),
 		containerManager:   container.NewManagerFromEnv(),
-		reconcileInterval:  time.Duration(getenvInt("UPLINK_RECONCILE_INTERVAL_SECONDS", defaultReconcileSecs)) * time.Second,
+		reconcileInterval:  time.Duration(envconf.PositiveInt("UPLINK_RECONCILE_INTERVAL_SECONDS", defaultReconcileSecs)) * time.Second,
 		reconcileStop:      make(chan struct{}),
+		reconcileAutoStop:  envconf.Bool("UPLINK_RECONCILE_AUTO_STOP", true),
+		reconcileMaxMisses: envconf.PositiveInt("UPLINK_RECONCILE_MAX_NON_RUNNING", defaultReconcileMaxMisses),
 		alwaysOn:           alwaysOn,
 		alwaysOnPaths:      alwaysOnPaths,
-		ignoreUplink:       getenvBool("IGNORE_UPLINK", false),
+		ignoreUplink:       envconf.Bool("IGNORE_UPLINK", false),
+		teardownGrace:      envconf.Duration("UPLINK_TEARDOWN_GRACE", 0),
 		uplinks:            make(map[string]*uplinkProcess),
+		pendingTeardowns:   make(map[string]*time.Timer),
+		startSlots:         make(chan struct{}, envconf.PositiveInt("UPLINK_MAX_CONCURRENT_STARTS", defaultMaxConcurrentStarts)),
+		starting:           make(map[string]*pendingStart),
 	}
 	manager.startReconciler()
 	return manager
@@ -142,7 +170,7 @@ func (m *Manager) Stop(req Request) error {
 	req = m.applyDefaults(req)
 	cameraKey := keyFor(req)
 	if m.isAlwaysOnRequest(req) {
-		log.Printf("[uplink] stop ignored for %s (always-on)", cameraKey)
+		uplinkLog(cameraKey).Debugf("[uplink] stop ignored for %s (always-on)", cameraKey)
 		return nil
 	}
 	return m.stopUplink(cameraKey, "stop command")
@@ -174,7 +202,88 @@ func (m *Manager) StopByCamera(info core.CameraInfo) {
 			m.stopProcess(proc, "camera cleanup")
 			delete(m.uplinks, key)
 		}
+		m.cancelPendingStart(key, "camera cleanup")
+	}
+}
+
+// ScheduleStopByCamera adia o StopByCamera por UPLINK_TEARDOWN_GRACE, para que um
+// disable/enable rápido (flap de config) não derrube o uplink. Sem grace, para na hora.
+// onStop (opcional) é chamado depois que o teardown for efetivamente executado,
+// ou na hora quando não há uplink a derrubar (ignore/always-on/sem chave), para
+// que o chamador sempre limpe o estado da câmera.
+func (m *Manager) ScheduleStopByCamera(info core.CameraInfo, onStop func()) {
+	if onStop == nil {
+		onStop = func() {}
+	}
+	if m == nil || m.ignoreUplink || m.alwaysOn {
+		onStop()
+		return
+	}
+	if m.teardownGrace <= 0 {
+		m.StopByCamera(info)
+		onStop()
+		return
+	}
+	key := teardownKeyFor(info)
+	if key == "" {
+		onStop()
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if timer, ok := m.pendingTeardowns[key]; ok {
+		timer.Stop()
+	}
+	log.Printf("[uplink] teardown de %s agendado em %s", key, m.teardownGrace)
+	var timer *time.Timer
+	timer = time.AfterFunc(m.teardownGrace, func() {
+		m.mu.Lock()
+		current, ok := m.pendingTeardowns[key]
+		if !ok || current != timer {
+			m.mu.Unlock()
+			return
+		}
+		delete(m.pendingTeardowns, key)
+		m.mu.Unlock()
+
+		log.Printf("[uplink] grace expirado para %s, encerrando uplink", key)
+		m.StopByCamera(info)
+		onStop()
+	})
+	m.pendingTeardowns[key] = timer
+}
+
+// CancelScheduledStop cancela um teardown pendente da câmera.
+// Retorna true quando havia teardown agendado.
+func (m *Manager) CancelScheduledStop(info core.CameraInfo) bool {
+	if m == nil {
+		return false
+	}
+	key := teardownKeyFor(info)
+	if key == "" {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	timer, ok := m.pendingTeardowns[key]
+	if !ok {
+		return false
+	}
+	timer.Stop()
+	delete(m.pendingTeardowns, key)
+	log.Printf("[uplink] câmera %s voltou dentro do grace, teardown cancelado", key)
+	return true
+}
+
+func teardownKeyFor(info core.CameraInfo) string {
+	if id := strings.TrimSpace(info.DeviceID); id != "" {
+		return strings.ToLower(id)
 	}
+	return normalizeAlwaysOnKey(info.CentralPath)
 }
 
 func (m *Manager) StopAll() {
@@ -184,10 +293,17 @@ func (m *Manager) StopAll() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	for key, timer := range m.pendingTeardowns {
+		timer.Stop()
+		delete(m.pendingTeardowns, key)
+	}
 	for key, proc := range m.uplinks {
 		m.stopProcess(proc, "shutdown")
 		delete(m.uplinks, key)
 	}
+	for key := range m.starting {
+		m.cancelPendingStart(key, "shutdown")
+	}
 }
 
 func (m *Manager) AlwaysOnEnabled(info core.CameraInfo) bool {
@@ -256,6 +372,11 @@ func validateStart(req Request) error {
 	return nil
 }
 
+// uplinkLog devolve o logger com a chave do uplink (central path ou camera id).
+func uplinkLog(key string) logging.Entry {
+	return logging.With(logging.Fields{"uplink": key})
+}
+
 func keyFor(req Request) string {
 	if req.CentralPath != "" {
 		return req.CentralPath
@@ -267,12 +388,23 @@ func (m *Manager) startUplink(cameraKey string, req Request) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	// um docker run por câmera: espera o que já está em andamento terminar
+	for {
+		pending, ok := m.starting[cameraKey]
+		if !ok {
+			break
+		}
+		m.mu.Unlock()
+		<-pending.done
+		m.mu.Lock()
+	}
+
 	alwaysOn := m.isAlwaysOnRequest(req)
 	if existing, ok := m.uplinks[cameraKey]; ok {
 		if sameRequest(existing.payload, req) {
 			existing.startCount++
 			existing.alwaysOn = alwaysOn
-			log.Printf("[uplink] already running for %s, startCount=%d stopCount=%d, refreshing TTL", cameraKey, existing.startCount, existing.stopCount)
+			uplinkLog(cameraKey).Debugf("[uplink] already running for %s, startCount=%d stopCount=%d, refreshing TTL", cameraKey, existing.startCount, existing.stopCount)
 			m.refreshTTL(existing, req.TTLSeconds)
 			return nil
 		}
@@ -318,7 +450,7 @@ func (m *Manager) startUplink(cameraKey string, req Request) error {
 		m.uplinks[cameraKey] = proc
 		m.refreshTTL(proc, req.TTLSeconds)
 
-		log.Printf("[uplink] %s mode active for %s -> %s (startCount=%d stopCount=%d)", m.mode, cameraKey, srtURL, proc.startCount, proc.stopCount)
+		uplinkLog(cameraKey).Infof("[uplink] %s mode active for %s -> %s (startCount=%d stopCount=%d)", m.mode, cameraKey, srtURL, proc.startCount, proc.stopCount)
 		m.notifyStatus(Status{
 			CameraID:      req.CameraID,
 			CentralPath:   req.CentralPath,
@@ -330,36 +462,18 @@ func (m *Manager) startUplink(cameraKey string, req Request) error {
 		return nil
 	}
 
-	startCtx := context.Background()
-	if len(srtCandidates) == 0 {
-		srtCandidates = []string{""}
-	}
-	var (
-		containerID string
-		startErr    error
-		usedSRTURL  string
-	)
-	for idx, srtURL := range srtCandidates {
-		if srtURL == "" {
-			startErr = fmt.Errorf("srt url required")
-			break
-		}
-		containerID, startErr = m.containerManager.Start(startCtx, container.Request{
-			Name:     containerName,
-			ProxyURL: proxyURL,
-			SRTURL:   srtURL,
-		})
-		if startErr == nil {
-			usedSRTURL = srtURL
-			break
-		}
-		if !isRetriableStartError(startErr) || idx == len(srtCandidates)-1 {
-			break
-		}
-		log.Printf("[uplink] retrying SRT params for %s (attempt %d/%d)", cameraKey, idx+2, len(srtCandidates))
-	}
+	// docker run fora do mu: Stop/status de outras câmeras não esperam o start,
+	// e só UPLINK_MAX_CONCURRENT_STARTS rodam ao mesmo tempo
+	pending := &pendingStart{done: make(chan struct{})}
+	m.starting[cameraKey] = pending
+	m.mu.Unlock()
+	containerID, usedSRTURL, startErr := m.runContainerStart(cameraKey, containerName, proxyURL, srtCandidates)
+	m.mu.Lock()
+	delete(m.starting, cameraKey)
+	close(pending.done)
+
 	if startErr != nil {
-		log.Printf("[uplink] docker run failed for %s (container=%s): %v", cameraKey, containerName, startErr)
+		uplinkLog(cameraKey).Errorf("[uplink] docker run failed for %s (container=%s): %v", cameraKey, containerName, startErr)
 		statusError := startErr.Error()
 		var startKind *container.StartError
 		if errors.As(startErr, &startKind) && startKind.Kind == container.StartErrorKindUnsupportedOption && startKind.Summary != "" {
@@ -376,6 +490,11 @@ func (m *Manager) startUplink(cameraKey string, req Request) error {
 		return fmt.Errorf("start container uplink: %w", startErr)
 	}
 
+	if pending.canceled {
+		m.stopProcess(&uplinkProcess{cameraKey: cameraKey, payload: req, container: containerName, containerID: containerID, startCount: 1, stopCount: 1}, "stop recebido durante o start")
+		return nil
+	}
+
 	proc := &uplinkProcess{
 		cameraKey:       cameraKey,
 		payload:         req,
@@ -389,7 +508,7 @@ func (m *Manager) startUplink(cameraKey string, req Request) error {
 	m.uplinks[cameraKey] = proc
 	m.refreshTTL(proc, req.TTLSeconds)
 
-	log.Printf("[uplink] started for %s -> %s (startCount=%d stopCount=%d)", cameraKey, usedSRTURL, proc.startCount, proc.stopCount)
+	uplinkLog(cameraKey).Infof("[uplink] started for %s -> %s (startCount=%d stopCount=%d)", cameraKey, usedSRTURL, proc.startCount, proc.stopCount)
 	m.notifyStatus(Status{
 		CameraID:      req.CameraID,
 		CentralPath:   req.CentralPath,
@@ -401,6 +520,60 @@ func (m *Manager) startUplink(cameraKey string, req Request) error {
 	return nil
 }
 
+// runContainerStart roda o docker run do uplink tentando cada candidato SRT,
+// ocupando uma vaga de startSlots enquanto isso.
+func (m *Manager) runContainerStart(cameraKey, containerName, proxyURL string, srtCandidates []string) (string, string, error) {
+	select {
+	case m.startSlots <- struct{}{}:
+	default:
+		log.Printf("[uplink] %s aguardando vaga para docker run (UPLINK_MAX_CONCURRENT_STARTS=%d)", cameraKey, cap(m.startSlots))
+		m.startSlots <- struct{}{}
+	}
+	defer func() { <-m.startSlots }()
+
+	startCtx := context.Background()
+	if len(srtCandidates) == 0 {
+		srtCandidates = []string{""}
+	}
+	var (
+		containerID string
+		startErr    error
+		usedSRTURL  string
+	)
+	for idx, srtURL := range srtCandidates {
+		if srtURL == "" {
+			startErr = fmt.Errorf("srt url required")
+			break
+		}
+		containerID, startErr = m.containerManager.Start(startCtx, container.Request{
+			Name:     containerName,
+			ProxyURL: proxyURL,
+			SRTURL:   srtURL,
+		})
+		if startErr == nil {
+			usedSRTURL = srtURL
+			break
+		}
+		if !isRetriableStartError(startErr) || idx == len(srtCandidates)-1 {
+			break
+		}
+		uplinkLog(cameraKey).Warnf("[uplink] retrying SRT params for %s (attempt %d/%d)", cameraKey, idx+2, len(srtCandidates))
+	}
+	return containerID, usedSRTURL, startErr
+}
+
+// cancelPendingStart marca o docker run em andamento da câmera para ser
+// desfeito assim que terminar. Chamar com m.mu travado.
+func (m *Manager) cancelPendingStart(cameraKey, reason string) bool {
+	pending, ok := m.starting[cameraKey]
+	if !ok || pending.canceled {
+		return ok
+	}
+	pending.canceled = true
+	log.Printf("[uplink] %s durante o start de %s; o container será encerrado ao subir", reason, cameraKey)
+	return true
+}
+
 func (m *Manager) startReconciler() {
 	if m.reconcileInterval <= 0 {
 		return
@@ -429,6 +602,48 @@ type uplinkSnapshot struct {
 	centralPath string
 	container   string
 	containerID string
+	request     Request
+	state       string
+	alwaysOn    bool
+}
+
+// UplinkInfo é um uplink ativo na visão da API de admin (GET /uplinks).
+type UplinkInfo struct {
+	CameraKey      string `json:"cameraKey"`
+	CameraID       string `json:"cameraId"`
+	ProxyPath      string `json:"proxyPath,omitempty"`
+	CentralHost    string `json:"centralHost,omitempty"`
+	CentralSRTPort int    `json:"centralSrtPort,omitempty"`
+	CentralPath    string `json:"centralPath"`
+	Container      string `json:"containerName,omitempty"`
+	ContainerID    string `json:"containerId,omitempty"`
+	State          string `json:"state"`
+	AlwaysOn       bool   `json:"alwaysOn"`
+}
+
+// Uplinks lista os uplinks ativos, ordenados pela chave da câmera.
+func (m *Manager) Uplinks() []UplinkInfo {
+	if m == nil {
+		return nil
+	}
+	snapshots := m.snapshotUplinks()
+	out := make([]UplinkInfo, 0, len(snapshots))
+	for _, snap := range snapshots {
+		out = append(out, UplinkInfo{
+			CameraKey:      snap.cameraKey,
+			CameraID:       snap.cameraID,
+			ProxyPath:      snap.request.ProxyPath,
+			CentralHost:    snap.request.CentralHost,
+			CentralSRTPort: snap.request.CentralSRTPort,
+			CentralPath:    snap.centralPath,
+			Container:      snap.container,
+			ContainerID:    snap.containerID,
+			State:          snap.state,
+			AlwaysOn:       snap.alwaysOn,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CameraKey < out[j].CameraKey })
+	return out
 }
 
 func (m *Manager) StatusFor(req Request) (Status, bool) {
@@ -479,6 +694,9 @@ func (m *Manager) snapshotUplinks() []uplinkSnapshot {
 			centralPath: proc.payload.CentralPath,
 			container:   proc.container,
 			containerID: proc.containerID,
+			request:     proc.payload,
+			state:       statusFromProcess(proc).State,
+			alwaysOn:    proc.alwaysOn,
 		})
 	}
 	return snapshots
@@ -497,11 +715,11 @@ func (m *Manager) reconcileOnce() {
 		status, err := m.containerManager.InspectStatus(ctx, snap.container)
 		cancel()
 		if err != nil {
-			log.Printf("[uplink] reconcile inspect failed for %s (container=%s): %v", snap.cameraKey, snap.container, err)
+			logthrottle.Printf("uplink:inspect:"+snap.container, "[uplink] reconcile inspect failed for %s (container=%s): %v", snap.cameraKey, snap.container, err)
 			continue
 		}
 		stateErr := strings.TrimSpace(status.Error)
-		log.Printf("[uplink] reconcile status for %s container=%s state=%s exitCode=%d stateError=%s", snap.cameraKey, snap.container, status.State, status.ExitCode, stateErr)
+		logthrottle.Printf("uplink:status:"+snap.container+":"+status.State, "[uplink] reconcile status for %s container=%s state=%s exitCode=%d stateError=%s", snap.cameraKey, snap.container, status.State, status.ExitCode, stateErr)
 		m.notifyStatus(Status{
 			CameraID:      snap.cameraID,
 			CentralPath:   snap.centralPath,
@@ -510,25 +728,45 @@ func (m *Manager) reconcileOnce() {
 			ExitCode:      status.ExitCode,
 			Error:         stateErr,
 		})
+		m.mu.Lock()
+		proc, ok := m.uplinks[snap.cameraKey]
+		if !ok || proc.container != snap.container {
+			m.mu.Unlock()
+			continue
+		}
+		proc.containerStatus = status.State
 		if status.State == "running" {
-			m.mu.Lock()
-			if proc, ok := m.uplinks[snap.cameraKey]; ok && proc.container == snap.container {
-				proc.containerStatus = status.State
-			}
+			proc.nonRunningCount = 0
 			m.mu.Unlock()
 			continue
 		}
-		m.mu.Lock()
-		proc, ok := m.uplinks[snap.cameraKey]
-		if ok && proc.container == snap.container {
-			proc.containerStatus = status.State
-			m.stopProcess(proc, fmt.Sprintf("container state=%s exitCode=%d stateError=%s", status.State, status.ExitCode, stateErr))
-			delete(m.uplinks, snap.cameraKey)
+		proc.nonRunningCount++
+		if !m.shouldReconcileStop(status.State, proc.nonRunningCount) {
+			log.Printf("[uplink] reconcile: %s container=%s state=%s transitório (%d/%d), mantendo",
+				snap.cameraKey, snap.container, status.State, proc.nonRunningCount, m.reconcileMaxMisses)
+			m.mu.Unlock()
+			continue
 		}
+		m.stopProcess(proc, fmt.Sprintf("container state=%s exitCode=%d stateError=%s", status.State, status.ExitCode, stateErr))
+		delete(m.uplinks, snap.cameraKey)
 		m.mu.Unlock()
 	}
 }
 
+// shouldReconcileStop decide se o reconciler derruba o uplink: estados terminais
+// (exited/dead) param na hora; transitórios (restarting/created/...) só depois de
+// UPLINK_RECONCILE_MAX_NON_RUNNING observações seguidas.
+func (m *Manager) shouldReconcileStop(state string, nonRunning int) bool {
+	if !m.reconcileAutoStop {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(state)) {
+	case "exited", "dead":
+		return true
+	}
+	return nonRunning >= m.reconcileMaxMisses
+}
+
 func (m *Manager) stopUplink(cameraKey, reason string) error {
 	if m != nil && m.ignoreUplink {
 		log.Printf("[uplink] ignoreUplink ativo, ignorando stop para %s (%s)", cameraKey, reason)
@@ -539,6 +777,9 @@ func (m *Manager) stopUplink(cameraKey, reason string) error {
 
 	proc, ok := m.uplinks[cameraKey]
 	if !ok {
+		if m.cancelPendingStart(cameraKey, reason) {
+			return nil
+		}
 		return fmt.Errorf("uplink not running")
 	}
 	proc.stopCount++
@@ -547,7 +788,7 @@ func (m *Manager) stopUplink(cameraKey, reason string) error {
 		delete(m.uplinks, cameraKey)
 		return nil
 	}
-	log.Printf("[uplink] stop requested for %s: %s (startCount=%d stopCount=%d), keeping uplink active", proc.cameraKey, reason, proc.startCount, proc.stopCount)
+	uplinkLog(proc.cameraKey).Debugf("[uplink] stop requested for %s: %s (startCount=%d stopCount=%d), keeping uplink active", proc.cameraKey, reason, proc.startCount, proc.stopCount)
 	return nil
 }
 
@@ -555,7 +796,7 @@ func (m *Manager) stopProcess(proc *uplinkProcess, reason string) {
 	if proc.ttlTimer != nil {
 		proc.ttlTimer.Stop()
 	}
-	log.Printf("[uplink] stopping %s: %s (startCount=%d stopCount=%d)", proc.cameraKey, reason, proc.startCount, proc.stopCount)
+	uplinkLog(proc.cameraKey).Infof("[uplink] stopping %s: %s (startCount=%d stopCount=%d)", proc.cameraKey, reason, proc.startCount, proc.stopCount)
 	if m.mode == uplinkModeMediaMTX || m.mode == uplinkModeCentralPull {
 		m.notifyStatus(Status{
 			CameraID:      proc.payload.CameraID,
@@ -569,7 +810,7 @@ func (m *Manager) stopProcess(proc *uplinkProcess, reason string) {
 	}
 	stopCtx := context.Background()
 	if err := m.containerManager.Stop(stopCtx, proc.container); err != nil {
-		log.Printf("[uplink] stopProcess failed for %s: %v", proc.cameraKey, err)
+		uplinkLog(proc.cameraKey).Errorf("[uplink] stopProcess failed for %s: %v", proc.cameraKey, err)
 		m.notifyStatus(Status{
 			CameraID:      proc.payload.CameraID,
 			CentralPath:   proc.payload.CentralPath,
@@ -610,20 +851,20 @@ func (m *Manager) refreshTTL(proc *uplinkProcess, ttlSeconds int) {
 		proc.ttlTimer = nil
 	}
 	if m != nil && m.ignoreUplink {
-		log.Printf("[uplink] ttl ignored for %s (ignore_uplink)", proc.cameraKey)
+		uplinkLog(proc.cameraKey).Debugf("[uplink] ttl ignored for %s (ignore_uplink)", proc.cameraKey)
 		return
 	}
 	if proc.alwaysOn {
-		log.Printf("[uplink] ttl ignored for %s (always-on)", proc.cameraKey)
+		uplinkLog(proc.cameraKey).Debugf("[uplink] ttl ignored for %s (always-on)", proc.cameraKey)
 		return
 	}
 	if ttlSeconds <= 0 {
 		return
 	}
-	log.Printf("[uplink] refreshing ttl for %s (ttlSeconds=%d startCount=%d stopCount=%d)", proc.cameraKey, ttlSeconds, proc.startCount, proc.stopCount)
+	uplinkLog(proc.cameraKey).Debugf("[uplink] refreshing ttl for %s (ttlSeconds=%d startCount=%d stopCount=%d)", proc.cameraKey, ttlSeconds, proc.startCount, proc.stopCount)
 	proc.ttlTimer = time.AfterFunc(time.Duration(ttlSeconds)*time.Second, func() {
 		if err := m.stopUplink(proc.cameraKey, "ttl expired"); err != nil {
-			log.Printf("[uplink] ttl stop failed for %s: %v", proc.cameraKey, err)
+			uplinkLog(proc.cameraKey).Errorf("[uplink] ttl stop failed for %s: %v", proc.cameraKey, err)
 		}
 	})
 }
@@ -693,21 +934,6 @@ func getenv(key, def string) string {
 	return def
 }
 
-func getenvBool(key string, def bool) bool {
-	raw := strings.TrimSpace(os.Getenv(key))
-	if raw == "" {
-		return def
-	}
-	switch strings.ToLower(raw) {
-	case "1", "true", "yes", "y", "on":
-		return true
-	case "0", "false", "no", "n", "off":
-		return false
-	default:
-		return def
-	}
-}
-
 func parseListEnv(raw string) map[string]struct{} {
 	out := make(map[string]struct{})
 	for _, part := range strings.FieldsFunc(raw, func(r rune) bool {
@@ -765,13 +991,3 @@ func normalizeMode(raw string) string {
 		return uplinkModeContainer
 	}
 }
-
-func getenvInt(key string, def int) int {
-	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
-		var x int
-		if _, err := fmt.Sscanf(v, "%d", &x); err == nil && x > 0 {
-			return x
-		}
-	}
-	return def
-}
@@ -0,0 +1,211 @@
+package uplink
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sua-org/cam-bus/internal/core"
+)
+
+// newTestManager cria um Manager em UPLINK_MODE=mediamtx (sem docker) com as
+// variáveis dadas.
+func newTestManager(t *testing.T, env map[string]string) *Manager {
+	t.Helper()
+	t.Setenv("UPLINK_MODE", uplinkModeMediaMTX)
+	t.Setenv("UPLINK_CENTRAL_HOST", "central.local")
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+	return NewManagerFromEnv()
+}
+
+func running(m *Manager, id string) bool {
+	_, ok := m.StatusFor(Request{CameraID: id})
+	return ok
+}
+
+func TestTeardownGraceKeepsUplinkOnQuickReenable(t *testing.T) {
+	m := newTestManager(t, map[string]string{"UPLINK_TEARDOWN_GRACE": "100ms"})
+	info := core.CameraInfo{DeviceID: "cam1"}
+	if err := m.Start(Request{CameraID: "cam1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	stopped := make(chan struct{}, 1)
+	m.ScheduleStopByCamera(info, func() { stopped <- struct{}{} })
+	if !running(m, "cam1") {
+		t.Fatal("uplink parou antes do grace")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if !m.CancelScheduledStop(info) {
+		t.Fatal("CancelScheduledStop dentro do grace deveria achar o teardown")
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if !running(m, "cam1") {
+		t.Fatal("disable/enable dentro do grace derrubou o uplink")
+	}
+	select {
+	case <-stopped:
+		t.Fatal("onStop chamado para teardown cancelado")
+	default:
+	}
+}
+
+func TestTeardownGraceStopsAfterExpiry(t *testing.T) {
+	m := newTestManager(t, map[string]string{"UPLINK_TEARDOWN_GRACE": "50ms"})
+	info := core.CameraInfo{DeviceID: "cam1"}
+	if err := m.Start(Request{CameraID: "cam1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	stopped := make(chan struct{}, 1)
+	m.ScheduleStopByCamera(info, func() { stopped <- struct{}{} })
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("teardown não executado após o grace")
+	}
+	if running(m, "cam1") {
+		t.Fatal("uplink continua após o grace")
+	}
+	if m.CancelScheduledStop(info) {
+		t.Fatal("não deveria haver teardown pendente após executar")
+	}
+}
+
+func TestTeardownWithoutGraceStopsNow(t *testing.T) {
+	m := newTestManager(t, nil)
+	if err := m.Start(Request{CameraID: "cam1"}); err != nil {
+		t.Fatal(err)
+	}
+	called := false
+	m.ScheduleStopByCamera(core.CameraInfo{DeviceID: "cam1"}, func() { called = true })
+	if !called || running(m, "cam1") {
+		t.Fatalf("sem grace o teardown deveria ser imediato (onStop=%v)", called)
+	}
+}
+
+func TestScheduleStopAlwaysCallsOnStop(t *testing.T) {
+	m := newTestManager(t, map[string]string{"UPLINK_TEARDOWN_GRACE": "1h", "IGNORE_UPLINK": "true"})
+	called := false
+	m.ScheduleStopByCamera(core.CameraInfo{DeviceID: "cam1"}, func() { called = true })
+	if !called {
+		t.Fatal("com IGNORE_UPLINK o onStop deveria rodar na hora")
+	}
+}
+
+// fakeDocker aponta UPLINK_DOCKER_BIN para um script que registra
+// "start/end <container>" em volta de cada docker run (que demora runDelay) e
+// "rm <container>" a cada remoção. Devolve a função que lê o log.
+func fakeDocker(t *testing.T, runDelay string) func() []string {
+	t.Helper()
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "docker.log")
+	script := fmt.Sprintf(`#!/bin/sh
+case "$1" in
+run) echo "start $4" >> %[1]q; sleep %[2]s; echo "end $4" >> %[1]q; echo "id-$4" ;;
+inspect) echo "running|0|" ;;
+rm) echo "rm $3" >> %[1]q ;;
+esac
+`, logPath, runDelay)
+	bin := filepath.Join(dir, "docker")
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("UPLINK_MODE", uplinkModeContainer)
+	t.Setenv("UPLINK_DOCKER_BIN", bin)
+	t.Setenv("UPLINK_DOCKER_CONFIG", dir)
+	t.Setenv("UPLINK_CENTRAL_HOST", "central.local")
+	return func() []string {
+		data, _ := os.ReadFile(logPath)
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+}
+
+func TestContainerStartsRespectConcurrencyLimit(t *testing.T) {
+	dockerLog := fakeDocker(t, "0.2")
+	t.Setenv("UPLINK_MAX_CONCURRENT_STARTS", "2")
+	m := NewManagerFromEnv()
+
+	ids := []string{"cam1", "cam2", "cam3", "cam4", "cam5"}
+	var wg sync.WaitGroup
+	errs := make(chan error, len(ids))
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			errs <- m.Start(Request{CameraID: id, ProxyPath: id, CentralPath: "site/" + id})
+		}(id)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	inFlight, peak, started := 0, 0, 0
+	for _, line := range dockerLog() {
+		switch {
+		case strings.HasPrefix(line, "start "):
+			inFlight++
+			started++
+			if inFlight > peak {
+				peak = inFlight
+			}
+		case strings.HasPrefix(line, "end "):
+			inFlight--
+		}
+	}
+	if peak != 2 {
+		t.Fatalf("pico de docker run simultâneos = %d, esperava UPLINK_MAX_CONCURRENT_STARTS=2", peak)
+	}
+	if started != len(ids) {
+		t.Fatalf("docker run = %d, esperava %d", started, len(ids))
+	}
+	for _, id := range ids {
+		if _, ok := m.StatusFor(Request{CameraID: id, CentralPath: "site/" + id}); !ok {
+			t.Errorf("uplink de %s não ficou ativo", id)
+		}
+	}
+}
+
+func TestStopDuringContainerStartTearsDown(t *testing.T) {
+	dockerLog := fakeDocker(t, "0.2")
+	m := NewManagerFromEnv()
+	req := Request{CameraID: "cam1", ProxyPath: "cam1", CentralPath: "site/cam1"}
+	name := "cam-bus-uplink-site-cam1"
+
+	done := make(chan error, 1)
+	go func() { done <- m.Start(req) }()
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(strings.Join(dockerLog(), "\n"), "start "+name) {
+		if time.Now().After(deadline) {
+			t.Fatal("docker run não começou")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := m.Stop(req); err != nil {
+		t.Fatalf("Stop durante o start: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	lines := dockerLog()
+	last := lines[len(lines)-1]
+	if last != "rm "+name {
+		t.Fatalf("log do docker = %v, esperava rm do container depois do run", lines)
+	}
+	if _, ok := m.StatusFor(req); ok {
+		t.Fatal("uplink parado durante o start não deveria ficar ativo")
+	}
+}
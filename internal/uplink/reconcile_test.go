@@ -0,0 +1,30 @@
+package uplink
+
+import "testing"
+
+func TestShouldReconcileStopTransientVsTerminal(t *testing.T) {
+	m := newTestManager(t, map[string]string{"UPLINK_RECONCILE_MAX_NON_RUNNING": "3"})
+
+	for _, state := range []string{"exited", "dead", " Exited "} {
+		if !m.shouldReconcileStop(state, 1) {
+			t.Errorf("estado terminal %q deveria parar na primeira observação", state)
+		}
+	}
+	for _, state := range []string{"restarting", "created", "paused"} {
+		for misses := 1; misses < 3; misses++ {
+			if m.shouldReconcileStop(state, misses) {
+				t.Errorf("estado transitório %q parou com %d observações", state, misses)
+			}
+		}
+		if !m.shouldReconcileStop(state, 3) {
+			t.Errorf("estado %q deveria parar ao atingir o limite", state)
+		}
+	}
+}
+
+func TestShouldReconcileStopDisabled(t *testing.T) {
+	m := newTestManager(t, map[string]string{"UPLINK_RECONCILE_AUTO_STOP": "false"})
+	if m.shouldReconcileStop("exited", 10) {
+		t.Fatal("com UPLINK_RECONCILE_AUTO_STOP=false o reconciler não deveria parar nada")
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"os"
 	"strconv"
 	"strings"
+
+	"github.com/sua-org/cam-bus/internal/envconf"
 )
 
 type SRTQueryOptions struct {
@@ -136,19 +138,19 @@ func srtOptionsFromEnv() SRTQueryOptions {
 
 func srtOptionsFromCustomEnv() SRTQueryOptions {
 	return SRTQueryOptions{
-		Latency:     getenvInt("UPLINK_SRT_LATENCY", 0),
-		PacketSize:  getenvInt("UPLINK_SRT_PACKET_SIZE", 0),
-		MaxBW:       getenvInt("UPLINK_SRT_MAXBW", 0),
-		RcvBuf:      getenvInt("UPLINK_SRT_RCVBUF", 0),
+		Latency:     envconf.PositiveInt("UPLINK_SRT_LATENCY", 0),
+		PacketSize:  envconf.PositiveInt("UPLINK_SRT_PACKET_SIZE", 0),
+		MaxBW:       envconf.PositiveInt("UPLINK_SRT_MAXBW", 0),
+		RcvBuf:      envconf.PositiveInt("UPLINK_SRT_RCVBUF", 0),
 		Passphrase:  strings.TrimSpace(os.Getenv("UPLINK_SRT_PASSPHRASE")),
-		PBKeyLen:    getenvInt("UPLINK_SRT_PBKEYLEN", 0),
-		PeerLatency: getenvInt("UPLINK_SRT_PEERLATENCY", 0),
-		RcvLatency:  getenvInt("UPLINK_SRT_RCVLATENCY", 0),
-		ConnTimeout: getenvInt("UPLINK_SRT_CONNTIMEO", 0),
-		SndBuf:      getenvInt("UPLINK_SRT_SNDBUF", 0),
-		InputBW:     getenvInt("UPLINK_SRT_INPUTBW", 0),
-		OheadBW:     getenvInt("UPLINK_SRT_OHEADBW", 0),
-		TLPktDrop:   getenvBool("UPLINK_SRT_TLPKTDROP", false),
+		PBKeyLen:    envconf.PositiveInt("UPLINK_SRT_PBKEYLEN", 0),
+		PeerLatency: envconf.PositiveInt("UPLINK_SRT_PEERLATENCY", 0),
+		RcvLatency:  envconf.PositiveInt("UPLINK_SRT_RCVLATENCY", 0),
+		ConnTimeout: envconf.PositiveInt("UPLINK_SRT_CONNTIMEO", 0),
+		SndBuf:      envconf.PositiveInt("UPLINK_SRT_SNDBUF", 0),
+		InputBW:     envconf.PositiveInt("UPLINK_SRT_INPUTBW", 0),
+		OheadBW:     envconf.PositiveInt("UPLINK_SRT_OHEADBW", 0),
+		TLPktDrop:   envconf.Bool("UPLINK_SRT_TLPKTDROP", false),
 		ExtraParams: strings.TrimSpace(os.Getenv("UPLINK_SRT_EXTRA_PARAMS")),
 	}
 }
@@ -180,7 +182,7 @@ func srtOptionsForProfile(profile string) (SRTQueryOptions, bool) {
 
 func applySRTAuxEnv(opts *SRTQueryOptions) {
 	opts.Passphrase = strings.TrimSpace(os.Getenv("UPLINK_SRT_PASSPHRASE"))
-	opts.PBKeyLen = getenvInt("UPLINK_SRT_PBKEYLEN", 0)
+	opts.PBKeyLen = envconf.PositiveInt("UPLINK_SRT_PBKEYLEN", 0)
 }
 
 func srtProfileFromEnv() string {
@@ -210,7 +212,7 @@ func srtOptionCandidates(base SRTQueryOptions) []SRTQueryOptions {
 		candidates = append(candidates, defaultLatency)
 	}
 
-	compatEnabled := getenvBool("UPLINK_SRT_COMPAT_PROFILE", false)
+	compatEnabled := envconf.Bool("UPLINK_SRT_COMPAT_PROFILE", false)
 	if !compatEnabled {
 		return candidates
 	}